@@ -0,0 +1,86 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+// Markers of the annotation [ActionError] appends to a message
+const (
+	actionMarker = " (action "
+	codeMarker   = ", code "
+)
+
+// ActionError is an error returned by an action, annotated with the action
+// type, its error code and the region and object it acted on. Results of
+// failed transactions record only the message, so the code is written into
+// it for [ErrorCodeFromMessage] to recover.
+type ActionError struct {
+	TypeID   uint8
+	RegionID string
+	ObjectID string
+	Err      error
+}
+
+func (e *ActionError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	fmt.Fprintf(&b, "%s%d%s%s", actionMarker, e.TypeID, codeMarker, e.Code())
+	if e.RegionID != "" {
+		fmt.Fprintf(&b, ", region %q", e.RegionID)
+	}
+	if e.ObjectID != "" {
+		fmt.Fprintf(&b, ", object %q", e.ObjectID)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the code of the underlying error.
+func (e *ActionError) Code() consts.ErrorCode {
+	return ErrorCodeOf(e.Err)
+}
+
+// WrapActionError annotates [err] with the action that returned it. It
+// returns nil for a nil error and leaves errors already annotated as they
+// are, so the innermost action is reported.
+func WrapActionError(typeID uint8, regionID, objectID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ae *ActionError
+	if errors.As(err, &ae) {
+		return err
+	}
+	return &ActionError{
+		TypeID:   typeID,
+		RegionID: regionID,
+		ObjectID: objectID,
+		Err:      err,
+	}
+}
+
+// wrapExecError annotates the error in [errp], if any, and logs it. Execute
+// methods defer it over their named error result.
+func wrapExecError(errp *error, typeID uint8, regionID, objectID string) {
+	*errp = WrapActionError(typeID, regionID, objectID, *errp)
+	if *errp != nil {
+		vmlog.Default().WithRegion(regionID).WithAction(typeID).Debug("action failed",
+			zap.String("object", objectID),
+			zap.Error(*errp),
+		)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	// AdminOpSchedule enables [AdminAction.TargetTypeID] from
+	// [AdminAction.ActivationHeight] onwards.
+	AdminOpSchedule uint8 = iota
+	// AdminOpDisable stops [AdminAction.TargetTypeID] from executing until
+	// it is scheduled again.
+	AdminOpDisable
+	// AdminOpActivateVersion activates every action version up to
+	// [AdminAction.Version] from [AdminAction.ActivationHeight] onwards,
+	// ahead of the version schedule of the rules.
+	AdminOpActivateVersion
+)
+
+const MaxAdminSignatures = 16
+
+var (
+	ErrInvalidAdminOp     = errors.New("invalid admin operation")
+	ErrInvalidAdminNonce  = errors.New("invalid admin nonce")
+	ErrAdminThreshold     = errors.New("admin signature threshold not met")
+	ErrUnknownAdminKey    = errors.New("signature from unknown admin key")
+	ErrTooManyAdminSigs   = errors.New("too many admin signatures")
+	ErrCannotDisableAdmin = errors.New("admin action cannot be disabled")
+	ErrActionDisabled     = errors.New("action type disabled")
+
+	_ chain.Action = (*AdminAction)(nil)
+)
+
+type AdminSignature struct {
+	PublicKey ed25519.PublicKey `serialize:"true" json:"public_key"`
+	Signature ed25519.Signature `serialize:"true" json:"signature"`
+}
+
+// AdminAction gates an action type, or activates an action version, once
+// signed by a threshold of the admin keys from genesis. It is intended for
+// responding to TEE vulnerabilities faster than governance allows.
+type AdminAction struct {
+	Op               uint8            `serialize:"true" json:"op"`
+	TargetTypeID     uint8            `serialize:"true" json:"target_type_id"`
+	Version          uint8            `serialize:"true" json:"version"`
+	ActivationHeight uint64           `serialize:"true" json:"activation_height"`
+	Nonce            uint64           `serialize:"true" json:"nonce"`
+	Signatures       []AdminSignature `serialize:"true" json:"signatures"`
+}
+
+func (*AdminAction) GetTypeID() uint8 {
+	return consts.AdminID
+}
+
+func (a *AdminAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.AdminSetKey()):   state.Read,
+		string(storage.AdminNonceKey()): state.All,
+	}
+	if a.Op == AdminOpActivateVersion {
+		keys[string(storage.AdminVersionKey())] = state.All
+	} else {
+		keys[string(storage.ActionGateKey(a.TargetTypeID))] = state.All
+	}
+	return addGateKeys(keys, actor, a)
+}
+
+// Digest is the message each admin key signs on the chain [chainID].
+func (a *AdminAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.AdminID)
+	d = append(d, a.Op, a.TargetTypeID, a.Version)
+	d = binary.BigEndian.AppendUint64(d, a.ActivationHeight)
+	return binary.BigEndian.AppendUint64(d, a.Nonce)
+}
+
+func (a *AdminAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	if a.Op != AdminOpActivateVersion && a.TargetTypeID == consts.AdminID {
+		return nil, ErrCannotDisableAdmin
+	}
+	if len(a.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if a.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, a.Digest(rules.GetChainID()), a.Signatures); err != nil {
+		return nil, err
+	}
+
+	result := &AdminResult{
+		Op:           a.Op,
+		TargetTypeID: a.TargetTypeID,
+		Nonce:        a.Nonce,
+	}
+	switch a.Op {
+	case AdminOpSchedule:
+		result.ActivationHeight = a.ActivationHeight
+		err = storage.SetActionGate(ctx, mu, a.TargetTypeID, &storage.ActionGate{ActivationHeight: a.ActivationHeight})
+	case AdminOpDisable:
+		err = storage.SetActionGate(ctx, mu, a.TargetTypeID, &storage.ActionGate{Disabled: true})
+	case AdminOpActivateVersion:
+		if a.Version == 0 || a.Version > consts.LatestActionVersion {
+			return nil, ErrUnsupportedActionVersion
+		}
+		result.Version = a.Version
+		result.ActivationHeight = a.ActivationHeight
+		err = storage.SetAdminVersion(ctx, mu, &storage.AdminVersion{Version: a.Version, Height: a.ActivationHeight})
+	default:
+		return nil, ErrInvalidAdminOp
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (a *AdminAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + uint64(len(a.Signatures))*schedule.AttestationUnits
+}
+
+func (*AdminAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type AdminResult struct {
+	Op               uint8  `serialize:"true" json:"op"`
+	TargetTypeID     uint8  `serialize:"true" json:"target_type_id"`
+	Version          uint8  `serialize:"true" json:"version"`
+	ActivationHeight uint64 `serialize:"true" json:"activation_height"`
+	Nonce            uint64 `serialize:"true" json:"nonce"`
+}
+
+func (*AdminResult) GetTypeID() uint8 {
+	return consts.AdminResultID
+}
+
+// adminDigest starts the digest of an admin-signed action of [typeID] with
+// [chainID], so its signatures cannot be replayed on another chain.
+func adminDigest(chainID ids.ID, typeID uint8) []byte {
+	d := make([]byte, 0, ids.IDLen+64)
+	d = append(d, chainID[:]...)
+	return append(d, typeID)
+}
+
+// verifyAdminSignatures counts distinct admin keys with a valid signature
+// over [digest] and requires at least the set threshold.
+func verifyAdminSignatures(set *storage.AdminSet, digest []byte, sigs []AdminSignature) error {
+	members := make(map[ed25519.PublicKey]struct{}, len(set.Keys))
+	for _, key := range set.Keys {
+		members[key] = struct{}{}
+	}
+	signed := make(map[ed25519.PublicKey]struct{}, len(sigs))
+	for _, sig := range sigs {
+		if _, ok := members[sig.PublicKey]; !ok {
+			return ErrUnknownAdminKey
+		}
+		if !ed25519.Verify(digest, sig.PublicKey, sig.Signature) {
+			return ErrInvalidSignature
+		}
+		signed[sig.PublicKey] = struct{}{}
+	}
+	if len(signed) < int(set.Threshold) {
+		return ErrAdminThreshold
+	}
+	return nil
+}
+
+// CheckActionEnabled returns [ErrActionDisabled] if an admin gate prevents
+// [typeID] from executing at the current height.
+func CheckActionEnabled(ctx context.Context, im state.Immutable, typeID uint8) error {
+	enabled, err := storage.ActionEnabled(ctx, im, typeID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrActionDisabled
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrSelfAllowance = errors.New("cannot approve own address")
+	ErrZeroAmount    = errors.New("amount is zero")
+
+	_ chain.Action = (*ApproveAction)(nil)
+	_ chain.Action = (*TransferFromAction)(nil)
+)
+
+// ApproveAction lets [Spender] move up to [Amount] of the actor's balance,
+// replacing any earlier allowance. An amount of zero revokes it. Region
+// operators approve their users so the users' requests can be sponsored
+// (see [QueueRequestAction]).
+type ApproveAction struct {
+	Spender codec.Address `serialize:"true" json:"spender"`
+	Amount  uint64        `serialize:"true" json:"amount"`
+}
+
+func (*ApproveAction) GetTypeID() uint8 {
+	return consts.ApproveID
+}
+
+func (a *ApproveAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.AllowanceKey(actor, a.Spender)): state.All,
+	}, actor, a)
+}
+
+func (a *ApproveAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	if a.Spender == actor {
+		return nil, ErrSelfAllowance
+	}
+	if err := storage.SetAllowance(ctx, mu, actor, a.Spender, a.Amount); err != nil {
+		return nil, err
+	}
+	return &ApproveResult{
+		Spender:   a.Spender,
+		Allowance: a.Amount,
+	}, nil
+}
+
+func (*ApproveAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.StateUpdateUnits
+}
+
+func (*ApproveAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type ApproveResult struct {
+	Spender   codec.Address `serialize:"true" json:"spender"`
+	Allowance uint64        `serialize:"true" json:"allowance"`
+}
+
+func (*ApproveResult) GetTypeID() uint8 {
+	return consts.ApproveResultID
+}
+
+// TransferFromAction moves [Value] from [From] to [To] out of the allowance
+// [From] gave the actor.
+type TransferFromAction struct {
+	From  codec.Address `serialize:"true" json:"from"`
+	To    codec.Address `serialize:"true" json:"to"`
+	Value uint64        `serialize:"true" json:"value"`
+}
+
+func (*TransferFromAction) GetTypeID() uint8 {
+	return consts.TransferFromID
+}
+
+func (t *TransferFromAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.AllowanceKey(t.From, actor)): state.Read | state.Write,
+		string(storage.BalanceKey(t.From)):          state.Read | state.Write,
+		string(storage.BalanceKey(t.To)):            state.All,
+	}, actor, t)
+}
+
+func (t *TransferFromAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, t); err != nil {
+		return nil, err
+	}
+
+	if t.Value == 0 {
+		return nil, ErrZeroAmount
+	}
+	allowance, err := storage.SpendAllowance(ctx, mu, t.From, actor, t.To, t.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferFromResult{Allowance: allowance}, nil
+}
+
+func (*TransferFromAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 3*schedule.StateUpdateUnits
+}
+
+func (*TransferFromAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type TransferFromResult struct {
+	// Allowance is what the actor may still move
+	Allowance uint64 `serialize:"true" json:"allowance"`
+}
+
+func (*TransferFromResult) GetTypeID() uint8 {
+	return consts.TransferFromResultID
+}
+
+// sponsorFee moves the fee for [units] from [sponsor] to [actor] out of the
+// allowance [sponsor] gave [actor]. It returns the amount moved.
+func sponsorFee(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	sponsor codec.Address,
+	actor codec.Address,
+	units uint64,
+) (uint64, error) {
+	fee := units * rules.GetMinUnitPrice()[fees.Compute]
+	if fee == 0 {
+		return 0, nil
+	}
+	if _, err := storage.SpendAllowance(ctx, mu, sponsor, actor, actor, fee); err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
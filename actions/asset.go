@@ -0,0 +1,324 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+const (
+	MaxAssetNameSize   = 64
+	MaxAssetSymbolSize = 8
+	MaxAssetDecimals   = 18
+)
+
+var (
+	ErrAssetNotFound     = errors.New("asset not found")
+	ErrAssetExists       = errors.New("asset already exists")
+	ErrNotAssetOwner     = errors.New("actor does not own asset")
+	ErrMaxSupply         = errors.New("mint exceeds max supply")
+	ErrInvalidAsset      = errors.New("invalid asset metadata")
+	ErrNativeAsset       = errors.New("native asset cannot be used")
+	ErrInsufficientUnits = errors.New("insufficient metering asset balance")
+
+	_ chain.Action = (*CreateAssetAction)(nil)
+	_ chain.Action = (*MintAssetAction)(nil)
+	_ chain.Action = (*TransferAssetAction)(nil)
+)
+
+// CreateAssetAction issues a new asset owned by the actor. Its ID is the ID
+// of the action, unless [RegionID] is set: a TEE of that region then creates
+// the region's metering asset, with ID [storage.RegionAssetID]. Executions
+// in a region with a metering asset burn one token of it per unit consumed
+// (see [TEEExecAction]).
+type CreateAssetAction struct {
+	Name      string `serialize:"true" json:"name"`
+	Symbol    string `serialize:"true" json:"symbol"`
+	Decimals  uint8  `serialize:"true" json:"decimals"`
+	RegionID  string `serialize:"true" json:"region_id"`
+	MaxSupply uint64 `serialize:"true" json:"max_supply"`
+}
+
+func (*CreateAssetAction) GetTypeID() uint8 {
+	return consts.CreateAssetID
+}
+
+func (c *CreateAssetAction) assetID(actionID ids.ID) ids.ID {
+	if c.RegionID != "" {
+		return storage.RegionAssetID(c.RegionID)
+	}
+	return actionID
+}
+
+func (c *CreateAssetAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.AssetKey(c.assetID(actionID))): state.All,
+	}
+	if c.RegionID != "" {
+		keys[string(storage.RegionKey(c.RegionID))] = state.Read
+	}
+	return addGateKeys(keys, actor, c)
+}
+
+func (c *CreateAssetAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.CreateAssetID, c.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, c); err != nil {
+		return nil, err
+	}
+
+	if len(c.Name) == 0 || len(c.Name) > MaxAssetNameSize {
+		return nil, fmt.Errorf("%w: name size %d", ErrInvalidAsset, len(c.Name))
+	}
+	if len(c.Symbol) == 0 || len(c.Symbol) > MaxAssetSymbolSize {
+		return nil, fmt.Errorf("%w: symbol size %d", ErrInvalidAsset, len(c.Symbol))
+	}
+	if c.Decimals > MaxAssetDecimals {
+		return nil, fmt.Errorf("%w: %d decimals", ErrInvalidAsset, c.Decimals)
+	}
+	if c.RegionID != "" {
+		tees, exists, err := storage.GetRegion(ctx, mu, c.RegionID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrRegionNotFound
+		}
+		if !containsAddress(tees, actor) {
+			return nil, ErrTEENotInRegion
+		}
+	}
+
+	assetID := c.assetID(actionID)
+	existing, err := storage.GetAsset(ctx, mu, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAssetExists
+	}
+	if err := storage.SetAsset(ctx, mu, assetID, &storage.Asset{
+		Name:      c.Name,
+		Symbol:    c.Symbol,
+		Decimals:  c.Decimals,
+		Owner:     actor,
+		RegionID:  c.RegionID,
+		MaxSupply: c.MaxSupply,
+	}); err != nil {
+		return nil, err
+	}
+	return &CreateAssetResult{AssetID: assetID}, nil
+}
+
+func (*CreateAssetAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.StateUpdateUnits
+}
+
+func (*CreateAssetAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type CreateAssetResult struct {
+	AssetID ids.ID `serialize:"true" json:"asset_id"`
+}
+
+func (*CreateAssetResult) GetTypeID() uint8 {
+	return consts.CreateAssetResultID
+}
+
+// MintAssetAction issues [Value] new tokens of [AssetID] to [To]. Only the
+// owner of the asset may mint, and never past its max supply.
+type MintAssetAction struct {
+	AssetID ids.ID        `serialize:"true" json:"asset_id"`
+	To      codec.Address `serialize:"true" json:"to"`
+	Value   uint64        `serialize:"true" json:"value"`
+}
+
+func (*MintAssetAction) GetTypeID() uint8 {
+	return consts.MintAssetID
+}
+
+func (m *MintAssetAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.AssetKey(m.AssetID)):              state.Read | state.Write,
+		string(storage.AssetBalanceKey(m.AssetID, m.To)): state.All,
+	}, actor, m)
+}
+
+func (m *MintAssetAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, m); err != nil {
+		return nil, err
+	}
+
+	if m.Value == 0 {
+		return nil, ErrZeroAmount
+	}
+	asset, err := storage.GetAsset(ctx, mu, m.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, ErrAssetNotFound
+	}
+	if asset.Owner != actor {
+		return nil, ErrNotAssetOwner
+	}
+	supply, err := smath.Add(asset.Supply, m.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMaxSupply, err)
+	}
+	if asset.MaxSupply > 0 && supply > asset.MaxSupply {
+		return nil, fmt.Errorf("%w: (supply=%d, max=%d)", ErrMaxSupply, supply, asset.MaxSupply)
+	}
+	asset.Supply = supply
+	if err := storage.SetAsset(ctx, mu, m.AssetID, asset); err != nil {
+		return nil, err
+	}
+	balance, err := storage.AddAssetBalance(ctx, mu, m.AssetID, m.To, m.Value, true)
+	if err != nil {
+		return nil, err
+	}
+	return &MintAssetResult{Supply: supply, Balance: balance}, nil
+}
+
+func (*MintAssetAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*MintAssetAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type MintAssetResult struct {
+	Supply  uint64 `serialize:"true" json:"supply"`
+	Balance uint64 `serialize:"true" json:"balance"`
+}
+
+func (*MintAssetResult) GetTypeID() uint8 {
+	return consts.MintAssetResultID
+}
+
+// TransferAssetAction moves [Value] of [AssetID] from the actor to [To].
+// The native token moves with [Transfer] instead.
+type TransferAssetAction struct {
+	AssetID ids.ID        `serialize:"true" json:"asset_id"`
+	To      codec.Address `serialize:"true" json:"to"`
+	Value   uint64        `serialize:"true" json:"value"`
+}
+
+func (*TransferAssetAction) GetTypeID() uint8 {
+	return consts.TransferAssetID
+}
+
+func (t *TransferAssetAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.AssetBalanceKey(t.AssetID, actor)): state.Read | state.Write,
+		string(storage.AssetBalanceKey(t.AssetID, t.To)):  state.All,
+	}, actor, t)
+}
+
+func (t *TransferAssetAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, t); err != nil {
+		return nil, err
+	}
+
+	if t.AssetID == storage.NativeAsset {
+		return nil, ErrNativeAsset
+	}
+	if t.Value == 0 {
+		return nil, ErrZeroAmount
+	}
+	senderBalance, err := storage.SubAssetBalance(ctx, mu, t.AssetID, actor, t.Value)
+	if err != nil {
+		return nil, err
+	}
+	receiverBalance, err := storage.AddAssetBalance(ctx, mu, t.AssetID, t.To, t.Value, true)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferAssetResult{
+		SenderBalance:   senderBalance,
+		ReceiverBalance: receiverBalance,
+	}, nil
+}
+
+func (*TransferAssetAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*TransferAssetAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type TransferAssetResult struct {
+	SenderBalance   uint64 `serialize:"true" json:"sender_balance"`
+	ReceiverBalance uint64 `serialize:"true" json:"receiver_balance"`
+}
+
+func (*TransferAssetResult) GetTypeID() uint8 {
+	return consts.TransferAssetResultID
+}
+
+// burnMeteredUnits burns [units] of the metering asset of [regionID] from
+// [actor], if the region has one. It returns the amount burned.
+func burnMeteredUnits(
+	ctx context.Context,
+	mu state.Mutable,
+	regionID string,
+	actor codec.Address,
+	units uint64,
+) (uint64, error) {
+	assetID := storage.RegionAssetID(regionID)
+	asset, err := storage.GetAsset(ctx, mu, assetID)
+	if err != nil || asset == nil || units == 0 {
+		return 0, err
+	}
+	if _, err := storage.SubAssetBalance(ctx, mu, assetID, actor, units); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInsufficientUnits, err)
+	}
+	asset.Supply -= units
+	if err := storage.SetAsset(ctx, mu, assetID, asset); err != nil {
+		return 0, err
+	}
+	return units, nil
+}
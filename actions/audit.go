@@ -0,0 +1,62 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// addAuditKeys declares the audit records action [actionID] appends to
+// the log of [regionID].
+func addAuditKeys(keys state.Keys, regionID string, actionID ids.ID) state.Keys {
+	keys[string(storage.HeightKey())] = state.Read
+	keys[string(storage.AuditHeadKey(regionID))] = state.All
+	keys[string(storage.AuditKey(regionID, actionID))] = state.All
+	return keys
+}
+
+// appendAudit records [entry] for action [actionID] as the latest admin
+// operation on [regionID], linked to the one before it.
+func appendAudit(ctx context.Context, mu state.Mutable, regionID string, actionID ids.ID, entry *storage.AuditEntry) error {
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return err
+	}
+	head, err := storage.GetAuditHead(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	entry.Height = height
+	entry.Prev = head.Latest
+	if err := storage.SetAuditEntry(ctx, mu, regionID, actionID, entry); err != nil {
+		return err
+	}
+	head.Latest = actionID
+	head.Count++
+	return storage.SetAuditHead(ctx, mu, regionID, head)
+}
+
+// evidenceHashes returns the SHA-256 hash of each piece of [evidence].
+func evidenceHashes(evidence ...[]byte) []ids.ID {
+	hashes := make([]ids.ID, len(evidence))
+	for i, e := range evidence {
+		hashes[i] = sha256.Sum256(e)
+	}
+	return hashes
+}
+
+// signatureHashes returns the hashes of the admin signatures [sigs].
+func signatureHashes(sigs []AdminSignature) []ids.ID {
+	evidence := make([][]byte, len(sigs))
+	for i, sig := range sigs {
+		evidence[i] = sig.Signature[:]
+	}
+	return evidenceHashes(evidence...)
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrBeaconEpoch     = errors.New("beacon epoch out of order")
+	ErrBeaconShares    = errors.New("beacon needs one share from each enclave of a pair")
+	ErrBeaconSeed      = errors.New("invalid beacon seed")
+	ErrBeaconReveal    = errors.New("beacon seed does not match commitment")
+	ErrBeaconNotFound  = errors.New("beacon not published")
+	ErrBeaconCommitted = errors.New("beacon committed to another pair")
+
+	_ chain.Action = (*PublishRandomnessAction)(nil)
+)
+
+// beaconDomain separates beacon signatures from the other statements an
+// enclave key signs.
+const beaconDomain = "shuttlevm/beacon"
+
+// BeaconShareDigest is what an enclave signs to contribute [seed] to the
+// beacon of [regionID] at [epoch], committing to [next] as the hash of its
+// seed for the following epoch.
+func BeaconShareDigest(regionID string, epoch uint64, seed []byte, next ids.ID) []byte {
+	h := sha256.New()
+	h.Write([]byte(beaconDomain))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(binary.BigEndian.AppendUint64(nil, epoch))
+	h.Write(seed)
+	h.Write(next[:])
+	return h.Sum(nil)
+}
+
+// BeaconCommitment is the commitment an enclave publishes for [seed].
+func BeaconCommitment(seed []byte) ids.ID {
+	return sha256.Sum256(seed)
+}
+
+// BeaconRandomness derives the randomness of [epoch] from the last one and
+// the seeds of the pair, in enclave ID order. Neither enclave sees the
+// other's seed before committing to its own, so neither alone can steer
+// the result.
+func BeaconRandomness(regionID string, epoch uint64, prev ids.ID, seeds [][]byte) ids.ID {
+	h := sha256.New()
+	h.Write([]byte(beaconDomain))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(binary.BigEndian.AppendUint64(nil, epoch))
+	h.Write(prev[:])
+	for _, seed := range seeds {
+		h.Write(seed)
+	}
+	var randomness ids.ID
+	copy(randomness[:], h.Sum(nil))
+	return randomness
+}
+
+// RandomnessShare is one enclave's contribution to a beacon epoch. Enclaves
+// generate seeds inside the enclave, so operators never see them before
+// they are revealed.
+type RandomnessShare struct {
+	// Seed must hash to the enclave's commitment from the last epoch
+	Seed []byte `serialize:"true" json:"seed"`
+	// Next is the [BeaconCommitment] of the enclave's seed for the next
+	// epoch
+	Next ids.ID `serialize:"true" json:"next"`
+	// Attestation signs [BeaconShareDigest]
+	Attestation attestation.Attestation `serialize:"true" json:"attestation"`
+}
+
+// PublishRandomnessAction publishes the randomness of a region for [Epoch]
+// from the shares of two of its enclaves, ordered by enclave ID. While the
+// pair that published the last epoch is within
+// [consts.BeaconRevealWindow], only it may publish, revealing the seeds it
+// committed to. After that, any pair of active enclaves may take over, so
+// one enclave going away does not stall the beacon.
+type PublishRandomnessAction struct {
+	RegionID string            `serialize:"true" json:"region_id"`
+	Epoch    uint64            `serialize:"true" json:"epoch"`
+	Shares   []RandomnessShare `serialize:"true" json:"shares"`
+}
+
+func (*PublishRandomnessAction) GetTypeID() uint8 {
+	return consts.PublishRandomnessID
+}
+
+func (p *PublishRandomnessAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.RegionKey(p.RegionID)):          state.Read,
+		string(storage.BeaconHeadKey(p.RegionID)):      state.All,
+		string(storage.BeaconKey(p.RegionID, p.Epoch)): state.All,
+	}
+	for _, share := range p.Shares {
+		enclaveID := share.Attestation.EnclaveID
+		keys[string(storage.EnclaveKey(p.RegionID, enclaveID))] = state.Read
+		keys[string(storage.EnclavePubKeyKey(p.RegionID, enclaveID))] = state.Read
+		keys[string(storage.EnclaveExpiryKey(p.RegionID, enclaveID))] = state.Read
+		addPlatformKeys(keys, p.RegionID, enclaveID)
+	}
+	return addGateKeys(keys, actor, p)
+}
+
+func (p *PublishRandomnessAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.PublishRandomnessID, p.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	if len(p.Shares) != 2 || bytes.Compare(p.Shares[0].Attestation.EnclaveID, p.Shares[1].Attestation.EnclaveID) >= 0 {
+		return nil, ErrBeaconShares
+	}
+	head, err := storage.GetBeaconHead(ctx, mu, p.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Epoch != head.Epoch {
+		return nil, fmt.Errorf("%w: (expected=%d, got=%d)", ErrBeaconEpoch, head.Epoch, p.Epoch)
+	}
+	now := uint64(timestamp / 1000)
+	// Commitments only bind until the reveal window closes
+	committed := len(head.Commitments) > 0 && now <= head.RevealBy
+	if committed && len(head.Commitments) != len(p.Shares) {
+		return nil, ErrBeaconCommitted
+	}
+
+	seeds := make([][]byte, len(p.Shares))
+	enclaves := make([][]byte, len(p.Shares))
+	commitments := make([]storage.BeaconCommitment, len(p.Shares))
+	for i, share := range p.Shares {
+		if len(share.Seed) != consts.BeaconSeedSize {
+			return nil, ErrBeaconSeed
+		}
+		enclaveID := share.Attestation.EnclaveID
+		digest := BeaconShareDigest(p.RegionID, p.Epoch, share.Seed, share.Next)
+		if err := verifySettlementSigner(ctx, mu, timestamp, p.RegionID, digest, &share.Attestation); err != nil {
+			return nil, err
+		}
+		if committed {
+			// Commitments are stored in enclave ID order, like shares
+			c := head.Commitments[i]
+			if !bytes.Equal(c.EnclaveID, enclaveID) {
+				return nil, ErrBeaconCommitted
+			}
+			if BeaconCommitment(share.Seed) != c.Commitment {
+				return nil, ErrBeaconReveal
+			}
+		}
+		seeds[i] = share.Seed
+		enclaves[i] = enclaveID
+		commitments[i] = storage.BeaconCommitment{EnclaveID: enclaveID, Commitment: share.Next}
+	}
+
+	randomness := BeaconRandomness(p.RegionID, p.Epoch, head.Randomness, seeds)
+	if err := storage.SetBeacon(ctx, mu, p.RegionID, p.Epoch, &storage.Beacon{
+		Randomness:  randomness,
+		Enclaves:    enclaves,
+		PublishedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetBeaconHead(ctx, mu, p.RegionID, &storage.BeaconHead{
+		Epoch:       p.Epoch + 1,
+		Randomness:  randomness,
+		Commitments: commitments,
+		RevealBy:    now + consts.BeaconRevealWindow,
+	}); err != nil {
+		return nil, err
+	}
+	return &PublishRandomnessResult{
+		RegionID:   p.RegionID,
+		Epoch:      p.Epoch,
+		Randomness: randomness,
+	}, nil
+}
+
+func (p *PublishRandomnessAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.TEEUnits + 2*schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(p).QuoteBytes)
+}
+
+func (*PublishRandomnessAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type PublishRandomnessResult struct {
+	RegionID   string `serialize:"true" json:"region_id"`
+	Epoch      uint64 `serialize:"true" json:"epoch"`
+	Randomness ids.ID `serialize:"true" json:"randomness"`
+}
+
+func (*PublishRandomnessResult) GetTypeID() uint8 {
+	return consts.PublishRandomnessResultID
+}
+
+// RegionRandomness returns the beacon randomness [regionID] published for
+// [epoch].
+func RegionRandomness(ctx context.Context, im state.Immutable, regionID string, epoch uint64) (ids.ID, error) {
+	b, err := storage.GetBeacon(ctx, im, regionID, epoch)
+	if err != nil {
+		return ids.Empty, err
+	}
+	if b == nil {
+		return ids.Empty, fmt.Errorf("%w: %s/%d", ErrBeaconNotFound, regionID, epoch)
+	}
+	return b.Randomness, nil
+}
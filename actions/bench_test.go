@@ -0,0 +1,86 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/attestation"
+	vmconsts "github.com/rhombus-tech/vm/consts"
+)
+
+func benchTEEExec(updates int) *TEEExecAction {
+	exec := &TEEExecAction{
+		Version:  vmconsts.LatestActionVersion,
+		RegionID: "us-east",
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.SGX,
+			EnclaveID:   make([]byte, codec.AddressLen),
+			Signature:   make([]byte, ed25519.SignatureLen),
+			Stamps: []attestation.Stamp{
+				{ServerID: "a", Time: 10, Signature: make([]byte, ed25519.SignatureLen)},
+				{ServerID: "b", Time: 11, Signature: make([]byte, ed25519.SignatureLen)},
+				{ServerID: "c", Time: 12, Signature: make([]byte, ed25519.SignatureLen)},
+			},
+		},
+	}
+	exec.ExecResult.StateUpdates = make(map[string][]byte, updates)
+	for i := 0; i < updates; i++ {
+		exec.ExecResult.StateUpdates[fmt.Sprintf("key-%d", i)] = make([]byte, 256)
+	}
+	return exec
+}
+
+func BenchmarkPackerRoundTrip(b *testing.B) {
+	for _, updates := range []int{1, 16, 128} {
+		b.Run(fmt.Sprintf("TEEExec/updates=%d", updates), func(b *testing.B) {
+			exec := benchTEEExec(updates)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p := codec.NewWriter(0, consts.NetworkSizeLimit)
+				exec.Marshal(p)
+				_, err := UnmarshalTEEExecAction(codec.NewReader(p.Bytes(), consts.NetworkSizeLimit))
+				require.NoError(b, err)
+			}
+		})
+	}
+	b.Run("UpdateRegion", func(b *testing.B) {
+		update := &UpdateRegionAction{
+			Version:  vmconsts.LatestActionVersion,
+			RegionID: "us-east",
+			AddTEEs:  []codec.Address{codectest.NewRandomAddress()},
+			RemTEEs:  []codec.Address{codectest.NewRandomAddress()},
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p := codec.NewWriter(0, consts.NetworkSizeLimit)
+			update.Marshal(p)
+			_, err := UnmarshalUpdateRegion(codec.NewReader(p.Bytes(), consts.NetworkSizeLimit))
+			require.NoError(b, err)
+		}
+	})
+}
+
+func BenchmarkResultDigest(b *testing.B) {
+	for _, updates := range []int{1, 16, 128} {
+		b.Run(fmt.Sprintf("updates=%d", updates), func(b *testing.B) {
+			exec := benchTEEExec(updates)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := exec.ExecResult.Digest()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrCallbackPending = errors.New("identical event still awaits its callback")
+
+// eventTag precedes an event ID in an ExecDigest
+const eventTag byte = 0x01
+
+// EventID identifies an event by its target, function and parameters, and
+// by its sender and nonce when it is ordered, so the sender and the enclave
+// executing it derive the same ID. Identical events with a callback can be
+// pending once; senders that repeat an unordered event include a nonce in
+// its parameters.
+func (a *SendEventAction) EventID() ids.ID {
+	h := sha256.New()
+	fields := [][]byte{[]byte(a.IDTo), []byte(a.FunctionCall), a.Parameters}
+	if a.Nonce != 0 {
+		fields = append(fields, a.Sender[:], binary.BigEndian.AppendUint64(nil, a.Nonce))
+	}
+	for _, field := range fields {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(field))))
+		h.Write(field)
+	}
+	return ids.ID(h.Sum(nil))
+}
+
+// completeCallback moves the callback awaiting [eventID], if any, into a
+// callback event carrying [resultDigest]. Later executions of an event
+// whose callback was delivered, such as the other enclave's attestation,
+// leave the delivery as it is.
+func completeCallback(
+	ctx context.Context,
+	mu state.Mutable,
+	eventID ids.ID,
+	resultDigest []byte,
+	timestamp int64,
+) error {
+	cb, err := storage.GetCallback(ctx, mu, eventID)
+	if err != nil {
+		return err
+	}
+	if cb == nil {
+		return nil
+	}
+	if err := storage.SetCallbackEvent(ctx, mu, eventID, &storage.CallbackEvent{
+		Object:     cb.Object,
+		Function:   cb.Function,
+		ResultHash: ids.ID(resultDigest),
+		QueuedAt:   timestamp,
+	}); err != nil {
+		return err
+	}
+	return storage.RemoveCallback(ctx, mu, eventID)
+}
@@ -0,0 +1,273 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidCommittee  = errors.New("invalid checkpoint committee")
+	ErrNoCommittee       = errors.New("no checkpoint committee")
+	ErrCheckpointHeight  = errors.New("checkpoint height not allowed")
+	ErrCheckpointSigners = errors.New("invalid checkpoint signers")
+	ErrCheckpointQuorum  = errors.New("checkpoint signers below quorum")
+
+	_ chain.Action = (*SubmitCheckpointAction)(nil)
+)
+
+// checkpointDomain separates checkpoint signatures from the other
+// statements a validator BLS key signs.
+const checkpointDomain = "shuttlevm/checkpoint"
+
+// CheckpointDigest is what a committee member signs to checkpoint [root]
+// as the root of [regionID] at [height].
+func CheckpointDigest(regionID string, height uint64, root ids.ID) []byte {
+	h := sha256.New()
+	h.Write([]byte(checkpointDomain))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(binary.BigEndian.AppendUint64(nil, height))
+	h.Write(root[:])
+	return h.Sum(nil)
+}
+
+// CheckpointValidator is a member of the checkpoint committee, by the BLS
+// key it signs checkpoints with.
+type CheckpointValidator struct {
+	PublicKey []byte `serialize:"true" json:"public_key"`
+	Weight    uint64 `serialize:"true" json:"weight"`
+}
+
+// CheckpointCommittee is the value encoding of
+// [consts.ParamCheckpointCommittee]: the validators that co-sign region
+// roots every [Interval] blocks. A checkpoint needs signers with at least
+// [Quorum] of their total weight.
+type CheckpointCommittee struct {
+	Validators []CheckpointValidator `serialize:"true" json:"validators"`
+	Quorum     uint64                `serialize:"true" json:"quorum"`
+	Interval   uint64                `serialize:"true" json:"interval"`
+}
+
+func (c *CheckpointCommittee) validate() error {
+	if len(c.Validators) == 0 || len(c.Validators) > consts.MaxCheckpointValidators {
+		return fmt.Errorf("%w: %d validators", ErrInvalidCommittee, len(c.Validators))
+	}
+	if c.Interval == 0 {
+		return fmt.Errorf("%w: no interval", ErrInvalidCommittee)
+	}
+	keys := make(map[string]struct{}, len(c.Validators))
+	var total uint64
+	for _, v := range c.Validators {
+		if !attestation.BLSCapable(v.PublicKey) {
+			return fmt.Errorf("%w: %x is not a BLS key", ErrInvalidCommittee, v.PublicKey)
+		}
+		if _, ok := keys[string(v.PublicKey)]; ok {
+			return fmt.Errorf("%w: duplicate key %x", ErrInvalidCommittee, v.PublicKey)
+		}
+		keys[string(v.PublicKey)] = struct{}{}
+		if v.Weight == 0 || total+v.Weight < total {
+			return fmt.Errorf("%w: weight %d", ErrInvalidCommittee, v.Weight)
+		}
+		total += v.Weight
+	}
+	// More than half the weight, so no two roots of a height both reach
+	// quorum unless members sign both
+	if c.Quorum <= total/2 || c.Quorum > total {
+		return fmt.Errorf("%w: quorum %d of %d", ErrInvalidCommittee, c.Quorum, total)
+	}
+	return nil
+}
+
+// signers returns the keys and total weight of the members set in the
+// bitset [signers], where member i is bit i%8 of byte i/8.
+func (c *CheckpointCommittee) signers(signers []byte) ([][]byte, uint64, error) {
+	if len(signers) != (len(c.Validators)+7)/8 {
+		return nil, 0, fmt.Errorf("%w: %d bytes for %d members", ErrCheckpointSigners, len(signers), len(c.Validators))
+	}
+	var (
+		keys   [][]byte
+		weight uint64
+	)
+	for i, b := range signers {
+		for b != 0 {
+			member := i*8 + bits.TrailingZeros8(b)
+			if member >= len(c.Validators) {
+				return nil, 0, fmt.Errorf("%w: no member %d", ErrCheckpointSigners, member)
+			}
+			keys = append(keys, c.Validators[member].PublicKey)
+			weight += c.Validators[member].Weight
+			b &= b - 1
+		}
+	}
+	if len(keys) == 0 {
+		return nil, 0, ErrCheckpointSigners
+	}
+	return keys, weight, nil
+}
+
+// GovernedCheckpointCommittee returns the checkpoint committee in effect
+// at the current height and the hash of its encoding, or nil if governance
+// never set one.
+func GovernedCheckpointCommittee(ctx context.Context, im state.Immutable) (*CheckpointCommittee, ids.ID, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return nil, ids.Empty, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(consts.ParamCheckpointCommittee))
+	if err != nil {
+		return nil, ids.Empty, err
+	}
+	return DecodeCheckpointCommittee(p.Value(height))
+}
+
+// DecodeCheckpointCommittee decodes a [consts.ParamCheckpointCommittee]
+// value and returns it with its hash. An empty value has no committee.
+func DecodeCheckpointCommittee(value []byte) (*CheckpointCommittee, ids.ID, error) {
+	if len(value) == 0 {
+		return nil, ids.Empty, nil
+	}
+	var c CheckpointCommittee
+	if err := codec.Unmarshal(value, &c); err != nil {
+		return nil, ids.Empty, err
+	}
+	return &c, sha256.Sum256(value), nil
+}
+
+// SubmitCheckpointAction records [Root] as the root of [RegionID] at
+// [Height], co-signed by a quorum of the checkpoint committee, so bridges
+// can rely on validator signatures and not only on enclave attestations.
+// [Height] must be a multiple of the committee's interval, reached, and
+// after the region's last checkpoint. Anyone may submit it.
+type SubmitCheckpointAction struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Height   uint64 `serialize:"true" json:"height"`
+	Root     ids.ID `serialize:"true" json:"root"`
+	// Signers is the bitset of the committee members that signed, and
+	// Signature aggregates their BLS signatures over [CheckpointDigest]
+	Signers   []byte `serialize:"true" json:"signers"`
+	Signature []byte `serialize:"true" json:"signature"`
+}
+
+func (*SubmitCheckpointAction) GetTypeID() uint8 {
+	return consts.SubmitCheckpointID
+}
+
+func (s *SubmitCheckpointAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.HeightKey()):                                      state.Read,
+		string(storage.ParamKey(uint8(consts.ParamCheckpointCommittee))): state.Read,
+		string(storage.RegionKey(s.RegionID)):                            state.Read,
+		string(storage.CheckpointHeadKey(s.RegionID)):                    state.All,
+		string(storage.CheckpointKey(s.RegionID, s.Height)):              state.All,
+	}, actor, s)
+}
+
+func (s *SubmitCheckpointAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SubmitCheckpointID, s.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	committee, committeeHash, err := GovernedCheckpointCommittee(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if committee == nil {
+		return nil, ErrNoCommittee
+	}
+	if _, exists, err := storage.GetRegion(ctx, mu, s.RegionID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrRegionNotFound
+	}
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	head, err := storage.GetCheckpointHead(ctx, mu, s.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Height == 0 || s.Height%committee.Interval != 0 || s.Height > height || s.Height <= head {
+		return nil, fmt.Errorf("%w: %d (interval=%d, height=%d, last=%d)", ErrCheckpointHeight, s.Height, committee.Interval, height, head)
+	}
+	keys, weight, err := committee.signers(s.Signers)
+	if err != nil {
+		return nil, err
+	}
+	if weight < committee.Quorum {
+		return nil, fmt.Errorf("%w: %d < %d", ErrCheckpointQuorum, weight, committee.Quorum)
+	}
+	aggregateKey, err := attestation.AggregatePublicKeys(keys...)
+	if err != nil {
+		return nil, err
+	}
+	if err := attestation.VerifyAggregate(CheckpointDigest(s.RegionID, s.Height, s.Root), s.Signature, aggregateKey); err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetCheckpoint(ctx, mu, s.RegionID, s.Height, &storage.Checkpoint{
+		Root:         s.Root,
+		Committee:    committeeHash,
+		Signers:      s.Signers,
+		Weight:       weight,
+		AggregateKey: aggregateKey,
+		Signature:    s.Signature,
+		Submitter:    actor,
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetCheckpointHead(ctx, mu, s.RegionID, s.Height); err != nil {
+		return nil, err
+	}
+	return &SubmitCheckpointResult{
+		RegionID: s.RegionID,
+		Height:   s.Height,
+		Root:     s.Root,
+		Weight:   weight,
+	}, nil
+}
+
+func (*SubmitCheckpointAction) ComputeUnits(rules chain.Rules) uint64 {
+	return FeeScheduleOf(rules).ExecUnits(1, 0, 2, 0, 0)
+}
+
+func (*SubmitCheckpointAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type SubmitCheckpointResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Height   uint64 `serialize:"true" json:"height"`
+	Root     ids.ID `serialize:"true" json:"root"`
+	Weight   uint64 `serialize:"true" json:"weight"`
+}
+
+func (*SubmitCheckpointResult) GetTypeID() uint8 {
+	return consts.SubmitCheckpointResultID
+}
@@ -0,0 +1,184 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrCollateralTooLarge = errors.New("collateral too large")
+	ErrCollateralRollback = errors.New("collateral older than the published collateral")
+	ErrCollateralNotFound = errors.New("no collateral published for platform")
+	ErrTCBNotAcceptable   = errors.New("platform TCB not acceptable")
+
+	_ chain.Action = (*PublishCollateralAction)(nil)
+)
+
+// PublishCollateralAction stores DCAP collateral for the platform family
+// named in its TCB info. Anyone may submit it: the collateral must be signed
+// by a TCB signing certificate chaining up to the SGX root from genesis, be
+// unexpired at the block timestamp, and carry a TCB evaluation data number
+// no lower than the collateral it replaces.
+type PublishCollateralAction struct {
+	Collateral attestation.Collateral `serialize:"true" json:"collateral"`
+}
+
+func (*PublishCollateralAction) GetTypeID() uint8 {
+	return consts.PublishCollateralID
+}
+
+// fmspc is the platform family the collateral is for, or nil if its TCB
+// info does not parse.
+func (p *PublishCollateralAction) fmspc() []byte {
+	info, err := attestation.ParseTCBInfo(p.Collateral.TCBInfo)
+	if err != nil {
+		return nil
+	}
+	fmspc, _ := hex.DecodeString(info.FMSPC)
+	return fmspc
+}
+
+func (p *PublishCollateralAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.SGXRootKey()):             state.Read,
+		string(storage.CollateralKey(p.fmspc())): state.All,
+	}, actor, p)
+}
+
+func (p *PublishCollateralAction) size() int {
+	c := &p.Collateral
+	n := len(c.TCBInfo) + len(c.TCBInfoSignature) + len(c.QEIdentity) + len(c.QEIdentitySignature) + len(c.RootCRL)
+	for _, cert := range c.SigningChain {
+		n += len(cert)
+	}
+	return n
+}
+
+func (p *PublishCollateralAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	if p.size() > attestation.MaxCollateralSize {
+		return nil, ErrCollateralTooLarge
+	}
+	root, err := storage.GetSGXRoot(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	v, err := attestation.VerifyCollateral(&p.Collateral, root, time.UnixMilli(timestamp))
+	if err != nil {
+		return nil, err
+	}
+	fmspc := p.fmspc()
+	prev, err := storage.GetCollateral(ctx, mu, fmspc)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil && v.TCBInfo.TCBEvaluationDataNumber < prev.EvaluationDataNumber {
+		return nil, ErrCollateralRollback
+	}
+
+	stored := &storage.StoredCollateral{
+		Collateral:           p.Collateral,
+		Expiry:               v.Expiry.UnixMilli(),
+		EvaluationDataNumber: v.TCBInfo.TCBEvaluationDataNumber,
+	}
+	if err := storage.SetCollateral(ctx, mu, fmspc, stored); err != nil {
+		return nil, err
+	}
+	return &PublishCollateralResult{
+		FMSPC:                fmspc,
+		Expiry:               stored.Expiry,
+		EvaluationDataNumber: stored.EvaluationDataNumber,
+	}, nil
+}
+
+func (p *PublishCollateralAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	kib := uint64(p.size()+1023) / 1024
+	return schedule.BaseUnits + schedule.TEEUnits + kib*schedule.StateKiBUnits
+}
+
+func (*PublishCollateralAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type PublishCollateralResult struct {
+	FMSPC                []byte `serialize:"true" json:"fmspc"`
+	Expiry               int64  `serialize:"true" json:"expiry"`
+	EvaluationDataNumber uint32 `serialize:"true" json:"evaluation_data_number"`
+}
+
+func (*PublishCollateralResult) GetTypeID() uint8 {
+	return consts.PublishCollateralResultID
+}
+
+// EvaluateTCB judges an SGX platform against the collateral published for
+// [fmspc], as of the block [timestamp] in milliseconds. It returns the worse
+// of the platform and quoting enclave statuses, and ErrTCBNotAcceptable if
+// that status should not be trusted. Only on-chain collateral is consulted,
+// so every validator reaches the same verdict.
+func EvaluateTCB(
+	ctx context.Context,
+	im state.Immutable,
+	fmspc []byte,
+	cpuSVN [attestation.CPUSVNLen]byte,
+	pceSVN uint16,
+	qeSVN uint16,
+	timestamp int64,
+) (attestation.TCBStatus, error) {
+	stored, err := storage.GetCollateral(ctx, im, fmspc)
+	if err != nil {
+		return "", err
+	}
+	if stored == nil {
+		return "", ErrCollateralNotFound
+	}
+	if timestamp >= stored.Expiry {
+		return "", attestation.ErrCollateralExpired
+	}
+	info, err := attestation.ParseTCBInfo(stored.Collateral.TCBInfo)
+	if err != nil {
+		return "", err
+	}
+	qe, err := attestation.ParseQEIdentity(stored.Collateral.QEIdentity)
+	if err != nil {
+		return "", err
+	}
+	platform, err := info.Status(cpuSVN, pceSVN)
+	if err != nil {
+		return "", err
+	}
+	enclave, err := qe.Status(qeSVN)
+	if err != nil {
+		return "", err
+	}
+	status := attestation.WorseTCBStatus(platform, enclave)
+	if !status.Acceptable() {
+		return status, ErrTCBNotAcceptable
+	}
+	return status, nil
+}
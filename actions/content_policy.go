@@ -0,0 +1,139 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrContentPolicy = errors.New("rejected by content policy")
+
+// ObjectContent is what content policies see of a new object.
+type ObjectContent struct {
+	ID string
+	// RegionID is the region the object is listed in, if any
+	RegionID string
+	// Code is decompressed, and CodeHash is its SHA-256 hash
+	Code     []byte
+	CodeHash ids.ID
+}
+
+// EventContent is what content policies see of a new event.
+type EventContent struct {
+	Target string
+	// RegionID is the region the target is listed in, if any
+	RegionID   string
+	Function   string
+	Parameters []byte
+	// Encrypted marks Parameters as a sealed envelope, which only the
+	// enclave can read
+	Encrypted bool
+}
+
+// ContentPolicy enforces an operator's rules on the objects and events the
+// node accepts. Policies are node-local, like the prefilter: they keep
+// content out of this node's mempool and blocks, but are not consensus
+// rules.
+type ContentPolicy interface {
+	// Name identifies the policy in rejections
+	Name() string
+	CheckObject(ctx context.Context, obj *ObjectContent) error
+	CheckEvent(ctx context.Context, event *EventContent) error
+}
+
+var contentPolicies []ContentPolicy
+
+// SetContentPolicies installs the policies checked by [CheckContent] and
+// when CreateObjectAction and SendEventAction execute, replacing any
+// installed before. The vm package installs those configured under
+// contentPolicy.
+func SetContentPolicies(policies ...ContentPolicy) {
+	contentPolicies = policies
+}
+
+// CheckContent returns why an installed policy rejects [action], if one
+// does. Only object creations and events are checked.
+func CheckContent(ctx context.Context, im state.Immutable, action chain.Action) error {
+	if len(contentPolicies) == 0 {
+		return nil
+	}
+	switch a := action.(type) {
+	case *CreateObjectAction:
+		return checkObjectContent(ctx, a.ID, objectRegion(a.Metadata), a.Code)
+	case *SendEventAction:
+		return a.verifyContent(ctx, im)
+	}
+	return nil
+}
+
+func objectRegion(m *storage.ObjectMetadata) string {
+	if m == nil {
+		return ""
+	}
+	return m.RegionID
+}
+
+// checkObjectContent runs the installed policies on the object [id] of
+// [regionID] with [code].
+func checkObjectContent(ctx context.Context, id string, regionID string, code []byte) error {
+	if len(contentPolicies) == 0 {
+		return nil
+	}
+	code, err := storage.DecodeCode(code, consts.MaxCodeSize)
+	if err != nil {
+		return err
+	}
+	obj := &ObjectContent{
+		ID:       id,
+		RegionID: regionID,
+		Code:     code,
+		CodeHash: sha256.Sum256(code),
+	}
+	for _, p := range contentPolicies {
+		if err := p.CheckObject(ctx, obj); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrContentPolicy, p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// checkEventContent runs the installed policies on [a], whose target is
+// listed in [regionID].
+func checkEventContent(ctx context.Context, a *SendEventAction, regionID string) error {
+	event := &EventContent{
+		Target:     a.IDTo,
+		RegionID:   regionID,
+		Function:   a.FunctionCall,
+		Parameters: a.Parameters,
+		Encrypted:  a.Encrypted,
+	}
+	for _, p := range contentPolicies {
+		if err := p.CheckEvent(ctx, event); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrContentPolicy, p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// verifyContent runs the installed policies on [a].
+func (a *SendEventAction) verifyContent(ctx context.Context, im state.Immutable) error {
+	if len(contentPolicies) == 0 {
+		return nil
+	}
+	m, err := storage.GetObjectMetadata(ctx, im, a.IDTo)
+	if err != nil {
+		return err
+	}
+	return checkEventContent(ctx, a, objectRegion(m))
+}
@@ -13,7 +13,7 @@ import (
     "github.com/ava-labs/hypersdk/crypto/ed25519"
     "github.com/ava-labs/hypersdk/state"
     "github.com/ava-labs/hypersdk/examples/shuttlevm/storage"
-    "github.com/ava-labs/hypersdk/examples/shuttlevm/consts"
+    "github.com/rhombus-tech/vm/consts"
 )
 
 var (
@@ -50,10 +50,10 @@ func (*ContractVerification) GetTypeID() uint8 {
 }
 
 func (cv *ContractVerification) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
-    return state.Keys{
+    return addGateKeys(state.Keys{
         string(storage.ContractKey(cv.ExpectedChecksum)): state.Read | state.Write,
         string(storage.BalanceKey(actor)):                state.Read | state.Write,
-    }
+    }, actor, cv)
 }
 
 func (cv *ContractVerification) Execute(
@@ -64,6 +64,10 @@ func (cv *ContractVerification) Execute(
     actor codec.Address,
     txID ids.ID,
 ) (codec.Typed, error) {
+    if err := checkGates(ctx, rules, mu, timestamp, actor, cv); err != nil {
+        return nil, err
+    }
+
     // Basic validation
     if len(cv.ContractCode) < MinContractSize || len(cv.ContractCode) > MaxContractSize {
         return nil, ErrInvalidContractCode
@@ -100,9 +104,12 @@ func (cv *ContractVerification) Execute(
     }, nil
 }
 
-func (cv *ContractVerification) ComputeUnits(chain.Rules) uint64 {
-    // Base cost plus additional cost based on contract size
-    return BaseComputeUnits + uint64(len(cv.ContractCode)/1024)
+func (cv *ContractVerification) ComputeUnits(rules chain.Rules) uint64 {
+    schedule := FeeScheduleOf(rules)
+    // Base cost plus signature check and per-KiB cost of the stored contract
+    return BaseComputeUnits +
+        schedule.AttestationUnits +
+        schedule.StorageUnits(len(cv.ContractCode), 0)
 }
 
 func (*ContractVerification) ValidRange(chain.Rules) (int64, int64) {
@@ -140,6 +147,8 @@ type ContractVerificationResult struct {
     Success          bool   `serialize:"true" json:"success"`
     ExecutionResults []byte `serialize:"true" json:"execution_results"`
     Checksum        []byte `serialize:"true" json:"checksum"`
+    ErrorCode       consts.ErrorCode `serialize:"true" json:"error_code"`
+    Message         string `serialize:"true" json:"message"`
 }
 
 func (*ContractVerificationResult) GetTypeID() uint8 {
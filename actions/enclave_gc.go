@@ -0,0 +1,159 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrEnclaveNotRetired = errors.New("enclave not removed from region")
+	ErrEnclaveRetained   = errors.New("enclave retention window not over")
+
+	_ chain.Action = (*PruneEnclaveAction)(nil)
+)
+
+// PruneEnclaveAction deletes the records of an enclave removed from
+// [RegionID] once the governed retention window has passed since its
+// removal. Until then the records stay on chain, inactive, for auditors.
+// Anyone may submit it.
+type PruneEnclaveAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+}
+
+func (*PruneEnclaveAction) GetTypeID() uint8 {
+	return consts.PruneEnclaveID
+}
+
+func (p *PruneEnclaveAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.ParamKey(uint8(consts.ParamEnclaveRetention))): state.Read,
+		string(storage.EnclaveKey(p.RegionID, p.EnclaveID)):           state.All,
+		string(storage.EnclavePubKeyKey(p.RegionID, p.EnclaveID)):     state.All,
+		string(storage.EnclaveTypeKey(p.RegionID, p.EnclaveID)):       state.All,
+		string(storage.EnclaveExpiryKey(p.RegionID, p.EnclaveID)):     state.All,
+		string(storage.EnclaveRetiredKey(p.RegionID, p.EnclaveID)):    state.All,
+		string(storage.EncryptionKeysKey(p.RegionID)):                 state.All,
+	}
+	for _, t := range attestation.EnclaveTypes {
+		keys[string(storage.PlatformCountKey(p.RegionID, t))] = state.All
+	}
+	return addGateKeys(addAuditKeys(keys, p.RegionID, actionID), actor, p)
+}
+
+func (p *PruneEnclaveAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.PruneEnclaveID, p.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	retired, err := storage.GetEnclaveRetired(ctx, mu, p.RegionID, p.EnclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if retired == 0 {
+		return nil, ErrEnclaveNotRetired
+	}
+	retention, err := Uint64Param(ctx, mu, consts.ParamEnclaveRetention, consts.EnclaveRetention)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(timestamp) < retired+retention*1000 {
+		return nil, ErrEnclaveRetained
+	}
+	if err := storage.DeleteEnclave(ctx, mu, p.RegionID, p.EnclaveID); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, p.RegionID, actionID, &storage.AuditEntry{
+		TypeID:    consts.PruneEnclaveID,
+		Actor:     actor,
+		Timestamp: timestamp,
+		EnclaveID: p.EnclaveID,
+	}); err != nil {
+		return nil, err
+	}
+	return &PruneEnclaveResult{RegionID: p.RegionID, EnclaveID: p.EnclaveID}, nil
+}
+
+func (*PruneEnclaveAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.TEEUnits
+}
+
+func (*PruneEnclaveAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type PruneEnclaveResult struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+}
+
+func (*PruneEnclaveResult) GetTypeID() uint8 {
+	return consts.PruneEnclaveResultID
+}
+
+// addTEEUpdateKeys declares the enclave records [updateEnclaves] reads and
+// writes for [tees] of [regionID].
+func addTEEUpdateKeys(keys state.Keys, regionID string, tees ...[]codec.Address) state.Keys {
+	for _, set := range tees {
+		for _, tee := range set {
+			keys[string(storage.EnclaveKey(regionID, tee[:]))] = state.All
+			keys[string(storage.EnclavePubKeyKey(regionID, tee[:]))] = state.Read
+			keys[string(storage.EnclaveRetiredKey(regionID, tee[:]))] = state.All
+		}
+	}
+	return keys
+}
+
+// updateEnclaves retires the enclaves of TEEs [removed] from [regionID] at
+// [timestamp], so they stop attesting for it, and reinstates those of TEEs
+// [added] back before their records were pruned.
+func updateEnclaves(ctx context.Context, mu state.Mutable, regionID string, added, removed []codec.Address, timestamp int64) error {
+	for _, tee := range removed {
+		status, _, err := storage.GetEnclave(ctx, mu, regionID, tee[:])
+		if err != nil {
+			return err
+		}
+		if status != storage.EnclaveActive {
+			continue
+		}
+		if err := storage.RetireEnclave(ctx, mu, regionID, tee[:], timestamp); err != nil {
+			return err
+		}
+	}
+	for _, tee := range added {
+		retired, err := storage.GetEnclaveRetired(ctx, mu, regionID, tee[:])
+		if err != nil {
+			return err
+		}
+		if retired == 0 {
+			continue
+		}
+		if err := storage.ReinstateEnclave(ctx, mu, regionID, tee[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,510 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+// nitroSigner installs a self-signed P-384 key as the Nitro root of [f],
+// standing in for both the AWS root and the hypervisor signing
+// certificate, valid for [validity] past the current block. It returns a
+// function signing a document for [publicKey] with PCR 0 set to 1 and
+// the PCRs in [pcrs].
+func nitroSigner(t *testing.T, f *testvm.Fixture, validity time.Duration, pcrs map[uint8][]byte) func(publicKey string) []byte {
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.UnixMilli(f.Timestamp).Add(-time.Hour),
+		NotAfter:              time.UnixMilli(f.Timestamp).Add(validity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(err)
+	require.NoError(storage.SetNitroRoot(context.Background(), f.State, der))
+
+	return func(publicKey string) []byte {
+		doc, err := attestation.SignNitroDocument(&attestation.NitroDocument{
+			ModuleID:    "i-0123-enc0123",
+			Timestamp:   uint64(f.Timestamp),
+			PCRs:        pcrs,
+			Certificate: der,
+			PublicKey:   []byte(publicKey),
+		}, key)
+		require.NoError(err)
+		return doc
+	}
+}
+
+func TestNitroEnclaveRegistration(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	sign := nitroSigner(t, f, time.Hour, map[uint8][]byte{0: {1}, 8: {2}})
+	register := &actions.RegisterNitroEnclaveAction{RegionID: testvm.Region, Document: sign("enclave key")}
+
+	tests := []struct {
+		name        string
+		policy      *attestation.NitroPolicy
+		expectedErr error
+		assertion   func(context.Context, *testing.T, codec.Typed)
+	}{
+		{
+			name:        "NoPolicy",
+			expectedErr: actions.ErrNitroNotAccepted,
+		},
+		{
+			name:        "PCRMismatch",
+			policy:      &attestation.NitroPolicy{PCRs: []attestation.PCR{{Index: 0, Value: []byte{9}}}},
+			expectedErr: attestation.ErrNitroPCRMismatch,
+		},
+		{
+			name: "Accepted",
+			policy: &attestation.NitroPolicy{
+				PCRs: []attestation.PCR{{Index: 0, Value: []byte{1}}, {Index: 8, Value: []byte{2}}},
+			},
+			assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				enclaveID := out.(*actions.RegisterNitroEnclaveResult).EnclaveID
+				status, pub, err := storage.GetEnclave(ctx, f.State, testvm.Region, enclaveID)
+				require.NoError(err)
+				require.Equal(storage.EnclaveActive, status)
+				require.Equal([]byte("enclave key"), pub)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.policy != nil {
+			require.NoError(t, storage.SetNitroPolicy(ctx, f.State, testvm.Region, tt.policy))
+		}
+		f.RunSteps(ctx, t, []testvm.Step{
+			{
+				Name:        tt.name,
+				Actor:       f.Actor,
+				Action:      register,
+				ExpectedErr: tt.expectedErr,
+				Assertion:   tt.assertion,
+			},
+		})
+	}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Registered",
+			Actor:       f.Actor,
+			Action:      register,
+			ExpectedErr: actions.ErrEnclaveRegistered,
+		},
+		{
+			Name:        "RegionNotFound",
+			Actor:       f.Actor,
+			Action:      &actions.RegisterNitroEnclaveAction{RegionID: "us-west", Document: register.Document},
+			ExpectedErr: actions.ErrRegionNotFound,
+		},
+	})
+
+	// Documents go stale like Roughtime stamps: past the governed drift
+	// they fail, and past the most it may be they expire unexecuted
+	require.NoError(t, storage.ScheduleParam(ctx, f.State, uint8(consts.ParamTimeDrift), binary.BigEndian.AppendUint64(nil, 60), f.Height, f.Height))
+	for _, tt := range []struct {
+		name        string
+		after       time.Duration
+		expectedErr error
+	}{
+		{
+			name:        "Stale",
+			after:       2 * time.Minute,
+			expectedErr: actions.ErrStaleNitroDocument,
+		},
+		{
+			name:        "Expired",
+			after:       10 * time.Minute,
+			expectedErr: testvm.ErrOutsideValidRange,
+		},
+	} {
+		require.NoError(t, f.Advance(ctx, 1, tt.after))
+		f.RunSteps(ctx, t, []testvm.Step{
+			{
+				Name:        tt.name,
+				Actor:       f.Actor,
+				Action:      register,
+				ExpectedErr: tt.expectedErr,
+			},
+		})
+	}
+}
+
+func TestPlatformPolicy(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	cpak, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	rak, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	cpakDER, err := x509.MarshalPKIXPublicKey(&cpak.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, storage.SetCCAPlatformKeys(ctx, f.State, &storage.CCAPlatformKeys{Keys: [][]byte{cpakDER}}))
+
+	// The region needs a CCA enclave alongside its SGX and SEV pair
+	require.NoError(t, storage.SetPlatformPolicy(ctx, f.State, testvm.Region, &attestation.PlatformPolicy{
+		Requirements: []attestation.PlatformRequirement{
+			{Type: attestation.SGX, Min: 1},
+			{Type: attestation.SEV, Min: 1},
+			{Type: attestation.CCA, Min: 1},
+		},
+		RealmMeasurements: [][]byte{{1, 2, 3}},
+	}))
+
+	platform := &attestation.CCAPlatform{
+		CPAK:             cpak,
+		RAK:              rak,
+		ImplementationID: make([]byte, 32),
+		InstanceID:       make([]byte, 33),
+		Lifecycle:        0x3000,
+	}
+	realmKey := []byte("realm key")
+	encryptionKey, err := envelope.GenerateKey()
+	require.NoError(t, err)
+	sealKey := encryptionKey.PublicKey().Bytes()
+	register := func(regionID string, measurement []byte, boundKey, sealKey []byte) *actions.RegisterCCAEnclaveAction {
+		token, err := platform.SignCCAToken(actions.CCAChallenge(regionID, realmKey, boundKey), measurement)
+		require.NoError(t, err)
+		return &actions.RegisterCCAEnclaveAction{RegionID: testvm.Region, PublicKey: realmKey, Token: token, EncryptionKey: sealKey}
+	}
+	registered := register(testvm.Region, []byte{1, 2, 3}, sealKey, sealKey)
+
+	exec := f.Exec(t, f.SGX, actions.TEEExecResult{ContractAddr: []byte("contract")})
+	// Enclaves cannot claim another type than they registered with
+	mistyped := *exec
+	mistyped.Attestation.EnclaveType = attestation.SEV
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "PlatformMix",
+			Actor:       f.SGX.Address,
+			Action:      exec,
+			ExpectedErr: attestation.ErrPlatformMix,
+		},
+		{
+			Name:        "OtherRegion",
+			Actor:       f.Actor,
+			Action:      register("us-west", []byte{1, 2, 3}, nil, nil),
+			ExpectedErr: actions.ErrCCAChallenge,
+		},
+		{
+			Name:        "RealmMeasurement",
+			Actor:       f.Actor,
+			Action:      register(testvm.Region, []byte{4}, nil, nil),
+			ExpectedErr: attestation.ErrRealmMeasurement,
+		},
+		{
+			// The token binds the encryption key the realm publishes
+			Name:        "EncryptionKeyNotBound",
+			Actor:       f.Actor,
+			Action:      register(testvm.Region, []byte{1, 2, 3}, nil, sealKey),
+			ExpectedErr: actions.ErrCCAChallenge,
+		},
+		{
+			Name:   "Register",
+			Actor:  f.Actor,
+			Action: registered,
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				require := require.New(t)
+				keys, err := storage.GetEncryptionKeys(ctx, f.State, testvm.Region)
+				require.NoError(err)
+				require.Equal(storage.EncryptionKey{EnclaveID: attestation.KeyEnclaveID(realmKey), Key: sealKey}, keys[len(keys)-1])
+
+				// The registration is logged with a hash of its token
+				head, err := storage.GetAuditHead(ctx, f.State, testvm.Region)
+				require.NoError(err)
+				entry, err := storage.GetAuditEntry(ctx, f.State, testvm.Region, head.Latest)
+				require.NoError(err)
+				require.Equal(consts.RegisterCCAEnclaveID, entry.TypeID)
+				require.Equal(f.Actor, entry.Actor)
+				require.Equal(attestation.KeyEnclaveID(realmKey), entry.EnclaveID)
+				require.Equal([]ids.ID{sha256.Sum256(registered.Token)}, entry.Attestations)
+			},
+		},
+		{
+			Name:      "Execute",
+			Actor:     f.SGX.Address,
+			Action:    exec,
+			Assertion: succeeded,
+		},
+		{
+			Name:        "EnclaveTypeMismatch",
+			Actor:       f.SGX.Address,
+			Action:      &mistyped,
+			ExpectedErr: actions.ErrEnclaveTypeMismatch,
+		},
+	})
+
+	// Types outside the policy cannot attest
+	require.NoError(t, storage.SetPlatformPolicy(ctx, f.State, testvm.Region, &attestation.PlatformPolicy{
+		Requirements: []attestation.PlatformRequirement{{Type: attestation.SEV}},
+	}))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "NotAllowed",
+			Actor:       f.SGX.Address,
+			Action:      exec,
+			ExpectedErr: attestation.ErrPlatformNotAllowed,
+		},
+	})
+}
+
+func TestHeterogeneousRegion(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	v := testvm.New()
+
+	first, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(err)
+	second, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(err)
+	sev, err := testvm.NewEnclave(testvm.EnclaveSEV)
+	require.NoError(err)
+	require.NoError(v.Mint(ctx, first.Address, testvm.Funds))
+	require.NoError(storage.SetPlatformPolicy(ctx, v.State, testvm.Region, &attestation.PlatformPolicy{
+		Requirements:  []attestation.PlatformRequirement{{Type: attestation.SGX}, {Type: attestation.SEV}},
+		Heterogeneous: true,
+	}))
+	exec, err := v.Attest(testvm.Region, first, actions.TEEExecResult{ContractAddr: []byte("contract")})
+	require.NoError(err)
+
+	// Pairing with another vendor lets the region execute again
+	tests := []struct {
+		name        string
+		enclaves    []*testvm.Enclave
+		expectedErr error
+	}{
+		{
+			name:        "SingleVendor",
+			enclaves:    []*testvm.Enclave{first, second},
+			expectedErr: attestation.ErrSingleVendor,
+		},
+		{
+			name:     "Paired",
+			enclaves: []*testvm.Enclave{first, second, sev},
+		},
+	}
+	for _, tt := range tests {
+		require.NoError(v.RegisterRegion(ctx, testvm.Region, tt.enclaves...))
+		v.RunSteps(ctx, t, []testvm.Step{
+			{
+				Name:        tt.name,
+				Actor:       first.Address,
+				Action:      exec,
+				ExpectedErr: tt.expectedErr,
+			},
+		})
+	}
+}
+
+func TestEnclaveReattestation(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	week := time.Duration(consts.AttestationValidity) * time.Second
+	sign := nitroSigner(t, f, 2*week, map[uint8][]byte{0: {1}})
+	require.NoError(t, storage.SetNitroPolicy(ctx, f.State, testvm.Region, &attestation.NitroPolicy{
+		PCRs: []attestation.PCR{{Index: 0, Value: []byte{1}}},
+	}))
+
+	var enclaveID []byte
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Register",
+			Actor:  f.Actor,
+			Action: &actions.RegisterNitroEnclaveAction{RegionID: testvm.Region, Document: sign("enclave key")},
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				enclaveID = out.(*actions.RegisterNitroEnclaveResult).EnclaveID
+				expiry, err := storage.GetEnclaveExpiry(ctx, f.State, testvm.Region, enclaveID)
+				require.NoError(t, err)
+				require.Equal(t, uint64(f.Timestamp)+uint64(week.Milliseconds()), expiry)
+			},
+		},
+	})
+
+	// A lapsed enclave is rejected until it re-attests
+	require.NoError(t, storage.SetEnclaveExpiry(ctx, f.State, testvm.Region, f.SGX.ID(), uint64(f.Timestamp)+1))
+	require.NoError(t, f.Advance(ctx, 1, time.Second))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Lapsed",
+			Actor:       f.SGX.Address,
+			Action:      f.Exec(t, f.SGX, actions.TEEExecResult{ContractAddr: []byte("contract")}),
+			ExpectedErr: actions.ErrEnclaveExpired,
+		},
+		{
+			Name:        "Unsupported",
+			Actor:       f.Actor,
+			Action:      &actions.ReattestEnclaveAction{RegionID: testvm.Region, EnclaveID: f.SGX.ID()},
+			ExpectedErr: actions.ErrReattestUnsupported,
+		},
+	})
+
+	require.NoError(t, f.Advance(ctx, 1, week))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "KeyMismatch",
+			Actor:       f.Actor,
+			Action:      &actions.ReattestEnclaveAction{RegionID: testvm.Region, EnclaveID: enclaveID, Evidence: sign("other key")},
+			ExpectedErr: actions.ErrReattestKeyMismatch,
+		},
+		{
+			Name:   "Reattest",
+			Actor:  f.Actor,
+			Action: &actions.ReattestEnclaveAction{RegionID: testvm.Region, EnclaveID: enclaveID, Evidence: sign("enclave key")},
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, uint64(f.Timestamp)+uint64(week.Milliseconds()), out.(*actions.ReattestEnclaveResult).Expiry)
+			},
+		},
+	})
+}
+
+func TestEnclaveRetention(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	admin := codectest.NewRandomAddress()
+
+	sgx, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(t, err)
+	sev, err := testvm.NewEnclave(testvm.EnclaveSEV)
+	require.NoError(t, err)
+	spare, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(t, err)
+	require.NoError(t, v.RegisterRegion(ctx, testvm.Region, sgx, sev, spare))
+	attest := func() *actions.TEEExecAction {
+		exec, err := v.Attest(testvm.Region, spare, actions.TEEExecResult{ContractAddr: []byte("contract")})
+		require.NoError(t, err)
+		return exec
+	}
+	update := func(add, rem []codec.Address) *actions.UpdateRegionAction {
+		return &actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: testvm.Region, AddTEEs: add, RemTEEs: rem}
+	}
+	spareOnly := []codec.Address{spare.Address}
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Attest",
+			Actor:  spare.Address,
+			Action: attest(),
+		},
+		{
+			Name:   "Remove",
+			Actor:  admin,
+			Action: update(nil, spareOnly),
+		},
+		{
+			// A removed TEE stops attesting at once, but its records are
+			// kept
+			Name:        "AttestRemoved",
+			Actor:       spare.Address,
+			Action:      attest(),
+			ExpectedErr: actions.ErrInvalidEnclave,
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				_, pubKey, err := storage.GetEnclave(ctx, v.State, testvm.Region, spare.ID())
+				require.NoError(t, err)
+				require.NotNil(t, pubKey)
+			},
+		},
+		{
+			// Added back within the window, it is reinstated
+			Name:   "AddBack",
+			Actor:  admin,
+			Action: update(spareOnly, nil),
+		},
+	})
+
+	require.NoError(t, v.Advance(ctx, 1, time.Second))
+	prune := &actions.PruneEnclaveAction{RegionID: testvm.Region, EnclaveID: spare.ID()}
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "AttestReinstated",
+			Actor:  spare.Address,
+			Action: attest(),
+		},
+		{
+			Name:   "RemoveAgain",
+			Actor:  admin,
+			Action: update(nil, spareOnly),
+		},
+		{
+			Name:        "Retained",
+			Actor:       admin,
+			Action:      prune,
+			ExpectedErr: actions.ErrEnclaveRetained,
+		},
+		{
+			Name:        "NotRetired",
+			Actor:       admin,
+			Action:      &actions.PruneEnclaveAction{RegionID: testvm.Region, EnclaveID: sgx.ID()},
+			ExpectedErr: actions.ErrEnclaveNotRetired,
+		},
+	})
+
+	count, err := storage.GetPlatformCount(ctx, v.State, testvm.Region, attestation.SGX)
+	require.NoError(t, err)
+	require.NoError(t, v.Advance(ctx, 1, consts.EnclaveRetention*time.Second))
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Prune",
+			Actor:  admin,
+			Action: prune,
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				require := require.New(t)
+				status, pubKey, err := storage.GetEnclave(ctx, v.State, testvm.Region, spare.ID())
+				require.NoError(err)
+				require.Equal(storage.EnclaveInactive, status)
+				require.Nil(pubKey)
+				pruned, err := storage.GetPlatformCount(ctx, v.State, testvm.Region, attestation.SGX)
+				require.NoError(err)
+				require.Equal(count-1, pruned)
+				keys, err := storage.GetEncryptionKeys(ctx, v.State, testvm.Region)
+				require.NoError(err)
+				require.Len(keys, 2)
+
+				// Pruning is recorded
+				head, err := storage.GetAuditHead(ctx, v.State, testvm.Region)
+				require.NoError(err)
+				entry, err := storage.GetAuditEntry(ctx, v.State, testvm.Region, head.Latest)
+				require.NoError(err)
+				require.Equal(consts.PruneEnclaveID, entry.TypeID)
+				require.Equal(spare.ID(), entry.EnclaveID)
+			},
+		},
+		{
+			// and only happens once
+			Name:        "PruneTwice",
+			Actor:       admin,
+			Action:      prune,
+			ExpectedErr: actions.ErrEnclaveNotRetired,
+		},
+	})
+}
@@ -0,0 +1,220 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// errorCodes maps action errors to the codes reported in results. Order
+// matters only when one error wraps another; the first match wins.
+var errorCodes = []struct {
+	err  error
+	code consts.ErrorCode
+}{
+	{ErrObjectExists, consts.ErrCodeObjectExists},
+	{ErrObjectNotFound, consts.ErrCodeObjectNotFound},
+	{ErrInvalidID, consts.ErrCodeInvalidID},
+	{ErrCodeTooLarge, consts.ErrCodeCodeTooLarge},
+	{ErrStorageTooLarge, consts.ErrCodeStorageTooLarge},
+	{ErrInvalidFunction, consts.ErrCodeInvalidFunction},
+	{ErrRegionExists, consts.ErrCodeRegionExists},
+	{ErrRegionNotFound, consts.ErrCodeRegionNotFound},
+	{ErrInvalidRegion, consts.ErrCodeRegionNotFound},
+	{ErrInvalidTEE, consts.ErrCodeInvalidTEE},
+	{ErrTooManyTEEs, consts.ErrCodeTooManyTEEs},
+	{ErrTooFewTEEs, consts.ErrCodeTooFewTEEs},
+	{ErrDuplicateTEE, consts.ErrCodeDuplicateTEE},
+	{ErrTEENotInRegion, consts.ErrCodeTEENotInRegion},
+	{ErrTEEBusy, consts.ErrCodeTEEBusy},
+	{ErrInvalidSignature, consts.ErrCodeInvalidSignature},
+	{ErrInvalidTimeStamps, consts.ErrCodeInvalidTimestamp},
+	{ErrStaleTimeStamp, consts.ErrCodeStaleTimestamp},
+	{ErrInvalidEnclave, consts.ErrCodeInvalidEnclave},
+	{ErrChecksumMismatch, consts.ErrCodeChecksumMismatch},
+	{storage.ErrInvalidBalance, consts.ErrCodeInsufficientBalance},
+	{storage.ErrKVKeyTooLarge, consts.ErrCodeKVTooLarge},
+	{storage.ErrKVValueTooLarge, consts.ErrCodeKVTooLarge},
+	{ErrUploadNotFound, consts.ErrCodeUploadNotFound},
+	{ErrUploadExpired, consts.ErrCodeUploadExpired},
+	{ErrInvalidCode, consts.ErrCodeInvalidCode},
+	{ErrSettlementNotFound, consts.ErrCodeSettlementNotFound},
+	{ErrSettlementDisputed, consts.ErrCodeSettlementDisputed},
+	{attestation.ErrNitroPCRMismatch, consts.ErrCodeNitroPolicyMismatch},
+	{attestation.ErrEmptyNitroPolicy, consts.ErrCodeNitroPolicyMismatch},
+	{attestation.ErrPlatformNotAllowed, consts.ErrCodePlatformPolicy},
+	{attestation.ErrPlatformMix, consts.ErrCodePlatformPolicy},
+	{attestation.ErrRealmMeasurement, consts.ErrCodePlatformPolicy},
+	{attestation.ErrSingleVendor, consts.ErrCodePlatformPolicy},
+	{ErrEnclaveTypeMismatch, consts.ErrCodePlatformPolicy},
+	{attestation.ErrCollateralChain, consts.ErrCodeCollateral},
+	{attestation.ErrCollateralSignature, consts.ErrCodeCollateral},
+	{attestation.ErrCollateralRevoked, consts.ErrCodeCollateral},
+	{attestation.ErrCollateralExpired, consts.ErrCodeCollateral},
+	{attestation.ErrMalformedCollateral, consts.ErrCodeCollateral},
+	{attestation.ErrTCBNotSupported, consts.ErrCodeCollateral},
+	{ErrCollateralTooLarge, consts.ErrCodeCollateral},
+	{ErrCollateralRollback, consts.ErrCodeCollateral},
+	{ErrCollateralNotFound, consts.ErrCodeCollateral},
+	{ErrTCBNotAcceptable, consts.ErrCodeCollateral},
+	{ErrEnclaveExpired, consts.ErrCodeEnclaveExpired},
+	{ErrRequestNotFound, consts.ErrCodeRequest},
+	{ErrRequestFulfilled, consts.ErrCodeRequest},
+	{ErrRequestExists, consts.ErrCodeRequest},
+	{ErrInvalidUserSig, consts.ErrCodeInvalidSignature},
+	{ErrPeerRequired, consts.ErrCodePlatformPolicy},
+	{ErrPeerSameEnclave, consts.ErrCodeInvalidEnclave},
+	{ErrDivergentResults, consts.ErrCodeDivergence},
+	{ErrCallbackPending, consts.ErrCodeCallback},
+	{ErrInvalidCallTrace, consts.ErrCodeObjectAccess},
+	{ErrUndeclaredObject, consts.ErrCodeObjectAccess},
+	{ErrStaleRead, consts.ErrCodeObjectAccess},
+	{ErrInvalidSchema, consts.ErrCodeInvalidParams},
+	{ErrParamMismatch, consts.ErrCodeInvalidParams},
+	{storage.ErrInsufficientAllowance, consts.ErrCodeAllowance},
+	{ErrSelfAllowance, consts.ErrCodeAllowance},
+	{ErrNotObjectCreator, consts.ErrCodeSponsor},
+	{ErrNoSponsorPolicy, consts.ErrCodeSponsor},
+	{ErrAssetNotFound, consts.ErrCodeAsset},
+	{ErrAssetExists, consts.ErrCodeAsset},
+	{ErrNotAssetOwner, consts.ErrCodeAsset},
+	{ErrMaxSupply, consts.ErrCodeAsset},
+	{ErrInvalidAsset, consts.ErrCodeAsset},
+	{ErrNativeAsset, consts.ErrCodeAsset},
+	{ErrInsufficientUnits, consts.ErrCodeAsset},
+	{ErrEventNonceUsed, consts.ErrCodeEventNonce},
+	{ErrEventNonceGap, consts.ErrCodeEventNonce},
+	{ErrEventSender, consts.ErrCodeEventNonce},
+	{ErrTipTooLarge, consts.ErrCodeInvalidParams},
+	{ErrSealedParams, consts.ErrCodeInvalidParams},
+	{ErrInvalidEncryptionKey, consts.ErrCodeInvalidEnclave},
+	{ErrBeaconEpoch, consts.ErrCodeBeacon},
+	{ErrBeaconShares, consts.ErrCodeBeacon},
+	{ErrBeaconSeed, consts.ErrCodeBeacon},
+	{ErrBeaconReveal, consts.ErrCodeBeacon},
+	{ErrBeaconNotFound, consts.ErrCodeBeacon},
+	{ErrBeaconCommitted, consts.ErrCodeBeacon},
+	{ErrFeedNotFound, consts.ErrCodeFeed},
+	{ErrInvalidFeed, consts.ErrCodeFeed},
+	{ErrFeedSigner, consts.ErrCodeFeed},
+	{ErrFeedRound, consts.ErrCodeFeed},
+	{ErrFeedDeviation, consts.ErrCodeFeed},
+	{ErrFeedStale, consts.ErrCodeFeed},
+	{ErrFeedRegion, consts.ErrCodeFeed},
+	{ErrStorageNotSealed, consts.ErrCodeSealedStorage},
+	{ErrSealedRegion, consts.ErrCodeSealedStorage},
+	{ErrSealedSigner, consts.ErrCodeSealedStorage},
+	{ErrSealedRecipients, consts.ErrCodeSealedStorage},
+	{ErrSealedStorageBlob, consts.ErrCodeSealedStorage},
+	{ErrKeyringPair, consts.ErrCodeKeyring},
+	{ErrInvalidAppKey, consts.ErrCodeKeyring},
+	{ErrAppKeyExists, consts.ErrCodeKeyring},
+	{ErrAppKeyNotFound, consts.ErrCodeKeyring},
+	{ErrAppKeyVersion, consts.ErrCodeKeyring},
+	{ErrAppKeyRevoked, consts.ErrCodeKeyring},
+	{ErrKeyringFull, consts.ErrCodeKeyring},
+	{ErrInvalidTemplate, consts.ErrCodeRegionTemplate},
+	{ErrTemplateNotFound, consts.ErrCodeRegionTemplate},
+	{ErrRegionQuota, consts.ErrCodeRegionTemplate},
+	{ErrInvalidName, consts.ErrCodeName},
+	{ErrNameTaken, consts.ErrCodeName},
+	{ErrNameNotFound, consts.ErrCodeName},
+	{ErrNameExpired, consts.ErrCodeName},
+	{ErrNameOwner, consts.ErrCodeName},
+	{ErrNameKind, consts.ErrCodeName},
+	{ErrInvalidMetadata, consts.ErrCodeInvalidParams},
+	{ErrInvalidInterface, consts.ErrCodeInterface},
+	{ErrInterfaceNotImplemented, consts.ErrCodeInterface},
+	{ErrMalformedWasm, consts.ErrCodeInvalidCode},
+	{ErrInvalidExecLimits, consts.ErrCodeInvalidParams},
+	{ErrOutOfResources, consts.ErrCodeOutOfResources},
+	{ErrDuplicateEvent, consts.ErrCodeDuplicateEvent},
+	{ErrRegionFrozen, consts.ErrCodeRegionFrozen},
+	{ErrImportInProgress, consts.ErrCodeRegionImport},
+	{ErrImportNotFound, consts.ErrCodeRegionImport},
+	{ErrImportMismatch, consts.ErrCodeRegionImport},
+	{ErrNotImporter, consts.ErrCodeRegionImport},
+	{ErrTooManyRecords, consts.ErrCodeRegionImport},
+	{ErrSchemaMismatch, consts.ErrCodeRegionImport},
+	{ErrEmptyRegionImport, consts.ErrCodeRegionImport},
+	{storage.ErrNotRegionRecord, consts.ErrCodeRegionImport},
+	{storage.ErrImportOverflow, consts.ErrCodeRegionImport},
+	{storage.ErrImportHashInvalid, consts.ErrCodeRegionImport},
+	{ErrRateLimited, consts.ErrCodeRateLimited},
+	{ErrInvalidSession, consts.ErrCodeSession},
+	{ErrSessionNotAuthorized, consts.ErrCodeSession},
+	{ErrSessionExpired, consts.ErrCodeSession},
+	{ErrSessionScope, consts.ErrCodeSession},
+	{ErrInvalidCommittee, consts.ErrCodeCheckpoint},
+	{ErrNoCommittee, consts.ErrCodeCheckpoint},
+	{ErrCheckpointHeight, consts.ErrCodeCheckpoint},
+	{ErrCheckpointSigners, consts.ErrCodeCheckpoint},
+	{ErrCheckpointQuorum, consts.ErrCodeCheckpoint},
+	{ErrEnclaveNotRetired, consts.ErrCodeEnclaveRetention},
+	{ErrEnclaveRetained, consts.ErrCodeEnclaveRetention},
+	{ErrInvalidIdempotencyKey, consts.ErrCodeInvalidParams},
+	{ErrInvalidEventOp, consts.ErrCodeEventPreempt},
+	{ErrEventNotQueued, consts.ErrCodeEventPreempt},
+	{ErrEventNotInRegion, consts.ErrCodeEventPreempt},
+	{ErrRefundRecipient, consts.ErrCodeEventPreempt},
+	{ErrInvalidSaga, consts.ErrCodeSaga},
+	{ErrSagaObject, consts.ErrCodeSaga},
+	{ErrSagaNotFound, consts.ErrCodeSaga},
+	{ErrExecFailed, consts.ErrCodeExecFailed},
+}
+
+// ErrorCodeOf returns the code for [err], ErrCodeNone for a nil error and
+// ErrCodeUnknown for errors that have no code assigned.
+func ErrorCodeOf(err error) consts.ErrorCode {
+	if err == nil {
+		return consts.ErrCodeNone
+	}
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+	return consts.ErrCodeUnknown
+}
+
+// ErrorCodeFromMessage recovers the code from an error message, such as the
+// one recorded in a failed transaction result. Action errors carry their
+// code in the message; other errors are matched on their leading message.
+func ErrorCodeFromMessage(msg string) consts.ErrorCode {
+	if len(msg) == 0 {
+		return consts.ErrCodeNone
+	}
+	if code, ok := messageCode(msg); ok {
+		return code
+	}
+	for _, ec := range errorCodes {
+		if strings.HasPrefix(msg, ec.err.Error()) {
+			return ec.code
+		}
+	}
+	return consts.ErrCodeUnknown
+}
+
+// messageCode returns the code an [ActionError] wrote into [msg], if any.
+func messageCode(msg string) (consts.ErrorCode, bool) {
+	i := strings.Index(msg, actionMarker)
+	if i < 0 {
+		return 0, false
+	}
+	rest := msg[i+len(actionMarker):]
+	j := strings.Index(rest, codeMarker)
+	if j < 0 {
+		return 0, false
+	}
+	name := rest[j+len(codeMarker):]
+	if k := strings.IndexAny(name, ",)"); k >= 0 {
+		name = name[:k]
+	}
+	return consts.ParseErrorCode(name)
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var ErrTipTooLarge = errors.New("event tip exceeds maximum")
+
+// Priority is the class the event's tip buys.
+func (a *SendEventAction) Priority() uint8 {
+	return EventPriority(a.Tip)
+}
+
+// EventPriority returns the highest class whose least tip [tip] covers.
+func EventPriority(tip uint64) uint8 {
+	class := consts.EventPriorityBase
+	for c := consts.EventPriorityBase; c < consts.NumEventPriorities; c++ {
+		if tip >= consts.EventPriorityTips[c] {
+			class = c
+		}
+	}
+	return class
+}
+
+// QueuedEvent is an event awaiting execution by a TEE.
+type QueuedEvent struct {
+	EventID  ids.ID `json:"event_id"`
+	ObjectID string `json:"object_id"`
+	Priority uint8  `json:"priority"`
+}
+
+// EventLanes orders queued events for execution. Each class is a FIFO lane
+// and the highest class with events is served first, except that a lane
+// passed over [consts.EventMaxSkips] times in a row is served next, lowest
+// class first. A waiting base event is thus taken within EventMaxSkips+1
+// events however much higher priority traffic arrives. The order depends
+// only on the pushes and pops, so every TEE of a region derives the same
+// one.
+type EventLanes struct {
+	lanes [consts.NumEventPriorities][]QueuedEvent
+	skips [consts.NumEventPriorities]int
+}
+
+// Push queues [ev] at the back of its lane. Unknown classes are queued as
+// base events.
+func (l *EventLanes) Push(ev QueuedEvent) {
+	if ev.Priority >= consts.NumEventPriorities {
+		ev.Priority = consts.EventPriorityBase
+	}
+	l.lanes[ev.Priority] = append(l.lanes[ev.Priority], ev)
+}
+
+func (l *EventLanes) Len() int {
+	n := 0
+	for _, lane := range l.lanes {
+		n += len(lane)
+	}
+	return n
+}
+
+// Pop returns the next event to execute, or false if none is queued.
+func (l *EventLanes) Pop() (QueuedEvent, bool) {
+	next := -1
+	for c := range l.lanes {
+		if len(l.lanes[c]) == 0 {
+			continue
+		}
+		if l.skips[c] >= consts.EventMaxSkips {
+			next = c
+			break
+		}
+		next = c
+	}
+	if next < 0 {
+		return QueuedEvent{}, false
+	}
+	for c := range l.lanes {
+		switch {
+		case c == next || len(l.lanes[c]) == 0:
+			l.skips[c] = 0
+		default:
+			l.skips[c]++
+		}
+	}
+	ev := l.lanes[next][0]
+	l.lanes[next] = l.lanes[next][1:]
+	return ev, true
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestEventPriority(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(consts.EventPriorityBase, EventPriority(0))
+	require.Equal(consts.EventPriorityBase, EventPriority(99))
+	require.Equal(consts.EventPriorityHigh, EventPriority(100))
+	require.Equal(consts.EventPriorityUrgent, EventPriority(consts.MaxEventTip))
+}
+
+func TestEventLanes(t *testing.T) {
+	require := require.New(t)
+
+	var lanes EventLanes
+	_, ok := lanes.Pop()
+	require.False(ok)
+
+	// Urgent events go first, and each lane is FIFO
+	lanes.Push(QueuedEvent{ObjectID: "bulk-1", Priority: consts.EventPriorityBase})
+	lanes.Push(QueuedEvent{ObjectID: "oracle-1", Priority: consts.EventPriorityUrgent})
+	lanes.Push(QueuedEvent{ObjectID: "oracle-2", Priority: consts.EventPriorityUrgent})
+	lanes.Push(QueuedEvent{ObjectID: "bulk-2", Priority: 9})
+	require.Equal(4, lanes.Len())
+	var order []string
+	for lanes.Len() > 0 {
+		ev, ok := lanes.Pop()
+		require.True(ok)
+		order = append(order, ev.ObjectID)
+	}
+	require.Equal([]string{"oracle-1", "oracle-2", "bulk-1", "bulk-2"}, order)
+
+	// Under a steady stream of higher priority events, a waiting base event
+	// is still served after EventMaxSkips of them
+	lanes.Push(QueuedEvent{ObjectID: "bulk", Priority: consts.EventPriorityBase})
+	for i := 0; i < consts.EventMaxSkips; i++ {
+		lanes.Push(QueuedEvent{ObjectID: "oracle", Priority: consts.EventPriorityUrgent})
+		lanes.Push(QueuedEvent{ObjectID: "feed", Priority: consts.EventPriorityHigh})
+		ev, ok := lanes.Pop()
+		require.True(ok)
+		require.Equal("oracle", ev.ObjectID)
+	}
+	ev, ok := lanes.Pop()
+	require.True(ok)
+	require.Equal("bulk", ev.ObjectID)
+}
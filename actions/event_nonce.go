@@ -0,0 +1,43 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrEventNonceUsed = errors.New("event nonce already used")
+	ErrEventNonceGap  = errors.New("event nonce skips ahead")
+)
+
+// CheckEventNonce checks [nonce] follows [last], the last nonce a sender
+// used for events to an object. Zero means the event is not ordered.
+func CheckEventNonce(last uint64, nonce uint64) error {
+	switch {
+	case nonce == 0:
+		return nil
+	case nonce <= last:
+		return fmt.Errorf("%w: (last=%d, nonce=%d)", ErrEventNonceUsed, last, nonce)
+	case nonce > last+1:
+		return fmt.Errorf("%w: (last=%d, nonce=%d)", ErrEventNonceGap, last, nonce)
+	}
+	return nil
+}
+
+// verifyNonce checks the event's nonce continues its sender's sequence for
+// the target object.
+func (a *SendEventAction) verifyNonce(ctx context.Context, im state.Immutable) error {
+	last, err := storage.GetEventNonce(ctx, im, a.IDTo, a.Sender)
+	if err != nil {
+		return err
+	}
+	return CheckEventNonce(last, a.Nonce)
+}
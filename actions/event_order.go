@@ -0,0 +1,41 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// appendEventLink links event [eventID], enqueued at verified time [now],
+// onto the event chain of [objectID]. Events sent again, such as without a
+// nonce, keep their first link.
+func appendEventLink(ctx context.Context, mu state.Mutable, objectID string, eventID ids.ID, now uint64) error {
+	if link, err := storage.GetEventLink(ctx, mu, objectID, eventID); err != nil || link != nil {
+		return err
+	}
+	head, err := storage.GetEventChainHead(ctx, mu, objectID)
+	if err != nil {
+		return err
+	}
+	link := &storage.EventLink{
+		Seq:          head.Count + 1,
+		EventID:      eventID,
+		VerifiedTime: now,
+		Prev:         head.Hash,
+		PrevEvent:    head.Latest,
+	}
+	if err := storage.SetEventLink(ctx, mu, objectID, link); err != nil {
+		return err
+	}
+	return storage.SetEventChainHead(ctx, mu, objectID, &storage.EventChainHead{
+		Count:  link.Seq,
+		Hash:   link.Hash(),
+		Latest: eventID,
+	})
+}
@@ -0,0 +1,265 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// Operations a [PreemptEventAction] applies to a queued event.
+const (
+	// EventOpCancel removes the event from the queue and refunds its
+	// units
+	EventOpCancel uint8 = iota
+	// EventOpReprioritize moves the event to another priority class,
+	// refunding the part of its tip a lower class does not need
+	EventOpReprioritize
+)
+
+var (
+	ErrInvalidEventOp   = errors.New("invalid queued event operation")
+	ErrEventNotQueued   = errors.New("event not queued")
+	ErrEventNotInRegion = errors.New("queued event not for an object of the region")
+	ErrRefundRecipient  = errors.New("refund recipient is not the event sender")
+
+	_ chain.Action = (*PreemptEventAction)(nil)
+)
+
+// PreemptEventAction cancels or reprioritizes the event queued under [Key]
+// for an object listed in [RegionID], once signed by a threshold of the
+// admin keys, for instance to purge a poison message no TEE can run.
+// Units refunded go to the event's sender, which [RefundTo] must name.
+type PreemptEventAction struct {
+	RegionID   string           `serialize:"true" json:"region_id"`
+	Key        []byte           `serialize:"true" json:"key"`
+	Op         uint8            `serialize:"true" json:"op"`
+	Priority   uint8            `serialize:"true" json:"priority"`
+	RefundTo   codec.Address    `serialize:"true" json:"refund_to"`
+	Nonce      uint64           `serialize:"true" json:"nonce"`
+	Signatures []AdminSignature `serialize:"true" json:"signatures"`
+}
+
+func (*PreemptEventAction) GetTypeID() uint8 {
+	return consts.PreemptEventID
+}
+
+func (p *PreemptEventAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.AdminSetKey()):          state.Read,
+		string(storage.AdminNonceKey()):        state.All,
+		string(storage.RegionKey(p.RegionID)):  state.Read,
+		string(p.Key):                          state.All,
+		string(storage.EventChargeKey(p.Key)):  state.All,
+		string(storage.BalanceKey(p.RefundTo)): state.All,
+		// Events act on no region, so their fees are collected outside any
+		string(storage.CollectedFeesKey("")): state.All,
+	}
+	if objectID, ok := storage.ParseEventQueueKey(p.Key); ok {
+		keys[string(storage.ObjectMetadataKey(objectID))] = state.Read
+	}
+	return addGateKeys(addAuditKeys(keys, p.RegionID, actionID), actor, p)
+}
+
+// Digest is the message each admin key signs on the chain [chainID].
+func (p *PreemptEventAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.PreemptEventID)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(p.RegionID)))
+	d = append(d, p.RegionID...)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(p.Key)))
+	d = append(d, p.Key...)
+	d = append(d, p.Op, p.Priority)
+	d = append(d, p.RefundTo[:]...)
+	return binary.BigEndian.AppendUint64(d, p.Nonce)
+}
+
+func (p *PreemptEventAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.PreemptEventID, p.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	if len(p.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	switch {
+	case p.Op == EventOpCancel:
+	case p.Op == EventOpReprioritize && p.Priority < consts.NumEventPriorities:
+	default:
+		return nil, ErrInvalidEventOp
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, p.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	objectID, ok := storage.ParseEventQueueKey(p.Key)
+	if !ok {
+		return nil, ErrEventNotQueued
+	}
+	metadata, err := storage.GetObjectMetadata(ctx, mu, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if objectRegion(metadata) != p.RegionID {
+		return nil, ErrEventNotInRegion
+	}
+	event, err := mu.GetValue(ctx, p.Key)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrEventNotQueued
+	}
+	if err != nil {
+		return nil, err
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if p.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, p.Digest(rules.GetChainID()), p.Signatures); err != nil {
+		return nil, err
+	}
+
+	charge, err := storage.GetEventCharge(ctx, mu, p.Key)
+	if err != nil {
+		return nil, err
+	}
+	if charge != nil && charge.Sender != codec.EmptyAddress && p.RefundTo != charge.Sender {
+		return nil, ErrRefundRecipient
+	}
+	var units uint64
+	if p.Op == EventOpCancel {
+		if _, err := dequeueEvent(ctx, mu, p.Key); err != nil {
+			return nil, err
+		}
+		if charge != nil {
+			units = charge.Units
+		}
+	} else {
+		if units, err = reprioritizeEvent(ctx, mu, p.Key, event, charge, p.Priority); err != nil {
+			return nil, err
+		}
+	}
+	var refund uint64
+	if units > 0 && charge.Sender != codec.EmptyAddress {
+		if refund, err = refundUnusedUnits(ctx, rules, mu, "", charge.Sender, units, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := appendAudit(ctx, mu, p.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.PreemptEventID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		Attestations: signatureHashes(p.Signatures),
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	return &PreemptEventResult{
+		RegionID: p.RegionID,
+		Key:      p.Key,
+		Op:       p.Op,
+		Priority: p.Priority,
+		Refund:   refund,
+		Nonce:    p.Nonce,
+	}, nil
+}
+
+// reprioritizeEvent moves the event queued under [key] to class
+// [priority], and returns the units of its tip the class does not need.
+func reprioritizeEvent(
+	ctx context.Context,
+	mu state.Mutable,
+	key []byte,
+	event []byte,
+	charge *storage.EventCharge,
+	priority uint8,
+) (uint64, error) {
+	var fields map[string]interface{}
+	if err := codec.Unmarshal(event, &fields); err != nil {
+		return 0, err
+	}
+	fields["priority"] = priority
+	v, err := codec.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	if err := mu.Insert(ctx, key, v); err != nil {
+		return 0, err
+	}
+	if charge == nil || charge.Tip <= consts.EventPriorityTips[priority] {
+		return 0, nil
+	}
+	excess := charge.Tip - consts.EventPriorityTips[priority]
+	charge.Tip -= excess
+	charge.Units -= excess
+	return excess, storage.SetEventCharge(ctx, mu, key, charge)
+}
+
+// dequeueEvent removes the event queued under [queueKey] and its charge,
+// and reports whether it was queued.
+func dequeueEvent(ctx context.Context, mu state.Mutable, queueKey []byte) (bool, error) {
+	if _, err := mu.GetValue(ctx, queueKey); errors.Is(err, database.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := mu.Remove(ctx, queueKey); err != nil {
+		return false, err
+	}
+	return true, storage.RemoveEventCharge(ctx, mu, queueKey)
+}
+
+func (p *PreemptEventAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + uint64(len(p.Signatures))*schedule.AttestationUnits
+}
+
+func (*PreemptEventAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type PreemptEventResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Key      []byte `serialize:"true" json:"key"`
+	Op       uint8  `serialize:"true" json:"op"`
+	Priority uint8  `serialize:"true" json:"priority"`
+	// Refund is the amount credited to the event's sender
+	Refund uint64 `serialize:"true" json:"refund"`
+	Nonce  uint64 `serialize:"true" json:"nonce"`
+}
+
+func (*PreemptEventResult) GetTypeID() uint8 {
+	return consts.PreemptEventResultID
+}
@@ -0,0 +1,726 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+func TestEventCallback(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "counter", "caller")
+
+	event := &actions.SendEventAction{
+		Version:          consts.LatestActionVersion,
+		IDTo:             "counter",
+		FunctionCall:     "increment",
+		Parameters:       []byte{1},
+		CallbackObject:   "caller",
+		CallbackFunction: "on_increment",
+	}
+	// The event is sent as its actor
+	bound := *event
+	bound.Sender = f.Actor
+	eventID := bound.EventID()
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:            "Send",
+			Actor:           f.Actor,
+			Action:          event,
+			ExpectedOutputs: &actions.SendEventResult{Success: true, IDTo: "counter", EventID: eventID},
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				callback, err := storage.GetCallback(ctx, f.State, eventID)
+				require.NoError(t, err)
+				require.Equal(t, &storage.Callback{Object: "caller", Function: "on_increment"}, callback)
+			},
+		},
+		{
+			// The callback is pending until the event runs
+			Name:        "SendAgain",
+			Actor:       f.Actor,
+			Action:      event,
+			ExpectedErr: actions.ErrCallbackPending,
+		},
+	})
+
+	result := actions.TEEExecResult{
+		ContractAddr: []byte("counter"),
+		StateUpdates: map[string][]byte{"counter": {1}},
+	}
+	digest, err := result.Digest()
+	require.NoError(t, err)
+	delivered := func(ctx context.Context, t *testing.T, _ codec.Typed) {
+		require := require.New(t)
+		callback, err := storage.GetCallbackEvent(ctx, f.State, eventID)
+		require.NoError(err)
+		require.Equal(&storage.CallbackEvent{
+			Object:     "caller",
+			Function:   "on_increment",
+			ResultHash: ids.ID(digest),
+			QueuedAt:   f.Timestamp,
+		}, callback)
+		pending, err := storage.GetCallback(ctx, f.State, eventID)
+		require.NoError(err)
+		require.Nil(pending)
+	}
+
+	// The enclave signature binds the event, so the result cannot be
+	// delivered to another callback
+	exec, err := f.AttestEvent(testvm.Region, f.SGX, eventID, result)
+	require.NoError(t, err)
+	moved := *exec
+	moved.EventID = ids.GenerateTestID()
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "OtherEvent",
+			Actor:       f.Actor,
+			Action:      &moved,
+			ExpectedErr: actions.ErrInvalidSignature,
+		},
+		{
+			Name:      "Deliver",
+			Actor:     f.Actor,
+			Action:    exec,
+			Assertion: delivered,
+		},
+	})
+
+	// The other enclave's attestation leaves the delivery as it is
+	queuedAt := f.Timestamp
+	require.NoError(t, f.Advance(ctx, 1, time.Second))
+	second, err := f.AttestEvent(testvm.Region, f.SEV, eventID, result)
+	require.NoError(t, err)
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Redeliver",
+			Actor:  f.Actor,
+			Action: second,
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				callback, err := storage.GetCallbackEvent(ctx, f.State, eventID)
+				require.NoError(t, err)
+				require.Equal(t, queuedAt, callback.QueuedAt)
+			},
+		},
+	})
+}
+
+func TestSendEvent(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	require.NoError(t, storage.SetName(ctx, f.State, "counter.app", &storage.NameRecord{
+		Owner:     f.Actor,
+		Kind:      storage.NameObject,
+		Target:    "counter",
+		ExpiresAt: uint64(f.Timestamp/1000) + consts.NameTerm,
+	}))
+
+	event := func(nonce uint64, toName string) *actions.SendEventAction {
+		return &actions.SendEventAction{
+			Version:      consts.LatestActionVersion,
+			IDTo:         "counter",
+			FunctionCall: "increment",
+			Parameters:   []byte{1},
+			Nonce:        nonce,
+			ToName:       toName,
+		}
+	}
+	// An event sent without a sender is sent as its actor
+	queued := func(nonce uint64) func(context.Context, *testing.T, codec.Typed) {
+		return func(ctx context.Context, t *testing.T, out codec.Typed) {
+			require := require.New(t)
+			bound := event(nonce, "")
+			bound.Sender = f.Actor
+			eventID := bound.EventID()
+			require.Equal(eventID, out.(*actions.SendEventResult).EventID)
+			_, err := f.State.GetValue(ctx, storage.EventQueueKey(eventID, "counter"))
+			require.NoError(err)
+			link, err := storage.GetEventLink(ctx, f.State, "counter", eventID)
+			require.NoError(err)
+			require.Equal(nonce, link.Seq)
+		}
+	}
+	misnamed := event(2, "counter.app")
+	misnamed.IDTo = "other"
+	reset := &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "counter", FunctionCall: "reset"}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "ObjectNotFound",
+			Actor:       f.Actor,
+			Action:      event(1, ""),
+			ExpectedErr: actions.ErrObjectNotFound,
+		},
+		{
+			Name:            "Create",
+			Actor:           f.Actor,
+			Action:          &actions.CreateObjectAction{Version: consts.LatestActionVersion, ID: "counter", Code: []byte{1}},
+			ExpectedOutputs: &actions.CreateObjectResult{ID: "counter", Success: true},
+		},
+		{
+			Name:        "CreateAgain",
+			Actor:       f.Actor,
+			Action:      &actions.CreateObjectAction{Version: consts.LatestActionVersion, ID: "counter", Code: []byte{1}},
+			ExpectedErr: actions.ErrObjectExists,
+		},
+		{
+			Name:      "Send",
+			Actor:     f.Actor,
+			Action:    event(1, ""),
+			Assertion: queued(1),
+		},
+		{
+			Name:        "NonceUsed",
+			Actor:       f.Actor,
+			Action:      event(1, ""),
+			ExpectedErr: actions.ErrEventNonceUsed,
+		},
+		{
+			// A name must refer to the target the event names by ID
+			Name:        "NameTarget",
+			Actor:       f.Actor,
+			Action:      misnamed,
+			ExpectedErr: actions.ErrNameTarget,
+		},
+		{
+			Name:      "Named",
+			Actor:     f.Actor,
+			Action:    event(2, "counter.app"),
+			Assertion: queued(2),
+		},
+		{
+			Name:   "Unordered",
+			Actor:  f.Actor,
+			Action: reset,
+		},
+		{
+			// An identical event is queued again only once the first ran
+			Name:        "Queued",
+			Actor:       f.Actor,
+			Action:      reset,
+			ExpectedErr: actions.ErrEventQueued,
+		},
+	})
+}
+
+func TestSessionEvents(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "counter")
+	metadata, err := codec.Marshal(&storage.ObjectMetadata{RegionID: testvm.Region})
+	require.NoError(t, err)
+	require.NoError(t, f.State.Insert(ctx, storage.ObjectMetadataKey("counter"), metadata))
+
+	// session is a funded session of the actor, granted [actionTypes] on
+	// [regions] until [expiry]
+	session := func(expiry int64, regions []string, actionTypes ...uint8) codec.Address {
+		addr := codec.CreateAddress(consts.SessionAuthID, ids.GenerateTestID())
+		require.NoError(t, f.Mint(ctx, addr, testvm.Funds))
+		require.NoError(t, storage.SetSessionGrant(ctx, f.State, addr, &storage.SessionGrant{
+			Account:     f.Actor,
+			Expiry:      expiry,
+			ActionTypes: actionTypes,
+			Regions:     regions,
+		}))
+		return addr
+	}
+	later := f.Timestamp + time.Hour.Milliseconds()
+	// A session can send events as its account
+	event := &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "counter", FunctionCall: "increment", Sender: f.Actor}
+	other := *event
+	other.Sender = codectest.NewRandomAddress()
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Expired",
+			Actor:       session(f.Timestamp-1, nil, consts.SendEventID),
+			Action:      event,
+			ExpectedErr: actions.ErrSessionExpired,
+		},
+		{
+			Name:        "ActionType",
+			Actor:       session(later, nil, consts.CreateObjectID),
+			Action:      event,
+			ExpectedErr: actions.ErrSessionScope,
+		},
+		{
+			// An event acts on the region its target is listed in
+			Name:        "Region",
+			Actor:       session(later, []string{"eu-west"}, consts.SendEventID),
+			Action:      event,
+			ExpectedErr: actions.ErrSessionScope,
+		},
+		{
+			// but not as anyone else
+			Name:        "OtherSender",
+			Actor:       session(later, []string{testvm.Region}, consts.SendEventID),
+			Action:      &other,
+			ExpectedErr: actions.ErrSessionScope,
+		},
+		{
+			Name:            "InScope",
+			Actor:           session(later, []string{testvm.Region}, consts.SendEventID),
+			Action:          event,
+			ExpectedOutputs: &actions.SendEventResult{Success: true, IDTo: "counter", EventID: event.EventID()},
+		},
+	})
+}
+
+func TestRateLimitedEvents(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "counter")
+	limits, err := codec.Marshal(&actions.RateLimits{PerBlock: 1})
+	require.NoError(t, err)
+	require.NoError(t, storage.ScheduleParam(ctx, f.State, uint8(consts.ParamRateLimits), limits, f.Height, f.Height))
+
+	event := func(function string) *actions.SendEventAction {
+		return &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "counter", FunctionCall: function}
+	}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Send",
+			Actor:  f.Actor,
+			Action: event("increment"),
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				counter, err := storage.GetRateCounter(ctx, f.State, f.Actor)
+				require.NoError(t, err)
+				require.Equal(t, uint32(1), counter.InBlock)
+			},
+		},
+		{
+			// Events count towards the actor's limits like any action
+			Name:        "BlockLimit",
+			Actor:       f.Actor,
+			Action:      event("decrement"),
+			ExpectedErr: actions.ErrRateLimited,
+		},
+	})
+
+	require.NoError(t, f.Advance(ctx, 1, time.Second))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "NextBlock",
+			Actor:  f.Actor,
+			Action: event("decrement"),
+		},
+	})
+}
+
+func TestGovernedEventCharge(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "counter")
+	schedule := actions.DefaultFeeSchedule
+	schedule.EventUnits = 7
+	v, err := codec.Marshal(&schedule)
+	require.NoError(t, err)
+	require.NoError(t, storage.ScheduleParam(ctx, f.State, uint8(consts.ParamFeeSchedule), v, f.Height, f.Height))
+
+	tip := consts.EventPriorityTips[consts.EventPriorityHigh]
+	event := &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "counter", FunctionCall: "increment", Sender: f.Actor, Tip: tip}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// The charge kept for refunds follows the governed schedule
+			Name:   "Send",
+			Actor:  f.Actor,
+			Action: event,
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				charge, err := storage.GetEventCharge(ctx, f.State, storage.EventQueueKey(event.EventID(), "counter"))
+				require.NoError(t, err)
+				require.Equal(t, &storage.EventCharge{Sender: f.Actor, Units: 7 + tip, Tip: tip}, charge)
+			},
+		},
+	})
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "parse", "render")
+
+	stage := &actions.PipelineAction{Version: consts.LatestActionVersion, ID: "parse", FunctionCall: "run", Next: "render"}
+	eventID := stage.Event().EventID()
+	missing := *stage
+	missing.Next = "missing"
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "NextNotFound",
+			Actor:       f.Actor,
+			Action:      &missing,
+			ExpectedErr: actions.ErrObjectNotFound,
+		},
+		{
+			// The input object, the event and the next stage move together
+			Name:            "Advance",
+			Actor:           f.Actor,
+			Action:          stage,
+			ExpectedOutputs: &actions.PipelineResult{Success: true, ID: "parse", Next: "render", EventID: eventID},
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				require := require.New(t)
+				input, err := storage.GetInputObject(ctx, f.State)
+				require.NoError(err)
+				require.Equal("parse", input)
+				next, err := storage.GetPipelineNext(ctx, f.State, "parse")
+				require.NoError(err)
+				require.Equal("render", next)
+				_, err = f.State.GetValue(ctx, storage.EventQueueKey(eventID, "parse"))
+				require.NoError(err)
+			},
+		},
+	})
+}
+
+func TestSealedEventParameters(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	keys, err := storage.GetEncryptionKeys(ctx, f.State, testvm.Region)
+	require.NoError(t, err)
+	require.Equal(t, []storage.EncryptionKey{f.SGX.PublishedKey(), f.SEV.PublishedKey()}, keys)
+
+	params := []byte("transfer 100 to alice")
+	event := &actions.SendEventAction{
+		IDTo:         "wallet",
+		FunctionCall: "transfer",
+		Parameters:   params,
+	}
+	require.NoError(t, event.Seal(keys))
+	require.True(t, event.Encrypted)
+	require.NotContains(t, string(event.Parameters), string(params))
+	require.NoError(t, envelope.Check(event.Parameters))
+
+	// Either enclave of the pair can open the parameters
+	outsider, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(t, err)
+	tests := []struct {
+		name        string
+		enclave     *testvm.Enclave
+		expectedErr error
+	}{
+		{
+			name:    "SGX",
+			enclave: f.SGX,
+		},
+		{
+			name:    "SEV",
+			enclave: f.SEV,
+		},
+		{
+			name:        "Outsider",
+			enclave:     outsider,
+			expectedErr: envelope.ErrNotRecipient,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := tt.enclave.OpenParameters(event)
+			require.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				require.Equal(t, params, opened)
+			}
+		})
+	}
+
+	// The envelope is bound to the call it was sealed for
+	event.FunctionCall = "withdraw"
+	_, err = f.SGX.OpenParameters(event)
+	require.Error(t, err)
+}
+
+func TestPreemptQueuedEvent(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	sender := codectest.NewRandomAddress()
+
+	adminKey, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	require.NoError(t, storage.SetAdminSet(ctx, f.State, &storage.AdminSet{
+		Threshold: 1,
+		Keys:      []ed25519.PublicKey{adminKey.PublicKey()},
+	}))
+	sign := func(p *actions.PreemptEventAction) *actions.PreemptEventAction {
+		p.Signatures = []actions.AdminSignature{{
+			PublicKey: adminKey.PublicKey(),
+			Signature: ed25519.Sign(p.Digest(f.Rules.GetChainID()), adminKey),
+		}}
+		return p
+	}
+
+	// A high priority event to an object of the region
+	f.Objects(t, "stuck")
+	metadata, err := codec.Marshal(&storage.ObjectMetadata{RegionID: testvm.Region})
+	require.NoError(t, err)
+	require.NoError(t, f.State.Insert(ctx, storage.ObjectMetadataKey("stuck"), metadata))
+	tip := consts.EventPriorityTips[consts.EventPriorityHigh]
+	event := &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "stuck", FunctionCall: "run", Sender: sender, Tip: tip}
+	key := storage.EventQueueKey(event.EventID(), "stuck")
+	price := f.Rules.GetMinUnitPrice()[fees.Compute]
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Send",
+			Actor:  sender,
+			Action: event,
+		},
+		{
+			Name:        "RefundRecipient",
+			Actor:       f.Actor,
+			Action:      sign(&actions.PreemptEventAction{RegionID: testvm.Region, Key: key, Op: actions.EventOpCancel, RefundTo: f.Actor}),
+			ExpectedErr: actions.ErrRefundRecipient,
+		},
+		{
+			Name:        "RegionNotFound",
+			Actor:       f.Actor,
+			Action:      sign(&actions.PreemptEventAction{RegionID: "us-west", Key: key, Op: actions.EventOpCancel, RefundTo: sender}),
+			ExpectedErr: actions.ErrRegionNotFound,
+		},
+		{
+			// Demoting the event refunds the tip the base class does not
+			// need
+			Name:  "Reprioritize",
+			Actor: f.Actor,
+			Action: sign(&actions.PreemptEventAction{
+				RegionID: testvm.Region,
+				Key:      key,
+				Op:       actions.EventOpReprioritize,
+				Priority: consts.EventPriorityBase,
+				RefundTo: sender,
+			}),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, tip*price, out.(*actions.PreemptEventResult).Refund)
+				charge, err := storage.GetEventCharge(ctx, f.State, key)
+				require.NoError(t, err)
+				require.Zero(t, charge.Tip)
+			},
+		},
+		{
+			// Cancelling it refunds the rest and leaves an audit record
+			Name:  "Cancel",
+			Actor: f.Actor,
+			Action: sign(&actions.PreemptEventAction{
+				RegionID: testvm.Region,
+				Key:      key,
+				Op:       actions.EventOpCancel,
+				Nonce:    1,
+				RefundTo: sender,
+			}),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.Equal(actions.DefaultFeeSchedule.EventUnits*price, out.(*actions.PreemptEventResult).Refund)
+				balance, err := f.Balance(ctx, sender)
+				require.NoError(err)
+				require.Equal((tip+actions.DefaultFeeSchedule.EventUnits)*price, balance)
+				_, err = f.State.GetValue(ctx, key)
+				require.ErrorIs(err, database.ErrNotFound)
+				head, err := storage.GetAuditHead(ctx, f.State, testvm.Region)
+				require.NoError(err)
+				entry, err := storage.GetAuditEntry(ctx, f.State, testvm.Region, head.Latest)
+				require.NoError(err)
+				require.Equal(consts.PreemptEventID, entry.TypeID)
+			},
+		},
+		{
+			Name:        "NotQueued",
+			Actor:       f.Actor,
+			Action:      sign(&actions.PreemptEventAction{RegionID: testvm.Region, Key: key, Nonce: 2, RefundTo: sender}),
+			ExpectedErr: actions.ErrEventNotQueued,
+		},
+	})
+}
+
+func TestSagaCompensation(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	west, _, err := f.NewRegion(ctx, "eu-west")
+	require.NoError(t, err)
+	f.Objects(t, "escrow", "notary", "ledger")
+
+	steps := []storage.SagaStep{
+		{Object: "escrow", Function: "lock", Parameters: []byte{1}, Compensate: "unlock", CompensateParameters: []byte{1}},
+		{Object: "notary", Function: "stamp"},
+		{Object: "ledger", Function: "credit", Compensate: "debit"},
+	}
+	// Each event the saga may queue is checked as a sent event is
+	require.NoError(t, storage.SetParamSchema(ctx, f.State, "escrow", "unlock", []byte{byte(actions.ParamBool)}))
+
+	sagaID := ids.GenerateTestID()
+	first := actions.SagaEventID(sagaID)
+	second := actions.SagaEventID(first)
+	third := actions.SagaEventID(second)
+	undo := actions.SagaEventID(third)
+	queued := func(eventID ids.ID, expected *storage.SagaEvent) func(context.Context, *testing.T, codec.Typed) {
+		return func(ctx context.Context, t *testing.T, _ codec.Typed) {
+			event, err := storage.GetSagaEvent(ctx, f.State, eventID)
+			require.NoError(t, err)
+			if expected != nil {
+				expected.QueuedAt = f.Timestamp
+			}
+			require.Equal(t, expected, event)
+		}
+	}
+	saga := func(eventID ids.ID, status uint8, step uint32) func(context.Context, *testing.T, codec.Typed) {
+		return func(ctx context.Context, t *testing.T, _ codec.Typed) {
+			require := require.New(t)
+			current, saga, err := actions.FindSaga(ctx, f.State, sagaID)
+			require.NoError(err)
+			require.Equal(eventID, current)
+			require.Equal(status, saga.Status)
+			require.Equal(step, saga.Step)
+			require.Equal(f.Actor, saga.Owner)
+		}
+	}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "NoSteps",
+			Actor:       f.Actor,
+			Action:      &actions.StartSagaAction{},
+			ExpectedErr: actions.ErrInvalidSaga,
+		},
+		{
+			Name:        "ObjectNotFound",
+			Actor:       f.Actor,
+			Action:      &actions.StartSagaAction{Steps: append([]storage.SagaStep{{Object: "missing", Function: "run"}}, steps...)},
+			ExpectedErr: actions.ErrObjectNotFound,
+		},
+		{
+			Name:  "CompensationParams",
+			Actor: f.Actor,
+			Action: &actions.StartSagaAction{Steps: []storage.SagaStep{
+				{Object: "escrow", Function: "lock", Compensate: "unlock", CompensateParameters: []byte{2}},
+			}},
+			ExpectedErr: actions.ErrParamMismatch,
+		},
+		{
+			Name:            "Start",
+			Actor:           f.Actor,
+			ActionID:        sagaID,
+			Action:          &actions.StartSagaAction{Steps: steps},
+			ExpectedOutputs: &actions.StartSagaResult{SagaID: sagaID, EventID: first},
+			Assertion:       queued(first, &storage.SagaEvent{Object: "escrow", Function: "lock", Parameters: []byte{1}}),
+		},
+	})
+
+	// Each execution is attested a block after the one before it
+	tests := []struct {
+		name        string
+		regionID    string
+		enclave     *testvm.Enclave
+		eventID     ids.ID
+		result      actions.TEEExecResult
+		expectedErr error
+		assertion   func(context.Context, *testing.T, codec.Typed)
+	}{
+		{
+			// Only an execution of the object the event was queued for
+			// advances it
+			name:        "OtherObject",
+			regionID:    testvm.Region,
+			enclave:     f.SGX,
+			eventID:     first,
+			result:      actions.TEEExecResult{ContractAddr: []byte("ledger")},
+			expectedErr: actions.ErrSagaObject,
+		},
+		{
+			// The first two steps complete, in different regions
+			name:     "Lock",
+			regionID: testvm.Region,
+			enclave:  f.SGX,
+			eventID:  first,
+			result: actions.TEEExecResult{
+				ContractAddr: []byte("escrow"),
+				StateUpdates: map[string][]byte{"locked": {1}},
+			},
+		},
+		{
+			name:     "Stamp",
+			regionID: "eu-west",
+			enclave:  west,
+			eventID:  second,
+			result: actions.TEEExecResult{
+				ContractAddr: []byte("notary"),
+				StateUpdates: map[string][]byte{"stamped": {1}},
+			},
+			assertion: saga(third, storage.SagaRunning, 2),
+		},
+		{
+			// A failed execution applies nothing
+			name:     "FailedWithEffects",
+			regionID: testvm.Region,
+			enclave:  f.SGX,
+			eventID:  third,
+			result: actions.TEEExecResult{
+				ContractAddr: []byte("ledger"),
+				StateUpdates: map[string][]byte{"credited": {1}},
+				Failed:       true,
+			},
+			expectedErr: actions.ErrExecFailed,
+		},
+		{
+			// The last step fails. The notary step has no compensation, so
+			// the escrow is unlocked next.
+			name:     "Credit",
+			regionID: testvm.Region,
+			enclave:  f.SGX,
+			eventID:  third,
+			result: actions.TEEExecResult{
+				ContractAddr: []byte("ledger"),
+				Failed:       true,
+			},
+			assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				queued(undo, &storage.SagaEvent{Object: "escrow", Function: "unlock", Parameters: []byte{1}})(ctx, t, out)
+				saga(undo, storage.SagaCompensating, 0)(ctx, t, out)
+			},
+		},
+		{
+			// Once the compensation completes the saga is done
+			name:     "Unlock",
+			regionID: testvm.Region,
+			enclave:  f.SGX,
+			eventID:  undo,
+			result: actions.TEEExecResult{
+				ContractAddr: []byte("escrow"),
+				StateUpdates: map[string][]byte{"locked": {0}},
+			},
+			assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				queued(undo, nil)(ctx, t, out)
+				saga(undo, storage.SagaCompensated, 0)(ctx, t, out)
+			},
+		},
+	}
+	for _, tt := range tests {
+		require.NoError(t, f.Advance(ctx, 1, time.Second))
+		exec, err := f.AttestEvent(tt.regionID, tt.enclave, tt.eventID, tt.result)
+		require.NoError(t, err)
+		f.RunSteps(ctx, t, []testvm.Step{
+			{
+				Name:        tt.name,
+				Actor:       f.Actor,
+				Action:      exec,
+				ExpectedErr: tt.expectedErr,
+				Assertion:   tt.assertion,
+			},
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrEventSender = errors.New("event sender is not the actor")
+
+// EventSender returns the address [actor] sends an event as when it names
+// [sender]: the actor itself, or the account that granted it, if it is a
+// session, [account]. An empty sender is the actor.
+func EventSender(actor codec.Address, account codec.Address, sender codec.Address) (codec.Address, error) {
+	switch {
+	case sender == codec.EmptyAddress || sender == actor:
+		return actor, nil
+	case IsSession(actor) && account != codec.EmptyAddress && sender == account:
+		return sender, nil
+	}
+	return codec.EmptyAddress, fmt.Errorf("%w: %s", ErrEventSender, sender)
+}
+
+// bindSender returns the event with Sender set to the address [actor]
+// sends it as. Nonces, idempotency keys and refunds follow Sender.
+func (a *SendEventAction) bindSender(ctx context.Context, im state.Immutable, actor codec.Address) (*SendEventAction, error) {
+	var account codec.Address
+	if IsSession(actor) {
+		grant, err := storage.GetSessionGrant(ctx, im, actor)
+		if err != nil {
+			return nil, err
+		}
+		if grant != nil {
+			account = grant.Account
+		}
+	}
+	sender, err := EventSender(actor, account, a.Sender)
+	if err != nil {
+		return nil, err
+	}
+	bound := *a
+	bound.Sender = sender
+	return &bound, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestEventSender(t *testing.T) {
+	actor := codectest.NewRandomAddress()
+	other := codectest.NewRandomAddress()
+	session := codec.CreateAddress(consts.SessionAuthID, ids.GenerateTestID())
+
+	tests := []struct {
+		name        string
+		actor       codec.Address
+		account     codec.Address
+		sender      codec.Address
+		expected    codec.Address
+		expectedErr error
+	}{
+		{
+			name:     "Empty",
+			actor:    actor,
+			sender:   codec.EmptyAddress,
+			expected: actor,
+		},
+		{
+			name:     "Actor",
+			actor:    actor,
+			sender:   actor,
+			expected: actor,
+		},
+		{
+			name:        "Other",
+			actor:       actor,
+			sender:      other,
+			expectedErr: ErrEventSender,
+		},
+		{
+			name:     "SessionAccount",
+			actor:    session,
+			account:  other,
+			sender:   other,
+			expected: other,
+		},
+		{
+			name:        "SessionNotAuthorized",
+			actor:       session,
+			sender:      other,
+			expectedErr: ErrEventSender,
+		},
+		{
+			// Only sessions act for an account
+			name:        "NotSession",
+			actor:       actor,
+			account:     other,
+			sender:      other,
+			expectedErr: ErrEventSender,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			sender, err := EventSender(tt.actor, tt.account, tt.sender)
+			require.ErrorIs(err, tt.expectedErr)
+			require.Equal(tt.expected, sender)
+		})
+	}
+}
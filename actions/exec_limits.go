@@ -0,0 +1,254 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidExecLimits = errors.New("invalid execution limits")
+	ErrOutOfResources    = errors.New("execution out of resources")
+
+	_ chain.Action = (*SetExecLimitsAction)(nil)
+)
+
+// Resource names a limited resource of an execution.
+type Resource string
+
+const (
+	ResourceMemory    Resource = "memory"
+	ResourceStack     Resource = "stack"
+	ResourceCallDepth Resource = "call_depth"
+	ResourceDeadline  Resource = "deadline"
+)
+
+// Valid reports whether [r] is a known resource.
+func (r Resource) Valid() bool {
+	switch r {
+	case ResourceMemory, ResourceStack, ResourceCallDepth, ResourceDeadline:
+		return true
+	}
+	return false
+}
+
+// ResourceUsage is the peak of each resource an execution used, as
+// measured by the enclave running it.
+type ResourceUsage struct {
+	MemoryPages   uint32 `json:"memory_pages"`
+	ValueStack    uint32 `json:"value_stack"`
+	HostCallDepth uint32 `json:"host_call_depth"`
+	ElapsedMs     uint64 `json:"elapsed_ms"`
+}
+
+// OutOfResourcesError reports an execution that exceeded, or was aborted
+// at, the limit of [Resource] in its region.
+type OutOfResourcesError struct {
+	Resource Resource
+	Used     uint64
+	Limit    uint64
+}
+
+func (e *OutOfResourcesError) Error() string {
+	return fmt.Sprintf("%s: %s %d of %d", ErrOutOfResources, e.Resource, e.Used, e.Limit)
+}
+
+func (e *OutOfResourcesError) Unwrap() error {
+	return ErrOutOfResources
+}
+
+// ValidateExecLimits checks no limit of [l] exceeds its bound in [consts].
+// Zero limits are left to their defaults.
+func ValidateExecLimits(l *storage.ExecLimits) error {
+	for _, limit := range []struct {
+		name       string
+		value, max uint64
+	}{
+		{"memory pages", uint64(l.MaxMemoryPages), consts.MaxMemoryPages},
+		{"value stack", uint64(l.MaxValueStack), consts.MaxValueStack},
+		{"host call depth", uint64(l.MaxHostCallDepth), consts.MaxHostCallDepth},
+		{"deadline", l.DeadlineMs, consts.MaxExecDeadlineMs},
+	} {
+		if limit.value > limit.max {
+			return fmt.Errorf("%w: %s %d > %d", ErrInvalidExecLimits, limit.name, limit.value, limit.max)
+		}
+	}
+	return nil
+}
+
+// CheckResourceUsage checks the usage [r] reports is within [limits]. A
+// result aborted on a limit must name a known resource and carry nothing
+// to apply.
+func CheckResourceUsage(limits storage.ExecLimits, r *TEEExecResult) error {
+	for _, usage := range []struct {
+		resource    Resource
+		used, limit uint64
+	}{
+		{ResourceMemory, uint64(r.Usage.MemoryPages), uint64(limits.MaxMemoryPages)},
+		{ResourceStack, uint64(r.Usage.ValueStack), uint64(limits.MaxValueStack)},
+		{ResourceCallDepth, uint64(r.Usage.HostCallDepth), uint64(limits.MaxHostCallDepth)},
+		{ResourceDeadline, r.Usage.ElapsedMs, limits.DeadlineMs},
+	} {
+		if usage.used > usage.limit {
+			return &OutOfResourcesError{Resource: usage.resource, Used: usage.used, Limit: usage.limit}
+		}
+	}
+	if r.Exhausted == "" {
+		return nil
+	}
+	if !r.Exhausted.Valid() {
+		return fmt.Errorf("%w: unknown resource %q", ErrOutOfResources, r.Exhausted)
+	}
+	if r.hasEffects() {
+		return fmt.Errorf("%w: aborted execution has effects", ErrOutOfResources)
+	}
+	return nil
+}
+
+// exhaustedError is the error reported for a result aborted on the limit of
+// its exhausted resource in [limits].
+func exhaustedError(limits storage.ExecLimits, r *TEEExecResult) *OutOfResourcesError {
+	e := &OutOfResourcesError{Resource: r.Exhausted}
+	switch r.Exhausted {
+	case ResourceMemory:
+		e.Used, e.Limit = uint64(r.Usage.MemoryPages), uint64(limits.MaxMemoryPages)
+	case ResourceStack:
+		e.Used, e.Limit = uint64(r.Usage.ValueStack), uint64(limits.MaxValueStack)
+	case ResourceCallDepth:
+		e.Used, e.Limit = uint64(r.Usage.HostCallDepth), uint64(limits.MaxHostCallDepth)
+	case ResourceDeadline:
+		e.Used, e.Limit = r.Usage.ElapsedMs, limits.DeadlineMs
+	}
+	return e
+}
+
+// SetExecLimitsAction sets the resource limits of one execution in
+// [RegionID], once signed by a threshold of the admin keys. Zero limits
+// restore the defaults.
+type SetExecLimitsAction struct {
+	RegionID   string             `serialize:"true" json:"region_id"`
+	Limits     storage.ExecLimits `serialize:"true" json:"limits"`
+	Nonce      uint64             `serialize:"true" json:"nonce"`
+	Signatures []AdminSignature   `serialize:"true" json:"signatures"`
+}
+
+func (*SetExecLimitsAction) GetTypeID() uint8 {
+	return consts.SetExecLimitsID
+}
+
+func (s *SetExecLimitsAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.AdminSetKey()):             state.Read,
+		string(storage.AdminNonceKey()):           state.All,
+		string(storage.RegionKey(s.RegionID)):     state.Read,
+		string(storage.ExecLimitsKey(s.RegionID)): state.All,
+	}, s.RegionID, actionID), actor, s)
+}
+
+// Digest is the message each admin key signs on the chain [chainID].
+func (s *SetExecLimitsAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.SetExecLimitsID)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(s.RegionID)))
+	d = append(d, s.RegionID...)
+	d = binary.BigEndian.AppendUint32(d, s.Limits.MaxMemoryPages)
+	d = binary.BigEndian.AppendUint32(d, s.Limits.MaxValueStack)
+	d = binary.BigEndian.AppendUint32(d, s.Limits.MaxHostCallDepth)
+	d = binary.BigEndian.AppendUint64(d, s.Limits.DeadlineMs)
+	return binary.BigEndian.AppendUint64(d, s.Nonce)
+}
+
+func (s *SetExecLimitsAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SetExecLimitsID, s.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	if len(s.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	if err := ValidateExecLimits(&s.Limits); err != nil {
+		return nil, err
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, s.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if s.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, s.Digest(rules.GetChainID()), s.Signatures); err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetExecLimits(ctx, mu, s.RegionID, &s.Limits); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, s.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.SetExecLimitsID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		Attestations: signatureHashes(s.Signatures),
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	return &SetExecLimitsResult{
+		RegionID: s.RegionID,
+		Limits:   s.Limits.WithDefaults(),
+		Nonce:    s.Nonce,
+	}, nil
+}
+
+func (s *SetExecLimitsAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + uint64(len(s.Signatures))*schedule.AttestationUnits
+}
+
+func (*SetExecLimitsAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// SetExecLimitsResult reports the limits now in effect, defaults included.
+type SetExecLimitsResult struct {
+	RegionID string             `serialize:"true" json:"region_id"`
+	Limits   storage.ExecLimits `serialize:"true" json:"limits"`
+	Nonce    uint64             `serialize:"true" json:"nonce"`
+}
+
+func (*SetExecLimitsResult) GetTypeID() uint8 {
+	return consts.SetExecLimitsResultID
+}
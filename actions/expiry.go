@@ -0,0 +1,48 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"math"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+// stampRange is the valid range of an action carrying [attestations]: the
+// block times, in unix milliseconds, within [consts.MaxTimeDrift] of some
+// stamp of each. Outside it no median of the stamps can pass the drift
+// check in Execute, so the action expires from the mempool instead of
+// failing in a block. The stamps are not verified here; Execute still
+// does. An attestation without stamps leaves the range open, as Execute
+// rejects it anyway.
+func stampRange(attestations ...*attestation.Attestation) (int64, int64) {
+	start, end := int64(-1), int64(-1)
+	for _, a := range attestations {
+		earliest, latest, ok := a.StampBounds()
+		if !ok {
+			return -1, -1
+		}
+		from, to := driftRange(earliest, latest)
+		start = max(start, from)
+		if end < 0 || (to >= 0 && to < end) {
+			end = to
+		}
+	}
+	return start, end
+}
+
+// driftRange is the block times, in unix milliseconds, whose second is
+// within [consts.MaxTimeDrift] of a time between [earliest] and [latest],
+// in unix seconds. An end beyond what a block time can hold is open.
+func driftRange(earliest, latest uint64) (int64, int64) {
+	var start int64
+	if earliest > consts.MaxTimeDrift {
+		start = int64(min(earliest-consts.MaxTimeDrift, math.MaxInt64/1000)) * 1000
+	}
+	if latest >= math.MaxInt64/1000-consts.MaxTimeDrift-1 {
+		return start, -1
+	}
+	return start, int64(latest+consts.MaxTimeDrift+1)*1000 - 1
+}
@@ -0,0 +1,330 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	MaxFeedNameSize = 64
+	// MaxDeviationBPS is a move of the whole value
+	MaxDeviationBPS = 10_000
+)
+
+var (
+	ErrFeedNotFound  = errors.New("feed not found")
+	ErrInvalidFeed   = errors.New("invalid feed")
+	ErrFeedSigner    = errors.New("enclave does not sign for feed")
+	ErrFeedRound     = errors.New("feed round out of order")
+	ErrFeedDeviation = errors.New("feed value within deviation threshold")
+	ErrFeedStale     = errors.New("feed value older than latest")
+	ErrFeedRegion    = errors.New("feed served by another region")
+
+	_ chain.Action = (*RegisterFeedAction)(nil)
+	_ chain.Action = (*PublishFeedAction)(nil)
+)
+
+// feedDomain separates feed signatures from the other statements an
+// enclave key signs.
+const feedDomain = "shuttlevm/feed"
+
+// FeedDigest is what an enclave signs to publish [value] as round [round]
+// of [feedID].
+func FeedDigest(feedID ids.ID, round, value uint64) []byte {
+	h := sha256.New()
+	h.Write([]byte(feedDomain))
+	h.Write(feedID[:])
+	h.Write(binary.BigEndian.AppendUint64(nil, round))
+	h.Write(binary.BigEndian.AppendUint64(nil, value))
+	return h.Sum(nil)
+}
+
+// RegisterFeedAction creates a data feed owned by the actor, with the ID of
+// the action. Values are published by [Signers], enclaves of [RegionID],
+// with [PublishFeedAction].
+type RegisterFeedAction struct {
+	Name         string   `serialize:"true" json:"name"`
+	RegionID     string   `serialize:"true" json:"region_id"`
+	Decimals     uint8    `serialize:"true" json:"decimals"`
+	Signers      [][]byte `serialize:"true" json:"signers"`
+	DeviationBPS uint32   `serialize:"true" json:"deviation_bps"`
+	Heartbeat    uint64   `serialize:"true" json:"heartbeat"`
+}
+
+func (*RegisterFeedAction) GetTypeID() uint8 {
+	return consts.RegisterFeedID
+}
+
+func (r *RegisterFeedAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.RegionKey(r.RegionID)): state.Read,
+		string(storage.FeedKey(actionID)):     state.All,
+	}, actor, r)
+}
+
+func (r *RegisterFeedAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RegisterFeedID, r.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	if len(r.Name) == 0 || len(r.Name) > MaxFeedNameSize {
+		return nil, fmt.Errorf("%w: name size %d", ErrInvalidFeed, len(r.Name))
+	}
+	if r.Decimals > MaxAssetDecimals {
+		return nil, fmt.Errorf("%w: %d decimals", ErrInvalidFeed, r.Decimals)
+	}
+	if r.DeviationBPS > MaxDeviationBPS {
+		return nil, fmt.Errorf("%w: deviation %d bps", ErrInvalidFeed, r.DeviationBPS)
+	}
+	if len(r.Signers) == 0 || len(r.Signers) > consts.MaxFeedSigners {
+		return nil, fmt.Errorf("%w: %d signers", ErrInvalidFeed, len(r.Signers))
+	}
+	for i, signer := range r.Signers {
+		for _, other := range r.Signers[:i] {
+			if bytes.Equal(signer, other) {
+				return nil, fmt.Errorf("%w: duplicate signer", ErrInvalidFeed)
+			}
+		}
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, r.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	existing, err := storage.GetFeed(ctx, mu, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: already registered", ErrInvalidFeed)
+	}
+	if err := storage.SetFeed(ctx, mu, actionID, &storage.Feed{
+		Name:         r.Name,
+		RegionID:     r.RegionID,
+		Decimals:     r.Decimals,
+		Owner:        actor,
+		Signers:      r.Signers,
+		DeviationBPS: r.DeviationBPS,
+		Heartbeat:    r.Heartbeat,
+	}); err != nil {
+		return nil, err
+	}
+	return &RegisterFeedResult{FeedID: actionID}, nil
+}
+
+func (*RegisterFeedAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.StateUpdateUnits
+}
+
+func (*RegisterFeedAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type RegisterFeedResult struct {
+	FeedID ids.ID `serialize:"true" json:"feed_id"`
+}
+
+func (*RegisterFeedResult) GetTypeID() uint8 {
+	return consts.RegisterFeedResultID
+}
+
+// PublishFeedAction publishes [Value] as [Round] of a feed, attested by one
+// of its signers. Its Roughtime stamps are checked as an execution's are,
+// and their median becomes the value's observation time. Unless the feed's
+// heartbeat has passed since the latest value was observed, the value must
+// move at least the feed's deviation threshold.
+type PublishFeedAction struct {
+	FeedID ids.ID `serialize:"true" json:"feed_id"`
+	// RegionID is the feed's region, declared so the signer's state keys
+	// are known up front
+	RegionID string `serialize:"true" json:"region_id"`
+	Round    uint64 `serialize:"true" json:"round"`
+	Value    uint64 `serialize:"true" json:"value"`
+	// Attestation signs [FeedDigest]
+	Attestation attestation.Attestation `serialize:"true" json:"attestation"`
+}
+
+func (*PublishFeedAction) GetTypeID() uint8 {
+	return consts.PublishFeedID
+}
+
+func (p *PublishFeedAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.HeightKey()):                                           state.Read,
+		string(storage.ParamKey(uint8(consts.ParamTimeDrift))):                state.Read,
+		string(storage.ParamKey(uint8(consts.ParamRoughtimeServers))):         state.Read,
+		string(storage.ParamKey(uint8(consts.ParamStampQuorum))):              state.Read,
+		string(storage.ParamKey(uint8(consts.ParamStampRadius))):              state.Read,
+		string(storage.RegionKey(p.RegionID)):                                 state.Read,
+		string(storage.EnclaveKey(p.RegionID, p.Attestation.EnclaveID)):       state.Read,
+		string(storage.EnclavePubKeyKey(p.RegionID, p.Attestation.EnclaveID)): state.Read,
+		string(storage.EnclaveExpiryKey(p.RegionID, p.Attestation.EnclaveID)): state.Read,
+		string(storage.FeedKey(p.FeedID)):                                     state.Read | state.Write,
+		string(storage.FeedValueKey(p.FeedID, p.Round)):                       state.All,
+	}
+	if p.Round > consts.FeedHistory {
+		keys[string(storage.FeedValueKey(p.FeedID, p.Round-consts.FeedHistory))] = state.Write
+	}
+	addPlatformKeys(keys, p.RegionID, p.Attestation.EnclaveID)
+	return addGateKeys(keys, actor, p)
+}
+
+func (p *PublishFeedAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.PublishFeedID, p.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	feed, err := storage.GetFeed(ctx, mu, p.FeedID)
+	if err != nil {
+		return nil, err
+	}
+	if feed == nil {
+		return nil, ErrFeedNotFound
+	}
+	if feed.RegionID != p.RegionID {
+		return nil, ErrFeedRegion
+	}
+	if p.Round != feed.Round+1 {
+		return nil, fmt.Errorf("%w: (expected=%d, got=%d)", ErrFeedRound, feed.Round+1, p.Round)
+	}
+	signer := false
+	for _, enclaveID := range feed.Signers {
+		signer = signer || bytes.Equal(enclaveID, p.Attestation.EnclaveID)
+	}
+	if !signer {
+		return nil, ErrFeedSigner
+	}
+	if err := verifySettlementSigner(ctx, mu, timestamp, p.RegionID, FeedDigest(p.FeedID, p.Round, p.Value), &p.Attestation); err != nil {
+		return nil, err
+	}
+
+	stampKeys, err := RoughtimeKeys(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	quorum, err := RoughtimeQuorum(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	observedAt, err := p.Attestation.TrustedMedianTime(stampKeys, quorum)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTimeStamps, err)
+	}
+	maxDrift, err := Uint64Param(ctx, mu, consts.ParamTimeDrift, consts.MaxTimeDrift)
+	if err != nil {
+		return nil, err
+	}
+	if !attestation.WithinDrift(observedAt, uint64(timestamp/1000), maxDrift) {
+		return nil, ErrStaleTimeStamp
+	}
+
+	if feed.Round > 0 {
+		latest := feed.Latest
+		if observedAt < latest.ObservedAt {
+			return nil, ErrFeedStale
+		}
+		heartbeat := feed.Heartbeat > 0 && observedAt-latest.ObservedAt >= feed.Heartbeat
+		if !heartbeat && !deviates(latest.Value, p.Value, feed.DeviationBPS) {
+			return nil, ErrFeedDeviation
+		}
+	}
+
+	value := storage.FeedValue{
+		Round:      p.Round,
+		Value:      p.Value,
+		ObservedAt: observedAt,
+		EnclaveID:  p.Attestation.EnclaveID,
+	}
+	if err := storage.SetFeedValue(ctx, mu, p.FeedID, &value); err != nil {
+		return nil, err
+	}
+	if p.Round > consts.FeedHistory {
+		if err := storage.DeleteFeedValue(ctx, mu, p.FeedID, p.Round-consts.FeedHistory); err != nil {
+			return nil, err
+		}
+	}
+	feed.Round = p.Round
+	feed.Latest = value
+	if err := storage.SetFeed(ctx, mu, p.FeedID, feed); err != nil {
+		return nil, err
+	}
+	return &PublishFeedResult{
+		FeedID:     p.FeedID,
+		Round:      p.Round,
+		ObservedAt: observedAt,
+	}, nil
+}
+
+func (p *PublishFeedAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.TEEUnits + 2*schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(p).QuoteBytes)
+}
+
+// ValidRange expires the value once its stamps are too old to pass the
+// drift check.
+func (p *PublishFeedAction) ValidRange(chain.Rules) (int64, int64) {
+	return stampRange(&p.Attestation)
+}
+
+type PublishFeedResult struct {
+	FeedID     ids.ID `serialize:"true" json:"feed_id"`
+	Round      uint64 `serialize:"true" json:"round"`
+	ObservedAt uint64 `serialize:"true" json:"observed_at"`
+}
+
+func (*PublishFeedResult) GetTypeID() uint8 {
+	return consts.PublishFeedResultID
+}
+
+// deviates reports whether [next] is at least [bps] basis points away from
+// [prev].
+func deviates(prev, next uint64, bps uint32) bool {
+	diff := next - prev
+	if prev > next {
+		diff = prev - next
+	}
+	// Compare diff/prev with bps/10_000 without overflowing
+	dhi, dlo := bits.Mul64(diff, MaxDeviationBPS)
+	thi, tlo := bits.Mul64(prev, uint64(bps))
+	return dhi > thi || (dhi == thi && dlo >= tlo)
+}
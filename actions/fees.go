@@ -0,0 +1,205 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+// FeeSchedule weights the work each action puts on validators. The compute
+// units it produces are charged to the transaction sponsor through
+// [storage.StateManager.Deduct].
+type FeeSchedule struct {
+	// Charged once per action
+	BaseUnits uint64 `serialize:"true" json:"base_units"`
+	// Charged per TEE signature checked
+	AttestationUnits uint64 `serialize:"true" json:"attestation_units"`
+	// Charged per Roughtime stamp checked
+	TimeStampUnits uint64 `serialize:"true" json:"time_stamp_units"`
+	// Charged per KiB (rounded up) of state written
+	StateKiBUnits uint64 `serialize:"true" json:"state_kib_units"`
+	// Charged per state key written
+	StateUpdateUnits uint64 `serialize:"true" json:"state_update_units"`
+	// Charged per event emitted
+	EventUnits uint64 `serialize:"true" json:"event_units"`
+	// Charged per KiB (rounded up) of code stored or verified
+	CodeKiBUnits uint64 `serialize:"true" json:"code_kib_units"`
+	// Charged per TEE address in a region action
+	TEEUnits uint64 `serialize:"true" json:"tee_units"`
+	// Charged per KiB (rounded up) of attestation payload verified: quotes,
+	// documents, evidence, signatures and stamps
+	QuoteKiBUnits uint64 `serialize:"true" json:"quote_kib_units"`
+}
+
+var DefaultFeeSchedule = FeeSchedule{
+	BaseUnits:        1,
+	AttestationUnits: 50,
+	TimeStampUnits:   10,
+	StateKiBUnits:    4,
+	StateUpdateUnits: 2,
+	EventUnits:       3,
+	CodeKiBUnits:     1,
+	TEEUnits:         1,
+	QuoteKiBUnits:    8,
+}
+
+// FeeRules is implemented by chain rules that price actions with a fee
+// schedule of their own. Other rules price them with [DefaultFeeSchedule].
+type FeeRules interface {
+	GetFeeSchedule() FeeSchedule
+}
+
+// FeeScheduleOf returns the fee schedule [rules] price actions with.
+func FeeScheduleOf(rules chain.Rules) FeeSchedule {
+	if r, ok := rules.(FeeRules); ok {
+		return r.GetFeeSchedule()
+	}
+	return DefaultFeeSchedule
+}
+
+// governedFeeRules are rules pricing actions with the governed fee
+// schedule.
+type governedFeeRules struct {
+	chain.Rules
+	schedule FeeSchedule
+}
+
+func (r *governedFeeRules) GetFeeSchedule() FeeSchedule {
+	return r.schedule
+}
+
+// GovernedRules returns [rules] pricing actions with the fee schedule in
+// effect at the current height, or [rules] itself if governance never set
+// one.
+func GovernedRules(ctx context.Context, rules chain.Rules, im state.Immutable) (chain.Rules, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(consts.ParamFeeSchedule))
+	if err != nil {
+		return nil, err
+	}
+	v := p.Value(height)
+	if len(v) == 0 {
+		return rules, nil
+	}
+	var schedule FeeSchedule
+	if err := codec.Unmarshal(v, &schedule); err != nil {
+		return nil, err
+	}
+	return &governedFeeRules{Rules: rules, schedule: schedule}, nil
+}
+
+// chargeGovernedFees collects the compute fee the chain charged [actor]
+// under [rules] for [action] into the collected fees of the region it acts
+// on. It then charges the units [action] costs under the governed fee
+// schedule beyond those, or refunds those charged beyond them. The chain
+// prices actions before executing them, without state to read the governed
+// schedule from.
+func chargeGovernedFees(ctx context.Context, rules chain.Rules, mu state.Mutable, actor codec.Address, action chain.Action) error {
+	regionID := ActionRegion(action)
+	price := rules.GetMinUnitPrice()[fees.Compute]
+	charged := action.ComputeUnits(rules)
+	fee, err := smath.Mul(charged, price)
+	if err != nil {
+		return err
+	}
+	if err := storage.CollectFee(ctx, mu, regionID, fee); err != nil {
+		return err
+	}
+	governed, err := GovernedRules(ctx, rules, mu)
+	if err != nil || governed == rules {
+		return err
+	}
+	units := action.ComputeUnits(governed)
+	if units <= charged {
+		_, err := refundUnusedUnits(ctx, rules, mu, regionID, actor, charged, units)
+		return err
+	}
+	extra, err := smath.Mul(units-charged, price)
+	if err != nil {
+		return err
+	}
+	if extra == 0 {
+		return nil
+	}
+	if _, err := storage.SubBalance(ctx, mu, actor, extra); err != nil {
+		return err
+	}
+	return storage.CollectFee(ctx, mu, regionID, extra)
+}
+
+// ExecUnits prices a TEE execution with [attestations] signatures,
+// [stamps] Roughtime stamps, [updates] state writes totalling [stateBytes]
+// and [events] emitted events.
+func (f FeeSchedule) ExecUnits(attestations, stamps, updates, stateBytes, events int) uint64 {
+	return f.BaseUnits +
+		uint64(attestations)*f.AttestationUnits +
+		uint64(stamps)*f.TimeStampUnits +
+		uint64(updates)*f.StateUpdateUnits +
+		kib(stateBytes)*f.StateKiBUnits +
+		uint64(events)*f.EventUnits
+}
+
+// StorageUnits prices an action that writes [codeBytes] of code and
+// [stateBytes] of other state.
+func (f FeeSchedule) StorageUnits(codeBytes, stateBytes int) uint64 {
+	return f.BaseUnits +
+		kib(codeBytes)*f.CodeKiBUnits +
+		kib(stateBytes)*f.StateKiBUnits
+}
+
+// RegionUnits prices a region action touching [tees] TEE addresses.
+func (f FeeSchedule) RegionUnits(tees int) uint64 {
+	return f.BaseUnits + f.StateUpdateUnits + uint64(tees)*f.TEEUnits
+}
+
+// QuoteUnits prices verifying [quoteBytes] of attestation payload.
+func (f FeeSchedule) QuoteUnits(quoteBytes int) uint64 {
+	return kib(quoteBytes) * f.QuoteKiBUnits
+}
+
+func kib(n int) uint64 {
+	return (uint64(n) + 1023) / 1024
+}
+
+// refundUnusedUnits credits [sponsor] for the [charged] compute units that
+// exceed [consumed], priced at the minimum compute unit price, and removes
+// the refund from the fees collected in [regionID]. It returns the amount
+// refunded.
+func refundUnusedUnits(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	regionID string,
+	sponsor codec.Address,
+	charged uint64,
+	consumed uint64,
+) (uint64, error) {
+	if charged <= consumed {
+		return 0, nil
+	}
+	refund := (charged - consumed) * rules.GetMinUnitPrice()[fees.Compute]
+	if refund == 0 {
+		return 0, nil
+	}
+	if err := storage.ReturnFee(ctx, mu, regionID, refund); err != nil {
+		return 0, err
+	}
+	if _, err := storage.AddBalance(ctx, mu, sponsor, refund, true); err != nil {
+		return 0, err
+	}
+	return refund, nil
+}
@@ -0,0 +1,530 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	vmauth "github.com/rhombus-tech/vm/auth"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+func TestSponsoredFees(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "counter")
+	operator := f.Actor
+	user := codectest.NewRandomAddress()
+	relayer := codectest.NewRandomAddress()
+
+	queue := &actions.QueueRequestAction{RegionID: testvm.Region, TxData: []byte("input"), Sponsor: operator}
+	fee := queue.ComputeUnits(f.Rules) * f.Rules.GetMinUnitPrice()[fees.Compute]
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// A sponsored request needs the sponsor's allowance
+			Name:        "NoAllowance",
+			Actor:       user,
+			Action:      queue,
+			ExpectedErr: storage.ErrInsufficientAllowance,
+		},
+		{
+			Name:   "Approve",
+			Actor:  operator,
+			Action: &actions.ApproveAction{Spender: user, Amount: fee},
+		},
+		{
+			Name:   "Queue",
+			Actor:  user,
+			Action: queue,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.Equal(fee, out.(*actions.QueueRequestResult).Sponsored)
+				balance, err := f.Balance(ctx, user)
+				require.NoError(err)
+				require.Equal(fee, balance)
+				allowance, err := storage.GetAllowance(ctx, f.State, operator, user)
+				require.NoError(err)
+				require.Zero(allowance)
+			},
+		},
+		{
+			// Allowances can also be spent directly
+			Name:   "ApproveMore",
+			Actor:  operator,
+			Action: &actions.ApproveAction{Spender: user, Amount: 100},
+		},
+		{
+			Name:        "TransferOverAllowance",
+			Actor:       user,
+			Action:      &actions.TransferFromAction{From: operator, To: user, Value: 101},
+			ExpectedErr: storage.ErrInsufficientAllowance,
+		},
+		{
+			Name:   "TransferFrom",
+			Actor:  user,
+			Action: &actions.TransferFromAction{From: operator, To: user, Value: 60},
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, uint64(40), out.(*actions.TransferFromResult).Allowance)
+			},
+		},
+		{
+			// An object's creator can have it pay for executions of events
+			// sent to it, up to its budget
+			Name:   "SetSponsor",
+			Actor:  operator,
+			Action: &actions.SetSponsorPolicyAction{ObjectID: "counter", MaxPerEvent: 10, Deposit: 25},
+		},
+		{
+			Name:        "NotCreator",
+			Actor:       user,
+			Action:      &actions.SetSponsorPolicyAction{ObjectID: "counter", MaxPerEvent: 10},
+			ExpectedErr: actions.ErrNotObjectCreator,
+		},
+		{
+			Name:        "NotCreatorWithdraw",
+			Actor:       user,
+			Action:      &actions.SetSponsorPolicyAction{ObjectID: "counter", Withdraw: true},
+			ExpectedErr: actions.ErrNotObjectCreator,
+		},
+	})
+
+	// Only executions of events queued for the object are sponsored
+	events := make([]ids.ID, 4)
+	sends := make([]testvm.Step, len(events))
+	for i := range events {
+		event := &actions.SendEventAction{
+			Version:      consts.LatestActionVersion,
+			IDTo:         "counter",
+			FunctionCall: "increment",
+			Parameters:   []byte{byte(i)},
+			Sender:       operator,
+		}
+		events[i] = event.EventID()
+		sends[i] = testvm.Step{Name: "Send", Actor: operator, Action: event}
+	}
+	f.RunSteps(ctx, t, sends)
+
+	result := actions.TEEExecResult{
+		ContractAddr: []byte("counter"),
+		StateUpdates: map[string][]byte{"counter": {1}},
+	}
+	tests := []struct {
+		name      string
+		eventID   ids.ID
+		sponsored uint64
+	}{
+		{
+			name:      "Sponsored",
+			eventID:   events[0],
+			sponsored: 10,
+		},
+		{
+			// The execution took the event off the queue, so it is paid
+			// for once
+			name:    "Redelivered",
+			eventID: events[0],
+		},
+		{
+			name:    "NotQueued",
+			eventID: ids.GenerateTestID(),
+		},
+		{
+			// Executions that complete no event are not sponsored
+			name: "NoEvent",
+		},
+		{
+			name:      "SecondEvent",
+			eventID:   events[1],
+			sponsored: 10,
+		},
+		{
+			name:      "BudgetLeft",
+			eventID:   events[2],
+			sponsored: 5,
+		},
+		{
+			name:    "BudgetSpent",
+			eventID: events[3],
+		},
+	}
+	for _, tt := range tests {
+		require.NoError(t, f.Advance(ctx, 1, time.Second))
+		var (
+			exec *actions.TEEExecAction
+			err  error
+		)
+		if tt.eventID != ids.Empty {
+			exec, err = f.AttestEvent(testvm.Region, f.SGX, tt.eventID, result)
+		} else {
+			exec, err = f.Attest(testvm.Region, f.SGX, result)
+		}
+		require.NoError(t, err)
+		f.RunSteps(ctx, t, []testvm.Step{
+			{
+				Name:   tt.name,
+				Actor:  relayer,
+				Action: exec,
+				Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+					require := require.New(t)
+					require.Equal(tt.sponsored, out.(*actions.TEEExecOutput).Sponsored)
+					queueKey := storage.EventQueueKey(tt.eventID, "counter")
+					_, err := f.State.GetValue(ctx, queueKey)
+					require.ErrorIs(err, database.ErrNotFound)
+					charge, err := storage.GetEventCharge(ctx, f.State, queueKey)
+					require.NoError(err)
+					require.Nil(charge)
+				},
+			},
+		})
+	}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// A spent policy stays the creator's
+			Name:        "NotCreatorSpent",
+			Actor:       user,
+			Action:      &actions.SetSponsorPolicyAction{ObjectID: "counter", MaxPerEvent: 10},
+			ExpectedErr: actions.ErrNotObjectCreator,
+		},
+		{
+			Name:            "Withdraw",
+			Actor:           operator,
+			Action:          &actions.SetSponsorPolicyAction{ObjectID: "counter", Withdraw: true},
+			ExpectedOutputs: &actions.SetSponsorPolicyResult{ObjectID: "counter"},
+		},
+		{
+			Name:        "NoPolicy",
+			Actor:       operator,
+			Action:      &actions.SetSponsorPolicyAction{ObjectID: "counter", Withdraw: true},
+			ExpectedErr: actions.ErrNoSponsorPolicy,
+		},
+	})
+}
+
+func TestAssets(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	issuer := codectest.NewRandomAddress()
+	holder := codectest.NewRandomAddress()
+	relayer := codectest.NewRandomAddress()
+	assetID := ids.GenerateTestID()
+	meterID := storage.RegionAssetID(testvm.Region)
+	meter := &actions.CreateAssetAction{Name: "Compute", Symbol: "CU", RegionID: testvm.Region}
+	exec := f.Exec(t, f.SGX, actions.TEEExecResult{
+		ContractAddr: []byte("counter"),
+		StateUpdates: map[string][]byte{"counter": {1}},
+	})
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// Anyone can issue an asset, keyed by the creating action
+			Name:            "Create",
+			Actor:           issuer,
+			ActionID:        assetID,
+			Action:          &actions.CreateAssetAction{Name: "Credits", Symbol: "CRD", MaxSupply: 100},
+			ExpectedOutputs: &actions.CreateAssetResult{AssetID: assetID},
+		},
+		{
+			// Only the owner mints, up to the max supply
+			Name:        "NotOwner",
+			Actor:       holder,
+			Action:      &actions.MintAssetAction{AssetID: assetID, To: holder, Value: 10},
+			ExpectedErr: actions.ErrNotAssetOwner,
+		},
+		{
+			Name:   "Mint",
+			Actor:  issuer,
+			Action: &actions.MintAssetAction{AssetID: assetID, To: holder, Value: 80},
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, uint64(80), out.(*actions.MintAssetResult).Supply)
+			},
+		},
+		{
+			Name:        "MaxSupply",
+			Actor:       issuer,
+			Action:      &actions.MintAssetAction{AssetID: assetID, To: holder, Value: 21},
+			ExpectedErr: actions.ErrMaxSupply,
+		},
+		{
+			// Balances are kept apart from the native token
+			Name:            "Transfer",
+			Actor:           holder,
+			Action:          &actions.TransferAssetAction{AssetID: assetID, To: issuer, Value: 30},
+			ExpectedOutputs: &actions.TransferAssetResult{SenderBalance: 50, ReceiverBalance: 30},
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				balance, err := f.Balance(ctx, issuer)
+				require.NoError(t, err)
+				require.Zero(t, balance)
+			},
+		},
+		{
+			Name:        "NativeAsset",
+			Actor:       holder,
+			Action:      &actions.TransferAssetAction{AssetID: storage.NativeAsset, To: issuer, Value: 1},
+			ExpectedErr: actions.ErrNativeAsset,
+		},
+		{
+			// Only a TEE of the region creates its metering asset
+			Name:        "MeterNotTEE",
+			Actor:       issuer,
+			Action:      meter,
+			ExpectedErr: actions.ErrTEENotInRegion,
+		},
+		{
+			Name:   "Meter",
+			Actor:  f.SGX.Address,
+			Action: meter,
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, meterID, out.(*actions.CreateAssetResult).AssetID)
+			},
+		},
+		{
+			Name:        "MeterExists",
+			Actor:       f.SGX.Address,
+			Action:      meter,
+			ExpectedErr: actions.ErrAssetExists,
+		},
+		{
+			// Executions in the region then burn a token per unit consumed
+			Name:        "Unmetered",
+			Actor:       relayer,
+			Action:      exec,
+			ExpectedErr: actions.ErrInsufficientUnits,
+		},
+		{
+			Name:   "MintUnits",
+			Actor:  f.SGX.Address,
+			Action: &actions.MintAssetAction{AssetID: meterID, To: relayer, Value: 1_000_000},
+		},
+		{
+			Name:   "Metered",
+			Actor:  relayer,
+			Action: exec,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				exOut := out.(*actions.TEEExecOutput)
+				require.Equal(exOut.UnitsConsumed, exOut.Metered)
+				remaining, err := storage.GetAssetBalance(ctx, f.State, meterID, relayer)
+				require.NoError(err)
+				require.Equal(1_000_000-exOut.Metered, remaining)
+				asset, err := storage.GetAsset(ctx, f.State, meterID)
+				require.NoError(err)
+				require.Equal(remaining, asset.Supply)
+			},
+		},
+	})
+}
+
+func TestRateLimits(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	issuer := codectest.NewRandomAddress()
+	create := func(name string, actor codec.Address, expectedErr error) testvm.Step {
+		return testvm.Step{
+			Name:        name,
+			Actor:       actor,
+			Action:      &actions.CreateAssetAction{Name: "Credits", Symbol: "CRD"},
+			ExpectedErr: expectedErr,
+		}
+	}
+
+	// Without limits, accounts may take any number of actions
+	unlimited := make([]testvm.Step, 5)
+	for i := range unlimited {
+		unlimited[i] = create("Unlimited", issuer, nil)
+	}
+	v.RunSteps(ctx, t, unlimited)
+
+	limits := &actions.RateLimits{PerBlock: 2, PerWindow: 4, WindowMs: 10_000}
+	value, err := codec.Marshal(limits)
+	require.NoError(t, err)
+	require.NoError(t, storage.ScheduleParam(ctx, v.State, uint8(consts.ParamRateLimits), value, v.Height, v.Height))
+	// Start at the beginning of a window
+	require.NoError(t, v.Advance(ctx, 1, 0))
+	v.Timestamp -= v.Timestamp % int64(limits.WindowMs)
+
+	tests := []struct {
+		name  string
+		gap   time.Duration
+		steps []testvm.Step
+	}{
+		{
+			name: "FirstBlock",
+			steps: []testvm.Step{
+				create("First", issuer, nil),
+				create("Second", issuer, nil),
+				create("OverBlock", issuer, actions.ErrRateLimited),
+				// Limits are per account
+				create("OtherAccount", codectest.NewRandomAddress(), nil),
+			},
+		},
+		{
+			// The next block has a new per-block allowance, but the window
+			// is spent
+			name: "NextBlock",
+			gap:  time.Second,
+			steps: []testvm.Step{
+				create("Third", issuer, nil),
+				create("Fourth", issuer, nil),
+			},
+		},
+		{
+			name: "WindowSpent",
+			gap:  time.Second,
+			steps: []testvm.Step{
+				create("OverWindow", issuer, actions.ErrRateLimited),
+			},
+		},
+		{
+			// Halfway through the next window, half of the last one still
+			// counts
+			name: "HalfWindow",
+			gap:  13 * time.Second,
+			steps: []testvm.Step{
+				create("First", issuer, nil),
+				create("Second", issuer, nil),
+			},
+		},
+		{
+			name: "HalfWindowSpent",
+			steps: []testvm.Step{
+				create("OverWindow", issuer, actions.ErrRateLimited),
+			},
+		},
+		{
+			// and none of it once a whole window passed
+			name: "NextWindow",
+			gap:  15 * time.Second,
+			steps: []testvm.Step{
+				create("First", issuer, nil),
+				create("Second", issuer, nil),
+			},
+		},
+	}
+	for i, tt := range tests {
+		if i > 0 {
+			require.NoError(t, v.Advance(ctx, 1, tt.gap))
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			v.RunSteps(ctx, t, tt.steps)
+		})
+	}
+}
+
+func TestSessionKeys(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	account := codectest.NewRandomAddress()
+	require.NoError(t, v.Mint(ctx, account, 1_000))
+	key, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	session := vmauth.SessionAddress(account, key.PublicKey())
+	createAsset := &actions.CreateAssetAction{Name: "Credits", Symbol: "CRD"}
+
+	authorize := &actions.AuthorizeSessionAction{
+		Key:         key.PublicKey(),
+		Expiry:      v.Timestamp + time.Hour.Milliseconds(),
+		ActionTypes: []uint8{consts.CreateAssetID},
+		Allowance:   100,
+	}
+	scoped := *authorize
+	scoped.Regions = []string{testvm.Region}
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "NotAuthorized",
+			Actor:       session,
+			Action:      createAsset,
+			ExpectedErr: actions.ErrSessionNotAuthorized,
+		},
+		{
+			Name:   "Authorize",
+			Actor:  account,
+			Action: authorize,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, session, out.(*actions.AuthorizeSessionResult).Session)
+				balance, err := v.Balance(ctx, session)
+				require.NoError(t, err)
+				require.Equal(t, uint64(100), balance)
+			},
+		},
+		{
+			// The session may take the granted action types only
+			Name:   "Granted",
+			Actor:  session,
+			Action: createAsset,
+		},
+		{
+			Name:        "MintOutOfScope",
+			Actor:       session,
+			Action:      &actions.MintAssetAction{To: account, Value: 1},
+			ExpectedErr: actions.ErrSessionScope,
+		},
+		{
+			Name:        "AuthorizeOutOfScope",
+			Actor:       session,
+			Action:      authorize,
+			ExpectedErr: actions.ErrSessionScope,
+		},
+		{
+			// and on the granted regions, when the grant names any
+			Name:   "AuthorizeRegions",
+			Actor:  account,
+			Action: &scoped,
+		},
+		{
+			Name:        "RegionOutOfScope",
+			Actor:       session,
+			Action:      createAsset,
+			ExpectedErr: actions.ErrSessionScope,
+		},
+		{
+			Name:   "AuthorizeAnyRegion",
+			Actor:  account,
+			Action: authorize,
+		},
+	})
+
+	// until it expires
+	require.NoError(t, v.Advance(ctx, 1, 2*time.Hour))
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Expired",
+			Actor:       session,
+			Action:      createAsset,
+			ExpectedErr: actions.ErrSessionExpired,
+		},
+		{
+			// Revoking returns what is left of the allowance
+			Name:   "Revoke",
+			Actor:  account,
+			Action: &actions.RevokeSessionAction{Key: key.PublicKey()},
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, uint64(300), out.(*actions.RevokeSessionResult).Refunded)
+				balance, err := v.Balance(ctx, account)
+				require.NoError(t, err)
+				require.Equal(t, uint64(1_000), balance)
+			},
+		},
+		{
+			Name:        "Revoked",
+			Actor:       session,
+			Action:      createAsset,
+			ExpectedErr: actions.ErrSessionNotAuthorized,
+		},
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// addGateKeys declares the keys [checkGates] reads and writes for
+// [action] taken by [actor].
+func addGateKeys(keys state.Keys, actor codec.Address, action chain.Action) state.Keys {
+	keys[string(storage.HeightKey())] |= state.Read
+	keys[string(storage.ActionGateKey(action.GetTypeID()))] |= state.Read
+	if _, ok := action.(Versioned); ok {
+		keys[string(storage.AdminVersionKey())] |= state.Read
+	}
+	keys[string(storage.ParamKey(uint8(consts.ParamRateLimits)))] |= state.Read
+	keys[string(storage.RateCounterKey(actor))] |= state.All
+	keys[string(storage.ParamKey(uint8(consts.ParamFeeSchedule)))] |= state.Read
+	keys[string(storage.BalanceKey(actor))] |= state.All
+	keys[string(storage.CollectedFeesKey(ActionRegion(action)))] |= state.All
+	if IsSession(actor) {
+		keys[string(storage.SessionKey(actor))] |= state.Read
+	}
+	return keys
+}
+
+// checkGates returns why [actor] may not take [action] at [timestamp], and
+// otherwise charges it the governed fees of [action]. Each action checks it
+// before anything else in Execute, as the chain runs no hook of its own
+// before an action executes.
+func checkGates(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	action chain.Action,
+) error {
+	if err := CheckActionEnabled(ctx, mu, action.GetTypeID()); err != nil {
+		return err
+	}
+	if err := CheckActionVersion(ctx, rules, mu, action); err != nil {
+		return err
+	}
+	if err := CheckSession(ctx, mu, actor, action, timestamp); err != nil {
+		return err
+	}
+	if err := CheckRateLimit(ctx, mu, actor, timestamp); err != nil {
+		return err
+	}
+	return chargeGovernedFees(ctx, rules, mu, actor, action)
+}
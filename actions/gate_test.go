@@ -0,0 +1,249 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestActionGate(t *testing.T) {
+	actor := codectest.NewRandomAddress()
+	to := codectest.NewRandomAddress()
+	assetID := ids.GenerateTestID()
+
+	// gatedState has [actor] holding 10 of [assetID] at [height], with
+	// transfers of assets gated by [gate] if set
+	gatedState := func(height uint64, gate *storage.ActionGate) state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, store.Insert(ctx, storage.HeightKey(), binary.BigEndian.AppendUint64(nil, height)))
+		_, err := storage.AddAssetBalance(ctx, store, assetID, actor, 10, true)
+		require.NoError(t, err)
+		if gate != nil {
+			require.NoError(t, storage.SetActionGate(ctx, store, consts.TransferAssetID, gate))
+		}
+		return store
+	}
+	transfer := &TransferAssetAction{AssetID: assetID, To: to, Value: 4}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "Disabled",
+			Actor:       actor,
+			Action:      transfer,
+			State:       gatedState(5, &storage.ActionGate{Disabled: true}),
+			ExpectedErr: ErrActionDisabled,
+		},
+		{
+			Name:        "BeforeActivation",
+			Actor:       actor,
+			Action:      transfer,
+			State:       gatedState(5, &storage.ActionGate{ActivationHeight: 6}),
+			ExpectedErr: ErrActionDisabled,
+		},
+		{
+			Name:            "AtActivation",
+			Actor:           actor,
+			Action:          transfer,
+			State:           gatedState(6, &storage.ActionGate{ActivationHeight: 6}),
+			ExpectedOutputs: &TransferAssetResult{SenderBalance: 6, ReceiverBalance: 4},
+		},
+		{
+			Name:            "Ungated",
+			Actor:           actor,
+			Action:          transfer,
+			State:           gatedState(5, nil),
+			ExpectedOutputs: &TransferAssetResult{SenderBalance: 6, ReceiverBalance: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+// scheduleRules are rules with their own action version schedule
+type scheduleRules struct {
+	chain.Rules
+	schedule []consts.VersionActivation
+}
+
+func (r *scheduleRules) GetActionVersionSchedule() []consts.VersionActivation {
+	return r.schedule
+}
+
+func TestActionVersionGate(t *testing.T) {
+	// The latest version activates at height 10, the rest at genesis
+	schedule := make([]consts.VersionActivation, consts.LatestActionVersion)
+	for i := range schedule {
+		schedule[i].Version = uint8(i + 1)
+	}
+	schedule[len(schedule)-1].Height = 10
+	rules := &scheduleRules{Rules: genesis.NewDefaultRules(), schedule: schedule}
+
+	atHeight := func(height uint64) state.Mutable {
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, store.Insert(context.Background(), storage.HeightKey(), binary.BigEndian.AppendUint64(nil, height)))
+		return store
+	}
+	// adminActivated is [atHeight] with the admin keys having activated the
+	// latest version from [activation]
+	adminActivated := func(height, activation uint64) state.Mutable {
+		store := atHeight(height)
+		require.NoError(t, storage.SetAdminVersion(context.Background(), store, &storage.AdminVersion{Version: consts.LatestActionVersion, Height: activation}))
+		return store
+	}
+	// With a single TEE, an action past the gate fails with ErrTooFewTEEs
+	create := func(version uint8) *CreateRegionAction {
+		return &CreateRegionAction{Version: version, RegionID: "us-east", TEEs: []codec.Address{codectest.NewRandomAddress()}}
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "Unset",
+			Action:      create(0),
+			Rules:       rules,
+			State:       atHeight(10),
+			ExpectedErr: ErrUnsupportedActionVersion,
+		},
+		{
+			Name:        "BeforeActivation",
+			Action:      create(consts.LatestActionVersion),
+			Rules:       rules,
+			State:       atHeight(9),
+			ExpectedErr: ErrUnsupportedActionVersion,
+		},
+		{
+			Name:        "EarlierVersion",
+			Action:      create(consts.LatestActionVersion - 1),
+			Rules:       rules,
+			State:       atHeight(9),
+			ExpectedErr: ErrTooFewTEEs,
+		},
+		{
+			Name:        "AtActivation",
+			Action:      create(consts.LatestActionVersion),
+			Rules:       rules,
+			State:       atHeight(10),
+			ExpectedErr: ErrTooFewTEEs,
+		},
+		{
+			// The admin keys can activate a version ahead of the schedule
+			Name:        "AdminActivated",
+			Action:      create(consts.LatestActionVersion),
+			Rules:       rules,
+			State:       adminActivated(9, 9),
+			ExpectedErr: ErrTooFewTEEs,
+		},
+		{
+			Name:        "BeforeAdminActivation",
+			Action:      create(consts.LatestActionVersion),
+			Rules:       rules,
+			State:       adminActivated(9, 10),
+			ExpectedErr: ErrUnsupportedActionVersion,
+		},
+		{
+			// Rules without a schedule follow the default one
+			Name:        "DefaultSchedule",
+			Action:      create(consts.LatestActionVersion),
+			Rules:       genesis.NewDefaultRules(),
+			State:       atHeight(0),
+			ExpectedErr: ErrTooFewTEEs,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestAdminAction(t *testing.T) {
+	actor := codectest.NewRandomAddress()
+	rules := genesis.NewDefaultRules()
+	rules.ChainID = ids.GenerateTestID()
+
+	adminKey, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	adminState := func() state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, store.Insert(ctx, storage.HeightKey(), binary.BigEndian.AppendUint64(nil, 5)))
+		require.NoError(t, storage.SetAdminSet(ctx, store, &storage.AdminSet{
+			Threshold: 1,
+			Keys:      []ed25519.PublicKey{adminKey.PublicKey()},
+		}))
+		return store
+	}
+	// sign signs [a] for the chain [chainID]
+	sign := func(a *AdminAction, chainID ids.ID) *AdminAction {
+		a.Signatures = []AdminSignature{{
+			PublicKey: adminKey.PublicKey(),
+			Signature: ed25519.Sign(a.Digest(chainID), adminKey),
+		}}
+		return a
+	}
+	activate := func(version uint8) *AdminAction {
+		return &AdminAction{Op: AdminOpActivateVersion, Version: version, ActivationHeight: 7}
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			// Signatures for another chain are not valid on this one
+			Name:        "OtherChain",
+			Actor:       actor,
+			Action:      sign(activate(consts.LatestActionVersion), ids.GenerateTestID()),
+			Rules:       rules,
+			State:       adminState(),
+			ExpectedErr: ErrInvalidSignature,
+		},
+		{
+			Name:        "UnknownVersion",
+			Actor:       actor,
+			Action:      sign(activate(consts.LatestActionVersion+1), rules.ChainID),
+			Rules:       rules,
+			State:       adminState(),
+			ExpectedErr: ErrUnsupportedActionVersion,
+		},
+		{
+			Name:   "ActivateVersion",
+			Actor:  actor,
+			Action: sign(activate(consts.LatestActionVersion), rules.ChainID),
+			Rules:  rules,
+			State:  adminState(),
+			ExpectedOutputs: &AdminResult{
+				Op:               AdminOpActivateVersion,
+				Version:          consts.LatestActionVersion,
+				ActivationHeight: 7,
+			},
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				require := require.New(t)
+				version, err := storage.GetAdminVersion(ctx, store)
+				require.NoError(err)
+				require.Equal(&storage.AdminVersion{Version: consts.LatestActionVersion, Height: 7}, version)
+				nonce, err := storage.GetAdminNonce(ctx, store)
+				require.NoError(err)
+				require.Equal(uint64(1), nonce)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -0,0 +1,459 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+const MaxParamValueLen = 4096
+
+var (
+	ErrInvalidParam       = errors.New("invalid governance parameter")
+	ErrInvalidParamValue  = errors.New("invalid governance parameter value")
+	ErrParamValueTooLarge = errors.New("parameter value too large")
+	ErrActivationTooEarly = errors.New("activation height too early")
+	ErrInsufficientWeight = errors.New("insufficient voting weight")
+	ErrAlreadyVoted       = errors.New("already voted")
+	ErrVotingClosed       = errors.New("voting period closed")
+	ErrVotingOpen         = errors.New("voting period still open")
+	ErrProposalExecuted   = errors.New("proposal already executed")
+	ErrProposalRejected   = errors.New("proposal rejected")
+
+	_ chain.Action = (*ProposeAction)(nil)
+	_ chain.Action = (*VoteAction)(nil)
+	_ chain.Action = (*ExecuteProposalAction)(nil)
+	_ chain.Action = (*WithdrawVoteAction)(nil)
+)
+
+// ProposeAction opens a vote on changing [Param] to [Value] at
+// [ActivationHeight]. The proposer must hold at least
+// [consts.MinProposalWeight]. The proposal is identified by the ID of the
+// transaction that created it.
+type ProposeAction struct {
+	Param            uint8  `serialize:"true" json:"param"`
+	Value            []byte `serialize:"true" json:"value"`
+	ActivationHeight uint64 `serialize:"true" json:"activation_height"`
+}
+
+func (*ProposeAction) GetTypeID() uint8 {
+	return consts.ProposeID
+}
+
+func (p *ProposeAction) StateKeys(actor codec.Address, txID ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.HeightKey()):       state.Read,
+		string(storage.BalanceKey(actor)): state.Read,
+		string(storage.ProposalKey(txID)): state.All,
+	}, actor, p)
+}
+
+func (p *ProposeAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	txID ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	if err := validateParamValue(consts.ParamID(p.Param), p.Value); err != nil {
+		return nil, err
+	}
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	voteEnd := height + consts.VotingPeriod
+	if p.ActivationHeight < voteEnd+consts.MinActivationDelay {
+		return nil, ErrActivationTooEarly
+	}
+	weight, err := storage.GetBalance(ctx, mu, actor)
+	if err != nil {
+		return nil, err
+	}
+	if weight < consts.MinProposalWeight {
+		return nil, ErrInsufficientWeight
+	}
+
+	if err := storage.SetProposal(ctx, mu, txID, &storage.Proposal{
+		Proposer:         actor,
+		Param:            p.Param,
+		Value:            p.Value,
+		VoteEnd:          voteEnd,
+		ActivationHeight: p.ActivationHeight,
+	}); err != nil {
+		return nil, err
+	}
+	return &ProposeResult{ProposalID: txID, VoteEnd: voteEnd}, nil
+}
+
+func (p *ProposeAction) ComputeUnits(rules chain.Rules) uint64 {
+	return FeeScheduleOf(rules).StorageUnits(0, len(p.Value))
+}
+
+func (*ProposeAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// VoteAction casts [Weight] of the actor's balance as voting weight on a
+// proposal. The weight is locked out of the balance until voting closes and
+// the voter withdraws it, so the same tokens cannot vote twice.
+type VoteAction struct {
+	ProposalID ids.ID `serialize:"true" json:"proposal_id"`
+	Support    bool   `serialize:"true" json:"support"`
+	Weight     uint64 `serialize:"true" json:"weight"`
+}
+
+func (*VoteAction) GetTypeID() uint8 {
+	return consts.VoteID
+}
+
+func (v *VoteAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.HeightKey()):                  state.Read,
+		string(storage.BalanceKey(actor)):            state.Read | state.Write,
+		string(storage.ProposalKey(v.ProposalID)):    state.Read | state.Write,
+		string(storage.VoteKey(v.ProposalID, actor)): state.All,
+	}, actor, v)
+}
+
+func (v *VoteAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, v); err != nil {
+		return nil, err
+	}
+
+	proposal, err := storage.GetProposal(ctx, mu, v.ProposalID)
+	if err != nil {
+		return nil, err
+	}
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if height >= proposal.VoteEnd {
+		return nil, ErrVotingClosed
+	}
+	voted, err := storage.HasVoted(ctx, mu, v.ProposalID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if voted {
+		return nil, ErrAlreadyVoted
+	}
+	weight := v.Weight
+	if weight == 0 {
+		return nil, ErrInsufficientWeight
+	}
+	if _, err := storage.SubBalance(ctx, mu, actor, weight); err != nil {
+		return nil, err
+	}
+
+	if v.Support {
+		proposal.Yes, err = smath.Add(proposal.Yes, weight)
+	} else {
+		proposal.No, err = smath.Add(proposal.No, weight)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.RecordVote(ctx, mu, v.ProposalID, actor, weight); err != nil {
+		return nil, err
+	}
+	if err := storage.SetProposal(ctx, mu, v.ProposalID, proposal); err != nil {
+		return nil, err
+	}
+	return &VoteResult{ProposalID: v.ProposalID, Weight: weight, Yes: proposal.Yes, No: proposal.No}, nil
+}
+
+func (*VoteAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*VoteAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// ExecuteProposalAction schedules the parameter change of a passed proposal
+// once voting has closed. Anyone may submit it.
+type ExecuteProposalAction struct {
+	ProposalID ids.ID `serialize:"true" json:"proposal_id"`
+	Param      uint8  `serialize:"true" json:"param"`
+}
+
+func (*ExecuteProposalAction) GetTypeID() uint8 {
+	return consts.ExecuteProposalID
+}
+
+func (e *ExecuteProposalAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.HeightKey()):               state.Read,
+		string(storage.ProposalKey(e.ProposalID)): state.Read | state.Write,
+		string(storage.ParamKey(e.Param)):         state.All,
+	}, actor, e)
+}
+
+func (e *ExecuteProposalAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, e); err != nil {
+		return nil, err
+	}
+
+	proposal, err := storage.GetProposal(ctx, mu, e.ProposalID)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Param != e.Param {
+		return nil, ErrInvalidParam
+	}
+	if proposal.Executed {
+		return nil, ErrProposalExecuted
+	}
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if height < proposal.VoteEnd {
+		return nil, ErrVotingOpen
+	}
+	total, err := smath.Add(proposal.Yes, proposal.No)
+	if err != nil {
+		return nil, err
+	}
+	if total < consts.QuorumWeight || proposal.Yes <= proposal.No {
+		return nil, ErrProposalRejected
+	}
+
+	if err := storage.ScheduleParam(ctx, mu, proposal.Param, proposal.Value, height, proposal.ActivationHeight); err != nil {
+		return nil, err
+	}
+	proposal.Executed = true
+	if err := storage.SetProposal(ctx, mu, e.ProposalID, proposal); err != nil {
+		return nil, err
+	}
+	return &ExecuteProposalResult{
+		ProposalID:       e.ProposalID,
+		Param:            proposal.Param,
+		ActivationHeight: proposal.ActivationHeight,
+	}, nil
+}
+
+func (*ExecuteProposalAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*ExecuteProposalAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// WithdrawVoteAction returns the weight the actor locked voting on a
+// proposal to its balance, once voting has closed.
+type WithdrawVoteAction struct {
+	ProposalID ids.ID `serialize:"true" json:"proposal_id"`
+}
+
+func (*WithdrawVoteAction) GetTypeID() uint8 {
+	return consts.WithdrawVoteID
+}
+
+func (w *WithdrawVoteAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.HeightKey()):                  state.Read,
+		string(storage.ProposalKey(w.ProposalID)):    state.Read,
+		string(storage.VoteKey(w.ProposalID, actor)): state.All,
+		string(storage.BalanceKey(actor)):            state.All,
+	}, actor, w)
+}
+
+func (w *WithdrawVoteAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, w); err != nil {
+		return nil, err
+	}
+
+	proposal, err := storage.GetProposal(ctx, mu, w.ProposalID)
+	if err != nil {
+		return nil, err
+	}
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if height < proposal.VoteEnd {
+		return nil, ErrVotingOpen
+	}
+	weight, err := storage.TakeVote(ctx, mu, w.ProposalID, actor)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := storage.AddBalance(ctx, mu, actor, weight, true)
+	if err != nil {
+		return nil, err
+	}
+	return &WithdrawVoteResult{ProposalID: w.ProposalID, Weight: weight, Balance: balance}, nil
+}
+
+func (*WithdrawVoteAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*WithdrawVoteAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// Result types
+type ProposeResult struct {
+	ProposalID ids.ID `serialize:"true" json:"proposal_id"`
+	VoteEnd    uint64 `serialize:"true" json:"vote_end"`
+}
+
+func (*ProposeResult) GetTypeID() uint8 {
+	return consts.ProposeResultID
+}
+
+type VoteResult struct {
+	ProposalID ids.ID `serialize:"true" json:"proposal_id"`
+	Weight     uint64 `serialize:"true" json:"weight"`
+	Yes        uint64 `serialize:"true" json:"yes"`
+	No         uint64 `serialize:"true" json:"no"`
+}
+
+func (*VoteResult) GetTypeID() uint8 {
+	return consts.VoteResultID
+}
+
+type ExecuteProposalResult struct {
+	ProposalID       ids.ID `serialize:"true" json:"proposal_id"`
+	Param            uint8  `serialize:"true" json:"param"`
+	ActivationHeight uint64 `serialize:"true" json:"activation_height"`
+}
+
+func (*ExecuteProposalResult) GetTypeID() uint8 {
+	return consts.ExecuteProposalResultID
+}
+
+type WithdrawVoteResult struct {
+	ProposalID ids.ID `serialize:"true" json:"proposal_id"`
+	Weight     uint64 `serialize:"true" json:"weight"`
+	Balance    uint64 `serialize:"true" json:"balance"`
+}
+
+func (*WithdrawVoteResult) GetTypeID() uint8 {
+	return consts.WithdrawVoteResultID
+}
+
+// Helper functions
+func validateParamValue(param consts.ParamID, value []byte) error {
+	if !param.Valid() {
+		return ErrInvalidParam
+	}
+	if len(value) > MaxParamValueLen {
+		return ErrParamValueTooLarge
+	}
+	switch param {
+	case consts.ParamFeeSchedule:
+		var schedule FeeSchedule
+		if err := codec.Unmarshal(value, &schedule); err != nil {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamTimeDrift:
+		// Attested actions expire from the mempool [consts.MaxTimeDrift]
+		// after their stamps, so the drift may only be tightened
+		if len(value) != 8 || binary.BigEndian.Uint64(value) == 0 || binary.BigEndian.Uint64(value) > consts.MaxTimeDrift {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamMaxBatchSize, consts.ParamSettlementWindow, consts.ParamAttestationValidity, consts.ParamStampRadius, consts.ParamVerificationBudget, consts.ParamEnclaveRetention:
+		if len(value) != 8 || binary.BigEndian.Uint64(value) == 0 {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamStampQuorum:
+		if len(value) != 8 {
+			return ErrInvalidParamValue
+		}
+		if servers := binary.BigEndian.Uint64(value); servers < attestation.MinStamps || servers > consts.MaxTimeStampsCount {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamRoughtimeServers:
+		var set RoughtimeServerSet
+		if err := codec.Unmarshal(value, &set); err != nil || set.validate() != nil {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamRegionTemplates:
+		var set RegionTemplates
+		if err := codec.Unmarshal(value, &set); err != nil || set.validate() != nil {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamRateLimits:
+		var limits RateLimits
+		if err := codec.Unmarshal(value, &limits); err != nil || limits.validate() != nil {
+			return ErrInvalidParamValue
+		}
+	case consts.ParamCheckpointCommittee:
+		var committee CheckpointCommittee
+		if err := codec.Unmarshal(value, &committee); err != nil || committee.validate() != nil {
+			return ErrInvalidParamValue
+		}
+	}
+	return nil
+}
+
+// Uint64Param returns the governed value of [param] in effect at the
+// current height, or [fallback] if governance never set it.
+func Uint64Param(ctx context.Context, im state.Immutable, param consts.ParamID, fallback uint64) (uint64, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return 0, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(param))
+	if err != nil {
+		return 0, err
+	}
+	v := p.Value(height)
+	if len(v) != 8 {
+		return fallback, nil
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
@@ -0,0 +1,221 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestVoteLocksWeight(t *testing.T) {
+	voter := codectest.NewRandomAddress()
+	proposalID := ids.GenerateTestID()
+
+	// proposalState has [voter] holding 100 with a vote of 60 cast, at
+	// [height] of a vote ending at 10
+	proposalState := func(height uint64, voted bool) state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, store.Insert(ctx, storage.HeightKey(), binary.BigEndian.AppendUint64(nil, height)))
+		require.NoError(t, storage.SetBalance(ctx, store, voter, 100))
+		proposal := &storage.Proposal{Param: uint8(consts.ParamMaxBatchSize), VoteEnd: 10}
+		if voted {
+			proposal.Yes = 60
+			require.NoError(t, storage.RecordVote(ctx, store, proposalID, voter, 60))
+		}
+		require.NoError(t, storage.SetProposal(ctx, store, proposalID, proposal))
+		return store
+	}
+	balanceIs := func(balance uint64) func(context.Context, *testing.T, state.Mutable) {
+		return func(ctx context.Context, t *testing.T, store state.Mutable) {
+			got, err := storage.GetBalance(ctx, store, voter)
+			require.NoError(t, err)
+			require.Equal(t, balance, got)
+		}
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "NoWeight",
+			Actor:       voter,
+			Action:      &VoteAction{ProposalID: proposalID, Support: true},
+			State:       proposalState(1, false),
+			ExpectedErr: ErrInsufficientWeight,
+		},
+		{
+			Name:        "WeightAboveBalance",
+			Actor:       voter,
+			Action:      &VoteAction{ProposalID: proposalID, Support: true, Weight: 101},
+			State:       proposalState(1, false),
+			ExpectedErr: storage.ErrInvalidBalance,
+		},
+		{
+			Name:   "Vote",
+			Actor:  voter,
+			Action: &VoteAction{ProposalID: proposalID, Support: true, Weight: 60},
+			State:  proposalState(1, false),
+			ExpectedOutputs: &VoteResult{
+				ProposalID: proposalID,
+				Weight:     60,
+				Yes:        60,
+			},
+			// The weight cannot be moved, and vote again, while locked
+			Assertion: balanceIs(40),
+		},
+		{
+			Name:        "WithdrawWhileOpen",
+			Actor:       voter,
+			Action:      &WithdrawVoteAction{ProposalID: proposalID},
+			State:       proposalState(9, true),
+			ExpectedErr: ErrVotingOpen,
+		},
+		{
+			Name:        "WithdrawWithoutVote",
+			Actor:       voter,
+			Action:      &WithdrawVoteAction{ProposalID: proposalID},
+			State:       proposalState(10, false),
+			ExpectedErr: storage.ErrVoteNotFound,
+		},
+		{
+			Name:   "Withdraw",
+			Actor:  voter,
+			Action: &WithdrawVoteAction{ProposalID: proposalID},
+			State:  proposalState(10, true),
+			ExpectedOutputs: &WithdrawVoteResult{
+				ProposalID: proposalID,
+				Weight:     60,
+				Balance:    160,
+			},
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				balanceIs(160)(ctx, t, store)
+				voted, err := storage.HasVoted(ctx, store, proposalID, voter)
+				require.NoError(t, err)
+				require.False(t, voted)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestScheduleParamPending(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	store := chaintest.NewInMemoryStore()
+	param := uint8(consts.ParamMaxBatchSize)
+
+	require.NoError(storage.ScheduleParam(ctx, store, param, []byte{1}, 1, 10))
+	// A second value cannot replace one pending
+	require.ErrorIs(storage.ScheduleParam(ctx, store, param, []byte{2}, 9, 20), storage.ErrParamPending)
+	p, err := storage.GetParam(ctx, store, param)
+	require.NoError(err)
+	require.Equal([]byte{1}, p.Value(10))
+
+	// but may follow it once it is active
+	require.NoError(storage.ScheduleParam(ctx, store, param, []byte{2}, 10, 20))
+	p, err = storage.GetParam(ctx, store, param)
+	require.NoError(err)
+	require.Equal([]byte{1}, p.Value(19))
+	require.Equal([]byte{2}, p.Value(20))
+}
+
+func TestGovernedFeeSchedule(t *testing.T) {
+	actor := codectest.NewRandomAddress()
+	assetID := ids.GenerateTestID()
+	rules := genesis.NewDefaultRules()
+	// 1 base and 2 state updates: 5 units under the default schedule
+	transfer := &TransferAssetAction{AssetID: assetID, To: codectest.NewRandomAddress(), Value: 1}
+	result := &TransferAssetResult{SenderBalance: 9, ReceiverBalance: 1}
+
+	// feeState has [actor] holding [balance] and 10 of [assetID], under
+	// the governed fee [schedule] if set
+	feeState := func(balance uint64, schedule *FeeSchedule) state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, storage.SetBalance(ctx, store, actor, balance))
+		_, err := storage.AddAssetBalance(ctx, store, assetID, actor, 10, true)
+		require.NoError(t, err)
+		if schedule != nil {
+			v, err := codec.Marshal(schedule)
+			require.NoError(t, err)
+			require.NoError(t, storage.ScheduleParam(ctx, store, uint8(consts.ParamFeeSchedule), v, 0, 0))
+		}
+		return store
+	}
+	// balanceIs checks [actor] holds [balance], and that the fees for the
+	// transfer, which acts on no region, were collected outside any
+	balanceIs := func(balance, collected uint64) func(context.Context, *testing.T, state.Mutable) {
+		return func(ctx context.Context, t *testing.T, store state.Mutable) {
+			got, err := storage.GetBalance(ctx, store, actor)
+			require.NoError(t, err)
+			require.Equal(t, balance, got)
+			fees, err := storage.GetCollectedFees(ctx, store, "")
+			require.NoError(t, err)
+			require.Equal(t, collected, fees)
+		}
+	}
+	pricier := DefaultFeeSchedule
+	pricier.BaseUnits = 11
+
+	require.Equal(t, uint64(5), transfer.ComputeUnits(rules))
+	require.Equal(t, uint64(15), transfer.ComputeUnits(&governedFeeRules{Rules: rules, schedule: pricier}))
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:            "Default",
+			Actor:           actor,
+			Action:          transfer,
+			Rules:           rules,
+			State:           feeState(5_000, nil),
+			ExpectedOutputs: result,
+			Assertion:       balanceIs(5_000, 500),
+		},
+		{
+			// 10 more units at the minimum price of 100
+			Name:            "Pricier",
+			Actor:           actor,
+			Action:          transfer,
+			Rules:           rules,
+			State:           feeState(5_000, &pricier),
+			ExpectedOutputs: result,
+			Assertion:       balanceIs(4_000, 1_500),
+		},
+		{
+			Name:        "PricierUnaffordable",
+			Actor:       actor,
+			Action:      transfer,
+			Rules:       rules,
+			State:       feeState(999, &pricier),
+			ExpectedErr: storage.ErrInvalidBalance,
+		},
+		{
+			// All 5 units charged are refunded
+			Name:            "Free",
+			Actor:           actor,
+			Action:          transfer,
+			Rules:           rules,
+			State:           feeState(5_000, &FeeSchedule{}),
+			ExpectedOutputs: result,
+			Assertion:       balanceIs(5_500, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
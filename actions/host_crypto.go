@@ -0,0 +1,138 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var ErrHostUnitsExhausted = errors.New("host crypto units exhausted")
+
+// HostCryptoCosts prices the crypto host functions in units of the
+// execution's [consts.MaxHostCryptoUnits] budget.
+type HostCryptoCosts struct {
+	// Charged per hash, plus per KiB (rounded up) hashed
+	HashUnits    uint64
+	HashKiBUnits uint64
+	// Charged per signature checked
+	Ed25519Units   uint64
+	Secp256k1Units uint64
+	BLSUnits       uint64
+}
+
+var DefaultHostCryptoCosts = HostCryptoCosts{
+	HashUnits:      1,
+	HashKiBUnits:   1,
+	Ed25519Units:   20,
+	Secp256k1Units: 30,
+	BLSUnits:       100,
+}
+
+// HostCrypto implements the crypto host functions of a runtime executing
+// object code in an enclave. The runtime binds its sha256, keccak256,
+// ed25519_verify, secp256k1_verify and bls_verify host functions to these
+// methods so contracts do not carry slow Wasm implementations of them.
+// Each call is charged before it runs; once the budget is spent calls fail
+// with [ErrHostUnitsExhausted], which should abort the execution.
+// Malformed keys and signatures do not verify and are not errors.
+type HostCrypto struct {
+	costs HostCryptoCosts
+	limit uint64
+	used  uint64
+}
+
+// NewHostCrypto meters calls with [DefaultHostCryptoCosts] against
+// [consts.MaxHostCryptoUnits].
+func NewHostCrypto() *HostCrypto {
+	return &HostCrypto{costs: DefaultHostCryptoCosts, limit: consts.MaxHostCryptoUnits}
+}
+
+// Used returns the units spent so far.
+func (h *HostCrypto) Used() uint64 {
+	return h.used
+}
+
+func (h *HostCrypto) charge(units uint64) error {
+	if units > h.limit-h.used {
+		return fmt.Errorf("%w: %d + %d > %d", ErrHostUnitsExhausted, h.used, units, h.limit)
+	}
+	h.used += units
+	return nil
+}
+
+func (h *HostCrypto) hashUnits(data []byte) uint64 {
+	return h.costs.HashUnits + kib(len(data))*h.costs.HashKiBUnits
+}
+
+func (h *HostCrypto) SHA256(data []byte) ([]byte, error) {
+	if err := h.charge(h.hashUnits(data)); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(data)
+	return digest[:], nil
+}
+
+// Keccak256 is the original Keccak hash used by Ethereum, not SHA3-256.
+func (h *HostCrypto) Keccak256(data []byte) ([]byte, error) {
+	if err := h.charge(h.hashUnits(data)); err != nil {
+		return nil, err
+	}
+	k := sha3.NewLegacyKeccak256()
+	k.Write(data)
+	return k.Sum(nil), nil
+}
+
+func (h *HostCrypto) VerifyEd25519(msg, pubKey, sig []byte) (bool, error) {
+	if err := h.charge(h.costs.Ed25519Units); err != nil {
+		return false, err
+	}
+	if len(pubKey) != ed25519.PublicKeyLen || len(sig) != ed25519.SignatureLen {
+		return false, nil
+	}
+	return ed25519.Verify(msg, ed25519.PublicKey(pubKey), ed25519.Signature(sig)), nil
+}
+
+// VerifySecp256k1 checks a recoverable signature ([r || s || v], 65 bytes)
+// over the 32 byte [hash] by the compressed public key [pubKey]. The
+// contract hashes the message itself, with whichever hash its signers use.
+func (h *HostCrypto) VerifySecp256k1(hash, pubKey, sig []byte) (bool, error) {
+	if err := h.charge(h.costs.Secp256k1Units); err != nil {
+		return false, err
+	}
+	if len(hash) != 32 || len(sig) != secp256k1.SignatureLen {
+		return false, nil
+	}
+	key, err := secp256k1.ToPublicKey(pubKey)
+	if err != nil {
+		return false, nil
+	}
+	return key.VerifyHash(hash, sig), nil
+}
+
+func (h *HostCrypto) VerifyBLS(msg, pubKey, sig []byte) (bool, error) {
+	if err := h.charge(h.costs.BLSUnits); err != nil {
+		return false, err
+	}
+	if len(pubKey) != bls.PublicKeyLen {
+		return false, nil
+	}
+	key, err := bls.PublicKeyFromBytes(pubKey)
+	if err != nil {
+		return false, nil
+	}
+	signature, err := bls.SignatureFromBytes(sig)
+	if err != nil {
+		return false, nil
+	}
+	return bls.Verify(msg, key, signature), nil
+}
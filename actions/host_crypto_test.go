@@ -0,0 +1,85 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestHostCrypto(t *testing.T) {
+	require := require.New(t)
+	h := NewHostCrypto()
+	msg := []byte("abc")
+
+	digest, err := h.SHA256(msg)
+	require.NoError(err)
+	require.Equal("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", hex.EncodeToString(digest))
+	digest, err = h.Keccak256(nil)
+	require.NoError(err)
+	require.Equal("c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(digest))
+
+	edKey, err := ed25519.GeneratePrivateKey()
+	require.NoError(err)
+	edPub := edKey.PublicKey()
+	edSig := ed25519.Sign(msg, edKey)
+	ok, err := h.VerifyEd25519(msg, edPub[:], edSig[:])
+	require.NoError(err)
+	require.True(ok)
+	ok, err = h.VerifyEd25519([]byte("abd"), edPub[:], edSig[:])
+	require.NoError(err)
+	require.False(ok)
+	ok, err = h.VerifyEd25519(msg, edPub[:1], edSig[:])
+	require.NoError(err)
+	require.False(ok)
+
+	secpKey, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	hash, err := h.Keccak256(msg)
+	require.NoError(err)
+	secpSig, err := secpKey.SignHash(hash)
+	require.NoError(err)
+	ok, err = h.VerifySecp256k1(hash, secpKey.PublicKey().Bytes(), secpSig)
+	require.NoError(err)
+	require.True(ok)
+	other, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	ok, err = h.VerifySecp256k1(hash, other.PublicKey().Bytes(), secpSig)
+	require.NoError(err)
+	require.False(ok)
+
+	blsKey, err := bls.GeneratePrivateKey()
+	require.NoError(err)
+	blsPub := bls.PublicKeyToBytes(bls.PublicFromPrivateKey(blsKey))
+	blsSig := bls.SignatureToBytes(bls.Sign(msg, blsKey))
+	ok, err = h.VerifyBLS(msg, blsPub, blsSig)
+	require.NoError(err)
+	require.True(ok)
+	ok, err = h.VerifyBLS(msg, blsPub, edSig[:])
+	require.NoError(err)
+	require.False(ok)
+
+	// Every call is charged, whether or not the signature verifies
+	costs := DefaultHostCryptoCosts
+	require.Equal(2*costs.HashUnits+2*costs.HashKiBUnits+costs.HashUnits+
+		3*costs.Ed25519Units+2*costs.Secp256k1Units+2*costs.BLSUnits, h.Used())
+
+	// Calls fail once the budget is spent
+	h = NewHostCrypto()
+	for range consts.MaxHostCryptoUnits / costs.BLSUnits {
+		_, err = h.VerifyBLS(msg, blsPub, blsSig)
+		require.NoError(err)
+	}
+	_, err = h.SHA256(msg)
+	require.ErrorIs(err, ErrHostUnitsExhausted)
+	require.Equal(uint64(consts.MaxHostCryptoUnits), h.Used())
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrDuplicateEvent        = errors.New("idempotency key already used")
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+)
+
+// claimIdempotencyKey remembers the event's idempotency key for
+// [consts.IdempotencyWindow] from [now], in unix seconds, replacing an
+// expired record of it.
+func (a *SendEventAction) claimIdempotencyKey(ctx context.Context, mu state.Mutable, eventID ids.ID, now uint64) error {
+	if err := a.checkIdempotencyKey(ctx, mu, now); err != nil {
+		return err
+	}
+	return storage.SetIdempotencyRecord(ctx, mu, a.IDTo, a.Sender, a.IdempotencyKey, &storage.IdempotencyRecord{
+		EventID: eventID,
+		Expiry:  now + consts.IdempotencyWindow,
+	})
+}
+
+// checkIdempotencyKey checks no event from the same sender to the same
+// object used the event's idempotency key within the window at [now].
+func (a *SendEventAction) checkIdempotencyKey(ctx context.Context, im state.Immutable, now uint64) error {
+	if len(a.IdempotencyKey) > consts.MaxIdempotencyKeySize {
+		return fmt.Errorf("%w: %d bytes", ErrInvalidIdempotencyKey, len(a.IdempotencyKey))
+	}
+	record, err := storage.GetIdempotencyRecord(ctx, im, a.IDTo, a.Sender, a.IdempotencyKey)
+	if err != nil || record == nil {
+		return err
+	}
+	if now < record.Expiry {
+		return fmt.Errorf("%w: by event %s until %d", ErrDuplicateEvent, record.EventID, record.Expiry)
+	}
+	return nil
+}
@@ -0,0 +1,746 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/rhombus-tech/hypersdk/x/contracts/runtime/events"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// JSON conventions: every byte field, including fixed size keys and
+// signatures, is a 0x-prefixed lowercase hex string. Empty byte fields
+// encode as "0x". Events are hex strings of their runtime encoding and
+// state update values are hex strings keyed by the raw state key.
+
+var ErrInvalidHex = errors.New("invalid hex string")
+
+type hexBytes []byte
+
+func (h hexBytes) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(h)), nil
+}
+
+func (h *hexBytes) UnmarshalText(text []byte) error {
+	s := string(text)
+	if !strings.HasPrefix(s, "0x") {
+		return ErrInvalidHex
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return ErrInvalidHex
+	}
+	*h = b
+	return nil
+}
+
+func toHexMap(m map[string][]byte) map[string]hexBytes {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]hexBytes, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func fromHexMap(m map[string]hexBytes) map[string][]byte {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string][]byte, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func (a *CreateObjectAction) MarshalJSON() ([]byte, error) {
+	type alias CreateObjectAction
+	return json.Marshal(&struct {
+		*alias
+		Code    hexBytes `json:"code"`
+		Storage hexBytes `json:"storage"`
+	}{(*alias)(a), a.Code, a.Storage})
+}
+
+func (a *CreateObjectAction) UnmarshalJSON(b []byte) error {
+	type alias CreateObjectAction
+	aux := &struct {
+		*alias
+		Code    hexBytes `json:"code"`
+		Storage hexBytes `json:"storage"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	a.Code, a.Storage = aux.Code, aux.Storage
+	return nil
+}
+
+func (a *SendEventAction) MarshalJSON() ([]byte, error) {
+	type alias SendEventAction
+	return json.Marshal(&struct {
+		*alias
+		Parameters hexBytes `json:"parameters"`
+	}{(*alias)(a), a.Parameters})
+}
+
+func (a *SendEventAction) UnmarshalJSON(b []byte) error {
+	type alias SendEventAction
+	aux := &struct {
+		*alias
+		Parameters hexBytes `json:"parameters"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	a.Parameters = aux.Parameters
+	return nil
+}
+
+type teeExecResultJSON struct {
+	ContractAddr hexBytes            `json:"contract_addr"`
+	Events       []hexBytes          `json:"events"`
+	StateUpdates map[string]hexBytes `json:"state_updates"`
+	StateRefs    map[string]hexBytes `json:"state_refs,omitempty"`
+	PreStateRoot hexBytes            `json:"pre_state_root,omitempty"`
+	StateRoot    hexBytes            `json:"state_root,omitempty"`
+	Reads        []ObjectRead        `json:"reads,omitempty"`
+	Calls        []CallFrame         `json:"calls,omitempty"`
+	Usage        ResourceUsage       `json:"usage"`
+	Exhausted    Resource            `json:"exhausted,omitempty"`
+	Logs         []logJSON           `json:"logs,omitempty"`
+	Failed       bool                `json:"failed,omitempty"`
+}
+
+type logJSON struct {
+	Topics []hexBytes `json:"topics"`
+	Data   hexBytes   `json:"data"`
+}
+
+func (r *TEEExecResult) MarshalJSON() ([]byte, error) {
+	out := teeExecResultJSON{
+		ContractAddr: r.ContractAddr,
+		Events:       make([]hexBytes, len(r.Events)),
+		StateUpdates: toHexMap(r.StateUpdates),
+		Reads:        r.Reads,
+		Calls:        r.Calls,
+		Usage:        r.Usage,
+		Exhausted:    r.Exhausted,
+		Failed:       r.Failed,
+	}
+	for _, log := range r.Logs {
+		l := logJSON{Topics: make([]hexBytes, len(log.Topics)), Data: log.Data}
+		for i := range log.Topics {
+			l.Topics[i] = log.Topics[i][:]
+		}
+		out.Logs = append(out.Logs, l)
+	}
+	if r.PreStateRoot != ids.Empty {
+		out.PreStateRoot = r.PreStateRoot[:]
+	}
+	if r.StateRoot != ids.Empty {
+		out.StateRoot = r.StateRoot[:]
+	}
+	if len(r.StateRefs) > 0 {
+		out.StateRefs = make(map[string]hexBytes, len(r.StateRefs))
+		for key, hash := range r.StateRefs {
+			out.StateRefs[key] = hash[:]
+		}
+	}
+	for i, event := range r.Events {
+		eventBytes, err := event.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		out.Events[i] = eventBytes
+	}
+	return json.Marshal(&out)
+}
+
+func (r *TEEExecResult) UnmarshalJSON(b []byte) error {
+	var in teeExecResultJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	r.ContractAddr = in.ContractAddr
+	r.StateUpdates = fromHexMap(in.StateUpdates)
+	r.Reads, r.Calls = in.Reads, in.Calls
+	r.Usage, r.Exhausted = in.Usage, in.Exhausted
+	r.Failed = in.Failed
+	r.Logs = nil
+	for _, l := range in.Logs {
+		log := storage.Log{Topics: make([]ids.ID, len(l.Topics)), Data: l.Data}
+		for i, raw := range l.Topics {
+			topic, err := ids.ToID(raw)
+			if err != nil {
+				return ErrInvalidHex
+			}
+			log.Topics[i] = topic
+		}
+		r.Logs = append(r.Logs, log)
+	}
+	r.PreStateRoot, r.StateRoot = ids.Empty, ids.Empty
+	for _, root := range []struct {
+		raw hexBytes
+		dst *ids.ID
+	}{{in.PreStateRoot, &r.PreStateRoot}, {in.StateRoot, &r.StateRoot}} {
+		if len(root.raw) == 0 {
+			continue
+		}
+		id, err := ids.ToID(root.raw)
+		if err != nil {
+			return ErrInvalidHex
+		}
+		*root.dst = id
+	}
+	r.StateRefs = nil
+	if len(in.StateRefs) > 0 {
+		r.StateRefs = make(map[string]ids.ID, len(in.StateRefs))
+		for key, raw := range in.StateRefs {
+			hash, err := ids.ToID(raw)
+			if err != nil {
+				return ErrInvalidHex
+			}
+			r.StateRefs[key] = hash
+		}
+	}
+	r.Events = make([]events.Event, len(in.Events))
+	for i, raw := range in.Events {
+		if err := r.Events[i].Unmarshal(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ObjectRead) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Object    string   `json:"object"`
+		Key       hexBytes `json:"key"`
+		ValueHash hexBytes `json:"value_hash"`
+	}{r.Object, r.Key, r.ValueHash[:]})
+}
+
+func (r *ObjectRead) UnmarshalJSON(b []byte) error {
+	var in struct {
+		Object    string   `json:"object"`
+		Key       hexBytes `json:"key"`
+		ValueHash hexBytes `json:"value_hash"`
+	}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	hash, err := ids.ToID(in.ValueHash)
+	if err != nil {
+		return ErrInvalidHex
+	}
+	r.Object, r.Key, r.ValueHash = in.Object, in.Key, hash
+	return nil
+}
+
+func (t *TEEExecAction) MarshalJSON() ([]byte, error) {
+	type alias TEEExecAction
+	return json.Marshal(&struct {
+		*alias
+		TxData  hexBytes `json:"tx_data"`
+		UserSig hexBytes `json:"user_sig"`
+	}{(*alias)(t), t.TxData, t.UserSig})
+}
+
+func (t *TEEExecAction) UnmarshalJSON(b []byte) error {
+	type alias TEEExecAction
+	aux := &struct {
+		*alias
+		TxData  hexBytes `json:"tx_data"`
+		UserSig hexBytes `json:"user_sig"`
+	}{alias: (*alias)(t)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	t.TxData, t.UserSig = aux.TxData, aux.UserSig
+	return nil
+}
+
+func (cv *ContractVerification) MarshalJSON() ([]byte, error) {
+	type alias ContractVerification
+	return json.Marshal(&struct {
+		*alias
+		ContractCode     hexBytes `json:"contract_code"`
+		Signature        hexBytes `json:"signature"`
+		PublicKey        hexBytes `json:"public_key"`
+		ExpectedChecksum hexBytes `json:"expected_checksum"`
+	}{(*alias)(cv), cv.ContractCode, cv.Signature, cv.PublicKey, cv.ExpectedChecksum})
+}
+
+func (cv *ContractVerification) UnmarshalJSON(b []byte) error {
+	type alias ContractVerification
+	aux := &struct {
+		*alias
+		ContractCode     hexBytes `json:"contract_code"`
+		Signature        hexBytes `json:"signature"`
+		PublicKey        hexBytes `json:"public_key"`
+		ExpectedChecksum hexBytes `json:"expected_checksum"`
+	}{alias: (*alias)(cv)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	cv.ContractCode, cv.Signature, cv.PublicKey, cv.ExpectedChecksum = aux.ContractCode, aux.Signature, aux.PublicKey, aux.ExpectedChecksum
+	return nil
+}
+
+func (r *ContractVerificationResult) MarshalJSON() ([]byte, error) {
+	type alias ContractVerificationResult
+	return json.Marshal(&struct {
+		*alias
+		ExecutionResults hexBytes `json:"execution_results"`
+		Checksum         hexBytes `json:"checksum"`
+	}{(*alias)(r), r.ExecutionResults, r.Checksum})
+}
+
+func (r *ContractVerificationResult) UnmarshalJSON(b []byte) error {
+	type alias ContractVerificationResult
+	aux := &struct {
+		*alias
+		ExecutionResults hexBytes `json:"execution_results"`
+		Checksum         hexBytes `json:"checksum"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.ExecutionResults, r.Checksum = aux.ExecutionResults, aux.Checksum
+	return nil
+}
+
+func (p *ProposeAction) MarshalJSON() ([]byte, error) {
+	type alias ProposeAction
+	return json.Marshal(&struct {
+		*alias
+		Value hexBytes `json:"value"`
+	}{(*alias)(p), p.Value})
+}
+
+func (p *ProposeAction) UnmarshalJSON(b []byte) error {
+	type alias ProposeAction
+	aux := &struct {
+		*alias
+		Value hexBytes `json:"value"`
+	}{alias: (*alias)(p)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	p.Value = aux.Value
+	return nil
+}
+
+func (s *AdminSignature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		PublicKey hexBytes `json:"public_key"`
+		Signature hexBytes `json:"signature"`
+	}{s.PublicKey[:], s.Signature[:]})
+}
+
+func (s *AdminSignature) UnmarshalJSON(b []byte) error {
+	var aux struct {
+		PublicKey hexBytes `json:"public_key"`
+		Signature hexBytes `json:"signature"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if len(aux.PublicKey) != ed25519.PublicKeyLen || len(aux.Signature) != ed25519.SignatureLen {
+		return ErrInvalidHex
+	}
+	copy(s.PublicKey[:], aux.PublicKey)
+	copy(s.Signature[:], aux.Signature)
+	return nil
+}
+
+func (t *Transfer) MarshalJSON() ([]byte, error) {
+	type alias Transfer
+	return json.Marshal(&struct {
+		*alias
+		Memo hexBytes `json:"memo"`
+	}{(*alias)(t), t.Memo})
+}
+
+func (t *Transfer) UnmarshalJSON(b []byte) error {
+	type alias Transfer
+	aux := &struct {
+		*alias
+		Memo hexBytes `json:"memo"`
+	}{alias: (*alias)(t)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	t.Memo = aux.Memo
+	return nil
+}
+
+func (a *AppendChunkAction) MarshalJSON() ([]byte, error) {
+	type alias AppendChunkAction
+	return json.Marshal(&struct {
+		*alias
+		Data hexBytes `json:"data"`
+	}{(*alias)(a), a.Data})
+}
+
+func (a *AppendChunkAction) UnmarshalJSON(b []byte) error {
+	type alias AppendChunkAction
+	aux := &struct {
+		*alias
+		Data hexBytes `json:"data"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	a.Data = aux.Data
+	return nil
+}
+
+func (a *CommitObjectAction) MarshalJSON() ([]byte, error) {
+	type alias CommitObjectAction
+	return json.Marshal(&struct {
+		*alias
+		Storage hexBytes `json:"storage"`
+	}{(*alias)(a), a.Storage})
+}
+
+func (a *CommitObjectAction) UnmarshalJSON(b []byte) error {
+	type alias CommitObjectAction
+	aux := &struct {
+		*alias
+		Storage hexBytes `json:"storage"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	a.Storage = aux.Storage
+	return nil
+}
+
+func (r *RegisterNitroEnclaveAction) MarshalJSON() ([]byte, error) {
+	type alias RegisterNitroEnclaveAction
+	return json.Marshal(&struct {
+		*alias
+		Document hexBytes `json:"document"`
+	}{(*alias)(r), r.Document})
+}
+
+func (r *RegisterNitroEnclaveAction) UnmarshalJSON(b []byte) error {
+	type alias RegisterNitroEnclaveAction
+	aux := &struct {
+		*alias
+		Document hexBytes `json:"document"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.Document = aux.Document
+	return nil
+}
+
+func (r *RegisterNitroEnclaveResult) MarshalJSON() ([]byte, error) {
+	type alias RegisterNitroEnclaveResult
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{(*alias)(r), r.EnclaveID})
+}
+
+func (r *RegisterNitroEnclaveResult) UnmarshalJSON(b []byte) error {
+	type alias RegisterNitroEnclaveResult
+	aux := &struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.EnclaveID = aux.EnclaveID
+	return nil
+}
+
+func (r *RegisterCCAEnclaveAction) MarshalJSON() ([]byte, error) {
+	type alias RegisterCCAEnclaveAction
+	return json.Marshal(&struct {
+		*alias
+		PublicKey hexBytes `json:"public_key"`
+		Token     hexBytes `json:"token"`
+	}{(*alias)(r), r.PublicKey, r.Token})
+}
+
+func (r *RegisterCCAEnclaveAction) UnmarshalJSON(b []byte) error {
+	type alias RegisterCCAEnclaveAction
+	aux := &struct {
+		*alias
+		PublicKey hexBytes `json:"public_key"`
+		Token     hexBytes `json:"token"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.PublicKey, r.Token = aux.PublicKey, aux.Token
+	return nil
+}
+
+func (r *RegisterCCAEnclaveResult) MarshalJSON() ([]byte, error) {
+	type alias RegisterCCAEnclaveResult
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID        hexBytes `json:"enclave_id"`
+		ImplementationID hexBytes `json:"implementation_id"`
+	}{(*alias)(r), r.EnclaveID, r.ImplementationID})
+}
+
+func (r *RegisterCCAEnclaveResult) UnmarshalJSON(b []byte) error {
+	type alias RegisterCCAEnclaveResult
+	aux := &struct {
+		*alias
+		EnclaveID        hexBytes `json:"enclave_id"`
+		ImplementationID hexBytes `json:"implementation_id"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.EnclaveID, r.ImplementationID = aux.EnclaveID, aux.ImplementationID
+	return nil
+}
+
+func (r *PublishCollateralResult) MarshalJSON() ([]byte, error) {
+	type alias PublishCollateralResult
+	return json.Marshal(&struct {
+		*alias
+		FMSPC hexBytes `json:"fmspc"`
+	}{(*alias)(r), r.FMSPC})
+}
+
+func (r *PublishCollateralResult) UnmarshalJSON(b []byte) error {
+	type alias PublishCollateralResult
+	aux := &struct {
+		*alias
+		FMSPC hexBytes `json:"fmspc"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.FMSPC = aux.FMSPC
+	return nil
+}
+
+func (r *ReattestEnclaveAction) MarshalJSON() ([]byte, error) {
+	type alias ReattestEnclaveAction
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+		Evidence  hexBytes `json:"evidence"`
+	}{(*alias)(r), r.EnclaveID, r.Evidence})
+}
+
+func (r *ReattestEnclaveAction) UnmarshalJSON(b []byte) error {
+	type alias ReattestEnclaveAction
+	aux := &struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+		Evidence  hexBytes `json:"evidence"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.EnclaveID, r.Evidence = aux.EnclaveID, aux.Evidence
+	return nil
+}
+
+func (r *ReattestEnclaveResult) MarshalJSON() ([]byte, error) {
+	type alias ReattestEnclaveResult
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{(*alias)(r), r.EnclaveID})
+}
+
+func (r *ReattestEnclaveResult) UnmarshalJSON(b []byte) error {
+	type alias ReattestEnclaveResult
+	aux := &struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.EnclaveID = aux.EnclaveID
+	return nil
+}
+
+func (q *QueueRequestAction) MarshalJSON() ([]byte, error) {
+	type alias QueueRequestAction
+	return json.Marshal(&struct {
+		*alias
+		TxData hexBytes `json:"tx_data"`
+	}{(*alias)(q), q.TxData})
+}
+
+func (q *QueueRequestAction) UnmarshalJSON(b []byte) error {
+	type alias QueueRequestAction
+	aux := &struct {
+		*alias
+		TxData hexBytes `json:"tx_data"`
+	}{alias: (*alias)(q)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	q.TxData = aux.TxData
+	return nil
+}
+
+func (e *WitnessEntry) MarshalJSON() ([]byte, error) {
+	type alias WitnessEntry
+	siblings := make([]hexBytes, len(e.Siblings))
+	for i, sibling := range e.Siblings {
+		siblings[i] = sibling[:]
+	}
+	return json.Marshal(&struct {
+		*alias
+		Key      hexBytes   `json:"key"`
+		Value    hexBytes   `json:"value"`
+		Siblings []hexBytes `json:"siblings"`
+	}{(*alias)(e), e.Key, e.Value, siblings})
+}
+
+func (e *WitnessEntry) UnmarshalJSON(b []byte) error {
+	type alias WitnessEntry
+	aux := &struct {
+		*alias
+		Key      hexBytes   `json:"key"`
+		Value    hexBytes   `json:"value"`
+		Siblings []hexBytes `json:"siblings"`
+	}{alias: (*alias)(e)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	e.Key, e.Value = aux.Key, aux.Value
+	e.Siblings = make([]ids.ID, len(aux.Siblings))
+	for i, raw := range aux.Siblings {
+		id, err := ids.ToID(raw)
+		if err != nil {
+			return ErrInvalidHex
+		}
+		e.Siblings[i] = id
+	}
+	return nil
+}
+
+func (a *PipelineAction) MarshalJSON() ([]byte, error) {
+	type alias PipelineAction
+	return json.Marshal(&struct {
+		*alias
+		Parameters hexBytes `json:"parameters"`
+	}{(*alias)(a), a.Parameters})
+}
+
+func (a *PipelineAction) UnmarshalJSON(b []byte) error {
+	type alias PipelineAction
+	aux := &struct {
+		*alias
+		Parameters hexBytes `json:"parameters"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	a.Parameters = aux.Parameters
+	return nil
+}
+
+func (p *PruneEnclaveAction) MarshalJSON() ([]byte, error) {
+	type alias PruneEnclaveAction
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{(*alias)(p), p.EnclaveID})
+}
+
+func (p *PruneEnclaveAction) UnmarshalJSON(b []byte) error {
+	type alias PruneEnclaveAction
+	aux := &struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{alias: (*alias)(p)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	p.EnclaveID = aux.EnclaveID
+	return nil
+}
+
+func (r *PruneEnclaveResult) MarshalJSON() ([]byte, error) {
+	type alias PruneEnclaveResult
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{(*alias)(r), r.EnclaveID})
+}
+
+func (r *PruneEnclaveResult) UnmarshalJSON(b []byte) error {
+	type alias PruneEnclaveResult
+	aux := &struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.EnclaveID = aux.EnclaveID
+	return nil
+}
+
+func (p *PreemptEventAction) MarshalJSON() ([]byte, error) {
+	type alias PreemptEventAction
+	return json.Marshal(&struct {
+		*alias
+		Key hexBytes `json:"key"`
+	}{(*alias)(p), p.Key})
+}
+
+func (p *PreemptEventAction) UnmarshalJSON(b []byte) error {
+	type alias PreemptEventAction
+	aux := &struct {
+		*alias
+		Key hexBytes `json:"key"`
+	}{alias: (*alias)(p)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	p.Key = aux.Key
+	return nil
+}
+
+func (r *PreemptEventResult) MarshalJSON() ([]byte, error) {
+	type alias PreemptEventResult
+	return json.Marshal(&struct {
+		*alias
+		Key hexBytes `json:"key"`
+	}{(*alias)(r), r.Key})
+}
+
+func (r *PreemptEventResult) UnmarshalJSON(b []byte) error {
+	type alias PreemptEventResult
+	aux := &struct {
+		*alias
+		Key hexBytes `json:"key"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	r.Key = aux.Key
+	return nil
+}
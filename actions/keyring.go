@@ -0,0 +1,415 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrKeyringPair    = errors.New("keyring change needs one signature from each enclave of a pair")
+	ErrInvalidAppKey  = errors.New("invalid application key")
+	ErrAppKeyExists   = errors.New("application key already active")
+	ErrAppKeyNotFound = errors.New("application key not found")
+	ErrAppKeyVersion  = errors.New("application key version out of order")
+	ErrAppKeyRevoked  = errors.New("application key revoked")
+	ErrKeyringFull    = errors.New("region keyring full")
+
+	_ chain.Action = (*PublishAppKeyAction)(nil)
+	_ chain.Action = (*RotateAppKeyAction)(nil)
+	_ chain.Action = (*RevokeAppKeyAction)(nil)
+)
+
+// keyringDomain separates keyring signatures from the other statements an
+// enclave key signs.
+const keyringDomain = "shuttlevm/keyring"
+
+// KeyringDigest is what both enclaves of a pair sign to apply keyring
+// change [op], the type ID of the action, to [version] of [name] in
+// [regionID]. [publicKey] is the new key, empty for a revocation.
+func KeyringDigest(op uint8, regionID, name string, version uint64, purpose uint8, publicKey []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(keyringDomain))
+	h.Write([]byte{op})
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(name))))
+	h.Write([]byte(name))
+	h.Write(binary.BigEndian.AppendUint64(nil, version))
+	h.Write([]byte{purpose})
+	h.Write(publicKey)
+	return h.Sum(nil)
+}
+
+// PublishAppKeyAction publishes [PublicKey] as [Version] of application key
+// [Name] in [RegionID]. Versions start at 1; a name whose latest version
+// was revoked may be published again at the next version. Both enclaves of
+// a pair of the region sign, ordered by enclave ID, so no single enclave
+// can put a key in the keyring.
+type PublishAppKeyAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	Name      string `serialize:"true" json:"name"`
+	Purpose   uint8  `serialize:"true" json:"purpose"`
+	Version   uint64 `serialize:"true" json:"version"`
+	PublicKey []byte `serialize:"true" json:"public_key"`
+	// Attestations sign [KeyringDigest]
+	Attestations []attestation.Attestation `serialize:"true" json:"attestations"`
+}
+
+func (*PublishAppKeyAction) GetTypeID() uint8 {
+	return consts.PublishAppKeyID
+}
+
+func (p *PublishAppKeyAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(keyringKeys(p.RegionID, p.Attestations), actor, p)
+}
+
+func (p *PublishAppKeyAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.PublishAppKeyID, p.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, p); err != nil {
+		return nil, err
+	}
+
+	if len(p.Name) == 0 || len(p.Name) > consts.MaxKeyNameSize {
+		return nil, fmt.Errorf("%w: name size %d", ErrInvalidAppKey, len(p.Name))
+	}
+	if err := checkAppKey(p.Purpose, p.PublicKey); err != nil {
+		return nil, err
+	}
+	keyring, err := storage.GetKeyring(ctx, mu, p.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	next := uint64(1)
+	if latest := keyring.Latest(p.Name); latest != nil {
+		if latest.Status != storage.AppKeyRevoked {
+			return nil, ErrAppKeyExists
+		}
+		next = latest.Version + 1
+	}
+	if p.Version != next {
+		return nil, fmt.Errorf("%w: (expected=%d, got=%d)", ErrAppKeyVersion, next, p.Version)
+	}
+	digest := KeyringDigest(consts.PublishAppKeyID, p.RegionID, p.Name, p.Version, p.Purpose, p.PublicKey)
+	if err := verifyKeyringPair(ctx, mu, timestamp, p.RegionID, digest, p.Attestations); err != nil {
+		return nil, err
+	}
+
+	keyring.Keys = append(keyring.Keys, storage.AppKey{
+		Name:        p.Name,
+		Purpose:     p.Purpose,
+		Version:     p.Version,
+		PublicKey:   p.PublicKey,
+		Status:      storage.AppKeyActive,
+		PublishedAt: uint64(timestamp / 1000),
+	})
+	if err := setKeyring(ctx, mu, p.RegionID, keyring, p.Name); err != nil {
+		return nil, err
+	}
+	return &PublishAppKeyResult{
+		RegionID: p.RegionID,
+		Name:     p.Name,
+		Version:  p.Version,
+	}, nil
+}
+
+func (p *PublishAppKeyAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits +
+		uint64(len(p.Attestations))*schedule.AttestationUnits +
+		schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(p).QuoteBytes)
+}
+
+func (*PublishAppKeyAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type PublishAppKeyResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Name     string `serialize:"true" json:"name"`
+	Version  uint64 `serialize:"true" json:"version"`
+}
+
+func (*PublishAppKeyResult) GetTypeID() uint8 {
+	return consts.PublishAppKeyResultID
+}
+
+// RotateAppKeyAction replaces the active version of [Name] with
+// [PublicKey] as [Version], the next version. The replaced version is
+// retired: it no longer encrypts, but still verifies what it signed.
+type RotateAppKeyAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	Name      string `serialize:"true" json:"name"`
+	Version   uint64 `serialize:"true" json:"version"`
+	PublicKey []byte `serialize:"true" json:"public_key"`
+	// Attestations sign [KeyringDigest] with the purpose of the active
+	// version
+	Attestations []attestation.Attestation `serialize:"true" json:"attestations"`
+}
+
+func (*RotateAppKeyAction) GetTypeID() uint8 {
+	return consts.RotateAppKeyID
+}
+
+func (r *RotateAppKeyAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(keyringKeys(r.RegionID, r.Attestations), actor, r)
+}
+
+func (r *RotateAppKeyAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RotateAppKeyID, r.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	keyring, err := storage.GetKeyring(ctx, mu, r.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	latest := keyring.Latest(r.Name)
+	if latest == nil {
+		return nil, ErrAppKeyNotFound
+	}
+	if latest.Status == storage.AppKeyRevoked {
+		return nil, ErrAppKeyRevoked
+	}
+	if r.Version != latest.Version+1 {
+		return nil, fmt.Errorf("%w: (expected=%d, got=%d)", ErrAppKeyVersion, latest.Version+1, r.Version)
+	}
+	if err := checkAppKey(latest.Purpose, r.PublicKey); err != nil {
+		return nil, err
+	}
+	digest := KeyringDigest(consts.RotateAppKeyID, r.RegionID, r.Name, r.Version, latest.Purpose, r.PublicKey)
+	if err := verifyKeyringPair(ctx, mu, timestamp, r.RegionID, digest, r.Attestations); err != nil {
+		return nil, err
+	}
+
+	now := uint64(timestamp / 1000)
+	latest.Status = storage.AppKeyRetired
+	latest.ChangedAt = now
+	keyring.Keys = append(keyring.Keys, storage.AppKey{
+		Name:        r.Name,
+		Purpose:     latest.Purpose,
+		Version:     r.Version,
+		PublicKey:   r.PublicKey,
+		Status:      storage.AppKeyActive,
+		PublishedAt: now,
+	})
+	if err := setKeyring(ctx, mu, r.RegionID, keyring, r.Name); err != nil {
+		return nil, err
+	}
+	return &RotateAppKeyResult{
+		RegionID: r.RegionID,
+		Name:     r.Name,
+		Version:  r.Version,
+	}, nil
+}
+
+func (r *RotateAppKeyAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits +
+		uint64(len(r.Attestations))*schedule.AttestationUnits +
+		schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(r).QuoteBytes)
+}
+
+func (*RotateAppKeyAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type RotateAppKeyResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Name     string `serialize:"true" json:"name"`
+	Version  uint64 `serialize:"true" json:"version"`
+}
+
+func (*RotateAppKeyResult) GetTypeID() uint8 {
+	return consts.RotateAppKeyResultID
+}
+
+// RevokeAppKeyAction revokes [Version] of [Name], typically because it was
+// compromised. A revoked version neither encrypts nor verifies. Revoking
+// the active version leaves the name without one until it is published
+// again.
+type RevokeAppKeyAction struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Name     string `serialize:"true" json:"name"`
+	Version  uint64 `serialize:"true" json:"version"`
+	// Attestations sign [KeyringDigest] with the version's purpose and no
+	// key
+	Attestations []attestation.Attestation `serialize:"true" json:"attestations"`
+}
+
+func (*RevokeAppKeyAction) GetTypeID() uint8 {
+	return consts.RevokeAppKeyID
+}
+
+func (r *RevokeAppKeyAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(keyringKeys(r.RegionID, r.Attestations), actor, r)
+}
+
+func (r *RevokeAppKeyAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RevokeAppKeyID, r.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	keyring, err := storage.GetKeyring(ctx, mu, r.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	key := keyring.Version(r.Name, r.Version)
+	if key == nil {
+		return nil, fmt.Errorf("%w: %s/%d", ErrAppKeyNotFound, r.Name, r.Version)
+	}
+	if key.Status == storage.AppKeyRevoked {
+		return nil, ErrAppKeyRevoked
+	}
+	digest := KeyringDigest(consts.RevokeAppKeyID, r.RegionID, r.Name, r.Version, key.Purpose, nil)
+	if err := verifyKeyringPair(ctx, mu, timestamp, r.RegionID, digest, r.Attestations); err != nil {
+		return nil, err
+	}
+
+	key.Status = storage.AppKeyRevoked
+	key.ChangedAt = uint64(timestamp / 1000)
+	if err := storage.SetKeyring(ctx, mu, r.RegionID, keyring); err != nil {
+		return nil, err
+	}
+	return &RevokeAppKeyResult{
+		RegionID: r.RegionID,
+		Name:     r.Name,
+		Version:  r.Version,
+	}, nil
+}
+
+func (r *RevokeAppKeyAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits +
+		uint64(len(r.Attestations))*schedule.AttestationUnits +
+		schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(r).QuoteBytes)
+}
+
+func (*RevokeAppKeyAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type RevokeAppKeyResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Name     string `serialize:"true" json:"name"`
+	Version  uint64 `serialize:"true" json:"version"`
+}
+
+func (*RevokeAppKeyResult) GetTypeID() uint8 {
+	return consts.RevokeAppKeyResultID
+}
+
+// keyringKeys declares what a keyring change signed by [pair] in
+// [regionID] reads and writes.
+func keyringKeys(regionID string, pair []attestation.Attestation) state.Keys {
+	keys := state.Keys{
+		string(storage.RegionKey(regionID)):  state.Read,
+		string(storage.KeyringKey(regionID)): state.All,
+	}
+	for _, a := range pair {
+		keys[string(storage.EnclaveKey(regionID, a.EnclaveID))] = state.Read
+		keys[string(storage.EnclavePubKeyKey(regionID, a.EnclaveID))] = state.Read
+		keys[string(storage.EnclaveExpiryKey(regionID, a.EnclaveID))] = state.Read
+		addPlatformKeys(keys, regionID, a.EnclaveID)
+	}
+	return keys
+}
+
+// verifyKeyringPair checks [pair] is two signatures over [digest] by
+// distinct active enclaves of [regionID], ordered by enclave ID.
+func verifyKeyringPair(ctx context.Context, im state.Immutable, timestamp int64, regionID string, digest []byte, pair []attestation.Attestation) error {
+	if len(pair) != 2 || bytes.Compare(pair[0].EnclaveID, pair[1].EnclaveID) >= 0 {
+		return ErrKeyringPair
+	}
+	for i := range pair {
+		if err := verifySettlementSigner(ctx, im, timestamp, regionID, digest, &pair[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAppKey checks [publicKey] is a key of the kind [purpose] needs.
+func checkAppKey(purpose uint8, publicKey []byte) error {
+	switch purpose {
+	case storage.AppKeyEncryption:
+		if len(publicKey) != envelope.KeySize {
+			return fmt.Errorf("%w: %d byte encryption key", ErrInvalidAppKey, len(publicKey))
+		}
+	case storage.AppKeyVerification:
+		if len(publicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("%w: %d byte verification key", ErrInvalidAppKey, len(publicKey))
+		}
+	default:
+		return fmt.Errorf("%w: purpose %d", ErrInvalidAppKey, purpose)
+	}
+	return nil
+}
+
+// setKeyring stores [keyring] after adding a version of [name], dropping
+// versions of [name] beyond [consts.KeyringHistory].
+func setKeyring(ctx context.Context, mu state.Mutable, regionID string, keyring *storage.Keyring, name string) error {
+	drop := len(keyring.Named(name)) - consts.KeyringHistory
+	kept := keyring.Keys[:0]
+	for _, key := range keyring.Keys {
+		if key.Name == name && drop > 0 {
+			drop--
+			continue
+		}
+		kept = append(kept, key)
+	}
+	keyring.Keys = kept
+	if len(keyring.Keys) > consts.MaxKeyringKeys {
+		return ErrKeyringFull
+	}
+	return storage.SetKeyring(ctx, mu, regionID, keyring)
+}
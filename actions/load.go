@@ -0,0 +1,118 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/chain"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var ErrTooManyAttestations = errors.New("too many attestations")
+
+// VerificationLoad is the work an action asks validators to do before its
+// attestations can be trusted. Quotes are cheap to send and expensive to
+// verify, so it is charged and budgeted by size as well as by count.
+type VerificationLoad struct {
+	// Attestations counts enclave signatures, documents and evidence
+	Attestations int
+	Stamps       int
+	// QuoteBytes is the size of every attestation payload, stamps
+	// included
+	QuoteBytes int
+}
+
+func (l *VerificationLoad) add(a *attestation.Attestation) {
+	l.Attestations++
+	l.Stamps += len(a.Stamps)
+	l.QuoteBytes += len(a.EnclaveID) + len(a.Signature)
+	for _, stamp := range a.Stamps {
+		l.QuoteBytes += len(stamp.ServerID) + 8 + len(stamp.Signature)
+	}
+}
+
+func (l *VerificationLoad) addQuote(quote []byte) {
+	l.Attestations++
+	l.QuoteBytes += len(quote)
+}
+
+// Units prices [l] with [DefaultFeeSchedule].
+func (l VerificationLoad) Units() uint64 {
+	return uint64(l.Attestations)*DefaultFeeSchedule.AttestationUnits +
+		uint64(l.Stamps)*DefaultFeeSchedule.TimeStampUnits +
+		DefaultFeeSchedule.QuoteUnits(l.QuoteBytes)
+}
+
+// LoadOf returns the [VerificationLoad] of [action]. Actions without
+// attestations have none.
+func LoadOf(action chain.Action) VerificationLoad {
+	var load VerificationLoad
+	switch a := action.(type) {
+	case *TEEExecAction:
+		load.add(&a.Attestation)
+		if a.Peer != nil {
+			load.add(&a.Peer.Attestation)
+			// The pair's signatures are checked as one
+			if a.Attestation.Flags.Aggregated() {
+				load.Attestations--
+			}
+		}
+	case *PublishFeedAction:
+		load.add(&a.Attestation)
+	case *PublishRandomnessAction:
+		for i := range a.Shares {
+			load.add(&a.Shares[i].Attestation)
+		}
+	case *SettleRegionAction:
+		load.add(&a.Attestation)
+	case *ChallengeSettlementAction:
+		load.add(&a.Attestation)
+	case *SealStorageAction:
+		load.add(&a.Attestation)
+	case *ResealStorageAction:
+		load.add(&a.Attestation)
+	case *PublishAppKeyAction:
+		for i := range a.Attestations {
+			load.add(&a.Attestations[i])
+		}
+	case *RotateAppKeyAction:
+		for i := range a.Attestations {
+			load.add(&a.Attestations[i])
+		}
+	case *RevokeAppKeyAction:
+		for i := range a.Attestations {
+			load.add(&a.Attestations[i])
+		}
+	case *RegisterNitroEnclaveAction:
+		load.addQuote(a.Document)
+	case *RegisterCCAEnclaveAction:
+		load.addQuote(a.Token)
+	case *ReattestEnclaveAction:
+		load.addQuote(a.Evidence)
+	}
+	return load
+}
+
+// CheckTxLoad rejects the actions of one transaction if together they
+// carry more than [consts.MaxTxAttestations] attestations or
+// [consts.MaxTxTimeStamps] Roughtime stamps.
+func CheckTxLoad(txActions []chain.Action) error {
+	var total VerificationLoad
+	for _, action := range txActions {
+		load := LoadOf(action)
+		total.Attestations += load.Attestations
+		total.Stamps += load.Stamps
+	}
+	if total.Attestations > consts.MaxTxAttestations {
+		return fmt.Errorf("%w: %d", ErrTooManyAttestations, total.Attestations)
+	}
+	if total.Stamps > consts.MaxTxTimeStamps {
+		return fmt.Errorf("%w: %d", ErrTooManyTimeStamps, total.Stamps)
+	}
+	return nil
+}
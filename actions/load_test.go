@@ -0,0 +1,57 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/chain"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestVerificationLoad(t *testing.T) {
+	require := require.New(t)
+
+	stamped := func(stamps int) attestation.Attestation {
+		a := attestation.Attestation{EnclaveID: []byte("enclave"), Signature: make([]byte, 64)}
+		for range stamps {
+			a.Stamps = append(a.Stamps, attestation.Stamp{ServerID: "server", Signature: make([]byte, 64)})
+		}
+		return a
+	}
+
+	// Larger quotes cost more to verify and to send
+	small := &RegisterNitroEnclaveAction{Document: make([]byte, 1024)}
+	large := &RegisterNitroEnclaveAction{Document: bytes.Repeat([]byte{1}, 64*1024)}
+	require.Less(LoadOf(small).Units(), LoadOf(large).Units())
+	require.Less(small.ComputeUnits(nil), large.ComputeUnits(nil))
+
+	exec := &TEEExecAction{Attestation: stamped(3)}
+	require.Equal(VerificationLoad{Attestations: 1, Stamps: 3, QuoteBytes: 7 + 64 + 3*(6+8+64)}, LoadOf(exec))
+	exec.Peer = &PeerExecution{Attestation: stamped(3)}
+	require.Equal(2, LoadOf(exec).Attestations)
+	require.Zero(LoadOf(&StartUploadAction{}))
+
+	// Caps apply to the transaction, not to each action
+	var txActions []chain.Action
+	for range consts.MaxTxAttestations {
+		txActions = append(txActions, &PublishFeedAction{Attestation: stamped(1)})
+	}
+	require.NoError(CheckTxLoad(txActions))
+	require.ErrorIs(CheckTxLoad(append(txActions, &PublishFeedAction{Attestation: stamped(1)})), ErrTooManyAttestations)
+
+	stampHeavy := []chain.Action{
+		&PublishFeedAction{Attestation: stamped(consts.MaxTimeStampsCount)},
+		&PublishFeedAction{Attestation: stamped(consts.MaxTimeStampsCount)},
+		&PublishFeedAction{Attestation: stamped(consts.MaxTimeStampsCount)},
+		&PublishFeedAction{Attestation: stamped(consts.MaxTimeStampsCount)},
+	}
+	require.NoError(CheckTxLoad(stampHeavy))
+	require.ErrorIs(CheckTxLoad(append(stampHeavy, &PublishFeedAction{Attestation: stamped(1)})), ErrTooManyTimeStamps)
+}
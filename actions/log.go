@@ -0,0 +1,23 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+// execLogger returns the default logger tagged with the height read from
+// [im], the region and the enclave of an execution.
+func execLogger(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte) *vmlog.Logger {
+	log := vmlog.Default().WithRegion(regionID).WithEnclave(enclaveID)
+	if height, err := storage.GetHeight(ctx, im); err == nil {
+		log = log.WithHeight(height)
+	}
+	return log
+}
@@ -0,0 +1,270 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidName  = errors.New("invalid name")
+	ErrNameTaken    = errors.New("name registered to another owner")
+	ErrNameNotFound = errors.New("name not registered")
+	ErrNameExpired  = errors.New("name expired")
+	ErrNameOwner    = errors.New("actor does not own name")
+	ErrNameKind     = errors.New("name does not refer to this kind of target")
+	ErrNameTarget   = errors.New("name does not refer to the event's target")
+
+	_ chain.Action = (*RegisterNameAction)(nil)
+	_ chain.Action = (*TransferNameAction)(nil)
+)
+
+// ValidateName checks [name] is [consts.MinNameLen] to [consts.MaxNameLen]
+// lowercase letters, digits, '-' and '.', so names cannot be confused with
+// one another by case or by invisible characters.
+func ValidateName(name string) error {
+	if len(name) < consts.MinNameLen || len(name) > consts.MaxNameLen {
+		return fmt.Errorf("%w: length %d", ErrInvalidName, len(name))
+	}
+	for _, c := range []byte(name) {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '.' {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+	return nil
+}
+
+// checkResolves returns the target of [r], the record of [name], if it
+// resolves to a target of [kind] at [now], in unix seconds.
+func checkResolves(name string, r *storage.NameRecord, kind uint8, now uint64) (string, error) {
+	if r == nil {
+		return "", fmt.Errorf("%w: %s", ErrNameNotFound, name)
+	}
+	if now >= r.ExpiresAt {
+		return "", fmt.Errorf("%w: %s", ErrNameExpired, name)
+	}
+	if r.Kind != kind {
+		return "", fmt.Errorf("%w: %s", ErrNameKind, name)
+	}
+	return r.Target, nil
+}
+
+// ResolveName returns the ID of the region or object, per [kind], that
+// [name] refers to at block time [timestamp].
+func ResolveName(ctx context.Context, im state.Immutable, name string, kind uint8, timestamp int64) (string, error) {
+	r, err := storage.GetName(ctx, im, name)
+	if err != nil {
+		return "", err
+	}
+	return checkResolves(name, r, kind, uint64(timestamp/1000))
+}
+
+// checkTarget checks the event's ToName, if set, refers to its target
+// IDTo at block time [timestamp]. The target is named by ID as well, so the
+// keys of the event are known before it executes.
+func (a *SendEventAction) checkTarget(ctx context.Context, im state.Immutable, timestamp int64) error {
+	if a.ToName == "" {
+		return nil
+	}
+	target, err := ResolveName(ctx, im, a.ToName, storage.NameObject, timestamp)
+	if err != nil {
+		return err
+	}
+	if target != a.IDTo {
+		return fmt.Errorf("%w: %s", ErrNameTarget, a.ToName)
+	}
+	return nil
+}
+
+// RegisterNameAction maps [Name] to the region or object [Target], per
+// [Kind], for [consts.NameTerm]. A name that is free, or lapsed beyond its
+// grace period, goes to the actor. Its owner registering it again renews
+// it for another term, from its expiry if it has not lapsed, and may
+// change its target.
+type RegisterNameAction struct {
+	Name   string `serialize:"true" json:"name"`
+	Kind   uint8  `serialize:"true" json:"kind"`
+	Target string `serialize:"true" json:"target"`
+}
+
+func (*RegisterNameAction) GetTypeID() uint8 {
+	return consts.RegisterNameID
+}
+
+func (r *RegisterNameAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.NameKey(r.Name)): state.All,
+	}
+	switch r.Kind {
+	case storage.NameRegion:
+		keys[string(storage.RegionKey(r.Target))] = state.Read
+	case storage.NameObject:
+		keys[string(storage.ObjectKey(r.Target))] = state.Read
+	}
+	return addGateKeys(keys, actor, r)
+}
+
+func (r *RegisterNameAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RegisterNameID, "", "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateName(r.Name); err != nil {
+		return nil, err
+	}
+	switch r.Kind {
+	case storage.NameRegion:
+		_, exists, err := storage.GetRegion(ctx, mu, r.Target)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrRegionNotFound
+		}
+	case storage.NameObject:
+		obj, err := storage.GetObject(ctx, mu, r.Target)
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			return nil, ErrObjectNotFound
+		}
+	default:
+		return nil, fmt.Errorf("%w: kind %d", ErrInvalidName, r.Kind)
+	}
+
+	now := uint64(timestamp / 1000)
+	record, err := storage.GetName(ctx, mu, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := now + consts.NameTerm
+	if record != nil && now < record.ExpiresAt+consts.NameGracePeriod {
+		if record.Owner != actor {
+			return nil, ErrNameTaken
+		}
+		if now < record.ExpiresAt {
+			expiresAt = record.ExpiresAt + consts.NameTerm
+		}
+	}
+	if err := storage.SetName(ctx, mu, r.Name, &storage.NameRecord{
+		Owner:     actor,
+		Kind:      r.Kind,
+		Target:    r.Target,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, err
+	}
+	return &RegisterNameResult{Name: r.Name, Owner: actor, ExpiresAt: expiresAt}, nil
+}
+
+func (*RegisterNameAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.StateUpdateUnits
+}
+
+func (*RegisterNameAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type RegisterNameResult struct {
+	Name      string        `serialize:"true" json:"name"`
+	Owner     codec.Address `serialize:"true" json:"owner"`
+	ExpiresAt uint64        `serialize:"true" json:"expires_at"`
+}
+
+func (*RegisterNameResult) GetTypeID() uint8 {
+	return consts.RegisterNameResultID
+}
+
+// TransferNameAction gives [Name], with its target and expiry, to [To].
+// Only the owner of a name that has not lapsed may transfer it.
+type TransferNameAction struct {
+	Name string        `serialize:"true" json:"name"`
+	To   codec.Address `serialize:"true" json:"to"`
+}
+
+func (*TransferNameAction) GetTypeID() uint8 {
+	return consts.TransferNameID
+}
+
+func (t *TransferNameAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.NameKey(t.Name)): state.Read | state.Write,
+	}, actor, t)
+}
+
+func (t *TransferNameAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.TransferNameID, "", "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, t); err != nil {
+		return nil, err
+	}
+
+	record, err := storage.GetName(ctx, mu, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNameNotFound, t.Name)
+	}
+	if record.Owner != actor {
+		return nil, ErrNameOwner
+	}
+	if uint64(timestamp/1000) >= record.ExpiresAt {
+		return nil, fmt.Errorf("%w: %s", ErrNameExpired, t.Name)
+	}
+	record.Owner = t.To
+	if err := storage.SetName(ctx, mu, t.Name, record); err != nil {
+		return nil, err
+	}
+	return &TransferNameResult{Name: t.Name, Owner: t.To, ExpiresAt: record.ExpiresAt}, nil
+}
+
+func (*TransferNameAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.StateUpdateUnits
+}
+
+func (*TransferNameAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type TransferNameResult struct {
+	Name      string        `serialize:"true" json:"name"`
+	Owner     codec.Address `serialize:"true" json:"owner"`
+	ExpiresAt uint64        `serialize:"true" json:"expires_at"`
+}
+
+func (*TransferNameResult) GetTypeID() uint8 {
+	return consts.TransferNameResultID
+}
@@ -0,0 +1,356 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// MaxPCRLen is the longest PCR value a policy may expect. Nitro PCRs are
+// SHA-384 digests.
+const MaxPCRLen = 64
+
+var (
+	ErrInvalidNitroPolicy = errors.New("invalid nitro PCR policy")
+	ErrNitroNotAccepted   = errors.New("region does not accept nitro enclaves")
+	ErrMissingNitroKey    = errors.New("nitro document binds no public key")
+	ErrStaleNitroDocument = errors.New("nitro document outside valid window")
+	ErrEnclaveRegistered  = errors.New("enclave already registered")
+
+	_ chain.Action = (*SetNitroPolicyAction)(nil)
+	_ chain.Action = (*RegisterNitroEnclaveAction)(nil)
+)
+
+// SetNitroPolicyAction sets the PCRs a Nitro enclave must report to serve
+// [RegionID], once signed by a threshold of the admin keys. An empty policy
+// stops the region accepting new Nitro enclaves; enclaves already
+// registered are unaffected.
+type SetNitroPolicyAction struct {
+	RegionID   string                  `serialize:"true" json:"region_id"`
+	Policy     attestation.NitroPolicy `serialize:"true" json:"policy"`
+	Nonce      uint64                  `serialize:"true" json:"nonce"`
+	Signatures []AdminSignature        `serialize:"true" json:"signatures"`
+}
+
+func (*SetNitroPolicyAction) GetTypeID() uint8 {
+	return consts.SetNitroPolicyID
+}
+
+func (s *SetNitroPolicyAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.AdminSetKey()):              state.Read,
+		string(storage.AdminNonceKey()):            state.All,
+		string(storage.RegionKey(s.RegionID)):      state.Read,
+		string(storage.NitroPolicyKey(s.RegionID)): state.All,
+	}, s.RegionID, actionID), actor, s)
+}
+
+// Digest is the message each admin key signs on the chain [chainID].
+func (s *SetNitroPolicyAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.SetNitroPolicyID)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(s.RegionID)))
+	d = append(d, s.RegionID...)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(s.Policy.PCRs)))
+	for _, pcr := range s.Policy.PCRs {
+		d = append(d, pcr.Index, byte(len(pcr.Value)))
+		d = append(d, pcr.Value...)
+	}
+	return binary.BigEndian.AppendUint64(d, s.Nonce)
+}
+
+func (s *SetNitroPolicyAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SetNitroPolicyID, s.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	if len(s.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	if err := validateNitroPolicy(&s.Policy); err != nil {
+		return nil, err
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, s.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if s.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, s.Digest(rules.GetChainID()), s.Signatures); err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetNitroPolicy(ctx, mu, s.RegionID, &s.Policy); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, s.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.SetNitroPolicyID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		Attestations: signatureHashes(s.Signatures),
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	return &SetNitroPolicyResult{
+		RegionID: s.RegionID,
+		PCRCount: uint16(len(s.Policy.PCRs)),
+		Nonce:    s.Nonce,
+	}, nil
+}
+
+func (s *SetNitroPolicyAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + uint64(len(s.Signatures))*schedule.AttestationUnits
+}
+
+func (*SetNitroPolicyAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type SetNitroPolicyResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	PCRCount uint16 `serialize:"true" json:"pcr_count"`
+	Nonce    uint64 `serialize:"true" json:"nonce"`
+}
+
+func (*SetNitroPolicyResult) GetTypeID() uint8 {
+	return consts.SetNitroPolicyResultID
+}
+
+// RegisterNitroEnclaveAction adds a Nitro enclave to [RegionID]. [Document]
+// is a Nitro attestation document chaining up to the root from genesis,
+// reporting the PCRs of the region policy and binding the key the enclave
+// signs with. Anyone may submit it; the document itself proves the enclave
+// runs the expected image. An enclave that accepts sealed event parameters
+// puts its X25519 encryption key in the document's user data, which is
+// then published for the region.
+type RegisterNitroEnclaveAction struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Document []byte `serialize:"true" json:"document"`
+}
+
+func (*RegisterNitroEnclaveAction) GetTypeID() uint8 {
+	return consts.RegisterNitroEnclaveID
+}
+
+// enclaveID is the ID the enclave attested by [Document] registers under,
+// or nil if the document does not parse.
+func (r *RegisterNitroEnclaveAction) enclaveID() []byte {
+	doc, err := attestation.ParseNitroDocument(r.Document)
+	if err != nil || len(doc.PublicKey) == 0 {
+		return nil
+	}
+	return attestation.KeyEnclaveID(doc.PublicKey)
+}
+
+func (r *RegisterNitroEnclaveAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	enclaveID := r.enclaveID()
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.HeightKey()):                                      state.Read,
+		string(storage.ParamKey(uint8(consts.ParamTimeDrift))):           state.Read,
+		string(storage.NitroRootKey()):                                   state.Read,
+		string(storage.RegionKey(r.RegionID)):                            state.Read,
+		string(storage.NitroPolicyKey(r.RegionID)):                       state.Read,
+		string(storage.EnclaveKey(r.RegionID, enclaveID)):                state.All,
+		string(storage.EnclavePubKeyKey(r.RegionID, enclaveID)):          state.All,
+		string(storage.EnclaveTypeKey(r.RegionID, enclaveID)):            state.All,
+		string(storage.PlatformCountKey(r.RegionID, attestation.Nitro)):  state.All,
+		string(storage.PlatformPolicyKey(r.RegionID)):                    state.Read,
+		string(storage.EnclaveExpiryKey(r.RegionID, enclaveID)):          state.All,
+		string(storage.ParamKey(uint8(consts.ParamAttestationValidity))): state.Read,
+		string(storage.EncryptionKeysKey(r.RegionID)):                    state.All,
+	}, r.RegionID, actionID), actor, r)
+}
+
+func (r *RegisterNitroEnclaveAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RegisterNitroEnclaveID, r.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	_, exists, err := storage.GetRegion(ctx, mu, r.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	doc, err := verifyNitroEvidence(ctx, mu, r.RegionID, r.Document, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	enclaveID := attestation.KeyEnclaveID(doc.PublicKey)
+	status, _, err := storage.GetEnclave(ctx, mu, r.RegionID, enclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if status == storage.EnclaveActive {
+		return nil, ErrEnclaveRegistered
+	}
+	if err := storage.SetEnclave(ctx, mu, r.RegionID, enclaveID, storage.EnclaveActive, doc.PublicKey); err != nil {
+		return nil, err
+	}
+	if err := storage.SetEnclaveType(ctx, mu, r.RegionID, enclaveID, attestation.Nitro); err != nil {
+		return nil, err
+	}
+	if _, err := renewEnclave(ctx, mu, r.RegionID, enclaveID, timestamp); err != nil {
+		return nil, err
+	}
+	if len(doc.UserData) != 0 {
+		if err := publishEncryptionKey(ctx, mu, r.RegionID, enclaveID, doc.UserData); err != nil {
+			return nil, err
+		}
+	}
+	if err := appendAudit(ctx, mu, r.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.RegisterNitroEnclaveID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		EnclaveID:    enclaveID,
+		Attestations: evidenceHashes(r.Document),
+	}); err != nil {
+		return nil, err
+	}
+	return &RegisterNitroEnclaveResult{
+		RegionID:  r.RegionID,
+		EnclaveID: enclaveID,
+		ModuleID:  doc.ModuleID,
+	}, nil
+}
+
+func (r *RegisterNitroEnclaveAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.TEEUnits + schedule.QuoteUnits(len(r.Document))
+}
+
+// ValidRange expires the registration once its document is too old to
+// pass the drift check.
+func (r *RegisterNitroEnclaveAction) ValidRange(chain.Rules) (int64, int64) {
+	return nitroRange(r.Document)
+}
+
+type RegisterNitroEnclaveResult struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+	ModuleID  string `serialize:"true" json:"module_id"`
+}
+
+func (*RegisterNitroEnclaveResult) GetTypeID() uint8 {
+	return consts.RegisterNitroEnclaveResultID
+}
+
+// verifyNitroEvidence checks that [document] is a Nitro attestation document
+// chaining up to the root from genesis, issued within the Roughtime drift of
+// [timestamp], binding a public key and reporting the PCRs [regionID]
+// requires.
+func verifyNitroEvidence(ctx context.Context, im state.Immutable, regionID string, document []byte, timestamp int64) (*attestation.NitroDocument, error) {
+	policy, err := storage.GetNitroPolicy(ctx, im, regionID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, ErrNitroNotAccepted
+	}
+	platform, err := storage.GetPlatformPolicy(ctx, im, regionID)
+	if err != nil {
+		return nil, err
+	}
+	if platform != nil && !platform.Allows(attestation.Nitro) {
+		return nil, ErrNitroNotAccepted
+	}
+	root, err := storage.GetNitroRoot(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := attestation.VerifyNitroDocument(document, root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnclave, err)
+	}
+	if len(doc.PublicKey) == 0 {
+		return nil, ErrMissingNitroKey
+	}
+	maxDrift, err := Uint64Param(ctx, im, consts.ParamTimeDrift, consts.MaxTimeDrift)
+	if err != nil {
+		return nil, err
+	}
+	if !attestation.WithinDrift(doc.Timestamp/1000, uint64(timestamp/1000), maxDrift) {
+		return nil, ErrStaleNitroDocument
+	}
+	if err := policy.Match(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// nitroRange is the valid range of an action carrying Nitro attestation
+// [document], or always valid if it does not parse.
+func nitroRange(document []byte) (int64, int64) {
+	doc, err := attestation.ParseNitroDocument(document)
+	if err != nil {
+		return -1, -1
+	}
+	return driftRange(doc.Timestamp/1000, doc.Timestamp/1000)
+}
+
+func validateNitroPolicy(p *attestation.NitroPolicy) error {
+	if len(p.PCRs) > attestation.MaxPCRIndex+1 {
+		return ErrInvalidNitroPolicy
+	}
+	seen := make(map[uint8]struct{}, len(p.PCRs))
+	for _, pcr := range p.PCRs {
+		if pcr.Index > attestation.MaxPCRIndex || len(pcr.Value) == 0 || len(pcr.Value) > MaxPCRLen {
+			return fmt.Errorf("%w: PCR%d", ErrInvalidNitroPolicy, pcr.Index)
+		}
+		if _, ok := seen[pcr.Index]; ok {
+			return fmt.Errorf("%w: duplicate PCR%d", ErrInvalidNitroPolicy, pcr.Index)
+		}
+		seen[pcr.Index] = struct{}{}
+	}
+	return nil
+}
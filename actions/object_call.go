@@ -0,0 +1,286 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidCallTrace = errors.New("invalid call trace")
+	ErrUndeclaredObject = errors.New("object accessed outside the call trace")
+	ErrStaleRead        = errors.New("object read does not match state")
+	ErrTooManyReads     = errors.New("too many object reads in execution result")
+	ErrTooManyCalls     = errors.New("too many calls in execution result")
+)
+
+// objectAccessTag precedes reads and calls in a result digest
+const objectAccessTag byte = 0x02
+
+// ObjectRead is a key of an object's key-value namespace an execution read,
+// with the hash of the value it saw (see [ObjectReadHash]).
+type ObjectRead struct {
+	Object    string `json:"object"`
+	Key       []byte `json:"key"`
+	ValueHash ids.ID `json:"value_hash"`
+}
+
+// CallFrame is one call in an execution's trace. Frames are listed in the
+// order the calls were made. The first frame, at depth 0, is the call into
+// the result's ContractAddr and has no caller; a frame at depth d is made by
+// the callee of the nearest earlier frame at depth d-1.
+type CallFrame struct {
+	Caller   string `json:"caller"`
+	Callee   string `json:"callee"`
+	Function string `json:"function"`
+	Depth    uint8  `json:"depth"`
+}
+
+// ObjectReadHash is the hash an ObjectRead records for [value]. Keys that
+// do not exist read as ids.Empty.
+func ObjectReadHash(value []byte, exists bool) ids.ID {
+	if !exists {
+		return ids.Empty
+	}
+	return sha256.Sum256(value)
+}
+
+// hashObjectAccess writes the reads and calls of [r] to [h].
+func hashObjectAccess(h hash.Hash, r *TEEExecResult) {
+	writeLenPrefixed := func(b []byte) {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(b))))
+		h.Write(b)
+	}
+	h.Write([]byte{objectAccessTag})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(r.Reads))))
+	for _, read := range r.Reads {
+		writeLenPrefixed([]byte(read.Object))
+		writeLenPrefixed(read.Key)
+		h.Write(read.ValueHash[:])
+	}
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(r.Calls))))
+	for _, call := range r.Calls {
+		writeLenPrefixed([]byte(call.Caller))
+		writeLenPrefixed([]byte(call.Callee))
+		writeLenPrefixed([]byte(call.Function))
+		h.Write([]byte{call.Depth})
+	}
+}
+
+// checkCallTrace checks that the calls of [r] form a single call tree
+// rooted at its contract, and returns the objects called. It returns nil if
+// the result carries no trace.
+func checkCallTrace(r *TEEExecResult) (map[string]struct{}, error) {
+	if len(r.Calls) == 0 {
+		return nil, nil
+	}
+	if len(r.Calls) > consts.MaxCallFrames {
+		return nil, ErrTooManyCalls
+	}
+	root := r.Calls[0]
+	if root.Depth != 0 || root.Caller != "" || root.Callee != string(r.ContractAddr) {
+		return nil, fmt.Errorf("%w: root frame does not call the contract", ErrInvalidCallTrace)
+	}
+	called := make(map[string]struct{})
+	stack := make([]string, 0, consts.MaxCallDepth+1)
+	for i, call := range r.Calls {
+		if len(call.Function) == 0 || len(call.Function) > 256 {
+			return nil, ErrInvalidFunction
+		}
+		if i > 0 {
+			if call.Depth == 0 || int(call.Depth) > len(stack) || call.Depth > consts.MaxCallDepth {
+				return nil, fmt.Errorf("%w: frame %d at depth %d", ErrInvalidCallTrace, i, call.Depth)
+			}
+			if call.Caller != stack[call.Depth-1] {
+				return nil, fmt.Errorf("%w: frame %d not made by %s", ErrInvalidCallTrace, i, stack[call.Depth-1])
+			}
+		}
+		stack = append(stack[:call.Depth], call.Callee)
+		called[call.Callee] = struct{}{}
+	}
+	return called, nil
+}
+
+// checkObjectAccess checks the objects [r] touched: each must exist and,
+// when the result carries a call trace, have been called in it. Reads must
+// match the state the result is applied to.
+func checkObjectAccess(ctx context.Context, im state.Immutable, r *TEEExecResult) error {
+	called, err := checkCallTrace(r)
+	if err != nil {
+		return err
+	}
+	if len(r.Reads) > consts.MaxObjectReads {
+		return ErrTooManyReads
+	}
+	exists := make(map[string]bool)
+	touch := func(objectID string) error {
+		if called != nil {
+			if _, ok := called[objectID]; !ok {
+				return fmt.Errorf("%w: %s", ErrUndeclaredObject, objectID)
+			}
+		}
+		if _, ok := exists[objectID]; ok {
+			return nil
+		}
+		obj, err := storage.GetObject(ctx, im, objectID)
+		if err != nil {
+			return err
+		}
+		if obj == nil {
+			return fmt.Errorf("%w: %s", ErrObjectNotFound, objectID)
+		}
+		exists[objectID] = true
+		return nil
+	}
+	for objectID := range called {
+		if err := touch(objectID); err != nil {
+			return err
+		}
+	}
+	for _, read := range r.Reads {
+		if err := touch(read.Object); err != nil {
+			return err
+		}
+		value, ok, err := storage.GetObjectKV(ctx, im, read.Object, read.Key)
+		if err != nil {
+			return err
+		}
+		if ObjectReadHash(value, ok) != read.ValueHash {
+			return fmt.Errorf("%w: %s/%x", ErrStaleRead, read.Object, read.Key)
+		}
+	}
+	for key := range r.StateUpdates {
+		if objectID, _, ok := parseObjectKVUpdate(key); ok {
+			if err := touch(objectID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CallTracer implements the host side of cross-object calls for a runtime
+// executing in an enclave. The runtime binds its call and return host
+// functions to Call and Return, its storage functions to Get, Set and
+// Delete, which act on the object currently executing, and its randomness
+// function to Randomness. Result returns the
+// TEEExecResult recording every read, write and call.
+type CallTracer struct {
+	im     state.Immutable
+	result TEEExecResult
+	stack  []string
+	read   map[string]struct{}
+}
+
+// NewCallTracer starts tracing a call of [function] on [contract] against
+// the state in [im].
+func NewCallTracer(im state.Immutable, contract, function string) *CallTracer {
+	return &CallTracer{
+		im: im,
+		result: TEEExecResult{
+			ContractAddr: []byte(contract),
+			StateUpdates: make(map[string][]byte),
+			Calls:        []CallFrame{{Callee: contract, Function: function}},
+		},
+		stack: []string{contract},
+		read:  make(map[string]struct{}),
+	}
+}
+
+// Call enters [function] of [callee] from the object currently executing.
+func (c *CallTracer) Call(callee, function string) error {
+	depth := len(c.stack)
+	if depth > consts.MaxCallDepth {
+		return fmt.Errorf("%w: depth exceeds %d", ErrInvalidCallTrace, consts.MaxCallDepth)
+	}
+	if len(c.result.Calls) >= consts.MaxCallFrames {
+		return ErrTooManyCalls
+	}
+	c.result.Calls = append(c.result.Calls, CallFrame{
+		Caller:   c.stack[depth-1],
+		Callee:   callee,
+		Function: function,
+		Depth:    uint8(depth),
+	})
+	c.stack = append(c.stack, callee)
+	return nil
+}
+
+// Return leaves the current call. The root call is never left.
+func (c *CallTracer) Return() error {
+	if len(c.stack) == 1 {
+		return fmt.Errorf("%w: return from the root call", ErrInvalidCallTrace)
+	}
+	c.stack = c.stack[:len(c.stack)-1]
+	return nil
+}
+
+func (c *CallTracer) current() string {
+	return c.stack[len(c.stack)-1]
+}
+
+// Get reads [key] of the current object, seeing earlier writes of the
+// execution. Reads of state are recorded once per key.
+func (c *CallTracer) Get(ctx context.Context, key []byte) ([]byte, bool, error) {
+	objectID := c.current()
+	updateKey := ObjectKVUpdateKey(objectID, key)
+	if value, ok := c.result.StateUpdates[updateKey]; ok {
+		return value, len(value) > 0, nil
+	}
+	value, exists, err := storage.GetObjectKV(ctx, c.im, objectID, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, ok := c.read[updateKey]; !ok {
+		if len(c.result.Reads) >= consts.MaxObjectReads {
+			return nil, false, ErrTooManyReads
+		}
+		c.read[updateKey] = struct{}{}
+		c.result.Reads = append(c.result.Reads, ObjectRead{
+			Object:    objectID,
+			Key:       key,
+			ValueHash: ObjectReadHash(value, exists),
+		})
+	}
+	return value, exists, nil
+}
+
+func (c *CallTracer) Set(key []byte, value []byte) error {
+	updateKey := ObjectKVUpdateKey(c.current(), key)
+	if _, ok := c.result.StateUpdates[updateKey]; !ok && len(c.result.StateUpdates) >= consts.MaxStateUpdates {
+		return ErrTooManyStateUpdates
+	}
+	c.result.StateUpdates[updateKey] = value
+	return nil
+}
+
+func (c *CallTracer) Delete(key []byte) error {
+	return c.Set(key, nil)
+}
+
+// Randomness returns the beacon randomness [regionID] published for
+// [epoch]. A published beacon never changes, so the read is not recorded.
+func (c *CallTracer) Randomness(ctx context.Context, regionID string, epoch uint64) (ids.ID, error) {
+	return RegionRandomness(ctx, c.im, regionID, epoch)
+}
+
+// Result returns the traced execution. Every call but the root must have
+// returned.
+func (c *CallTracer) Result() (TEEExecResult, error) {
+	if len(c.stack) != 1 {
+		return TEEExecResult{}, fmt.Errorf("%w: %d calls not returned", ErrInvalidCallTrace, len(c.stack)-1)
+	}
+	return c.result, nil
+}
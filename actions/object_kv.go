@@ -0,0 +1,43 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// ObjectStore is one object's key-value namespace as seen by contract code.
+// A runtime binds its storage host functions (get, set, delete) to these
+// methods so contracts update single keys instead of rewriting the whole
+// storage blob. Per-key size limits are enforced on every write.
+type ObjectStore struct {
+	mu       state.Mutable
+	objectID string
+}
+
+func NewObjectStore(mu state.Mutable, objectID string) *ObjectStore {
+	return &ObjectStore{mu: mu, objectID: objectID}
+}
+
+func (s *ObjectStore) Get(ctx context.Context, key []byte) ([]byte, bool, error) {
+	return storage.GetObjectKV(ctx, s.mu, s.objectID, key)
+}
+
+func (s *ObjectStore) Set(ctx context.Context, key []byte, value []byte) error {
+	return storage.SetObjectKV(ctx, s.mu, s.objectID, key, value)
+}
+
+func (s *ObjectStore) Delete(ctx context.Context, key []byte) error {
+	return storage.SetObjectKV(ctx, s.mu, s.objectID, key, nil)
+}
+
+// ObjectKVUpdateKey returns the TEEExecResult state update key that writes
+// [key] of [objectID]. An empty update value deletes the key.
+func ObjectKVUpdateKey(objectID string, key []byte) string {
+	return objectKVUpdatePrefix + objectID + objectKVUpdateSep + string(key)
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidMetadata         = errors.New("invalid object metadata")
+	ErrInvalidInterface        = errors.New("invalid interface")
+	ErrInterfaceNotImplemented = errors.New("code does not implement declared interface")
+)
+
+// ValidateObjectMetadata checks [m] fits the bounds in [consts] and lists
+// the object in a named region.
+func ValidateObjectMetadata(m *storage.ObjectMetadata) error {
+	if len(m.Name) > consts.MaxNameLen {
+		return fmt.Errorf("%w: name length %d", ErrInvalidMetadata, len(m.Name))
+	}
+	if len(m.Description) > consts.MaxObjectDescriptionLen {
+		return fmt.Errorf("%w: description length %d", ErrInvalidMetadata, len(m.Description))
+	}
+	if len(m.RegionID) == 0 || len(m.RegionID) > consts.MaxIDLength {
+		return fmt.Errorf("%w: region %q", ErrInvalidMetadata, m.RegionID)
+	}
+	if len(m.Tags) > consts.MaxObjectTags {
+		return fmt.Errorf("%w: %d tags", ErrInvalidMetadata, len(m.Tags))
+	}
+	for _, tag := range m.Tags {
+		if len(tag) == 0 || len(tag) > consts.MaxObjectTagLen {
+			return fmt.Errorf("%w: tag %q", ErrInvalidMetadata, tag)
+		}
+	}
+	if len(m.Exports) > consts.MaxObjectExports {
+		return fmt.Errorf("%w: %d exports", ErrInvalidMetadata, len(m.Exports))
+	}
+	for _, export := range m.Exports {
+		if len(export) == 0 || len(export) > 256 {
+			return fmt.Errorf("%w: export %q", ErrInvalidMetadata, export)
+		}
+	}
+	if len(m.Interfaces) > consts.MaxObjectInterfaces {
+		return fmt.Errorf("%w: %d interfaces", ErrInvalidMetadata, len(m.Interfaces))
+	}
+	seen := make(map[ids.ID]struct{}, len(m.Interfaces))
+	for i := range m.Interfaces {
+		if err := validateInterface(&m.Interfaces[i]); err != nil {
+			return err
+		}
+		id := m.Interfaces[i].ID()
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("%w: %s declared twice", ErrInvalidInterface, id)
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
+
+func validateInterface(i *storage.Interface) error {
+	if len(i.Functions) == 0 || len(i.Functions) > consts.MaxInterfaceFunctions {
+		return fmt.Errorf("%w: %d functions", ErrInvalidInterface, len(i.Functions))
+	}
+	names := make(map[string]struct{}, len(i.Functions))
+	for _, fn := range i.Functions {
+		if len(fn.Name) == 0 || len(fn.Name) > 256 {
+			return fmt.Errorf("%w: function %q", ErrInvalidInterface, fn.Name)
+		}
+		if _, ok := names[fn.Name]; ok {
+			return fmt.Errorf("%w: duplicate function %q", ErrInvalidInterface, fn.Name)
+		}
+		names[fn.Name] = struct{}{}
+	}
+	return nil
+}
+
+// CheckInterfaces checks [code] exports every function of [interfaces]
+// with the declared signature.
+func CheckInterfaces(code []byte, interfaces []storage.Interface) error {
+	if len(interfaces) == 0 {
+		return nil
+	}
+	exports, err := WasmExports(code)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCode, err)
+	}
+	for i := range interfaces {
+		for _, fn := range interfaces[i].Functions {
+			export, ok := exports[fn.Name]
+			if !ok || !bytes.Equal(export.Params, fn.Params) || !bytes.Equal(export.Results, fn.Results) {
+				return fmt.Errorf("%w: %s: %s", ErrInterfaceNotImplemented, interfaces[i].ID(), fn.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// encodeMetadata returns the encoding of [m], empty if it is nil.
+func encodeMetadata(m *storage.ObjectMetadata) []byte {
+	if m == nil {
+		return nil
+	}
+	b, _ := codec.Marshal(m)
+	return b
+}
+
+// decodeMetadata is the inverse of [encodeMetadata].
+func decodeMetadata(b []byte) (*storage.ObjectMetadata, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	return storage.ParseObjectMetadata(b)
+}
+
+// objectKeys returns the keys creating [objectID] listed with [m] writes.
+func objectKeys(objectID string, m *storage.ObjectMetadata) state.Keys {
+	keys := state.Keys{
+		string(storage.ObjectKey(objectID)):        state.All,
+		string(storage.ObjectCreatorKey(objectID)): state.All,
+	}
+	if m == nil {
+		return keys
+	}
+	keys[string(storage.ObjectMetadataKey(objectID))] = state.All
+	keys[string(storage.RegionKey(m.RegionID))] = state.Read
+	keys[string(storage.ObjectIndexHeadKey(m.RegionID))] = state.All
+	keys[string(storage.ObjectIndexKey(m.RegionID, objectID))] = state.All
+	for i := range m.Interfaces {
+		interfaceID := m.Interfaces[i].ID()
+		keys[string(storage.InterfaceKey(interfaceID))] = state.All
+		keys[string(storage.InterfaceIndexHeadKey(m.RegionID, interfaceID))] = state.All
+		keys[string(storage.InterfaceIndexKey(m.RegionID, interfaceID, objectID))] = state.All
+	}
+	return keys
+}
+
+// verifyMetadata checks the object's metadata and that the region it is
+// listed in exists.
+func (a *CreateObjectAction) verifyMetadata(ctx context.Context, im state.Immutable) error {
+	if err := ValidateObjectMetadata(a.Metadata); err != nil {
+		return err
+	}
+	if err := CheckInterfaces(a.Code, a.Metadata.Interfaces); err != nil {
+		return err
+	}
+	_, exists, err := storage.GetRegion(ctx, im, a.Metadata.RegionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRegionNotFound
+	}
+	return nil
+}
+
+// indexMetadata stores the object's metadata and appends the object to the
+// index of its region and to that of each interface it implements.
+func (a *CreateObjectAction) indexMetadata(ctx context.Context, mu state.Mutable) error {
+	if err := storage.SetObjectMetadata(ctx, mu, a.ID, a.Metadata); err != nil {
+		return err
+	}
+	regionID := a.Metadata.RegionID
+	if err := storage.AppendIndex(ctx, mu, storage.ObjectIndexHeadKey(regionID), storage.ObjectIndexKey(regionID, a.ID), a.ID); err != nil {
+		return err
+	}
+	for i := range a.Metadata.Interfaces {
+		iface := &a.Metadata.Interfaces[i]
+		interfaceID := iface.ID()
+		if err := storage.SetInterface(ctx, mu, iface); err != nil {
+			return err
+		}
+		if err := storage.AppendIndex(ctx, mu, storage.InterfaceIndexHeadKey(regionID, interfaceID), storage.InterfaceIndexKey(regionID, interfaceID, a.ID), a.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	hconsts "github.com/ava-labs/hypersdk/consts"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var (
+	ErrInvalidSchema = errors.New("invalid parameter schema")
+	ErrParamMismatch = errors.New("parameters do not match schema")
+)
+
+// ParamType is the type of one function parameter. Parameters are encoded
+// back to back as the hypersdk codec packs them, which is also what the
+// generated clients emit.
+type ParamType uint8
+
+const (
+	ParamBool    ParamType = iota + 1 // 1 byte, 0 or 1
+	ParamUint64                       // 8 bytes, big endian
+	ParamInt64                        // 8 bytes, big endian
+	ParamString                       // uint16 length, then UTF-8 bytes
+	ParamBytes                        // uint32 length, then bytes
+	ParamAddress                      // codec.AddressLen bytes
+	ParamID                           // ids.IDLen bytes
+)
+
+var paramTypeNames = map[ParamType]string{
+	ParamBool:    "bool",
+	ParamUint64:  "u64",
+	ParamInt64:   "i64",
+	ParamString:  "string",
+	ParamBytes:   "bytes",
+	ParamAddress: "address",
+	ParamID:      "id",
+}
+
+func (t ParamType) String() string {
+	if name, ok := paramTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ParamType(%d)", uint8(t))
+}
+
+func (t ParamType) MarshalText() ([]byte, error) {
+	if _, ok := paramTypeNames[t]; !ok {
+		return nil, fmt.Errorf("%w: unknown type %d", ErrInvalidSchema, uint8(t))
+	}
+	return []byte(t.String()), nil
+}
+
+func (t *ParamType) UnmarshalText(b []byte) error {
+	for typ, name := range paramTypeNames {
+		if name == string(b) {
+			*t = typ
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown type %q", ErrInvalidSchema, b)
+}
+
+// FunctionSchema declares the parameters an exported function of an object
+// accepts. Events calling the function must carry parameters matching it.
+type FunctionSchema struct {
+	Function string      `serialize:"true" json:"function"`
+	Params   []ParamType `serialize:"true" json:"params"`
+}
+
+func (s *FunctionSchema) encode() []byte {
+	b := make([]byte, len(s.Params))
+	for i, t := range s.Params {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+func validateSchemas(schemas []FunctionSchema) error {
+	if len(schemas) > consts.MaxSchemasPerObject {
+		return fmt.Errorf("%w: more than %d functions", ErrInvalidSchema, consts.MaxSchemasPerObject)
+	}
+	seen := make(map[string]struct{}, len(schemas))
+	for _, s := range schemas {
+		if len(s.Function) == 0 || len(s.Function) > 256 {
+			return ErrInvalidFunction
+		}
+		if _, ok := seen[s.Function]; ok {
+			return fmt.Errorf("%w: %s declared twice", ErrInvalidSchema, s.Function)
+		}
+		seen[s.Function] = struct{}{}
+		if len(s.Params) > consts.MaxSchemaParams {
+			return fmt.Errorf("%w: %s takes more than %d parameters", ErrInvalidSchema, s.Function, consts.MaxSchemaParams)
+		}
+		for _, t := range s.Params {
+			if _, ok := paramTypeNames[t]; !ok {
+				return fmt.Errorf("%w: %s has unknown type %d", ErrInvalidSchema, s.Function, uint8(t))
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateParams checks that [params] is exactly one value of each type in
+// [schema], as stored for a function.
+func ValidateParams(schema []byte, params []byte) error {
+	truncated := func(i int) error {
+		return fmt.Errorf("%w: parameter %d truncated", ErrParamMismatch, i)
+	}
+	off := 0
+	for i, b := range schema {
+		var n int
+		switch ParamType(b) {
+		case ParamBool:
+			n = 1
+			if off < len(params) && params[off] > 1 {
+				return fmt.Errorf("%w: parameter %d is not a bool", ErrParamMismatch, i)
+			}
+		case ParamUint64, ParamInt64:
+			n = hconsts.Uint64Len
+		case ParamString:
+			if len(params)-off < hconsts.Uint16Len {
+				return truncated(i)
+			}
+			n = hconsts.Uint16Len + int(binary.BigEndian.Uint16(params[off:]))
+		case ParamBytes:
+			if len(params)-off < hconsts.Uint32Len {
+				return truncated(i)
+			}
+			size := binary.BigEndian.Uint32(params[off:])
+			if uint64(size) > uint64(len(params)-off-hconsts.Uint32Len) {
+				return truncated(i)
+			}
+			n = hconsts.Uint32Len + int(size)
+		case ParamAddress:
+			n = codec.AddressLen
+		case ParamID:
+			n = ids.IDLen
+		default:
+			return fmt.Errorf("%w: unknown type %d", ErrInvalidSchema, b)
+		}
+		if n > len(params)-off {
+			return truncated(i)
+		}
+		off += n
+	}
+	if off != len(params) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrParamMismatch, len(params)-off)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var _ chain.Action = (*PipelineAction)(nil)
+
+// PipelineAction advances a pipeline by one stage in a single transaction:
+// it makes ID the input object, sends it FunctionCall with Parameters, and
+// records Next, if set, as the object the pipeline routes input to once ID
+// is done. Either all three take effect or none does, so no block sees the
+// input object moved without its event queued.
+type PipelineAction struct {
+	Version      uint8  `json:"version"`
+	ID           string `json:"id"`
+	FunctionCall string `json:"function_call"`
+	Parameters   []byte `json:"parameters"`
+	Next         string `json:"next,omitempty"`
+}
+
+func (*PipelineAction) GetTypeID() uint8 { return consts.PipelineID }
+
+func (a *PipelineAction) Marshal(p *codec.Packer) {
+	if protoWire {
+		packProto(p, a.appendProto(nil))
+		return
+	}
+	packVersion(p, a.Version)
+	p.PackString(a.ID)
+	p.PackString(a.FunctionCall)
+	p.PackBytes(a.Parameters)
+	p.PackString(a.Next)
+}
+
+func UnmarshalPipeline(p *codec.Packer) (chain.Action, error) {
+	if protoWire {
+		m, err := unpackProto(p)
+		if err != nil {
+			return nil, err
+		}
+		return pipelineFromProto(m)
+	}
+
+	var act PipelineAction
+
+	version, err := unpackVersion(p)
+	if err != nil {
+		return nil, err
+	}
+	act.Version = version
+
+	if act.ID, err = p.UnpackString(); err != nil {
+		return nil, err
+	}
+	if act.FunctionCall, err = p.UnpackString(); err != nil {
+		return nil, err
+	}
+	if act.Parameters, err = p.UnpackBytes(); err != nil {
+		return nil, err
+	}
+	if act.Next, err = p.UnpackString(); err != nil {
+		return nil, err
+	}
+	return &act, nil
+}
+
+// Event returns the event the action sends to its stage.
+func (a *PipelineAction) Event() *SendEventAction {
+	return &SendEventAction{
+		Version:      a.Version,
+		IDTo:         a.ID,
+		FunctionCall: a.FunctionCall,
+		Parameters:   a.Parameters,
+	}
+}
+
+func (a *PipelineAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := (&SetInputObjectAction{ID: a.ID}).inputKeys()
+	for k, p := range a.Event().eventKeys(actor) {
+		keys[k] |= p
+	}
+	keys[string(storage.PipelineNextKey(a.ID))] = state.All
+	if a.Next != "" {
+		keys[string(storage.ObjectKey(a.Next))] |= state.Read
+	}
+	return addGateKeys(keys, actor, a)
+}
+
+func (a *PipelineAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.PipelineID, "", a.ID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	if a.Next != "" {
+		if len(a.Next) > 256 {
+			return nil, ErrInvalidID
+		}
+		obj, err := storage.GetObject(ctx, mu, a.Next)
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			return nil, ErrObjectNotFound
+		}
+	}
+	if _, err := (&SetInputObjectAction{ID: a.ID}).set(ctx, mu); err != nil {
+		return nil, err
+	}
+	sent, err := a.Event().send(ctx, rules, mu, timestamp, actor)
+	if err != nil {
+		return nil, err
+	}
+	// An empty Next ends the pipeline at ID
+	if err := storage.SetPipelineNext(ctx, mu, a.ID, a.Next); err != nil {
+		return nil, err
+	}
+	return &PipelineResult{Success: true, ID: a.ID, Next: a.Next, EventID: sent.EventID}, nil
+}
+
+func (a *PipelineAction) ActionVersion() uint8 {
+	return a.Version
+}
+
+func (a *PipelineAction) ComputeUnits(rules chain.Rules) uint64 {
+	return a.Event().ComputeUnits(rules) + FeeScheduleOf(rules).StorageUnits(0, len(a.ID)+len(a.Next))
+}
+
+func (*PipelineAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type PipelineResult struct {
+	Success   bool             `json:"success"`
+	ID        string           `json:"id"`
+	Next      string           `json:"next,omitempty"`
+	ErrorCode consts.ErrorCode `json:"error_code"`
+	Message   string           `json:"message"`
+	// EventID is the ID a TEEExecAction completing the stage's event carries
+	EventID ids.ID `json:"event_id"`
+}
+
+func (*PipelineResult) GetTypeID() uint8 { return consts.PipelineResultID }
+
+func (r *PipelineResult) Marshal(p *codec.Packer) {
+	p.PackBool(r.Success)
+	p.PackString(r.ID)
+	p.PackString(r.Next)
+	packErrorCode(p, r.ErrorCode, r.Message)
+	p.PackID(r.EventID)
+}
+
+func UnmarshalPipelineResult(p *codec.Packer) (codec.Typed, error) {
+	var res PipelineResult
+	var err error
+	if res.Success, err = p.UnpackBool(); err != nil {
+		return nil, err
+	}
+	if res.ID, err = p.UnpackString(); err != nil {
+		return nil, err
+	}
+	if res.Next, err = p.UnpackString(); err != nil {
+		return nil, err
+	}
+
+	res.ErrorCode, res.Message, err = unpackErrorCode(p)
+	if err != nil {
+		return nil, err
+	}
+
+	p.UnpackID(false, &res.EventID)
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,421 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	// MaxRealmMeasurements bounds the CCA realm measurements a region
+	// policy accepts
+	MaxRealmMeasurements = 16
+	// MaxRealmMeasurementLen fits a SHA-512 realm initial measurement
+	MaxRealmMeasurementLen = 64
+)
+
+var (
+	ErrInvalidPlatformPolicy = errors.New("invalid platform policy")
+	ErrCCANotAccepted        = errors.New("region does not accept CCA enclaves")
+	ErrCCAChallenge          = errors.New("CCA realm challenge does not bind region and key")
+	ErrEnclaveTypeMismatch   = errors.New("attestation type differs from registered enclave type")
+
+	_ chain.Action = (*SetPlatformPolicyAction)(nil)
+	_ chain.Action = (*RegisterCCAEnclaveAction)(nil)
+)
+
+// ccaDomain, ccaSealedDomain and ccaReattestDomain separate CCA realm
+// challenges from other digests and from each other
+const (
+	ccaDomain         = "shuttlevm/cca"
+	ccaSealedDomain   = "shuttlevm/cca-sealed"
+	ccaReattestDomain = "shuttlevm/cca-reattest"
+)
+
+// CCAChallenge is the realm challenge a CCA enclave must attest to join
+// [regionID] with [publicKey], publishing [encryptionKey] if it is set. It
+// binds the token to all of them, so a token cannot register another key
+// or serve another region.
+func CCAChallenge(regionID string, publicKey, encryptionKey []byte) []byte {
+	h := sha512.New()
+	if len(encryptionKey) == 0 {
+		h.Write([]byte(ccaDomain))
+	} else {
+		h.Write([]byte(ccaSealedDomain))
+	}
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	if len(encryptionKey) != 0 {
+		h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(publicKey))))
+	}
+	h.Write(publicKey)
+	h.Write(encryptionKey)
+	return h.Sum(nil)
+}
+
+// CCAReattestChallenge is the realm challenge a registered CCA enclave
+// attests to renew its registration in [regionID]. Binding the current
+// [expiry] makes each token renew the enclave at most once.
+func CCAReattestChallenge(regionID string, publicKey []byte, expiry uint64) []byte {
+	h := sha512.New()
+	h.Write([]byte(ccaReattestDomain))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(binary.BigEndian.AppendUint64(nil, expiry))
+	h.Write(publicKey)
+	return h.Sum(nil)
+}
+
+// SetPlatformPolicyAction sets which enclave types may serve [RegionID],
+// how many of each it needs, and which CCA realms it accepts, once signed
+// by a threshold of the admin keys. A policy with no requirements lets any
+// enclave type serve the region again.
+type SetPlatformPolicyAction struct {
+	RegionID   string                     `serialize:"true" json:"region_id"`
+	Policy     attestation.PlatformPolicy `serialize:"true" json:"policy"`
+	Nonce      uint64                     `serialize:"true" json:"nonce"`
+	Signatures []AdminSignature           `serialize:"true" json:"signatures"`
+}
+
+func (*SetPlatformPolicyAction) GetTypeID() uint8 {
+	return consts.SetPlatformPolicyID
+}
+
+func (s *SetPlatformPolicyAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.AdminSetKey()):                 state.Read,
+		string(storage.AdminNonceKey()):               state.All,
+		string(storage.RegionKey(s.RegionID)):         state.Read,
+		string(storage.PlatformPolicyKey(s.RegionID)): state.All,
+	}, s.RegionID, actionID), actor, s)
+}
+
+// Digest is the message each admin key signs on the chain [chainID].
+func (s *SetPlatformPolicyAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.SetPlatformPolicyID)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(s.RegionID)))
+	d = append(d, s.RegionID...)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(s.Policy.Requirements)))
+	for _, req := range s.Policy.Requirements {
+		d = append(d, byte(len(req.Type)))
+		d = append(d, req.Type...)
+		d = append(d, req.Min)
+	}
+	d = binary.BigEndian.AppendUint16(d, uint16(len(s.Policy.RealmMeasurements)))
+	for _, m := range s.Policy.RealmMeasurements {
+		d = append(d, byte(len(m)))
+		d = append(d, m...)
+	}
+	for _, flag := range []bool{s.Policy.Heterogeneous, s.Policy.DualExecution} {
+		if flag {
+			d = append(d, 1)
+		} else {
+			d = append(d, 0)
+		}
+	}
+	return binary.BigEndian.AppendUint64(d, s.Nonce)
+}
+
+func (s *SetPlatformPolicyAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SetPlatformPolicyID, s.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	if len(s.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	if err := validatePlatformPolicy(&s.Policy); err != nil {
+		return nil, err
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, s.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if s.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, s.Digest(rules.GetChainID()), s.Signatures); err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetPlatformPolicy(ctx, mu, s.RegionID, &s.Policy); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, s.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.SetPlatformPolicyID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		Attestations: signatureHashes(s.Signatures),
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	return &SetPlatformPolicyResult{
+		RegionID: s.RegionID,
+		Nonce:    s.Nonce,
+	}, nil
+}
+
+func (s *SetPlatformPolicyAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + uint64(len(s.Signatures))*schedule.AttestationUnits
+}
+
+func (*SetPlatformPolicyAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type SetPlatformPolicyResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Nonce    uint64 `serialize:"true" json:"nonce"`
+}
+
+func (*SetPlatformPolicyResult) GetTypeID() uint8 {
+	return consts.SetPlatformPolicyResultID
+}
+
+// RegisterCCAEnclaveAction adds an Arm CCA realm to [RegionID] with
+// [PublicKey] as its signing key. [Token] is the realm's CCA attestation
+// token: its platform token must be signed by a CPAK from genesis, its
+// realm measurement must be accepted by the region's platform policy, and
+// its challenge must be [CCAChallenge] of the region and keys.
+type RegisterCCAEnclaveAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	PublicKey []byte `serialize:"true" json:"public_key"`
+	Token     []byte `serialize:"true" json:"token"`
+	// EncryptionKey, when set, is the X25519 key the realm publishes for
+	// event parameters sealed to the region
+	EncryptionKey []byte `serialize:"true" json:"encryption_key,omitempty"`
+}
+
+func (*RegisterCCAEnclaveAction) GetTypeID() uint8 {
+	return consts.RegisterCCAEnclaveID
+}
+
+func (r *RegisterCCAEnclaveAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	enclaveID := attestation.KeyEnclaveID(r.PublicKey)
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.HeightKey()):                                      state.Read,
+		string(storage.CCAPlatformKeysKey()):                             state.Read,
+		string(storage.RegionKey(r.RegionID)):                            state.Read,
+		string(storage.PlatformPolicyKey(r.RegionID)):                    state.Read,
+		string(storage.EnclaveKey(r.RegionID, enclaveID)):                state.All,
+		string(storage.EnclavePubKeyKey(r.RegionID, enclaveID)):          state.All,
+		string(storage.EnclaveTypeKey(r.RegionID, enclaveID)):            state.All,
+		string(storage.PlatformCountKey(r.RegionID, attestation.CCA)):    state.All,
+		string(storage.EnclaveExpiryKey(r.RegionID, enclaveID)):          state.All,
+		string(storage.ParamKey(uint8(consts.ParamAttestationValidity))): state.Read,
+		string(storage.EncryptionKeysKey(r.RegionID)):                    state.All,
+	}, r.RegionID, actionID), actor, r)
+}
+
+func (r *RegisterCCAEnclaveAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RegisterCCAEnclaveID, r.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	_, exists, err := storage.GetRegion(ctx, mu, r.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	token, err := verifyCCAEvidence(ctx, mu, r.RegionID, r.Token, CCAChallenge(r.RegionID, r.PublicKey, r.EncryptionKey))
+	if err != nil {
+		return nil, err
+	}
+
+	enclaveID := attestation.KeyEnclaveID(r.PublicKey)
+	status, _, err := storage.GetEnclave(ctx, mu, r.RegionID, enclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if status == storage.EnclaveActive {
+		return nil, ErrEnclaveRegistered
+	}
+	if err := storage.SetEnclave(ctx, mu, r.RegionID, enclaveID, storage.EnclaveActive, r.PublicKey); err != nil {
+		return nil, err
+	}
+	if err := storage.SetEnclaveType(ctx, mu, r.RegionID, enclaveID, attestation.CCA); err != nil {
+		return nil, err
+	}
+	if _, err := renewEnclave(ctx, mu, r.RegionID, enclaveID, timestamp); err != nil {
+		return nil, err
+	}
+	if len(r.EncryptionKey) != 0 {
+		if err := publishEncryptionKey(ctx, mu, r.RegionID, enclaveID, r.EncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+	if err := appendAudit(ctx, mu, r.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.RegisterCCAEnclaveID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		EnclaveID:    enclaveID,
+		Attestations: evidenceHashes(r.Token),
+	}); err != nil {
+		return nil, err
+	}
+	return &RegisterCCAEnclaveResult{
+		RegionID:         r.RegionID,
+		EnclaveID:        enclaveID,
+		ImplementationID: token.ImplementationID,
+	}, nil
+}
+
+func (r *RegisterCCAEnclaveAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.TEEUnits + schedule.QuoteUnits(len(r.Token))
+}
+
+func (*RegisterCCAEnclaveAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type RegisterCCAEnclaveResult struct {
+	RegionID         string `serialize:"true" json:"region_id"`
+	EnclaveID        []byte `serialize:"true" json:"enclave_id"`
+	ImplementationID []byte `serialize:"true" json:"implementation_id"`
+}
+
+func (*RegisterCCAEnclaveResult) GetTypeID() uint8 {
+	return consts.RegisterCCAEnclaveResultID
+}
+
+// verifyCCAEvidence checks that [token] is a CCA attestation token from a
+// platform trusted in genesis, for a realm [regionID] accepts, attesting
+// [challenge].
+func verifyCCAEvidence(ctx context.Context, im state.Immutable, regionID string, token []byte, challenge []byte) (*attestation.CCAToken, error) {
+	policy, err := storage.GetPlatformPolicy(ctx, im, regionID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || !policy.Allows(attestation.CCA) {
+		return nil, ErrCCANotAccepted
+	}
+	platformKeys, err := storage.GetCCAPlatformKeys(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := attestation.VerifyCCAToken(token, platformKeys)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnclave, err)
+	}
+	if !bytes.Equal(t.Challenge, challenge) {
+		return nil, ErrCCAChallenge
+	}
+	if err := policy.AllowsRealm(t.InitialMeasurement); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// addPlatformKeys declares the keys [checkPlatform] reads.
+func addPlatformKeys(keys state.Keys, regionID string, enclaveID []byte) {
+	keys[string(storage.PlatformPolicyKey(regionID))] = state.Read
+	keys[string(storage.EnclaveTypeKey(regionID, enclaveID))] = state.Read
+	for _, t := range attestation.EnclaveTypes {
+		keys[string(storage.PlatformCountKey(regionID, t))] = state.Read
+	}
+}
+
+// checkPlatform enforces the platform policy of [regionID] on an
+// attestation of type [claimed] from [enclaveID]. Enclaves registered from
+// an attestation document have a recorded type the attestation must match.
+func checkPlatform(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte, claimed attestation.EnclaveType) error {
+	recorded, err := storage.GetEnclaveType(ctx, im, regionID, enclaveID)
+	if err != nil {
+		return err
+	}
+	if recorded != "" && recorded != claimed {
+		return fmt.Errorf("%w: (registered=%s, attested=%s)", ErrEnclaveTypeMismatch, recorded, claimed)
+	}
+	policy, err := storage.GetPlatformPolicy(ctx, im, regionID)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return nil
+	}
+	if !policy.Allows(claimed) {
+		return fmt.Errorf("%w: %s", attestation.ErrPlatformNotAllowed, claimed)
+	}
+	return policy.Satisfied(func(t attestation.EnclaveType) (uint64, error) {
+		return storage.GetPlatformCount(ctx, im, regionID, t)
+	})
+}
+
+func validatePlatformPolicy(p *attestation.PlatformPolicy) error {
+	seen := make(map[attestation.EnclaveType]struct{}, len(p.Requirements))
+	for _, req := range p.Requirements {
+		if !req.Type.Valid() {
+			return fmt.Errorf("%w: %w: %q", ErrInvalidPlatformPolicy, attestation.ErrUnknownEnclaveType, req.Type)
+		}
+		if _, ok := seen[req.Type]; ok {
+			return fmt.Errorf("%w: duplicate %s", ErrInvalidPlatformPolicy, req.Type)
+		}
+		seen[req.Type] = struct{}{}
+	}
+	if p.Heterogeneous && p.Vendors() < 2 {
+		return fmt.Errorf("%w: heterogeneous policy allows a single vendor", ErrInvalidPlatformPolicy)
+	}
+	if len(p.RealmMeasurements) > MaxRealmMeasurements {
+		return fmt.Errorf("%w: too many realm measurements", ErrInvalidPlatformPolicy)
+	}
+	for _, m := range p.RealmMeasurements {
+		if len(m) == 0 || len(m) > MaxRealmMeasurementLen {
+			return fmt.Errorf("%w: realm measurement length %d", ErrInvalidPlatformPolicy, len(m))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// PreVerify runs the attestation checks of [action] against [im] as if it
+// executed at [timestamp]: that each attestation is signed by an active
+// enclave of the region and carries Roughtime stamps within the governed
+// drift. Actions without attestations pass. Every check is one Execute
+// also makes, so an action rejected here would fail against the same
+// state.
+func PreVerify(ctx context.Context, im state.Immutable, timestamp int64, action chain.Action) error {
+	switch a := action.(type) {
+	case *TEEExecAction:
+		return a.preVerify(ctx, im, timestamp)
+	case *PublishFeedAction:
+		if err := verifySettlementSigner(ctx, im, timestamp, a.RegionID, FeedDigest(a.FeedID, a.Round, a.Value), &a.Attestation); err != nil {
+			return err
+		}
+		return checkStamps(ctx, im, timestamp, &a.Attestation)
+	}
+	return nil
+}
+
+func (t *TEEExecAction) preVerify(ctx context.Context, im state.Immutable, timestamp int64) error {
+	_, exists, err := storage.GetRegion(ctx, im, t.RegionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrInvalidRegion
+	}
+	pubKey, digest, err := t.signedDigest(ctx, im, timestamp, &t.Attestation, t.ExecResult)
+	if err != nil {
+		return err
+	}
+	if t.Attestation.Flags.Aggregated() {
+		if t.Peer == nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSignature, ErrAggregateWithoutPeer)
+		}
+	} else if err := t.Attestation.Verify(ExecDigest(digest, t.RegionID, t.TxData, t.EventID), pubKey); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if err := checkStamps(ctx, im, timestamp, &t.Attestation); err != nil {
+		return err
+	}
+	if t.Peer == nil {
+		return nil
+	}
+	if bytes.Equal(t.Peer.Attestation.EnclaveID, t.Attestation.EnclaveID) {
+		return ErrPeerSameEnclave
+	}
+	peerKey, peerDigest, err := t.signedDigest(ctx, im, timestamp, &t.Peer.Attestation, t.Peer.ExecResult)
+	if err != nil {
+		return err
+	}
+	if err := t.verifyPeerSignature(pubKey, digest, peerKey, peerDigest); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return checkStamps(ctx, im, timestamp, &t.Peer.Attestation)
+}
+
+// signedDigest returns the key of the active enclave that attested [a]
+// and the digest of [result], the execution it signed.
+func (t *TEEExecAction) signedDigest(
+	ctx context.Context,
+	im state.Immutable,
+	timestamp int64,
+	a *attestation.Attestation,
+	result TEEExecResult,
+) ([]byte, []byte, error) {
+	pubKey, err := activeEnclave(ctx, im, t.RegionID, a.EnclaveID, timestamp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkPlatform(ctx, im, t.RegionID, a.EnclaveID, a.EnclaveType); err != nil {
+		return nil, nil, err
+	}
+	resolved, err := resolveStateRefs(ctx, im, result)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest, err := resolved.Digest()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pubKey, digest, nil
+}
+
+// checkStamps checks that the stamps of [a] are signed by a quorum of the
+// governed Roughtime servers and that their median is within the governed
+// drift of [timestamp].
+func checkStamps(ctx context.Context, im state.Immutable, timestamp int64, a *attestation.Attestation) error {
+	stampKeys, err := RoughtimeKeys(ctx, im)
+	if err != nil {
+		return err
+	}
+	quorum, err := RoughtimeQuorum(ctx, im)
+	if err != nil {
+		return err
+	}
+	medianTime, err := a.TrustedMedianTime(stampKeys, quorum)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTimeStamps, err)
+	}
+	maxDrift, err := Uint64Param(ctx, im, consts.ParamTimeDrift, consts.MaxTimeDrift)
+	if err != nil {
+		return err
+	}
+	if !attestation.WithinDrift(medianTime, uint64(timestamp/1000), maxDrift) {
+		return ErrStaleTimeStamp
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrRateLimited = errors.New("account rate limited")
+
+// RateLimits is the value encoding of [consts.ParamRateLimits]: how many
+// actions an account may take per block, and per sliding window of
+// [WindowMs] milliseconds. Zero limits are not enforced.
+type RateLimits struct {
+	PerBlock  uint32 `serialize:"true" json:"per_block"`
+	PerWindow uint32 `serialize:"true" json:"per_window"`
+	WindowMs  uint64 `serialize:"true" json:"window_ms"`
+}
+
+func (l *RateLimits) validate() error {
+	if l.PerWindow != 0 && l.WindowMs == 0 {
+		return fmt.Errorf("%w: window limit without a window", ErrInvalidParamValue)
+	}
+	return nil
+}
+
+// Enabled reports whether [l] limits anything.
+func (l *RateLimits) Enabled() bool {
+	return l.PerBlock != 0 || l.PerWindow != 0
+}
+
+// GovernedRateLimits returns the rate limits in effect at the current
+// height, none if governance never set any.
+func GovernedRateLimits(ctx context.Context, im state.Immutable) (*RateLimits, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(consts.ParamRateLimits))
+	if err != nil {
+		return nil, err
+	}
+	var l RateLimits
+	if v := p.Value(height); len(v) > 0 {
+		if err := codec.Unmarshal(v, &l); err != nil {
+			return nil, err
+		}
+	}
+	return &l, nil
+}
+
+// CheckRateLimit returns [ErrRateLimited] if [actor] already reached a
+// governed rate limit, and otherwise counts one more action of [actor] at
+// [timestamp].
+//
+// The window limit uses a sliding window counter: the count of the
+// previous window is weighted by how much of it still overlaps the window
+// ending at [timestamp], and added to the count of the current one. This
+// keeps two counters per account rather than a log of its actions.
+func CheckRateLimit(ctx context.Context, mu state.Mutable, actor codec.Address, timestamp int64) error {
+	limits, err := GovernedRateLimits(ctx, mu)
+	if err != nil {
+		return err
+	}
+	if !limits.Enabled() {
+		return nil
+	}
+	height, err := storage.GetHeight(ctx, mu)
+	if err != nil {
+		return err
+	}
+	c, err := storage.GetRateCounter(ctx, mu, actor)
+	if err != nil {
+		return err
+	}
+	if c.Height != height {
+		c.Height = height
+		c.InBlock = 0
+	}
+	if limits.PerBlock != 0 && c.InBlock >= limits.PerBlock {
+		return fmt.Errorf("%w: %d actions in block %d", ErrRateLimited, c.InBlock, height)
+	}
+	if limits.PerWindow != 0 {
+		ts := uint64(max(timestamp, 0))
+		window := ts / limits.WindowMs
+		switch {
+		case window == c.Window:
+		case window == c.Window+1:
+			c.Window, c.Previous, c.Current = window, c.Current, 0
+		default:
+			c.Window, c.Previous, c.Current = window, 0, 0
+		}
+		elapsed := ts % limits.WindowMs
+		estimate := uint64(c.Previous)*(limits.WindowMs-elapsed)/limits.WindowMs + uint64(c.Current)
+		if estimate >= uint64(limits.PerWindow) {
+			return fmt.Errorf("%w: %d actions in the last %dms", ErrRateLimited, estimate, limits.WindowMs)
+		}
+		c.Current++
+	}
+	c.InBlock++
+	return storage.SetRateCounter(ctx, mu, actor, c)
+}
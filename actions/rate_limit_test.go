@@ -0,0 +1,100 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestRateLimitedExecute(t *testing.T) {
+	actor := codectest.NewRandomAddress()
+	assetID := ids.GenerateTestID()
+	limits := &RateLimits{PerBlock: 1, PerWindow: 2, WindowMs: 1_000}
+
+	// limitedState is at [height], with [actor] holding 10 of [assetID]
+	// and having acted as [counter] counts
+	limitedState := func(height uint64, counter *storage.RateCounter) state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, store.Insert(ctx, storage.HeightKey(), binary.BigEndian.AppendUint64(nil, height)))
+		v, err := codec.Marshal(limits)
+		require.NoError(t, err)
+		require.NoError(t, storage.ScheduleParam(ctx, store, uint8(consts.ParamRateLimits), v, 0, 0))
+		_, err = storage.AddAssetBalance(ctx, store, assetID, actor, 10, true)
+		require.NoError(t, err)
+		require.NoError(t, storage.SetRateCounter(ctx, store, actor, counter))
+		return store
+	}
+	counterIs := func(counter *storage.RateCounter) func(context.Context, *testing.T, state.Mutable) {
+		return func(ctx context.Context, t *testing.T, store state.Mutable) {
+			got, err := storage.GetRateCounter(ctx, store, actor)
+			require.NoError(t, err)
+			require.Equal(t, counter, got)
+		}
+	}
+	transfer := &TransferAssetAction{AssetID: assetID, To: codectest.NewRandomAddress(), Value: 1}
+	result := &TransferAssetResult{SenderBalance: 9, ReceiverBalance: 1}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:            "FirstInBlock",
+			Actor:           actor,
+			Action:          transfer,
+			State:           limitedState(5, &storage.RateCounter{}),
+			Timestamp:       500,
+			ExpectedOutputs: result,
+			Assertion:       counterIs(&storage.RateCounter{Height: 5, InBlock: 1, Current: 1}),
+		},
+		{
+			Name:        "BlockLimit",
+			Actor:       actor,
+			Action:      transfer,
+			State:       limitedState(5, &storage.RateCounter{Height: 5, InBlock: 1, Current: 1}),
+			Timestamp:   500,
+			ExpectedErr: ErrRateLimited,
+		},
+		{
+			Name:        "WindowLimit",
+			Actor:       actor,
+			Action:      transfer,
+			State:       limitedState(6, &storage.RateCounter{Height: 5, InBlock: 1, Current: 2}),
+			Timestamp:   500,
+			ExpectedErr: ErrRateLimited,
+		},
+		{
+			// Half the previous window still overlaps
+			Name:        "SlidingWindowLimit",
+			Actor:       actor,
+			Action:      transfer,
+			State:       limitedState(6, &storage.RateCounter{Height: 5, InBlock: 1, Current: 4}),
+			Timestamp:   1_500,
+			ExpectedErr: ErrRateLimited,
+		},
+		{
+			Name:            "WindowsLater",
+			Actor:           actor,
+			Action:          transfer,
+			State:           limitedState(6, &storage.RateCounter{Height: 5, InBlock: 1, Current: 2}),
+			Timestamp:       2_500,
+			ExpectedOutputs: result,
+			Assertion:       counterIs(&storage.RateCounter{Height: 6, InBlock: 1, Window: 2, Current: 1}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrEnclaveExpired      = errors.New("enclave attestation expired")
+	ErrReattestUnsupported = errors.New("enclave type cannot re-attest on chain")
+	ErrReattestKeyMismatch = errors.New("attestation binds a different enclave key")
+
+	_ chain.Action = (*ReattestEnclaveAction)(nil)
+)
+
+// ReattestEnclaveAction renews the registration of an enclave of
+// [RegionID] with fresh attestation evidence, restarting its validity
+// period. An enclave whose registration lapsed is treated as inactive until
+// it re-attests. [Evidence] is a Nitro attestation document binding the
+// enclave key, or a CCA attestation token whose challenge is
+// [CCAReattestChallenge] of the region, key and current expiry. Anyone may
+// submit it.
+type ReattestEnclaveAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+	Evidence  []byte `serialize:"true" json:"evidence"`
+}
+
+func (*ReattestEnclaveAction) GetTypeID() uint8 {
+	return consts.ReattestEnclaveID
+}
+
+func (r *ReattestEnclaveAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.HeightKey()):                                      state.Read,
+		string(storage.ParamKey(uint8(consts.ParamTimeDrift))):           state.Read,
+		string(storage.ParamKey(uint8(consts.ParamAttestationValidity))): state.Read,
+		string(storage.NitroRootKey()):                                   state.Read,
+		string(storage.NitroPolicyKey(r.RegionID)):                       state.Read,
+		string(storage.CCAPlatformKeysKey()):                             state.Read,
+		string(storage.PlatformPolicyKey(r.RegionID)):                    state.Read,
+		string(storage.EnclaveKey(r.RegionID, r.EnclaveID)):              state.Read,
+		string(storage.EnclavePubKeyKey(r.RegionID, r.EnclaveID)):        state.Read,
+		string(storage.EnclaveTypeKey(r.RegionID, r.EnclaveID)):          state.Read,
+		string(storage.EnclaveExpiryKey(r.RegionID, r.EnclaveID)):        state.All,
+	}, r.RegionID, actionID), actor, r)
+}
+
+func (r *ReattestEnclaveAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.ReattestEnclaveID, r.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	status, pubKey, err := storage.GetEnclave(ctx, mu, r.RegionID, r.EnclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if status != storage.EnclaveActive {
+		return nil, ErrInvalidEnclave
+	}
+	enclaveType, err := storage.GetEnclaveType(ctx, mu, r.RegionID, r.EnclaveID)
+	if err != nil {
+		return nil, err
+	}
+	switch enclaveType {
+	case attestation.Nitro:
+		doc, err := verifyNitroEvidence(ctx, mu, r.RegionID, r.Evidence, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(doc.PublicKey, pubKey) {
+			return nil, ErrReattestKeyMismatch
+		}
+	case attestation.CCA:
+		expiry, err := storage.GetEnclaveExpiry(ctx, mu, r.RegionID, r.EnclaveID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := verifyCCAEvidence(ctx, mu, r.RegionID, r.Evidence, CCAReattestChallenge(r.RegionID, pubKey, expiry)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrReattestUnsupported, enclaveType)
+	}
+
+	expiry, err := renewEnclave(ctx, mu, r.RegionID, r.EnclaveID, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, r.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.ReattestEnclaveID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		EnclaveID:    r.EnclaveID,
+		Attestations: evidenceHashes(r.Evidence),
+	}); err != nil {
+		return nil, err
+	}
+	return &ReattestEnclaveResult{
+		RegionID:  r.RegionID,
+		EnclaveID: r.EnclaveID,
+		Expiry:    expiry,
+	}, nil
+}
+
+func (r *ReattestEnclaveAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.TEEUnits + schedule.QuoteUnits(len(r.Evidence))
+}
+
+// ValidRange expires a Nitro reattestation once its document is too old
+// to pass the drift check. CCA evidence carries no time and is always
+// valid.
+func (r *ReattestEnclaveAction) ValidRange(chain.Rules) (int64, int64) {
+	return nitroRange(r.Evidence)
+}
+
+type ReattestEnclaveResult struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+	// Expiry is the unix millisecond time the enclave must re-attest by
+	Expiry uint64 `serialize:"true" json:"expiry"`
+}
+
+func (*ReattestEnclaveResult) GetTypeID() uint8 {
+	return consts.ReattestEnclaveResultID
+}
+
+// renewEnclave starts a new validity period for an enclave at [timestamp]
+// and returns when it ends.
+func renewEnclave(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte, timestamp int64) (uint64, error) {
+	validity, err := Uint64Param(ctx, mu, consts.ParamAttestationValidity, consts.AttestationValidity)
+	if err != nil {
+		return 0, err
+	}
+	expiry := uint64(timestamp) + validity*1000
+	return expiry, storage.SetEnclaveExpiry(ctx, mu, regionID, enclaveID, expiry)
+}
+
+// activeEnclave returns the public key of an enclave of [regionID] that may
+// attest at [timestamp]. Enclaves whose registration lapsed are inactive
+// until they re-attest.
+func activeEnclave(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte, timestamp int64) ([]byte, error) {
+	status, pubKey, err := storage.GetEnclave(ctx, im, regionID, enclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if status != storage.EnclaveActive {
+		return nil, ErrInvalidEnclave
+	}
+	expiry, err := storage.GetEnclaveExpiry(ctx, im, regionID, enclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if expiry != 0 && uint64(timestamp) >= expiry {
+		return nil, ErrEnclaveExpired
+	}
+	return pubKey, nil
+}
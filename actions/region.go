@@ -0,0 +1,478 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrRegionExists   = errors.New("region already exists")
+	ErrRegionNotFound = errors.New("region not found")
+	ErrTooManyTEEs    = errors.New("too many TEEs for region")
+	ErrTooFewTEEs     = errors.New("too few TEEs for region")
+	ErrDuplicateTEE   = errors.New("duplicate TEE address")
+	ErrTEENotInRegion = errors.New("TEE not in region")
+	ErrTEEBusy        = errors.New("TEE has in-flight work")
+	ErrInvalidTEE     = errors.New("invalid TEE address")
+
+	_ chain.Action = (*CreateRegionAction)(nil)
+	_ chain.Action = (*UpdateRegionAction)(nil)
+)
+
+type CreateRegionAction struct {
+	Version  uint8           `json:"version"`
+	RegionID string          `json:"region_id"`
+	TEEs     []codec.Address `json:"tees"`
+}
+
+func (*CreateRegionAction) GetTypeID() uint8 {
+	return consts.CreateRegionID
+}
+
+func (a *CreateRegionAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.RegionKey(a.RegionID)): state.All,
+	}, a.RegionID, actionID), actor, a)
+}
+
+func (a *CreateRegionAction) Marshal(p *codec.Packer) {
+	if protoWire {
+		packProto(p, a.appendProto(nil))
+		return
+	}
+	packVersion(p, a.Version)
+	p.PackString(a.RegionID)
+	packAddresses(p, a.TEEs)
+}
+
+func UnmarshalCreateRegion(p *codec.Packer) (chain.Action, error) {
+	if protoWire {
+		m, err := unpackProto(p)
+		if err != nil {
+			return nil, err
+		}
+		return createRegionFromProto(m)
+	}
+
+	var act CreateRegionAction
+
+	version, err := unpackVersion(p)
+	if err != nil {
+		return nil, err
+	}
+	act.Version = version
+
+	regionID, err := p.UnpackString()
+	if err != nil {
+		return nil, err
+	}
+	act.RegionID = regionID
+
+	tees, err := unpackAddresses(p, consts.MaxTEEsPerRegion)
+	if err != nil {
+		return nil, err
+	}
+	act.TEEs = tees
+
+	return &act, nil
+}
+
+func (a *CreateRegionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.CreateRegionID, a.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	if len(a.RegionID) == 0 || len(a.RegionID) > consts.MaxIDLength {
+		return nil, ErrInvalidID
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, a.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRegionExists
+	}
+	if err := validateTEESet(a.TEEs); err != nil {
+		return nil, err
+	}
+	if err := storage.SetRegion(ctx, mu, a.RegionID, a.TEEs); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, a.RegionID, actionID, &storage.AuditEntry{
+		TypeID:    consts.CreateRegionID,
+		Actor:     actor,
+		Timestamp: timestamp,
+	}); err != nil {
+		return nil, err
+	}
+	return &CreateRegionResult{RegionID: a.RegionID, Success: true}, nil
+}
+
+func (a *CreateRegionAction) ComputeUnits(rules chain.Rules) uint64 {
+	return FeeScheduleOf(rules).RegionUnits(len(a.TEEs))
+}
+
+func (a *CreateRegionAction) ActionVersion() uint8 {
+	return a.Version
+}
+
+func (*CreateRegionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type UpdateRegionAction struct {
+	Version  uint8           `json:"version"`
+	RegionID string          `json:"region_id"`
+	AddTEEs  []codec.Address `json:"add_tees"`
+	RemTEEs  []codec.Address `json:"rem_tees"`
+}
+
+func (*UpdateRegionAction) GetTypeID() uint8 {
+	return consts.UpdateRegionID
+}
+
+func (a *UpdateRegionAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	keys := addTEEUpdateKeys(state.Keys{
+		string(storage.RegionKey(a.RegionID)):         state.Read | state.Write,
+		string(storage.RegionTemplateKey(a.RegionID)): state.Read,
+		string(storage.RegionFreezeKey(a.RegionID)):   state.Read,
+	}, a.RegionID, a.AddTEEs, a.RemTEEs)
+	keys[string(storage.SettlementOpenKey(a.RegionID))] = state.Read
+	return addGateKeys(addAuditKeys(keys, a.RegionID, actionID), actor, a)
+}
+
+func (a *UpdateRegionAction) Marshal(p *codec.Packer) {
+	if protoWire {
+		packProto(p, a.appendProto(nil))
+		return
+	}
+	packVersion(p, a.Version)
+	p.PackString(a.RegionID)
+	packAddresses(p, a.AddTEEs)
+	packAddresses(p, a.RemTEEs)
+}
+
+func UnmarshalUpdateRegion(p *codec.Packer) (chain.Action, error) {
+	if protoWire {
+		m, err := unpackProto(p)
+		if err != nil {
+			return nil, err
+		}
+		return updateRegionFromProto(m)
+	}
+
+	var act UpdateRegionAction
+
+	version, err := unpackVersion(p)
+	if err != nil {
+		return nil, err
+	}
+	act.Version = version
+
+	regionID, err := p.UnpackString()
+	if err != nil {
+		return nil, err
+	}
+	act.RegionID = regionID
+
+	addTEEs, err := unpackAddresses(p, consts.MaxTEEsPerRegion)
+	if err != nil {
+		return nil, err
+	}
+	act.AddTEEs = addTEEs
+
+	remTEEs, err := unpackAddresses(p, consts.MaxTEEsPerRegion)
+	if err != nil {
+		return nil, err
+	}
+	act.RemTEEs = remTEEs
+
+	return &act, nil
+}
+
+func (a *UpdateRegionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.UpdateRegionID, a.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	tees, exists, err := storage.GetRegion(ctx, mu, a.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	if err := checkRegionFrozen(ctx, mu, a.RegionID); err != nil {
+		return nil, err
+	}
+
+	updated, err := applyTEEUpdate(tees, a.AddTEEs, a.RemTEEs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTEEsIdle(ctx, mu, a.RegionID, a.RemTEEs); err != nil {
+		return nil, err
+	}
+	template, err := RegionTemplateOf(ctx, mu, a.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if template != nil {
+		if err := template.checkTEEs(updated); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := storage.SetRegion(ctx, mu, a.RegionID, updated); err != nil {
+		return nil, err
+	}
+	// Removed TEEs stop attesting at once, but their records are kept
+	// for the retention window
+	if err := updateEnclaves(ctx, mu, a.RegionID, a.AddTEEs, a.RemTEEs, timestamp); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, a.RegionID, actionID, &storage.AuditEntry{
+		TypeID:    consts.UpdateRegionID,
+		Actor:     actor,
+		Timestamp: timestamp,
+	}); err != nil {
+		return nil, err
+	}
+	return &UpdateRegionResult{RegionID: a.RegionID, Success: true}, nil
+}
+
+func (a *UpdateRegionAction) ComputeUnits(rules chain.Rules) uint64 {
+	return FeeScheduleOf(rules).RegionUnits(len(a.AddTEEs) + len(a.RemTEEs))
+}
+
+func (a *UpdateRegionAction) ActionVersion() uint8 {
+	return a.Version
+}
+
+func (*UpdateRegionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+// Result types
+type CreateRegionResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Success  bool   `serialize:"true" json:"success"`
+}
+
+func (*CreateRegionResult) GetTypeID() uint8 {
+	return consts.CreateRegionResultID
+}
+
+type UpdateRegionResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Success  bool   `serialize:"true" json:"success"`
+}
+
+func (*UpdateRegionResult) GetTypeID() uint8 {
+	return consts.UpdateRegionResultID
+}
+
+// Helper functions
+func packAddresses(p *codec.Packer, addrs []codec.Address) {
+	p.PackInt(len(addrs))
+	for _, addr := range addrs {
+		p.PackAddress(addr)
+	}
+}
+
+// validateTEESet checks that [tees] is within the per-region bounds and
+// contains no empty address or duplicates.
+func validateTEESet(tees []codec.Address) error {
+	if len(tees) < consts.MinTEEsPerRegion {
+		return ErrTooFewTEEs
+	}
+	if len(tees) > consts.MaxTEEsPerRegion {
+		return ErrTooManyTEEs
+	}
+	seen := make(map[codec.Address]struct{}, len(tees))
+	for _, tee := range tees {
+		if tee == codec.EmptyAddress {
+			return ErrInvalidTEE
+		}
+		if _, ok := seen[tee]; ok {
+			return ErrDuplicateTEE
+		}
+		seen[tee] = struct{}{}
+	}
+	return nil
+}
+
+// checkTEEsIdle rejects removing TEEs from [regionID] while work only
+// they can complete is in flight: a settlement they posted must be
+// finalized first. Queued events and requests are not bound to a TEE, so
+// the region's remaining TEEs take them over.
+func checkTEEsIdle(ctx context.Context, im state.Immutable, regionID string, removed []codec.Address) error {
+	open, err := storage.GetOpenSettlement(ctx, im, regionID)
+	if err != nil || open == nil {
+		return err
+	}
+	for _, tee := range removed {
+		if bytes.Equal(open, tee[:]) {
+			return fmt.Errorf("%w: open settlement of %s", ErrTEEBusy, tee)
+		}
+	}
+	return nil
+}
+
+// applyTEEUpdate removes [rem] from and appends [add] to [current]. Every
+// removed TEE must be a member and no added TEE may already be one (or be
+// added twice). The resulting set must satisfy [validateTEESet].
+func applyTEEUpdate(current, add, rem []codec.Address) ([]codec.Address, error) {
+	members := make(map[codec.Address]struct{}, len(current))
+	for _, tee := range current {
+		members[tee] = struct{}{}
+	}
+
+	removed := make(map[codec.Address]struct{}, len(rem))
+	for _, tee := range rem {
+		if _, ok := members[tee]; !ok {
+			return nil, ErrTEENotInRegion
+		}
+		if _, ok := removed[tee]; ok {
+			return nil, ErrDuplicateTEE
+		}
+		removed[tee] = struct{}{}
+	}
+
+	updated := make([]codec.Address, 0, len(current)+len(add))
+	for _, tee := range current {
+		if _, ok := removed[tee]; !ok {
+			updated = append(updated, tee)
+		}
+	}
+	for _, tee := range add {
+		if _, ok := members[tee]; ok {
+			if _, ok := removed[tee]; !ok {
+				return nil, ErrDuplicateTEE
+			}
+		}
+		updated = append(updated, tee)
+	}
+	if err := validateTEESet(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// unpackAddresses rejects counts above [limit] before allocating.
+func unpackAddresses(p *codec.Packer, limit int) ([]codec.Address, error) {
+	count, err := p.UnpackInt()
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 || count > limit {
+		return nil, ErrTooManyTEEs
+	}
+	addrs := make([]codec.Address, count)
+	for i := 0; i < count; i++ {
+		addr, err := p.UnpackAddress()
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = addr
+	}
+	return addrs, nil
+}
+
+// ActionRegion returns the region [action] acts on, if any.
+func ActionRegion(action chain.Action) string {
+	switch a := action.(type) {
+	case *TEEExecAction:
+		return a.RegionID
+	case *CreateRegionAction:
+		return a.RegionID
+	case *UpdateRegionAction:
+		return a.RegionID
+	case *QueueRequestAction:
+		return a.RegionID
+	case *ClaimRewardsAction:
+		return a.RegionID
+	case *SettleRegionAction:
+		return a.RegionID
+	case *ChallengeSettlementAction:
+		return a.RegionID
+	case *FinalizeSettlementAction:
+		return a.RegionID
+	case *SetNitroPolicyAction:
+		return a.RegionID
+	case *RegisterNitroEnclaveAction:
+		return a.RegionID
+	case *SetPlatformPolicyAction:
+		return a.RegionID
+	case *SetExecLimitsAction:
+		return a.RegionID
+	case *FreezeAndExportRegionAction:
+		return a.RegionID
+	case *ImportRegionAction:
+		return a.RegionID
+	case *RegisterCCAEnclaveAction:
+		return a.RegionID
+	case *ReattestEnclaveAction:
+		return a.RegionID
+	case *CreateAssetAction:
+		return a.RegionID
+	case *PublishRandomnessAction:
+		return a.RegionID
+	case *RegisterFeedAction:
+		return a.RegionID
+	case *PublishFeedAction:
+		return a.RegionID
+	case *SealStorageAction:
+		return a.RegionID
+	case *ResealStorageAction:
+		return a.RegionID
+	case *PublishAppKeyAction:
+		return a.RegionID
+	case *RotateAppKeyAction:
+		return a.RegionID
+	case *RevokeAppKeyAction:
+		return a.RegionID
+	case *CreateRegionFromTemplateAction:
+		return a.RegionID
+	case *SubmitCheckpointAction:
+		return a.RegionID
+	case *PruneEnclaveAction:
+		return a.RegionID
+	case *PreemptEventAction:
+		return a.RegionID
+	}
+	return ""
+}
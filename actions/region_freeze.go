@@ -0,0 +1,364 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// MaxImportRecords bounds the snapshot records one [ImportRegionAction]
+// writes.
+const MaxImportRecords = 64
+
+var (
+	ErrRegionFrozen      = errors.New("region is frozen")
+	ErrImportInProgress  = errors.New("region import already in progress")
+	ErrImportNotFound    = errors.New("no region import in progress")
+	ErrImportMismatch    = errors.New("chunk does not continue the region import")
+	ErrNotImporter       = errors.New("actor did not start the region import")
+	ErrTooManyRecords    = errors.New("too many snapshot records")
+	ErrSchemaMismatch    = errors.New("snapshot schema version differs from state")
+	ErrEmptyRegionImport = errors.New("region snapshot has no records")
+
+	_ chain.Action = (*FreezeAndExportRegionAction)(nil)
+	_ chain.Action = (*ImportRegionAction)(nil)
+)
+
+// checkRegionFrozen returns [ErrRegionFrozen] if [regionID] is frozen.
+func checkRegionFrozen(ctx context.Context, im state.Immutable, regionID string) error {
+	freeze, err := storage.GetRegionFreeze(ctx, im, regionID)
+	if err != nil {
+		return err
+	}
+	if freeze != nil {
+		return ErrRegionFrozen
+	}
+	return nil
+}
+
+// FreezeAndExportRegionAction halts [RegionID], once signed by a threshold
+// of the admin keys, so its state can be exported and imported elsewhere
+// when its whole TEE fleet must be replaced. The freeze records the
+// region's latest attested root, and exports of the region carry it in
+// their manifest. A frozen region stays frozen.
+type FreezeAndExportRegionAction struct {
+	RegionID   string           `serialize:"true" json:"region_id"`
+	Nonce      uint64           `serialize:"true" json:"nonce"`
+	Signatures []AdminSignature `serialize:"true" json:"signatures"`
+}
+
+func (*FreezeAndExportRegionAction) GetTypeID() uint8 {
+	return consts.FreezeAndExportRegionID
+}
+
+func (f *FreezeAndExportRegionAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.AdminSetKey()):               state.Read,
+		string(storage.AdminNonceKey()):             state.All,
+		string(storage.RegionKey(f.RegionID)):       state.Read,
+		string(storage.RegionRootKey(f.RegionID)):   state.Read,
+		string(storage.RegionFreezeKey(f.RegionID)): state.All,
+	}, f.RegionID, actionID), actor, f)
+}
+
+// Digest is the message each admin key signs on the chain [chainID].
+func (f *FreezeAndExportRegionAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.FreezeAndExportRegionID)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(f.RegionID)))
+	d = append(d, f.RegionID...)
+	return binary.BigEndian.AppendUint64(d, f.Nonce)
+}
+
+func (f *FreezeAndExportRegionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.FreezeAndExportRegionID, f.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, f); err != nil {
+		return nil, err
+	}
+
+	if len(f.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, f.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	if err := checkRegionFrozen(ctx, mu, f.RegionID); err != nil {
+		return nil, err
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if f.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, f.Digest(rules.GetChainID()), f.Signatures); err != nil {
+		return nil, err
+	}
+
+	root, err := storage.GetRegionRoot(ctx, mu, f.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	freeze := &storage.RegionFreeze{FrozenAt: timestamp, RegionRoot: root}
+	if err := storage.SetRegionFreeze(ctx, mu, f.RegionID, freeze); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, f.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.FreezeAndExportRegionID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		Attestations: signatureHashes(f.Signatures),
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	return &FreezeAndExportRegionResult{
+		RegionID:   f.RegionID,
+		FrozenAt:   freeze.FrozenAt,
+		RegionRoot: freeze.RegionRoot,
+		Nonce:      f.Nonce,
+	}, nil
+}
+
+func (f *FreezeAndExportRegionAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + uint64(len(f.Signatures))*schedule.AttestationUnits
+}
+
+func (*FreezeAndExportRegionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type FreezeAndExportRegionResult struct {
+	RegionID   string `serialize:"true" json:"region_id"`
+	FrozenAt   int64  `serialize:"true" json:"frozen_at"`
+	RegionRoot ids.ID `serialize:"true" json:"region_root"`
+	Nonce      uint64 `serialize:"true" json:"nonce"`
+}
+
+func (*FreezeAndExportRegionResult) GetTypeID() uint8 {
+	return consts.FreezeAndExportRegionResultID
+}
+
+// ImportRegionAction writes a chunk of a region snapshot, exported with
+// cmd/snapshot, into a region that does not exist yet. The first chunk, at
+// [Offset] zero, must be signed by a threshold of the admin keys over the
+// snapshot's manifest; the others are accepted from the actor that
+// submitted it, in order. The region is frozen until the last chunk is in
+// and the records match the manifest hash.
+type ImportRegionAction struct {
+	RegionID      string                   `serialize:"true" json:"region_id"`
+	SnapshotHash  ids.ID                   `serialize:"true" json:"snapshot_hash"`
+	Records       uint64                   `serialize:"true" json:"records"`
+	SchemaVersion uint32                   `serialize:"true" json:"schema_version"`
+	Offset        uint64                   `serialize:"true" json:"offset"`
+	Entries       []storage.SnapshotRecord `serialize:"true" json:"entries"`
+	Nonce         uint64                   `serialize:"true" json:"nonce"`
+	Signatures    []AdminSignature         `serialize:"true" json:"signatures"`
+}
+
+func (*ImportRegionAction) GetTypeID() uint8 {
+	return consts.ImportRegionID
+}
+
+func (i *ImportRegionAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.AdminSetKey()):               state.Read,
+		string(storage.AdminNonceKey()):             state.All,
+		string(storage.RegionKey(i.RegionID)):       state.All,
+		string(storage.RegionFreezeKey(i.RegionID)): state.All,
+		string(storage.RegionImportKey(i.RegionID)): state.All,
+	}
+	for _, record := range i.Entries {
+		keys[string(record.Key)] = state.All
+	}
+	return addGateKeys(keys, actor, i)
+}
+
+// Digest is the message each admin key signs on the chain [chainID] to start
+// an import. It binds the manifest, not the chunks: the chunks are checked
+// against its hash.
+func (i *ImportRegionAction) Digest(chainID ids.ID) []byte {
+	d := adminDigest(chainID, consts.ImportRegionID)
+	d = binary.BigEndian.AppendUint16(d, uint16(len(i.RegionID)))
+	d = append(d, i.RegionID...)
+	d = append(d, i.SnapshotHash[:]...)
+	d = binary.BigEndian.AppendUint64(d, i.Records)
+	d = binary.BigEndian.AppendUint32(d, i.SchemaVersion)
+	return binary.BigEndian.AppendUint64(d, i.Nonce)
+}
+
+func (i *ImportRegionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.ImportRegionID, i.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, i); err != nil {
+		return nil, err
+	}
+
+	if len(i.Entries) > MaxImportRecords {
+		return nil, ErrTooManyRecords
+	}
+	var imp *storage.RegionImport
+	if i.Offset == 0 {
+		imp, err = i.start(ctx, mu, timestamp, actor)
+	} else {
+		imp, err = i.resume(ctx, mu, actor)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := imp.Apply(ctx, mu, i.RegionID, i.Entries)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		if err := storage.RemoveRegionImport(ctx, mu, i.RegionID); err != nil {
+			return nil, err
+		}
+		if err := storage.RemoveRegionFreeze(ctx, mu, i.RegionID); err != nil {
+			return nil, err
+		}
+	} else if err := storage.SetRegionImport(ctx, mu, i.RegionID, imp); err != nil {
+		return nil, err
+	}
+	return &ImportRegionResult{
+		RegionID: i.RegionID,
+		Imported: imp.Imported,
+		Done:     done,
+	}, nil
+}
+
+// start checks the admin signatures over the manifest of a new import and
+// freezes the region until it is done.
+func (i *ImportRegionAction) start(ctx context.Context, mu state.Mutable, timestamp int64, actor codec.Address) (*storage.RegionImport, error) {
+	if len(i.Signatures) > MaxAdminSignatures {
+		return nil, ErrTooManyAdminSigs
+	}
+	if len(i.RegionID) == 0 || len(i.RegionID) > consts.MaxIDLength {
+		return nil, ErrInvalidID
+	}
+	if i.Records == 0 {
+		return nil, ErrEmptyRegionImport
+	}
+	if i.SchemaVersion != storage.DefaultMigrations.Latest() {
+		return nil, ErrSchemaMismatch
+	}
+	if prev, err := storage.GetRegionImport(ctx, mu, i.RegionID); err != nil {
+		return nil, err
+	} else if prev != nil {
+		return nil, ErrImportInProgress
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, i.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRegionExists
+	}
+	set, err := storage.GetAdminSet(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := storage.GetAdminNonce(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	if i.Nonce != nonce {
+		return nil, ErrInvalidAdminNonce
+	}
+	if err := verifyAdminSignatures(set, i.Digest(rules.GetChainID()), i.Signatures); err != nil {
+		return nil, err
+	}
+	if err := storage.SetAdminNonce(ctx, mu, nonce+1); err != nil {
+		return nil, err
+	}
+	if err := storage.SetRegionFreeze(ctx, mu, i.RegionID, &storage.RegionFreeze{FrozenAt: timestamp}); err != nil {
+		return nil, err
+	}
+	return &storage.RegionImport{
+		Importer:     actor,
+		SnapshotHash: i.SnapshotHash,
+		Records:      i.Records,
+	}, nil
+}
+
+// resume returns the import this chunk continues.
+func (i *ImportRegionAction) resume(ctx context.Context, mu state.Mutable, actor codec.Address) (*storage.RegionImport, error) {
+	imp, err := storage.GetRegionImport(ctx, mu, i.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if imp == nil {
+		return nil, ErrImportNotFound
+	}
+	if imp.Importer != actor {
+		return nil, ErrNotImporter
+	}
+	if imp.SnapshotHash != i.SnapshotHash || imp.Records != i.Records || imp.Imported != i.Offset {
+		return nil, ErrImportMismatch
+	}
+	return imp, nil
+}
+
+func (i *ImportRegionAction) ComputeUnits(rules chain.Rules) uint64 {
+	size := 0
+	for _, record := range i.Entries {
+		size += len(record.Key) + len(record.Value)
+	}
+	return FeeScheduleOf(rules).ExecUnits(len(i.Signatures), 0, len(i.Entries), size, 0)
+}
+
+func (*ImportRegionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type ImportRegionResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	// Imported is how many records of the snapshot are in
+	Imported uint64 `serialize:"true" json:"imported"`
+	// Done is set once the import is complete and the region unfrozen
+	Done bool `serialize:"true" json:"done"`
+}
+
+func (*ImportRegionResult) GetTypeID() uint8 {
+	return consts.ImportRegionResultID
+}
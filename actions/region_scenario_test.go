@@ -0,0 +1,704 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+// orderedPair returns the enclaves of [f] ordered by enclave ID, the order
+// pair-signed region changes list their shares in
+func orderedPair(f *testvm.Fixture) (*testvm.Enclave, *testvm.Enclave) {
+	if bytes.Compare(f.SGX.ID(), f.SEV.ID()) > 0 {
+		return f.SEV, f.SGX
+	}
+	return f.SGX, f.SEV
+}
+
+func TestRegionKeyring(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	// Keyring changes are signed by both enclaves
+	first, second := orderedPair(f)
+	pair := func(op uint8, name string, version uint64, purpose uint8, publicKey []byte) []attestation.Attestation {
+		return []attestation.Attestation{
+			first.SignKeyring(op, testvm.Region, name, version, purpose, publicKey),
+			second.SignKeyring(op, testvm.Region, name, version, purpose, publicKey),
+		}
+	}
+	publish := func(name string, version uint64, purpose uint8, publicKey []byte) *actions.PublishAppKeyAction {
+		return &actions.PublishAppKeyAction{
+			RegionID:     testvm.Region,
+			Name:         name,
+			Purpose:      purpose,
+			Version:      version,
+			PublicKey:    publicKey,
+			Attestations: pair(consts.PublishAppKeyID, name, version, purpose, publicKey),
+		}
+	}
+	rotate := func(version uint64, publicKey []byte) *actions.RotateAppKeyAction {
+		return &actions.RotateAppKeyAction{
+			RegionID:     testvm.Region,
+			Name:         "inbox",
+			Version:      version,
+			PublicKey:    publicKey,
+			Attestations: pair(consts.RotateAppKeyID, "inbox", version, storage.AppKeyEncryption, publicKey),
+		}
+	}
+	encKey := func() []byte {
+		priv, err := envelope.GenerateKey()
+		require.NoError(t, err)
+		return priv.PublicKey().Bytes()
+	}
+
+	signer, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	verifyKey := signer.PublicKey()
+	single := publish("inbox", 1, storage.AppKeyEncryption, encKey())
+	single.Attestations = single.Attestations[:1]
+	swapped := publish("inbox", 1, storage.AppKeyEncryption, encKey())
+	swapped.Attestations[0], swapped.Attestations[1] = swapped.Attestations[1], swapped.Attestations[0]
+	v1, v2, v3 := encKey(), encKey(), encKey()
+	revoke := &actions.RevokeAppKeyAction{
+		RegionID:     testvm.Region,
+		Name:         "inbox",
+		Version:      2,
+		Attestations: pair(consts.RevokeAppKeyID, "inbox", 2, storage.AppKeyEncryption, nil),
+	}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Publish",
+			Actor:  f.Actor,
+			Action: publish("outputs", 1, storage.AppKeyVerification, verifyKey[:]),
+		},
+		{
+			Name:        "Exists",
+			Actor:       f.Actor,
+			Action:      publish("outputs", 2, storage.AppKeyVerification, verifyKey[:]),
+			ExpectedErr: actions.ErrAppKeyExists,
+		},
+		{
+			Name:        "InvalidKey",
+			Actor:       f.Actor,
+			Action:      publish("inbox", 1, storage.AppKeyVerification, encKey()[:16]),
+			ExpectedErr: actions.ErrInvalidAppKey,
+		},
+		{
+			// One enclave alone cannot change the keyring
+			Name:        "Single",
+			Actor:       f.Actor,
+			Action:      single,
+			ExpectedErr: actions.ErrKeyringPair,
+		},
+		{
+			Name:        "Swapped",
+			Actor:       f.Actor,
+			Action:      swapped,
+			ExpectedErr: actions.ErrKeyringPair,
+		},
+		{
+			Name:   "PublishInbox",
+			Actor:  f.Actor,
+			Action: publish("inbox", 1, storage.AppKeyEncryption, v1),
+		},
+		{
+			// Rotation retires the active version
+			Name:   "Rotate",
+			Actor:  f.Actor,
+			Action: rotate(2, v2),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.Equal(uint64(2), out.(*actions.RotateAppKeyResult).Version)
+				keyring, err := storage.GetKeyring(ctx, f.State, testvm.Region)
+				require.NoError(err)
+				inbox := keyring.Named("inbox")
+				require.Len(inbox, 2)
+				require.Equal(storage.AppKeyRetired, inbox[0].Status)
+				require.Equal(v1, inbox[0].PublicKey)
+				require.Equal(storage.AppKeyActive, inbox[1].Status)
+				require.Equal(v2, inbox[1].PublicKey)
+			},
+		},
+		{
+			Name:        "RotateTwice",
+			Actor:       f.Actor,
+			Action:      rotate(2, v2),
+			ExpectedErr: actions.ErrAppKeyVersion,
+		},
+		{
+			// Revoking the active version leaves the name without one until
+			// it is published again
+			Name:   "Revoke",
+			Actor:  f.Actor,
+			Action: revoke,
+		},
+		{
+			Name:        "RevokeTwice",
+			Actor:       f.Actor,
+			Action:      revoke,
+			ExpectedErr: actions.ErrAppKeyRevoked,
+		},
+		{
+			Name:        "RotateRevoked",
+			Actor:       f.Actor,
+			Action:      rotate(3, v3),
+			ExpectedErr: actions.ErrAppKeyRevoked,
+		},
+		{
+			Name:   "Republish",
+			Actor:  f.Actor,
+			Action: publish("inbox", 3, storage.AppKeyEncryption, v3),
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				require := require.New(t)
+				keyring, err := storage.GetKeyring(ctx, f.State, testvm.Region)
+				require.NoError(err)
+				latest := keyring.Latest("inbox")
+				require.Equal(uint64(3), latest.Version)
+				require.Equal(storage.AppKeyActive, latest.Status)
+				require.Equal(storage.AppKeyRevoked, keyring.Version("inbox", 2).Status)
+				require.Len(keyring.Named("outputs"), 1)
+			},
+		},
+	})
+}
+
+func TestRegionTemplates(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	operator := codectest.NewRandomAddress()
+	relayer := codectest.NewRandomAddress()
+
+	fees := actions.DefaultFeeSchedule
+	fees.StateUpdateUnits *= 2
+	templates := &actions.RegionTemplates{Templates: []actions.RegionTemplate{{
+		Name: "diverse",
+		Platform: attestation.PlatformPolicy{
+			Requirements: []attestation.PlatformRequirement{
+				{Type: attestation.SGX, Min: 1},
+				{Type: attestation.SEV, Min: 1},
+			},
+			Heterogeneous: true,
+		},
+		Fees:   fees,
+		Quotas: actions.RegionQuotas{MaxTEEs: 2, MaxExecUnits: 1_000},
+	}}}
+	value, err := codec.Marshal(templates)
+	require.NoError(t, err)
+	require.NoError(t, storage.ScheduleParam(ctx, v.State, uint8(consts.ParamRegionTemplates), value, v.Height, v.Height))
+
+	sgx, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(t, err)
+	sev, err := testvm.NewEnclave(testvm.EnclaveSEV)
+	require.NoError(t, err)
+	tees := []codec.Address{sgx.Address, sev.Address}
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "TemplateNotFound",
+			Actor:       operator,
+			Action:      &actions.CreateRegionFromTemplateAction{RegionID: "eu-west", Template: "minimal", TEEs: tees},
+			ExpectedErr: actions.ErrTemplateNotFound,
+		},
+		{
+			Name:  "TooManyTEEs",
+			Actor: operator,
+			Action: &actions.CreateRegionFromTemplateAction{
+				RegionID: "eu-west",
+				Template: "diverse",
+				TEEs:     append(tees, codectest.NewRandomAddress()),
+			},
+			ExpectedErr: actions.ErrRegionQuota,
+		},
+		{
+			// The region gets the template's platform policy and keeps its
+			// quotas
+			Name:   "Create",
+			Actor:  operator,
+			Action: &actions.CreateRegionFromTemplateAction{RegionID: "eu-west", Template: "diverse", TEEs: tees},
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.Equal("diverse", out.(*actions.CreateRegionFromTemplateResult).Template)
+				policy, err := storage.GetPlatformPolicy(ctx, v.State, "eu-west")
+				require.NoError(err)
+				require.Equal(templates.Templates[0].Platform.Requirements, policy.Requirements)
+				require.True(policy.Heterogeneous)
+			},
+		},
+		{
+			Name:        "AddOverQuota",
+			Actor:       operator,
+			Action:      &actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "eu-west", AddTEEs: []codec.Address{codectest.NewRandomAddress()}},
+			ExpectedErr: actions.ErrRegionQuota,
+		},
+	})
+
+	// Changing the templates does not change regions already created
+	require.NoError(t, storage.ScheduleParam(ctx, v.State, uint8(consts.ParamRegionTemplates), nil, v.Height, v.Height))
+	template, err := actions.RegionTemplateOf(ctx, v.State, "eu-west")
+	require.NoError(t, err)
+	require.Equal(t, fees, template.Fees)
+	require.Equal(t, templates.Templates[0].Quotas, template.Quotas)
+
+	require.NoError(t, v.RegisterRegion(ctx, "eu-west", sgx, sev))
+	meterID := storage.RegionAssetID("eu-west")
+	exec, err := v.Attest("eu-west", sgx, counter(1))
+	require.NoError(t, err)
+	overQuota, err := v.Attest("eu-west", sgx, actions.TEEExecResult{
+		ContractAddr: []byte("counter"),
+		StateUpdates: map[string][]byte{"counter": bytes.Repeat([]byte{1}, 256*1024)},
+	})
+	require.NoError(t, err)
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:            "Meter",
+			Actor:           sgx.Address,
+			Action:          &actions.CreateAssetAction{Name: "Compute", Symbol: "CU", RegionID: "eu-west"},
+			ExpectedOutputs: &actions.CreateAssetResult{AssetID: meterID},
+		},
+		{
+			Name:   "MintUnits",
+			Actor:  sgx.Address,
+			Action: &actions.MintAssetAction{AssetID: meterID, To: relayer, Value: 1_000_000},
+		},
+		{
+			// Executions burn the metering asset at the template's fee
+			// schedule
+			Name:   "Metered",
+			Actor:  relayer,
+			Action: exec,
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				exOut := out.(*actions.TEEExecOutput)
+				require.Greater(t, exOut.Metered, exOut.UnitsConsumed)
+			},
+		},
+		{
+			// and may not consume more than its execution quota
+			Name:        "OverQuota",
+			Actor:       relayer,
+			Action:      overQuota,
+			ExpectedErr: actions.ErrRegionQuota,
+		},
+	})
+}
+
+func TestRandomnessBeacon(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	seed := func(b byte) []byte {
+		return bytes.Repeat([]byte{b}, consts.BeaconSeedSize)
+	}
+	// Shares are ordered by enclave ID
+	first, second := orderedPair(f)
+	publish := func(epoch uint64, a, b, nextA, nextB byte) *actions.PublishRandomnessAction {
+		return &actions.PublishRandomnessAction{
+			RegionID: testvm.Region,
+			Epoch:    epoch,
+			Shares: []actions.RandomnessShare{
+				first.ShareRandomness(testvm.Region, epoch, seed(a), seed(nextA)),
+				second.ShareRandomness(testvm.Region, epoch, seed(b), seed(nextB)),
+			},
+		}
+	}
+
+	var randomness ids.ID
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "WrongEpoch",
+			Actor:       f.Actor,
+			Action:      publish(1, 1, 2, 3, 4),
+			ExpectedErr: actions.ErrBeaconEpoch,
+		},
+		{
+			Name:   "Publish",
+			Actor:  f.Actor,
+			Action: publish(0, 1, 2, 3, 4),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				randomness = out.(*actions.PublishRandomnessResult).Randomness
+				stored, err := actions.RegionRandomness(ctx, f.State, testvm.Region, 0)
+				require.NoError(t, err)
+				require.Equal(t, randomness, stored)
+			},
+		},
+		{
+			// The pair must reveal the seeds it committed to
+			Name:        "WrongReveal",
+			Actor:       f.Actor,
+			Action:      publish(1, 3, 5, 6, 7),
+			ExpectedErr: actions.ErrBeaconReveal,
+		},
+		{
+			Name:   "Reveal",
+			Actor:  f.Actor,
+			Action: publish(1, 3, 4, 5, 6),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				next := out.(*actions.PublishRandomnessResult).Randomness
+				require.NotEqual(randomness, next)
+
+				// Object code reads published beacons
+				tracer := actions.NewCallTracer(f.State, "contract", "draw")
+				got, err := tracer.Randomness(ctx, testvm.Region, 1)
+				require.NoError(err)
+				require.Equal(next, got)
+				_, err = tracer.Randomness(ctx, testvm.Region, 2)
+				require.ErrorIs(err, actions.ErrBeaconNotFound)
+			},
+		},
+	})
+
+	// Once the reveal window closes, fresh seeds are accepted
+	require.NoError(t, f.Advance(ctx, 1, (consts.BeaconRevealWindow+1)*time.Second))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "FreshSeeds",
+			Actor:  f.Actor,
+			Action: publish(2, 9, 9, 1, 1),
+		},
+	})
+}
+
+func TestDataFeeds(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	relayer := codectest.NewRandomAddress()
+	feedID := ids.GenerateTestID()
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:     "Register",
+			Actor:    f.Actor,
+			ActionID: feedID,
+			Action: &actions.RegisterFeedAction{
+				Name:         "ETH/USD",
+				RegionID:     testvm.Region,
+				Decimals:     8,
+				Signers:      [][]byte{f.SGX.ID()},
+				DeviationBPS: 50,
+				Heartbeat:    3600,
+			},
+		},
+		{
+			// Only the feed's signers publish, in round order
+			Name:        "NotSigner",
+			Actor:       relayer,
+			Action:      f.SEV.PublishFeed(feedID, testvm.Region, 1, 2_000, f.Timestamp),
+			ExpectedErr: actions.ErrFeedSigner,
+		},
+		{
+			Name:        "WrongRound",
+			Actor:       relayer,
+			Action:      f.SGX.PublishFeed(feedID, testvm.Region, 2, 2_000, f.Timestamp),
+			ExpectedErr: actions.ErrFeedRound,
+		},
+		{
+			Name:   "Publish",
+			Actor:  relayer,
+			Action: f.SGX.PublishFeed(feedID, testvm.Region, 1, 2_000, f.Timestamp),
+		},
+	})
+
+	// A value within half a percent waits for the heartbeat
+	require.NoError(t, f.Advance(ctx, 1, time.Minute))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "WithinDeviation",
+			Actor:       relayer,
+			Action:      f.SGX.PublishFeed(feedID, testvm.Region, 2, 2_009, f.Timestamp),
+			ExpectedErr: actions.ErrFeedDeviation,
+		},
+		{
+			Name:   "Deviated",
+			Actor:  relayer,
+			Action: f.SGX.PublishFeed(feedID, testvm.Region, 2, 2_010, f.Timestamp),
+		},
+	})
+
+	require.NoError(t, f.Advance(ctx, 1, time.Hour))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Heartbeat",
+			Actor:  relayer,
+			Action: f.SGX.PublishFeed(feedID, testvm.Region, 3, 2_010, f.Timestamp),
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				require := require.New(t)
+				feed, err := storage.GetFeed(ctx, f.State, feedID)
+				require.NoError(err)
+				require.Equal(uint64(3), feed.Round)
+				require.Equal(uint64(2_010), feed.Latest.Value)
+				require.Equal(uint64(f.Timestamp/1000), feed.Latest.ObservedAt)
+			},
+		},
+	})
+}
+
+func TestNameRegistry(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "counter")
+	owner := f.Actor
+	other := codectest.NewRandomAddress()
+	resolves := func(name string, kind uint8, expected string, expectedErr error) func(context.Context, *testing.T, codec.Typed) {
+		return func(ctx context.Context, t *testing.T, _ codec.Typed) {
+			target, err := actions.ResolveName(ctx, f.State, name, kind, f.Timestamp)
+			require.ErrorIs(t, err, expectedErr)
+			require.Equal(t, expected, target)
+		}
+	}
+
+	var expiresAt uint64
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "RegionNotFound",
+			Actor:       owner,
+			Action:      &actions.RegisterNameAction{Name: "east", Kind: storage.NameRegion, Target: "us-west"},
+			ExpectedErr: actions.ErrRegionNotFound,
+		},
+		{
+			Name:   "Register",
+			Actor:  owner,
+			Action: &actions.RegisterNameAction{Name: "east", Kind: storage.NameRegion, Target: testvm.Region},
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				expiresAt = out.(*actions.RegisterNameResult).ExpiresAt
+				require.Equal(t, uint64(f.Timestamp/1000)+consts.NameTerm, expiresAt)
+				resolves("east", storage.NameRegion, testvm.Region, nil)(ctx, t, out)
+				resolves("east", storage.NameObject, "", actions.ErrNameKind)(ctx, t, out)
+			},
+		},
+		{
+			Name:   "RegisterObject",
+			Actor:  owner,
+			Action: &actions.RegisterNameAction{Name: "counter", Kind: storage.NameObject, Target: "counter"},
+		},
+		{
+			// Only the owner may take, renew or transfer a name
+			Name:        "Taken",
+			Actor:       other,
+			Action:      &actions.RegisterNameAction{Name: "east", Kind: storage.NameObject, Target: "counter"},
+			ExpectedErr: actions.ErrNameTaken,
+		},
+		{
+			Name:        "NotOwner",
+			Actor:       other,
+			Action:      &actions.TransferNameAction{Name: "east", To: other},
+			ExpectedErr: actions.ErrNameOwner,
+		},
+		{
+			Name:   "Renew",
+			Actor:  owner,
+			Action: &actions.RegisterNameAction{Name: "east", Kind: storage.NameRegion, Target: testvm.Region},
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, expiresAt+consts.NameTerm, out.(*actions.RegisterNameResult).ExpiresAt)
+			},
+		},
+		{
+			Name:   "Transfer",
+			Actor:  owner,
+			Action: &actions.TransferNameAction{Name: "counter", To: other},
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				record, err := storage.GetName(ctx, f.State, "counter")
+				require.NoError(t, err)
+				require.Equal(t, other, record.Owner)
+			},
+		},
+	})
+
+	// A lapsed name stops resolving, and is free once its grace period ends
+	require.NoError(t, f.Advance(ctx, 1, (consts.NameTerm+1)*time.Second))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "TransferExpired",
+			Actor:       other,
+			Action:      &actions.TransferNameAction{Name: "counter", To: owner},
+			ExpectedErr: actions.ErrNameExpired,
+			Assertion:   resolves("counter", storage.NameObject, "", actions.ErrNameExpired),
+		},
+		{
+			Name:        "InGrace",
+			Actor:       owner,
+			Action:      &actions.RegisterNameAction{Name: "counter", Kind: storage.NameObject, Target: "counter"},
+			ExpectedErr: actions.ErrNameTaken,
+		},
+	})
+
+	require.NoError(t, f.Advance(ctx, 1, consts.NameGracePeriod*time.Second))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Reregister",
+			Actor:  owner,
+			Action: &actions.RegisterNameAction{Name: "counter", Kind: storage.NameObject, Target: "counter"},
+			Assertion: func(_ context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, owner, out.(*actions.RegisterNameResult).Owner)
+			},
+		},
+	})
+}
+
+func TestSealedStorage(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	sgx, sev := f.SGX, f.SEV
+	require.NoError(t, storage.SetObject(ctx, f.State, "vault", map[string][]byte{
+		"code":    {1},
+		"storage": []byte("balance=100"),
+	}))
+	keys, err := storage.GetEncryptionKeys(ctx, f.State, testvm.Region)
+	require.NoError(t, err)
+	seal, err := sgx.SealStorage("vault", testvm.Region, 0, []byte("balance=100"), keys)
+	require.NoError(t, err)
+	opens := func(enclave *testvm.Enclave, blob []byte, expectedErr error) func(context.Context, *testing.T, codec.Typed) {
+		return func(_ context.Context, t *testing.T, _ codec.Typed) {
+			plain, err := enclave.OpenStorage("vault", blob)
+			require.ErrorIs(t, err, expectedErr)
+			if expectedErr == nil {
+				require.Equal(t, []byte("balance=100"), plain)
+			}
+		}
+	}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Seal",
+			Actor:  f.Actor,
+			Action: seal,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.Equal(uint64(1), out.(*actions.SealStorageResult).Sequence)
+
+				// Only the hash is kept, and the storage in the clear is
+				// dropped
+				sealed, err := storage.GetSealedStorage(ctx, f.State, "vault")
+				require.NoError(err)
+				require.Equal(ids.ID(sha256.Sum256(seal.Blob)), sealed.Hash)
+				require.Equal([][]byte{sgx.ID(), sev.ID()}, sealed.Recipients)
+				obj, err := storage.GetObject(ctx, f.State, "vault")
+				require.NoError(err)
+				require.NotContains(obj, "storage")
+				opens(sev, seal.Blob, nil)(ctx, t, out)
+			},
+		},
+		{
+			// A seal cannot be replayed to roll the storage back
+			Name:        "Replay",
+			Actor:       f.Actor,
+			Action:      seal,
+			ExpectedErr: actions.ErrInvalidSignature,
+		},
+	})
+
+	// A new enclave joins and sev leaves. Until the storage is resealed,
+	// the newcomer cannot open or replace it.
+	third, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(t, err)
+	require.NoError(t, f.RegisterRegion(ctx, testvm.Region, sgx, sev, third))
+	sevKey := sev.PublicKey()
+	require.NoError(t, storage.SetEnclave(ctx, f.State, testvm.Region, sev.ID(), storage.EnclaveInactive, sevKey[:]))
+	opens(third, seal.Blob, envelope.ErrNotRecipient)(ctx, t, nil)
+
+	remaining := []storage.EncryptionKey{sgx.PublishedKey(), third.PublishedKey()}
+	unsigned, err := third.SealStorage("vault", testvm.Region, 1, []byte("balance=0"), []storage.EncryptionKey{third.PublishedKey()})
+	require.NoError(t, err)
+	stale, err := sgx.ResealStorage("vault", testvm.Region, 1, seal.Blob, []storage.EncryptionKey{sgx.PublishedKey(), sev.PublishedKey()})
+	require.NoError(t, err)
+	reseal, err := sgx.ResealStorage("vault", testvm.Region, 1, seal.Blob, remaining)
+	require.NoError(t, err)
+	update, err := third.SealStorage("vault", testvm.Region, 2, []byte("balance=0"), remaining)
+	require.NoError(t, err)
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "NewcomerUpdate",
+			Actor:       f.Actor,
+			Action:      unsigned,
+			ExpectedErr: actions.ErrSealedSigner,
+		},
+		{
+			// Sealing to an enclave that left is refused
+			Name:        "ResealToLeaver",
+			Actor:       f.Actor,
+			Action:      stale,
+			ExpectedErr: actions.ErrSealedRecipients,
+		},
+		{
+			Name:   "Reseal",
+			Actor:  f.Actor,
+			Action: reseal,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require.Equal(t, uint64(2), out.(*actions.ResealStorageResult).Sequence)
+				opens(third, reseal.Blob, nil)(ctx, t, out)
+				opens(sev, reseal.Blob, envelope.ErrNotRecipient)(ctx, t, out)
+			},
+		},
+		{
+			// The newcomer can now update the storage
+			Name:   "Update",
+			Actor:  f.Actor,
+			Action: update,
+		},
+		{
+			Name:        "NotSealed",
+			Actor:       f.Actor,
+			Action:      &actions.ResealStorageAction{ObjectID: "plain", RegionID: testvm.Region},
+			ExpectedErr: actions.ErrStorageNotSealed,
+		},
+	})
+}
+
+func TestMultiRegionTx(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	v := testvm.New()
+	admin := codectest.NewRandomAddress()
+	_, _, err := v.NewRegion(ctx, "us-east")
+	require.NoError(err)
+	_, _, err = v.NewRegion(ctx, "eu-west")
+	require.NoError(err)
+	east, west := codectest.NewRandomAddress(), codectest.NewRandomAddress()
+	addEast := &actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", AddTEEs: []codec.Address{east}}
+	addWest := &actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "eu-west", AddTEEs: []codec.Address{west}}
+
+	// A failing action in one region leaves the others untouched
+	_, err = v.RunTx(ctx, admin, []chain.Action{
+		addEast,
+		&actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "ap-south", AddTEEs: []codec.Address{west}},
+	})
+	require.ErrorIs(err, actions.ErrRegionNotFound)
+	tees, _, err := storage.GetRegion(ctx, v.State, "us-east")
+	require.NoError(err)
+	require.NotContains(tees, east)
+
+	results, err := v.RunTx(ctx, admin, []chain.Action{addEast, addWest})
+	require.NoError(err)
+	require.Len(results, 2)
+	require.Equal("us-east", results[0].RegionID)
+	require.Equal([]int{0}, results[0].Positions)
+	require.Equal("eu-west", results[1].RegionID)
+	require.Equal([]int{1}, results[1].Positions)
+	tees, _, err = storage.GetRegion(ctx, v.State, "us-east")
+	require.NoError(err)
+	require.Contains(tees, east)
+	tees, _, err = storage.GetRegion(ctx, v.State, "eu-west")
+	require.NoError(err)
+	require.Contains(tees, west)
+}
@@ -0,0 +1,254 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidTemplate  = errors.New("invalid region template")
+	ErrTemplateNotFound = errors.New("region template not found")
+	ErrRegionQuota      = errors.New("region quota exceeded")
+
+	_ chain.Action = (*CreateRegionFromTemplateAction)(nil)
+)
+
+// RegionQuotas bound what a region created from a template may use. Zero
+// values leave the bound to the chain-wide limits.
+type RegionQuotas struct {
+	// MaxTEEs caps the region's TEE set below [consts.MaxTEEsPerRegion]
+	MaxTEEs uint8 `serialize:"true" json:"max_tees"`
+	// MaxExecUnits caps the units one execution in the region consumes
+	MaxExecUnits uint64 `serialize:"true" json:"max_exec_units"`
+}
+
+// RegionTemplate is a region configuration maintained by governance. A
+// region created from it gets its platform policy, has its metering asset
+// burned at its fee schedule and is held to its quotas.
+type RegionTemplate struct {
+	Name     string                     `serialize:"true" json:"name"`
+	Platform attestation.PlatformPolicy `serialize:"true" json:"platform"`
+	// Fees prices executions in the region's metering asset. A zero
+	// schedule meters with [DefaultFeeSchedule].
+	Fees   FeeSchedule  `serialize:"true" json:"fees"`
+	Quotas RegionQuotas `serialize:"true" json:"quotas"`
+}
+
+func (t *RegionTemplate) validate() error {
+	if len(t.Name) == 0 || len(t.Name) > consts.MaxIDLength {
+		return fmt.Errorf("%w: name length %d", ErrInvalidTemplate, len(t.Name))
+	}
+	if err := validatePlatformPolicy(&t.Platform); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, t.Name, err)
+	}
+	if maxTEEs := t.Quotas.MaxTEEs; maxTEEs != 0 && (maxTEEs < consts.MinTEEsPerRegion || maxTEEs > consts.MaxTEEsPerRegion) {
+		return fmt.Errorf("%w: %s allows %d TEEs", ErrInvalidTemplate, t.Name, maxTEEs)
+	}
+	return nil
+}
+
+func (t *RegionTemplate) meteringSchedule() FeeSchedule {
+	if t.Fees == (FeeSchedule{}) {
+		return DefaultFeeSchedule
+	}
+	return t.Fees
+}
+
+// checkTEEs enforces the TEE quota of [t] on the TEE set [tees].
+func (t *RegionTemplate) checkTEEs(tees []codec.Address) error {
+	if t.Quotas.MaxTEEs != 0 && len(tees) > int(t.Quotas.MaxTEEs) {
+		return fmt.Errorf("%w: %d TEEs > %d", ErrRegionQuota, len(tees), t.Quotas.MaxTEEs)
+	}
+	return nil
+}
+
+// RegionTemplates is the value encoding of [consts.ParamRegionTemplates].
+type RegionTemplates struct {
+	Templates []RegionTemplate `serialize:"true" json:"templates"`
+}
+
+func (s *RegionTemplates) validate() error {
+	if len(s.Templates) > consts.MaxRegionTemplates {
+		return fmt.Errorf("%w: more than %d templates", ErrInvalidTemplate, consts.MaxRegionTemplates)
+	}
+	names := make(map[string]struct{}, len(s.Templates))
+	for i := range s.Templates {
+		if err := s.Templates[i].validate(); err != nil {
+			return err
+		}
+		if _, ok := names[s.Templates[i].Name]; ok {
+			return fmt.Errorf("%w: duplicate %s", ErrInvalidTemplate, s.Templates[i].Name)
+		}
+		names[s.Templates[i].Name] = struct{}{}
+	}
+	return nil
+}
+
+// Get returns the template named [name], or nil if there is none.
+func (s *RegionTemplates) Get(name string) *RegionTemplate {
+	for i := range s.Templates {
+		if s.Templates[i].Name == name {
+			return &s.Templates[i]
+		}
+	}
+	return nil
+}
+
+// GovernedRegionTemplates returns the region templates in effect at the
+// current height, empty if governance never set any.
+func GovernedRegionTemplates(ctx context.Context, im state.Immutable) (*RegionTemplates, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(consts.ParamRegionTemplates))
+	if err != nil {
+		return nil, err
+	}
+	return DecodeRegionTemplates(p.Value(height))
+}
+
+// DecodeRegionTemplates decodes a [consts.ParamRegionTemplates] value. An
+// empty value has no templates.
+func DecodeRegionTemplates(value []byte) (*RegionTemplates, error) {
+	var s RegionTemplates
+	if len(value) == 0 {
+		return &s, nil
+	}
+	if err := codec.Unmarshal(value, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RegionTemplateOf returns the template [regionID] was created from, or nil
+// if it was created without one.
+func RegionTemplateOf(ctx context.Context, im state.Immutable, regionID string) (*RegionTemplate, error) {
+	v, err := storage.GetRegionTemplate(ctx, im, regionID)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	var t RegionTemplate
+	if err := codec.Unmarshal(v, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CreateRegionFromTemplateAction creates [RegionID] with [TEEs] and the
+// configuration of the governed template [Template]: its platform policy
+// is set, and its fee schedule and quotas are kept with the region. Later
+// changes to the template do not affect regions already created from it.
+type CreateRegionFromTemplateAction struct {
+	RegionID string          `serialize:"true" json:"region_id"`
+	Template string          `serialize:"true" json:"template"`
+	TEEs     []codec.Address `serialize:"true" json:"tees"`
+}
+
+func (*CreateRegionFromTemplateAction) GetTypeID() uint8 {
+	return consts.CreateRegionFromTemplateID
+}
+
+func (c *CreateRegionFromTemplateAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	return addGateKeys(addAuditKeys(state.Keys{
+		string(storage.ParamKey(uint8(consts.ParamRegionTemplates))): state.Read,
+		string(storage.RegionKey(c.RegionID)):                        state.All,
+		string(storage.PlatformPolicyKey(c.RegionID)):                state.All,
+		string(storage.RegionTemplateKey(c.RegionID)):                state.All,
+	}, c.RegionID, actionID), actor, c)
+}
+
+func (c *CreateRegionFromTemplateAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.CreateRegionFromTemplateID, c.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, c); err != nil {
+		return nil, err
+	}
+
+	if len(c.RegionID) == 0 || len(c.RegionID) > consts.MaxIDLength {
+		return nil, ErrInvalidID
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, c.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRegionExists
+	}
+	templates, err := GovernedRegionTemplates(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	template := templates.Get(c.Template)
+	if template == nil {
+		return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, c.Template)
+	}
+	if err := validateTEESet(c.TEEs); err != nil {
+		return nil, err
+	}
+	if err := template.checkTEEs(c.TEEs); err != nil {
+		return nil, err
+	}
+
+	if err := storage.SetRegion(ctx, mu, c.RegionID, c.TEEs); err != nil {
+		return nil, err
+	}
+	if err := storage.SetPlatformPolicy(ctx, mu, c.RegionID, &template.Platform); err != nil {
+		return nil, err
+	}
+	encoded, err := codec.Marshal(template)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.SetRegionTemplate(ctx, mu, c.RegionID, encoded); err != nil {
+		return nil, err
+	}
+	if err := appendAudit(ctx, mu, c.RegionID, actionID, &storage.AuditEntry{
+		TypeID:       consts.CreateRegionFromTemplateID,
+		Actor:        actor,
+		Timestamp:    timestamp,
+		Attestations: evidenceHashes(encoded),
+	}); err != nil {
+		return nil, err
+	}
+	return &CreateRegionFromTemplateResult{RegionID: c.RegionID, Template: c.Template}, nil
+}
+
+func (c *CreateRegionFromTemplateAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.RegionUnits(len(c.TEEs)) + 2*schedule.StateUpdateUnits
+}
+
+func (*CreateRegionFromTemplateAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type CreateRegionFromTemplateResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Template string `serialize:"true" json:"template"`
+}
+
+func (*CreateRegionFromTemplateResult) GetTypeID() uint8 {
+	return consts.CreateRegionFromTemplateResultID
+}
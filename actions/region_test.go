@@ -0,0 +1,171 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	hconsts "github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestUpdateRegionAction(t *testing.T) {
+	tee1 := codectest.NewRandomAddress()
+	tee2 := codectest.NewRandomAddress()
+	tee3 := codectest.NewRandomAddress()
+
+	regionState := func() state.Mutable {
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, storage.SetRegion(context.Background(), store, "us-east", []codec.Address{tee1, tee2}))
+		return store
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:  "RegionNotFound",
+			Actor: codec.EmptyAddress,
+			Action: &UpdateRegionAction{
+				RegionID: "eu-west",
+				AddTEEs:  []codec.Address{tee3},
+			},
+			State:       chaintest.NewInMemoryStore(),
+			ExpectedErr: ErrRegionNotFound,
+		},
+		{
+			Name:  "RemoveBelowMinimum",
+			Actor: codec.EmptyAddress,
+			Action: &UpdateRegionAction{
+				RegionID: "us-east",
+				RemTEEs:  []codec.Address{tee1},
+			},
+			State:       regionState(),
+			ExpectedErr: ErrTooFewTEEs,
+		},
+		{
+			Name:  "RemoveNonMember",
+			Actor: codec.EmptyAddress,
+			Action: &UpdateRegionAction{
+				RegionID: "us-east",
+				RemTEEs:  []codec.Address{tee3},
+			},
+			State:       regionState(),
+			ExpectedErr: ErrTEENotInRegion,
+		},
+		{
+			Name:  "AddExistingMember",
+			Actor: codec.EmptyAddress,
+			Action: &UpdateRegionAction{
+				RegionID: "us-east",
+				AddTEEs:  []codec.Address{tee2},
+			},
+			State:       regionState(),
+			ExpectedErr: ErrDuplicateTEE,
+		},
+		{
+			Name:  "AddTwice",
+			Actor: codec.EmptyAddress,
+			Action: &UpdateRegionAction{
+				RegionID: "us-east",
+				AddTEEs:  []codec.Address{tee3, tee3},
+			},
+			State:       regionState(),
+			ExpectedErr: ErrDuplicateTEE,
+		},
+		{
+			Name:  "ReplaceMember",
+			Actor: codec.EmptyAddress,
+			Action: &UpdateRegionAction{
+				RegionID: "us-east",
+				AddTEEs:  []codec.Address{tee3},
+				RemTEEs:  []codec.Address{tee1},
+			},
+			State: regionState(),
+			Assertion: func(ctx context.Context, t *testing.T, store state.Mutable) {
+				tees, exists, err := storage.GetRegion(ctx, store, "us-east")
+				require.NoError(t, err)
+				require.True(t, exists)
+				require.Equal(t, []codec.Address{tee2, tee3}, tees)
+			},
+			ExpectedOutputs: &UpdateRegionResult{
+				RegionID: "us-east",
+				Success:  true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestRegionTEECap(t *testing.T) {
+	tees := func(n int) []codec.Address {
+		addrs := make([]codec.Address, n)
+		for i := range addrs {
+			addrs[i] = codectest.NewRandomAddress()
+		}
+		return addrs
+	}
+
+	tests := []struct {
+		name        string
+		action      interface{ Marshal(*codec.Packer) }
+		unmarshal   func(*codec.Packer) (chain.Action, error)
+		expectedErr error
+	}{
+		{
+			name:      "CreateAtCap",
+			action:    &CreateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", TEEs: tees(consts.MaxTEEsPerRegion)},
+			unmarshal: UnmarshalCreateRegion,
+		},
+		{
+			name:        "CreateOverCap",
+			action:      &CreateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", TEEs: tees(consts.MaxTEEsPerRegion + 1)},
+			unmarshal:   UnmarshalCreateRegion,
+			expectedErr: ErrTooManyTEEs,
+		},
+		{
+			name:      "UpdateAtCap",
+			action:    &UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", AddTEEs: tees(consts.MaxTEEsPerRegion), RemTEEs: tees(consts.MaxTEEsPerRegion)},
+			unmarshal: UnmarshalUpdateRegion,
+		},
+		{
+			name:        "UpdateAddOverCap",
+			action:      &UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", AddTEEs: tees(consts.MaxTEEsPerRegion + 1)},
+			unmarshal:   UnmarshalUpdateRegion,
+			expectedErr: ErrTooManyTEEs,
+		},
+		{
+			name:        "UpdateRemoveOverCap",
+			action:      &UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", RemTEEs: tees(consts.MaxTEEsPerRegion + 1)},
+			unmarshal:   UnmarshalUpdateRegion,
+			expectedErr: ErrTooManyTEEs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			p := codec.NewWriter(0, hconsts.NetworkSizeLimit)
+			tt.action.Marshal(p)
+			require.NoError(p.Err())
+			decoded, err := tt.unmarshal(codec.NewReader(p.Bytes(), hconsts.NetworkSizeLimit))
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				require.Equal(tt.action, decoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// MaxTxDataSize bounds the user input a request may queue
+const MaxTxDataSize = 16 * 1024
+
+// UserSigLen is the length of a UserSig: an ED25519 public key followed by
+// its signature
+const UserSigLen = ed25519.PublicKeyLen + ed25519.SignatureLen
+
+var (
+	ErrTxDataTooLarge   = errors.New("request data too large")
+	ErrRequestExists    = errors.New("request already queued")
+	ErrRequestNotFound  = errors.New("no queued request for tx data")
+	ErrRequestFulfilled = errors.New("request already fulfilled with a different result")
+	ErrInvalidUserSig   = errors.New("invalid user signature")
+
+	_ chain.Action = (*QueueRequestAction)(nil)
+)
+
+// requestDomain separates request signatures from other digests
+const requestDomain = "shuttlevm/request"
+
+// RequestID identifies the request carrying [txData] in [regionID].
+func RequestID(regionID string, txData []byte) ids.ID {
+	h := sha256.New()
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(txData)
+	return ids.ID(h.Sum(nil))
+}
+
+// RequestDigest is the message a requester signs to let a region's enclaves
+// execute [txData].
+func RequestDigest(regionID string, txData []byte) []byte {
+	id := RequestID(regionID, txData)
+	return append([]byte(requestDomain), id[:]...)
+}
+
+// SignRequest returns the UserSig of [priv] over [txData] in [regionID].
+func SignRequest(priv ed25519.PrivateKey, regionID string, txData []byte) []byte {
+	pub := priv.PublicKey()
+	sig := ed25519.Sign(RequestDigest(regionID, txData), priv)
+	return append(pub[:], sig[:]...)
+}
+
+// ExecDigest is the message an enclave signs for an execution: the digest
+// of its result, bound to the request it serves when [txData] is set and to
+// the event it completes when [eventID] is set. Without the binding a
+// relayer could pair a result with any queued request or pending callback.
+func ExecDigest(resultDigest []byte, regionID string, txData []byte, eventID ids.ID) []byte {
+	if len(txData) == 0 && eventID == ids.Empty {
+		return resultDigest
+	}
+	h := sha256.New()
+	h.Write(resultDigest)
+	if len(txData) > 0 {
+		id := RequestID(regionID, txData)
+		h.Write(id[:])
+	}
+	// Tagged so the event cannot pass for a request ID
+	if eventID != ids.Empty {
+		h.Write([]byte{eventTag})
+		h.Write(eventID[:])
+	}
+	return h.Sum(nil)
+}
+
+// QueueRequestAction queues [TxData] for the enclaves of [RegionID] on
+// behalf of the actor. A TEEExecAction carrying the same TxData fulfills
+// it, and must carry the actor's signature over [RequestDigest]. Identical
+// input can be queued once per region; requesters that repeat input
+// include a nonce in it. When [Sponsor] is set, the request's fee is paid
+// back to the actor out of the allowance [Sponsor] gave it, so region
+// operators can cover their users' fees.
+type QueueRequestAction struct {
+	RegionID string        `serialize:"true" json:"region_id"`
+	TxData   []byte        `serialize:"true" json:"tx_data"`
+	Sponsor  codec.Address `serialize:"true" json:"sponsor"`
+}
+
+func (*QueueRequestAction) GetTypeID() uint8 {
+	return consts.QueueRequestID
+}
+
+func (q *QueueRequestAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.RegionKey(q.RegionID)):                                   state.Read,
+		string(storage.RegionFreezeKey(q.RegionID)):                             state.Read,
+		string(storage.RequestKey(q.RegionID, RequestID(q.RegionID, q.TxData))): state.All,
+	}
+	if q.Sponsor != codec.EmptyAddress {
+		keys[string(storage.AllowanceKey(q.Sponsor, actor))] = state.Read | state.Write
+		keys[string(storage.BalanceKey(q.Sponsor))] = state.Read | state.Write
+		keys[string(storage.BalanceKey(actor))] = state.All
+	}
+	return addGateKeys(keys, actor, q)
+}
+
+func (q *QueueRequestAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.QueueRequestID, q.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, q); err != nil {
+		return nil, err
+	}
+
+	if len(q.TxData) == 0 || len(q.TxData) > MaxTxDataSize {
+		return nil, ErrTxDataTooLarge
+	}
+	_, exists, err := storage.GetRegion(ctx, mu, q.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	if err := checkRegionFrozen(ctx, mu, q.RegionID); err != nil {
+		return nil, err
+	}
+	requestID := RequestID(q.RegionID, q.TxData)
+	prev, err := storage.GetRequest(ctx, mu, q.RegionID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		return nil, ErrRequestExists
+	}
+	if err := storage.SetRequest(ctx, mu, q.RegionID, requestID, &storage.Request{
+		Requester: actor,
+		Status:    storage.RequestPending,
+		QueuedAt:  timestamp,
+	}); err != nil {
+		return nil, err
+	}
+	var sponsored uint64
+	if q.Sponsor != codec.EmptyAddress {
+		// The sponsor covers the units the gates charged
+		governed, err := GovernedRules(ctx, rules, mu)
+		if err != nil {
+			return nil, err
+		}
+		if sponsored, err = sponsorFee(ctx, rules, mu, q.Sponsor, actor, q.ComputeUnits(governed)); err != nil {
+			return nil, err
+		}
+	}
+	return &QueueRequestResult{
+		RegionID:  q.RegionID,
+		RequestID: requestID,
+		Sponsored: sponsored,
+	}, nil
+}
+
+func (q *QueueRequestAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	kib := uint64(len(q.TxData)+1023) / 1024
+	units := schedule.BaseUnits + schedule.StateUpdateUnits + kib*schedule.StateKiBUnits
+	if q.Sponsor != codec.EmptyAddress {
+		// Allowance and both balances
+		units += 3 * schedule.StateUpdateUnits
+	}
+	return units
+}
+
+func (*QueueRequestAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type QueueRequestResult struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	RequestID ids.ID `serialize:"true" json:"request_id"`
+	// Sponsored is the fee paid back to the actor by the sponsor
+	Sponsored uint64 `serialize:"true" json:"sponsored"`
+}
+
+func (*QueueRequestResult) GetTypeID() uint8 {
+	return consts.QueueRequestResultID
+}
+
+// fulfillRequest checks that [userSig] is the requester's signature over
+// the request queued for [txData] and records [resultDigest] as its result.
+// A fulfilled request accepts further attestations of the same result, so
+// each enclave of a pair can submit its own.
+func fulfillRequest(
+	ctx context.Context,
+	mu state.Mutable,
+	regionID string,
+	txData []byte,
+	userSig []byte,
+	resultDigest []byte,
+) error {
+	requestID := RequestID(regionID, txData)
+	r, err := storage.GetRequest(ctx, mu, regionID, requestID)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return ErrRequestNotFound
+	}
+	if len(userSig) != UserSigLen {
+		return ErrInvalidUserSig
+	}
+	var (
+		pub ed25519.PublicKey
+		sig ed25519.Signature
+	)
+	copy(pub[:], userSig[:ed25519.PublicKeyLen])
+	copy(sig[:], userSig[ed25519.PublicKeyLen:])
+	if auth.NewED25519Address(pub) != r.Requester {
+		return ErrInvalidUserSig
+	}
+	if !ed25519.Verify(RequestDigest(regionID, txData), pub, sig) {
+		return ErrInvalidUserSig
+	}
+
+	result := ids.ID(resultDigest)
+	switch r.Status {
+	case storage.RequestFulfilled:
+		if r.Result != result {
+			return ErrRequestFulfilled
+		}
+		return nil
+	default:
+		r.Status = storage.RequestFulfilled
+		r.Result = result
+		return storage.SetRequest(ctx, mu, regionID, requestID, r)
+	}
+}
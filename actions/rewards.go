@@ -0,0 +1,188 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/state"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// OperatorRewardBps is the share, in basis points, of an execution fee that
+// is credited to the enclave that produced it. The remainder stays in the
+// region pool.
+const OperatorRewardBps = 8_000
+
+var (
+	ErrNoRewards   = errors.New("no rewards to claim")
+	ErrNotRewardee = errors.New("actor is not the account of the enclave")
+
+	_ chain.Action = (*ClaimRewardsAction)(nil)
+)
+
+// ClaimRewardsAction pays out the rewards accrued by enclave [EnclaveID] in
+// a region to its account, which must be the actor. See [EnclaveAccount].
+type ClaimRewardsAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+}
+
+func (*ClaimRewardsAction) GetTypeID() uint8 {
+	return consts.ClaimRewardsID
+}
+
+func (c *ClaimRewardsAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.RegionKey(c.RegionID)):                     state.Read,
+		string(storage.EnclaveKey(c.RegionID, c.EnclaveID)):       state.Read,
+		string(storage.EnclavePubKeyKey(c.RegionID, c.EnclaveID)): state.Read,
+		string(storage.RewardPoolKey(c.RegionID)):                 state.Read | state.Write,
+		string(storage.EnclaveRewardKey(c.RegionID, c.EnclaveID)): state.Read | state.Write,
+		string(storage.BalanceKey(actor)):                         state.All,
+	}, actor, c)
+}
+
+func (c *ClaimRewardsAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.ClaimRewardsID, c.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, c); err != nil {
+		return nil, err
+	}
+
+	_, exists, err := storage.GetRegion(ctx, mu, c.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRegionNotFound
+	}
+	account, err := EnclaveAccount(ctx, mu, c.RegionID, c.EnclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if account != actor {
+		return nil, ErrNotRewardee
+	}
+
+	reward, err := storage.TakeEnclaveReward(ctx, mu, c.RegionID, c.EnclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if reward == 0 {
+		return nil, ErrNoRewards
+	}
+	balance, err := storage.AddBalance(ctx, mu, actor, reward, true)
+	if err != nil {
+		return nil, err
+	}
+	return &ClaimRewardsResult{
+		RegionID: c.RegionID,
+		Amount:   reward,
+		Balance:  balance,
+	}, nil
+}
+
+func (*ClaimRewardsAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*ClaimRewardsAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type ClaimRewardsResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Amount   uint64 `serialize:"true" json:"amount"`
+	Balance  uint64 `serialize:"true" json:"balance"`
+}
+
+func (*ClaimRewardsResult) GetTypeID() uint8 {
+	return consts.ClaimRewardsResultID
+}
+
+// EnclaveAccount returns the account the rewards of [enclaveID] in
+// [regionID] are paid to. An enclave registered under its TEE address is
+// paid there; one registered under the hash of its key, such as a Nitro or
+// CCA enclave, is paid to the address of that key.
+func EnclaveAccount(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte) (codec.Address, error) {
+	if len(enclaveID) == codec.AddressLen {
+		return codec.ToAddress(enclaveID)
+	}
+	_, pubKey, err := storage.GetEnclave(ctx, im, regionID, enclaveID)
+	if err != nil {
+		return codec.EmptyAddress, err
+	}
+	switch len(pubKey) {
+	case ed25519.PublicKeyLen:
+		return auth.NewED25519Address(ed25519.PublicKey(pubKey)), nil
+	case bls.PublicKeyLen:
+		key, err := bls.PublicKeyFromBytes(pubKey)
+		if err != nil {
+			return codec.EmptyAddress, err
+		}
+		return auth.NewBLSAddress(key), nil
+	default:
+		return codec.EmptyAddress, ErrInvalidEnclave
+	}
+}
+
+// accrueExecReward moves the fee for [units] consumed by an attested
+// execution from the fees collected in [regionID] to its pool, and credits
+// the operator share to the producing enclave.
+func accrueExecReward(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	regionID string,
+	enclaveID []byte,
+	units uint64,
+) error {
+	fee, err := smath.Mul(units, rules.GetMinUnitPrice()[fees.Compute])
+	if err != nil {
+		return err
+	}
+	// The sponsor paid at least the minimum price for the units, so this
+	// only bounds executions run outside a transaction
+	collected, err := storage.GetCollectedFees(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	fee = min(fee, collected)
+	share, err := smath.Mul(fee, OperatorRewardBps)
+	if err != nil {
+		return err
+	}
+	return storage.AccrueReward(ctx, mu, regionID, enclaveID, fee, share/10_000)
+}
+
+func containsAddress(addrs []codec.Address, addr codec.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
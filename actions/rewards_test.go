@@ -0,0 +1,150 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestClaimRewardsAction(t *testing.T) {
+	rules := genesis.NewDefaultRules()
+	price := rules.GetMinUnitPrice()[fees.Compute]
+	tee1 := codectest.NewRandomAddress()
+	tee2 := codectest.NewRandomAddress()
+
+	priv, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	pub := priv.PublicKey()
+	nitroID := attestation.KeyEnclaveID(pub[:])
+	nitroAccount := auth.NewED25519Address(pub)
+
+	// rewardState has [units] executed by [enclaveID] accrued, out of
+	// [collected] fees
+	rewardState := func(enclaveID []byte, collected, units uint64) state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		require.NoError(t, storage.SetRegion(ctx, store, "us-east", []codec.Address{tee1, tee2}))
+		require.NoError(t, storage.SetEnclave(ctx, store, "us-east", nitroID, storage.EnclaveActive, pub[:]))
+		require.NoError(t, storage.CollectFee(ctx, store, "us-east", collected))
+		require.NoError(t, accrueExecReward(ctx, rules, store, "us-east", enclaveID, units))
+		return store
+	}
+	poolIs := func(pool uint64) func(context.Context, *testing.T, state.Mutable) {
+		return func(ctx context.Context, t *testing.T, store state.Mutable) {
+			got, err := storage.GetRewardPool(ctx, store, "us-east")
+			require.NoError(t, err)
+			require.Equal(t, pool, got)
+		}
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "RegionNotFound",
+			Actor:       tee1,
+			Action:      &ClaimRewardsAction{RegionID: "eu-west", EnclaveID: tee1[:]},
+			State:       rewardState(tee1[:], 10*price, 10),
+			ExpectedErr: ErrRegionNotFound,
+		},
+		{
+			Name:        "OtherEnclave",
+			Actor:       tee2,
+			Action:      &ClaimRewardsAction{RegionID: "us-east", EnclaveID: tee1[:]},
+			State:       rewardState(tee1[:], 10*price, 10),
+			ExpectedErr: ErrNotRewardee,
+		},
+		{
+			Name:        "NoRewards",
+			Actor:       tee2,
+			Action:      &ClaimRewardsAction{RegionID: "us-east", EnclaveID: tee2[:]},
+			State:       rewardState(tee1[:], 10*price, 10),
+			ExpectedErr: ErrNoRewards,
+		},
+		{
+			Name:   "TEEAddress",
+			Actor:  tee1,
+			Action: &ClaimRewardsAction{RegionID: "us-east", EnclaveID: tee1[:]},
+			State:  rewardState(tee1[:], 10*price, 10),
+			ExpectedOutputs: &ClaimRewardsResult{
+				RegionID: "us-east",
+				Amount:   8 * price,
+				Balance:  8 * price,
+			},
+			// The rest of the fee stays in the pool
+			Assertion: poolIs(2 * price),
+		},
+		{
+			Name:   "EnclaveKey",
+			Actor:  nitroAccount,
+			Action: &ClaimRewardsAction{RegionID: "us-east", EnclaveID: nitroID},
+			State:  rewardState(nitroID, 10*price, 10),
+			ExpectedOutputs: &ClaimRewardsResult{
+				RegionID: "us-east",
+				Amount:   8 * price,
+				Balance:  8 * price,
+			},
+			Assertion: poolIs(2 * price),
+		},
+		{
+			// Only collected fees fund the pool
+			Name:   "FeesNotCollected",
+			Actor:  tee1,
+			Action: &ClaimRewardsAction{RegionID: "us-east", EnclaveID: tee1[:]},
+			State:  rewardState(tee1[:], 5*price, 10),
+			ExpectedOutputs: &ClaimRewardsResult{
+				RegionID: "us-east",
+				Amount:   4 * price,
+				Balance:  4 * price,
+			},
+			Assertion: poolIs(price),
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Rules = rules
+		tt.Run(context.Background(), t)
+	}
+}
+
+func TestAccrueExecReward(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	rules := genesis.NewDefaultRules()
+	tee := codectest.NewRandomAddress()
+
+	// A share of a fee below 10,000 basis points is not rounded away
+	store := chaintest.NewInMemoryStore()
+	require.NoError(storage.CollectFee(ctx, store, "us-east", 1_000))
+	require.NoError(accrueExecReward(ctx, rules, store, "us-east", tee[:], 1))
+	reward, err := storage.GetEnclaveReward(ctx, store, "us-east", tee[:])
+	require.NoError(err)
+	require.Equal(rules.GetMinUnitPrice()[fees.Compute]*OperatorRewardBps/10_000, reward)
+	require.NotZero(reward)
+	collected, err := storage.GetCollectedFees(ctx, store, "us-east")
+	require.NoError(err)
+	require.Equal(1_000-rules.GetMinUnitPrice()[fees.Compute], collected)
+
+	// Fees collected in another region do not fund this one
+	require.NoError(storage.CollectFee(ctx, store, "eu-west", 1_000))
+	require.NoError(accrueExecReward(ctx, rules, store, "ap-south", tee[:], 1))
+	reward, err = storage.GetEnclaveReward(ctx, store, "ap-south", tee[:])
+	require.NoError(err)
+	require.Zero(reward)
+
+	// and fees too large to price fail instead of wrapping
+	require.Error(accrueExecReward(ctx, rules, store, "us-east", tee[:], ^uint64(0)))
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrInvalidRoughtimeServer = errors.New("invalid roughtime server")
+
+// RoughtimeServer is a server whose stamps attestations may carry.
+type RoughtimeServer struct {
+	// ID is the server ID stamps name
+	ID string `serialize:"true" json:"id"`
+	// Address is the server's host:port
+	Address string `serialize:"true" json:"address"`
+	// PublicKey is the ed25519 key the server signs stamps with
+	PublicKey []byte `serialize:"true" json:"public_key"`
+}
+
+// RoughtimeServerSet is the value encoding of [consts.ParamRoughtimeServers].
+// While a new set is in its first [consts.RoughtimeGraceBlocks] blocks,
+// stamps of the set it replaced are accepted too, so enclaves can move to
+// the new servers and keys without a window of rejected executions.
+type RoughtimeServerSet struct {
+	Servers []RoughtimeServer `serialize:"true" json:"servers"`
+}
+
+// legacyRoughtimeServerSet is how the parameter was encoded before servers
+// carried keys.
+type legacyRoughtimeServerSet struct {
+	Servers []string `serialize:"true" json:"servers"`
+}
+
+func (s *RoughtimeServerSet) validate() error {
+	if len(s.Servers) == 0 {
+		return ErrInvalidRoughtimeServer
+	}
+	ids := make(map[string]struct{}, len(s.Servers))
+	for _, server := range s.Servers {
+		if _, ok := ids[server.ID]; ok || server.ID == "" {
+			return ErrInvalidRoughtimeServer
+		}
+		ids[server.ID] = struct{}{}
+		if _, _, err := net.SplitHostPort(server.Address); err != nil {
+			return ErrInvalidRoughtimeServer
+		}
+		if len(server.PublicKey) != ed25519.PublicKeySize {
+			return ErrInvalidRoughtimeServer
+		}
+	}
+	return nil
+}
+
+// stampKeys returns the keys of the servers of [s], or nil if any server
+// has no key, as in a legacy set: stamps cannot be checked against it.
+func (s *RoughtimeServerSet) stampKeys() attestation.StampKeys {
+	keys := make(attestation.StampKeys, len(s.Servers))
+	for _, server := range s.Servers {
+		if len(server.PublicKey) != ed25519.PublicKeySize {
+			return nil
+		}
+		keys[server.ID] = append(keys[server.ID], ed25519.PublicKey(server.PublicKey))
+	}
+	return keys
+}
+
+// decodeRoughtimeServerSet decodes a parameter value. It returns nil for an
+// unset value, and decodes legacy sets to servers without keys.
+func decodeRoughtimeServerSet(v []byte) (*RoughtimeServerSet, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	var set RoughtimeServerSet
+	if err := codec.Unmarshal(v, &set); err == nil {
+		return &set, nil
+	}
+	var legacy legacyRoughtimeServerSet
+	if err := codec.Unmarshal(v, &legacy); err != nil {
+		return nil, err
+	}
+	for _, address := range legacy.Servers {
+		set.Servers = append(set.Servers, RoughtimeServer{ID: address, Address: address})
+	}
+	return &set, nil
+}
+
+// RoughtimeServers returns the addresses of the Roughtime servers
+// governance set for the current height, or nil if it never set any.
+func RoughtimeServers(ctx context.Context, im state.Immutable) ([]string, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(consts.ParamRoughtimeServers))
+	if err != nil {
+		return nil, err
+	}
+	set, err := decodeRoughtimeServerSet(p.Value(height))
+	if err != nil || set == nil {
+		return nil, err
+	}
+	addresses := make([]string, len(set.Servers))
+	for i, server := range set.Servers {
+		addresses[i] = server.Address
+	}
+	return addresses, nil
+}
+
+// RoughtimeKeys returns the keys stamps are checked against at the current
+// height: those of the governed set and, during its grace period, of the
+// set it replaced. It returns nil, trusting any stamp, if governance never
+// set servers with keys.
+func RoughtimeKeys(ctx context.Context, im state.Immutable) (attestation.StampKeys, error) {
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return nil, err
+	}
+	p, err := storage.GetParam(ctx, im, uint8(consts.ParamRoughtimeServers))
+	if err != nil {
+		return nil, err
+	}
+	set, err := decodeRoughtimeServerSet(p.Value(height))
+	if err != nil || set == nil {
+		return nil, err
+	}
+	keys := set.stampKeys()
+	if keys == nil {
+		return nil, nil
+	}
+	switched := p.HasPending && height >= p.ActivationHeight
+	if !switched || height >= p.ActivationHeight+consts.RoughtimeGraceBlocks {
+		return keys, nil
+	}
+	previous, err := decodeRoughtimeServerSet(p.Current)
+	if err != nil || previous == nil {
+		return keys, err
+	}
+	for id, old := range previous.stampKeys() {
+		keys[id] = append(keys[id], old...)
+	}
+	return keys, nil
+}
+
+// RoughtimeQuorum returns how many Roughtime servers must agree on an
+// attestation's time, and within what radius, at the current height.
+func RoughtimeQuorum(ctx context.Context, im state.Immutable) (attestation.StampQuorum, error) {
+	servers, err := Uint64Param(ctx, im, consts.ParamStampQuorum, attestation.MinStamps)
+	if err != nil {
+		return attestation.StampQuorum{}, err
+	}
+	radius, err := Uint64Param(ctx, im, consts.ParamStampRadius, consts.StampRadius)
+	if err != nil {
+		return attestation.StampQuorum{}, err
+	}
+	return attestation.StampQuorum{Servers: int(servers), Radius: radius}, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestRoughtimeKeysGrace(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	store := chaintest.NewInMemoryStore()
+
+	server := func(id string) RoughtimeServer {
+		pub, _, err := ed25519.GenerateKey(nil)
+		require.NoError(err)
+		return RoughtimeServer{ID: id, Address: id + ".example:2002", PublicKey: pub}
+	}
+	encode := func(servers ...RoughtimeServer) []byte {
+		set := &RoughtimeServerSet{Servers: servers}
+		require.NoError(set.validate())
+		v, err := codec.Marshal(set)
+		require.NoError(err)
+		return v
+	}
+	setHeight := func(height uint64) {
+		require.NoError(store.Insert(ctx, storage.HeightKey(), binary.BigEndian.AppendUint64(nil, height)))
+	}
+	param := uint8(consts.ParamRoughtimeServers)
+
+	// Nothing governed: any stamp is trusted
+	setHeight(1)
+	keys, err := RoughtimeKeys(ctx, store)
+	require.NoError(err)
+	require.Nil(keys)
+
+	a, b, c := server("a"), server("b"), server("c")
+	require.NoError(storage.ScheduleParam(ctx, store, param, encode(a, b), 1, 10))
+	setHeight(10)
+	keys, err = RoughtimeKeys(ctx, store)
+	require.NoError(err)
+	require.Len(keys, 2)
+
+	// Rotate b's key and replace a with c
+	b2 := server("b")
+	require.NoError(storage.ScheduleParam(ctx, store, param, encode(b2, c), 10, 20))
+	setHeight(20)
+	keys, err = RoughtimeKeys(ctx, store)
+	require.NoError(err)
+	require.Len(keys, 3)
+	require.Len(keys["b"], 2)
+	require.Contains(keys, "a")
+
+	setHeight(20 + consts.RoughtimeGraceBlocks)
+	keys, err = RoughtimeKeys(ctx, store)
+	require.NoError(err)
+	require.Len(keys, 2)
+	require.Equal([]ed25519.PublicKey{b2.PublicKey}, keys["b"])
+	require.NotContains(keys, "a")
+
+	servers, err := RoughtimeServers(ctx, store)
+	require.NoError(err)
+	require.Equal([]string{b2.Address, c.Address}, servers)
+}
@@ -0,0 +1,289 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// sagaTag precedes the ID a saga event follows in the hash giving its ID
+const sagaTag byte = 0x02
+
+var (
+	ErrInvalidSaga  = errors.New("saga needs 1 to MaxSagaSteps steps, each naming an object and function")
+	ErrSagaObject   = errors.New("execution is not of the object the saga event was queued for")
+	ErrSagaNotFound = errors.New("saga not found")
+
+	_ chain.Action = (*StartSagaAction)(nil)
+)
+
+// SagaEventID returns the ID of the event a saga queues after the event
+// [prev]: the event of its next step, or the compensating event of the
+// step it undoes next. The first event of a saga follows the ID of the
+// action that started it. Deriving each ID from the last lets the
+// execution of an event declare the keys it moves the saga to.
+func SagaEventID(prev ids.ID) ids.ID {
+	return ids.ID(sha256.Sum256(append([]byte{sagaTag}, prev[:]...)))
+}
+
+// StartSagaAction starts a saga running [Steps] in order, for workflows
+// whose steps touch objects, or regions, that cannot apply atomically. The
+// event of each step is queued once the step before completes. When the
+// attested execution of a step reports failure, the compensating events
+// of the steps that completed are queued instead, latest first. Each event
+// the saga may queue is checked as a SendEventAction to its object is, and
+// paid for when the saga starts.
+type StartSagaAction struct {
+	Steps []storage.SagaStep `serialize:"true" json:"steps"`
+}
+
+func (*StartSagaAction) GetTypeID() uint8 {
+	return consts.StartSagaID
+}
+
+func (s *StartSagaAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+	first := SagaEventID(actionID)
+	keys := state.Keys{
+		string(storage.SagaKey(first)):      state.All,
+		string(storage.SagaEventKey(first)): state.All,
+	}
+	for _, step := range s.Steps {
+		keys[string(storage.ObjectKey(step.Object))] = state.Read
+		keys[string(storage.ObjectMetadataKey(step.Object))] = state.Read
+		keys[string(storage.ParamSchemaKey(step.Object, step.Function))] = state.Read
+		if step.Compensate != "" {
+			keys[string(storage.ParamSchemaKey(step.Object, step.Compensate))] = state.Read
+		}
+	}
+	return addGateKeys(keys, actor, s)
+}
+
+func (s *StartSagaAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	actionID ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.StartSagaID, "", "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	if len(s.Steps) == 0 || len(s.Steps) > consts.MaxSagaSteps {
+		return nil, ErrInvalidSaga
+	}
+	for _, step := range s.Steps {
+		if len(step.Object) == 0 || len(step.Object) > consts.MaxIDLength || step.Function == "" {
+			return nil, ErrInvalidSaga
+		}
+		obj, err := storage.GetObject(ctx, mu, step.Object)
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			return nil, ErrObjectNotFound
+		}
+		if err := checkSagaEvent(ctx, mu, step.Object, step.Function, step.Parameters); err != nil {
+			return nil, err
+		}
+		if step.Compensate != "" {
+			if err := checkSagaEvent(ctx, mu, step.Object, step.Compensate, step.CompensateParameters); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	first := SagaEventID(actionID)
+	step := s.Steps[0]
+	if err := storage.SetSagaEvent(ctx, mu, first, &storage.SagaEvent{
+		Object:     step.Object,
+		Function:   step.Function,
+		Parameters: step.Parameters,
+		QueuedAt:   timestamp,
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetSaga(ctx, mu, first, &storage.Saga{
+		ID:     actionID,
+		Owner:  actor,
+		Steps:  s.Steps,
+		Status: storage.SagaRunning,
+	}); err != nil {
+		return nil, err
+	}
+	return &StartSagaResult{SagaID: actionID, EventID: first}, nil
+}
+
+// ComputeUnits prices every event the saga may queue: the event of each
+// step and each compensation.
+func (s *StartSagaAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	var size, sagaEvents int
+	for _, step := range s.Steps {
+		size += len(step.Object) + len(step.Function) + len(step.Parameters) +
+			len(step.Compensate) + len(step.CompensateParameters)
+		sagaEvents++
+		if step.Compensate != "" {
+			sagaEvents++
+		}
+	}
+	return schedule.BaseUnits + uint64(sagaEvents)*schedule.EventUnits +
+		schedule.StorageUnits(0, size)
+}
+
+// checkSagaEvent checks an event a saga may queue to [object] as a
+// SendEventAction to it is checked: against the limits on its function
+// and parameters, the parameter schema of the function and the installed
+// content policies.
+func checkSagaEvent(ctx context.Context, im state.Immutable, object string, function string, params []byte) error {
+	if len(function) > 256 {
+		return ErrInvalidFunction
+	}
+	if len(params) > MaxStorageSize {
+		return ErrStorageTooLarge
+	}
+	schema, ok, err := storage.GetParamSchema(ctx, im, object, function)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := ValidateParams(schema, params); err != nil {
+			return err
+		}
+	}
+	if len(contentPolicies) == 0 {
+		return nil
+	}
+	m, err := storage.GetObjectMetadata(ctx, im, object)
+	if err != nil {
+		return err
+	}
+	return checkEventContent(ctx, &SendEventAction{IDTo: object, FunctionCall: function, Parameters: params}, objectRegion(m))
+}
+
+func (*StartSagaAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type StartSagaResult struct {
+	SagaID ids.ID `serialize:"true" json:"saga_id"`
+	// EventID is the ID the event of the first step is queued and executed by
+	EventID ids.ID `serialize:"true" json:"event_id"`
+}
+
+func (*StartSagaResult) GetTypeID() uint8 {
+	return consts.StartSagaResultID
+}
+
+// addSagaKeys declares the keys [advanceSaga] reads and writes for the
+// execution of [eventID].
+func addSagaKeys(keys state.Keys, eventID ids.ID) state.Keys {
+	next := SagaEventID(eventID)
+	keys[string(storage.SagaKey(eventID))] = state.All
+	keys[string(storage.SagaEventKey(eventID))] = state.All
+	keys[string(storage.SagaKey(next))] = state.All
+	keys[string(storage.SagaEventKey(next))] = state.All
+	return keys
+}
+
+// advanceSaga moves the saga awaiting [eventID], if any, on from an
+// execution of the event by [object] at [timestamp]. A step that completed
+// queues the next step's event; a step that [failed], or a compensation
+// that completed, queues the compensation of the latest step before it
+// that has one. Later executions of the same event, such as the other
+// enclave's attestation, find the saga moved on and leave it as it is.
+func advanceSaga(
+	ctx context.Context,
+	mu state.Mutable,
+	eventID ids.ID,
+	object string,
+	failed bool,
+	timestamp int64,
+) error {
+	saga, err := storage.GetSaga(ctx, mu, eventID)
+	if err != nil {
+		return err
+	}
+	if saga == nil || saga.Done() {
+		return nil
+	}
+	if object != saga.Steps[saga.Step].Object {
+		return ErrSagaObject
+	}
+	if err := storage.RemoveSagaEvent(ctx, mu, eventID); err != nil {
+		return err
+	}
+
+	var event *storage.SagaEvent
+	switch {
+	case saga.Status == storage.SagaRunning && !failed:
+		if int(saga.Step)+1 == len(saga.Steps) {
+			saga.Status = storage.SagaCompleted
+			return storage.SetSaga(ctx, mu, eventID, saga)
+		}
+		saga.Step++
+		step := saga.Steps[saga.Step]
+		event = &storage.SagaEvent{Object: step.Object, Function: step.Function, Parameters: step.Parameters}
+	case saga.Status == storage.SagaCompensating && failed:
+		saga.Status = storage.SagaStuck
+		return storage.SetSaga(ctx, mu, eventID, saga)
+	default:
+		// The failed step applied nothing, so undoing starts before it
+		saga.Status = storage.SagaCompensating
+		i := int(saga.Step) - 1
+		for i >= 0 && saga.Steps[i].Compensate == "" {
+			i--
+		}
+		if i < 0 {
+			saga.Status = storage.SagaCompensated
+			return storage.SetSaga(ctx, mu, eventID, saga)
+		}
+		saga.Step = uint32(i)
+		step := saga.Steps[i]
+		event = &storage.SagaEvent{Object: step.Object, Function: step.Compensate, Parameters: step.CompensateParameters}
+	}
+
+	next := SagaEventID(eventID)
+	event.QueuedAt = timestamp
+	if err := storage.SetSagaEvent(ctx, mu, next, event); err != nil {
+		return err
+	}
+	if err := storage.SetSaga(ctx, mu, next, saga); err != nil {
+		return err
+	}
+	return storage.RemoveSaga(ctx, mu, eventID)
+}
+
+// FindSaga follows the saga [sagaID] from its first event to the one it
+// awaits, or the last if it is done, and returns that event's ID and the
+// saga.
+func FindSaga(ctx context.Context, im state.Immutable, sagaID ids.ID) (ids.ID, *storage.Saga, error) {
+	eventID := SagaEventID(sagaID)
+	// Each step queues at most its event and its compensation
+	for i := 0; i < 2*consts.MaxSagaSteps; i++ {
+		saga, err := storage.GetSaga(ctx, im, eventID)
+		if err != nil {
+			return ids.Empty, nil, err
+		}
+		if saga != nil {
+			return eventID, saga, nil
+		}
+		eventID = SagaEventID(eventID)
+	}
+	return ids.Empty, nil, ErrSagaNotFound
+}
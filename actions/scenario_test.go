@@ -0,0 +1,31 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+// Scenarios run actions end-to-end on a testvm.VM, mostly from a
+// testvm.Fixture, and live in the actions_test package since testvm
+// imports actions.
+
+// counter is an execution of "contract" setting its counter to [value]
+func counter(value byte) actions.TEEExecResult {
+	return actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: map[string][]byte{"counter": {value}},
+	}
+}
+
+// succeeded asserts that a TEEExecAction applied its result
+func succeeded(_ context.Context, t *testing.T, out codec.Typed) {
+	require.True(t, out.(*actions.TEEExecOutput).Success)
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrSealedParams         = errors.New("malformed sealed parameters")
+	ErrNotSealed            = errors.New("event parameters are not sealed")
+	ErrInvalidEncryptionKey = errors.New("invalid enclave encryption key")
+)
+
+// EventAAD is the additional data sealed parameters of an event to
+// [function] of [idTo] are bound to, so an envelope cannot be replayed
+// against another call.
+func EventAAD(idTo, function string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(idTo)))
+	b = append(b, idTo...)
+	return append(b, function...)
+}
+
+// Seal encrypts the event's parameters to [keys], the encryption keys of
+// the target region's enclaves, and marks the event encrypted.
+func (a *SendEventAction) Seal(keys []storage.EncryptionKey) error {
+	recipients := make([][]byte, len(keys))
+	for i, k := range keys {
+		recipients[i] = k.Key
+	}
+	sealed, err := envelope.Seal(recipients, a.Parameters, EventAAD(a.IDTo, a.FunctionCall))
+	if err != nil {
+		return err
+	}
+	a.Parameters = sealed
+	a.Encrypted = true
+	return nil
+}
+
+// OpenParameters decrypts sealed event parameters with an enclave's
+// encryption key. It is meant to run inside the enclave.
+func OpenParameters(priv *ecdh.PrivateKey, idTo, function string, parameters []byte) ([]byte, error) {
+	return envelope.Open(priv, parameters, EventAAD(idTo, function))
+}
+
+// OpenParameters decrypts the event's parameters with [priv], an enclave's
+// encryption key.
+func (a *SendEventAction) OpenParameters(priv *ecdh.PrivateKey) ([]byte, error) {
+	if !a.Encrypted {
+		return nil, ErrNotSealed
+	}
+	return OpenParameters(priv, a.IDTo, a.FunctionCall, a.Parameters)
+}
+
+// publishEncryptionKey records [key] as the encryption key of [enclaveID]
+// in [regionID], replacing any key it published before. Once
+// [envelope.MaxRecipients] enclaves have published, the oldest key is
+// dropped so that one envelope can always reach every listed enclave.
+func publishEncryptionKey(ctx context.Context, mu state.Mutable, regionID string, enclaveID, key []byte) error {
+	if _, err := envelope.ParseKey(key); err != nil || len(key) != envelope.KeySize {
+		return fmt.Errorf("%w: %d bytes", ErrInvalidEncryptionKey, len(key))
+	}
+	keys, err := storage.GetEncryptionKeys(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	kept := make([]storage.EncryptionKey, 0, len(keys)+1)
+	for _, k := range keys {
+		if !bytes.Equal(k.EnclaveID, enclaveID) {
+			kept = append(kept, k)
+		}
+	}
+	kept = append(kept, storage.EncryptionKey{EnclaveID: enclaveID, Key: key})
+	if len(kept) > envelope.MaxRecipients {
+		kept = kept[len(kept)-envelope.MaxRecipients:]
+	}
+	return storage.SetEncryptionKeys(ctx, mu, regionID, kept)
+}
@@ -0,0 +1,371 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrStorageNotSealed  = errors.New("object storage is not sealed")
+	ErrSealedRegion      = errors.New("object storage sealed by another region")
+	ErrSealedSigner      = errors.New("enclave cannot open sealed storage")
+	ErrSealedRecipients  = errors.New("invalid sealed storage recipients")
+	ErrSealedStorageBlob = errors.New("malformed sealed storage")
+
+	_ chain.Action = (*SealStorageAction)(nil)
+	_ chain.Action = (*ResealStorageAction)(nil)
+)
+
+// sealDomain and resealDomain separate sealed storage signatures from the
+// other statements an enclave key signs, and from each other: a reseal
+// vouches that the storage did not change.
+const (
+	sealDomain   = "shuttlevm/seal"
+	resealDomain = "shuttlevm/reseal"
+)
+
+// StorageAAD is the additional data the sealed storage of [objectID] is
+// bound to, so a blob cannot be passed off as another object's.
+func StorageAAD(objectID string) []byte {
+	return []byte(sealDomain + "/" + objectID)
+}
+
+// SealStorageDigest is what an enclave signs to seal new storage of
+// [objectID], with hash [hash], to [recipients]. [sequence] is the sequence
+// of the sealed storage it replaces, zero when the storage was in the
+// clear.
+func SealStorageDigest(objectID string, sequence uint64, hash ids.ID, recipients [][]byte) []byte {
+	return sealedStorageDigest(sealDomain, objectID, sequence, hash, recipients)
+}
+
+// ResealStorageDigest is what an enclave signs to seal the unchanged
+// storage of [objectID] to [recipients] instead.
+func ResealStorageDigest(objectID string, sequence uint64, hash ids.ID, recipients [][]byte) []byte {
+	return sealedStorageDigest(resealDomain, objectID, sequence, hash, recipients)
+}
+
+func sealedStorageDigest(domain, objectID string, sequence uint64, hash ids.ID, recipients [][]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(domain))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(objectID))))
+	h.Write([]byte(objectID))
+	h.Write(binary.BigEndian.AppendUint64(nil, sequence))
+	h.Write(hash[:])
+	for _, r := range recipients {
+		h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(r))))
+		h.Write(r)
+	}
+	return h.Sum(nil)
+}
+
+// SealStorageAction replaces the storage of [ObjectID] with [Blob], an
+// envelope sealed by an enclave of [RegionID] to the encryption keys of
+// [Recipients]. State only keeps the blob's hash; the region keeps the
+// blob. The first seal drops the object's storage in the clear. After that,
+// only a recipient of the current blob may seal new storage, typically
+// after an execution changed it.
+type SealStorageAction struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	RegionID string `serialize:"true" json:"region_id"`
+	Blob     []byte `serialize:"true" json:"blob"`
+	// Recipients are enclaves of the region with published encryption
+	// keys, in the order of the blob's recipients
+	Recipients [][]byte `serialize:"true" json:"recipients"`
+	// Attestation signs [SealStorageDigest]
+	Attestation attestation.Attestation `serialize:"true" json:"attestation"`
+}
+
+func (*SealStorageAction) GetTypeID() uint8 {
+	return consts.SealStorageID
+}
+
+func (s *SealStorageAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := sealedStorageKeys(s.ObjectID, s.RegionID, s.Recipients, &s.Attestation)
+	keys[string(storage.ObjectKey(s.ObjectID))] = state.Read | state.Write
+	return addGateKeys(keys, actor, s)
+}
+
+func (s *SealStorageAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SealStorageID, s.RegionID, s.ObjectID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	obj, err := storage.GetObject(ctx, mu, s.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, ErrObjectNotFound
+	}
+	sealed, err := storage.GetSealedStorage(ctx, mu, s.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	var sequence uint64
+	if sealed != nil {
+		sequence = sealed.Sequence
+	}
+	hash := ids.ID(sha256.Sum256(s.Blob))
+	digest := SealStorageDigest(s.ObjectID, sequence, hash, s.Recipients)
+	next, err := checkSeal(ctx, mu, timestamp, s.RegionID, sealed, s.Blob, s.Recipients, digest, &s.Attestation)
+	if err != nil {
+		return nil, err
+	}
+
+	if sealed == nil {
+		// The storage in the clear is superseded by the sealed blob
+		rest := make(map[string][]byte, len(obj))
+		for k, v := range obj {
+			if k != "storage" {
+				rest[k] = v
+			}
+		}
+		if err := storage.SetObject(ctx, mu, s.ObjectID, rest); err != nil {
+			return nil, err
+		}
+	}
+	if err := storage.SetSealedStorage(ctx, mu, s.ObjectID, next); err != nil {
+		return nil, err
+	}
+	return &SealStorageResult{
+		ObjectID: s.ObjectID,
+		Hash:     hash,
+		Sequence: next.Sequence,
+	}, nil
+}
+
+func (s *SealStorageAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.StorageUnits(0, len(s.Blob)) +
+		schedule.AttestationUnits +
+		uint64(len(s.Recipients))*schedule.TEEUnits +
+		2*schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(s).QuoteBytes)
+}
+
+func (*SealStorageAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type SealStorageResult struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	Hash     ids.ID `serialize:"true" json:"hash"`
+	Sequence uint64 `serialize:"true" json:"sequence"`
+}
+
+func (*SealStorageResult) GetTypeID() uint8 {
+	return consts.SealStorageResultID
+}
+
+// ResealStorageAction seals the unchanged storage of [ObjectID] to new
+// [Recipients], so that enclaves joining the region can open it and
+// enclaves leaving no longer can. The signer must be a recipient of the
+// current blob, the only kind of enclave able to open it.
+type ResealStorageAction struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	// RegionID is the region the storage is sealed by, declared so the
+	// signer's state keys are known up front
+	RegionID   string   `serialize:"true" json:"region_id"`
+	Blob       []byte   `serialize:"true" json:"blob"`
+	Recipients [][]byte `serialize:"true" json:"recipients"`
+	// Attestation signs [ResealStorageDigest]
+	Attestation attestation.Attestation `serialize:"true" json:"attestation"`
+}
+
+func (*ResealStorageAction) GetTypeID() uint8 {
+	return consts.ResealStorageID
+}
+
+func (r *ResealStorageAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(sealedStorageKeys(r.ObjectID, r.RegionID, r.Recipients, &r.Attestation), actor, r)
+}
+
+func (r *ResealStorageAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.ResealStorageID, r.RegionID, r.ObjectID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	sealed, err := storage.GetSealedStorage(ctx, mu, r.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if sealed == nil {
+		return nil, ErrStorageNotSealed
+	}
+	hash := ids.ID(sha256.Sum256(r.Blob))
+	digest := ResealStorageDigest(r.ObjectID, sealed.Sequence, hash, r.Recipients)
+	next, err := checkSeal(ctx, mu, timestamp, r.RegionID, sealed, r.Blob, r.Recipients, digest, &r.Attestation)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.SetSealedStorage(ctx, mu, r.ObjectID, next); err != nil {
+		return nil, err
+	}
+	return &ResealStorageResult{
+		ObjectID: r.ObjectID,
+		Hash:     hash,
+		Sequence: next.Sequence,
+	}, nil
+}
+
+func (r *ResealStorageAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.StorageUnits(0, len(r.Blob)) +
+		schedule.AttestationUnits +
+		uint64(len(r.Recipients))*schedule.TEEUnits +
+		schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(r).QuoteBytes)
+}
+
+func (*ResealStorageAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type ResealStorageResult struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	Hash     ids.ID `serialize:"true" json:"hash"`
+	Sequence uint64 `serialize:"true" json:"sequence"`
+}
+
+func (*ResealStorageResult) GetTypeID() uint8 {
+	return consts.ResealStorageResultID
+}
+
+// sealedStorageKeys declares what sealing the storage of [objectID] reads
+// and writes, apart from the object itself.
+func sealedStorageKeys(objectID, regionID string, recipients [][]byte, a *attestation.Attestation) state.Keys {
+	keys := state.Keys{
+		string(storage.ObjectKey(objectID)):                     state.Read,
+		string(storage.SealedStorageKey(objectID)):              state.All,
+		string(storage.RegionKey(regionID)):                     state.Read,
+		string(storage.EncryptionKeysKey(regionID)):             state.Read,
+		string(storage.EnclaveKey(regionID, a.EnclaveID)):       state.Read,
+		string(storage.EnclavePubKeyKey(regionID, a.EnclaveID)): state.Read,
+		string(storage.EnclaveExpiryKey(regionID, a.EnclaveID)): state.Read,
+	}
+	addPlatformKeys(keys, regionID, a.EnclaveID)
+	for _, r := range recipients {
+		keys[string(storage.EnclaveKey(regionID, r))] = state.Read
+		keys[string(storage.EnclavePubKeyKey(regionID, r))] = state.Read
+	}
+	return keys
+}
+
+// checkSeal checks a blob sealed by [a]'s enclave in [regionID] may replace
+// [sealed], the current sealed storage or nil, and returns the record that
+// replaces it. The blob must be an envelope whose recipients are the
+// published encryption keys of [recipients], all active enclaves of the
+// region.
+func checkSeal(
+	ctx context.Context,
+	im state.Immutable,
+	timestamp int64,
+	regionID string,
+	sealed *storage.SealedStorage,
+	blob []byte,
+	recipients [][]byte,
+	digest []byte,
+	a *attestation.Attestation,
+) (*storage.SealedStorage, error) {
+	if len(blob) > consts.MaxStorageSize {
+		return nil, ErrStorageTooLarge
+	}
+	keyIDs, err := envelope.Recipients(blob)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSealedStorageBlob, err)
+	}
+	if len(keyIDs) != len(recipients) {
+		return nil, fmt.Errorf("%w: %d recipients sealed to, %d declared", ErrSealedRecipients, len(keyIDs), len(recipients))
+	}
+	if sealed != nil {
+		if sealed.RegionID != regionID {
+			return nil, ErrSealedRegion
+		}
+		if !sealed.IsRecipient(a.EnclaveID) {
+			return nil, ErrSealedSigner
+		}
+	}
+	if err := verifySettlementSigner(ctx, im, timestamp, regionID, digest, a); err != nil {
+		return nil, err
+	}
+
+	published, err := storage.GetEncryptionKeys(ctx, im, regionID)
+	if err != nil {
+		return nil, err
+	}
+	for i, enclaveID := range recipients {
+		for _, other := range recipients[:i] {
+			if bytes.Equal(enclaveID, other) {
+				return nil, fmt.Errorf("%w: duplicate recipient", ErrSealedRecipients)
+			}
+		}
+		var key []byte
+		for _, k := range published {
+			if bytes.Equal(k.EnclaveID, enclaveID) {
+				key = k.Key
+			}
+		}
+		if key == nil {
+			return nil, fmt.Errorf("%w: %x has no encryption key", ErrSealedRecipients, enclaveID)
+		}
+		if !bytes.Equal(envelope.KeyID(key), keyIDs[i]) {
+			return nil, fmt.Errorf("%w: blob not sealed to %x", ErrSealedRecipients, enclaveID)
+		}
+		status, _, err := storage.GetEnclave(ctx, im, regionID, enclaveID)
+		if err != nil {
+			return nil, err
+		}
+		if status != storage.EnclaveActive {
+			return nil, fmt.Errorf("%w: %x is not active", ErrSealedRecipients, enclaveID)
+		}
+	}
+
+	next := &storage.SealedStorage{
+		RegionID:   regionID,
+		Hash:       sha256.Sum256(blob),
+		Size:       uint64(len(blob)),
+		Recipients: recipients,
+		Sequence:   1,
+	}
+	if sealed != nil {
+		next.Sequence = sealed.Sequence + 1
+	}
+	return next, nil
+}
@@ -0,0 +1,261 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/auth"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidSession       = errors.New("invalid session grant")
+	ErrSessionNotAuthorized = errors.New("session key not authorized")
+	ErrSessionExpired       = errors.New("session key expired")
+	ErrSessionScope         = errors.New("outside session key scope")
+
+	_ chain.Action = (*AuthorizeSessionAction)(nil)
+	_ chain.Action = (*RevokeSessionAction)(nil)
+)
+
+// IsSession reports whether [addr] is the address of a session key.
+func IsSession(addr codec.Address) bool {
+	return addr[0] == consts.SessionAuthID
+}
+
+// CheckSession returns why [actor] may not take [action] at [timestamp],
+// if it is a session outside the scope its account granted it. Other
+// actors are not checked.
+func CheckSession(ctx context.Context, im state.Immutable, actor codec.Address, action chain.Action, timestamp int64) error {
+	if !IsSession(actor) {
+		return nil
+	}
+	grant, err := storage.GetSessionGrant(ctx, im, actor)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		return ErrSessionNotAuthorized
+	}
+	if timestamp > grant.Expiry {
+		return fmt.Errorf("%w: at %d", ErrSessionExpired, grant.Expiry)
+	}
+	if !slices.Contains(grant.ActionTypes, action.GetTypeID()) {
+		return fmt.Errorf("%w: action type %d", ErrSessionScope, action.GetTypeID())
+	}
+	if len(grant.Regions) > 0 {
+		regionID, err := sessionRegion(ctx, im, action)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(grant.Regions, regionID) {
+			return fmt.Errorf("%w: region %q", ErrSessionScope, regionID)
+		}
+	}
+	// A session sends events as its account
+	if a, ok := action.(*SendEventAction); ok && a.Sender != codec.EmptyAddress && a.Sender != grant.Account && a.Sender != actor {
+		return fmt.Errorf("%w: sender %s", ErrSessionScope, a.Sender)
+	}
+	return nil
+}
+
+// sessionRegion returns the region [action] acts on. An event acts on the
+// region its target is listed in.
+func sessionRegion(ctx context.Context, im state.Immutable, action chain.Action) (string, error) {
+	a, ok := action.(*SendEventAction)
+	if !ok {
+		return ActionRegion(action), nil
+	}
+	m, err := storage.GetObjectMetadata(ctx, im, a.IDTo)
+	if err != nil {
+		return "", err
+	}
+	return objectRegion(m), nil
+}
+
+// AuthorizeSessionAction lets the session key [Key] of the actor act until
+// [Expiry], in block time milliseconds, with the action types
+// [ActionTypes] and, if [Regions] is set, only on those regions. The key
+// signs with auth.Session, as its auth.SessionAddress; [Allowance] is moved
+// there from the actor's balance to pay its fees. Authorizing a key again
+// replaces its grant.
+type AuthorizeSessionAction struct {
+	Key         ed25519.PublicKey `serialize:"true" json:"key"`
+	Expiry      int64             `serialize:"true" json:"expiry"`
+	ActionTypes []uint8           `serialize:"true" json:"action_types"`
+	Regions     []string          `serialize:"true" json:"regions"`
+	Allowance   uint64            `serialize:"true" json:"allowance"`
+}
+
+func (*AuthorizeSessionAction) GetTypeID() uint8 {
+	return consts.AuthorizeSessionID
+}
+
+func (a *AuthorizeSessionAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	session := auth.SessionAddress(actor, a.Key)
+	keys := state.Keys{
+		string(storage.SessionKey(session)): state.All,
+	}
+	if a.Allowance > 0 {
+		keys[string(storage.BalanceKey(actor))] = state.Read | state.Write
+		keys[string(storage.BalanceKey(session))] = state.All
+	}
+	return addGateKeys(keys, actor, a)
+}
+
+func (a *AuthorizeSessionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.AuthorizeSessionID, "", "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case IsSession(actor):
+		return nil, fmt.Errorf("%w: sessions may not authorize keys", ErrInvalidSession)
+	case a.Expiry <= timestamp:
+		return nil, fmt.Errorf("%w: expiry %d not after %d", ErrInvalidSession, a.Expiry, timestamp)
+	case len(a.ActionTypes) == 0 || len(a.ActionTypes) > consts.MaxSessionActionTypes:
+		return nil, fmt.Errorf("%w: %d action types", ErrInvalidSession, len(a.ActionTypes))
+	case len(a.Regions) > consts.MaxSessionRegions:
+		return nil, fmt.Errorf("%w: %d regions", ErrInvalidSession, len(a.Regions))
+	}
+	session := auth.SessionAddress(actor, a.Key)
+	if err := storage.SetSessionGrant(ctx, mu, session, &storage.SessionGrant{
+		Account:     actor,
+		Expiry:      a.Expiry,
+		ActionTypes: a.ActionTypes,
+		Regions:     a.Regions,
+	}); err != nil {
+		return nil, err
+	}
+	if a.Allowance > 0 {
+		if _, err := storage.SubBalance(ctx, mu, actor, a.Allowance); err != nil {
+			return nil, err
+		}
+		if _, err := storage.AddBalance(ctx, mu, session, a.Allowance, true); err != nil {
+			return nil, err
+		}
+	}
+	return &AuthorizeSessionResult{Session: session, Expiry: a.Expiry}, nil
+}
+
+func (a *AuthorizeSessionAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	units := schedule.BaseUnits + schedule.StateUpdateUnits
+	if a.Allowance > 0 {
+		units += 2 * schedule.StateUpdateUnits
+	}
+	return units
+}
+
+func (*AuthorizeSessionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type AuthorizeSessionResult struct {
+	Session codec.Address `serialize:"true" json:"session"`
+	Expiry  int64         `serialize:"true" json:"expiry"`
+}
+
+func (*AuthorizeSessionResult) GetTypeID() uint8 {
+	return consts.AuthorizeSessionResultID
+}
+
+// RevokeSessionAction ends the grant of the actor's session key [Key]
+// before it expires, and returns what is left of its allowance.
+type RevokeSessionAction struct {
+	Key ed25519.PublicKey `serialize:"true" json:"key"`
+}
+
+func (*RevokeSessionAction) GetTypeID() uint8 {
+	return consts.RevokeSessionID
+}
+
+func (r *RevokeSessionAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	session := auth.SessionAddress(actor, r.Key)
+	return addGateKeys(state.Keys{
+		string(storage.SessionKey(session)): state.Read | state.Write,
+		string(storage.BalanceKey(session)): state.Read | state.Write,
+		string(storage.BalanceKey(actor)):   state.All,
+	}, actor, r)
+}
+
+func (r *RevokeSessionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.RevokeSessionID, "", "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, r); err != nil {
+		return nil, err
+	}
+
+	session := auth.SessionAddress(actor, r.Key)
+	grant, err := storage.GetSessionGrant(ctx, mu, session)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil {
+		return nil, ErrSessionNotAuthorized
+	}
+	if err := storage.RemoveSessionGrant(ctx, mu, session); err != nil {
+		return nil, err
+	}
+	refund, err := storage.GetBalance(ctx, mu, session)
+	if err != nil {
+		return nil, err
+	}
+	if refund > 0 {
+		if _, err := storage.SubBalance(ctx, mu, session, refund); err != nil {
+			return nil, err
+		}
+		if _, err := storage.AddBalance(ctx, mu, actor, refund, true); err != nil {
+			return nil, err
+		}
+	}
+	return &RevokeSessionResult{Session: session, Refunded: refund}, nil
+}
+
+func (*RevokeSessionAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 3*schedule.StateUpdateUnits
+}
+
+func (*RevokeSessionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type RevokeSessionResult struct {
+	Session  codec.Address `serialize:"true" json:"session"`
+	Refunded uint64        `serialize:"true" json:"refunded"`
+}
+
+func (*RevokeSessionResult) GetTypeID() uint8 {
+	return consts.RevokeSessionResultID
+}
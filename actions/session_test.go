@@ -0,0 +1,113 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestSessionScopedExecute(t *testing.T) {
+	session := codec.CreateAddress(consts.SessionAuthID, ids.GenerateTestID())
+	account := codectest.NewRandomAddress()
+	assetID := ids.GenerateTestID()
+
+	// sessionState has [session] holding 10 of [assetID] under [grant], if
+	// set
+	sessionState := func(grant *storage.SessionGrant) state.Mutable {
+		ctx := context.Background()
+		store := chaintest.NewInMemoryStore()
+		_, err := storage.AddAssetBalance(ctx, store, assetID, session, 10, true)
+		require.NoError(t, err)
+		if grant != nil {
+			require.NoError(t, storage.SetSessionGrant(ctx, store, session, grant))
+		}
+		return store
+	}
+	grant := func(expiry int64, regions []string, actionTypes ...uint8) *storage.SessionGrant {
+		return &storage.SessionGrant{Account: account, Expiry: expiry, ActionTypes: actionTypes, Regions: regions}
+	}
+	transfer := &TransferAssetAction{AssetID: assetID, To: account, Value: 1}
+	// With a single TEE, a region created past the session check fails with
+	// ErrTooFewTEEs
+	create := &CreateRegionAction{
+		Version:  consts.LatestActionVersion,
+		RegionID: "us-east",
+		TEEs:     []codec.Address{codectest.NewRandomAddress()},
+	}
+
+	tests := []chaintest.ActionTest{
+		{
+			Name:        "NotAuthorized",
+			Actor:       session,
+			Action:      transfer,
+			State:       sessionState(nil),
+			Timestamp:   5,
+			ExpectedErr: ErrSessionNotAuthorized,
+		},
+		{
+			Name:        "Expired",
+			Actor:       session,
+			Action:      transfer,
+			State:       sessionState(grant(4, nil, consts.TransferAssetID)),
+			Timestamp:   5,
+			ExpectedErr: ErrSessionExpired,
+		},
+		{
+			Name:        "ActionType",
+			Actor:       session,
+			Action:      transfer,
+			State:       sessionState(grant(5, nil, consts.MintAssetID)),
+			Timestamp:   5,
+			ExpectedErr: ErrSessionScope,
+		},
+		{
+			Name:            "InScope",
+			Actor:           session,
+			Action:          transfer,
+			State:           sessionState(grant(5, nil, consts.TransferAssetID)),
+			Timestamp:       5,
+			ExpectedOutputs: &TransferAssetResult{SenderBalance: 9, ReceiverBalance: 1},
+		},
+		{
+			Name:        "Region",
+			Actor:       session,
+			Action:      create,
+			State:       sessionState(grant(5, []string{"eu-west"}, consts.CreateRegionID)),
+			Timestamp:   5,
+			ExpectedErr: ErrSessionScope,
+		},
+		{
+			Name:        "InRegion",
+			Actor:       session,
+			Action:      create,
+			State:       sessionState(grant(5, []string{"us-east"}, consts.CreateRegionID)),
+			Timestamp:   5,
+			ExpectedErr: ErrTooFewTEEs,
+		},
+		{
+			// Other actors are not checked
+			Name:        "Account",
+			Actor:       account,
+			Action:      &TransferAssetAction{AssetID: assetID, To: session, Value: 0},
+			State:       sessionState(nil),
+			Timestamp:   5,
+			ExpectedErr: ErrZeroAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.Run(context.Background(), t)
+	}
+}
@@ -0,0 +1,417 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrWrongEpoch            = errors.New("settlement out of order")
+	ErrSettlementPending     = errors.New("settlement already posted for epoch")
+	ErrSettlementNotFound    = errors.New("settlement not found")
+	ErrSettlementDisputed    = errors.New("settlement disputed")
+	ErrSettlementFinal       = errors.New("settlement already final")
+	ErrChallengeWindowOpen   = errors.New("challenge window still open")
+	ErrChallengeWindowClosed = errors.New("challenge window closed")
+	ErrNoDivergence          = errors.New("challenge root matches settlement")
+
+	_ chain.Action = (*SettleRegionAction)(nil)
+	_ chain.Action = (*ChallengeSettlementAction)(nil)
+	_ chain.Action = (*FinalizeSettlementAction)(nil)
+)
+
+// settlementDomain separates settlement signatures from execution result
+// signatures made with the same enclave key.
+const settlementDomain = "shuttlevm/settlement"
+
+// SettlementDigest is what an enclave signs to settle [stateRoot] as the
+// root of [regionID] after [epoch], starting from [prevRoot].
+func SettlementDigest(regionID string, epoch uint64, prevRoot, stateRoot ids.ID) []byte {
+	h := sha256.New()
+	h.Write([]byte(settlementDomain))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(regionID))))
+	h.Write([]byte(regionID))
+	h.Write(binary.BigEndian.AppendUint64(nil, epoch))
+	h.Write(prevRoot[:])
+	h.Write(stateRoot[:])
+	return h.Sum(nil)
+}
+
+// SettleRegionAction posts the state root a lane region reached at the end
+// of [Epoch]. Lane regions execute off the main chain and only settle roots
+// here. The root becomes the region's attested root once it survives the
+// challenge window; see [FinalizeSettlementAction].
+type SettleRegionAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	Epoch     uint64 `serialize:"true" json:"epoch"`
+	PrevRoot  ids.ID `serialize:"true" json:"prev_root"`
+	StateRoot ids.ID `serialize:"true" json:"state_root"`
+	// Attestation signs [SettlementDigest]
+	Attestation attestation.Attestation `serialize:"true" json:"attestation"`
+}
+
+func (*SettleRegionAction) GetTypeID() uint8 {
+	return consts.SettleRegionID
+}
+
+func (s *SettleRegionAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.HeightKey()):                                           state.Read,
+		string(storage.ParamKey(uint8(consts.ParamSettlementWindow))):         state.Read,
+		string(storage.RegionKey(s.RegionID)):                                 state.Read,
+		string(storage.EnclaveKey(s.RegionID, s.Attestation.EnclaveID)):       state.Read,
+		string(storage.EnclavePubKeyKey(s.RegionID, s.Attestation.EnclaveID)): state.Read,
+		string(storage.EnclaveExpiryKey(s.RegionID, s.Attestation.EnclaveID)): state.Read,
+		string(storage.RegionRootKey(s.RegionID)):                             state.Read,
+		string(storage.SettlementHeadKey(s.RegionID)):                         state.Read,
+		string(storage.SettlementKey(s.RegionID, s.Epoch)):                    state.All,
+		string(storage.SettlementOpenKey(s.RegionID)):                         state.All,
+	}
+	addPlatformKeys(keys, s.RegionID, s.Attestation.EnclaveID)
+	return addGateKeys(keys, actor, s)
+}
+
+func (s *SettleRegionAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SettleRegionID, s.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	if err := verifySettlementSigner(ctx, mu, timestamp, s.RegionID, SettlementDigest(s.RegionID, s.Epoch, s.PrevRoot, s.StateRoot), &s.Attestation); err != nil {
+		return nil, err
+	}
+	head, err := storage.GetSettlementHead(ctx, mu, s.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Epoch != head {
+		return nil, fmt.Errorf("%w: (expected=%d, got=%d)", ErrWrongEpoch, head, s.Epoch)
+	}
+	prev, err := storage.GetSettlement(ctx, mu, s.RegionID, s.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		return nil, ErrSettlementPending
+	}
+	root, err := storage.GetRegionRoot(ctx, mu, s.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if s.PrevRoot != root {
+		return nil, fmt.Errorf("%w: (attested=%s, claimed=%s)", ErrStateRootMismatch, root, s.PrevRoot)
+	}
+	window, err := Uint64Param(ctx, mu, consts.ParamSettlementWindow, consts.SettlementWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := &storage.Settlement{
+		PrevRoot:  s.PrevRoot,
+		StateRoot: s.StateRoot,
+		EnclaveID: s.Attestation.EnclaveID,
+		Submitter: actor,
+		Status:    storage.SettlementPending,
+		Deadline:  uint64(timestamp/1000) + window,
+	}
+	if err := storage.SetSettlement(ctx, mu, s.RegionID, s.Epoch, settlement); err != nil {
+		return nil, err
+	}
+	// The enclave may not leave the region before the settlement closes
+	if err := storage.SetOpenSettlement(ctx, mu, s.RegionID, s.Attestation.EnclaveID); err != nil {
+		return nil, err
+	}
+	return &SettleRegionResult{
+		RegionID: s.RegionID,
+		Epoch:    s.Epoch,
+		Deadline: settlement.Deadline,
+	}, nil
+}
+
+func (s *SettleRegionAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.TEEUnits + schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(s).QuoteBytes)
+}
+
+func (*SettleRegionAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type SettleRegionResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Epoch    uint64 `serialize:"true" json:"epoch"`
+	// Deadline is the block time, in seconds, until which the settlement
+	// may be challenged.
+	Deadline uint64 `serialize:"true" json:"deadline"`
+}
+
+func (*SettleRegionResult) GetTypeID() uint8 {
+	return consts.SettleRegionResultID
+}
+
+// ChallengeSettlementAction disputes a pending settlement with a divergent
+// root signed by another enclave of the region for the same epoch and
+// starting root. A disputed settlement never becomes the region's root.
+type ChallengeSettlementAction struct {
+	RegionID  string `serialize:"true" json:"region_id"`
+	Epoch     uint64 `serialize:"true" json:"epoch"`
+	StateRoot ids.ID `serialize:"true" json:"state_root"`
+	// Attestation signs [SettlementDigest]
+	Attestation attestation.Attestation `serialize:"true" json:"attestation"`
+}
+
+func (*ChallengeSettlementAction) GetTypeID() uint8 {
+	return consts.ChallengeSettlementID
+}
+
+func (c *ChallengeSettlementAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := state.Keys{
+		string(storage.RegionKey(c.RegionID)):                                 state.Read,
+		string(storage.EnclaveKey(c.RegionID, c.Attestation.EnclaveID)):       state.Read,
+		string(storage.EnclavePubKeyKey(c.RegionID, c.Attestation.EnclaveID)): state.Read,
+		string(storage.EnclaveExpiryKey(c.RegionID, c.Attestation.EnclaveID)): state.Read,
+		string(storage.SettlementKey(c.RegionID, c.Epoch)):                    state.Read | state.Write,
+	}
+	addPlatformKeys(keys, c.RegionID, c.Attestation.EnclaveID)
+	return addGateKeys(keys, actor, c)
+}
+
+func (c *ChallengeSettlementAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.ChallengeSettlementID, c.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, c); err != nil {
+		return nil, err
+	}
+
+	s, err := storage.GetSettlement(ctx, mu, c.RegionID, c.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrSettlementNotFound
+	}
+	switch s.Status {
+	case storage.SettlementDisputed:
+		return nil, ErrSettlementDisputed
+	case storage.SettlementFinal:
+		return nil, ErrSettlementFinal
+	}
+	if uint64(timestamp/1000) > s.Deadline {
+		return nil, ErrChallengeWindowClosed
+	}
+	if c.StateRoot == s.StateRoot {
+		return nil, ErrNoDivergence
+	}
+	if err := verifySettlementSigner(ctx, mu, timestamp, c.RegionID, SettlementDigest(c.RegionID, c.Epoch, s.PrevRoot, c.StateRoot), &c.Attestation); err != nil {
+		return nil, err
+	}
+
+	s.Status = storage.SettlementDisputed
+	s.Challenger = actor
+	if err := storage.SetSettlement(ctx, mu, c.RegionID, c.Epoch, s); err != nil {
+		return nil, err
+	}
+	return &ChallengeSettlementResult{
+		RegionID:      c.RegionID,
+		Epoch:         c.Epoch,
+		SettledRoot:   s.StateRoot,
+		DivergentRoot: c.StateRoot,
+	}, nil
+}
+
+func (c *ChallengeSettlementAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.TEEUnits + schedule.StateUpdateUnits +
+		schedule.QuoteUnits(LoadOf(c).QuoteBytes)
+}
+
+func (*ChallengeSettlementAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type ChallengeSettlementResult struct {
+	RegionID      string `serialize:"true" json:"region_id"`
+	Epoch         uint64 `serialize:"true" json:"epoch"`
+	SettledRoot   ids.ID `serialize:"true" json:"settled_root"`
+	DivergentRoot ids.ID `serialize:"true" json:"divergent_root"`
+}
+
+func (*ChallengeSettlementResult) GetTypeID() uint8 {
+	return consts.ChallengeSettlementResultID
+}
+
+// FinalizeSettlementAction closes the settlement of [Epoch]. An undisputed
+// settlement past its deadline becomes the region's attested root and the
+// region moves on to the next epoch. A disputed settlement, or one whose
+// starting root is no longer the region's root, is dropped so the epoch
+// can be settled again. Anyone may finalize.
+type FinalizeSettlementAction struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Epoch    uint64 `serialize:"true" json:"epoch"`
+}
+
+func (*FinalizeSettlementAction) GetTypeID() uint8 {
+	return consts.FinalizeSettlementID
+}
+
+func (f *FinalizeSettlementAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.RegionRootKey(f.RegionID)):          state.All,
+		string(storage.SettlementHeadKey(f.RegionID)):      state.All,
+		string(storage.SettlementKey(f.RegionID, f.Epoch)): state.Read | state.Write,
+		string(storage.SettlementOpenKey(f.RegionID)):      state.All,
+	}, actor, f)
+}
+
+func (f *FinalizeSettlementAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.FinalizeSettlementID, f.RegionID, "")
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, f); err != nil {
+		return nil, err
+	}
+
+	s, err := storage.GetSettlement(ctx, mu, f.RegionID, f.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrSettlementNotFound
+	}
+	if s.Status == storage.SettlementFinal {
+		return nil, ErrSettlementFinal
+	}
+	root, err := storage.GetRegionRoot(ctx, mu, f.RegionID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status == storage.SettlementPending && s.PrevRoot == root {
+		if uint64(timestamp/1000) <= s.Deadline {
+			return nil, ErrChallengeWindowOpen
+		}
+		if err := storage.DeleteOpenSettlement(ctx, mu, f.RegionID); err != nil {
+			return nil, err
+		}
+		s.Status = storage.SettlementFinal
+		if err := storage.SetSettlement(ctx, mu, f.RegionID, f.Epoch, s); err != nil {
+			return nil, err
+		}
+		if err := storage.SetRegionRoot(ctx, mu, f.RegionID, s.StateRoot); err != nil {
+			return nil, err
+		}
+		if err := storage.SetSettlementHead(ctx, mu, f.RegionID, f.Epoch+1); err != nil {
+			return nil, err
+		}
+		return &FinalizeSettlementResult{
+			RegionID:  f.RegionID,
+			Epoch:     f.Epoch,
+			Finalized: true,
+			StateRoot: s.StateRoot,
+		}, nil
+	}
+
+	// Disputed or stale: drop it and leave the region's root unchanged
+	if err := storage.DeleteOpenSettlement(ctx, mu, f.RegionID); err != nil {
+		return nil, err
+	}
+	if err := storage.DeleteSettlement(ctx, mu, f.RegionID, f.Epoch); err != nil {
+		return nil, err
+	}
+	return &FinalizeSettlementResult{
+		RegionID:  f.RegionID,
+		Epoch:     f.Epoch,
+		StateRoot: root,
+	}, nil
+}
+
+func (*FinalizeSettlementAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 3*schedule.StateUpdateUnits
+}
+
+func (*FinalizeSettlementAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type FinalizeSettlementResult struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Epoch    uint64 `serialize:"true" json:"epoch"`
+	// Finalized is unset if the settlement was dropped
+	Finalized bool `serialize:"true" json:"finalized"`
+	// StateRoot is the region's attested root after finalization
+	StateRoot ids.ID `serialize:"true" json:"state_root"`
+}
+
+func (*FinalizeSettlementResult) GetTypeID() uint8 {
+	return consts.FinalizeSettlementResultID
+}
+
+// verifySettlementSigner checks that [a] is from an enclave of [regionID]
+// active at [timestamp] and signs [digest].
+func verifySettlementSigner(
+	ctx context.Context,
+	im state.Immutable,
+	timestamp int64,
+	regionID string,
+	digest []byte,
+	a *attestation.Attestation,
+) error {
+	_, exists, err := storage.GetRegion(ctx, im, regionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrInvalidRegion
+	}
+	pubKey, err := activeEnclave(ctx, im, regionID, a.EnclaveID, timestamp)
+	if err != nil {
+		return err
+	}
+	if err := checkPlatform(ctx, im, regionID, a.EnclaveID, a.EnclaveType); err != nil {
+		return err
+	}
+	if err := a.Verify(digest, pubKey); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return nil
+}
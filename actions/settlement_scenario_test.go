@@ -0,0 +1,265 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+// regionRoot asserts the settled root of [testvm.Region] is [expected]
+func regionRoot(v *testvm.VM, expected ids.ID) func(context.Context, *testing.T, codec.Typed) {
+	return func(ctx context.Context, t *testing.T, _ codec.Typed) {
+		root, err := storage.GetRegionRoot(ctx, v.State, testvm.Region)
+		require.NoError(t, err)
+		require.Equal(t, expected, root)
+	}
+}
+
+func TestRegionSettlement(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	finalize := &actions.FinalizeSettlementAction{RegionID: testvm.Region, Epoch: 0}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "WrongEpoch",
+			Actor:       f.Actor,
+			Action:      f.SGX.Settle(testvm.Region, 1, ids.Empty, ids.ID{1}),
+			ExpectedErr: actions.ErrWrongEpoch,
+		},
+		{
+			Name:   "Settle",
+			Actor:  f.Actor,
+			Action: f.SGX.Settle(testvm.Region, 0, ids.Empty, ids.ID{1}),
+		},
+		{
+			// The settlement only takes effect after the challenge window
+			Name:        "ChallengeWindowOpen",
+			Actor:       f.Actor,
+			Action:      finalize,
+			ExpectedErr: actions.ErrChallengeWindowOpen,
+		},
+	})
+
+	require.NoError(t, f.Advance(ctx, 1, 25*time.Hour))
+	divergent := f.SEV.Settle(testvm.Region, 1, ids.ID{1}, ids.ID{3})
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:            "Finalize",
+			Actor:           f.Actor,
+			Action:          finalize,
+			ExpectedOutputs: &actions.FinalizeSettlementResult{RegionID: testvm.Region, Epoch: 0, Finalized: true, StateRoot: ids.ID{1}},
+			Assertion:       regionRoot(f.VM, ids.ID{1}),
+		},
+		{
+			Name:   "SettleNext",
+			Actor:  f.Actor,
+			Action: f.SGX.Settle(testvm.Region, 1, ids.ID{1}, ids.ID{2}),
+		},
+		{
+			// A divergent root from the other enclave disputes the epoch
+			Name:  "Challenge",
+			Actor: f.Actor,
+			Action: &actions.ChallengeSettlementAction{
+				RegionID:    testvm.Region,
+				Epoch:       1,
+				StateRoot:   divergent.StateRoot,
+				Attestation: divergent.Attestation,
+			},
+		},
+		{
+			Name:   "FinalizeDisputed",
+			Actor:  f.Actor,
+			Action: &actions.FinalizeSettlementAction{RegionID: testvm.Region, Epoch: 1},
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require.False(t, out.(*actions.FinalizeSettlementResult).Finalized)
+				regionRoot(f.VM, ids.ID{1})(ctx, t, out)
+			},
+		},
+		{
+			// The epoch can be settled again
+			Name:   "Resettle",
+			Actor:  f.Actor,
+			Action: f.SGX.Settle(testvm.Region, 1, ids.ID{1}, ids.ID{2}),
+		},
+	})
+}
+
+func TestRemoveTEEWithOpenSettlement(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	v := testvm.New()
+	admin := codectest.NewRandomAddress()
+
+	sgx, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(err)
+	sev, err := testvm.NewEnclave(testvm.EnclaveSEV)
+	require.NoError(err)
+	spare, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(err)
+	require.NoError(v.RegisterRegion(ctx, testvm.Region, sgx, sev, spare))
+	update := func(add, rem *testvm.Enclave) *actions.UpdateRegionAction {
+		action := &actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: testvm.Region}
+		if add != nil {
+			action.AddTEEs = []codec.Address{add.Address}
+		}
+		if rem != nil {
+			action.RemTEEs = []codec.Address{rem.Address}
+		}
+		return action
+	}
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Settle",
+			Actor:  admin,
+			Action: spare.Settle(testvm.Region, 0, ids.Empty, ids.ID{1}),
+		},
+		{
+			// The TEE that posted the open settlement stays until it closes
+			Name:        "RemoveBusy",
+			Actor:       admin,
+			Action:      update(nil, spare),
+			ExpectedErr: actions.ErrTEEBusy,
+		},
+		{
+			Name:   "RemoveOther",
+			Actor:  admin,
+			Action: update(nil, sev),
+		},
+	})
+
+	require.NoError(v.Advance(ctx, 1, 25*time.Hour))
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Finalize",
+			Actor:  admin,
+			Action: &actions.FinalizeSettlementAction{RegionID: testvm.Region, Epoch: 0},
+		},
+		{
+			Name:   "AddBack",
+			Actor:  admin,
+			Action: update(sev, nil),
+		},
+		{
+			Name:   "Remove",
+			Actor:  admin,
+			Action: update(nil, spare),
+		},
+	})
+}
+
+func TestCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	keys := make([]*bls.PrivateKey, 3)
+	committee := &actions.CheckpointCommittee{Quorum: 2, Interval: 10}
+	for i := range keys {
+		key, err := bls.GeneratePrivateKey()
+		require.NoError(t, err)
+		keys[i] = key
+		committee.Validators = append(committee.Validators, actions.CheckpointValidator{
+			PublicKey: bls.PublicKeyToBytes(bls.PublicFromPrivateKey(key)),
+			Weight:    1,
+		})
+	}
+	sign := func(height uint64, root ids.ID, members ...int) *actions.SubmitCheckpointAction {
+		digest := actions.CheckpointDigest(testvm.Region, height, root)
+		signers := make([]byte, 1)
+		var sigs [][]byte
+		for _, i := range members {
+			signers[0] |= 1 << i
+			sigs = append(sigs, bls.SignatureToBytes(bls.Sign(digest, keys[i])))
+		}
+		signature, err := attestation.Aggregate(sigs...)
+		require.NoError(t, err)
+		return &actions.SubmitCheckpointAction{RegionID: testvm.Region, Height: height, Root: root, Signers: signers, Signature: signature}
+	}
+	require.NoError(t, f.Advance(ctx, 10-f.Height%10, time.Second))
+	height := f.Height
+	root := ids.GenerateTestID()
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "NoCommittee",
+			Actor:       f.Actor,
+			Action:      sign(height, root, 0, 1),
+			ExpectedErr: actions.ErrNoCommittee,
+		},
+	})
+
+	value, err := codec.Marshal(committee)
+	require.NoError(t, err)
+	require.NoError(t, storage.ScheduleParam(ctx, f.State, uint8(consts.ParamCheckpointCommittee), value, f.Height, f.Height))
+	forged := sign(height, root, 0, 1)
+	forged.Root = ids.GenerateTestID()
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// Checkpoints need a quorum of valid signatures
+			Name:        "NoQuorum",
+			Actor:       f.Actor,
+			Action:      sign(height, root, 2),
+			ExpectedErr: actions.ErrCheckpointQuorum,
+		},
+		{
+			Name:        "Forged",
+			Actor:       f.Actor,
+			Action:      forged,
+			ExpectedErr: attestation.ErrAggregateSignature,
+		},
+		{
+			// at heights on the interval that were reached
+			Name:        "OffInterval",
+			Actor:       f.Actor,
+			Action:      sign(height+1, root, 0, 1),
+			ExpectedErr: actions.ErrCheckpointHeight,
+		},
+		{
+			Name:        "Unreached",
+			Actor:       f.Actor,
+			Action:      sign(height+10, root, 0, 1),
+			ExpectedErr: actions.ErrCheckpointHeight,
+		},
+		{
+			Name:   "Submit",
+			Actor:  f.Actor,
+			Action: sign(height, root, 0, 2),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.Equal(uint64(2), out.(*actions.SubmitCheckpointResult).Weight)
+				checkpoint, err := storage.GetCheckpoint(ctx, f.State, testvm.Region, height)
+				require.NoError(err)
+				require.Equal(root, checkpoint.Root)
+				require.Equal(ids.ID(sha256.Sum256(value)), checkpoint.Committee)
+				aggregateKey, err := attestation.AggregatePublicKeys(committee.Validators[0].PublicKey, committee.Validators[2].PublicKey)
+				require.NoError(err)
+				require.Equal(aggregateKey, checkpoint.AggregateKey)
+			},
+		},
+		{
+			// and only move forward
+			Name:        "Resubmit",
+			Actor:       f.Actor,
+			Action:      sign(height, ids.GenerateTestID(), 0, 1),
+			ExpectedErr: actions.ErrCheckpointHeight,
+		},
+	})
+}
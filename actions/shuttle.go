@@ -7,9 +7,15 @@ import (
     "errors"
     "fmt"
 
+    "github.com/ava-labs/avalanchego/database"
+    "github.com/ava-labs/avalanchego/ids"
     "github.com/ava-labs/hypersdk/chain"
     "github.com/ava-labs/hypersdk/codec"
-    "github.com/ava-labs/hypersdk/consts"
+    "github.com/ava-labs/hypersdk/state"
+
+    "github.com/rhombus-tech/vm/consts"
+    "github.com/rhombus-tech/vm/envelope"
+    "github.com/rhombus-tech/vm/storage"
 )
 
 var (
@@ -19,33 +25,59 @@ var (
     ErrInvalidFunction = errors.New("invalid function call")
     ErrCodeTooLarge    = errors.New("code size exceeds maximum")
     ErrStorageTooLarge = errors.New("storage size exceeds maximum")
+    ErrEventQueued     = errors.New("identical event already queued")
     
     MaxCodeSize    = 1024 * 1024    // 1MB
     MaxStorageSize = 1024 * 1024    // 1MB
-)
 
-const (
-    CreateObject uint8 = iota
-    SendEvent
-    SetInputObject
+    _ chain.Action = (*CreateObjectAction)(nil)
+    _ chain.Action = (*SendEventAction)(nil)
+    _ chain.Action = (*SetInputObjectAction)(nil)
 )
 
 type CreateObjectAction struct {
+    Version uint8  `json:"version"`
     ID      string `json:"id"`
     Code    []byte `json:"code"`
     Storage []byte `json:"storage"`
+    // Metadata, when set, lists the object in its region's discovery index
+    Metadata *storage.ObjectMetadata `json:"metadata,omitempty"`
 }
 
-func (*CreateObjectAction) GetTypeID() uint8 { return CreateObject }
+func (*CreateObjectAction) GetTypeID() uint8 { return consts.CreateObjectID }
 
 func (a *CreateObjectAction) Marshal(p *codec.Packer) {
+    if protoWire {
+        packProto(p, a.appendProto(nil))
+        return
+    }
+    version := a.Version
+    packVersion(p, version)
     p.PackString(a.ID)
     p.PackBytes(a.Code)
     p.PackBytes(a.Storage)
+    if version >= consts.ActionVersion12 {
+        p.PackBytes(encodeMetadata(a.Metadata))
+    }
 }
 
 func UnmarshalCreateObject(p *codec.Packer) (chain.Action, error) {
+    if protoWire {
+        m, err := unpackProto(p)
+        if err != nil {
+            return nil, err
+        }
+        return createObjectFromProto(m)
+    }
+
     var act CreateObjectAction
+
+    version, err := unpackVersion(p)
+    if err != nil {
+        return nil, err
+    }
+    act.Version = version
+
     id, err := p.UnpackString()
     if err != nil {
         return nil, err
@@ -63,61 +95,175 @@ func UnmarshalCreateObject(p *codec.Packer) (chain.Action, error) {
         return nil, err
     }
     act.Storage = storage
+
+    if act.Version >= consts.ActionVersion12 {
+        metadata, err := p.UnpackBytes()
+        if err != nil {
+            return nil, err
+        }
+        if act.Metadata, err = decodeMetadata(metadata); err != nil {
+            return nil, err
+        }
+    }
     
     return &act, nil
 }
 
-func (a *CreateObjectAction) Verify(ctx context.Context, vm chain.VM) error {
+func (a *CreateObjectAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+    return addGateKeys(objectKeys(a.ID, a.Metadata), actor, a)
+}
+
+func (a *CreateObjectAction) Execute(
+    ctx context.Context,
+    rules chain.Rules,
+    mu state.Mutable,
+    timestamp int64,
+    actor codec.Address,
+    _ ids.ID,
+) (_ codec.Typed, err error) {
+    defer wrapExecError(&err, consts.CreateObjectID, objectRegion(a.Metadata), a.ID)
+
+    if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+        return nil, err
+    }
+
     if len(a.ID) == 0 || len(a.ID) > 256 {
-        return ErrInvalidID
+        return nil, ErrInvalidID
     }
     if len(a.Code) > MaxCodeSize {
-        return ErrCodeTooLarge
+        return nil, ErrCodeTooLarge
     }
     if len(a.Storage) > MaxStorageSize {
-        return ErrStorageTooLarge
+        return nil, ErrStorageTooLarge
     }
-    if exists, err := objectExists(ctx, vm, a.ID); err != nil {
-        return err
-    } else if exists {
-        return ErrObjectExists
+    obj, err := storage.GetObject(ctx, mu, a.ID)
+    if err != nil {
+        return nil, err
+    }
+    if obj != nil {
+        return nil, ErrObjectExists
+    }
+    if a.Metadata != nil {
+        if err := a.verifyMetadata(ctx, mu); err != nil {
+            return nil, err
+        }
+    }
+    if err := checkObjectContent(ctx, a.ID, objectRegion(a.Metadata), a.Code); err != nil {
+        return nil, err
     }
-    return validateCode(a.Code)
-}
 
-func (a *CreateObjectAction) Execute(ctx context.Context, vm chain.VM) (*CreateObjectResult, error) {
-    key := []byte("object:" + a.ID)
-    obj := map[string][]byte{
+    if err := storage.SetObject(ctx, mu, a.ID, map[string][]byte{
         "code":    a.Code,
         "storage": a.Storage,
-    }
-    objBytes, err := codec.Marshal(obj)
-    if err != nil {
+    }); err != nil {
         return nil, err
     }
-    if err := vm.State().Set(ctx, key, objBytes); err != nil {
+    if err := storage.SetObjectCreator(ctx, mu, a.ID, actor); err != nil {
         return nil, err
     }
-    return &CreateObjectResult{ID: a.ID}, nil
+    if a.Metadata != nil {
+        if err := a.indexMetadata(ctx, mu); err != nil {
+            return nil, err
+        }
+    }
+    return &CreateObjectResult{ID: a.ID, Success: true}, nil
+}
+
+func (a *CreateObjectAction) ActionVersion() uint8 {
+    return a.Version
+}
+
+func (a *CreateObjectAction) ComputeUnits(rules chain.Rules) uint64 {
+    return FeeScheduleOf(rules).StorageUnits(len(a.Code), len(a.Storage)+len(encodeMetadata(a.Metadata)))
+}
+
+func (*CreateObjectAction) ValidRange(chain.Rules) (int64, int64) {
+    // Returning -1, -1 means that the action is always valid.
+    return -1, -1
 }
 
 type SendEventAction struct {
+    Version      uint8  `json:"version"`
     IDTo         string `json:"id_to"`
     FunctionCall string `json:"function_call"`
     Parameters   []byte `json:"parameters"`
+    // CallbackObject and CallbackFunction, when set, receive the hash of
+    // the event's execution result once a TEEExecAction completes it
+    CallbackObject   string `json:"callback_object,omitempty"`
+    CallbackFunction string `json:"callback_function,omitempty"`
+    // Sender and Nonce, when Nonce is set, order the events Sender sends to
+    // IDTo: Nonce must be one more than the last nonce Sender used for it.
+    // Sender is bound to the actor (see [EventSender]); empty means the
+    // actor.
+    Sender codec.Address `json:"sender"`
+    Nonce  uint64        `json:"nonce,omitempty"`
+    // Tip, in compute units on top of the event's own, buys a priority
+    // class (see [EventLanes])
+    Tip uint64 `json:"tip,omitempty"`
+    // Encrypted marks Parameters as an envelope sealed to the encryption
+    // keys of the region's enclaves, with [EventAAD] as additional data.
+    // Only its format is checked on chain; the enclave opens it.
+    Encrypted bool `json:"encrypted,omitempty"`
+    // ToName, when set, names the target IDTo by a registered name: the
+    // event fails unless the name refers to IDTo when it executes
+    ToName string `json:"to_name,omitempty"`
+    // IdempotencyKey, when set, makes retries of the event harmless: for
+    // [consts.IdempotencyWindow], another event from Sender to the same
+    // object with the same key is rejected instead of queued again
+    IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
-func (*SendEventAction) GetTypeID() uint8 { return SendEvent }
+func (*SendEventAction) GetTypeID() uint8 { return consts.SendEventID }
 
 func (a *SendEventAction) Marshal(p *codec.Packer) {
+    if protoWire {
+        packProto(p, a.appendProto(nil))
+        return
+    }
+    version := a.Version
+    packVersion(p, version)
     p.PackString(a.IDTo)
     p.PackString(a.FunctionCall)
     p.PackBytes(a.Parameters)
+    if version >= consts.ActionVersion5 {
+        p.PackString(a.CallbackObject)
+        p.PackString(a.CallbackFunction)
+    }
+    if version >= consts.ActionVersion7 {
+        p.PackAddress(a.Sender)
+        p.PackUint64(a.Nonce)
+    }
+    if version >= consts.ActionVersion8 {
+        p.PackUint64(a.Tip)
+    }
+    if version >= consts.ActionVersion9 {
+        p.PackBool(a.Encrypted)
+    }
+    if version >= consts.ActionVersion11 {
+        p.PackString(a.ToName)
+    }
+    if version >= consts.ActionVersion15 {
+        p.PackString(a.IdempotencyKey)
+    }
 }
 
 func UnmarshalSendEvent(p *codec.Packer) (chain.Action, error) {
+    if protoWire {
+        m, err := unpackProto(p)
+        if err != nil {
+            return nil, err
+        }
+        return sendEventFromProto(m)
+    }
+
     var act SendEventAction
-    
+
+    version, err := unpackVersion(p)
+    if err != nil {
+        return nil, err
+    }
+    act.Version = version
+
     idTo, err := p.UnpackString()
     if err != nil {
         return nil, err
@@ -135,14 +281,181 @@ func UnmarshalSendEvent(p *codec.Packer) (chain.Action, error) {
         return nil, err
     }
     act.Parameters = parameters
+
+    if act.Version >= consts.ActionVersion5 {
+        if act.CallbackObject, err = p.UnpackString(); err != nil {
+            return nil, err
+        }
+        if act.CallbackFunction, err = p.UnpackString(); err != nil {
+            return nil, err
+        }
+    }
+    if act.Version >= consts.ActionVersion7 {
+        if act.Sender, err = p.UnpackAddress(); err != nil {
+            return nil, err
+        }
+        if act.Nonce, err = p.UnpackUint64(); err != nil {
+            return nil, err
+        }
+    }
+    if act.Version >= consts.ActionVersion8 {
+        if act.Tip, err = p.UnpackUint64(); err != nil {
+            return nil, err
+        }
+    }
+    if act.Version >= consts.ActionVersion9 {
+        if act.Encrypted, err = p.UnpackBool(); err != nil {
+            return nil, err
+        }
+    }
+    if act.Version >= consts.ActionVersion11 {
+        if act.ToName, err = p.UnpackString(); err != nil {
+            return nil, err
+        }
+    }
+    if act.Version >= consts.ActionVersion15 {
+        if act.IdempotencyKey, err = p.UnpackString(); err != nil {
+            return nil, err
+        }
+    }
     
     return &act, nil
 }
 
-func (a *SendEventAction) Verify(ctx context.Context, vm chain.VM) error {
-    if exists, err := objectExists(ctx, vm, a.IDTo); err != nil {
+func (a *SendEventAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+    return addGateKeys(a.eventKeys(actor), actor, a)
+}
+
+// eventKeys returns the keys sending the event as [actor] touches.
+func (a *SendEventAction) eventKeys(actor codec.Address) state.Keys {
+    // The sender is bound when the event executes; one named is checked
+    // against the actor then
+    bound := *a
+    if bound.Sender == codec.EmptyAddress {
+        bound.Sender = actor
+    }
+    eventID := bound.EventID()
+    queueKey := storage.EventQueueKey(eventID, a.IDTo)
+    keys := state.Keys{
+        string(storage.ObjectKey(a.IDTo)):                      state.Read,
+        string(storage.ObjectMetadataKey(a.IDTo)):              state.Read,
+        string(storage.ParamSchemaKey(a.IDTo, a.FunctionCall)): state.Read,
+        string(queueKey):                                       state.All,
+        string(storage.EventChargeKey(queueKey)):               state.All,
+        string(storage.EventChainHeadKey(a.IDTo)):              state.All,
+        string(storage.EventLinkKey(a.IDTo, eventID)):          state.All,
+    }
+    if a.ToName != "" {
+        keys[string(storage.NameKey(a.ToName))] = state.Read
+    }
+    if a.Nonce != 0 {
+        keys[string(storage.EventNonceKey(a.IDTo, bound.Sender))] = state.All
+    }
+    if a.IdempotencyKey != "" {
+        keys[string(storage.IdempotencyKey(a.IDTo, bound.Sender, a.IdempotencyKey))] = state.All
+    }
+    if a.CallbackObject != "" || a.CallbackFunction != "" {
+        keys[string(storage.ObjectKey(a.CallbackObject))] = state.Read
+        keys[string(storage.CallbackKey(eventID))] = state.All
+    }
+    return keys
+}
+
+func (a *SendEventAction) Execute(
+    ctx context.Context,
+    rules chain.Rules,
+    mu state.Mutable,
+    timestamp int64,
+    actor codec.Address,
+    _ ids.ID,
+) (_ codec.Typed, err error) {
+    defer wrapExecError(&err, consts.SendEventID, "", a.IDTo)
+
+    if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+        return nil, err
+    }
+    return a.send(ctx, rules, mu, timestamp, actor)
+}
+
+// send queues the event [actor] sends at block time [timestamp], charged
+// under the governed fee schedule of [rules].
+func (a *SendEventAction) send(ctx context.Context, rules chain.Rules, mu state.Mutable, timestamp int64, actor codec.Address) (*SendEventResult, error) {
+    a, err := a.bindSender(ctx, mu, actor)
+    if err != nil {
+        return nil, err
+    }
+    governed, err := GovernedRules(ctx, rules, mu)
+    if err != nil {
+        return nil, err
+    }
+    if err := a.verify(ctx, mu, timestamp); err != nil {
+        return nil, err
+    }
+
+    eventID := a.EventID()
+    now := uint64(timestamp / 1000)
+    if a.IdempotencyKey != "" {
+        if err := a.claimIdempotencyKey(ctx, mu, eventID, now); err != nil {
+            return nil, err
+        }
+    }
+
+    queueKey := storage.EventQueueKey(eventID, a.IDTo)
+    event, err := codec.Marshal(map[string]interface{}{
+        "function_call": a.FunctionCall,
+        "parameters":    a.Parameters,
+        "encrypted":     a.Encrypted,
+        "priority":      a.Priority(),
+        "queued_at":     now,
+    })
+    if err != nil {
+        return nil, err
+    }
+    if err := mu.Insert(ctx, queueKey, event); err != nil {
+        return nil, err
+    }
+    // Kept so region admins can refund the event if they cancel it
+    if err := storage.SetEventCharge(ctx, mu, queueKey, &storage.EventCharge{
+        Sender: a.Sender,
+        Units:  FeeScheduleOf(governed).EventUnits + a.Tip,
+        Tip:    a.Tip,
+    }); err != nil {
+        return nil, err
+    }
+    // Auditors prove the order of events to an object from its chain
+    if err := appendEventLink(ctx, mu, a.IDTo, eventID, now); err != nil {
+        return nil, err
+    }
+    if a.Nonce != 0 {
+        if err := storage.SetEventNonce(ctx, mu, a.IDTo, a.Sender, a.Nonce); err != nil {
+            return nil, err
+        }
+    }
+    if a.CallbackObject != "" {
+        if err := storage.SetCallback(ctx, mu, eventID, &storage.Callback{
+            Object:   a.CallbackObject,
+            Function: a.CallbackFunction,
+        }); err != nil {
+            return nil, err
+        }
+    }
+    return &SendEventResult{Success: true, IDTo: a.IDTo, EventID: eventID}, nil
+}
+
+// verify checks the event, whose sender is bound, can be queued at block
+// time [timestamp].
+func (a *SendEventAction) verify(ctx context.Context, im state.Immutable, timestamp int64) error {
+    if len(a.IDTo) == 0 || len(a.IDTo) > 256 {
+        return ErrInvalidID
+    }
+    if err := a.checkTarget(ctx, im, timestamp); err != nil {
         return err
-    } else if !exists {
+    }
+    obj, err := storage.GetObject(ctx, im, a.IDTo)
+    if err != nil {
+        return err
+    }
+    if obj == nil {
         return ErrObjectNotFound
     }
     if len(a.FunctionCall) == 0 || len(a.FunctionCall) > 256 {
@@ -151,48 +464,118 @@ func (a *SendEventAction) Verify(ctx context.Context, vm chain.VM) error {
     if len(a.Parameters) > MaxStorageSize {
         return ErrStorageTooLarge
     }
-    return validateFunctionExists(ctx, vm, a.IDTo, a.FunctionCall)
+    if a.Tip > consts.MaxEventTip {
+        return ErrTipTooLarge
+    }
+    if a.CallbackObject != "" || a.CallbackFunction != "" {
+        if err := a.verifyCallback(ctx, im); err != nil {
+            return err
+        }
+    }
+    if a.Nonce != 0 {
+        if err := a.verifyNonce(ctx, im); err != nil {
+            return err
+        }
+    }
+    if a.IdempotencyKey != "" {
+        if err := a.checkIdempotencyKey(ctx, im, uint64(timestamp/1000)); err != nil {
+            return err
+        }
+    }
+    if err := a.verifyContent(ctx, im); err != nil {
+        return err
+    }
+    if _, err := im.GetValue(ctx, storage.EventQueueKey(a.EventID(), a.IDTo)); err == nil {
+        return ErrEventQueued
+    } else if !errors.Is(err, database.ErrNotFound) {
+        return err
+    }
+    if a.Encrypted {
+        // Sealed parameters can only be checked against the schema inside
+        // the enclave
+        if err := envelope.Check(a.Parameters); err != nil {
+            return fmt.Errorf("%w: %w", ErrSealedParams, err)
+        }
+        return nil
+    }
+    // Reject malformed payloads before anyone pays for their execution
+    params, ok, err := storage.GetParamSchema(ctx, im, a.IDTo, a.FunctionCall)
+    if err != nil || !ok {
+        return err
+    }
+    return ValidateParams(params, a.Parameters)
 }
 
-func (a *SendEventAction) Execute(ctx context.Context, vm chain.VM) (*SendEventResult, error) {
-    key := []byte("object:" + a.IDTo)
-    objBytes, err := vm.State().Get(ctx, key)
+// verifyCallback checks the callback names an existing object and function
+// and that no earlier identical event still awaits its result.
+func (a *SendEventAction) verifyCallback(ctx context.Context, im state.Immutable) error {
+    obj, err := storage.GetObject(ctx, im, a.CallbackObject)
     if err != nil {
-        return nil, err
+        return err
     }
-    if objBytes == nil {
-        return nil, ErrObjectNotFound
+    if obj == nil {
+        return ErrObjectNotFound
     }
-    
-    event := map[string]interface{}{
-        "function_call": a.FunctionCall,
-        "parameters":    a.Parameters,
+    if len(a.CallbackFunction) == 0 || len(a.CallbackFunction) > 256 {
+        return ErrInvalidFunction
     }
-    eventBytes, err := codec.Marshal(event)
+    pending, err := storage.GetCallback(ctx, im, a.EventID())
     if err != nil {
-        return nil, err
+        return err
     }
-    
-    queueKey := []byte(fmt.Sprintf("event:%s:%s", roughtime.Now(), a.IDTo))
-    if err := vm.State().Set(ctx, queueKey, eventBytes); err != nil {
-        return nil, err
+    if pending != nil {
+        return ErrCallbackPending
     }
-    
-    return &SendEventResult{Success: true, IDTo: a.IDTo}, nil
+    return nil
+}
+
+func (a *SendEventAction) ActionVersion() uint8 {
+    return a.Version
+}
+
+func (a *SendEventAction) ComputeUnits(rules chain.Rules) uint64 {
+    schedule := FeeScheduleOf(rules)
+    return schedule.StorageUnits(0, len(a.Parameters)+len(a.IdempotencyKey)) + schedule.EventUnits + a.Tip
+}
+
+func (*SendEventAction) ValidRange(chain.Rules) (int64, int64) {
+    // Returning -1, -1 means that the action is always valid.
+    return -1, -1
 }
 
 type SetInputObjectAction struct {
-    ID string `json:"id"`
+    Version uint8  `json:"version"`
+    ID      string `json:"id"`
 }
 
-func (*SetInputObjectAction) GetTypeID() uint8 { return SetInputObject }
+func (*SetInputObjectAction) GetTypeID() uint8 { return consts.SetInputObjectID }
 
 func (a *SetInputObjectAction) Marshal(p *codec.Packer) {
+    if protoWire {
+        packProto(p, a.appendProto(nil))
+        return
+    }
+    packVersion(p, a.Version)
     p.PackString(a.ID)
 }
 
 func UnmarshalSetInputObject(p *codec.Packer) (chain.Action, error) {
+    if protoWire {
+        m, err := unpackProto(p)
+        if err != nil {
+            return nil, err
+        }
+        return setInputObjectFromProto(m)
+    }
+
     var act SetInputObjectAction
+
+    version, err := unpackVersion(p)
+    if err != nil {
+        return nil, err
+    }
+    act.Version = version
+
     id, err := p.UnpackString()
     if err != nil {
         return nil, err
@@ -201,35 +584,79 @@ func UnmarshalSetInputObject(p *codec.Packer) (chain.Action, error) {
     return &act, nil
 }
 
-func (a *SetInputObjectAction) Verify(ctx context.Context, vm chain.VM) error {
-    if len(a.ID) == 0 || len(a.ID) > 256 {
-        return ErrInvalidID
+func (a *SetInputObjectAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+    return addGateKeys(a.inputKeys(), actor, a)
+}
+
+// inputKeys returns the keys making the object the input object touches.
+func (a *SetInputObjectAction) inputKeys() state.Keys {
+    return state.Keys{
+        string(storage.ObjectKey(a.ID)):  state.Read,
+        string(storage.InputObjectKey()): state.All,
     }
-    if exists, err := objectExists(ctx, vm, a.ID); err != nil {
-        return err
-    } else if !exists {
-        return ErrObjectNotFound
+}
+
+func (a *SetInputObjectAction) Execute(
+    ctx context.Context,
+    rules chain.Rules,
+    mu state.Mutable,
+    timestamp int64,
+    actor codec.Address,
+    _ ids.ID,
+) (_ codec.Typed, err error) {
+    defer wrapExecError(&err, consts.SetInputObjectID, "", a.ID)
+
+    if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+        return nil, err
     }
-    return nil
+    return a.set(ctx, mu)
 }
 
-func (a *SetInputObjectAction) Execute(ctx context.Context, vm chain.VM) (*SetInputObjectResult, error) {
-    key := []byte("input_object")
-    if err := vm.State().Set(ctx, key, []byte(a.ID)); err != nil {
+// set makes the object the input object.
+func (a *SetInputObjectAction) set(ctx context.Context, mu state.Mutable) (*SetInputObjectResult, error) {
+    if len(a.ID) == 0 || len(a.ID) > 256 {
+        return nil, ErrInvalidID
+    }
+    obj, err := storage.GetObject(ctx, mu, a.ID)
+    if err != nil {
+        return nil, err
+    }
+    if obj == nil {
+        return nil, ErrObjectNotFound
+    }
+    if err := storage.SetInputObject(ctx, mu, a.ID); err != nil {
         return nil, err
     }
     return &SetInputObjectResult{ID: a.ID, Success: true}, nil
 }
 
+func (a *SetInputObjectAction) ActionVersion() uint8 {
+    return a.Version
+}
+
+func (a *SetInputObjectAction) ComputeUnits(rules chain.Rules) uint64 {
+    return FeeScheduleOf(rules).StorageUnits(0, len(a.ID))
+}
+
+func (*SetInputObjectAction) ValidRange(chain.Rules) (int64, int64) {
+    // Returning -1, -1 means that the action is always valid.
+    return -1, -1
+}
+
 // Result types
 type CreateObjectResult struct {
-    ID string `json:"id"`
+    ID        string           `json:"id"`
+    Success   bool             `json:"success"`
+    ErrorCode consts.ErrorCode `json:"error_code"`
+    Message   string           `json:"message"`
 }
 
-func (*CreateObjectResult) GetTypeID() uint8 { return CreateObject }
+func (*CreateObjectResult) GetTypeID() uint8 { return consts.CreateObjectResultID }
 
 func (r *CreateObjectResult) Marshal(p *codec.Packer) {
     p.PackString(r.ID)
+    p.PackBool(r.Success)
+    packErrorCode(p, r.ErrorCode, r.Message)
 }
 
 func UnmarshalCreateObjectResult(p *codec.Packer) (codec.Typed, error) {
@@ -239,19 +666,36 @@ func UnmarshalCreateObjectResult(p *codec.Packer) (codec.Typed, error) {
         return nil, err
     }
     res.ID = id
+
+    success, err := p.UnpackBool()
+    if err != nil {
+        return nil, err
+    }
+    res.Success = success
+
+    res.ErrorCode, res.Message, err = unpackErrorCode(p)
+    if err != nil {
+        return nil, err
+    }
     return &res, nil
 }
 
 type SendEventResult struct {
-    Success bool   `json:"success"`
-    IDTo    string `json:"id_to"`
+    Success   bool             `json:"success"`
+    IDTo      string           `json:"id_to"`
+    ErrorCode consts.ErrorCode `json:"error_code"`
+    Message   string           `json:"message"`
+    // EventID is the ID a TEEExecAction completing the event carries
+    EventID ids.ID `json:"event_id"`
 }
 
-func (*SendEventResult) GetTypeID() uint8 { return SendEvent }
+func (*SendEventResult) GetTypeID() uint8 { return consts.SendEventResultID }
 
 func (r *SendEventResult) Marshal(p *codec.Packer) {
     p.PackBool(r.Success)
     p.PackString(r.IDTo)
+    packErrorCode(p, r.ErrorCode, r.Message)
+    p.PackID(r.EventID)
 }
 
 func UnmarshalSendEventResult(p *codec.Packer) (codec.Typed, error) {
@@ -267,19 +711,32 @@ func UnmarshalSendEventResult(p *codec.Packer) (codec.Typed, error) {
         return nil, err
     }
     res.IDTo = idTo
+
+    res.ErrorCode, res.Message, err = unpackErrorCode(p)
+    if err != nil {
+        return nil, err
+    }
+
+    p.UnpackID(false, &res.EventID)
+    if err := p.Err(); err != nil {
+        return nil, err
+    }
     return &res, nil
 }
 
 type SetInputObjectResult struct {
-    ID      string `json:"id"`
-    Success bool   `json:"success"`
+    ID        string           `json:"id"`
+    Success   bool             `json:"success"`
+    ErrorCode consts.ErrorCode `json:"error_code"`
+    Message   string           `json:"message"`
 }
 
-func (*SetInputObjectResult) GetTypeID() uint8 { return SetInputObject }
+func (*SetInputObjectResult) GetTypeID() uint8 { return consts.SetInputObjectResultID }
 
 func (r *SetInputObjectResult) Marshal(p *codec.Packer) {
     p.PackString(r.ID)
     p.PackBool(r.Success)
+    packErrorCode(p, r.ErrorCode, r.Message)
 }
 
 func UnmarshalSetInputObjectResult(p *codec.Packer) (codec.Typed, error) {
@@ -295,26 +752,28 @@ func UnmarshalSetInputObjectResult(p *codec.Packer) (codec.Typed, error) {
         return nil, err
     }
     res.Success = success
+
+    res.ErrorCode, res.Message, err = unpackErrorCode(p)
+    if err != nil {
+        return nil, err
+    }
     return &res, nil
 }
 
 // Helper functions
-func objectExists(ctx context.Context, vm chain.VM, id string) (bool, error) {
-    key := []byte("object:" + id)
-    return vm.State().Has(ctx, key)
-}
-
-func validateCode(code []byte) error {
-    return nil
-}
-
-func validateFunctionExists(ctx context.Context, vm chain.VM, objectID, function string) error {
-    return nil
+func packErrorCode(p *codec.Packer, code consts.ErrorCode, msg string) {
+    p.PackUint64(uint64(code))
+    p.PackString(msg)
 }
 
-// RegisterActions registers core actions with the auth factory
-func RegisterActions(f *chain.AuthFactory) {
-    f.Register(&CreateObjectAction{}, UnmarshalCreateObject)
-    f.Register(&SendEventAction{}, UnmarshalSendEvent)
-    f.Register(&SetInputObjectAction{}, UnmarshalSetInputObject)
+func unpackErrorCode(p *codec.Packer) (consts.ErrorCode, string, error) {
+    code, err := p.UnpackUint64()
+    if err != nil {
+        return 0, "", err
+    }
+    msg, err := p.UnpackString()
+    if err != nil {
+        return 0, "", err
+    }
+    return consts.ErrorCode(code), msg, nil
 }
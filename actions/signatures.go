@@ -0,0 +1,105 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// SignedMessage is an ed25519 signature carried by an action, with the
+// key and message it must verify against.
+type SignedMessage struct {
+	Message   []byte
+	PublicKey ed25519.PublicKey
+	Signature ed25519.Signature
+	// Err is what the action fails with if the signature does not verify
+	Err error
+}
+
+func (m *SignedMessage) Verify() bool {
+	return ed25519.Verify(m.Message, m.PublicKey, m.Signature)
+}
+
+// Signatures returns the ed25519 signatures of [action] that can be
+// checked ahead of execution against [im]: the requester's signature over
+// the input of a TEE execution, and the signatures of enclaves registered
+// with ed25519 keys over digests known without executing. Signatures
+// that are malformed, or whose enclave is unknown, are left to execution,
+// which rejects them.
+func Signatures(ctx context.Context, im state.Immutable, action chain.Action) ([]SignedMessage, error) {
+	var msgs []SignedMessage
+	switch a := action.(type) {
+	case *TEEExecAction:
+		if len(a.TxData) > 0 && len(a.UserSig) == UserSigLen {
+			msg := SignedMessage{Message: RequestDigest(a.RegionID, a.TxData), Err: ErrInvalidUserSig}
+			copy(msg.PublicKey[:], a.UserSig[:ed25519.PublicKeyLen])
+			copy(msg.Signature[:], a.UserSig[ed25519.PublicKeyLen:])
+			msgs = append(msgs, msg)
+		}
+		executions := []*TEEExecAction{a}
+		// An aggregated pair is signed with BLS keys
+		if a.Peer != nil && !a.Attestation.Flags.Aggregated() {
+			executions = append(executions, &TEEExecAction{
+				RegionID:    a.RegionID,
+				TxData:      a.TxData,
+				EventID:     a.EventID,
+				ExecResult:  a.Peer.ExecResult,
+				Attestation: a.Peer.Attestation,
+			})
+		}
+		for _, exec := range executions {
+			if exec.Attestation.Flags.Aggregated() {
+				continue
+			}
+			result, err := resolveStateRefs(ctx, im, exec.ExecResult)
+			if err != nil {
+				// Execution reports the missing blob
+				continue
+			}
+			digest, err := result.Digest()
+			if err != nil {
+				return nil, err
+			}
+			msgs, err = appendEnclaveSignature(ctx, im, msgs, a.RegionID, ExecDigest(digest, exec.RegionID, exec.TxData, exec.EventID), &exec.Attestation)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case *PublishFeedAction:
+		return appendEnclaveSignature(ctx, im, msgs, a.RegionID, FeedDigest(a.FeedID, a.Round, a.Value), &a.Attestation)
+	case *SettleRegionAction:
+		return appendEnclaveSignature(ctx, im, msgs, a.RegionID, SettlementDigest(a.RegionID, a.Epoch, a.PrevRoot, a.StateRoot), &a.Attestation)
+	}
+	return msgs, nil
+}
+
+// appendEnclaveSignature appends the signature of [a] over [digest] if its
+// enclave is registered in [regionID] with an ed25519 key.
+func appendEnclaveSignature(
+	ctx context.Context,
+	im state.Immutable,
+	msgs []SignedMessage,
+	regionID string,
+	digest []byte,
+	a *attestation.Attestation,
+) ([]SignedMessage, error) {
+	_, pubKey, err := storage.GetEnclave(ctx, im, regionID, a.EnclaveID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pubKey) != ed25519.PublicKeyLen || len(a.Signature) != ed25519.SignatureLen {
+		return msgs, nil
+	}
+	msg := SignedMessage{Message: digest, Err: ErrInvalidSignature}
+	copy(msg.PublicKey[:], pubKey)
+	copy(msg.Signature[:], a.Signature)
+	return append(msgs, msg), nil
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+var (
+	ErrNotObjectCreator = errors.New("actor did not create object")
+	ErrNoSponsorPolicy  = errors.New("object has no sponsor policy")
+
+	_ chain.Action = (*SetSponsorPolicyAction)(nil)
+)
+
+// SetSponsorPolicyAction makes the actor pay for executions of events sent
+// to [ObjectID]. [Deposit] moves from the actor's balance into the policy
+// budget, and each execution completing an event for the object is
+// reimbursed up to [MaxPerEvent] from it. Only the object's creator may
+// set or change its policy; setting [Withdraw] removes it and returns what
+// is left of the budget.
+type SetSponsorPolicyAction struct {
+	ObjectID    string `serialize:"true" json:"object_id"`
+	MaxPerEvent uint64 `serialize:"true" json:"max_per_event"`
+	Deposit     uint64 `serialize:"true" json:"deposit"`
+	Withdraw    bool   `serialize:"true" json:"withdraw"`
+}
+
+func (*SetSponsorPolicyAction) GetTypeID() uint8 {
+	return consts.SetSponsorPolicyID
+}
+
+func (s *SetSponsorPolicyAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.ObjectKey(s.ObjectID)):        state.Read,
+		string(storage.ObjectCreatorKey(s.ObjectID)): state.Read,
+		string(storage.SponsorPolicyKey(s.ObjectID)): state.All,
+		string(storage.BalanceKey(actor)):            state.All,
+	}, actor, s)
+}
+
+func (s *SetSponsorPolicyAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.SetSponsorPolicyID, "", s.ObjectID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, s); err != nil {
+		return nil, err
+	}
+
+	obj, err := storage.GetObject(ctx, mu, s.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, ErrObjectNotFound
+	}
+	creator, err := storage.GetObjectCreator(ctx, mu, s.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if creator != actor {
+		return nil, ErrNotObjectCreator
+	}
+	p, err := storage.GetSponsorPolicy(ctx, mu, s.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Withdraw {
+		if p == nil {
+			return nil, ErrNoSponsorPolicy
+		}
+		if _, err := storage.AddBalance(ctx, mu, actor, p.Budget, true); err != nil {
+			return nil, err
+		}
+		if err := storage.RemoveSponsorPolicy(ctx, mu, s.ObjectID); err != nil {
+			return nil, err
+		}
+		return &SetSponsorPolicyResult{ObjectID: s.ObjectID, Refund: p.Budget}, nil
+	}
+
+	if p == nil {
+		p = &storage.SponsorPolicy{Payer: actor}
+	}
+	if s.Deposit > 0 {
+		if _, err := storage.SubBalance(ctx, mu, actor, s.Deposit); err != nil {
+			return nil, err
+		}
+		if p.Budget, err = smath.Add(p.Budget, s.Deposit); err != nil {
+			return nil, err
+		}
+	}
+	p.MaxPerEvent = s.MaxPerEvent
+	if err := storage.SetSponsorPolicy(ctx, mu, s.ObjectID, p); err != nil {
+		return nil, err
+	}
+	return &SetSponsorPolicyResult{ObjectID: s.ObjectID, Budget: p.Budget}, nil
+}
+
+func (*SetSponsorPolicyAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + 2*schedule.StateUpdateUnits
+}
+
+func (*SetSponsorPolicyAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type SetSponsorPolicyResult struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	// Budget is what the policy holds after the action
+	Budget uint64 `serialize:"true" json:"budget"`
+	// Refund is what a withdrawal returned to the payer
+	Refund uint64 `serialize:"true" json:"refund"`
+}
+
+func (*SetSponsorPolicyResult) GetTypeID() uint8 {
+	return consts.SetSponsorPolicyResultID
+}
+
+// reimburseEvent pays [actor] the fee for [units] out of the sponsor policy
+// of [objectID], if it has one, capped by the policy. It returns the amount
+// paid.
+func reimburseEvent(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	objectID string,
+	actor codec.Address,
+	units uint64,
+) (uint64, error) {
+	p, err := storage.GetSponsorPolicy(ctx, mu, objectID)
+	if err != nil || p == nil {
+		return 0, err
+	}
+	amount := min(units*rules.GetMinUnitPrice()[fees.Compute], p.MaxPerEvent, p.Budget)
+	if amount == 0 {
+		return 0, nil
+	}
+	p.Budget -= amount
+	if err := storage.SetSponsorPolicy(ctx, mu, objectID, p); err != nil {
+		return 0, err
+	}
+	if _, err := storage.AddBalance(ctx, mu, actor, amount, true); err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
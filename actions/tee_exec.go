@@ -1,13 +1,27 @@
 package actions
 
 import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/hex"
     "errors"
+    "fmt"
+    "math"
     "github.com/ava-labs/avalanchego/ids"
     "github.com/ava-labs/hypersdk/chain"
     "github.com/ava-labs/hypersdk/codec"
     "github.com/ava-labs/hypersdk/state"
     "github.com/rhombus-tech/hypersdk/x/contracts/runtime/events"
     "sort"
+    "strings"
+
+    "go.uber.org/zap"
+
+    "github.com/rhombus-tech/vm/attestation"
+    "github.com/rhombus-tech/vm/consts"
+    "github.com/rhombus-tech/vm/storage"
 )
 
 var (
@@ -17,37 +31,263 @@ var (
     ErrInvalidTimeStamps = errors.New("invalid timestamps")
     ErrStaleTimeStamp = errors.New("stale timestamp")
     ErrInvalidExecResult = errors.New("invalid execution result")
+    ErrTooManyEvents = errors.New("too many events in execution result")
+    ErrTooManyStateUpdates = errors.New("too many state updates in execution result")
+    ErrTooManyLogs = errors.New("too many logs in execution result")
+    ErrInvalidLog = errors.New("invalid log")
+    ErrTooManyTimeStamps = attestation.ErrTooManyStamps
+    ErrBlobNotFound = errors.New("referenced blob not found")
+    ErrConflictingStateRef = errors.New("state update given both by value and by reference")
+    ErrStateRootMismatch = errors.New("execution did not start from the attested region root")
+    ErrPeerRequired = errors.New("region requires the peer enclave's execution")
+    ErrPeerSameEnclave = errors.New("peer execution attested by the same enclave")
+    ErrNestedPeer = errors.New("peer execution carries its own peer")
+    ErrDivergentResults = errors.New("enclave pair results diverge")
+    ErrAggregateWithoutPeer = errors.New("aggregated signature without a peer execution")
+    ErrAggregateDivergent = errors.New("aggregated signature over diverging results")
+    ErrExecFailed = errors.New("contract execution failed")
 )
 
-type RoughtimeStamp struct {
-    ServerID  string
-    Time      uint64
-    Signature []byte
-}
+// State update keys addressing an object's key-value namespace
+const (
+    objectKVUpdatePrefix = "object:"
+    objectKVUpdateSep    = ":kv:"
+)
 
 type TEEExecResult struct {
-    ContractAddr []byte
-    Events      []events.Event
-    StateUpdates map[string][]byte
+    ContractAddr []byte            `json:"contract_addr"`
+    Events      []events.Event     `json:"events"`
+    StateUpdates map[string][]byte `json:"state_updates"`
+    // StateRefs gives state updates by the hash of their value instead of
+    // the value itself. A hash must match a value in StateUpdates or a blob
+    // stored by an earlier execution. Refs are a wire encoding only: they
+    // are resolved into StateUpdates before the digest is checked.
+    StateRefs map[string]ids.ID `json:"state_refs"`
+    // PreStateRoot and StateRoot are the regional state roots the enclave
+    // executed against and produced. Both are optional; a set StateRoot
+    // becomes the region's attested root.
+    PreStateRoot ids.ID `json:"pre_state_root"`
+    StateRoot    ids.ID `json:"state_root"`
+    // Reads and Calls record the object state the execution read and the
+    // object-to-object calls it made. Both are optional; when Calls is set
+    // every object the result reads or writes must be called in it.
+    Reads []ObjectRead `json:"reads"`
+    Calls []CallFrame  `json:"calls"`
+    // Usage is the peak of each limited resource the execution used.
+    // Exhausted, when set, is the resource whose limit aborted it; an
+    // aborted result applies nothing.
+    Usage     ResourceUsage `json:"usage"`
+    Exhausted Resource      `json:"exhausted"`
+    // Logs are records of the contract for clients, indexed by topic as
+    // blocks are accepted. Unlike events they are not queued.
+    Logs []storage.Log `json:"logs"`
+    // Failed reports that the contract failed. Like an aborted result, a
+    // failed one applies nothing; a saga step it executes fails.
+    Failed bool `json:"failed"`
+}
+
+// WithBlobRefs returns a copy of [r] in which each value of at least
+// [consts.MinBlobSize] bytes is replaced by a reference if [stored] reports
+// its blob exists or an earlier key in the result has the same value. The
+// digest of the copy is the same.
+func (r *TEEExecResult) WithBlobRefs(stored func(ids.ID) bool) TEEExecResult {
+    out := TEEExecResult{
+        ContractAddr: r.ContractAddr,
+        Events:       r.Events,
+        StateUpdates: make(map[string][]byte, len(r.StateUpdates)),
+        StateRefs:    make(map[string]ids.ID, len(r.StateRefs)),
+        PreStateRoot: r.PreStateRoot,
+        StateRoot:    r.StateRoot,
+        Reads:        r.Reads,
+        Calls:        r.Calls,
+        Usage:        r.Usage,
+        Exhausted:    r.Exhausted,
+        Logs:         r.Logs,
+        Failed:       r.Failed,
+    }
+    for key, hash := range r.StateRefs {
+        out.StateRefs[key] = hash
+    }
+    inline := make(map[ids.ID]struct{})
+    for _, key := range sortedKeys(r.StateUpdates) {
+        value := r.StateUpdates[key]
+        if len(value) < consts.MinBlobSize {
+            out.StateUpdates[key] = value
+            continue
+        }
+        hash := storage.BlobHash(value)
+        if _, ok := inline[hash]; ok || stored(hash) {
+            out.StateRefs[key] = hash
+            continue
+        }
+        inline[hash] = struct{}{}
+        out.StateUpdates[key] = value
+    }
+    return out
+}
+
+// resolveStateRefs returns a copy of [r] with every reference replaced by
+// its value.
+func resolveStateRefs(ctx context.Context, im state.Immutable, r TEEExecResult) (TEEExecResult, error) {
+    if len(r.StateRefs) == 0 {
+        return r, nil
+    }
+    if len(r.StateUpdates)+len(r.StateRefs) > consts.MaxStateUpdates {
+        return TEEExecResult{}, ErrTooManyStateUpdates
+    }
+    updates := make(map[string][]byte, len(r.StateUpdates)+len(r.StateRefs))
+    inline := make(map[ids.ID][]byte)
+    for key, value := range r.StateUpdates {
+        updates[key] = value
+        if len(value) >= consts.MinBlobSize {
+            inline[storage.BlobHash(value)] = value
+        }
+    }
+    for key, hash := range r.StateRefs {
+        if _, ok := updates[key]; ok {
+            return TEEExecResult{}, fmt.Errorf("%w: %s", ErrConflictingStateRef, key)
+        }
+        value, ok := inline[hash]
+        if !ok {
+            blob, exists, err := storage.GetBlob(ctx, im, hash)
+            if err != nil {
+                return TEEExecResult{}, err
+            }
+            if !exists {
+                return TEEExecResult{}, fmt.Errorf("%w: %s", ErrBlobNotFound, hash)
+            }
+            value = blob
+        }
+        updates[key] = value
+    }
+    return TEEExecResult{
+        ContractAddr: r.ContractAddr,
+        Events:       r.Events,
+        StateUpdates: updates,
+        PreStateRoot: r.PreStateRoot,
+        StateRoot:    r.StateRoot,
+        Reads:        r.Reads,
+        Calls:        r.Calls,
+        Usage:        r.Usage,
+        Exhausted:    r.Exhausted,
+        Logs:         r.Logs,
+        Failed:       r.Failed,
+    }, nil
+}
+
+// hasEffects reports whether applying [r] changes anything.
+func (r *TEEExecResult) hasEffects() bool {
+    return len(r.Events) > 0 || len(r.Logs) > 0 || len(r.StateUpdates) > 0 || len(r.StateRefs) > 0 || r.StateRoot != ids.Empty
+}
+
+// Digest is the message an enclave signs over its execution result. Events
+// and state updates are hashed in a fixed order so every TEE in a region
+// produces the same digest for the same result.
+func (r *TEEExecResult) Digest() ([]byte, error) {
+    h := sha256.New()
+    writeLenPrefixed := func(b []byte) {
+        h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(b))))
+        h.Write(b)
+    }
+    writeLenPrefixed(r.ContractAddr)
+    h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(r.Events))))
+    for _, event := range r.Events {
+        eventBytes, err := event.Marshal()
+        if err != nil {
+            return nil, err
+        }
+        writeLenPrefixed(eventBytes)
+    }
+    h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(r.StateUpdates))))
+    for _, key := range sortedKeys(r.StateUpdates) {
+        writeLenPrefixed([]byte(key))
+        writeLenPrefixed(r.StateUpdates[key])
+    }
+    // Roots are only hashed when set so results without them keep their
+    // digest
+    if r.PreStateRoot != ids.Empty || r.StateRoot != ids.Empty {
+        h.Write(r.PreStateRoot[:])
+        h.Write(r.StateRoot[:])
+    }
+    if len(r.Reads) > 0 || len(r.Calls) > 0 {
+        hashObjectAccess(h, r)
+    }
+    // Likewise usage, so results of enclaves that do not report it keep
+    // theirs
+    if r.Usage != (ResourceUsage{}) || r.Exhausted != "" {
+        h.Write(binary.BigEndian.AppendUint32(nil, r.Usage.MemoryPages))
+        h.Write(binary.BigEndian.AppendUint32(nil, r.Usage.ValueStack))
+        h.Write(binary.BigEndian.AppendUint32(nil, r.Usage.HostCallDepth))
+        h.Write(binary.BigEndian.AppendUint64(nil, r.Usage.ElapsedMs))
+        writeLenPrefixed([]byte(r.Exhausted))
+    }
+    if len(r.Logs) > 0 {
+        h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(r.Logs))))
+        for _, log := range r.Logs {
+            h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(log.Topics))))
+            for _, topic := range log.Topics {
+                h.Write(topic[:])
+            }
+            writeLenPrefixed(log.Data)
+        }
+    }
+    if r.Failed {
+        h.Write([]byte{1})
+    }
+    return h.Sum(nil), nil
 }
 
 type TEEExecAction struct {
-    RegionID     string
-    TxData       []byte
-    UserSig      []byte
-    EnclaveType  string    // "SGX" or "SEV"
-    EnclaveID    []byte
-    ExecResult   TEEExecResult
-    TEESig       []byte
-    TimeStamps   []RoughtimeStamp
+    Version    uint8         `json:"version"`
+    RegionID   string        `json:"region_id"`
+    TxData     []byte        `json:"tx_data"`
+    UserSig    []byte        `json:"user_sig"`
+    ExecResult TEEExecResult `json:"exec_result"`
+    // Attestation is the enclave's signature over ExecResult.Digest. With
+    // [attestation.FlagAggregated], its signature aggregates the peer's.
+    Attestation attestation.Attestation `json:"attestation"`
+    // Upper bound on units the sender is willing to pay for; any excess
+    // over the units actually consumed is refunded
+    MaxComputeUnits uint64 `json:"max_compute_units"`
+    // Witness proves region state the execution read against
+    // ExecResult.PreStateRoot, for validators without the region's state
+    Witness *StateWitness `json:"witness,omitempty"`
+    // Peer is a second enclave's execution of the same input, required by
+    // regions whose platform policy sets DualExecution
+    Peer *PeerExecution `json:"peer,omitempty"`
+    // EventID is the event whose execution this is; its result is delivered
+    // to the callback the event registered, if any
+    EventID ids.ID `json:"event_id"`
+}
+
+// PeerExecution is the result a second enclave of the region produced for
+// the input of a TEEExecAction, with its attestation. It is encoded as a
+// nested TEEExecAction carrying only these fields.
+type PeerExecution struct {
+    ExecResult  TEEExecResult           `json:"exec_result"`
+    Attestation attestation.Attestation `json:"attestation"`
+}
+
+func (pe *PeerExecution) action(version uint8) *TEEExecAction {
+    return &TEEExecAction{
+        Version:     version,
+        ExecResult:  pe.ExecResult,
+        Attestation: pe.Attestation,
+    }
 }
 
 func (t *TEEExecAction) Marshal(p *codec.Packer) {
+    if protoWire {
+        packProto(p, t.appendProto(nil))
+        return
+    }
+    version := t.Version
+    packVersion(p, version)
     p.PackString(t.RegionID)
     p.PackBytes(t.TxData)
     p.PackBytes(t.UserSig)
-    p.PackString(t.EnclaveType)
-    p.PackBytes(t.EnclaveID)
+    p.PackString(string(t.Attestation.EnclaveType))
+    p.PackBytes(t.Attestation.EnclaveID)
     
     // Pack ExecResult
     p.PackBytes(t.ExecResult.ContractAddr)
@@ -59,27 +299,119 @@ func (t *TEEExecAction) Marshal(p *codec.Packer) {
         p.PackBytes(eventBytes)
     }
     
-    // Pack StateUpdates
+    // Pack StateUpdates in key order so the encoding is deterministic
     p.PackInt(len(t.ExecResult.StateUpdates))
-    for key, value := range t.ExecResult.StateUpdates {
+    for _, key := range sortedKeys(t.ExecResult.StateUpdates) {
         p.PackString(key)
-        p.PackBytes(value)
+        p.PackBytes(t.ExecResult.StateUpdates[key])
     }
     
-    p.PackBytes(t.TEESig)
-    
-    // Pack TimeStamps
-    p.PackInt(len(t.TimeStamps))
-    for _, ts := range t.TimeStamps {
-        p.PackString(ts.ServerID)
-        p.PackUint64(ts.Time)
-        p.PackBytes(ts.Signature)
+    p.PackBytes(t.Attestation.Signature)
+    attestation.PackStamps(p, t.Attestation.Stamps)
+
+    p.PackUint64(t.MaxComputeUnits)
+
+    if version >= consts.ActionVersion2 {
+        refKeys := make([]string, 0, len(t.ExecResult.StateRefs))
+        for key := range t.ExecResult.StateRefs {
+            refKeys = append(refKeys, key)
+        }
+        sort.Strings(refKeys)
+        p.PackInt(len(refKeys))
+        for _, key := range refKeys {
+            p.PackString(key)
+            p.PackID(t.ExecResult.StateRefs[key])
+        }
+    }
+
+    if version >= consts.ActionVersion3 {
+        p.PackID(t.ExecResult.PreStateRoot)
+        p.PackID(t.ExecResult.StateRoot)
+        p.PackBool(t.Witness != nil)
+        if t.Witness != nil {
+            packWitness(p, t.Witness)
+        }
+    }
+
+    if version >= consts.ActionVersion4 {
+        p.PackBool(t.Peer != nil)
+        if t.Peer != nil {
+            t.Peer.action(version).Marshal(p)
+        }
+    }
+
+    if version >= consts.ActionVersion5 {
+        p.PackID(t.EventID)
+    }
+
+    if version >= consts.ActionVersion6 {
+        p.PackInt(len(t.ExecResult.Reads))
+        for _, read := range t.ExecResult.Reads {
+            p.PackString(read.Object)
+            p.PackBytes(read.Key)
+            p.PackID(read.ValueHash)
+        }
+        p.PackInt(len(t.ExecResult.Calls))
+        for _, call := range t.ExecResult.Calls {
+            p.PackString(call.Caller)
+            p.PackString(call.Callee)
+            p.PackString(call.Function)
+            p.PackInt(int(call.Depth))
+        }
+    }
+
+    if version >= consts.ActionVersion10 {
+        p.PackInt(int(t.Attestation.Flags))
+    }
+
+    if version >= consts.ActionVersion13 {
+        p.PackInt(int(t.ExecResult.Usage.MemoryPages))
+        p.PackInt(int(t.ExecResult.Usage.ValueStack))
+        p.PackInt(int(t.ExecResult.Usage.HostCallDepth))
+        p.PackUint64(t.ExecResult.Usage.ElapsedMs)
+        p.PackString(string(t.ExecResult.Exhausted))
+    }
+
+    if version >= consts.ActionVersion14 {
+        p.PackInt(len(t.ExecResult.Logs))
+        for _, log := range t.ExecResult.Logs {
+            p.PackInt(len(log.Topics))
+            for _, topic := range log.Topics {
+                p.PackID(topic)
+            }
+            p.PackBytes(log.Data)
+        }
+    }
+
+    if version >= consts.ActionVersion16 {
+        p.PackBool(t.ExecResult.Failed)
     }
 }
 
-func UnmarshalTEEExecAction(p *codec.Packer) (*TEEExecAction, error) {
+func UnmarshalTEEExecAction(p *codec.Packer) (chain.Action, error) {
+    if protoWire {
+        m, err := unpackProto(p)
+        if err != nil {
+            return nil, err
+        }
+        return teeExecFromProto(m)
+    }
+    return unpackTEEExec(p, true)
+}
+
+// unpackTEEExec decodes a TEEExecAction, which may only carry a peer
+// execution if [allowPeer] is set.
+func unpackTEEExec(p *codec.Packer, allowPeer bool) (*TEEExecAction, error) {
     var act TEEExecAction
 
+    // All versions so far share the v1 layout; fields added later are
+    // unpacked behind a check on act.Version
+    version, err := unpackVersion(p)
+    if err != nil {
+        return nil, err
+    }
+    act.Version = version
+
     regionID, err := p.UnpackString()
     if err != nil {
         return nil, err
@@ -102,13 +434,13 @@ func UnmarshalTEEExecAction(p *codec.Packer) (*TEEExecAction, error) {
     if err != nil {
         return nil, err
     }
-    act.EnclaveType = enclaveType
+    act.Attestation.EnclaveType = attestation.EnclaveType(enclaveType)
 
     enclaveID, err := p.UnpackBytes()
     if err != nil {
         return nil, err
     }
-    act.EnclaveID = enclaveID
+    act.Attestation.EnclaveID = enclaveID
 
     // Unpack ExecResult
     contractAddr, err := p.UnpackBytes()
@@ -122,6 +454,9 @@ func UnmarshalTEEExecAction(p *codec.Packer) (*TEEExecAction, error) {
     if err != nil {
         return nil, err
     }
+    if eventCount < 0 || eventCount > consts.MaxEventsPerExec {
+        return nil, ErrTooManyEvents
+    }
     act.ExecResult.Events = make([]events.Event, eventCount)
     for i := 0; i < eventCount; i++ {
         eventBytes, err := p.UnpackBytes()
@@ -140,6 +475,9 @@ func UnmarshalTEEExecAction(p *codec.Packer) (*TEEExecAction, error) {
     if err != nil {
         return nil, err
     }
+    if updateCount < 0 || updateCount > consts.MaxStateUpdates {
+        return nil, ErrTooManyStateUpdates
+    }
     act.ExecResult.StateUpdates = make(map[string][]byte, updateCount)
     for i := 0; i < updateCount; i++ {
         key, err := p.UnpackString()
@@ -157,170 +495,928 @@ func UnmarshalTEEExecAction(p *codec.Packer) (*TEEExecAction, error) {
     if err != nil {
         return nil, err
     }
-    act.TEESig = teeSig
+    act.Attestation.Signature = teeSig
+
+    stamps, err := attestation.UnpackStamps(p)
+    if err != nil {
+        return nil, err
+    }
+    act.Attestation.Stamps = stamps
 
-    // Unpack TimeStamps
-    tsLen, err := p.UnpackInt()
+    maxUnits, err := p.UnpackUint64()
     if err != nil {
         return nil, err
     }
+    act.MaxComputeUnits = maxUnits
 
-    act.TimeStamps = make([]RoughtimeStamp, tsLen)
-    for i := 0; i < tsLen; i++ {
-        serverID, err := p.UnpackString()
+    if act.Version >= consts.ActionVersion2 {
+        refCount, err := p.UnpackInt()
         if err != nil {
             return nil, err
         }
+        if refCount < 0 || refCount > consts.MaxStateUpdates {
+            return nil, ErrTooManyStateUpdates
+        }
+        act.ExecResult.StateRefs = make(map[string]ids.ID, refCount)
+        for i := 0; i < refCount; i++ {
+            key, err := p.UnpackString()
+            if err != nil {
+                return nil, err
+            }
+            var hash ids.ID
+            p.UnpackID(true, &hash)
+            if err := p.Err(); err != nil {
+                return nil, err
+            }
+            act.ExecResult.StateRefs[key] = hash
+        }
+    }
+
+    if act.Version >= consts.ActionVersion3 {
+        p.UnpackID(false, &act.ExecResult.PreStateRoot)
+        p.UnpackID(false, &act.ExecResult.StateRoot)
+        hasWitness := p.UnpackBool()
+        if err := p.Err(); err != nil {
+            return nil, err
+        }
+        if hasWitness {
+            witness, err := unpackWitness(p)
+            if err != nil {
+                return nil, err
+            }
+            act.Witness = witness
+        }
+    }
+
+    if act.Version >= consts.ActionVersion4 {
+        hasPeer := p.UnpackBool()
+        if err := p.Err(); err != nil {
+            return nil, err
+        }
+        if hasPeer {
+            if !allowPeer {
+                return nil, ErrNestedPeer
+            }
+            peer, err := unpackTEEExec(p, false)
+            if err != nil {
+                return nil, err
+            }
+            act.Peer = &PeerExecution{
+                ExecResult:  peer.ExecResult,
+                Attestation: peer.Attestation,
+            }
+        }
+    }
 
-        time, err := p.UnpackUint64()
+    if act.Version >= consts.ActionVersion5 {
+        p.UnpackID(false, &act.EventID)
+        if err := p.Err(); err != nil {
+            return nil, err
+        }
+    }
+
+    if act.Version >= consts.ActionVersion6 {
+        readCount, err := p.UnpackInt()
         if err != nil {
             return nil, err
         }
+        if readCount < 0 || readCount > consts.MaxObjectReads {
+            return nil, ErrTooManyReads
+        }
+        act.ExecResult.Reads = make([]ObjectRead, readCount)
+        for i := range act.ExecResult.Reads {
+            read := &act.ExecResult.Reads[i]
+            if read.Object, err = p.UnpackString(); err != nil {
+                return nil, err
+            }
+            if read.Key, err = p.UnpackBytes(); err != nil {
+                return nil, err
+            }
+            p.UnpackID(false, &read.ValueHash)
+        }
+        callCount, err := p.UnpackInt()
+        if err != nil {
+            return nil, err
+        }
+        if callCount < 0 || callCount > consts.MaxCallFrames {
+            return nil, ErrTooManyCalls
+        }
+        act.ExecResult.Calls = make([]CallFrame, callCount)
+        for i := range act.ExecResult.Calls {
+            call := &act.ExecResult.Calls[i]
+            if call.Caller, err = p.UnpackString(); err != nil {
+                return nil, err
+            }
+            if call.Callee, err = p.UnpackString(); err != nil {
+                return nil, err
+            }
+            if call.Function, err = p.UnpackString(); err != nil {
+                return nil, err
+            }
+            depth, err := p.UnpackInt()
+            if err != nil {
+                return nil, err
+            }
+            if depth < 0 || depth > consts.MaxCallDepth {
+                return nil, ErrInvalidCallTrace
+            }
+            call.Depth = uint8(depth)
+        }
+        if err := p.Err(); err != nil {
+            return nil, err
+        }
+    }
 
-        sig, err := p.UnpackBytes()
+    if act.Version >= consts.ActionVersion10 {
+        flags, err := p.UnpackInt()
         if err != nil {
             return nil, err
         }
+        if err := checkExecFlags(attestation.Flags(flags), flags, allowPeer); err != nil {
+            return nil, err
+        }
+        act.Attestation.Flags = attestation.Flags(flags)
+    }
 
-        act.TimeStamps[i] = RoughtimeStamp{
-            ServerID:  serverID,
-            Time:      time,
-            Signature: sig,
+    if act.Version >= consts.ActionVersion13 {
+        usage := &act.ExecResult.Usage
+        for _, dst := range []*uint32{&usage.MemoryPages, &usage.ValueStack, &usage.HostCallDepth} {
+            v, err := p.UnpackInt()
+            if err != nil {
+                return nil, err
+            }
+            if v < 0 || v > math.MaxUint32 {
+                return nil, fmt.Errorf("%w: usage %d", ErrOutOfResources, v)
+            }
+            *dst = uint32(v)
+        }
+        if usage.ElapsedMs, err = p.UnpackUint64(); err != nil {
+            return nil, err
+        }
+        exhausted, err := p.UnpackString()
+        if err != nil {
+            return nil, err
+        }
+        act.ExecResult.Exhausted = Resource(exhausted)
+    }
+
+    if act.Version >= consts.ActionVersion14 {
+        logCount, err := p.UnpackInt()
+        if err != nil {
+            return nil, err
+        }
+        if logCount < 0 || logCount > consts.MaxLogsPerExec {
+            return nil, ErrTooManyLogs
+        }
+        act.ExecResult.Logs = make([]storage.Log, logCount)
+        for i := range act.ExecResult.Logs {
+            log := &act.ExecResult.Logs[i]
+            topicCount, err := p.UnpackInt()
+            if err != nil {
+                return nil, err
+            }
+            if topicCount < 0 || topicCount > consts.MaxLogTopics {
+                return nil, fmt.Errorf("%w: %d topics", ErrInvalidLog, topicCount)
+            }
+            log.Topics = make([]ids.ID, topicCount)
+            for j := range log.Topics {
+                p.UnpackID(true, &log.Topics[j])
+            }
+            if log.Data, err = p.UnpackBytes(); err != nil {
+                return nil, err
+            }
+        }
+        if err := validateLogs(act.ExecResult.Logs); err != nil {
+            return nil, err
+        }
+        if err := p.Err(); err != nil {
+            return nil, err
+        }
+    }
+
+    if act.Version >= consts.ActionVersion16 {
+        if act.ExecResult.Failed, err = p.UnpackBool(); err != nil {
+            return nil, err
         }
     }
 
     return &act, nil
 }
 
-func (t *TEEExecAction) Execute(ctx chain.Context) error {
-    sm := state.NewManager(ctx)
+// validateLogs checks [logs] fit the bounds in [consts].
+func validateLogs(logs []storage.Log) error {
+    if len(logs) > consts.MaxLogsPerExec {
+        return ErrTooManyLogs
+    }
+    for _, log := range logs {
+        if len(log.Topics) > consts.MaxLogTopics {
+            return fmt.Errorf("%w: %d topics", ErrInvalidLog, len(log.Topics))
+        }
+        if len(log.Data) > consts.MaxLogDataSize {
+            return fmt.Errorf("%w: %d bytes of data", ErrInvalidLog, len(log.Data))
+        }
+    }
+    return nil
+}
+
+// checkExecFlags rejects unknown flags, and any flag on a peer execution:
+// only the first attestation of a pair carries the aggregate.
+func checkExecFlags(flags attestation.Flags, raw int, allowPeer bool) error {
+    if raw < 0 || raw > 0xff || !flags.Valid() || (flags != 0 && !allowPeer) {
+        return fmt.Errorf("%w: %d", attestation.ErrUnknownFlags, raw)
+    }
+    return nil
+}
+
+func (*TEEExecAction) GetTypeID() uint8 {
+    return consts.TEEExecID
+}
+
+func (t *TEEExecAction) Execute(
+    ctx context.Context,
+    rules chain.Rules,
+    mu state.Mutable,
+    timestamp int64,
+    actor codec.Address,
+    actionID ids.ID,
+) (_ codec.Typed, err error) {
+    defer wrapExecError(&err, consts.TEEExecID, t.RegionID, string(t.ExecResult.ContractAddr))
+
+    if err := checkGates(ctx, rules, mu, timestamp, actor, t); err != nil {
+        return nil, err
+    }
+    // Units are priced as the gates charged them
+    if rules, err = GovernedRules(ctx, rules, mu); err != nil {
+        return nil, err
+    }
 
     // 1. Verify Region
-    regionKey := state.Key("region", t.RegionID)
-    exists, err := sm.Exists(regionKey)
+    _, exists, err := storage.GetRegion(ctx, mu, t.RegionID)
     if err != nil {
-        return err
+        return nil, err
     }
     if !exists {
-        return ErrInvalidRegion
+        return nil, ErrInvalidRegion
+    }
+    if err := checkRegionFrozen(ctx, mu, t.RegionID); err != nil {
+        return nil, err
+    }
+    template, err := RegionTemplateOf(ctx, mu, t.RegionID)
+    if err != nil {
+        return nil, err
+    }
+    if template != nil && template.Quotas.MaxExecUnits != 0 {
+        if consumed := t.consumedUnits(rules); consumed > template.Quotas.MaxExecUnits {
+            return nil, fmt.Errorf("%w: %d units > %d", ErrRegionQuota, consumed, template.Quotas.MaxExecUnits)
+        }
+    }
+    limits, err := storage.GetExecLimits(ctx, mu, t.RegionID)
+    if err != nil {
+        return nil, err
+    }
+    if err := CheckResourceUsage(limits, &t.ExecResult); err != nil {
+        return nil, err
     }
 
     // 2. Verify Enclave is registered and active
-    enclaveKey := state.Key("enclave", t.RegionID, t.EnclaveID)
-    enclaveStatus, err := sm.Get(enclaveKey)
+    pubKey, err := activeEnclave(ctx, mu, t.RegionID, t.Attestation.EnclaveID, timestamp)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkPlatform(ctx, mu, t.RegionID, t.Attestation.EnclaveID, t.Attestation.EnclaveType); err != nil {
+        return nil, err
+    }
+
+    // 3. Resolve blob references, then verify the TEE signature over the
+    // full result with the enclave public key
+    result, err := resolveStateRefs(ctx, mu, t.ExecResult)
     if err != nil {
-        return err
+        return nil, err
     }
-    if enclaveStatus == nil || enclaveStatus[0] != 1 { // 1 = active
-        return ErrInvalidEnclave
+    digest, err := result.Digest()
+    if err != nil {
+        return nil, err
+    }
+    // An aggregated signature also covers the peer, and is checked with
+    // its key once the peer's enclave is known
+    if t.Attestation.Flags.Aggregated() {
+        if t.Peer == nil {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, ErrAggregateWithoutPeer)
+        }
+    } else if err := t.Attestation.Verify(ExecDigest(digest, t.RegionID, t.TxData, t.EventID), pubKey); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
     }
 
-    // 3. Get Enclave Public Key and verify TEE signature
-    pubKeyKey := state.Key("enclave-pubkey", t.RegionID, t.EnclaveID)
-    pubKey, err := sm.Get(pubKeyKey)
+    // Check the execution started from the region's attested root
+    regionRoot, err := storage.GetRegionRoot(ctx, mu, t.RegionID)
     if err != nil {
-        return err
+        return nil, err
     }
-    if !verifyTEESignature(t.ExecResult, t.TEESig, pubKey, t.EnclaveType) {
-        return ErrInvalidSignature
+    if err := t.VerifyPreconditions(regionRoot); err != nil {
+        return nil, err
     }
 
-    // 4. Verify Roughtime stamps
-    medianTime, err := verifyTimeStamps(t.TimeStamps)
+    // 4. Verify Roughtime stamps against the governed servers and quorum
+    stampKeys, err := RoughtimeKeys(ctx, mu)
+    if err != nil {
+        return nil, err
+    }
+    quorum, err := RoughtimeQuorum(ctx, mu)
+    if err != nil {
+        return nil, err
+    }
+    medianTime, err := t.Attestation.TrustedMedianTime(stampKeys, quorum)
     if err != nil {
-        return err
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTimeStamps, err)
     }
 
-    // 5. Check if timestamp is within acceptable range
-    if !isTimeStampValid(medianTime, ctx.Time()) {
-        return ErrStaleTimeStamp
+    // 5. Check if timestamp is within the governed drift window
+    maxDrift, err := Uint64Param(ctx, mu, consts.ParamTimeDrift, consts.MaxTimeDrift)
+    if err != nil {
+        return nil, err
+    }
+    if !attestation.WithinDrift(medianTime, uint64(timestamp/1000), maxDrift) {
+        return nil, ErrStaleTimeStamp
     }
 
-    // 6. Process state updates
-    for key, value := range t.ExecResult.StateUpdates {
-        stateKey := state.Key("state", t.RegionID, []byte(key))
-        if err := sm.Set(stateKey, value); err != nil {
-            return err
+    // The median is trusted from here on, so it advances the region's
+    // verified time
+    if err := commitVerifiedTime(ctx, mu, t.RegionID, medianTime, timestamp); err != nil {
+        return nil, err
+    }
+
+    // 6. Regions requiring dual execution only apply results both enclaves
+    // of a pair agree on. A divergence is recorded instead of applied.
+    policy, err := storage.GetPlatformPolicy(ctx, mu, t.RegionID)
+    if err != nil {
+        return nil, err
+    }
+    if t.Peer == nil && policy != nil && policy.DualExecution {
+        return nil, ErrPeerRequired
+    }
+    if t.Peer != nil {
+        peerDigest, err := t.verifyPeer(ctx, mu, timestamp, pubKey, digest, maxDrift, stampKeys, quorum)
+        if err != nil {
+            return nil, err
+        }
+        if !bytes.Equal(digest, peerDigest) {
+            return t.recordDivergence(ctx, rules, mu, timestamp, actor, actionID, digest, peerDigest)
         }
     }
 
-    // 7. Store events
+    // Executions serving a user request must carry the requester's
+    // signature over its input, and fulfill the queued request
+    if len(t.TxData) > 0 {
+        if err := fulfillRequest(ctx, mu, t.RegionID, t.TxData, t.UserSig, digest); err != nil {
+            return nil, err
+        }
+    } else if len(t.UserSig) > 0 {
+        return nil, ErrInvalidUserSig
+    }
+
+    // An execution aborted on a resource limit, or whose contract failed,
+    // applies nothing, but still answers the request and event it served
+    if result.Failed && result.hasEffects() {
+        return nil, fmt.Errorf("%w: failed execution has effects", ErrExecFailed)
+    }
+    if result.Failed || result.Exhausted != "" {
+        return t.recordAbort(ctx, rules, mu, timestamp, actor, actionID, digest, regionRoot, limits)
+    }
+
+    // 7. Check the objects the execution called, read and wrote. Reads
+    // must still hold, so a result is only applied to the state it saw.
+    if err := checkObjectAccess(ctx, mu, &result); err != nil {
+        return nil, err
+    }
+
+    // 8. Process state updates, routing object:<ID>:kv:<key> updates into
+    // that object's key-value namespace. Large values sent inline are also
+    // stored as blobs for later executions to reference.
+    for _, key := range sortedKeys(result.StateUpdates) {
+        value := result.StateUpdates[key]
+        if _, ok := t.ExecResult.StateUpdates[key]; ok && len(value) >= consts.MinBlobSize {
+            if _, err := storage.PutBlob(ctx, mu, value); err != nil {
+                return nil, err
+            }
+        }
+        objectID, kvKey, ok := parseObjectKVUpdate(key)
+        if !ok {
+            if err := storage.SetRegionState(ctx, mu, t.RegionID, []byte(key), value); err != nil {
+                return nil, err
+            }
+            continue
+        }
+        obj, err := storage.GetObject(ctx, mu, objectID)
+        if err != nil {
+            return nil, err
+        }
+        if obj == nil {
+            return nil, ErrObjectNotFound
+        }
+        if err := storage.SetObjectKV(ctx, mu, objectID, kvKey, value); err != nil {
+            return nil, err
+        }
+    }
+
+    if result.StateRoot != ids.Empty {
+        if err := storage.SetRegionRoot(ctx, mu, t.RegionID, result.StateRoot); err != nil {
+            return nil, err
+        }
+        regionRoot = result.StateRoot
+    }
+
+    // 9. Store events
     for i, event := range t.ExecResult.Events {
-        eventKey := state.Key("event", t.RegionID, t.ExecResult.ContractAddr, uint64(i))
         eventBytes, err := event.Marshal()
         if err != nil {
-            return err
+            return nil, err
         }
-        if err := sm.Set(eventKey, eventBytes); err != nil {
-            return err
+        eventKey := storage.ExecEventKey(t.RegionID, t.ExecResult.ContractAddr, uint64(i))
+        if err := mu.Insert(ctx, eventKey, eventBytes); err != nil {
+            return nil, err
         }
     }
 
-    return nil
+    // 10. Record a receipt others can prove against the chain state root
+    if err := storage.SetReceipt(ctx, mu, t.RegionID, actionID, &storage.Receipt{
+        Enclave:    t.Attestation.EnclaveID,
+        ResultHash: ids.ID(digest),
+        RegionRoot: regionRoot,
+        Timestamp:  timestamp,
+    }); err != nil {
+        return nil, err
+    }
+
+    // 11. Deliver the result to the callback the event registered, and
+    // move on the saga awaiting it
+    if t.EventID != ids.Empty {
+        if err := completeCallback(ctx, mu, t.EventID, digest, timestamp); err != nil {
+            return nil, err
+        }
+        if err := advanceSaga(ctx, mu, t.EventID, string(result.ContractAddr), false, timestamp); err != nil {
+            return nil, err
+        }
+    }
+
+    // 12. Refund units declared but not consumed
+    consumed := t.consumedUnits(rules)
+    refund, err := refundUnusedUnits(ctx, rules, mu, t.RegionID, actor, t.ComputeUnits(rules), consumed)
+    if err != nil {
+        return nil, err
+    }
+
+    // 13. Credit the producing enclave from the region fee pool
+    if err := accrueExecReward(ctx, rules, mu, t.RegionID, t.Attestation.EnclaveID, consumed); err != nil {
+        return nil, err
+    }
+
+    // 14. Take the event off its object's queue, and let the object pay
+    // for its execution. An event no longer queued is not paid for again.
+    var sponsored uint64
+    if t.EventID != ids.Empty {
+        queued, err := dequeueEvent(ctx, mu, storage.EventQueueKey(t.EventID, string(result.ContractAddr)))
+        if err != nil {
+            return nil, err
+        }
+        if queued {
+            sponsored, err = reimburseEvent(ctx, rules, mu, string(result.ContractAddr), actor, consumed)
+            if err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    // 15. Burn the units consumed from the region's metering asset, priced
+    // at the fee schedule of the region's template if it has one
+    meteredUnits := consumed
+    if template != nil {
+        meteredUnits = t.execUnits(template.meteringSchedule())
+    }
+    metered, err := burnMeteredUnits(ctx, mu, t.RegionID, actor, meteredUnits)
+    if err != nil {
+        return nil, err
+    }
+
+    execLogger(ctx, mu, t.RegionID, t.Attestation.EnclaveID).WithAction(consts.TEEExecID).Debug("execution applied",
+        zap.Stringer("actionID", actionID),
+        zap.Uint64("units", consumed),
+        zap.Uint64("refund", refund),
+        zap.Uint64("metered", metered),
+    )
+    return &TEEExecOutput{
+        RegionID:      t.RegionID,
+        Success:       true,
+        UnitsConsumed: consumed,
+        RefundIssued:  refund,
+        Sponsored:     sponsored,
+        Metered:       metered,
+    }, nil
+}
+
+// verifyPeer checks the peer execution as the primary one is checked: it
+// must come from another active enclave of the region allowed by its
+// platform policy, sign its result for the same input, and carry stamps
+// within [maxDrift] of the block, signed with [stampKeys] by [quorum]
+// servers. It returns the digest of the peer result. An aggregated pair
+// must agree on [digest], the primary result's, as both keys, the
+// primary's being [pubKey], are checked against it at once.
+func (t *TEEExecAction) verifyPeer(
+    ctx context.Context,
+    im state.Immutable,
+    timestamp int64,
+    primaryKey []byte,
+    primaryDigest []byte,
+    maxDrift uint64,
+    stampKeys attestation.StampKeys,
+    quorum attestation.StampQuorum,
+) ([]byte, error) {
+    peer := t.Peer
+    if bytes.Equal(peer.Attestation.EnclaveID, t.Attestation.EnclaveID) {
+        return nil, ErrPeerSameEnclave
+    }
+    pubKey, err := activeEnclave(ctx, im, t.RegionID, peer.Attestation.EnclaveID, timestamp)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkPlatform(ctx, im, t.RegionID, peer.Attestation.EnclaveID, peer.Attestation.EnclaveType); err != nil {
+        return nil, err
+    }
+    result, err := resolveStateRefs(ctx, im, peer.ExecResult)
+    if err != nil {
+        return nil, err
+    }
+    digest, err := result.Digest()
+    if err != nil {
+        return nil, err
+    }
+    if err := t.verifyPeerSignature(primaryKey, primaryDigest, pubKey, digest); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+    }
+    medianTime, err := peer.Attestation.TrustedMedianTime(stampKeys, quorum)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTimeStamps, err)
+    }
+    if !attestation.WithinDrift(medianTime, uint64(timestamp/1000), maxDrift) {
+        return nil, ErrStaleTimeStamp
+    }
+    return digest, nil
 }
 
-func (t *TEEExecAction) StateKeys(chain.Auth) []string {
-    keys := []string{
-        state.Key("region", t.RegionID),
-        state.Key("enclave", t.RegionID, t.EnclaveID),
-        state.Key("enclave-pubkey", t.RegionID, t.EnclaveID),
+// verifyPeerSignature checks the peer's signature over [digest] with
+// [pubKey] or, for an aggregated pair, the aggregate of both signatures.
+// Diverging results cannot be aggregated, as each enclave signed its own.
+func (t *TEEExecAction) verifyPeerSignature(primaryKey, primaryDigest, pubKey, digest []byte) error {
+    msg := ExecDigest(digest, t.RegionID, t.TxData, t.EventID)
+    if !t.Attestation.Flags.Aggregated() {
+        return t.Peer.Attestation.Verify(msg, pubKey)
     }
+    if !bytes.Equal(primaryDigest, digest) {
+        return ErrAggregateDivergent
+    }
+    if len(t.Peer.Attestation.Signature) > 0 {
+        return attestation.ErrAggregateSignature
+    }
+    return attestation.VerifyAggregate(msg, t.Attestation.Signature, primaryKey, pubKey)
+}
 
-    // Add state update keys
-    for key := range t.ExecResult.StateUpdates {
-        keys = append(keys, state.Key("state", t.RegionID, []byte(key)))
+// recordDivergence stores the digests of a dual execution whose results
+// differ and reports it as an unsuccessful execution. Neither result is
+// applied and neither enclave is rewarded; the sender is still refunded
+// the units it did not consume.
+func (t *TEEExecAction) recordDivergence(
+    ctx context.Context,
+    rules chain.Rules,
+    mu state.Mutable,
+    timestamp int64,
+    actor codec.Address,
+    actionID ids.ID,
+    digest []byte,
+    peerDigest []byte,
+) (codec.Typed, error) {
+    if err := storage.SetDivergence(ctx, mu, t.RegionID, actionID, &storage.Divergence{
+        Enclave:     t.Attestation.EnclaveID,
+        Digest:      ids.ID(digest),
+        PeerEnclave: t.Peer.Attestation.EnclaveID,
+        PeerDigest:  ids.ID(peerDigest),
+        ReportedAt:  timestamp,
+    }); err != nil {
+        return nil, err
+    }
+    consumed := t.consumedUnits(rules)
+    refund, err := refundUnusedUnits(ctx, rules, mu, t.RegionID, actor, t.ComputeUnits(rules), consumed)
+    if err != nil {
+        return nil, err
+    }
+    err = fmt.Errorf("%w: (%s=%x, %s=%x)", ErrDivergentResults,
+        t.Attestation.EnclaveType, digest, t.Peer.Attestation.EnclaveType, peerDigest)
+    execLogger(ctx, mu, t.RegionID, t.Attestation.EnclaveID).WithAction(consts.TEEExecID).Warn("enclave pair diverged",
+        zap.Stringer("actionID", actionID),
+        zap.String("peer", hex.EncodeToString(t.Peer.Attestation.EnclaveID)),
+        zap.Error(err),
+    )
+    return &TEEExecOutput{
+        RegionID:      t.RegionID,
+        UnitsConsumed: consumed,
+        RefundIssued:  refund,
+        ErrorCode:     ErrorCodeOf(err),
+        Message:       err.Error(),
+    }, nil
+}
+
+// recordAbort reports an execution its enclave aborted on a resource
+// limit, or whose contract failed, as unsuccessful. The receipt and
+// callback carry its digest, the enclave is rewarded for the units
+// consumed and the rest are refunded.
+func (t *TEEExecAction) recordAbort(
+    ctx context.Context,
+    rules chain.Rules,
+    mu state.Mutable,
+    timestamp int64,
+    actor codec.Address,
+    actionID ids.ID,
+    digest []byte,
+    regionRoot ids.ID,
+    limits storage.ExecLimits,
+) (codec.Typed, error) {
+    if err := storage.SetReceipt(ctx, mu, t.RegionID, actionID, &storage.Receipt{
+        Enclave:    t.Attestation.EnclaveID,
+        ResultHash: ids.ID(digest),
+        RegionRoot: regionRoot,
+        Timestamp:  timestamp,
+    }); err != nil {
+        return nil, err
+    }
+    if t.EventID != ids.Empty {
+        if err := completeCallback(ctx, mu, t.EventID, digest, timestamp); err != nil {
+            return nil, err
+        }
+        // An aborted or failed step of a saga fails it
+        if err := advanceSaga(ctx, mu, t.EventID, string(t.ExecResult.ContractAddr), true, timestamp); err != nil {
+            return nil, err
+        }
+    }
+    consumed := t.consumedUnits(rules)
+    refund, err := refundUnusedUnits(ctx, rules, mu, t.RegionID, actor, t.ComputeUnits(rules), consumed)
+    if err != nil {
+        return nil, err
+    }
+    if err := accrueExecReward(ctx, rules, mu, t.RegionID, t.Attestation.EnclaveID, consumed); err != nil {
+        return nil, err
+    }
+    if t.ExecResult.Failed {
+        err = ErrExecFailed
+    } else {
+        err = exhaustedError(limits, &t.ExecResult)
+    }
+    execLogger(ctx, mu, t.RegionID, t.Attestation.EnclaveID).WithAction(consts.TEEExecID).Debug("execution aborted",
+        zap.Stringer("actionID", actionID),
+        zap.Error(err),
+    )
+    return &TEEExecOutput{
+        RegionID:      t.RegionID,
+        UnitsConsumed: consumed,
+        RefundIssued:  refund,
+        ErrorCode:     ErrorCodeOf(err),
+        Message:       err.Error(),
+        Exhausted:     t.ExecResult.Exhausted,
+    }, nil
+}
+
+func (t *TEEExecAction) StateKeys(actor codec.Address, actionID ids.ID) state.Keys {
+    keys := state.Keys{
+        string(storage.HeightKey()):                                state.Read,
+        string(storage.ParamKey(uint8(consts.ParamTimeDrift))):     state.Read,
+        string(storage.ParamKey(uint8(consts.ParamRoughtimeServers))): state.Read,
+        string(storage.ParamKey(uint8(consts.ParamStampQuorum))):      state.Read,
+        string(storage.ParamKey(uint8(consts.ParamStampRadius))):      state.Read,
+        string(storage.RegionKey(t.RegionID)):                      state.Read,
+        string(storage.RegionTemplateKey(t.RegionID)):              state.Read,
+        string(storage.RegionFreezeKey(t.RegionID)):                state.Read,
+        string(storage.ExecLimitsKey(t.RegionID)):                  state.Read,
+        string(storage.EnclaveKey(t.RegionID, t.Attestation.EnclaveID)):       state.Read,
+        string(storage.EnclavePubKeyKey(t.RegionID, t.Attestation.EnclaveID)): state.Read,
+        string(storage.EnclaveExpiryKey(t.RegionID, t.Attestation.EnclaveID)): state.Read,
+        string(storage.BalanceKey(actor)):                          state.Read | state.Write,
+        string(storage.CollectedFeesKey(t.RegionID)):               state.Read | state.Write,
+        string(storage.RewardPoolKey(t.RegionID)):                  state.All,
+        string(storage.EnclaveRewardKey(t.RegionID, t.Attestation.EnclaveID)):  state.All,
+        string(storage.RegionRootKey(t.RegionID)):                  state.All,
+        string(storage.TimeAnchorKey(t.RegionID)):                  state.All,
+        string(storage.ReceiptKey(t.RegionID, actionID)):           state.All,
+        string(storage.AssetKey(storage.RegionAssetID(t.RegionID))): state.Read | state.Write,
+        string(storage.AssetBalanceKey(storage.RegionAssetID(t.RegionID), actor)): state.Read | state.Write,
+    }
+    addPlatformKeys(keys, t.RegionID, t.Attestation.EnclaveID)
+    if len(t.TxData) > 0 {
+        keys[string(storage.RequestKey(t.RegionID, RequestID(t.RegionID, t.TxData)))] = state.All
+    }
+    if t.EventID != ids.Empty {
+        keys[string(storage.CallbackKey(t.EventID))] = state.All
+        keys[string(storage.CallbackEventKey(t.EventID))] = state.All
+        queueKey := storage.EventQueueKey(t.EventID, string(t.ExecResult.ContractAddr))
+        keys[string(queueKey)] = state.All
+        keys[string(storage.EventChargeKey(queueKey))] = state.All
+        keys[string(storage.SponsorPolicyKey(string(t.ExecResult.ContractAddr)))] = state.Read | state.Write
+        addSagaKeys(keys, t.EventID)
+    }
+    if t.Peer != nil {
+        peerID := t.Peer.Attestation.EnclaveID
+        keys[string(storage.EnclaveKey(t.RegionID, peerID))] = state.Read
+        keys[string(storage.EnclavePubKeyKey(t.RegionID, peerID))] = state.Read
+        keys[string(storage.EnclaveExpiryKey(t.RegionID, peerID))] = state.Read
+        addPlatformKeys(keys, t.RegionID, peerID)
+        keys[string(storage.DivergenceKey(t.RegionID, actionID))] = state.All
+    }
+
+    // Add state update keys, and the blobs they store or reference
+    updateKeys := make([]string, 0, len(t.ExecResult.StateUpdates)+len(t.ExecResult.StateRefs))
+    for key, value := range t.ExecResult.StateUpdates {
+        updateKeys = append(updateKeys, key)
+        if len(value) >= consts.MinBlobSize {
+            keys[string(storage.BlobKey(storage.BlobHash(value)))] = state.All
+        }
+    }
+    for key, hash := range t.ExecResult.StateRefs {
+        updateKeys = append(updateKeys, key)
+        blobKey := string(storage.BlobKey(hash))
+        if _, ok := keys[blobKey]; !ok {
+            keys[blobKey] = state.Read
+        }
+    }
+    if t.Peer != nil {
+        for _, hash := range t.Peer.ExecResult.StateRefs {
+            blobKey := string(storage.BlobKey(hash))
+            if _, ok := keys[blobKey]; !ok {
+                keys[blobKey] = state.Read
+            }
+        }
+    }
+    for _, key := range updateKeys {
+        objectID, kvKey, ok := parseObjectKVUpdate(key)
+        if !ok {
+            keys[string(storage.RegionStateKey(t.RegionID, []byte(key)))] = state.All
+            continue
+        }
+        keys[string(storage.ObjectKey(objectID))] = state.Read
+        keys[string(storage.ObjectKVKey(objectID, kvKey))] = state.All
+    }
+    for _, read := range t.ExecResult.Reads {
+        keys[string(storage.ObjectKey(read.Object))] = state.Read
+        kvKey := string(storage.ObjectKVKey(read.Object, read.Key))
+        if _, ok := keys[kvKey]; !ok {
+            keys[kvKey] = state.Read
+        }
+    }
+    for _, call := range t.ExecResult.Calls {
+        keys[string(storage.ObjectKey(call.Callee))] = state.Read
     }
 
     // Add event keys
     for i := range t.ExecResult.Events {
-        keys = append(keys, state.Key("event", t.RegionID, t.ExecResult.ContractAddr, uint64(i)))
+        keys[string(storage.ExecEventKey(t.RegionID, t.ExecResult.ContractAddr, uint64(i)))] = state.All
     }
 
-    return keys
+    return addGateKeys(keys, actor, t)
 }
 
-func (t *TEEExecAction) MaxUnits(chain.Auth) uint64 {
-    return chain.DefaultUnits
+// ComputeUnits charges the declared maximum when it covers the work in the
+// action. Whatever is left over after execution is refunded.
+func (t *TEEExecAction) ComputeUnits(rules chain.Rules) uint64 {
+    consumed := t.consumedUnits(rules)
+    if t.MaxComputeUnits > consumed {
+        return t.MaxComputeUnits
+    }
+    return consumed
 }
 
-// Helper functions
+func (t *TEEExecAction) consumedUnits(rules chain.Rules) uint64 {
+    return t.execUnits(FeeScheduleOf(rules))
+}
 
-func verifyTEESignature(result TEEExecResult, sig, pubKey []byte, enclaveType string) bool {
-    // Implement signature verification based on enclave type
-    return true // placeholder
+// execUnits prices the work in the action with [f].
+func (t *TEEExecAction) execUnits(f FeeSchedule) uint64 {
+    stateBytes := 0
+    updates := len(t.ExecResult.StateUpdates) + len(t.ExecResult.StateRefs)
+    for key, value := range t.ExecResult.StateUpdates {
+        stateBytes += len(key) + len(value)
+        if len(value) >= consts.MinBlobSize {
+            // Stored again as a blob
+            updates++
+            stateBytes += len(value)
+        }
+    }
+    for key := range t.ExecResult.StateRefs {
+        stateBytes += len(key) + ids.IDLen
+    }
+    if t.EventID != ids.Empty {
+        // Queues the event's callback and draws on the object's sponsor
+        updates += 2
+    }
+    // Burns the region's metering asset
+    updates += 2
+    // Reads and calls are checked against state, and priced by size
+    for _, read := range t.ExecResult.Reads {
+        stateBytes += len(read.Object) + len(read.Key) + ids.IDLen
+    }
+    for _, call := range t.ExecResult.Calls {
+        stateBytes += len(call.Caller) + len(call.Callee) + len(call.Function) + 1
+    }
+    // Logs are indexed like events
+    for _, log := range t.ExecResult.Logs {
+        stateBytes += len(log.Topics)*ids.IDLen + len(log.Data)
+    }
+    attestations, stamps := 1, len(t.Attestation.Stamps)
+    if t.Peer != nil {
+        // An aggregated pair is verified with one signature check
+        if !t.Attestation.Flags.Aggregated() {
+            attestations++
+        }
+        stamps += len(t.Peer.Attestation.Stamps)
+    }
+    // Time in the enclave is charged at a fixed rate, so a region's
+    // deadline also bounds the units an execution costs
+    return f.ExecUnits(
+        attestations,
+        stamps,
+        updates,
+        stateBytes,
+        len(t.ExecResult.Events)+len(t.ExecResult.Logs),
+    ) + f.QuoteUnits(LoadOf(t).QuoteBytes) + t.ExecResult.Usage.ElapsedMs*consts.ExecUnitsPerMs
 }
 
-func verifyTimeStamps(stamps []RoughtimeStamp) (uint64, error) {
-    if len(stamps) < 3 {
-        return 0, ErrInvalidTimeStamps
+// VerifyPreconditions checks the action against [regionRoot], the last root
+// attested for its region, using only data the action carries: the result
+// must start from that root and the witness must prove its entries under
+// it. Validators that do not hold the region's state rely on this in place
+// of reading it.
+func (t *TEEExecAction) VerifyPreconditions(regionRoot ids.ID) error {
+    pre := t.ExecResult.PreStateRoot
+    if pre != ids.Empty && pre != regionRoot {
+        return fmt.Errorf("%w: (attested=%s, claimed=%s)", ErrStateRootMismatch, regionRoot, pre)
+    }
+    if t.Witness == nil {
+        return nil
     }
+    if pre == ids.Empty {
+        return fmt.Errorf("%w: no pre-state root", ErrInvalidWitness)
+    }
+    return t.Witness.Verify(pre)
+}
 
-    times := make([]uint64, len(stamps))
-    for i, stamp := range stamps {
-        if !verifyRoughtimeStamp(stamp) {
-            return 0, ErrInvalidTimeStamps
-        }
-        times[i] = stamp.Time
+func (t *TEEExecAction) ActionVersion() uint8 {
+    return t.Version
+}
+
+// ValidRange expires the action once its stamps, and those of its peer
+// execution, are too old to pass the drift check.
+func (t *TEEExecAction) ValidRange(chain.Rules) (int64, int64) {
+    if t.Peer != nil {
+        return stampRange(&t.Attestation, &t.Peer.Attestation)
     }
+    return stampRange(&t.Attestation)
+}
 
-    sort.Slice(times, func(i, j int) bool {
-        return times[i] < times[j]
-    })
+// TEEExecOutput is the result of a TEEExecAction
+type TEEExecOutput struct {
+    RegionID      string           `serialize:"true" json:"region_id"`
+    Success       bool             `serialize:"true" json:"success"`
+    UnitsConsumed uint64           `serialize:"true" json:"units_consumed"`
+    RefundIssued  uint64           `serialize:"true" json:"refund_issued"`
+    // Sponsored is what the target object's sponsor policy paid the actor
+    Sponsored     uint64           `serialize:"true" json:"sponsored"`
+    // Metered is what was burned of the region's metering asset
+    Metered       uint64           `serialize:"true" json:"metered"`
+    ErrorCode     consts.ErrorCode `serialize:"true" json:"error_code"`
+    Message       string           `serialize:"true" json:"message"`
+    // Exhausted is the resource whose limit aborted the execution, if any
+    Exhausted     Resource         `serialize:"true" json:"exhausted,omitempty"`
+}
 
-    return times[len(times)/2], nil
+func (*TEEExecOutput) GetTypeID() uint8 {
+    return consts.TEEExecResultID
 }
 
-func verifyRoughtimeStamp(stamp RoughtimeStamp) bool {
-    // Implement Roughtime signature verification
-    return true // placeholder
+// Helper functions
+
+// parseObjectKVUpdate splits a state update key of the form
+// object:<ID>:kv:<key>. Object IDs may not contain ":kv:".
+func parseObjectKVUpdate(key string) (string, []byte, bool) {
+    rest, ok := strings.CutPrefix(key, objectKVUpdatePrefix)
+    if !ok {
+        return "", nil, false
+    }
+    objectID, kvKey, ok := strings.Cut(rest, objectKVUpdateSep)
+    if !ok || objectID == "" {
+        return "", nil, false
+    }
+    return objectID, []byte(kvKey), true
 }
 
-func isTimeStampValid(stampTime, currentTime uint64) bool {
-    // Check if timestamp is within acceptable range (e.g., 5 minutes)
-    const maxDrift = 5 * 60 // 5 minutes in seconds
-    diff := currentTime - stampTime
-    if diff < 0 {
-        diff = -diff
+func sortedKeys(m map[string][]byte) []string {
+    keys := make([]string, 0, len(m))
+    for key := range m {
+        keys = append(keys, key)
     }
-    return diff <= maxDrift
+    sort.Strings(keys)
+    return keys
 }
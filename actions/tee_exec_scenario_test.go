@@ -0,0 +1,597 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	hconsts "github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+func TestStaleExecutionExpires(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	result := actions.TEEExecResult{ContractAddr: []byte("contract")}
+
+	// An execution stamped ahead of the block waits for it
+	early, err := f.SGX.Attest(testvm.Region, result, f.Timestamp+(consts.MaxTimeDrift+2)*1000)
+	require.NoError(t, err)
+	exec := f.Exec(t, f.SGX, result)
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Early",
+			Actor:       f.SGX.Address,
+			Action:      early,
+			ExpectedErr: testvm.ErrOutsideValidRange,
+		},
+	})
+
+	require.NoError(t, f.Advance(ctx, 1, (consts.MaxTimeDrift+2)*time.Second))
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Stale",
+			Actor:       f.SGX.Address,
+			Action:      exec,
+			ExpectedErr: testvm.ErrOutsideValidRange,
+		},
+		{
+			Name:   "Due",
+			Actor:  f.SGX.Address,
+			Action: early,
+		},
+	})
+}
+
+func TestPreVerify(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	result := actions.TEEExecResult{ContractAddr: []byte("contract")}
+
+	exec := f.Exec(t, f.SGX, result)
+	tampered := *exec
+	tampered.ExecResult.ContractAddr = []byte("other")
+	unregistered, err := testvm.NewEnclave(testvm.EnclaveSGX)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		action      chain.Action
+		timestamp   int64
+		expectedErr error
+	}{
+		{
+			name:      "Valid",
+			action:    exec,
+			timestamp: f.Timestamp,
+		},
+		{
+			name:        "Tampered",
+			action:      &tampered,
+			timestamp:   f.Timestamp,
+			expectedErr: actions.ErrInvalidSignature,
+		},
+		{
+			name:        "Unregistered",
+			action:      f.Exec(t, unregistered, result),
+			timestamp:   f.Timestamp,
+			expectedErr: actions.ErrInvalidEnclave,
+		},
+		{
+			name:        "Stale",
+			action:      exec,
+			timestamp:   f.Timestamp + (consts.MaxTimeDrift+1)*1000,
+			expectedErr: actions.ErrStaleTimeStamp,
+		},
+		{
+			// Actions without attestations are left to execution
+			name:      "Unattested",
+			action:    &actions.StartUploadAction{ObjectID: "big", Size: 5},
+			timestamp: f.Timestamp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, actions.PreVerify(ctx, f.State, tt.timestamp, tt.action), tt.expectedErr)
+		})
+	}
+}
+
+func TestRegionStateRoots(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	before := map[string][]byte{"counter": {1}}
+	after := map[string][]byte{"counter": {2}}
+	result := actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: before,
+		StateRoot:    actions.RegionStateRoot(before),
+	}
+	witness, err := actions.BuildWitness(before, []string{"counter"})
+	require.NoError(t, err)
+	next := actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: after,
+		PreStateRoot: ids.ID{1},
+		StateRoot:    actions.RegionStateRoot(after),
+	}
+	mismatched := f.Exec(t, f.SEV, next)
+	mismatched.Witness = witness
+	next.PreStateRoot = result.StateRoot
+	chained := f.Exec(t, f.SEV, next)
+	chained.Witness = witness
+
+	rootIs := func(root ids.ID) func(context.Context, *testing.T, codec.Typed) {
+		return func(ctx context.Context, t *testing.T, _ codec.Typed) {
+			got, err := storage.GetRegionRoot(ctx, f.State, testvm.Region)
+			require.NoError(t, err)
+			require.Equal(t, root, got)
+		}
+	}
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:      "First",
+			Actor:     f.SGX.Address,
+			Action:    f.Exec(t, f.SGX, result),
+			Assertion: rootIs(result.StateRoot),
+		},
+		{
+			Name:        "PreStateRootMismatch",
+			Actor:       f.SEV.Address,
+			Action:      mismatched,
+			ExpectedErr: actions.ErrStateRootMismatch,
+		},
+		{
+			Name:      "Chained",
+			Actor:     f.SEV.Address,
+			Action:    chained,
+			Assertion: rootIs(next.StateRoot),
+		},
+	})
+}
+
+func TestUserRequestBinding(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	userKey, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	user := auth.NewED25519Address(userKey.PublicKey())
+	require.NoError(t, f.Mint(ctx, user, testvm.Funds))
+	otherKey, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	txData := []byte("increment counter")
+	userSig := actions.SignRequest(userKey, testvm.Region, txData)
+	attest := func(enclave *testvm.Enclave, userSig []byte, result actions.TEEExecResult) *actions.TEEExecAction {
+		exec, err := f.AttestRequest(testvm.Region, enclave, txData, userSig, result)
+		require.NoError(t, err)
+		return exec
+	}
+	first := attest(f.SGX, userSig, counter(1))
+	// The enclave signature binds the request, so it cannot be moved to
+	// other input
+	moved := *first
+	moved.TxData = []byte("other input")
+	queue := &actions.QueueRequestAction{RegionID: testvm.Region, TxData: txData}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// Executions must serve a queued request
+			Name:        "NotQueued",
+			Actor:       user,
+			Action:      first,
+			ExpectedErr: actions.ErrRequestNotFound,
+		},
+		{
+			Name:   "Queue",
+			Actor:  user,
+			Action: queue,
+		},
+		{
+			Name:        "QueueTwice",
+			Actor:       user,
+			Action:      queue,
+			ExpectedErr: actions.ErrRequestExists,
+		},
+		{
+			// Only the requester can authorize its input
+			Name:        "ForgedUserSig",
+			Actor:       user,
+			Action:      attest(f.SGX, actions.SignRequest(otherKey, testvm.Region, txData), counter(1)),
+			ExpectedErr: actions.ErrInvalidUserSig,
+		},
+		{
+			Name:        "MovedInput",
+			Actor:       user,
+			Action:      &moved,
+			ExpectedErr: actions.ErrInvalidSignature,
+		},
+		{
+			Name:   "Execute",
+			Actor:  user,
+			Action: first,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				succeeded(ctx, t, out)
+				request, err := storage.GetRequest(ctx, f.State, testvm.Region, actions.RequestID(testvm.Region, txData))
+				require.NoError(t, err)
+				require.Equal(t, storage.RequestFulfilled, request.Status)
+				require.Equal(t, user, request.Requester)
+			},
+		},
+		{
+			// The other enclave of the pair may attest the same result,
+			// but not a different one
+			Name:   "Peer",
+			Actor:  user,
+			Action: attest(f.SEV, userSig, counter(1)),
+		},
+		{
+			Name:        "PeerDivergent",
+			Actor:       user,
+			Action:      attest(f.SEV, userSig, counter(2)),
+			ExpectedErr: actions.ErrRequestFulfilled,
+		},
+	})
+}
+
+func TestDualExecution(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	require.NoError(t, storage.SetPlatformPolicy(ctx, f.State, testvm.Region, &attestation.PlatformPolicy{
+		Requirements:  []attestation.PlatformRequirement{{Type: attestation.SGX}, {Type: attestation.SEV}},
+		DualExecution: true,
+	}))
+	dual := func(enclave, peer *testvm.Enclave, result, peerResult actions.TEEExecResult) *actions.TEEExecAction {
+		exec, err := f.AttestDual(testvm.Region, enclave, peer, result, peerResult)
+		require.NoError(t, err)
+		return exec
+	}
+	counterIs := func(value byte) func(context.Context, *testing.T, codec.Typed) {
+		return func(ctx context.Context, t *testing.T, _ codec.Typed) {
+			got, err := f.State.GetValue(ctx, storage.RegionStateKey(testvm.Region, []byte("counter")))
+			require.NoError(t, err)
+			require.Equal(t, []byte{value}, got)
+		}
+	}
+	// The peer must sign the same input
+	tampered := dual(f.SGX, f.SEV, counter(2), counter(2))
+	tampered.Peer.ExecResult = counter(3)
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Single",
+			Actor:       f.SGX.Address,
+			Action:      f.Exec(t, f.SGX, counter(1)),
+			ExpectedErr: actions.ErrPeerRequired,
+		},
+		{
+			Name:        "SameEnclave",
+			Actor:       f.SGX.Address,
+			Action:      dual(f.SGX, f.SGX, counter(1), counter(1)),
+			ExpectedErr: actions.ErrPeerSameEnclave,
+		},
+		{
+			Name:   "Dual",
+			Actor:  f.SGX.Address,
+			Action: dual(f.SGX, f.SEV, counter(1), counter(1)),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				succeeded(ctx, t, out)
+				counterIs(1)(ctx, t, out)
+			},
+		},
+		{
+			// Differing results are reported and neither is applied
+			Name:   "Divergent",
+			Actor:  f.SGX.Address,
+			Action: dual(f.SGX, f.SEV, counter(2), counter(3)),
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				output := out.(*actions.TEEExecOutput)
+				require.False(t, output.Success)
+				require.Equal(t, consts.ErrCodeDivergence, output.ErrorCode)
+				counterIs(1)(ctx, t, out)
+			},
+		},
+		{
+			Name:        "PeerTampered",
+			Actor:       f.SGX.Address,
+			Action:      tampered,
+			ExpectedErr: actions.ErrInvalidSignature,
+		},
+	})
+}
+
+func TestAggregatedPair(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	// Aggregation needs BLS-capable enclaves, so the pair gets its own
+	// region
+	sgx, err := testvm.NewBLSEnclave(testvm.EnclaveSGX)
+	require.NoError(err)
+	sev, err := testvm.NewBLSEnclave(testvm.EnclaveSEV)
+	require.NoError(err)
+	require.NoError(f.RegisterRegion(ctx, "eu-west", sgx, sev))
+	require.NoError(f.Mint(ctx, sgx.Address, testvm.Funds))
+	encoded := func(action *actions.TEEExecAction) []byte {
+		p := codec.NewWriter(0, hconsts.NetworkSizeLimit)
+		action.Marshal(p)
+		require.NoError(p.Err())
+		return p.Bytes()
+	}
+
+	dual, err := f.AttestDual("eu-west", sgx, sev, counter(1), counter(1))
+	require.NoError(err)
+	aggregated, err := f.Attest("eu-west", sgx, counter(1))
+	require.NoError(err)
+	require.NoError(sev.AggregatePeer(aggregated, f.Timestamp))
+	require.Less(len(encoded(aggregated)), len(encoded(dual)))
+	require.Less(actions.LoadOf(aggregated).Units(), actions.LoadOf(dual).Units())
+
+	// The flag survives the wire
+	decoded, err := actions.UnmarshalTEEExecAction(codec.NewReader(encoded(aggregated), hconsts.NetworkSizeLimit))
+	require.NoError(err)
+	require.True(decoded.(*actions.TEEExecAction).Attestation.Flags.Aggregated())
+
+	forged := *aggregated
+	forged.Attestation.Signature = sgx.Sign([]byte("other"))
+	// Each enclave of a diverging pair signed its own result, so the pair
+	// cannot be aggregated
+	divergent := *aggregated
+	divergent.Peer = &actions.PeerExecution{
+		ExecResult:  actions.TEEExecResult{ContractAddr: []byte("contract")},
+		Attestation: aggregated.Peer.Attestation,
+	}
+	alone := *aggregated
+	alone.Peer = nil
+	// Enclaves registered with ed25519 keys cannot take part
+	plain, err := f.AttestDual(testvm.Region, f.SGX, f.SEV, counter(1), counter(1))
+	require.NoError(err)
+	plain.Attestation.Flags = attestation.FlagAggregated
+	plain.Peer.Attestation.Signature = nil
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Forged",
+			Actor:       sgx.Address,
+			Action:      &forged,
+			ExpectedErr: attestation.ErrAggregateSignature,
+		},
+		{
+			Name:        "Divergent",
+			Actor:       sgx.Address,
+			Action:      &divergent,
+			ExpectedErr: actions.ErrAggregateDivergent,
+		},
+		{
+			Name:        "WithoutPeer",
+			Actor:       sgx.Address,
+			Action:      &alone,
+			ExpectedErr: actions.ErrAggregateWithoutPeer,
+		},
+		{
+			Name:      "Aggregated",
+			Actor:     sgx.Address,
+			Action:    aggregated,
+			Assertion: succeeded,
+		},
+		{
+			Name:        "NotBLSCapable",
+			Actor:       f.SGX.Address,
+			Action:      plain,
+			ExpectedErr: attestation.ErrNotBLSCapable,
+		},
+	})
+}
+
+func TestExecutionReceipt(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	result := counter(1)
+	result.StateRoot = ids.ID{7}
+	digest, err := result.Digest()
+	require.NoError(t, err)
+	actionID := ids.GenerateTestID()
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:     "Execute",
+			Actor:    f.SGX.Address,
+			ActionID: actionID,
+			Action:   f.Exec(t, f.SGX, result),
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				receipt, err := storage.GetReceipt(ctx, f.State, testvm.Region, actionID)
+				require.NoError(t, err)
+				require.Equal(t, &storage.Receipt{
+					Enclave:    f.SGX.ID(),
+					ResultHash: ids.ID(digest),
+					RegionRoot: ids.ID{7},
+					Timestamp:  f.Timestamp,
+				}, receipt)
+			},
+		},
+	})
+}
+
+func TestObjectCalls(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	f.Objects(t, "router", "token", "ledger")
+	require.NoError(t, storage.SetObjectKV(ctx, f.State, "token", []byte("supply"), []byte{10}))
+
+	// router calls token, which calls ledger; each touches its own state
+	trace := func() actions.TEEExecResult {
+		require := require.New(t)
+		tracer := actions.NewCallTracer(f.State, "router", "transfer")
+		require.NoError(tracer.Set([]byte("last"), []byte("transfer")))
+		require.NoError(tracer.Call("token", "debit"))
+		supply, ok, err := tracer.Get(ctx, []byte("supply"))
+		require.NoError(err)
+		require.True(ok)
+		require.NoError(tracer.Set([]byte("supply"), []byte{supply[0] - 1}))
+		require.NoError(tracer.Call("ledger", "record"))
+		require.NoError(tracer.Set([]byte("entries"), []byte{1}))
+		require.NoError(tracer.Return())
+		require.NoError(tracer.Return())
+		result, err := tracer.Result()
+		require.NoError(err)
+		return result
+	}
+	result := trace()
+	require.Equal(t, []actions.CallFrame{
+		{Callee: "router", Function: "transfer"},
+		{Caller: "router", Callee: "token", Function: "debit", Depth: 1},
+		{Caller: "token", Callee: "ledger", Function: "record", Depth: 2},
+	}, result.Calls)
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Execute",
+			Actor:  f.Actor,
+			Action: f.Exec(t, f.SGX, result),
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				for _, kv := range []struct {
+					object, key string
+					value       []byte
+				}{
+					{"router", "last", []byte("transfer")},
+					{"token", "supply", []byte{9}},
+					{"ledger", "entries", []byte{1}},
+				} {
+					got, ok, err := storage.GetObjectKV(ctx, f.State, kv.object, []byte(kv.key))
+					require.NoError(t, err)
+					require.True(t, ok)
+					require.Equal(t, kv.value, got)
+				}
+			},
+		},
+	})
+
+	// Traces taken past the first execution read its state
+	undeclared := trace()
+	undeclared.Calls = undeclared.Calls[:2]
+	forged := trace()
+	forged.Calls[2].Caller = "router"
+	missing := trace()
+	missing.Calls[2].Callee = "missing"
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			// The result read supply before it changed, so it cannot
+			// apply again
+			Name:        "Replay",
+			Actor:       f.Actor,
+			Action:      f.Exec(t, f.SGX, result),
+			ExpectedErr: actions.ErrStaleRead,
+		},
+		{
+			// Writes to objects outside the trace are rejected
+			Name:        "UndeclaredObject",
+			Actor:       f.Actor,
+			Action:      f.Exec(t, f.SGX, undeclared),
+			ExpectedErr: actions.ErrUndeclaredObject,
+		},
+		{
+			// Every frame must be made by the callee of its parent
+			Name:        "ForgedCaller",
+			Actor:       f.Actor,
+			Action:      f.Exec(t, f.SGX, forged),
+			ExpectedErr: actions.ErrInvalidCallTrace,
+		},
+		{
+			Name:        "MissingCallee",
+			Actor:       f.Actor,
+			Action:      f.Exec(t, f.SGX, missing),
+			ExpectedErr: actions.ErrObjectNotFound,
+		},
+	})
+}
+
+func TestExecLimits(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	require.NoError(t, storage.SetExecLimits(ctx, f.State, testvm.Region, &storage.ExecLimits{MaxMemoryPages: 16}))
+
+	limits, err := storage.GetExecLimits(ctx, f.State, testvm.Region)
+	require.NoError(t, err)
+	require.Equal(t, uint32(16), limits.MaxMemoryPages)
+	require.Equal(t, uint64(consts.DefaultExecDeadlineMs), limits.DeadlineMs)
+
+	// Time in the enclave is charged
+	result := counter(1)
+	result.Usage = actions.ResourceUsage{MemoryPages: 16, ValueStack: 512, HostCallDepth: 2, ElapsedMs: 40}
+	timed := f.Exec(t, f.SGX, result)
+	untimed := *timed
+	untimed.ExecResult.Usage.ElapsedMs = 0
+
+	// Usage past a limit is rejected
+	result.Usage.MemoryPages = 17
+	overLimit := f.Exec(t, f.SGX, result)
+
+	// An aborted execution may not apply anything, and is reported with a
+	// receipt
+	result.Usage = actions.ResourceUsage{ElapsedMs: consts.DefaultExecDeadlineMs}
+	result.Exhausted = actions.ResourceDeadline
+	abortedWithUpdates := f.Exec(t, f.SGX, result)
+	result.StateUpdates = nil
+	aborted := f.Exec(t, f.SGX, result)
+	actionID := ids.GenerateTestID()
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Timed",
+			Actor:  f.SGX.Address,
+			Action: timed,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				succeeded(ctx, t, out)
+				require.Equal(t, untimed.ComputeUnits(nil)+40*consts.ExecUnitsPerMs, out.(*actions.TEEExecOutput).UnitsConsumed)
+			},
+		},
+		{
+			Name:        "AbortedWithUpdates",
+			Actor:       f.SGX.Address,
+			Action:      abortedWithUpdates,
+			ExpectedErr: actions.ErrOutOfResources,
+		},
+		{
+			Name:     "Aborted",
+			Actor:    f.SGX.Address,
+			ActionID: actionID,
+			Action:   aborted,
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				output := out.(*actions.TEEExecOutput)
+				require.False(output.Success)
+				require.Equal(consts.ErrCodeOutOfResources, output.ErrorCode)
+				require.Equal(actions.ResourceDeadline, output.Exhausted)
+				receipt, err := storage.GetReceipt(ctx, f.State, testvm.Region, actionID)
+				require.NoError(err)
+				require.Equal(f.SGX.ID(), receipt.Enclave)
+			},
+		},
+	})
+
+	// The error names the limit that was passed
+	_, err = f.Run(ctx, f.SGX.Address, overLimit)
+	require.ErrorIs(t, err, actions.ErrOutOfResources)
+	var oor *actions.OutOfResourcesError
+	require.ErrorAs(t, err, &oor)
+	require.Equal(t, actions.ResourceMemory, oor.Resource)
+	require.Equal(t, uint64(16), oor.Limit)
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/rhombus-tech/hypersdk/x/contracts/runtime/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/attestation"
+	vmconsts "github.com/rhombus-tech/vm/consts"
+)
+
+func TestTEEExecCaps(t *testing.T) {
+	stamps := func(n int) []attestation.Stamp {
+		s := make([]attestation.Stamp, n)
+		for i := range s {
+			s[i] = attestation.Stamp{ServerID: fmt.Sprintf("s%d", i), Time: uint64(i), Signature: make([]byte, ed25519.SignatureLen)}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name        string
+		events      int
+		updates     int
+		stamps      int
+		expectedErr error
+	}{
+		{
+			name:   "EventsAtCap",
+			events: vmconsts.MaxEventsPerExec,
+		},
+		{
+			name:        "EventsOverCap",
+			events:      vmconsts.MaxEventsPerExec + 1,
+			expectedErr: ErrTooManyEvents,
+		},
+		{
+			name:    "StateUpdatesAtCap",
+			updates: vmconsts.MaxStateUpdates,
+		},
+		{
+			name:        "StateUpdatesOverCap",
+			updates:     vmconsts.MaxStateUpdates + 1,
+			expectedErr: ErrTooManyStateUpdates,
+		},
+		{
+			name:   "StampsAtCap",
+			stamps: vmconsts.MaxTimeStampsCount,
+		},
+		{
+			name:        "StampsOverCap",
+			stamps:      vmconsts.MaxTimeStampsCount + 1,
+			expectedErr: ErrTooManyTimeStamps,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			exec := benchTEEExec(0)
+			exec.ExecResult.Events = make([]events.Event, tt.events)
+			for i := 0; i < tt.updates; i++ {
+				exec.ExecResult.StateUpdates[fmt.Sprintf("key-%d", i)] = []byte{1}
+			}
+			exec.Attestation.Stamps = stamps(tt.stamps)
+
+			p := codec.NewWriter(0, consts.NetworkSizeLimit)
+			exec.Marshal(p)
+			require.NoError(p.Err())
+			decoded, err := UnmarshalTEEExecAction(codec.NewReader(p.Bytes(), consts.NetworkSizeLimit))
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr != nil {
+				return
+			}
+			got := decoded.(*TEEExecAction)
+			require.Len(got.ExecResult.Events, tt.events)
+			require.Len(got.ExecResult.StateUpdates, tt.updates)
+			require.Len(got.Attestation.Stamps, tt.stamps)
+		})
+	}
+}
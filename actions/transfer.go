@@ -17,10 +17,7 @@ import (
 	mconsts "github.com/ava-labs/hypersdk-starter-kit/consts"
 )
 
-const (
-	TransferComputeUnits = 1
-	MaxMemoSize          = 256
-)
+const MaxMemoSize = 256
 
 var (
 	ErrOutputValueZero                 = errors.New("value is zero")
@@ -44,20 +41,24 @@ func (*Transfer) GetTypeID() uint8 {
 }
 
 func (t *Transfer) StateKeys(actor codec.Address) state.Keys {
-	return state.Keys{
+	return addGateKeys(state.Keys{
 		string(storage.BalanceKey(actor)): state.Read | state.Write,
 		string(storage.BalanceKey(t.To)):  state.All,
-	}
+	}, actor, t)
 }
 
 func (t *Transfer) Execute(
 	ctx context.Context,
-	_ chain.Rules,
+	rules chain.Rules,
 	mu state.Mutable,
-	_ int64,
+	timestamp int64,
 	actor codec.Address,
 	_ ids.ID,
 ) (codec.Typed, error) {
+	if err := checkGates(ctx, rules, mu, timestamp, actor, t); err != nil {
+		return nil, err
+	}
+
 	if t.Value == 0 {
 		return nil, ErrOutputValueZero
 	}
@@ -79,8 +80,8 @@ func (t *Transfer) Execute(
 	}, nil
 }
 
-func (*Transfer) ComputeUnits(chain.Rules) uint64 {
-	return TransferComputeUnits
+func (*Transfer) ComputeUnits(rules chain.Rules) uint64 {
+	return FeeScheduleOf(rules).BaseUnits
 }
 
 func (*Transfer) ValidRange(chain.Rules) (int64, int64) {
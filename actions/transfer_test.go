@@ -29,6 +29,7 @@ func TestTransferAction(t *testing.T) {
 				To:    codec.EmptyAddress,
 				Value: 0,
 			},
+			State:       chaintest.NewInMemoryStore(),
 			ExpectedErr: ErrOutputValueZero,
 		},
 		{
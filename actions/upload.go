@@ -0,0 +1,383 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrUploadExists     = errors.New("upload already in progress")
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrUploadExpired    = errors.New("upload expired")
+	ErrNotUploadOwner   = errors.New("upload owned by another account")
+	ErrChunkOutOfOrder  = errors.New("chunk out of order")
+	ErrInvalidChunk     = errors.New("invalid chunk size")
+	ErrUploadIncomplete = errors.New("upload incomplete")
+	ErrInvalidCode      = errors.New("invalid code")
+	ErrEmptyUpload      = errors.New("empty upload")
+
+	_ chain.Action = (*StartUploadAction)(nil)
+	_ chain.Action = (*AppendChunkAction)(nil)
+	_ chain.Action = (*CommitObjectAction)(nil)
+)
+
+// CodeValidator checks assembled code before a chunked upload is committed.
+type CodeValidator interface {
+	ValidateCode(code []byte) error
+}
+
+type nopCodeValidator struct{}
+
+func (nopCodeValidator) ValidateCode([]byte) error { return nil }
+
+var codeValidator CodeValidator = nopCodeValidator{}
+
+// SetCodeValidator installs the validator run by [CommitObjectAction]. The
+// vm package installs its format-aware validator at init.
+func SetCodeValidator(v CodeValidator) {
+	codeValidator = v
+}
+
+// StartUploadAction opens a chunked upload of [Size] bytes of code for a new
+// object, for code too large to fit in a single CreateObjectAction. An
+// abandoned upload of the same object is reclaimed.
+type StartUploadAction struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	Size     uint32 `serialize:"true" json:"size"`
+}
+
+func (*StartUploadAction) GetTypeID() uint8 {
+	return consts.StartUploadID
+}
+
+func (a *StartUploadAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := uploadKeys(a.ObjectID)
+	keys[string(storage.ObjectKey(a.ObjectID))] = state.Read
+	return addGateKeys(keys, actor, a)
+}
+
+func (a *StartUploadAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.StartUploadID, "", a.ObjectID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	if len(a.ObjectID) == 0 || len(a.ObjectID) > consts.MaxIDLength {
+		return nil, ErrInvalidID
+	}
+	if a.Size == 0 {
+		return nil, ErrEmptyUpload
+	}
+	if a.Size > consts.MaxCodeSize {
+		return nil, ErrCodeTooLarge
+	}
+	obj, err := storage.GetObject(ctx, mu, a.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if obj != nil {
+		return nil, ErrObjectExists
+	}
+	prev, err := storage.GetUpload(ctx, mu, a.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		if !prev.Expired(timestamp) {
+			return nil, ErrUploadExists
+		}
+		if err := storage.DeleteUpload(ctx, mu, a.ObjectID, prev); err != nil {
+			return nil, err
+		}
+	}
+
+	u := &storage.Upload{
+		Owner:  actor,
+		Size:   a.Size,
+		Expiry: timestamp + consts.UploadTimeout,
+	}
+	if err := storage.SetUpload(ctx, mu, a.ObjectID, u); err != nil {
+		return nil, err
+	}
+	return &StartUploadResult{
+		ObjectID:  a.ObjectID,
+		Reclaimed: prev != nil,
+		Expiry:    u.Expiry,
+	}, nil
+}
+
+func (*StartUploadAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.BaseUnits + schedule.StateUpdateUnits
+}
+
+func (*StartUploadAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type StartUploadResult struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	// Reclaimed is set if an abandoned upload of the object was removed.
+	Reclaimed bool  `serialize:"true" json:"reclaimed"`
+	Expiry    int64 `serialize:"true" json:"expiry"`
+}
+
+func (*StartUploadResult) GetTypeID() uint8 {
+	return consts.StartUploadResultID
+}
+
+// AppendChunkAction appends the next chunk of an upload and extends its
+// expiry. Chunks must be sent in order starting at index zero.
+type AppendChunkAction struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	Index    uint16 `serialize:"true" json:"index"`
+	Data     []byte `serialize:"true" json:"data"`
+}
+
+func (*AppendChunkAction) GetTypeID() uint8 {
+	return consts.AppendChunkID
+}
+
+func (a *AppendChunkAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	return addGateKeys(state.Keys{
+		string(storage.UploadKey(a.ObjectID)):               state.Read | state.Write,
+		string(storage.UploadChunkKey(a.ObjectID, a.Index)): state.All,
+	}, actor, a)
+}
+
+func (a *AppendChunkAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.AppendChunkID, "", a.ObjectID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	u, err := ownedUpload(ctx, mu, a.ObjectID, timestamp, actor)
+	if err != nil {
+		return nil, err
+	}
+	if a.Index != u.Chunks {
+		return nil, fmt.Errorf("%w: (expected=%d, got=%d)", ErrChunkOutOfOrder, u.Chunks, a.Index)
+	}
+	if len(a.Data) == 0 || len(a.Data) > consts.MaxChunkSize || int(a.Index) >= consts.MaxUploadChunks {
+		return nil, ErrInvalidChunk
+	}
+	if uint64(u.Received)+uint64(len(a.Data)) > uint64(u.Size) {
+		return nil, ErrCodeTooLarge
+	}
+	if err := storage.SetUploadChunk(ctx, mu, a.ObjectID, a.Index, a.Data); err != nil {
+		return nil, err
+	}
+	u.Received += uint32(len(a.Data))
+	u.Chunks++
+	u.Expiry = timestamp + consts.UploadTimeout
+	if err := storage.SetUpload(ctx, mu, a.ObjectID, u); err != nil {
+		return nil, err
+	}
+	return &AppendChunkResult{
+		ObjectID: a.ObjectID,
+		Index:    a.Index,
+		Received: u.Received,
+		Size:     u.Size,
+	}, nil
+}
+
+func (a *AppendChunkAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	return schedule.StorageUnits(len(a.Data), 0) + schedule.StateUpdateUnits
+}
+
+func (*AppendChunkAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type AppendChunkResult struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	Index    uint16 `serialize:"true" json:"index"`
+	Received uint32 `serialize:"true" json:"received"`
+	Size     uint32 `serialize:"true" json:"size"`
+}
+
+func (*AppendChunkResult) GetTypeID() uint8 {
+	return consts.AppendChunkResultID
+}
+
+// CommitObjectAction assembles a complete upload, validates the code and
+// creates the object with [Storage] as its initial storage and [Schemas]
+// as the parameter schemas of its exported functions. The upload and its
+// chunks are removed.
+type CommitObjectAction struct {
+	ObjectID string           `serialize:"true" json:"object_id"`
+	Storage  []byte           `serialize:"true" json:"storage"`
+	Schemas  []FunctionSchema `serialize:"true" json:"schemas"`
+}
+
+func (*CommitObjectAction) GetTypeID() uint8 {
+	return consts.CommitObjectID
+}
+
+func (a *CommitObjectAction) StateKeys(actor codec.Address, _ ids.ID) state.Keys {
+	keys := uploadKeys(a.ObjectID)
+	keys[string(storage.ObjectKey(a.ObjectID))] = state.All
+	keys[string(storage.ObjectCreatorKey(a.ObjectID))] = state.All
+	for _, s := range a.Schemas {
+		keys[string(storage.ParamSchemaKey(a.ObjectID, s.Function))] = state.All
+	}
+	return addGateKeys(keys, actor, a)
+}
+
+func (a *CommitObjectAction) Execute(
+	ctx context.Context,
+	rules chain.Rules,
+	mu state.Mutable,
+	timestamp int64,
+	actor codec.Address,
+	_ ids.ID,
+) (_ codec.Typed, err error) {
+	defer wrapExecError(&err, consts.CommitObjectID, "", a.ObjectID)
+
+	if err := checkGates(ctx, rules, mu, timestamp, actor, a); err != nil {
+		return nil, err
+	}
+
+	if len(a.Storage) > consts.MaxStorageSize {
+		return nil, ErrStorageTooLarge
+	}
+	if err := validateSchemas(a.Schemas); err != nil {
+		return nil, err
+	}
+	u, err := ownedUpload(ctx, mu, a.ObjectID, timestamp, actor)
+	if err != nil {
+		return nil, err
+	}
+	if u.Received != u.Size {
+		return nil, fmt.Errorf("%w: (received=%d, size=%d)", ErrUploadIncomplete, u.Received, u.Size)
+	}
+	obj, err := storage.GetObject(ctx, mu, a.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if obj != nil {
+		return nil, ErrObjectExists
+	}
+	code, err := storage.AssembleUpload(ctx, mu, a.ObjectID, u)
+	if err != nil {
+		return nil, err
+	}
+	if err := codeValidator.ValidateCode(code); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCode, err)
+	}
+	stored, err := storage.EncodeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.SetObject(ctx, mu, a.ObjectID, map[string][]byte{
+		"code":    stored,
+		"storage": a.Storage,
+	}); err != nil {
+		return nil, err
+	}
+	if err := storage.SetObjectCreator(ctx, mu, a.ObjectID, actor); err != nil {
+		return nil, err
+	}
+	for _, s := range a.Schemas {
+		if err := storage.SetParamSchema(ctx, mu, a.ObjectID, s.Function, s.encode()); err != nil {
+			return nil, err
+		}
+	}
+	if err := storage.DeleteUpload(ctx, mu, a.ObjectID, u); err != nil {
+		return nil, err
+	}
+	return &CommitObjectResult{
+		ObjectID: a.ObjectID,
+		CodeSize: u.Size,
+	}, nil
+}
+
+func (a *CommitObjectAction) ComputeUnits(rules chain.Rules) uint64 {
+	schedule := FeeScheduleOf(rules)
+	// Code was charged as it was appended; only the chunk removals and the
+	// initial storage are charged here.
+	return schedule.StorageUnits(0, len(a.Storage)) +
+		(consts.MaxUploadChunks+uint64(len(a.Schemas)))*schedule.StateUpdateUnits
+}
+
+func (*CommitObjectAction) ValidRange(chain.Rules) (int64, int64) {
+	// Returning -1, -1 means that the action is always valid.
+	return -1, -1
+}
+
+type CommitObjectResult struct {
+	ObjectID string `serialize:"true" json:"object_id"`
+	CodeSize uint32 `serialize:"true" json:"code_size"`
+}
+
+func (*CommitObjectResult) GetTypeID() uint8 {
+	return consts.CommitObjectResultID
+}
+
+// uploadKeys declares an upload and every chunk it may hold. Chunk counts
+// are bounded by [consts.MaxUploadChunks], so actions that reclaim or
+// commit an upload can declare its keys without reading it first.
+func uploadKeys(objectID string) state.Keys {
+	keys := state.Keys{
+		string(storage.UploadKey(objectID)): state.All,
+	}
+	for i := uint16(0); i < consts.MaxUploadChunks; i++ {
+		keys[string(storage.UploadChunkKey(objectID, i))] = state.Read | state.Write
+	}
+	return keys
+}
+
+// ownedUpload returns the live upload of [objectID] if [actor] started it.
+func ownedUpload(
+	ctx context.Context,
+	im state.Immutable,
+	objectID string,
+	timestamp int64,
+	actor codec.Address,
+) (*storage.Upload, error) {
+	u, err := storage.GetUpload(ctx, im, objectID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUploadNotFound
+	}
+	if u.Expired(timestamp) {
+		return nil, ErrUploadExpired
+	}
+	if u.Owner != actor {
+		return nil, ErrNotUploadOwner
+	}
+	return u, nil
+}
@@ -0,0 +1,204 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+func TestChunkedUpload(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	owner := codectest.NewRandomAddress()
+	other := codectest.NewRandomAddress()
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Start",
+			Actor:  owner,
+			Action: &actions.StartUploadAction{ObjectID: "big", Size: 5},
+		},
+		{
+			Name:        "StartTaken",
+			Actor:       other,
+			Action:      &actions.StartUploadAction{ObjectID: "big", Size: 5},
+			ExpectedErr: actions.ErrUploadExists,
+		},
+		{
+			Name:        "OutOfOrder",
+			Actor:       owner,
+			Action:      &actions.AppendChunkAction{ObjectID: "big", Index: 1, Data: []byte{4, 5}},
+			ExpectedErr: actions.ErrChunkOutOfOrder,
+		},
+		{
+			Name:   "FirstChunk",
+			Actor:  owner,
+			Action: &actions.AppendChunkAction{ObjectID: "big", Index: 0, Data: []byte{1, 2, 3}},
+		},
+		{
+			Name:        "CommitIncomplete",
+			Actor:       owner,
+			Action:      &actions.CommitObjectAction{ObjectID: "big"},
+			ExpectedErr: actions.ErrUploadIncomplete,
+		},
+		{
+			Name:   "LastChunk",
+			Actor:  owner,
+			Action: &actions.AppendChunkAction{ObjectID: "big", Index: 1, Data: []byte{4, 5}},
+		},
+		{
+			Name:            "Commit",
+			Actor:           owner,
+			Action:          &actions.CommitObjectAction{ObjectID: "big", Storage: []byte{9}},
+			ExpectedOutputs: &actions.CommitObjectResult{ObjectID: "big", CodeSize: 5},
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				require := require.New(t)
+				obj, err := storage.GetObject(ctx, v.State, "big")
+				require.NoError(err)
+				require.Equal([]byte{1, 2, 3, 4, 5}, obj["code"])
+				upload, err := storage.GetUpload(ctx, v.State, "big")
+				require.NoError(err)
+				require.Nil(upload)
+			},
+		},
+	})
+}
+
+func TestAbandonedUploadReclaimed(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	owner := codectest.NewRandomAddress()
+	other := codectest.NewRandomAddress()
+
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Start",
+			Actor:  owner,
+			Action: &actions.StartUploadAction{ObjectID: "big", Size: 5},
+		},
+		{
+			Name:   "FirstChunk",
+			Actor:  owner,
+			Action: &actions.AppendChunkAction{ObjectID: "big", Index: 0, Data: []byte{1}},
+		},
+	})
+	require.NoError(t, v.Advance(ctx, 1, 11*time.Minute))
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:        "Expired",
+			Actor:       owner,
+			Action:      &actions.AppendChunkAction{ObjectID: "big", Index: 1, Data: []byte{2}},
+			ExpectedErr: actions.ErrUploadExpired,
+		},
+		{
+			Name:   "Reclaim",
+			Actor:  other,
+			Action: &actions.StartUploadAction{ObjectID: "big", Size: 5},
+			Assertion: func(ctx context.Context, t *testing.T, out codec.Typed) {
+				require := require.New(t)
+				require.True(out.(*actions.StartUploadResult).Reclaimed)
+				_, err := v.State.GetValue(ctx, storage.UploadChunkKey("big", 0))
+				require.Error(err)
+			},
+		},
+	})
+}
+
+func TestParamSchema(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	owner := codectest.NewRandomAddress()
+
+	transfer := actions.FunctionSchema{
+		Function: "transfer",
+		Params:   []actions.ParamType{actions.ParamAddress, actions.ParamUint64, actions.ParamString},
+	}
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Start",
+			Actor:  owner,
+			Action: &actions.StartUploadAction{ObjectID: "token", Size: 3},
+		},
+		{
+			Name:   "Chunk",
+			Actor:  owner,
+			Action: &actions.AppendChunkAction{ObjectID: "token", Index: 0, Data: []byte{1, 2, 3}},
+		},
+		{
+			Name:        "DuplicateFunction",
+			Actor:       owner,
+			Action:      &actions.CommitObjectAction{ObjectID: "token", Schemas: []actions.FunctionSchema{transfer, transfer}},
+			ExpectedErr: actions.ErrInvalidSchema,
+		},
+		{
+			Name:   "Commit",
+			Actor:  owner,
+			Action: &actions.CommitObjectAction{ObjectID: "token", Schemas: []actions.FunctionSchema{transfer, {Function: "pause"}}},
+		},
+	})
+
+	schema, ok, err := storage.GetParamSchema(ctx, v.State, "token", "transfer")
+	require.NoError(t, err)
+	require.True(t, ok)
+	pause, ok, err := storage.GetParamSchema(ctx, v.State, "token", "pause")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, pause)
+	_, ok, err = storage.GetParamSchema(ctx, v.State, "token", "mint")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	to := codectest.NewRandomAddress()
+	params := append(to[:], 0, 0, 0, 0, 0, 0, 0, 100)
+	params = append(params, 0, 2, 'h', 'i')
+	tests := []struct {
+		name        string
+		schema      []byte
+		params      []byte
+		expectedErr error
+	}{
+		{
+			name:   "Valid",
+			schema: schema,
+			params: params,
+		},
+		{
+			name:        "Truncated",
+			schema:      schema,
+			params:      params[:len(params)-1],
+			expectedErr: actions.ErrParamMismatch,
+		},
+		{
+			name:        "TrailingBytes",
+			schema:      schema,
+			params:      append(params[:len(params):len(params)], 0),
+			expectedErr: actions.ErrParamMismatch,
+		},
+		{
+			name:   "NoParams",
+			schema: pause,
+		},
+		{
+			name:        "UnexpectedParams",
+			schema:      pause,
+			params:      []byte{1},
+			expectedErr: actions.ErrParamMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, actions.ValidateParams(tt.schema, tt.params), tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// GetVerifiedTime returns the time, in unix seconds, actions and contracts
+// of [regionID] act on in place of the local clock, which differs between
+// validators. It is the last trusted Roughtime median an execution in the
+// region committed, advanced by the block time elapsed since. Before the
+// region commits one, and for an empty [regionID], it is the time of the
+// last accepted block. Both come from state, so every validator and
+// enclave executing at the same state sees the same time.
+func GetVerifiedTime(ctx context.Context, im state.Immutable, regionID string) (uint64, error) {
+	blockTime, err := storage.GetTimestamp(ctx, im)
+	if err != nil {
+		return 0, err
+	}
+	var anchor *storage.TimeAnchor
+	if regionID != "" {
+		if anchor, err = storage.GetTimeAnchor(ctx, im, regionID); err != nil {
+			return 0, err
+		}
+	}
+	return verifiedTime(anchor, blockTime), nil
+}
+
+// verifiedTime is the time, in unix seconds, [anchor] gives at [blockTime],
+// in unix milliseconds.
+func verifiedTime(anchor *storage.TimeAnchor, blockTime int64) uint64 {
+	if anchor == nil {
+		return uint64(max(blockTime, 0) / 1000)
+	}
+	return anchor.Median + uint64(max(blockTime-anchor.BlockTime, 0)/1000)
+}
+
+// commitVerifiedTime anchors the verified time of [regionID] to [median],
+// the trusted Roughtime median of an attestation executed in a block at
+// [timestamp]. Medians behind the region's verified time are ignored, so
+// it never moves back.
+func commitVerifiedTime(ctx context.Context, mu state.Mutable, regionID string, median uint64, timestamp int64) error {
+	anchor, err := storage.GetTimeAnchor(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	if anchor != nil && verifiedTime(anchor, timestamp) >= median {
+		return nil
+	}
+	return storage.SetTimeAnchor(ctx, mu, regionID, &storage.TimeAnchor{
+		Median:    median,
+		BlockTime: timestamp,
+	})
+}
+
+// HostTime implements the time host function of a runtime executing
+// object code of a region in an enclave. The runtime binds its
+// verified_time host function to [HostTime.VerifiedTime], so contracts
+// read [GetVerifiedTime] as of the state the execution starts from and
+// every enclave executing the same input sees the same time.
+type HostTime struct {
+	im       state.Immutable
+	regionID string
+}
+
+func NewHostTime(im state.Immutable, regionID string) *HostTime {
+	return &HostTime{im: im, regionID: regionID}
+}
+
+// VerifiedTime returns the region's verified time in unix seconds.
+func (h *HostTime) VerifiedTime(ctx context.Context) (uint64, error) {
+	return GetVerifiedTime(ctx, h.im, h.regionID)
+}
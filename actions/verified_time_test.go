@@ -0,0 +1,58 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestVerifiedTime(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	store := chaintest.NewInMemoryStore()
+	const region = "us-east"
+
+	setBlockTime := func(ms int64) {
+		require.NoError(store.Insert(ctx, storage.TimestampKey(), binary.BigEndian.AppendUint64(nil, uint64(ms))))
+	}
+	now := func(regionID string) uint64 {
+		v, err := GetVerifiedTime(ctx, store, regionID)
+		require.NoError(err)
+		return v
+	}
+
+	// Without an anchor the block time is the verified time
+	setBlockTime(1_000_500)
+	require.Equal(uint64(1000), now(region))
+	require.Equal(uint64(1000), now(""))
+
+	// A median anchors the region, which advances with the block time
+	require.NoError(commitVerifiedTime(ctx, store, region, 990, 1_000_500))
+	require.Equal(uint64(990), now(region))
+	setBlockTime(1_010_500)
+	require.Equal(uint64(1000), now(region))
+	require.Equal(uint64(1010), now(""))
+
+	// A median behind the region's verified time is ignored
+	require.NoError(commitVerifiedTime(ctx, store, region, 995, 1_010_500))
+	require.Equal(uint64(1000), now(region))
+	require.NoError(commitVerifiedTime(ctx, store, region, 1005, 1_010_500))
+	require.Equal(uint64(1005), now(region))
+
+	// Every region has its own anchor
+	require.Equal(uint64(1010), now("eu-west"))
+
+	host := NewHostTime(store, region)
+	v, err := host.VerifiedTime(ctx)
+	require.NoError(err)
+	require.Equal(uint64(1005), v)
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrUnsupportedActionVersion = errors.New("unsupported action version")
+
+// Versioned is implemented by actions whose wire format starts with a
+// version byte. Unknown versions are rejected while unmarshaling; known
+// versions are rejected during execution until their activation height.
+type Versioned interface {
+	ActionVersion() uint8
+}
+
+// VersionRules is implemented by chain rules that set the heights action
+// versions activate at. Other rules follow [consts.ActionVersionSchedule].
+type VersionRules interface {
+	GetActionVersionSchedule() []consts.VersionActivation
+}
+
+// ActionVersionSchedule returns the version activation schedule of [rules].
+func ActionVersionSchedule(rules chain.Rules) []consts.VersionActivation {
+	if r, ok := rules.(VersionRules); ok {
+		return r.GetActionVersionSchedule()
+	}
+	return consts.ActionVersionSchedule
+}
+
+// packVersion writes [v]. An unset version is written as is, and rejected
+// when read back.
+func packVersion(p *codec.Packer, v uint8) {
+	p.PackByte(v)
+}
+
+// unpackVersion reads the version byte and rejects versions this binary
+// cannot decode.
+func unpackVersion(p *codec.Packer) (uint8, error) {
+	v := p.UnpackByte()
+	if err := p.Err(); err != nil {
+		return 0, err
+	}
+	if v == 0 || v > consts.LatestActionVersion {
+		return 0, ErrUnsupportedActionVersion
+	}
+	return v, nil
+}
+
+// CheckActionVersion rejects a versioned action whose version is unset or
+// not yet active at the current height, under either [rules] or an
+// activation by the admin keys.
+func CheckActionVersion(ctx context.Context, rules chain.Rules, im state.Immutable, action any) error {
+	versioned, ok := action.(Versioned)
+	if !ok {
+		return nil
+	}
+	height, err := storage.GetHeight(ctx, im)
+	if err != nil {
+		return err
+	}
+	v := versioned.ActionVersion()
+	if v == 0 {
+		return fmt.Errorf("%w: version %d at height %d", ErrUnsupportedActionVersion, v, height)
+	}
+	if v <= consts.MaxActionVersionAt(ActionVersionSchedule(rules), height) {
+		return nil
+	}
+	admin, err := storage.GetAdminVersion(ctx, im)
+	if err != nil {
+		return err
+	}
+	if admin == nil || v > admin.Version || height < admin.Height {
+		return fmt.Errorf("%w: version %d at height %d", ErrUnsupportedActionVersion, v, height)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrMalformedWasm = errors.New("malformed wasm module")
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+
+const (
+	wasmTypeSection     = 1
+	wasmImportSection   = 2
+	wasmFunctionSection = 3
+	wasmExportSection   = 7
+
+	wasmFuncType = 0x60
+	wasmKindFunc = 0
+)
+
+// wasmReader decodes the LEB128 integers, vectors and names of the Wasm
+// binary format. The first error sticks and later reads return zero
+// values.
+type wasmReader struct {
+	b   []byte
+	err error
+}
+
+func (r *wasmReader) fail() {
+	if r.err == nil {
+		r.err = ErrMalformedWasm
+	}
+	r.b = nil
+}
+
+func (r *wasmReader) byte() byte {
+	if len(r.b) == 0 {
+		r.fail()
+		return 0
+	}
+	c := r.b[0]
+	r.b = r.b[1:]
+	return c
+}
+
+func (r *wasmReader) u32() uint32 {
+	var v uint64
+	for shift := 0; shift < 35; shift += 7 {
+		c := r.byte()
+		v |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			if v > 0xffffffff {
+				r.fail()
+				return 0
+			}
+			return uint32(v)
+		}
+	}
+	r.fail()
+	return 0
+}
+
+func (r *wasmReader) bytes(n uint32) []byte {
+	if uint64(n) > uint64(len(r.b)) {
+		r.fail()
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *wasmReader) vec() []byte {
+	return r.bytes(r.u32())
+}
+
+// limits skips the limits of a table or memory
+func (r *wasmReader) limits() {
+	flags := r.byte()
+	r.u32()
+	if flags&1 != 0 {
+		r.u32()
+	}
+}
+
+// WasmExports returns the signatures of the functions [code] exports, by
+// export name. Only the sections needed to find them are decoded; the
+// module is not otherwise validated.
+func WasmExports(code []byte) (map[string]storage.FunctionSig, error) {
+	if !bytes.HasPrefix(code, wasmHeader) {
+		return nil, ErrMalformedWasm
+	}
+	var (
+		types   []storage.FunctionSig
+		funcs   []uint32 // type index of each function, imports first
+		exports = map[string]storage.FunctionSig{}
+	)
+	r := &wasmReader{b: code[len(wasmHeader):]}
+	for len(r.b) > 0 && r.err == nil {
+		id := r.byte()
+		section := &wasmReader{b: r.vec()}
+		switch id {
+		case wasmTypeSection:
+			for n := section.u32(); n > 0 && section.err == nil; n-- {
+				if section.byte() != wasmFuncType {
+					section.fail()
+				}
+				types = append(types, storage.FunctionSig{Params: section.vec(), Results: section.vec()})
+			}
+		case wasmImportSection:
+			for n := section.u32(); n > 0 && section.err == nil; n-- {
+				section.vec() // module
+				section.vec() // name
+				switch section.byte() {
+				case 0: // func
+					funcs = append(funcs, section.u32())
+				case 1: // table
+					section.byte()
+					section.limits()
+				case 2: // memory
+					section.limits()
+				case 3: // global
+					section.byte()
+					section.byte()
+				default:
+					section.fail()
+				}
+			}
+		case wasmFunctionSection:
+			for n := section.u32(); n > 0 && section.err == nil; n-- {
+				funcs = append(funcs, section.u32())
+			}
+		case wasmExportSection:
+			for n := section.u32(); n > 0 && section.err == nil; n-- {
+				name := string(section.vec())
+				kind := section.byte()
+				index := section.u32()
+				if section.err != nil || kind != wasmKindFunc {
+					continue
+				}
+				if uint64(index) >= uint64(len(funcs)) || uint64(funcs[index]) >= uint64(len(types)) {
+					return nil, fmt.Errorf("%w: export %q of unknown function", ErrMalformedWasm, name)
+				}
+				sig := types[funcs[index]]
+				sig.Name = name
+				exports[name] = sig
+			}
+		}
+		if section.err != nil {
+			return nil, section.err
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return exports, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	i32 = 0x7f
+	i64 = 0x7e
+)
+
+// testModule imports one function and exports two, add and init, and a
+// memory.
+func testModule() []byte {
+	module := append([]byte{}, wasmHeader...)
+	section := func(id byte, content ...byte) {
+		module = append(module, id, byte(len(content)))
+		module = append(module, content...)
+	}
+	section(wasmTypeSection,
+		2,
+		wasmFuncType, 2, i32, i32, 1, i32, // (i32, i32) -> i32
+		wasmFuncType, 0, 0, // () -> ()
+	)
+	section(wasmImportSection,
+		1,
+		3, 'e', 'n', 'v', 3, 'l', 'o', 'g', 0, 1,
+	)
+	section(wasmFunctionSection, 2, 0, 1)
+	section(wasmExportSection,
+		3,
+		3, 'a', 'd', 'd', 0, 1,
+		4, 'i', 'n', 'i', 't', 0, 2,
+		3, 'm', 'e', 'm', 2, 0,
+	)
+	return module
+}
+
+func TestWasmExports(t *testing.T) {
+	require := require.New(t)
+
+	exports, err := WasmExports(testModule())
+	require.NoError(err)
+	require.Len(exports, 2)
+	require.Equal([]byte{i32, i32}, exports["add"].Params)
+	require.Equal([]byte{i32}, exports["add"].Results)
+	require.Empty(exports["init"].Params)
+
+	_, err = WasmExports([]byte("not wasm"))
+	require.ErrorIs(err, ErrMalformedWasm)
+	truncated := testModule()
+	_, err = WasmExports(truncated[:len(truncated)-3])
+	require.ErrorIs(err, ErrMalformedWasm)
+}
+
+func TestCheckInterfaces(t *testing.T) {
+	require := require.New(t)
+
+	adder := storage.Interface{Functions: []storage.FunctionSig{
+		{Name: "add", Params: []byte{i32, i32}, Results: []byte{i32}},
+		{Name: "init"},
+	}}
+	require.NoError(CheckInterfaces(testModule(), []storage.Interface{adder}))
+
+	// Interfaces are identified by their signatures, in any order
+	reordered := storage.Interface{Functions: []storage.FunctionSig{adder.Functions[1], adder.Functions[0]}}
+	require.Equal(adder.ID(), reordered.ID())
+
+	wide := storage.Interface{Functions: []storage.FunctionSig{
+		{Name: "add", Params: []byte{i64, i64}, Results: []byte{i64}},
+	}}
+	require.NotEqual(adder.ID(), wide.ID())
+	require.ErrorIs(CheckInterfaces(testModule(), []storage.Interface{wide}), ErrInterfaceNotImplemented)
+	missing := storage.Interface{Functions: []storage.FunctionSig{{Name: "sub"}}}
+	require.ErrorIs(CheckInterfaces(testModule(), []storage.Interface{missing}), ErrInterfaceNotImplemented)
+	require.ErrorIs(CheckInterfaces([]byte("not wasm"), []storage.Interface{adder}), ErrInvalidCode)
+
+	require.ErrorIs(ValidateObjectMetadata(&storage.ObjectMetadata{
+		RegionID:   "us-east",
+		Interfaces: []storage.Interface{adder, reordered},
+	}), ErrInvalidInterface)
+}
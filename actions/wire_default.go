@@ -0,0 +1,10 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !protowire
+
+package actions
+
+// protoWire selects the protobuf wire format for packed actions. Build with
+// `-tags protowire` to enable it.
+const protoWire = false
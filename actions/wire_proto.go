@@ -0,0 +1,739 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/rhombus-tech/hypersdk/x/contracts/runtime/events"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// Encoders and decoders for the messages in proto/shuttlevm/v1. They are
+// always compiled so both backends can be tested; [protoWire] selects which
+// one Marshal and the Unmarshal functions use.
+
+var ErrMalformedProto = errors.New("malformed protobuf action")
+
+// protoMsg is a decoded message keyed by field number. Unknown fields are
+// kept and ignored so older decoders accept newer encodings.
+type protoMsg struct {
+	varints map[protowire.Number]uint64
+	bytes   map[protowire.Number][][]byte
+}
+
+func parseProto(b []byte) (*protoMsg, error) {
+	m := &protoMsg{
+		varints: make(map[protowire.Number]uint64),
+		bytes:   make(map[protowire.Number][][]byte),
+	}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, ErrMalformedProto
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, ErrMalformedProto
+			}
+			m.varints[num] = v
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, ErrMalformedProto
+			}
+			m.bytes[num] = append(m.bytes[num], v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, ErrMalformedProto
+			}
+			b = b[n:]
+		}
+	}
+	return m, nil
+}
+
+func (m *protoMsg) uint64(num protowire.Number) uint64 {
+	return m.varints[num]
+}
+
+func (m *protoMsg) bytesField(num protowire.Number) []byte {
+	vs := m.bytes[num]
+	if len(vs) == 0 {
+		return nil
+	}
+	// Last one wins, as in the protobuf spec for scalar fields
+	return vs[len(vs)-1]
+}
+
+func (m *protoMsg) string(num protowire.Number) string {
+	return string(m.bytesField(num))
+}
+
+func (m *protoMsg) repeated(num protowire.Number, limit int, errTooMany error) ([][]byte, error) {
+	vs := m.bytes[num]
+	if len(vs) > limit {
+		return nil, errTooMany
+	}
+	return vs, nil
+}
+
+func (m *protoMsg) version() (uint8, error) {
+	v := m.uint64(1)
+	if v == 0 || v > uint64(consts.LatestActionVersion) {
+		return 0, ErrUnsupportedActionVersion
+	}
+	return uint8(v), nil
+}
+
+func appendVersion(b []byte, v uint8) []byte {
+	return appendUint64(b, 1, uint64(v))
+}
+
+func appendUint64(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	return appendBytes(b, num, []byte(v))
+}
+
+func appendAddresses(b []byte, num protowire.Number, addrs []codec.Address) []byte {
+	for _, addr := range addrs {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, addr[:])
+	}
+	return b
+}
+
+func protoAddresses(m *protoMsg, num protowire.Number) ([]codec.Address, error) {
+	vs, err := m.repeated(num, consts.MaxTEEsPerRegion, ErrTooManyTEEs)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]codec.Address, len(vs))
+	for i, v := range vs {
+		if len(v) != codec.AddressLen {
+			return nil, ErrMalformedProto
+		}
+		copy(addrs[i][:], v)
+	}
+	return addrs, nil
+}
+
+// unpackProto reads the length-prefixed message written by packProto.
+func unpackProto(p *codec.Packer) (*protoMsg, error) {
+	b, err := p.UnpackBytes()
+	if err != nil {
+		return nil, err
+	}
+	return parseProto(b)
+}
+
+func packProto(p *codec.Packer, b []byte) {
+	p.PackBytes(b)
+}
+
+func (a *CreateObjectAction) appendProto(b []byte) []byte {
+	version := a.Version
+	b = appendVersion(b, version)
+	b = appendString(b, 2, a.ID)
+	b = appendBytes(b, 3, a.Code)
+	b = appendBytes(b, 4, a.Storage)
+	if version >= consts.ActionVersion12 {
+		b = appendBytes(b, 5, encodeMetadata(a.Metadata))
+	}
+	return b
+}
+
+func createObjectFromProto(m *protoMsg) (*CreateObjectAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	act := &CreateObjectAction{
+		Version: version,
+		ID:      m.string(2),
+		Code:    m.bytesField(3),
+		Storage: m.bytesField(4),
+	}
+	if version >= consts.ActionVersion12 {
+		if act.Metadata, err = decodeMetadata(m.bytesField(5)); err != nil {
+			return nil, err
+		}
+	}
+	return act, nil
+}
+
+func (a *SendEventAction) appendProto(b []byte) []byte {
+	version := a.Version
+	b = appendVersion(b, version)
+	b = appendString(b, 2, a.IDTo)
+	b = appendString(b, 3, a.FunctionCall)
+	b = appendBytes(b, 4, a.Parameters)
+	if version >= consts.ActionVersion5 {
+		b = appendString(b, 5, a.CallbackObject)
+		b = appendString(b, 6, a.CallbackFunction)
+	}
+	if version >= consts.ActionVersion7 {
+		b = appendBytes(b, 7, a.Sender[:])
+		b = appendUint64(b, 8, a.Nonce)
+	}
+	if version >= consts.ActionVersion8 {
+		b = appendUint64(b, 9, a.Tip)
+	}
+	if version >= consts.ActionVersion9 && a.Encrypted {
+		b = appendUint64(b, 10, 1)
+	}
+	if version >= consts.ActionVersion11 {
+		b = appendString(b, 11, a.ToName)
+	}
+	if version >= consts.ActionVersion15 {
+		b = appendString(b, 12, a.IdempotencyKey)
+	}
+	return b
+}
+
+func sendEventFromProto(m *protoMsg) (*SendEventAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	act := &SendEventAction{
+		Version:      version,
+		IDTo:         m.string(2),
+		FunctionCall: m.string(3),
+		Parameters:   m.bytesField(4),
+	}
+	if version >= consts.ActionVersion5 {
+		act.CallbackObject = m.string(5)
+		act.CallbackFunction = m.string(6)
+	}
+	if version >= consts.ActionVersion7 {
+		if sender := m.bytesField(7); sender != nil {
+			if len(sender) != codec.AddressLen {
+				return nil, ErrMalformedProto
+			}
+			copy(act.Sender[:], sender)
+		}
+		act.Nonce = m.uint64(8)
+	}
+	if version >= consts.ActionVersion8 {
+		act.Tip = m.uint64(9)
+	}
+	if version >= consts.ActionVersion9 {
+		act.Encrypted = m.uint64(10) != 0
+	}
+	if version >= consts.ActionVersion11 {
+		act.ToName = m.string(11)
+	}
+	if version >= consts.ActionVersion15 {
+		act.IdempotencyKey = m.string(12)
+	}
+	return act, nil
+}
+
+func (a *SetInputObjectAction) appendProto(b []byte) []byte {
+	b = appendVersion(b, a.Version)
+	return appendString(b, 2, a.ID)
+}
+
+func setInputObjectFromProto(m *protoMsg) (*SetInputObjectAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	return &SetInputObjectAction{Version: version, ID: m.string(2)}, nil
+}
+
+func (a *PipelineAction) appendProto(b []byte) []byte {
+	b = appendVersion(b, a.Version)
+	b = appendString(b, 2, a.ID)
+	b = appendString(b, 3, a.FunctionCall)
+	b = appendBytes(b, 4, a.Parameters)
+	return appendString(b, 5, a.Next)
+}
+
+func pipelineFromProto(m *protoMsg) (*PipelineAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	return &PipelineAction{
+		Version:      version,
+		ID:           m.string(2),
+		FunctionCall: m.string(3),
+		Parameters:   m.bytesField(4),
+		Next:         m.string(5),
+	}, nil
+}
+
+func (a *CreateRegionAction) appendProto(b []byte) []byte {
+	b = appendVersion(b, a.Version)
+	b = appendString(b, 2, a.RegionID)
+	return appendAddresses(b, 3, a.TEEs)
+}
+
+func createRegionFromProto(m *protoMsg) (*CreateRegionAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	tees, err := protoAddresses(m, 3)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateRegionAction{Version: version, RegionID: m.string(2), TEEs: tees}, nil
+}
+
+func (a *UpdateRegionAction) appendProto(b []byte) []byte {
+	b = appendVersion(b, a.Version)
+	b = appendString(b, 2, a.RegionID)
+	b = appendAddresses(b, 3, a.AddTEEs)
+	return appendAddresses(b, 4, a.RemTEEs)
+}
+
+func updateRegionFromProto(m *protoMsg) (*UpdateRegionAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	add, err := protoAddresses(m, 3)
+	if err != nil {
+		return nil, err
+	}
+	rem, err := protoAddresses(m, 4)
+	if err != nil {
+		return nil, err
+	}
+	return &UpdateRegionAction{Version: version, RegionID: m.string(2), AddTEEs: add, RemTEEs: rem}, nil
+}
+
+func (t *TEEExecAction) appendProto(b []byte) []byte {
+	version := t.Version
+	b = appendVersion(b, version)
+	b = appendString(b, 2, t.RegionID)
+	b = appendBytes(b, 3, t.TxData)
+	b = appendBytes(b, 4, t.UserSig)
+	b = appendString(b, 5, string(t.Attestation.EnclaveType))
+	b = appendBytes(b, 6, t.Attestation.EnclaveID)
+	b = appendBytes(b, 7, t.ExecResult.ContractAddr)
+	for _, event := range t.ExecResult.Events {
+		eventBytes, _ := event.Marshal()
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendBytes(b, eventBytes)
+	}
+	for _, key := range sortedKeys(t.ExecResult.StateUpdates) {
+		var update []byte
+		update = appendString(update, 1, key)
+		update = appendBytes(update, 2, t.ExecResult.StateUpdates[key])
+		b = protowire.AppendTag(b, 9, protowire.BytesType)
+		b = protowire.AppendBytes(b, update)
+	}
+	if version >= consts.ActionVersion2 {
+		refKeys := make([]string, 0, len(t.ExecResult.StateRefs))
+		for key := range t.ExecResult.StateRefs {
+			refKeys = append(refKeys, key)
+		}
+		sort.Strings(refKeys)
+		for _, key := range refKeys {
+			ref := t.ExecResult.StateRefs[key]
+			var update []byte
+			update = appendString(update, 1, key)
+			update = appendBytes(update, 3, ref[:])
+			b = protowire.AppendTag(b, 9, protowire.BytesType)
+			b = protowire.AppendBytes(b, update)
+		}
+	}
+	b = appendBytes(b, 10, t.Attestation.Signature)
+	for _, ts := range t.Attestation.Stamps {
+		var stamp []byte
+		stamp = appendString(stamp, 1, ts.ServerID)
+		stamp = appendUint64(stamp, 2, ts.Time)
+		stamp = appendBytes(stamp, 3, ts.Signature)
+		b = protowire.AppendTag(b, 11, protowire.BytesType)
+		b = protowire.AppendBytes(b, stamp)
+	}
+	b = appendUint64(b, 12, t.MaxComputeUnits)
+	if version >= consts.ActionVersion3 {
+		if root := t.ExecResult.PreStateRoot; root != ids.Empty {
+			b = appendBytes(b, 13, root[:])
+		}
+		if root := t.ExecResult.StateRoot; root != ids.Empty {
+			b = appendBytes(b, 14, root[:])
+		}
+		if t.Witness != nil {
+			b = protowire.AppendTag(b, 15, protowire.BytesType)
+			b = protowire.AppendBytes(b, appendWitnessProto(nil, t.Witness))
+		}
+	}
+	if version >= consts.ActionVersion4 && t.Peer != nil {
+		b = protowire.AppendTag(b, 16, protowire.BytesType)
+		b = protowire.AppendBytes(b, t.Peer.action(version).appendProto(nil))
+	}
+	if version >= consts.ActionVersion5 && t.EventID != ids.Empty {
+		b = appendBytes(b, 17, t.EventID[:])
+	}
+	if version >= consts.ActionVersion6 {
+		for _, read := range t.ExecResult.Reads {
+			var r []byte
+			r = appendString(r, 1, read.Object)
+			r = appendBytes(r, 2, read.Key)
+			r = appendBytes(r, 3, read.ValueHash[:])
+			b = protowire.AppendTag(b, 18, protowire.BytesType)
+			b = protowire.AppendBytes(b, r)
+		}
+		for _, call := range t.ExecResult.Calls {
+			var c []byte
+			c = appendString(c, 1, call.Caller)
+			c = appendString(c, 2, call.Callee)
+			c = appendString(c, 3, call.Function)
+			c = appendUint64(c, 4, uint64(call.Depth))
+			b = protowire.AppendTag(b, 19, protowire.BytesType)
+			b = protowire.AppendBytes(b, c)
+		}
+	}
+	if version >= consts.ActionVersion10 && t.Attestation.Flags != 0 {
+		b = appendUint64(b, 20, uint64(t.Attestation.Flags))
+	}
+	if version >= consts.ActionVersion13 {
+		usage := t.ExecResult.Usage
+		b = appendUint64(b, 21, uint64(usage.MemoryPages))
+		b = appendUint64(b, 22, uint64(usage.ValueStack))
+		b = appendUint64(b, 23, uint64(usage.HostCallDepth))
+		b = appendUint64(b, 24, usage.ElapsedMs)
+		b = appendString(b, 25, string(t.ExecResult.Exhausted))
+	}
+	if version >= consts.ActionVersion14 {
+		for _, log := range t.ExecResult.Logs {
+			var l []byte
+			for _, topic := range log.Topics {
+				l = appendBytes(l, 1, topic[:])
+			}
+			l = appendBytes(l, 2, log.Data)
+			b = protowire.AppendTag(b, 26, protowire.BytesType)
+			b = protowire.AppendBytes(b, l)
+		}
+	}
+	if version >= consts.ActionVersion16 && t.ExecResult.Failed {
+		b = appendUint64(b, 27, 1)
+	}
+	return b
+}
+
+func appendWitnessProto(b []byte, w *StateWitness) []byte {
+	b = appendUint64(b, 1, w.LeafCount)
+	for _, e := range w.Entries {
+		var entry []byte
+		entry = appendBytes(entry, 1, e.Key)
+		entry = appendBytes(entry, 2, e.Value)
+		entry = appendUint64(entry, 3, e.Index)
+		for _, sibling := range e.Siblings {
+			entry = protowire.AppendTag(entry, 4, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, sibling[:])
+		}
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b
+}
+
+func witnessFromProto(m *protoMsg) (*StateWitness, error) {
+	rawEntries, err := m.repeated(2, MaxWitnessEntries, ErrWitnessTooLong)
+	if err != nil {
+		return nil, err
+	}
+	w := &StateWitness{
+		LeafCount: m.uint64(1),
+		Entries:   make([]WitnessEntry, len(rawEntries)),
+	}
+	for i, raw := range rawEntries {
+		entry, err := parseProto(raw)
+		if err != nil {
+			return nil, err
+		}
+		rawSiblings, err := entry.repeated(4, MaxWitnessDepth, ErrWitnessTooLong)
+		if err != nil {
+			return nil, err
+		}
+		siblings := make([]ids.ID, len(rawSiblings))
+		for j, raw := range rawSiblings {
+			if siblings[j], err = ids.ToID(raw); err != nil {
+				return nil, ErrMalformedProto
+			}
+		}
+		w.Entries[i] = WitnessEntry{
+			Key:      entry.bytesField(1),
+			Value:    entry.bytesField(2),
+			Index:    entry.uint64(3),
+			Siblings: siblings,
+		}
+	}
+	return w, nil
+}
+
+// protoID decodes an optional 32-byte field, leaving [ids.Empty] if unset.
+func protoID(m *protoMsg, num protowire.Number) (ids.ID, error) {
+	raw := m.bytesField(num)
+	if raw == nil {
+		return ids.Empty, nil
+	}
+	id, err := ids.ToID(raw)
+	if err != nil {
+		return ids.Empty, ErrMalformedProto
+	}
+	return id, nil
+}
+
+// peerFromProto decodes a peer execution, a nested TEEExecAction that may
+// not carry a peer of its own.
+func peerFromProto(raw []byte) (*PeerExecution, error) {
+	m, err := parseProto(raw)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := m.bytes[16]; ok {
+		return nil, ErrNestedPeer
+	}
+	peer, err := teeExecFromProto(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExecFlags(peer.Attestation.Flags, int(peer.Attestation.Flags), false); err != nil {
+		return nil, err
+	}
+	return &PeerExecution{
+		ExecResult:  peer.ExecResult,
+		Attestation: peer.Attestation,
+	}, nil
+}
+
+func teeExecFromProto(m *protoMsg) (*TEEExecAction, error) {
+	version, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+	act := &TEEExecAction{
+		Version:  version,
+		RegionID: m.string(2),
+		TxData:   m.bytesField(3),
+		UserSig:  m.bytesField(4),
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(m.string(5)),
+			EnclaveID:   m.bytesField(6),
+			Signature:   m.bytesField(10),
+		},
+		MaxComputeUnits: m.uint64(12),
+	}
+	act.ExecResult.ContractAddr = m.bytesField(7)
+
+	rawEvents, err := m.repeated(8, consts.MaxEventsPerExec, ErrTooManyEvents)
+	if err != nil {
+		return nil, err
+	}
+	act.ExecResult.Events = make([]events.Event, len(rawEvents))
+	for i, raw := range rawEvents {
+		if err := act.ExecResult.Events[i].Unmarshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	rawUpdates, err := m.repeated(9, consts.MaxStateUpdates, ErrTooManyStateUpdates)
+	if err != nil {
+		return nil, err
+	}
+	act.ExecResult.StateUpdates = make(map[string][]byte, len(rawUpdates))
+	for _, raw := range rawUpdates {
+		update, err := parseProto(raw)
+		if err != nil {
+			return nil, err
+		}
+		// References are only understood from v2; older versions keep
+		// treating the update as a value
+		if ref := update.bytesField(3); ref != nil && version >= consts.ActionVersion2 {
+			hash, err := ids.ToID(ref)
+			if err != nil {
+				return nil, ErrMalformedProto
+			}
+			if act.ExecResult.StateRefs == nil {
+				act.ExecResult.StateRefs = make(map[string]ids.ID)
+			}
+			act.ExecResult.StateRefs[update.string(1)] = hash
+			continue
+		}
+		act.ExecResult.StateUpdates[update.string(1)] = update.bytesField(2)
+	}
+
+	if version >= consts.ActionVersion3 {
+		if act.ExecResult.PreStateRoot, err = protoID(m, 13); err != nil {
+			return nil, err
+		}
+		if act.ExecResult.StateRoot, err = protoID(m, 14); err != nil {
+			return nil, err
+		}
+		if raw := m.bytesField(15); raw != nil {
+			witness, err := parseProto(raw)
+			if err != nil {
+				return nil, err
+			}
+			if act.Witness, err = witnessFromProto(witness); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if version >= consts.ActionVersion4 {
+		if raw := m.bytesField(16); raw != nil {
+			if act.Peer, err = peerFromProto(raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if version >= consts.ActionVersion5 {
+		if act.EventID, err = protoID(m, 17); err != nil {
+			return nil, err
+		}
+	}
+
+	if version >= consts.ActionVersion6 {
+		rawReads, err := m.repeated(18, consts.MaxObjectReads, ErrTooManyReads)
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range rawReads {
+			read, err := parseProto(raw)
+			if err != nil {
+				return nil, err
+			}
+			hash, err := protoID(read, 3)
+			if err != nil {
+				return nil, err
+			}
+			act.ExecResult.Reads = append(act.ExecResult.Reads, ObjectRead{
+				Object:    read.string(1),
+				Key:       read.bytesField(2),
+				ValueHash: hash,
+			})
+		}
+		rawCalls, err := m.repeated(19, consts.MaxCallFrames, ErrTooManyCalls)
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range rawCalls {
+			call, err := parseProto(raw)
+			if err != nil {
+				return nil, err
+			}
+			depth := call.uint64(4)
+			if depth > consts.MaxCallDepth {
+				return nil, ErrInvalidCallTrace
+			}
+			act.ExecResult.Calls = append(act.ExecResult.Calls, CallFrame{
+				Caller:   call.string(1),
+				Callee:   call.string(2),
+				Function: call.string(3),
+				Depth:    uint8(depth),
+			})
+		}
+	}
+
+	if version >= consts.ActionVersion10 {
+		flags := m.uint64(20)
+		if err := checkExecFlags(attestation.Flags(flags), int(min(flags, math.MaxInt32)), true); err != nil {
+			return nil, err
+		}
+		act.Attestation.Flags = attestation.Flags(flags)
+	}
+
+	if version >= consts.ActionVersion13 {
+		usage := &act.ExecResult.Usage
+		for i, dst := range []*uint32{&usage.MemoryPages, &usage.ValueStack, &usage.HostCallDepth} {
+			v := m.uint64(protowire.Number(21 + i))
+			if v > math.MaxUint32 {
+				return nil, ErrMalformedProto
+			}
+			*dst = uint32(v)
+		}
+		usage.ElapsedMs = m.uint64(24)
+		act.ExecResult.Exhausted = Resource(m.string(25))
+	}
+
+	if version >= consts.ActionVersion14 {
+		rawLogs, err := m.repeated(26, consts.MaxLogsPerExec, ErrTooManyLogs)
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range rawLogs {
+			l, err := parseProto(raw)
+			if err != nil {
+				return nil, err
+			}
+			rawTopics, err := l.repeated(1, consts.MaxLogTopics, ErrInvalidLog)
+			if err != nil {
+				return nil, err
+			}
+			log := storage.Log{Data: l.bytesField(2)}
+			for _, rawTopic := range rawTopics {
+				topic, err := ids.ToID(rawTopic)
+				if err != nil {
+					return nil, ErrMalformedProto
+				}
+				log.Topics = append(log.Topics, topic)
+			}
+			act.ExecResult.Logs = append(act.ExecResult.Logs, log)
+		}
+		if err := validateLogs(act.ExecResult.Logs); err != nil {
+			return nil, err
+		}
+	}
+
+	if version >= consts.ActionVersion16 {
+		act.ExecResult.Failed = m.uint64(27) != 0
+	}
+
+	rawStamps, err := m.repeated(11, consts.MaxTimeStampsCount, ErrTooManyTimeStamps)
+	if err != nil {
+		return nil, err
+	}
+	act.Attestation.Stamps = make([]attestation.Stamp, len(rawStamps))
+	for i, raw := range rawStamps {
+		stamp, err := parseProto(raw)
+		if err != nil {
+			return nil, err
+		}
+		act.Attestation.Stamps[i] = attestation.Stamp{
+			ServerID:  stamp.string(1),
+			Time:      stamp.uint64(2),
+			Signature: stamp.bytesField(3),
+		}
+	}
+	return act, nil
+}
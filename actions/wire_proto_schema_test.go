@@ -0,0 +1,296 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/rhombus-tech/hypersdk/x/contracts/runtime/events"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const actionsProto = "../proto/shuttlevm/v1/actions.proto"
+
+var (
+	protoMessageLine = regexp.MustCompile(`^message (\w+) \{$`)
+	protoFieldLine   = regexp.MustCompile(`^(repeated )?(\w+) (\w+) = (\d+);$`)
+
+	protoScalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+		"bool":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+		"uint32": descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+		"uint64": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+		"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		"bytes":  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+	}
+)
+
+// loadActionsProto builds the descriptor of the wire schema from its
+// source. The schema only declares flat messages of scalar, message and
+// repeated fields, so it is read line by line rather than with protoc.
+func loadActionsProto(t *testing.T) protoreflect.FileDescriptor {
+	require := require.New(t)
+
+	f, err := os.Open(actionsProto)
+	require.NoError(err)
+	defer f.Close()
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("shuttlevm/v1/actions.proto"),
+		Package: proto.String("shuttlevm.v1"),
+		Syntax:  proto.String("proto3"),
+	}
+	var msg *descriptorpb.DescriptorProto
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "syntax ") || strings.HasPrefix(line, "package "):
+		case protoMessageLine.MatchString(line):
+			require.Nil(msg, "nested message %q", line)
+			msg = &descriptorpb.DescriptorProto{Name: proto.String(protoMessageLine.FindStringSubmatch(line)[1])}
+			file.MessageType = append(file.MessageType, msg)
+		case line == "}":
+			require.NotNil(msg)
+			msg = nil
+		case protoFieldLine.MatchString(line):
+			require.NotNil(msg, "field %q outside a message", line)
+			match := protoFieldLine.FindStringSubmatch(line)
+			num, err := strconv.ParseInt(match[4], 10, 32)
+			require.NoError(err)
+			field := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(match[3]),
+				JsonName: proto.String(match[3]),
+				Number:   proto.Int32(int32(num)),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}
+			if match[1] != "" {
+				field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			}
+			if typ, ok := protoScalarTypes[match[2]]; ok {
+				field.Type = typ.Enum()
+			} else {
+				field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				field.TypeName = proto.String(".shuttlevm.v1." + match[2])
+			}
+			msg.Field = append(msg.Field, field)
+		default:
+			require.FailNow("unexpected line", line)
+		}
+	}
+	require.NoError(scanner.Err())
+
+	fd, err := protodesc.NewFile(file, nil)
+	require.NoError(err)
+	return fd
+}
+
+// checkDeclared fails if [msg], or any message it holds, carries a field
+// the schema does not declare with that wire type, and records the fields
+// that are set in [seen].
+func checkDeclared(t *testing.T, msg protoreflect.Message, seen map[protoreflect.FullName]bool) {
+	desc := msg.Descriptor()
+	require.Empty(t, msg.GetUnknown(), "undeclared fields in %s", desc.FullName())
+	seen[desc.FullName()] = true
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		seen[fd.FullName()] = true
+		switch {
+		case fd.Message() == nil:
+		case fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				checkDeclared(t, v.List().Get(i).Message(), seen)
+			}
+		default:
+			checkDeclared(t, v.Message(), seen)
+		}
+		return true
+	})
+}
+
+// TestProtoSchema checks the hand-written codec against the wire schema.
+// Each action, with every field set, is encoded by the codec, decoded and
+// re-encoded by the protobuf runtime from the schema's descriptors, and
+// decoded by the codec again. Every field of every message must be reached
+// and none may be left unknown.
+func TestProtoSchema(t *testing.T) {
+	fd := loadActionsProto(t)
+
+	sig := []byte{9, 9}
+	execResult := func() TEEExecResult {
+		return TEEExecResult{
+			ContractAddr: []byte("token"),
+			Events:       make([]events.Event, 1),
+			StateUpdates: map[string][]byte{"k1": {1}},
+			StateRefs:    map[string]ids.ID{"k2": {2}},
+			PreStateRoot: ids.ID{3},
+			StateRoot:    ids.ID{4},
+			Reads:        []ObjectRead{{Object: "oracle", Key: []byte("price"), ValueHash: ids.ID{5}}},
+			Calls:        []CallFrame{{Caller: "token", Callee: "oracle", Function: "price", Depth: 1}},
+			Usage:        ResourceUsage{MemoryPages: 16, ValueStack: 512, HostCallDepth: 3, ElapsedMs: 250},
+			Exhausted:    ResourceDeadline,
+			Logs:         []storage.Log{{Topics: []ids.ID{{6}}, Data: []byte("transfer")}},
+			Failed:       true,
+		}
+	}
+	exec := &TEEExecAction{
+		Version:  consts.LatestActionVersion,
+		RegionID: "us-east",
+		TxData:   []byte{1},
+		UserSig:  []byte{2},
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.SGX,
+			EnclaveID:   []byte{1, 2, 3},
+			Signature:   sig,
+			Stamps:      []attestation.Stamp{{ServerID: "a", Time: 10, Signature: sig}},
+			Flags:       attestation.FlagAggregated,
+		},
+		ExecResult:      execResult(),
+		MaxComputeUnits: 500,
+		Witness: &StateWitness{
+			LeafCount: 2,
+			Entries:   []WitnessEntry{{Key: []byte("k1"), Value: []byte{1}, Index: 1, Siblings: []ids.ID{{7}}}},
+		},
+		Peer: &PeerExecution{
+			ExecResult: execResult(),
+			Attestation: attestation.Attestation{
+				EnclaveType: attestation.SEV,
+				EnclaveID:   []byte{4, 5, 6},
+				Signature:   sig,
+			},
+		},
+		EventID: ids.ID{8},
+	}
+
+	tests := []struct {
+		message protoreflect.Name
+		action  interface{ appendProto([]byte) []byte }
+		decode  func(*protoMsg) (any, error)
+	}{
+		{
+			message: "CreateObjectAction",
+			action: &CreateObjectAction{
+				Version:  consts.LatestActionVersion,
+				ID:       "amm",
+				Code:     []byte{0, 'a', 's', 'm'},
+				Storage:  []byte{1},
+				Metadata: &storage.ObjectMetadata{Name: "AMM", RegionID: "us-east"},
+			},
+			decode: func(m *protoMsg) (any, error) { return createObjectFromProto(m) },
+		},
+		{
+			message: "SendEventAction",
+			action: &SendEventAction{
+				Version:          consts.LatestActionVersion,
+				IDTo:             "worker",
+				FunctionCall:     "run",
+				Parameters:       []byte{1},
+				CallbackObject:   "client",
+				CallbackFunction: "done",
+				Sender:           codectest.NewRandomAddress(),
+				Nonce:            1,
+				Tip:              2,
+				Encrypted:        true,
+				ToName:           "jobs.worker",
+				IdempotencyKey:   "job-42",
+			},
+			decode: func(m *protoMsg) (any, error) { return sendEventFromProto(m) },
+		},
+		{
+			message: "SetInputObjectAction",
+			action:  &SetInputObjectAction{Version: consts.LatestActionVersion, ID: "input"},
+			decode:  func(m *protoMsg) (any, error) { return setInputObjectFromProto(m) },
+		},
+		{
+			message: "PipelineAction",
+			action: &PipelineAction{
+				Version:      consts.LatestActionVersion,
+				ID:           "parse",
+				FunctionCall: "run",
+				Parameters:   []byte{1},
+				Next:         "render",
+			},
+			decode: func(m *protoMsg) (any, error) { return pipelineFromProto(m) },
+		},
+		{
+			message: "CreateRegionAction",
+			action: &CreateRegionAction{
+				Version:  consts.LatestActionVersion,
+				RegionID: "us-east",
+				TEEs:     []codec.Address{codectest.NewRandomAddress()},
+			},
+			decode: func(m *protoMsg) (any, error) { return createRegionFromProto(m) },
+		},
+		{
+			message: "UpdateRegionAction",
+			action: &UpdateRegionAction{
+				Version:  consts.LatestActionVersion,
+				RegionID: "us-east",
+				AddTEEs:  []codec.Address{codectest.NewRandomAddress()},
+				RemTEEs:  []codec.Address{codectest.NewRandomAddress()},
+			},
+			decode: func(m *protoMsg) (any, error) { return updateRegionFromProto(m) },
+		},
+		{
+			message: "TEEExecAction",
+			action:  exec,
+			decode:  func(m *protoMsg) (any, error) { return teeExecFromProto(m) },
+		},
+	}
+
+	seen := make(map[protoreflect.FullName]bool)
+	for _, tt := range tests {
+		t.Run(string(tt.message), func(t *testing.T) {
+			require := require.New(t)
+
+			desc := fd.Messages().ByName(tt.message)
+			require.NotNil(desc, "message not in schema")
+
+			b := tt.action.appendProto(nil)
+			m, err := parseProto(b)
+			require.NoError(err)
+			expected, err := tt.decode(m)
+			require.NoError(err)
+
+			msg := dynamicpb.NewMessage(desc)
+			require.NoError(proto.Unmarshal(b, msg))
+			checkDeclared(t, msg, seen)
+
+			b, err = proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+			require.NoError(err)
+			m, err = parseProto(b)
+			require.NoError(err)
+			decoded, err := tt.decode(m)
+			require.NoError(err)
+			require.Equal(expected, decoded)
+		})
+	}
+
+	// Every message and field of the schema is covered
+	messages := fd.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		desc := messages.Get(i)
+		require.True(t, seen[desc.FullName()], "message %s not covered", desc.FullName())
+		fields := desc.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			require.True(t, seen[fields.Get(j).FullName()], "field %s not covered", fields.Get(j).FullName())
+		}
+	}
+}
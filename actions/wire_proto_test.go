@@ -0,0 +1,474 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	update := &UpdateRegionAction{
+		Version:  consts.ActionVersion1,
+		RegionID: "us-east",
+		AddTEEs:  []codec.Address{codectest.NewRandomAddress()},
+		RemTEEs:  []codec.Address{codectest.NewRandomAddress()},
+	}
+	m, err := parseProto(update.appendProto(nil))
+	require.NoError(err)
+	decoded, err := updateRegionFromProto(m)
+	require.NoError(err)
+	require.Equal(update, decoded)
+
+	exec := &TEEExecAction{
+		Version:  consts.ActionVersion1,
+		RegionID: "us-east",
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.SGX,
+			EnclaveID:   []byte{1, 2, 3},
+			Signature:   []byte{4, 5, 6},
+			Stamps: []attestation.Stamp{
+				{ServerID: "a", Time: 10, Signature: []byte{7}},
+				{ServerID: "b", Time: 11, Signature: []byte{8}},
+			},
+		},
+		MaxComputeUnits: 500,
+	}
+	exec.ExecResult.StateUpdates = map[string][]byte{"k1": {1}, "k2": {2}}
+	m, err = parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decodedExec, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.StateKeys(codec.EmptyAddress, [32]byte{}), decodedExec.StateKeys(codec.EmptyAddress, [32]byte{}))
+	require.Equal(exec.Attestation, decodedExec.Attestation)
+	require.Equal(exec.MaxComputeUnits, decodedExec.MaxComputeUnits)
+}
+
+func TestProtoStateRefs(t *testing.T) {
+	require := require.New(t)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion2, RegionID: "us-east"}
+	exec.ExecResult.StateUpdates = map[string][]byte{"k1": {1}}
+	exec.ExecResult.StateRefs = map[string]ids.ID{"k2": {2}}
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.ExecResult.StateUpdates, decoded.ExecResult.StateUpdates)
+	require.Equal(exec.ExecResult.StateRefs, decoded.ExecResult.StateRefs)
+
+	// v1 encodings carry no references
+	exec.Version = consts.ActionVersion1
+	m, err = parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err = teeExecFromProto(m)
+	require.NoError(err)
+	require.Empty(decoded.ExecResult.StateRefs)
+}
+
+func TestProtoRejectsUnknownVersion(t *testing.T) {
+	b := appendUint64(nil, 1, uint64(consts.LatestActionVersion)+1)
+	m, err := parseProto(b)
+	require.NoError(t, err)
+	_, err = setInputObjectFromProto(m)
+	require.ErrorIs(t, err, ErrUnsupportedActionVersion)
+
+	// An unset version is encoded as is, not as the latest
+	m, err = parseProto((&SetInputObjectAction{ID: "input"}).appendProto(nil))
+	require.NoError(t, err)
+	_, err = setInputObjectFromProto(m)
+	require.ErrorIs(t, err, ErrUnsupportedActionVersion)
+}
+
+func TestProtoIgnoresUnknownFields(t *testing.T) {
+	b := (&SetInputObjectAction{Version: consts.ActionVersion1, ID: "input"}).appendProto(nil)
+	b = protowire.AppendTag(b, 99, protowire.VarintType)
+	b = protowire.AppendVarint(b, 7)
+	m, err := parseProto(b)
+	require.NoError(t, err)
+	act, err := setInputObjectFromProto(m)
+	require.NoError(t, err)
+	require.Equal(t, "input", act.ID)
+}
+
+func TestProtoPeerExecution(t *testing.T) {
+	require := require.New(t)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion4, RegionID: "us-east"}
+	exec.ExecResult.StateUpdates = map[string][]byte{"k1": {1}}
+	exec.Peer = &PeerExecution{
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.SEV,
+			EnclaveID:   []byte{1, 2, 3},
+			Signature:   []byte{4, 5, 6},
+		},
+	}
+	exec.Peer.ExecResult.StateUpdates = map[string][]byte{"k1": {2}}
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.Peer.ExecResult.StateUpdates, decoded.Peer.ExecResult.StateUpdates)
+	require.Equal(exec.Peer.Attestation.EnclaveID, decoded.Peer.Attestation.EnclaveID)
+
+	// A peer may not nest another peer
+	nested := exec.Peer.action(consts.ActionVersion4)
+	nested.Peer = exec.Peer
+	b := (&TEEExecAction{Version: consts.ActionVersion4, RegionID: "us-east"}).appendProto(nil)
+	b = protowire.AppendTag(b, 16, protowire.BytesType)
+	b = protowire.AppendBytes(b, nested.appendProto(nil))
+	m, err = parseProto(b)
+	require.NoError(err)
+	_, err = teeExecFromProto(m)
+	require.ErrorIs(err, ErrNestedPeer)
+}
+
+func TestProtoEventCallback(t *testing.T) {
+	require := require.New(t)
+
+	event := &SendEventAction{
+		Version:          consts.ActionVersion5,
+		IDTo:             "counter",
+		FunctionCall:     "increment",
+		Parameters:       []byte{1},
+		CallbackObject:   "caller",
+		CallbackFunction: "on_increment",
+	}
+	m, err := parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err := sendEventFromProto(m)
+	require.NoError(err)
+	require.Equal(event, decoded)
+
+	// Before v5 the callback is not encoded
+	event.Version = consts.ActionVersion4
+	m, err = parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err = sendEventFromProto(m)
+	require.NoError(err)
+	require.Empty(decoded.CallbackObject)
+	require.Empty(decoded.CallbackFunction)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion5, RegionID: "us-east", EventID: ids.GenerateTestID()}
+	m, err = parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decodedExec, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.EventID, decodedExec.EventID)
+}
+
+func TestProtoObjectAccess(t *testing.T) {
+	require := require.New(t)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion6, RegionID: "us-east"}
+	exec.ExecResult.Reads = []ObjectRead{
+		{Object: "token", Key: []byte("supply"), ValueHash: ids.GenerateTestID()},
+		{Object: "token", Key: []byte("missing")},
+	}
+	exec.ExecResult.Calls = []CallFrame{
+		{Callee: "router", Function: "transfer"},
+		{Caller: "router", Callee: "token", Function: "debit", Depth: 1},
+	}
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.ExecResult.Reads, decoded.ExecResult.Reads)
+	require.Equal(exec.ExecResult.Calls, decoded.ExecResult.Calls)
+
+	// Calls deeper than the limit are rejected while decoding
+	var frame []byte
+	frame = appendString(frame, 2, "token")
+	frame = appendUint64(frame, 4, consts.MaxCallDepth+1)
+	b := appendUint64(nil, 1, uint64(consts.ActionVersion6))
+	b = protowire.AppendTag(b, 19, protowire.BytesType)
+	b = protowire.AppendBytes(b, frame)
+	m, err = parseProto(b)
+	require.NoError(err)
+	_, err = teeExecFromProto(m)
+	require.ErrorIs(err, ErrInvalidCallTrace)
+}
+
+func TestProtoResourceUsage(t *testing.T) {
+	require := require.New(t)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion13, RegionID: "us-east"}
+	exec.ExecResult.Usage = ResourceUsage{MemoryPages: 16, ValueStack: 512, HostCallDepth: 3, ElapsedMs: 250}
+	exec.ExecResult.Exhausted = ResourceDeadline
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.ExecResult.Usage, decoded.ExecResult.Usage)
+	require.Equal(ResourceDeadline, decoded.ExecResult.Exhausted)
+
+	// Earlier versions carry no usage
+	exec.Version = consts.ActionVersion12
+	m, err = parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err = teeExecFromProto(m)
+	require.NoError(err)
+	require.Zero(decoded.ExecResult.Usage)
+	require.Empty(decoded.ExecResult.Exhausted)
+}
+
+func TestProtoFailed(t *testing.T) {
+	require := require.New(t)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion16, RegionID: "us-east"}
+	exec.ExecResult.Failed = true
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.True(decoded.ExecResult.Failed)
+
+	// The status is attested
+	failed, err := exec.ExecResult.Digest()
+	require.NoError(err)
+	decoded.ExecResult.Failed = false
+	succeeded, err := decoded.ExecResult.Digest()
+	require.NoError(err)
+	require.NotEqual(failed, succeeded)
+
+	// Earlier versions carry no status
+	exec.Version = consts.ActionVersion15
+	m, err = parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err = teeExecFromProto(m)
+	require.NoError(err)
+	require.False(decoded.ExecResult.Failed)
+}
+
+func TestProtoLogs(t *testing.T) {
+	require := require.New(t)
+
+	exec := &TEEExecAction{Version: consts.ActionVersion14, RegionID: "us-east"}
+	exec.ExecResult.Logs = []storage.Log{
+		{Topics: []ids.ID{{1}, {2}}, Data: []byte("transfer")},
+		{Data: []byte("anonymous")},
+	}
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.ExecResult.Logs, decoded.ExecResult.Logs)
+
+	// Logs with too many topics are rejected while decoding
+	exec.ExecResult.Logs = []storage.Log{{Topics: make([]ids.ID, consts.MaxLogTopics+1)}}
+	m, err = parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	_, err = teeExecFromProto(m)
+	require.ErrorIs(err, ErrInvalidLog)
+}
+
+func TestProtoEventNonce(t *testing.T) {
+	require := require.New(t)
+
+	event := &SendEventAction{
+		Version:      consts.ActionVersion7,
+		IDTo:         "stream",
+		FunctionCall: "pay",
+		Parameters:   []byte{1},
+		Sender:       codectest.NewRandomAddress(),
+		Nonce:        3,
+	}
+	m, err := parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err := sendEventFromProto(m)
+	require.NoError(err)
+	require.Equal(event, decoded)
+
+	// Ordered events with the same payload are distinct
+	next := *event
+	next.Nonce++
+	require.NotEqual(event.EventID(), next.EventID())
+	unordered := *event
+	unordered.Sender, unordered.Nonce = codec.EmptyAddress, 0
+	require.Equal((&SendEventAction{IDTo: "stream", FunctionCall: "pay", Parameters: []byte{1}}).EventID(), unordered.EventID())
+
+	require.NoError(CheckEventNonce(2, 3))
+	require.NoError(CheckEventNonce(2, 0))
+	require.ErrorIs(CheckEventNonce(3, 3), ErrEventNonceUsed)
+	require.ErrorIs(CheckEventNonce(1, 3), ErrEventNonceGap)
+}
+
+func TestProtoEventTip(t *testing.T) {
+	require := require.New(t)
+
+	event := &SendEventAction{
+		Version:      consts.ActionVersion8,
+		IDTo:         "oracle",
+		FunctionCall: "update",
+		Tip:          250,
+	}
+	m, err := parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err := sendEventFromProto(m)
+	require.NoError(err)
+	require.Equal(event, decoded)
+	require.Equal(consts.EventPriorityHigh, decoded.Priority())
+
+	// Before v8 the tip is not encoded
+	event.Version = consts.ActionVersion7
+	m, err = parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err = sendEventFromProto(m)
+	require.NoError(err)
+	require.Zero(decoded.Tip)
+}
+
+func TestProtoEventEncrypted(t *testing.T) {
+	require := require.New(t)
+
+	event := &SendEventAction{
+		Version:      consts.ActionVersion9,
+		IDTo:         "wallet",
+		FunctionCall: "transfer",
+		Parameters:   []byte{1, 2, 3},
+		Encrypted:    true,
+	}
+	m, err := parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err := sendEventFromProto(m)
+	require.NoError(err)
+	require.Equal(event, decoded)
+
+	// Before v9 parameters are always plain
+	event.Version = consts.ActionVersion8
+	m, err = parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err = sendEventFromProto(m)
+	require.NoError(err)
+	require.False(decoded.Encrypted)
+}
+
+func TestProtoEventToName(t *testing.T) {
+	require := require.New(t)
+
+	event := &SendEventAction{
+		Version:      consts.ActionVersion11,
+		ToName:       "oracle.eth-usd",
+		FunctionCall: "update",
+	}
+	m, err := parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err := sendEventFromProto(m)
+	require.NoError(err)
+	require.Equal(event, decoded)
+
+	// Before v11 events target IDs only
+	event.Version = consts.ActionVersion10
+	m, err = parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err = sendEventFromProto(m)
+	require.NoError(err)
+	require.Empty(decoded.ToName)
+
+	require.NoError(ValidateName("oracle.eth-usd"))
+	require.ErrorIs(ValidateName("Oracle"), ErrInvalidName)
+	require.ErrorIs(ValidateName("ab"), ErrInvalidName)
+}
+
+func TestProtoObjectMetadata(t *testing.T) {
+	require := require.New(t)
+
+	create := &CreateObjectAction{
+		Version: consts.ActionVersion12,
+		ID:      "amm",
+		Code:    []byte{0, 'a', 's', 'm'},
+		Metadata: &storage.ObjectMetadata{
+			Name:        "AMM",
+			Description: "Constant product market maker",
+			RegionID:    "us-east",
+			Tags:        []string{"erc20-pool"},
+			Exports:     []string{"swap", "add_liquidity"},
+		},
+	}
+	m, err := parseProto(create.appendProto(nil))
+	require.NoError(err)
+	decoded, err := createObjectFromProto(m)
+	require.NoError(err)
+	require.Equal(create, decoded)
+	require.NoError(ValidateObjectMetadata(decoded.Metadata))
+	require.True(decoded.Metadata.HasTag("erc20-pool"))
+
+	// Before v12 objects carry no metadata
+	create.Version = consts.ActionVersion11
+	m, err = parseProto(create.appendProto(nil))
+	require.NoError(err)
+	decoded, err = createObjectFromProto(m)
+	require.NoError(err)
+	require.Nil(decoded.Metadata)
+
+	unlisted := *create.Metadata
+	unlisted.RegionID = ""
+	require.ErrorIs(ValidateObjectMetadata(&unlisted), ErrInvalidMetadata)
+	untagged := *create.Metadata
+	untagged.Tags = []string{""}
+	require.ErrorIs(ValidateObjectMetadata(&untagged), ErrInvalidMetadata)
+}
+
+func TestProtoEventIdempotencyKey(t *testing.T) {
+	require := require.New(t)
+
+	event := &SendEventAction{
+		Version:        consts.ActionVersion15,
+		IDTo:           "worker",
+		FunctionCall:   "run",
+		IdempotencyKey: "job-42",
+	}
+	m, err := parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err := sendEventFromProto(m)
+	require.NoError(err)
+	require.Equal(event, decoded)
+
+	// Before v15 events carry no key
+	event.Version = consts.ActionVersion14
+	m, err = parseProto(event.appendProto(nil))
+	require.NoError(err)
+	decoded, err = sendEventFromProto(m)
+	require.NoError(err)
+	require.Empty(decoded.IdempotencyKey)
+
+	// Keys are scoped to the sender and the target object
+	require.NotEqual(
+		storage.IdempotencyKey("worker", codec.Address{1}, "job-42"),
+		storage.IdempotencyKey("worker", codec.Address{2}, "job-42"),
+	)
+}
+
+func TestProtoPipeline(t *testing.T) {
+	require := require.New(t)
+
+	stage := &PipelineAction{
+		Version:      consts.ActionVersion15,
+		ID:           "parse",
+		FunctionCall: "run",
+		Parameters:   []byte{1, 2},
+		Next:         "render",
+	}
+	m, err := parseProto(stage.appendProto(nil))
+	require.NoError(err)
+	decoded, err := pipelineFromProto(m)
+	require.NoError(err)
+	require.Equal(stage, decoded)
+
+	// The stage's event is the one SendEventAction would queue
+	event := &SendEventAction{IDTo: "parse", FunctionCall: "run", Parameters: []byte{1, 2}}
+	require.Equal(event.EventID(), decoded.Event().EventID())
+}
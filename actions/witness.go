@@ -0,0 +1,244 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+// MaxWitnessDepth bounds proof length; 2^64 leaves is more than any region
+// can hold.
+const MaxWitnessDepth = 64
+
+// MaxWitnessEntries bounds the entries a witness may carry.
+const MaxWitnessEntries = consts.MaxStateUpdates
+
+var (
+	ErrInvalidWitness = errors.New("invalid state witness")
+	ErrWitnessKey     = errors.New("key not in state")
+	ErrWitnessTooLong = errors.New("state witness too long")
+)
+
+// A regional state root is a binary Merkle tree over the region's state
+// entries sorted by key. Leaves and inner nodes are domain separated and an
+// odd node at the end of a level is carried up unchanged. The root of an
+// empty region is [ids.Empty].
+const (
+	leafTag  = 0x00
+	innerTag = 0x01
+)
+
+// WitnessEntry proves that [Key] had [Value] in the state committed to by a
+// regional root.
+type WitnessEntry struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	// Index is the position of the leaf in key order
+	Index uint64 `json:"index"`
+	// Siblings are the sibling hashes from the leaf upwards. Levels where
+	// the node has no sibling are skipped.
+	Siblings []ids.ID `json:"siblings"`
+}
+
+// StateWitness carries part of a region's state with proofs against its
+// root, so a validator that does not hold the region's state can still
+// check what an execution read.
+type StateWitness struct {
+	LeafCount uint64         `json:"leaf_count"`
+	Entries   []WitnessEntry `json:"entries"`
+}
+
+func leafHash(key, value []byte) ids.ID {
+	h := sha256.New()
+	h.Write([]byte{leafTag})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(key))))
+	h.Write(key)
+	h.Write(value)
+	return ids.ID(h.Sum(nil))
+}
+
+func innerHash(left, right ids.ID) ids.ID {
+	h := sha256.New()
+	h.Write([]byte{innerTag})
+	h.Write(left[:])
+	h.Write(right[:])
+	return ids.ID(h.Sum(nil))
+}
+
+func sortedLeaves(entries map[string][]byte) ([]string, []ids.ID) {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	leaves := make([]ids.ID, len(keys))
+	for i, key := range keys {
+		leaves[i] = leafHash([]byte(key), entries[key])
+	}
+	return keys, leaves
+}
+
+func nextLevel(level []ids.ID) []ids.ID {
+	next := make([]ids.ID, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+		next = append(next, innerHash(level[i], level[i+1]))
+	}
+	return next
+}
+
+// RegionStateRoot computes the root over [entries], keyed by region state
+// key.
+func RegionStateRoot(entries map[string][]byte) ids.ID {
+	_, level := sortedLeaves(entries)
+	if len(level) == 0 {
+		return ids.Empty
+	}
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0]
+}
+
+// BuildWitness proves the values of [keys] in [entries].
+func BuildWitness(entries map[string][]byte, keys []string) (*StateWitness, error) {
+	sorted, leaves := sortedLeaves(entries)
+	w := &StateWitness{
+		LeafCount: uint64(len(leaves)),
+		Entries:   make([]WitnessEntry, 0, len(keys)),
+	}
+	for _, key := range keys {
+		i := sort.SearchStrings(sorted, key)
+		if i == len(sorted) || sorted[i] != key {
+			return nil, fmt.Errorf("%w: %q", ErrWitnessKey, key)
+		}
+		w.Entries = append(w.Entries, WitnessEntry{
+			Key:   []byte(key),
+			Value: entries[key],
+			Index: uint64(i),
+		})
+	}
+	depth := 0
+	for level := leaves; len(level) > 1; level = nextLevel(level) {
+		for j := range w.Entries {
+			e := &w.Entries[j]
+			sibling := (e.Index >> depth) ^ 1
+			if sibling < uint64(len(level)) {
+				e.Siblings = append(e.Siblings, level[sibling])
+			}
+		}
+		depth++
+	}
+	return w, nil
+}
+
+// Verify checks every entry of [w] against [root].
+func (w *StateWitness) Verify(root ids.ID) error {
+	if w.LeafCount == 0 {
+		return fmt.Errorf("%w: empty tree", ErrInvalidWitness)
+	}
+	for _, e := range w.Entries {
+		if e.Index >= w.LeafCount || len(e.Siblings) > MaxWitnessDepth {
+			return fmt.Errorf("%w: %x", ErrInvalidWitness, e.Key)
+		}
+		node := leafHash(e.Key, e.Value)
+		pos, width := e.Index, w.LeafCount
+		siblings := e.Siblings
+		for width > 1 {
+			sibling := pos ^ 1
+			if sibling < width {
+				if len(siblings) == 0 {
+					return fmt.Errorf("%w: %x", ErrInvalidWitness, e.Key)
+				}
+				if pos&1 == 0 {
+					node = innerHash(node, siblings[0])
+				} else {
+					node = innerHash(siblings[0], node)
+				}
+				siblings = siblings[1:]
+			}
+			pos >>= 1
+			width = (width + 1) / 2
+		}
+		if len(siblings) != 0 || node != root {
+			return fmt.Errorf("%w: %x", ErrInvalidWitness, e.Key)
+		}
+	}
+	return nil
+}
+
+// Values returns the witnessed entries keyed by state key.
+func (w *StateWitness) Values() map[string][]byte {
+	values := make(map[string][]byte, len(w.Entries))
+	for _, e := range w.Entries {
+		values[string(e.Key)] = e.Value
+	}
+	return values
+}
+
+func packWitness(p *codec.Packer, w *StateWitness) {
+	p.PackUint64(w.LeafCount)
+	p.PackInt(len(w.Entries))
+	for _, e := range w.Entries {
+		p.PackBytes(e.Key)
+		p.PackBytes(e.Value)
+		p.PackUint64(e.Index)
+		p.PackInt(len(e.Siblings))
+		for _, sibling := range e.Siblings {
+			p.PackID(sibling)
+		}
+	}
+}
+
+func unpackWitness(p *codec.Packer) (*StateWitness, error) {
+	leafCount, err := p.UnpackUint64()
+	if err != nil {
+		return nil, err
+	}
+	w := &StateWitness{LeafCount: leafCount}
+	n, err := p.UnpackInt()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > MaxWitnessEntries {
+		return nil, ErrWitnessTooLong
+	}
+	w.Entries = make([]WitnessEntry, n)
+	for i := range w.Entries {
+		e := &w.Entries[i]
+		if e.Key, err = p.UnpackBytes(); err != nil {
+			return nil, err
+		}
+		if e.Value, err = p.UnpackBytes(); err != nil {
+			return nil, err
+		}
+		if e.Index, err = p.UnpackUint64(); err != nil {
+			return nil, err
+		}
+		depth, err := p.UnpackInt()
+		if err != nil {
+			return nil, err
+		}
+		if depth < 0 || depth > MaxWitnessDepth {
+			return nil, ErrWitnessTooLong
+		}
+		e.Siblings = make([]ids.ID, depth)
+		for j := range e.Siblings {
+			p.UnpackID(true, &e.Siblings[j])
+		}
+	}
+	return w, p.Err()
+}
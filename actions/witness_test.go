@@ -0,0 +1,89 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package actions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+
+	vmconsts "github.com/rhombus-tech/vm/consts"
+)
+
+func TestStateWitness(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		t.Run(fmt.Sprintf("leaves=%d", n), func(t *testing.T) {
+			require := require.New(t)
+
+			entries := make(map[string][]byte, n)
+			keys := make([]string, 0, n)
+			for i := 0; i < n; i++ {
+				key := fmt.Sprintf("k%d", i)
+				entries[key] = []byte{byte(i)}
+				keys = append(keys, key)
+			}
+			root := RegionStateRoot(entries)
+			w, err := BuildWitness(entries, keys)
+			require.NoError(err)
+			require.NoError(w.Verify(root))
+			require.Equal(entries, w.Values())
+
+			w.Entries[n-1].Value = []byte{0xff}
+			require.ErrorIs(w.Verify(root), ErrInvalidWitness)
+		})
+	}
+}
+
+func TestStateWitnessRejectsMissingKey(t *testing.T) {
+	_, err := BuildWitness(map[string][]byte{"a": {1}}, []string{"b"})
+	require.ErrorIs(t, err, ErrWitnessKey)
+}
+
+func TestPreconditions(t *testing.T) {
+	require := require.New(t)
+
+	entries := map[string][]byte{"a": {1}, "b": {2}, "c": {3}}
+	root := RegionStateRoot(entries)
+	w, err := BuildWitness(entries, []string{"b"})
+	require.NoError(err)
+
+	exec := &TEEExecAction{Version: vmconsts.ActionVersion3, RegionID: "us-east", Witness: w}
+	exec.ExecResult.PreStateRoot = root
+	require.NoError(exec.VerifyPreconditions(root))
+	require.ErrorIs(exec.VerifyPreconditions(ids.Empty), ErrStateRootMismatch)
+
+	exec.ExecResult.PreStateRoot = ids.Empty
+	require.ErrorIs(exec.VerifyPreconditions(ids.Empty), ErrInvalidWitness)
+}
+
+func TestWitnessWireFormats(t *testing.T) {
+	require := require.New(t)
+
+	entries := map[string][]byte{"a": {1}, "b": {2}, "c": {3}}
+	w, err := BuildWitness(entries, []string{"a", "c"})
+	require.NoError(err)
+	exec := &TEEExecAction{Version: vmconsts.ActionVersion3, RegionID: "us-east", Witness: w}
+	exec.ExecResult.PreStateRoot = RegionStateRoot(entries)
+	exec.ExecResult.StateRoot = ids.ID{1}
+
+	m, err := parseProto(exec.appendProto(nil))
+	require.NoError(err)
+	decoded, err := teeExecFromProto(m)
+	require.NoError(err)
+	require.Equal(exec.ExecResult.PreStateRoot, decoded.ExecResult.PreStateRoot)
+	require.Equal(exec.ExecResult.StateRoot, decoded.ExecResult.StateRoot)
+	require.Equal(w, decoded.Witness)
+
+	p := codec.NewWriter(0, consts.NetworkSizeLimit)
+	packWitness(p, w)
+	require.NoError(p.Err())
+	unpacked, err := unpackWitness(codec.NewReader(p.Bytes(), consts.NetworkSizeLimit))
+	require.NoError(err)
+	require.Equal(w, unpacked)
+}
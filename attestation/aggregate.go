@@ -0,0 +1,114 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/crypto/bls"
+)
+
+var (
+	ErrUnknownFlags       = errors.New("unknown attestation flags")
+	ErrNotBLSCapable      = errors.New("enclave key is not a BLS key")
+	ErrAggregateSignature = errors.New("invalid aggregate signature")
+)
+
+// Flags negotiate how an attestation is encoded and verified. They are
+// carried in the attestation header of the actions that support them.
+type Flags uint8
+
+const (
+	// FlagAggregated marks the first attestation of an enclave pair as
+	// carrying one BLS signature aggregating both enclaves' signatures
+	// over the same digest. The second attestation then carries none.
+	FlagAggregated Flags = 1 << iota
+
+	knownFlags = FlagAggregated
+)
+
+func (f Flags) Valid() bool {
+	return f&^knownFlags == 0
+}
+
+func (f Flags) Aggregated() bool {
+	return f&FlagAggregated != 0
+}
+
+// BLSCapable reports whether [pubKey], the key an enclave registered, is a
+// BLS public key, so the enclave's signatures can be aggregated with those
+// of other BLS-capable enclaves.
+func BLSCapable(pubKey []byte) bool {
+	if len(pubKey) != bls.PublicKeyLen {
+		return false
+	}
+	_, err := bls.PublicKeyFromBytes(pubKey)
+	return err == nil
+}
+
+// Aggregate combines BLS [signatures] over one digest into a single
+// signature of the same size.
+func Aggregate(signatures ...[]byte) ([]byte, error) {
+	sigs := make([]*bls.Signature, len(signatures))
+	for i, raw := range signatures {
+		sig, err := bls.SignatureFromBytes(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAggregateSignature, err)
+		}
+		sigs[i] = sig
+	}
+	aggregate, err := bls.AggregateSignatures(sigs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAggregateSignature, err)
+	}
+	return bls.SignatureToBytes(aggregate), nil
+}
+
+// VerifyAggregate checks that [signature] aggregates a signature over
+// [digest] by each of [pubKeys]. As every enclave signed the same digest,
+// one pairing check covers them all.
+func VerifyAggregate(digest, signature []byte, pubKeys ...[]byte) error {
+	aggregateKey, err := aggregatePublicKeys(pubKeys)
+	if err != nil {
+		return err
+	}
+	sig, err := bls.SignatureFromBytes(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrAggregateSignature, err)
+	}
+	if !bls.Verify(digest, aggregateKey, sig) {
+		return ErrAggregateSignature
+	}
+	return nil
+}
+
+// AggregatePublicKeys combines BLS [pubKeys] into one key, which verifies
+// the signatures aggregated from theirs over a common digest.
+func AggregatePublicKeys(pubKeys ...[]byte) ([]byte, error) {
+	aggregateKey, err := aggregatePublicKeys(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	return bls.PublicKeyToBytes(aggregateKey), nil
+}
+
+func aggregatePublicKeys(pubKeys [][]byte) (*bls.PublicKey, error) {
+	keys := make([]*bls.PublicKey, len(pubKeys))
+	for i, raw := range pubKeys {
+		if len(raw) != bls.PublicKeyLen {
+			return nil, ErrNotBLSCapable
+		}
+		key, err := bls.PublicKeyFromBytes(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotBLSCapable, err)
+		}
+		keys[i] = key
+	}
+	aggregateKey, err := bls.AggregatePublicKeys(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAggregateSignature, err)
+	}
+	return aggregateKey, nil
+}
@@ -0,0 +1,315 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package attestation defines the signed statement an enclave attaches to
+// its output: which enclave signed, its signature, and the Roughtime stamps
+// bounding when it signed.
+package attestation
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var (
+	ErrUnknownEnclaveType = errors.New("unknown enclave type")
+	ErrTooFewStamps       = errors.New("too few roughtime stamps")
+	ErrTooManyStamps      = errors.New("too many roughtime stamps")
+	ErrInvalidStamp       = errors.New("invalid roughtime stamp")
+	ErrUntrustedStamp     = errors.New("stamp of untrusted roughtime server")
+	ErrDuplicateStamp     = errors.New("several stamps of one roughtime server")
+	ErrNoStampQuorum      = errors.New("too few roughtime servers agree")
+	ErrInvalidSignature   = errors.New("invalid enclave signature")
+)
+
+// MinStamps is how many Roughtime stamps an attestation needs for its
+// median to tolerate one faulty server.
+const MinStamps = 3
+
+// StampQuorum sets how many Roughtime servers must agree on the time for
+// an attestation's stamps to be trusted. A median alone still moves when a
+// third of the servers lie, so stamps far from it are discarded first.
+type StampQuorum struct {
+	// Servers is how many distinct servers must stamp within Radius of the
+	// median. It is never less than [MinStamps].
+	Servers int
+	// Radius is how far, in seconds, a stamp may be from the median of all
+	// stamps before it is discarded as an outlier
+	Radius uint64
+}
+
+// DefaultStampQuorum applies until governance sets
+// [consts.ParamStampQuorum] and [consts.ParamStampRadius].
+var DefaultStampQuorum = StampQuorum{
+	Servers: MinStamps,
+	Radius:  consts.StampRadius,
+}
+
+// EnclaveType is the TEE technology that produced an attestation.
+type EnclaveType string
+
+const (
+	SGX   EnclaveType = "SGX"
+	SEV   EnclaveType = "SEV"
+	Nitro EnclaveType = "NITRO"
+	CCA   EnclaveType = "CCA"
+)
+
+// Vendor is who makes the hardware root of trust of [t]. Enclaves of one
+// vendor may share a compromise.
+func (t EnclaveType) Vendor() string {
+	switch t {
+	case SGX:
+		return "intel"
+	case SEV:
+		return "amd"
+	case Nitro:
+		return "aws"
+	case CCA:
+		return "arm"
+	default:
+		return ""
+	}
+}
+
+func (t EnclaveType) Valid() bool {
+	for _, valid := range EnclaveTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Stamp is a Roughtime server's signed statement of the time.
+type Stamp struct {
+	ServerID string `serialize:"true" json:"server_id"`
+	// Time is in unix seconds
+	Time      uint64 `serialize:"true" json:"time"`
+	Signature []byte `serialize:"true" json:"signature"`
+}
+
+func (s Stamp) Timestamp() time.Time {
+	return time.Unix(int64(s.Time), 0)
+}
+
+// Verify checks the stamp signature against its server's key.
+func (Stamp) Verify() bool {
+	// Implement Roughtime signature verification
+	return true // placeholder
+}
+
+// stampContext keeps stamp signatures from being valid for anything else
+// a server signs.
+const stampContext = "shuttle roughtime stamp v1\x00"
+
+// Message is what a server signs for [s]: its ID and the time.
+func (s Stamp) Message() []byte {
+	msg := make([]byte, 0, len(stampContext)+4+len(s.ServerID)+8)
+	msg = append(msg, stampContext...)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(s.ServerID)))
+	msg = append(msg, s.ServerID...)
+	return binary.BigEndian.AppendUint64(msg, s.Time)
+}
+
+// StampKeys maps the ID of each trusted Roughtime server to the ed25519
+// keys its stamps may be signed with. A server has several keys while a
+// rotation is in its grace period.
+type StampKeys map[string][]ed25519.PublicKey
+
+// verify checks [s] is signed by one of its server's keys.
+func (k StampKeys) verify(s Stamp) error {
+	keys, ok := k[s.ServerID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUntrustedStamp, s.ServerID)
+	}
+	msg := s.Message()
+	for _, key := range keys {
+		if ed25519.Verify(key, msg, s.Signature) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidStamp, s.ServerID)
+}
+
+// Attestation is an enclave's signature over a digest of its output.
+type Attestation struct {
+	EnclaveType EnclaveType `serialize:"true" json:"enclave_type"`
+	EnclaveID   []byte      `serialize:"true" json:"enclave_id"`
+	Signature   []byte      `serialize:"true" json:"signature"`
+	Stamps      []Stamp     `serialize:"true" json:"stamps"`
+	// Flags are only encoded by actions whose wire format has room for
+	// them, from TEEExecAction's [consts.ActionVersion10]
+	Flags Flags `json:"flags,omitempty"`
+}
+
+// Verify checks that [a] signs [digest] with [pubKey], the key registered
+// for its enclave.
+func (a *Attestation) Verify(digest, pubKey []byte) error {
+	if !a.EnclaveType.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownEnclaveType, a.EnclaveType)
+	}
+	// Implement signature verification based on enclave type
+	return nil // placeholder
+}
+
+// StampBounds returns the earliest and latest stamp times of [a], in unix
+// seconds, without verifying the stamps. [ok] is false if [a] has none.
+func (a *Attestation) StampBounds() (earliest, latest uint64, ok bool) {
+	if len(a.Stamps) == 0 {
+		return 0, 0, false
+	}
+	earliest, latest = a.Stamps[0].Time, a.Stamps[0].Time
+	for _, stamp := range a.Stamps[1:] {
+		earliest = min(earliest, stamp.Time)
+		latest = max(latest, stamp.Time)
+	}
+	return earliest, latest, true
+}
+
+// MedianTime verifies the stamps of [a] and returns their median time in
+// unix seconds.
+func (a *Attestation) MedianTime() (uint64, error) {
+	return a.TrustedMedianTime(nil, DefaultStampQuorum)
+}
+
+// TrustedMedianTime is [MedianTime] for stamps of the servers in [keys]:
+// each stamp must be signed by one of its server's keys, and each server
+// may stamp once. A nil [keys] trusts any server. Stamps further than
+// [quorum] Radius from the median of all stamps are discarded, and the
+// median of the rest is returned if enough distinct servers remain.
+func (a *Attestation) TrustedMedianTime(keys StampKeys, quorum StampQuorum) (uint64, error) {
+	servers := max(quorum.Servers, MinStamps)
+	if len(a.Stamps) < servers {
+		return 0, ErrTooFewStamps
+	}
+	times := make([]uint64, len(a.Stamps))
+	seen := make(map[string]struct{}, len(a.Stamps))
+	for i, stamp := range a.Stamps {
+		if keys == nil {
+			if !stamp.Verify() {
+				return 0, fmt.Errorf("%w: %s", ErrInvalidStamp, stamp.ServerID)
+			}
+		} else if err := keys.verify(stamp); err != nil {
+			return 0, err
+		}
+		// A server stamping twice would count twice toward the quorum
+		if _, ok := seen[stamp.ServerID]; ok {
+			return 0, fmt.Errorf("%w: %s", ErrDuplicateStamp, stamp.ServerID)
+		}
+		seen[stamp.ServerID] = struct{}{}
+		times[i] = stamp.Time
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+	median := times[len(times)/2]
+	agreeing := times[:0]
+	for _, t := range times {
+		if WithinDrift(t, median, quorum.Radius) {
+			agreeing = append(agreeing, t)
+		}
+	}
+	if len(agreeing) < servers {
+		return 0, fmt.Errorf("%w: %d of %d", ErrNoStampQuorum, len(agreeing), servers)
+	}
+	return agreeing[len(agreeing)/2], nil
+}
+
+// WithinDrift reports whether [stampTime] is within [maxDrift] seconds of
+// [now], in either direction.
+func WithinDrift(stampTime, now, maxDrift uint64) bool {
+	if stampTime > now {
+		return stampTime-now <= maxDrift
+	}
+	return now-stampTime <= maxDrift
+}
+
+// Hash is the canonical hash of [a]. Every field is length prefixed, so
+// distinct attestations never share an encoding.
+func (a *Attestation) Hash() ids.ID {
+	h := sha256.New()
+	writeLenPrefixed := func(b []byte) {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(b))))
+		h.Write(b)
+	}
+	writeLenPrefixed([]byte(a.EnclaveType))
+	writeLenPrefixed(a.EnclaveID)
+	writeLenPrefixed(a.Signature)
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(a.Stamps))))
+	for _, stamp := range a.Stamps {
+		writeLenPrefixed([]byte(stamp.ServerID))
+		h.Write(binary.BigEndian.AppendUint64(nil, stamp.Time))
+		writeLenPrefixed(stamp.Signature)
+	}
+	return ids.ID(h.Sum(nil))
+}
+
+func (a *Attestation) Marshal(p *codec.Packer) {
+	p.PackString(string(a.EnclaveType))
+	p.PackBytes(a.EnclaveID)
+	p.PackBytes(a.Signature)
+	PackStamps(p, a.Stamps)
+}
+
+func Unmarshal(p *codec.Packer) (*Attestation, error) {
+	enclaveType, err := p.UnpackString()
+	if err != nil {
+		return nil, err
+	}
+	a := &Attestation{EnclaveType: EnclaveType(enclaveType)}
+	if a.EnclaveID, err = p.UnpackBytes(); err != nil {
+		return nil, err
+	}
+	if a.Signature, err = p.UnpackBytes(); err != nil {
+		return nil, err
+	}
+	if a.Stamps, err = UnpackStamps(p); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// PackStamps packs [stamps] with a count prefix. Actions that interleave
+// attestation fields with their own use it directly.
+func PackStamps(p *codec.Packer, stamps []Stamp) {
+	p.PackInt(len(stamps))
+	for _, stamp := range stamps {
+		p.PackString(stamp.ServerID)
+		p.PackUint64(stamp.Time)
+		p.PackBytes(stamp.Signature)
+	}
+}
+
+func UnpackStamps(p *codec.Packer) ([]Stamp, error) {
+	n, err := p.UnpackInt()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > consts.MaxTimeStampsCount {
+		return nil, ErrTooManyStamps
+	}
+	stamps := make([]Stamp, n)
+	for i := range stamps {
+		if stamps[i].ServerID, err = p.UnpackString(); err != nil {
+			return nil, err
+		}
+		if stamps[i].Time, err = p.UnpackUint64(); err != nil {
+			return nil, err
+		}
+		if stamps[i].Signature, err = p.UnpackBytes(); err != nil {
+			return nil, err
+		}
+	}
+	return stamps, nil
+}
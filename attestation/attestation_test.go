@@ -0,0 +1,152 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/stretchr/testify/require"
+)
+
+func testAttestation() *Attestation {
+	return &Attestation{
+		EnclaveType: SGX,
+		EnclaveID:   []byte{1, 2, 3},
+		Signature:   []byte{4, 5, 6},
+		Stamps: []Stamp{
+			{ServerID: "a", Time: 12, Signature: []byte{7}},
+			{ServerID: "b", Time: 10, Signature: []byte{8}},
+			{ServerID: "c", Time: 11, Signature: []byte{9}},
+		},
+	}
+}
+
+func TestMedianTime(t *testing.T) {
+	require := require.New(t)
+
+	a := testAttestation()
+	median, err := a.MedianTime()
+	require.NoError(err)
+	require.Equal(uint64(11), median)
+
+	a.Stamps = a.Stamps[:2]
+	_, err = a.MedianTime()
+	require.ErrorIs(err, ErrTooFewStamps)
+}
+
+func TestTrustedMedianTime(t *testing.T) {
+	require := require.New(t)
+
+	keys := StampKeys{}
+	privs := map[string]ed25519.PrivateKey{}
+	for _, id := range []string{"a", "b", "c"} {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		require.NoError(err)
+		keys[id] = []ed25519.PublicKey{pub}
+		privs[id] = priv
+	}
+	a := testAttestation()
+	for i := range a.Stamps {
+		a.Stamps[i].Signature = ed25519.Sign(privs[a.Stamps[i].ServerID], a.Stamps[i].Message())
+	}
+	median, err := a.TrustedMedianTime(keys, DefaultStampQuorum)
+	require.NoError(err)
+	require.Equal(uint64(11), median)
+
+	// A rotated key is accepted alongside the old one
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(err)
+	keys["a"] = append(keys["a"], pub)
+	a.Stamps[0].Signature = ed25519.Sign(priv, a.Stamps[0].Message())
+	_, err = a.TrustedMedianTime(keys, DefaultStampQuorum)
+	require.NoError(err)
+
+	a.Stamps[0].Time++
+	_, err = a.TrustedMedianTime(keys, DefaultStampQuorum)
+	require.ErrorIs(err, ErrInvalidStamp)
+
+	a = testAttestation()
+	a.Stamps[0].ServerID = "d"
+	_, err = a.TrustedMedianTime(keys, DefaultStampQuorum)
+	require.ErrorIs(err, ErrUntrustedStamp)
+
+	a = testAttestation()
+	for i := range a.Stamps {
+		a.Stamps[i].Signature = ed25519.Sign(privs[a.Stamps[i].ServerID], a.Stamps[i].Message())
+	}
+	a.Stamps[1] = a.Stamps[2]
+	_, err = a.TrustedMedianTime(keys, DefaultStampQuorum)
+	require.ErrorIs(err, ErrDuplicateStamp)
+}
+
+func TestStampQuorum(t *testing.T) {
+	require := require.New(t)
+
+	// One server far off is discarded and does not move the median
+	a := testAttestation()
+	a.Stamps = append(a.Stamps, Stamp{ServerID: "d", Time: 1_000})
+	median, err := a.TrustedMedianTime(nil, DefaultStampQuorum)
+	require.NoError(err)
+	require.Equal(uint64(11), median)
+
+	// Without it, too few servers agree
+	_, err = a.TrustedMedianTime(nil, StampQuorum{Servers: 4, Radius: 10})
+	require.ErrorIs(err, ErrNoStampQuorum)
+
+	a.Stamps = append(a.Stamps, Stamp{ServerID: "e", Time: 1_000}, Stamp{ServerID: "f", Time: 1_000})
+	_, err = a.TrustedMedianTime(nil, StampQuorum{Servers: 4, Radius: 10})
+	require.ErrorIs(err, ErrNoStampQuorum)
+
+	// A server stamping twice does not count twice
+	a = testAttestation()
+	a.Stamps = append(a.Stamps, a.Stamps[0])
+	_, err = a.TrustedMedianTime(nil, StampQuorum{Servers: 4, Radius: 10})
+	require.ErrorIs(err, ErrDuplicateStamp)
+}
+
+func TestVerifyRejectsUnknownType(t *testing.T) {
+	a := testAttestation()
+	a.EnclaveType = "TDX"
+	require.ErrorIs(t, a.Verify(nil, nil), ErrUnknownEnclaveType)
+}
+
+func TestHashCoversEveryField(t *testing.T) {
+	require := require.New(t)
+
+	base := testAttestation().Hash()
+	for _, mutate := range []func(*Attestation){
+		func(a *Attestation) { a.EnclaveType = SEV },
+		func(a *Attestation) { a.EnclaveID = []byte{1, 2} },
+		func(a *Attestation) { a.Signature = nil },
+		func(a *Attestation) { a.Stamps[0].Time++ },
+		func(a *Attestation) { a.Stamps = a.Stamps[:2] },
+	} {
+		a := testAttestation()
+		mutate(a)
+		require.NotEqual(base, a.Hash())
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	a := testAttestation()
+	p := codec.NewWriter(0, consts.NetworkSizeLimit)
+	a.Marshal(p)
+	require.NoError(p.Err())
+	decoded, err := Unmarshal(codec.NewReader(p.Bytes(), consts.NetworkSizeLimit))
+	require.NoError(err)
+	require.Equal(a, decoded)
+
+	b, err := json.Marshal(a)
+	require.NoError(err)
+	require.Contains(string(b), `"enclave_id":"0x010203"`)
+	var fromJSON Attestation
+	require.NoError(json.Unmarshal(b, &fromJSON))
+	require.Equal(a, &fromJSON)
+}
@@ -0,0 +1,185 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// A minimal CBOR (RFC 8949) codec covering what Nitro attestation documents
+// use: integers, byte and text strings, arrays, maps, tags and the simple
+// values false, true and null. Indefinite lengths and floats are rejected.
+// Decoded items are uint64, int64 (negative only), []byte, string, []any,
+// map[any]any, bool or nil.
+
+var ErrMalformedCBOR = errors.New("malformed CBOR")
+
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+
+	cborFalse = 20
+	cborTrue  = 21
+	cborNull  = 22
+
+	maxCBORDepth = 16
+)
+
+type cborDecoder struct {
+	b []byte
+}
+
+func decodeCBOR(b []byte) (any, error) {
+	d := &cborDecoder{b: b}
+	v, err := d.item(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.b) != 0 {
+		return nil, ErrMalformedCBOR
+	}
+	return v, nil
+}
+
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if len(d.b) == 0 {
+		return 0, 0, ErrMalformedCBOR
+	}
+	major, info := d.b[0]>>5, d.b[0]&0x1f
+	d.b = d.b[1:]
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	n := 0
+	switch info {
+	case 24:
+		n = 1
+	case 25:
+		n = 2
+	case 26:
+		n = 4
+	case 27:
+		n = 8
+	default:
+		return 0, 0, ErrMalformedCBOR
+	}
+	if len(d.b) < n {
+		return 0, 0, ErrMalformedCBOR
+	}
+	var v uint64
+	for _, c := range d.b[:n] {
+		v = v<<8 | uint64(c)
+	}
+	d.b = d.b[n:]
+	return major, v, nil
+}
+
+func (d *cborDecoder) item(depth int) (any, error) {
+	if depth > maxCBORDepth {
+		return nil, ErrMalformedCBOR
+	}
+	major, v, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return v, nil
+	case cborNegInt:
+		if v > math.MaxInt64 {
+			return nil, ErrMalformedCBOR
+		}
+		return -1 - int64(v), nil
+	case cborBytes, cborText:
+		if v > uint64(len(d.b)) {
+			return nil, ErrMalformedCBOR
+		}
+		s := d.b[:v:v]
+		d.b = d.b[v:]
+		if major == cborText {
+			return string(s), nil
+		}
+		return s, nil
+	case cborArray:
+		// Every item takes at least one byte
+		if v > uint64(len(d.b)) {
+			return nil, ErrMalformedCBOR
+		}
+		items := make([]any, v)
+		for i := range items {
+			if items[i], err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case cborMap:
+		if v > uint64(len(d.b))/2 {
+			return nil, ErrMalformedCBOR
+		}
+		m := make(map[any]any, v)
+		for i := uint64(0); i < v; i++ {
+			key, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case uint64, int64, string:
+			default:
+				return nil, ErrMalformedCBOR
+			}
+			if _, ok := m[key]; ok {
+				return nil, ErrMalformedCBOR
+			}
+			if m[key], err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case cborTag:
+		// Tags only annotate the item that follows
+		return d.item(depth + 1)
+	default:
+		switch v {
+		case cborFalse:
+			return false, nil
+		case cborTrue:
+			return true, nil
+		case cborNull:
+			return nil, nil
+		}
+		return nil, ErrMalformedCBOR
+	}
+}
+
+func appendCBORHead(b []byte, major byte, v uint64) []byte {
+	major <<= 5
+	switch {
+	case v < 24:
+		return append(b, major|byte(v))
+	case v <= math.MaxUint8:
+		return append(b, major|24, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), v)
+	}
+}
+
+func appendCBORBytes(b, v []byte) []byte {
+	return append(appendCBORHead(b, cborBytes, uint64(len(v))), v...)
+}
+
+func appendCBORText(b []byte, v string) []byte {
+	return append(appendCBORHead(b, cborText, uint64(len(v))), v...)
+}
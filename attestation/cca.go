@@ -0,0 +1,293 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+)
+
+// An Arm CCA attestation token is a CBOR map of two COSE_Sign1 tokens. The
+// realm token is signed by the Realm Attestation Key (RAK) and carries the
+// realm's measurements and challenge. The platform token is signed by the
+// CPAK, a key endorsed by the silicon vendor, and binds the RAK through its
+// challenge.
+
+const (
+	// MaxCCATokenSize bounds the tokens accepted for verification
+	MaxCCATokenSize = 16 * 1024
+
+	ccaPlatformToken = 44234
+	ccaRealmToken    = 44241
+
+	eatChallenge = 10
+
+	realmPersonalization = 44235
+	realmHashAlgorithm   = 44236
+	realmPublicKey       = 44237
+	realmInitialMeas     = 44238
+	realmExtensibleMeas  = 44239
+	realmPubKeyHashAlg   = 44240
+
+	platformProfile        = 265
+	platformInstanceID     = 256
+	platformLifecycle      = 2395
+	platformImplementation = 2396
+
+	// Lifecycle states from 0x3000 to 0x30ff are "secured": debug is
+	// disabled and the platform keys are provisioned
+	lifecycleSecuredMin = 0x3000
+	lifecycleSecuredMax = 0x30ff
+)
+
+var (
+	ErrMalformedCCAToken   = errors.New("malformed CCA attestation token")
+	ErrCCATokenTooLarge    = errors.New("CCA attestation token too large")
+	ErrUnknownCCAPlatform  = errors.New("CCA platform token not signed by a trusted key")
+	ErrInvalidCCARealmSig  = errors.New("invalid CCA realm token signature")
+	ErrCCABindingMismatch  = errors.New("CCA platform challenge does not bind the realm key")
+	ErrCCAPlatformInsecure = errors.New("CCA platform not in a secured lifecycle state")
+)
+
+// CCAToken holds the claims of a verified CCA attestation token.
+type CCAToken struct {
+	// Realm claims
+	Challenge              []byte
+	Personalization        []byte
+	InitialMeasurement     []byte
+	ExtensibleMeasurements [][]byte
+	RealmHashAlgorithm     string
+	RAK                    []byte
+	RAKHashAlgorithm       string
+
+	// Platform claims
+	Profile          string
+	PlatformNonce    []byte
+	ImplementationID []byte
+	InstanceID       []byte
+	Lifecycle        uint64
+}
+
+// VerifyCCAToken checks that the platform token of [token] is signed by one
+// of [platformKeys] and binds the RAK that signed the realm token, and
+// returns the claims of both.
+func VerifyCCAToken(token []byte, platformKeys []*ecdsa.PublicKey) (*CCAToken, error) {
+	if len(token) > MaxCCATokenSize {
+		return nil, ErrCCATokenTooLarge
+	}
+	v, err := decodeCBOR(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedCCAToken, err)
+	}
+	collection, ok := v.(map[any]any)
+	if !ok {
+		return nil, ErrMalformedCCAToken
+	}
+	platformBytes, ok1 := collection[uint64(ccaPlatformToken)].([]byte)
+	realmBytes, ok2 := collection[uint64(ccaRealmToken)].([]byte)
+	if !ok1 || !ok2 {
+		return nil, ErrMalformedCCAToken
+	}
+	platform, err := decodeCOSESign1(platformBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedCCAToken, err)
+	}
+	realm, err := decodeCOSESign1(realmBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedCCAToken, err)
+	}
+
+	t := &CCAToken{}
+	if err := t.parseRealmClaims(realm.payload); err != nil {
+		return nil, err
+	}
+	if err := t.parsePlatformClaims(platform.payload); err != nil {
+		return nil, err
+	}
+
+	// The realm token is signed by the RAK it carries; the platform token
+	// vouches for that RAK
+	rak, err := parseECPoint(t.RAK)
+	if err != nil {
+		return nil, err
+	}
+	if err := realm.verify(rak); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCCARealmSig, err)
+	}
+	trusted := false
+	for _, key := range platformKeys {
+		if platform.verify(key) == nil {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, ErrUnknownCCAPlatform
+	}
+	hash, err := ccaHash(t.RAKHashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(t.RAK)
+	if !bytes.Equal(h.Sum(nil), t.PlatformNonce) {
+		return nil, ErrCCABindingMismatch
+	}
+	if t.Lifecycle < lifecycleSecuredMin || t.Lifecycle > lifecycleSecuredMax {
+		return nil, fmt.Errorf("%w: %#x", ErrCCAPlatformInsecure, t.Lifecycle)
+	}
+	return t, nil
+}
+
+// CCAPlatform holds the keys and claims needed to sign CCA tokens. Real
+// tokens are produced by the RMM and platform firmware; this is for tests
+// and mock workers.
+type CCAPlatform struct {
+	CPAK             *ecdsa.PrivateKey
+	RAK              *ecdsa.PrivateKey
+	ImplementationID []byte
+	InstanceID       []byte
+	Lifecycle        uint64
+}
+
+// SignCCAToken builds a token for a realm with [initialMeasurement] that
+// answers [challenge].
+func (p *CCAPlatform) SignCCAToken(challenge, initialMeasurement []byte) ([]byte, error) {
+	rak := elliptic.Marshal(p.RAK.Curve, p.RAK.X, p.RAK.Y)
+	var realm []byte
+	realm = appendCBORHead(realm, cborMap, 7)
+	realm = appendCBORHead(realm, cborUint, eatChallenge)
+	realm = appendCBORBytes(realm, challenge)
+	realm = appendCBORHead(realm, cborUint, realmPersonalization)
+	realm = appendCBORBytes(realm, make([]byte, 64))
+	realm = appendCBORHead(realm, cborUint, realmHashAlgorithm)
+	realm = appendCBORText(realm, "sha-256")
+	realm = appendCBORHead(realm, cborUint, realmPublicKey)
+	realm = appendCBORBytes(realm, rak)
+	realm = appendCBORHead(realm, cborUint, realmInitialMeas)
+	realm = appendCBORBytes(realm, initialMeasurement)
+	realm = appendCBORHead(realm, cborUint, realmExtensibleMeas)
+	realm = appendCBORHead(realm, cborArray, 4)
+	for i := 0; i < 4; i++ {
+		realm = appendCBORBytes(realm, make([]byte, 32))
+	}
+	realm = appendCBORHead(realm, cborUint, realmPubKeyHashAlg)
+	realm = appendCBORText(realm, "sha-256")
+	realmToken, err := signCOSESign1(realm, p.RAK)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, _ := ccaHash("sha-256")
+	h := hash.New()
+	h.Write(rak)
+	var platform []byte
+	platform = appendCBORHead(platform, cborMap, 5)
+	platform = appendCBORHead(platform, cborUint, platformProfile)
+	platform = appendCBORText(platform, "http://arm.com/CCA-SSD/1.0.0")
+	platform = appendCBORHead(platform, cborUint, eatChallenge)
+	platform = appendCBORBytes(platform, h.Sum(nil))
+	platform = appendCBORHead(platform, cborUint, platformImplementation)
+	platform = appendCBORBytes(platform, p.ImplementationID)
+	platform = appendCBORHead(platform, cborUint, platformInstanceID)
+	platform = appendCBORBytes(platform, p.InstanceID)
+	platform = appendCBORHead(platform, cborUint, platformLifecycle)
+	platform = appendCBORHead(platform, cborUint, p.Lifecycle)
+	platformToken, err := signCOSESign1(platform, p.CPAK)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	b = appendCBORHead(b, cborMap, 2)
+	b = appendCBORHead(b, cborUint, ccaPlatformToken)
+	b = appendCBORBytes(b, platformToken)
+	b = appendCBORHead(b, cborUint, ccaRealmToken)
+	return appendCBORBytes(b, realmToken), nil
+}
+
+func (t *CCAToken) parseRealmClaims(payload []byte) error {
+	v, err := decodeCBOR(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedCCAToken, err)
+	}
+	m, ok := v.(map[any]any)
+	if !ok {
+		return ErrMalformedCCAToken
+	}
+	var ok1, ok2, ok3, ok4, ok5, ok6 bool
+	t.Challenge, ok1 = m[uint64(eatChallenge)].([]byte)
+	t.Personalization, ok2 = m[uint64(realmPersonalization)].([]byte)
+	t.InitialMeasurement, ok3 = m[uint64(realmInitialMeas)].([]byte)
+	t.RealmHashAlgorithm, ok4 = m[uint64(realmHashAlgorithm)].(string)
+	t.RAK, ok5 = m[uint64(realmPublicKey)].([]byte)
+	t.RAKHashAlgorithm, ok6 = m[uint64(realmPubKeyHashAlg)].(string)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 {
+		return ErrMalformedCCAToken
+	}
+	rems, ok := m[uint64(realmExtensibleMeas)].([]any)
+	if !ok {
+		return ErrMalformedCCAToken
+	}
+	t.ExtensibleMeasurements = make([][]byte, len(rems))
+	for i, rem := range rems {
+		if t.ExtensibleMeasurements[i], ok = rem.([]byte); !ok {
+			return ErrMalformedCCAToken
+		}
+	}
+	return nil
+}
+
+func (t *CCAToken) parsePlatformClaims(payload []byte) error {
+	v, err := decodeCBOR(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedCCAToken, err)
+	}
+	m, ok := v.(map[any]any)
+	if !ok {
+		return ErrMalformedCCAToken
+	}
+	var ok1, ok2, ok3, ok4, ok5 bool
+	t.Profile, ok1 = m[uint64(platformProfile)].(string)
+	t.PlatformNonce, ok2 = m[uint64(eatChallenge)].([]byte)
+	t.ImplementationID, ok3 = m[uint64(platformImplementation)].([]byte)
+	t.InstanceID, ok4 = m[uint64(platformInstanceID)].([]byte)
+	t.Lifecycle, ok5 = m[uint64(platformLifecycle)].(uint64)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 {
+		return ErrMalformedCCAToken
+	}
+	return nil
+}
+
+// parseECPoint decodes an uncompressed P-256 or P-384 point.
+func parseECPoint(b []byte) (*ecdsa.PublicKey, error) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		if len(b) != 1+2*coordLen(curve) {
+			continue
+		}
+		x, y := elliptic.Unmarshal(curve, b)
+		if x == nil {
+			break
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("%w: invalid realm public key", ErrMalformedCCAToken)
+}
+
+func ccaHash(name string) (crypto.Hash, error) {
+	switch name {
+	case "sha-256":
+		return crypto.SHA256, nil
+	case "sha-384":
+		return crypto.SHA384, nil
+	case "sha-512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown hash algorithm %q", ErrMalformedCCAToken, name)
+	}
+}
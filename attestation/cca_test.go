@@ -0,0 +1,109 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newCCAPlatform(t *testing.T) *CCAPlatform {
+	require := require.New(t)
+
+	cpak, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	rak, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	return &CCAPlatform{
+		CPAK:             cpak,
+		RAK:              rak,
+		ImplementationID: make([]byte, 32),
+		InstanceID:       append([]byte{1}, make([]byte, 32)...),
+		Lifecycle:        0x3000,
+	}
+}
+
+func TestVerifyCCAToken(t *testing.T) {
+	require := require.New(t)
+
+	p := newCCAPlatform(t)
+	challenge := make([]byte, 64)
+	challenge[0] = 7
+	token, err := p.SignCCAToken(challenge, []byte{1, 2, 3})
+	require.NoError(err)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	got, err := VerifyCCAToken(token, []*ecdsa.PublicKey{&other.PublicKey, &p.CPAK.PublicKey})
+	require.NoError(err)
+	require.Equal(challenge, got.Challenge)
+	require.Equal([]byte{1, 2, 3}, got.InitialMeasurement)
+	require.Equal(p.ImplementationID, got.ImplementationID)
+	require.Len(got.ExtensibleMeasurements, 4)
+
+	_, err = VerifyCCAToken(token, []*ecdsa.PublicKey{&other.PublicKey})
+	require.ErrorIs(err, ErrUnknownCCAPlatform)
+
+	tampered := append([]byte{}, token...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = VerifyCCAToken(tampered, []*ecdsa.PublicKey{&p.CPAK.PublicKey})
+	require.Error(err)
+}
+
+func TestVerifyCCATokenRejectsInsecurePlatform(t *testing.T) {
+	p := newCCAPlatform(t)
+	p.Lifecycle = 0x2000
+	token, err := p.SignCCAToken(make([]byte, 64), []byte{1})
+	require.NoError(t, err)
+
+	_, err = VerifyCCAToken(token, []*ecdsa.PublicKey{&p.CPAK.PublicKey})
+	require.ErrorIs(t, err, ErrCCAPlatformInsecure)
+}
+
+func TestPlatformPolicy(t *testing.T) {
+	require := require.New(t)
+
+	p := &PlatformPolicy{
+		Requirements: []PlatformRequirement{
+			{Type: SGX, Min: 1},
+			{Type: SEV, Min: 1},
+			{Type: CCA},
+		},
+		RealmMeasurements: [][]byte{{1}},
+	}
+	require.True(p.Allows(CCA))
+	require.False(p.Allows(Nitro))
+	require.NoError(p.AllowsRealm([]byte{1}))
+	require.ErrorIs(p.AllowsRealm([]byte{2}), ErrRealmMeasurement)
+
+	counts := map[EnclaveType]uint64{SGX: 2}
+	count := func(t EnclaveType) (uint64, error) {
+		return counts[t], nil
+	}
+	require.ErrorIs(p.Satisfied(count), ErrPlatformMix)
+	counts[SEV] = 1
+	require.NoError(p.Satisfied(count))
+}
+
+func TestHeterogeneousPolicy(t *testing.T) {
+	require := require.New(t)
+
+	p := &PlatformPolicy{
+		Requirements:  []PlatformRequirement{{Type: SGX}, {Type: SEV}},
+		Heterogeneous: true,
+	}
+	require.Equal(2, p.Vendors())
+
+	counts := map[EnclaveType]uint64{SGX: 2}
+	count := func(t EnclaveType) (uint64, error) {
+		return counts[t], nil
+	}
+	require.ErrorIs(p.Satisfied(count), ErrSingleVendor)
+	counts[SEV] = 1
+	require.NoError(p.Satisfied(count))
+}
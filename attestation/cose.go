@@ -0,0 +1,170 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// COSE_Sign1 (RFC 9052) messages signed with ECDSA, as used by Nitro
+// attestation documents and CCA attestation tokens.
+
+var (
+	ErrMalformedCOSE  = errors.New("malformed COSE_Sign1 message")
+	ErrCOSEAlgorithm  = errors.New("unsupported COSE signing algorithm")
+	ErrInvalidCOSESig = errors.New("invalid COSE_Sign1 signature")
+)
+
+const (
+	coseHeaderAlg   = 1
+	coseSign1Fields = 4
+	coseSign1Label  = "Signature1"
+)
+
+// COSEAlgorithm is a COSE signature algorithm identifier.
+type COSEAlgorithm int64
+
+const (
+	ES256 COSEAlgorithm = -7
+	ES384 COSEAlgorithm = -35
+)
+
+func (a COSEAlgorithm) params() (elliptic.Curve, crypto.Hash, bool) {
+	switch a {
+	case ES256:
+		return elliptic.P256(), crypto.SHA256, true
+	case ES384:
+		return elliptic.P384(), crypto.SHA384, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// coseSign1 is a decoded COSE_Sign1 message. The unprotected header is
+// ignored.
+type coseSign1 struct {
+	alg       COSEAlgorithm
+	protected []byte
+	payload   []byte
+	signature []byte
+}
+
+func decodeCOSESign1(b []byte) (*coseSign1, error) {
+	v, err := decodeCBOR(b)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := v.([]any)
+	if !ok || len(fields) != coseSign1Fields {
+		return nil, ErrMalformedCOSE
+	}
+	protected, ok1 := fields[0].([]byte)
+	payload, ok2 := fields[2].([]byte)
+	sig, ok3 := fields[3].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, ErrMalformedCOSE
+	}
+
+	header, err := decodeCBOR(protected)
+	if err != nil {
+		return nil, err
+	}
+	headerMap, ok := header.(map[any]any)
+	if !ok {
+		return nil, ErrMalformedCOSE
+	}
+	alg, _ := headerMap[uint64(coseHeaderAlg)].(int64)
+	curve, _, ok := COSEAlgorithm(alg).params()
+	if !ok {
+		return nil, ErrCOSEAlgorithm
+	}
+	if len(sig) != 2*coordLen(curve) {
+		return nil, ErrMalformedCOSE
+	}
+	return &coseSign1{
+		alg:       COSEAlgorithm(alg),
+		protected: protected,
+		payload:   payload,
+		signature: sig,
+	}, nil
+}
+
+// verify checks the signature of [m] against [pub], which must be on the
+// curve its algorithm names.
+func (m *coseSign1) verify(pub *ecdsa.PublicKey) error {
+	curve, hash, _ := m.alg.params()
+	if pub.Curve != curve {
+		return ErrCOSEAlgorithm
+	}
+	h := hash.New()
+	h.Write(coseSigStructure(m.protected, m.payload))
+	n := coordLen(curve)
+	r := new(big.Int).SetBytes(m.signature[:n])
+	s := new(big.Int).SetBytes(m.signature[n:])
+	if !ecdsa.Verify(pub, h.Sum(nil), r, s) {
+		return ErrInvalidCOSESig
+	}
+	return nil
+}
+
+// signCOSESign1 signs [payload] with [key], choosing the algorithm from its
+// curve.
+func signCOSESign1(payload []byte, key *ecdsa.PrivateKey) ([]byte, error) {
+	var alg COSEAlgorithm
+	switch key.Curve {
+	case elliptic.P256():
+		alg = ES256
+	case elliptic.P384():
+		alg = ES384
+	default:
+		return nil, ErrCOSEAlgorithm
+	}
+	_, hash, _ := alg.params()
+
+	var protected []byte
+	protected = appendCBORHead(protected, cborMap, 1)
+	protected = appendCBORHead(protected, cborUint, coseHeaderAlg)
+	protected = appendCBORHead(protected, cborNegInt, uint64(-1-alg))
+
+	h := hash.New()
+	h.Write(coseSigStructure(protected, payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	n := coordLen(key.Curve)
+	sig := make([]byte, 2*n)
+	r.FillBytes(sig[:n])
+	s.FillBytes(sig[n:])
+
+	var b []byte
+	b = appendCBORHead(b, cborArray, coseSign1Fields)
+	b = appendCBORBytes(b, protected)
+	b = appendCBORHead(b, cborMap, 0)
+	b = appendCBORBytes(b, payload)
+	return appendCBORBytes(b, sig), nil
+}
+
+// coseSigStructure is the Sig_structure a COSE_Sign1 signature covers, with
+// no external data.
+func coseSigStructure(protected, payload []byte) []byte {
+	var b []byte
+	b = appendCBORHead(b, cborArray, 4)
+	b = appendCBORText(b, coseSign1Label)
+	b = appendCBORBytes(b, protected)
+	b = appendCBORBytes(b, nil)
+	return appendCBORBytes(b, payload)
+}
+
+func coordLen(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
@@ -0,0 +1,292 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// DCAP quotes are judged against collateral Intel publishes per platform
+// family (FMSPC): the TCB info listing which microcode and PCE versions are
+// up to date, the identity of the quoting enclave, and the CRL of the SGX
+// root CA. Collateral is signed by the Intel TCB signing key, which chains
+// up to the SGX root, so anyone can publish it and validators can check it
+// without contacting Intel.
+
+const (
+	// MaxCollateralSize bounds the encoded collateral accepted on chain
+	MaxCollateralSize = 64 * 1024
+	// FMSPCLen is the length of a platform family ID
+	FMSPCLen = 6
+	// CPUSVNLen is the length of the CPU security version in a quote
+	CPUSVNLen = 16
+)
+
+var (
+	ErrCollateralChain     = errors.New("collateral signing chain invalid")
+	ErrCollateralSignature = errors.New("invalid collateral signature")
+	ErrCollateralRevoked   = errors.New("collateral signing certificate revoked")
+	ErrCollateralExpired   = errors.New("collateral expired")
+	ErrMalformedCollateral = errors.New("malformed collateral")
+	ErrTCBNotSupported     = errors.New("TCB level not supported by collateral")
+)
+
+// TCBStatus is Intel's verdict on a platform or quoting enclave TCB.
+type TCBStatus string
+
+const (
+	TCBUpToDate                          TCBStatus = "UpToDate"
+	TCBSWHardeningNeeded                 TCBStatus = "SWHardeningNeeded"
+	TCBConfigurationNeeded               TCBStatus = "ConfigurationNeeded"
+	TCBConfigurationAndSWHardeningNeeded TCBStatus = "ConfigurationAndSWHardeningNeeded"
+	TCBOutOfDate                         TCBStatus = "OutOfDate"
+	TCBOutOfDateConfigurationNeeded      TCBStatus = "OutOfDateConfigurationNeeded"
+	TCBRevoked                           TCBStatus = "Revoked"
+)
+
+// tcbSeverity orders statuses from best to worst
+var tcbSeverity = map[TCBStatus]int{
+	TCBUpToDate:                          0,
+	TCBSWHardeningNeeded:                 1,
+	TCBConfigurationNeeded:               2,
+	TCBConfigurationAndSWHardeningNeeded: 3,
+	TCBOutOfDate:                         4,
+	TCBOutOfDateConfigurationNeeded:      5,
+	TCBRevoked:                           6,
+}
+
+// Acceptable reports whether a platform with status [s] may attest.
+// Platforms needing only software hardening are accepted, as Intel
+// recommends.
+func (s TCBStatus) Acceptable() bool {
+	return s == TCBUpToDate || s == TCBSWHardeningNeeded
+}
+
+// Collateral is a DCAP collateral bundle as Intel signs it. The TCB info
+// and QE identity are the exact JSON bodies covered by their signatures.
+type Collateral struct {
+	TCBInfo             []byte `serialize:"true" json:"tcb_info"`
+	TCBInfoSignature    []byte `serialize:"true" json:"tcb_info_signature"`
+	QEIdentity          []byte `serialize:"true" json:"qe_identity"`
+	QEIdentitySignature []byte `serialize:"true" json:"qe_identity_signature"`
+	// SigningChain is the TCB signing certificate followed by any
+	// intermediates, in DER
+	SigningChain [][]byte `serialize:"true" json:"signing_chain"`
+	// RootCRL is the DER CRL of the SGX root CA
+	RootCRL []byte `serialize:"true" json:"root_crl"`
+}
+
+// TCBInfo is the body of an Intel TCB info document.
+type TCBInfo struct {
+	ID                      string     `json:"id"`
+	Version                 int        `json:"version"`
+	IssueDate               time.Time  `json:"issueDate"`
+	NextUpdate              time.Time  `json:"nextUpdate"`
+	FMSPC                   string     `json:"fmspc"`
+	PCEID                   string     `json:"pceId"`
+	TCBEvaluationDataNumber uint32     `json:"tcbEvaluationDataNumber"`
+	TCBLevels               []TCBLevel `json:"tcbLevels"`
+}
+
+type TCBLevel struct {
+	TCB struct {
+		SGXTCBComponents []struct {
+			SVN uint8 `json:"svn"`
+		} `json:"sgxtcbcomponents"`
+		PCESVN uint16 `json:"pcesvn"`
+	} `json:"tcb"`
+	TCBStatus TCBStatus `json:"tcbStatus"`
+}
+
+// QEIdentity is the body of an Intel quoting enclave identity document.
+type QEIdentity struct {
+	ID                      string    `json:"id"`
+	Version                 int       `json:"version"`
+	IssueDate               time.Time `json:"issueDate"`
+	NextUpdate              time.Time `json:"nextUpdate"`
+	TCBEvaluationDataNumber uint32    `json:"tcbEvaluationDataNumber"`
+	MRSigner                string    `json:"mrsigner"`
+	ISVProdID               uint16    `json:"isvprodid"`
+	TCBLevels               []struct {
+		TCB struct {
+			ISVSVN uint16 `json:"isvsvn"`
+		} `json:"tcb"`
+		TCBStatus TCBStatus `json:"tcbStatus"`
+	} `json:"tcbLevels"`
+}
+
+// VerifiedCollateral is collateral whose signatures checked out.
+type VerifiedCollateral struct {
+	TCBInfo    *TCBInfo
+	QEIdentity *QEIdentity
+	// Expiry is the earliest next update of the TCB info, QE identity and
+	// root CRL
+	Expiry time.Time
+}
+
+// VerifyCollateral checks that [c] is signed by a TCB signing certificate
+// chaining up to [root] and not revoked by it, all valid at [now].
+func VerifyCollateral(c *Collateral, root *x509.Certificate, now time.Time) (*VerifiedCollateral, error) {
+	if len(c.SigningChain) == 0 {
+		return nil, ErrCollateralChain
+	}
+	leaf, err := x509.ParseCertificate(c.SigningChain[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCollateralChain, err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, der := range c.SigningChain[1:] {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCollateralChain, err)
+		}
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCollateralChain, err)
+	}
+
+	crl, err := x509.ParseRevocationList(c.RootCRL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedCollateral, err)
+	}
+	if err := crl.CheckSignatureFrom(root); err != nil {
+		return nil, fmt.Errorf("%w: root CRL: %w", ErrCollateralSignature, err)
+	}
+	for _, revoked := range crl.RevokedCertificateEntries {
+		if revoked.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return nil, ErrCollateralRevoked
+		}
+	}
+
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("%w: signing key is not P-256", ErrCollateralChain)
+	}
+	if !verifyP256(pub, c.TCBInfo, c.TCBInfoSignature) {
+		return nil, fmt.Errorf("%w: TCB info", ErrCollateralSignature)
+	}
+	if !verifyP256(pub, c.QEIdentity, c.QEIdentitySignature) {
+		return nil, fmt.Errorf("%w: QE identity", ErrCollateralSignature)
+	}
+
+	v := &VerifiedCollateral{}
+	if v.TCBInfo, err = ParseTCBInfo(c.TCBInfo); err != nil {
+		return nil, err
+	}
+	if v.QEIdentity, err = ParseQEIdentity(c.QEIdentity); err != nil {
+		return nil, err
+	}
+	v.Expiry = v.TCBInfo.NextUpdate
+	if v.QEIdentity.NextUpdate.Before(v.Expiry) {
+		v.Expiry = v.QEIdentity.NextUpdate
+	}
+	if crl.NextUpdate.Before(v.Expiry) {
+		v.Expiry = crl.NextUpdate
+	}
+	if !now.Before(v.Expiry) {
+		return nil, ErrCollateralExpired
+	}
+	return v, nil
+}
+
+// ParseTCBInfo decodes a TCB info body. It does not check the signature.
+func ParseTCBInfo(b []byte) (*TCBInfo, error) {
+	var t TCBInfo
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("%w: TCB info: %w", ErrMalformedCollateral, err)
+	}
+	if fmspc, err := hex.DecodeString(t.FMSPC); err != nil || len(fmspc) != FMSPCLen {
+		return nil, fmt.Errorf("%w: FMSPC %q", ErrMalformedCollateral, t.FMSPC)
+	}
+	for _, level := range t.TCBLevels {
+		if len(level.TCB.SGXTCBComponents) != CPUSVNLen {
+			return nil, fmt.Errorf("%w: TCB level has %d components", ErrMalformedCollateral, len(level.TCB.SGXTCBComponents))
+		}
+	}
+	return &t, nil
+}
+
+// ParseQEIdentity decodes a QE identity body. It does not check the
+// signature.
+func ParseQEIdentity(b []byte) (*QEIdentity, error) {
+	var q QEIdentity
+	if err := json.Unmarshal(b, &q); err != nil {
+		return nil, fmt.Errorf("%w: QE identity: %w", ErrMalformedCollateral, err)
+	}
+	return &q, nil
+}
+
+// Status returns the status of the first TCB level, in Intel's order, that
+// [cpuSVN] and [pceSVN] meet or exceed.
+func (t *TCBInfo) Status(cpuSVN [CPUSVNLen]byte, pceSVN uint16) (TCBStatus, error) {
+	for _, level := range t.TCBLevels {
+		if pceSVN < level.TCB.PCESVN {
+			continue
+		}
+		meets := true
+		for i, component := range level.TCB.SGXTCBComponents {
+			if cpuSVN[i] < component.SVN {
+				meets = false
+				break
+			}
+		}
+		if meets {
+			return level.TCBStatus, nil
+		}
+	}
+	return "", ErrTCBNotSupported
+}
+
+// Status returns the status of the first QE TCB level [isvSVN] meets.
+func (q *QEIdentity) Status(isvSVN uint16) (TCBStatus, error) {
+	for _, level := range q.TCBLevels {
+		if isvSVN >= level.TCB.ISVSVN {
+			return level.TCBStatus, nil
+		}
+	}
+	return "", ErrTCBNotSupported
+}
+
+// WorseTCBStatus returns the more severe of [a] and [b]. Unknown statuses
+// are the most severe.
+func WorseTCBStatus(a, b TCBStatus) TCBStatus {
+	sa, ok := tcbSeverity[a]
+	if !ok {
+		return a
+	}
+	sb, ok := tcbSeverity[b]
+	if !ok || sb > sa {
+		return b
+	}
+	return a
+}
+
+// verifyP256 checks a raw r||s ECDSA signature over the SHA-256 of [msg],
+// the format Intel uses for collateral.
+func verifyP256(pub *ecdsa.PublicKey, msg, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	digest := sha256.Sum256(msg)
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	return ecdsa.Verify(pub, digest[:], r, s)
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var collateralNow = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+type collateralSigner struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+	leaf    []byte
+	leafKey *ecdsa.PrivateKey
+}
+
+func newCollateralSigner(t *testing.T) *collateralSigner {
+	require := require.New(t)
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "SGX Root CA"},
+		NotBefore:             collateralNow.Add(-time.Hour),
+		NotAfter:              collateralNow.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	require.NoError(err)
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "SGX TCB Signing"},
+		NotBefore:    collateralNow.Add(-time.Hour),
+		NotAfter:     collateralNow.Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	require.NoError(err)
+	return &collateralSigner{root: root, rootKey: rootKey, leaf: leaf, leafKey: leafKey}
+}
+
+func (s *collateralSigner) crl(t *testing.T, revoked ...*big.Int) []byte {
+	entries := make([]x509.RevocationListEntry, len(revoked))
+	for i, serial := range revoked {
+		entries[i] = x509.RevocationListEntry{SerialNumber: serial, RevocationTime: collateralNow}
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                collateralNow.Add(-time.Hour),
+		NextUpdate:                collateralNow.Add(30 * 24 * time.Hour),
+		RevokedCertificateEntries: entries,
+	}, s.root, s.rootKey)
+	require.NoError(t, err)
+	return crl
+}
+
+func (s *collateralSigner) sign(t *testing.T, msg []byte) []byte {
+	digest := sha256.Sum256(msg)
+	r, sig, err := ecdsa.Sign(rand.Reader, s.leafKey, digest[:])
+	require.NoError(t, err)
+	out := make([]byte, 64)
+	r.FillBytes(out[:32])
+	sig.FillBytes(out[32:])
+	return out
+}
+
+func tcbLevel(svn uint8, pceSVN uint16, status TCBStatus) string {
+	components := make([]string, CPUSVNLen)
+	for i := range components {
+		components[i] = fmt.Sprintf(`{"svn":%d}`, svn)
+	}
+	return fmt.Sprintf(`{"tcb":{"sgxtcbcomponents":[%s],"pcesvn":%d},"tcbStatus":%q}`,
+		strings.Join(components, ","), pceSVN, status)
+}
+
+func (s *collateralSigner) collateral(t *testing.T, nextUpdate time.Time) *Collateral {
+	tcbInfo := []byte(fmt.Sprintf(
+		`{"id":"SGX","version":3,"issueDate":%q,"nextUpdate":%q,"fmspc":"00906ED50000","pceId":"0000","tcbEvaluationDataNumber":16,"tcbLevels":[%s,%s]}`,
+		collateralNow.Format(time.RFC3339), nextUpdate.Format(time.RFC3339),
+		tcbLevel(5, 13, TCBUpToDate), tcbLevel(2, 10, TCBOutOfDate),
+	))
+	qeIdentity := []byte(fmt.Sprintf(
+		`{"id":"QE","version":2,"issueDate":%q,"nextUpdate":%q,"tcbEvaluationDataNumber":16,"tcbLevels":[{"tcb":{"isvsvn":8},"tcbStatus":"UpToDate"},{"tcb":{"isvsvn":0},"tcbStatus":"OutOfDate"}]}`,
+		collateralNow.Format(time.RFC3339), nextUpdate.Format(time.RFC3339),
+	))
+	return &Collateral{
+		TCBInfo:             tcbInfo,
+		TCBInfoSignature:    s.sign(t, tcbInfo),
+		QEIdentity:          qeIdentity,
+		QEIdentitySignature: s.sign(t, qeIdentity),
+		SigningChain:        [][]byte{s.leaf},
+		RootCRL:             s.crl(t),
+	}
+}
+
+func TestVerifyCollateral(t *testing.T) {
+	require := require.New(t)
+
+	s := newCollateralSigner(t)
+	c := s.collateral(t, collateralNow.Add(7*24*time.Hour))
+	v, err := VerifyCollateral(c, s.root, collateralNow)
+	require.NoError(err)
+	require.Equal("00906ED50000", v.TCBInfo.FMSPC)
+	// The TCB info expires before the root CRL
+	require.Equal(collateralNow.Add(7*24*time.Hour), v.Expiry)
+
+	var svn [CPUSVNLen]byte
+	for i := range svn {
+		svn[i] = 5
+	}
+	status, err := v.TCBInfo.Status(svn, 13)
+	require.NoError(err)
+	require.Equal(TCBUpToDate, status)
+	status, err = v.TCBInfo.Status(svn, 12)
+	require.NoError(err)
+	require.Equal(TCBOutOfDate, status)
+	_, err = v.TCBInfo.Status([CPUSVNLen]byte{}, 13)
+	require.ErrorIs(err, ErrTCBNotSupported)
+
+	qeStatus, err := v.QEIdentity.Status(3)
+	require.NoError(err)
+	require.Equal(TCBOutOfDate, WorseTCBStatus(TCBUpToDate, qeStatus))
+
+	_, err = VerifyCollateral(c, s.root, collateralNow.Add(8*24*time.Hour))
+	require.ErrorIs(err, ErrCollateralExpired)
+
+	tampered := *c
+	tampered.TCBInfo = append([]byte{}, c.TCBInfo...)
+	tampered.TCBInfo[len(tampered.TCBInfo)-2] = ' '
+	_, err = VerifyCollateral(&tampered, s.root, collateralNow)
+	require.ErrorIs(err, ErrCollateralSignature)
+}
+
+func TestVerifyCollateralRejectsRevokedSigner(t *testing.T) {
+	require := require.New(t)
+
+	s := newCollateralSigner(t)
+	c := s.collateral(t, collateralNow.Add(7*24*time.Hour))
+	c.RootCRL = s.crl(t, big.NewInt(2))
+	_, err := VerifyCollateral(c, s.root, collateralNow)
+	require.ErrorIs(err, ErrCollateralRevoked)
+
+	other := newCollateralSigner(t)
+	_, err = VerifyCollateral(s.collateral(t, collateralNow.Add(time.Hour)), other.root, collateralNow)
+	require.ErrorIs(err, ErrCollateralChain)
+}
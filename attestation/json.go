@@ -0,0 +1,176 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Byte fields are 0x-prefixed lowercase hex strings, following the JSON
+// conventions of the actions package.
+
+var ErrInvalidHex = errors.New("invalid hex string")
+
+type hexBytes []byte
+
+func (h hexBytes) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(h)), nil
+}
+
+func (h *hexBytes) UnmarshalText(text []byte) error {
+	s := string(text)
+	if !strings.HasPrefix(s, "0x") {
+		return ErrInvalidHex
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return ErrInvalidHex
+	}
+	*h = b
+	return nil
+}
+
+func (s *Stamp) MarshalJSON() ([]byte, error) {
+	type alias Stamp
+	return json.Marshal(&struct {
+		*alias
+		Signature hexBytes `json:"signature"`
+	}{(*alias)(s), s.Signature})
+}
+
+func (s *Stamp) UnmarshalJSON(b []byte) error {
+	type alias Stamp
+	aux := &struct {
+		*alias
+		Signature hexBytes `json:"signature"`
+	}{alias: (*alias)(s)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	s.Signature = aux.Signature
+	return nil
+}
+
+func (a *Attestation) MarshalJSON() ([]byte, error) {
+	type alias Attestation
+	return json.Marshal(&struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+		Signature hexBytes `json:"signature"`
+	}{(*alias)(a), a.EnclaveID, a.Signature})
+}
+
+func (a *Attestation) UnmarshalJSON(b []byte) error {
+	type alias Attestation
+	aux := &struct {
+		*alias
+		EnclaveID hexBytes `json:"enclave_id"`
+		Signature hexBytes `json:"signature"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	a.EnclaveID, a.Signature = aux.EnclaveID, aux.Signature
+	return nil
+}
+
+func (p *PCR) MarshalJSON() ([]byte, error) {
+	type alias PCR
+	return json.Marshal(&struct {
+		*alias
+		Value hexBytes `json:"value"`
+	}{(*alias)(p), p.Value})
+}
+
+func (p *PCR) UnmarshalJSON(b []byte) error {
+	type alias PCR
+	aux := &struct {
+		*alias
+		Value hexBytes `json:"value"`
+	}{alias: (*alias)(p)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	p.Value = aux.Value
+	return nil
+}
+
+func (p *PlatformPolicy) MarshalJSON() ([]byte, error) {
+	type alias PlatformPolicy
+	measurements := make([]hexBytes, len(p.RealmMeasurements))
+	for i, m := range p.RealmMeasurements {
+		measurements[i] = m
+	}
+	return json.Marshal(&struct {
+		*alias
+		RealmMeasurements []hexBytes `json:"realm_measurements"`
+	}{(*alias)(p), measurements})
+}
+
+func (p *PlatformPolicy) UnmarshalJSON(b []byte) error {
+	type alias PlatformPolicy
+	aux := &struct {
+		*alias
+		RealmMeasurements []hexBytes `json:"realm_measurements"`
+	}{alias: (*alias)(p)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	p.RealmMeasurements = make([][]byte, len(aux.RealmMeasurements))
+	for i, m := range aux.RealmMeasurements {
+		p.RealmMeasurements[i] = m
+	}
+	return nil
+}
+
+// Collateral keeps the TCB info and QE identity as the JSON strings Intel
+// signed, and hex-encodes the binary fields.
+func (c *Collateral) MarshalJSON() ([]byte, error) {
+	type alias Collateral
+	chain := make([]hexBytes, len(c.SigningChain))
+	for i, cert := range c.SigningChain {
+		chain[i] = cert
+	}
+	return json.Marshal(&struct {
+		*alias
+		TCBInfo             string     `json:"tcb_info"`
+		TCBInfoSignature    hexBytes   `json:"tcb_info_signature"`
+		QEIdentity          string     `json:"qe_identity"`
+		QEIdentitySignature hexBytes   `json:"qe_identity_signature"`
+		SigningChain        []hexBytes `json:"signing_chain"`
+		RootCRL             hexBytes   `json:"root_crl"`
+	}{
+		(*alias)(c),
+		string(c.TCBInfo), c.TCBInfoSignature,
+		string(c.QEIdentity), c.QEIdentitySignature,
+		chain, c.RootCRL,
+	})
+}
+
+func (c *Collateral) UnmarshalJSON(b []byte) error {
+	type alias Collateral
+	aux := &struct {
+		*alias
+		TCBInfo             string     `json:"tcb_info"`
+		TCBInfoSignature    hexBytes   `json:"tcb_info_signature"`
+		QEIdentity          string     `json:"qe_identity"`
+		QEIdentitySignature hexBytes   `json:"qe_identity_signature"`
+		SigningChain        []hexBytes `json:"signing_chain"`
+		RootCRL             hexBytes   `json:"root_crl"`
+	}{alias: (*alias)(c)}
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	c.TCBInfo, c.TCBInfoSignature = []byte(aux.TCBInfo), aux.TCBInfoSignature
+	c.QEIdentity, c.QEIdentitySignature = []byte(aux.QEIdentity), aux.QEIdentitySignature
+	c.SigningChain = make([][]byte, len(aux.SigningChain))
+	for i, cert := range aux.SigningChain {
+		c.SigningChain[i] = cert
+	}
+	c.RootCRL = aux.RootCRL
+	return nil
+}
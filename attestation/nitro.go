@@ -0,0 +1,267 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Nitro attestation documents are COSE_Sign1 messages (RFC 9052) signed
+// with ES384 by a certificate chaining up to the AWS Nitro root. The payload
+// is a CBOR map carrying the enclave's PCRs and the public key the enclave
+// will sign with.
+
+const (
+	// MaxNitroDocumentSize bounds the documents accepted for verification
+	MaxNitroDocumentSize = 16 * 1024
+	// MaxPCRIndex is the highest PCR a Nitro enclave reports
+	MaxPCRIndex = 31
+
+	nitroDigest = "SHA384"
+)
+
+var (
+	ErrMalformedNitroDocument = errors.New("malformed nitro attestation document")
+	ErrNitroDocumentTooLarge  = errors.New("nitro attestation document too large")
+	ErrNitroAlgorithm         = errors.New("unsupported nitro signing algorithm")
+	ErrNitroCertificate       = errors.New("nitro certificate chain invalid")
+	ErrNitroSignature         = errors.New("invalid nitro document signature")
+	ErrNitroPCRMismatch       = errors.New("nitro PCR does not match region policy")
+	ErrEmptyNitroPolicy       = errors.New("nitro policy has no PCRs")
+)
+
+// NitroDocument is the payload of a Nitro attestation document.
+type NitroDocument struct {
+	ModuleID string
+	// Timestamp is when the Nitro hypervisor signed the document, in unix
+	// milliseconds
+	Timestamp   uint64
+	PCRs        map[uint8][]byte
+	Certificate []byte
+	// CABundle is the chain from the AWS Nitro root, root first, down to
+	// the issuer of Certificate
+	CABundle  [][]byte
+	PublicKey []byte
+	UserData  []byte
+	Nonce     []byte
+}
+
+// ParseNitroDocument decodes the attestation document [doc] without
+// verifying it.
+func ParseNitroDocument(doc []byte) (*NitroDocument, error) {
+	d, _, err := parseNitroDocument(doc)
+	return d, err
+}
+
+// VerifyNitroDocument checks that [doc] is signed by a certificate chaining
+// up to [root], valid at the time the document was signed, and returns its
+// payload.
+func VerifyNitroDocument(doc []byte, root *x509.Certificate) (*NitroDocument, error) {
+	if len(doc) > MaxNitroDocumentSize {
+		return nil, ErrNitroDocumentTooLarge
+	}
+	d, m, err := parseNitroDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(d.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNitroCertificate, err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, der := range d.CABundle {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNitroCertificate, err)
+		}
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.UnixMilli(int64(d.Timestamp)),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNitroCertificate, err)
+	}
+
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P384() {
+		return nil, ErrNitroAlgorithm
+	}
+	if err := m.verify(pub); err != nil {
+		return nil, ErrNitroSignature
+	}
+	return d, nil
+}
+
+// KeyEnclaveID is the enclave ID an enclave registered from an attestation
+// document or token is known by: the hash of the public key it binds.
+func KeyEnclaveID(publicKey []byte) []byte {
+	id := sha256.Sum256(publicKey)
+	return id[:]
+}
+
+// PCR is an expected platform configuration register value.
+type PCR struct {
+	Index uint8  `serialize:"true" json:"index"`
+	Value []byte `serialize:"true" json:"value"`
+}
+
+// NitroPolicy lists the PCRs a Nitro enclave must report to serve a region,
+// typically PCR0-2 (image, kernel and application) and PCR8 (signing
+// certificate).
+type NitroPolicy struct {
+	PCRs []PCR `serialize:"true" json:"pcrs"`
+}
+
+// Match checks that [doc] reports every PCR in [p].
+func (p *NitroPolicy) Match(doc *NitroDocument) error {
+	if len(p.PCRs) == 0 {
+		return ErrEmptyNitroPolicy
+	}
+	for _, want := range p.PCRs {
+		got, ok := doc.PCRs[want.Index]
+		if !ok || !bytes.Equal(got, want.Value) {
+			return fmt.Errorf("%w: PCR%d", ErrNitroPCRMismatch, want.Index)
+		}
+	}
+	return nil
+}
+
+// SignNitroDocument encodes [d] as a COSE_Sign1 document signed by [key].
+// Real documents are signed by the Nitro hypervisor; this is for tests and
+// mock workers.
+func SignNitroDocument(d *NitroDocument, key *ecdsa.PrivateKey) ([]byte, error) {
+	if key.Curve != elliptic.P384() {
+		return nil, ErrNitroAlgorithm
+	}
+	return signCOSESign1(d.appendCBOR(nil), key)
+}
+
+func (d *NitroDocument) appendCBOR(b []byte) []byte {
+	appendOptional := func(b, v []byte) []byte {
+		if v == nil {
+			return append(b, cborSimple<<5|cborNull)
+		}
+		return appendCBORBytes(b, v)
+	}
+	b = appendCBORHead(b, cborMap, 9)
+	b = appendCBORText(b, "module_id")
+	b = appendCBORText(b, d.ModuleID)
+	b = appendCBORText(b, "digest")
+	b = appendCBORText(b, nitroDigest)
+	b = appendCBORText(b, "timestamp")
+	b = appendCBORHead(b, cborUint, d.Timestamp)
+	b = appendCBORText(b, "pcrs")
+	indexes := make([]int, 0, len(d.PCRs))
+	for index := range d.PCRs {
+		indexes = append(indexes, int(index))
+	}
+	sort.Ints(indexes)
+	b = appendCBORHead(b, cborMap, uint64(len(indexes)))
+	for _, index := range indexes {
+		b = appendCBORHead(b, cborUint, uint64(index))
+		b = appendCBORBytes(b, d.PCRs[uint8(index)])
+	}
+	b = appendCBORText(b, "certificate")
+	b = appendCBORBytes(b, d.Certificate)
+	b = appendCBORText(b, "cabundle")
+	b = appendCBORHead(b, cborArray, uint64(len(d.CABundle)))
+	for _, cert := range d.CABundle {
+		b = appendCBORBytes(b, cert)
+	}
+	b = appendCBORText(b, "public_key")
+	b = appendOptional(b, d.PublicKey)
+	b = appendCBORText(b, "user_data")
+	b = appendOptional(b, d.UserData)
+	b = appendCBORText(b, "nonce")
+	return appendOptional(b, d.Nonce)
+}
+
+func parseNitroDocument(doc []byte) (*NitroDocument, *coseSign1, error) {
+	m, err := decodeCOSESign1(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrMalformedNitroDocument, err)
+	}
+	if m.alg != ES384 {
+		return nil, nil, ErrNitroAlgorithm
+	}
+	body, err := decodeCBOR(m.payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrMalformedNitroDocument, err)
+	}
+	d, err := nitroDocumentFromCBOR(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, m, nil
+}
+
+func nitroDocumentFromCBOR(v any) (*NitroDocument, error) {
+	m, ok := v.(map[any]any)
+	if !ok {
+		return nil, ErrMalformedNitroDocument
+	}
+	optional := func(key string) ([]byte, bool) {
+		switch b := m[key].(type) {
+		case nil:
+			return nil, true
+		case []byte:
+			return b, true
+		default:
+			return nil, false
+		}
+	}
+
+	d := &NitroDocument{}
+	var ok1, ok2, ok3, ok4, ok5, ok6 bool
+	d.ModuleID, ok1 = m["module_id"].(string)
+	digest, _ := m["digest"].(string)
+	d.Timestamp, ok2 = m["timestamp"].(uint64)
+	d.Certificate, ok3 = m["certificate"].([]byte)
+	d.PublicKey, ok4 = optional("public_key")
+	d.UserData, ok5 = optional("user_data")
+	d.Nonce, ok6 = optional("nonce")
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 || digest != nitroDigest {
+		return nil, ErrMalformedNitroDocument
+	}
+
+	pcrs, ok := m["pcrs"].(map[any]any)
+	if !ok {
+		return nil, ErrMalformedNitroDocument
+	}
+	d.PCRs = make(map[uint8][]byte, len(pcrs))
+	for key, value := range pcrs {
+		index, ok1 := key.(uint64)
+		pcr, ok2 := value.([]byte)
+		if !ok1 || !ok2 || index > MaxPCRIndex {
+			return nil, ErrMalformedNitroDocument
+		}
+		d.PCRs[uint8(index)] = pcr
+	}
+
+	bundle, ok := m["cabundle"].([]any)
+	if !ok {
+		return nil, ErrMalformedNitroDocument
+	}
+	d.CABundle = make([][]byte, len(bundle))
+	for i, cert := range bundle {
+		if d.CABundle[i], ok = cert.([]byte); !ok {
+			return nil, ErrMalformedNitroDocument
+		}
+	}
+	return d, nil
+}
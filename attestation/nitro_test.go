@@ -0,0 +1,158 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nitroCA struct {
+	root    *x509.Certificate
+	leaf    []byte
+	leafKey *ecdsa.PrivateKey
+}
+
+// newNitroCA builds a P-384 root and a leaf it issued, standing in for the
+// AWS Nitro root and a hypervisor signing certificate.
+func newNitroCA(t *testing.T) *nitroCA {
+	require := require.New(t)
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test nitro root"},
+		NotBefore:             time.Unix(0, 0),
+		NotAfter:              time.Unix(1<<32, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	require.NoError(err)
+	root, err := x509.ParseCertificate(rootDER)
+	require.NoError(err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test nitro enclave"},
+		NotBefore:    time.Unix(1000, 0),
+		NotAfter:     time.Unix(2000, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, leafTemplate, root, &leafKey.PublicKey, rootKey)
+	require.NoError(err)
+	return &nitroCA{root: root, leaf: leaf, leafKey: leafKey}
+}
+
+func (ca *nitroCA) document() *NitroDocument {
+	return &NitroDocument{
+		ModuleID:    "i-0123-enc0123",
+		Timestamp:   1500 * 1000,
+		PCRs:        map[uint8][]byte{0: {1, 2, 3}, 1: {4, 5, 6}, 8: {7}},
+		Certificate: ca.leaf,
+		CABundle:    [][]byte{ca.root.Raw},
+		PublicKey:   []byte("enclave key"),
+	}
+}
+
+func TestVerifyNitroDocument(t *testing.T) {
+	require := require.New(t)
+
+	ca := newNitroCA(t)
+	want := ca.document()
+	doc, err := SignNitroDocument(want, ca.leafKey)
+	require.NoError(err)
+
+	got, err := VerifyNitroDocument(doc, ca.root)
+	require.NoError(err)
+	require.Equal(want.ModuleID, got.ModuleID)
+	require.Equal(want.Timestamp, got.Timestamp)
+	require.Equal(want.PCRs, got.PCRs)
+	require.Equal(want.PublicKey, got.PublicKey)
+	require.Nil(got.UserData)
+	require.Nil(got.Nonce)
+
+	// Any flipped byte breaks the encoding, the chain or the signature
+	for _, i := range []int{len(doc) / 2, len(doc) - 1} {
+		tampered := append([]byte{}, doc...)
+		tampered[i] ^= 1
+		_, err := VerifyNitroDocument(tampered, ca.root)
+		require.Error(err)
+	}
+}
+
+func TestVerifyNitroDocumentRejectsOtherRoot(t *testing.T) {
+	ca := newNitroCA(t)
+	doc, err := SignNitroDocument(ca.document(), ca.leafKey)
+	require.NoError(t, err)
+
+	_, err = VerifyNitroDocument(doc, newNitroCA(t).root)
+	require.ErrorIs(t, err, ErrNitroCertificate)
+}
+
+func TestVerifyNitroDocumentRejectsExpiredCertificate(t *testing.T) {
+	ca := newNitroCA(t)
+	d := ca.document()
+	d.Timestamp = 2500 * 1000
+	doc, err := SignNitroDocument(d, ca.leafKey)
+	require.NoError(t, err)
+
+	_, err = VerifyNitroDocument(doc, ca.root)
+	require.ErrorIs(t, err, ErrNitroCertificate)
+}
+
+func TestVerifyNitroDocumentRejectsWrongSigner(t *testing.T) {
+	ca := newNitroCA(t)
+	other, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	doc, err := SignNitroDocument(ca.document(), other)
+	require.NoError(t, err)
+
+	_, err = VerifyNitroDocument(doc, ca.root)
+	require.ErrorIs(t, err, ErrNitroSignature)
+}
+
+func TestNitroPolicyMatch(t *testing.T) {
+	require := require.New(t)
+
+	doc := newNitroCA(t).document()
+	policy := &NitroPolicy{PCRs: []PCR{{Index: 0, Value: []byte{1, 2, 3}}, {Index: 8, Value: []byte{7}}}}
+	require.NoError(policy.Match(doc))
+
+	policy.PCRs[1].Value = []byte{8}
+	require.ErrorIs(policy.Match(doc), ErrNitroPCRMismatch)
+
+	policy.PCRs[1] = PCR{Index: 2, Value: []byte{7}}
+	require.ErrorIs(policy.Match(doc), ErrNitroPCRMismatch)
+
+	require.ErrorIs((&NitroPolicy{}).Match(doc), ErrEmptyNitroPolicy)
+}
+
+func TestDecodeCBORRejectsMalformed(t *testing.T) {
+	for _, b := range [][]byte{
+		{},
+		{0x42, 1},       // short byte string
+		{0x82, 1},       // short array
+		{0xa1, 0x80, 1}, // array map key
+		{0xa2, 1, 1, 1, 1},
+		{0x01, 0x01}, // trailing bytes
+		{0x1f},       // indefinite length
+		{0xf9, 0, 0}, // half float
+	} {
+		_, err := decodeCBOR(b)
+		require.ErrorIs(t, err, ErrMalformedCBOR, "%x", b)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package attestation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPlatformNotAllowed = errors.New("enclave type not allowed by region policy")
+	ErrPlatformMix        = errors.New("region enclaves do not meet platform mix")
+	ErrRealmMeasurement   = errors.New("CCA realm measurement not allowed by region policy")
+	ErrSingleVendor       = errors.New("region enclaves all from one vendor")
+)
+
+// EnclaveTypes lists every enclave type the VM accepts.
+var EnclaveTypes = []EnclaveType{SGX, SEV, Nitro, CCA}
+
+// PlatformRequirement requires [Min] active enclaves of [Type] in a region.
+// A requirement with Min 0 allows the type without requiring it.
+type PlatformRequirement struct {
+	Type EnclaveType `serialize:"true" json:"type"`
+	Min  uint8       `serialize:"true" json:"min"`
+}
+
+// PlatformPolicy restricts which enclave types may serve a region and how
+// many of each it must keep registered, so a single hardware vendor cannot
+// attest for a region alone. For example, one SGX and one SEV enclave.
+type PlatformPolicy struct {
+	Requirements []PlatformRequirement `serialize:"true" json:"requirements"`
+	// RealmMeasurements are the CCA realm initial measurements accepted
+	RealmMeasurements [][]byte `serialize:"true" json:"realm_measurements"`
+	// Heterogeneous requires the region's enclaves to come from at least two
+	// vendors, so the pair attesting an execution cannot share a hardware
+	// compromise
+	Heterogeneous bool `serialize:"true" json:"heterogeneous"`
+	// DualExecution requires each execution to carry the results of two
+	// enclaves of the region, applied only when they match
+	DualExecution bool `serialize:"true" json:"dual_execution"`
+}
+
+// Allows reports whether [t] is listed by [p].
+func (p *PlatformPolicy) Allows(t EnclaveType) bool {
+	for _, req := range p.Requirements {
+		if req.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Vendors returns how many distinct vendors make the types [p] allows.
+func (p *PlatformPolicy) Vendors() int {
+	vendors := make(map[string]struct{}, len(p.Requirements))
+	for _, req := range p.Requirements {
+		vendors[req.Type.Vendor()] = struct{}{}
+	}
+	return len(vendors)
+}
+
+// Satisfied checks that [count] reports at least the required number of
+// enclaves of each type and, for a heterogeneous policy, enclaves from at
+// least two vendors.
+func (p *PlatformPolicy) Satisfied(count func(EnclaveType) (uint64, error)) error {
+	vendors := make(map[string]struct{}, len(p.Requirements))
+	for _, req := range p.Requirements {
+		n, err := count(req.Type)
+		if err != nil {
+			return err
+		}
+		if n < uint64(req.Min) {
+			return fmt.Errorf("%w: %s (required=%d, active=%d)", ErrPlatformMix, req.Type, req.Min, n)
+		}
+		if n > 0 {
+			vendors[req.Type.Vendor()] = struct{}{}
+		}
+	}
+	if p.Heterogeneous && len(vendors) < 2 {
+		return ErrSingleVendor
+	}
+	return nil
+}
+
+// AllowsRealm checks that [measurement] is a realm initial measurement
+// accepted by [p].
+func (p *PlatformPolicy) AllowsRealm(measurement []byte) error {
+	for _, m := range p.RealmMeasurements {
+		if bytes.Equal(m, measurement) {
+			return nil
+		}
+	}
+	return ErrRealmMeasurement
+}
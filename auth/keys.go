@@ -3,7 +3,8 @@
 
 // Package auth provides utilities for generating and loading private keys.
 // This package is only used for testing and CLI purposes and is not required
-// to be implemented by the VM developer.
+// to be implemented by the VM developer. It also provides [Session], the
+// auth of session keys.
 
 package auth
 
@@ -0,0 +1,129 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/utils"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+const (
+	SessionComputeUnits = 5
+	SessionSize         = codec.AddressLen + ed25519.PublicKeyLen + ed25519.SignatureLen
+)
+
+var (
+	ErrInvalidSessionSignature = errors.New("invalid session key signature")
+
+	_ chain.Auth        = (*Session)(nil)
+	_ chain.AuthFactory = (*SessionFactory)(nil)
+)
+
+// SessionAddress returns the address a session [key] of [account] acts as.
+// It is bound to both, so no other account's grant can cover the key.
+func SessionAddress(account codec.Address, key ed25519.PublicKey) codec.Address {
+	return codec.CreateAddress(consts.SessionAuthID, utils.ToID(append(account[:], key[:]...)))
+}
+
+// Session authenticates transactions signed by a session key of [Account],
+// so clients can send frequently without exposing the account's own key.
+//
+// Auth is verified without state, so a session cannot act as [Account]
+// itself: its actor and fee payer is its [SessionAddress]. The grant
+// [Account] gives the key on chain, with AuthorizeSessionAction, scopes
+// what that address may do and lets its events name [Account] as sender.
+type Session struct {
+	Account   codec.Address     `json:"account"`
+	Key       ed25519.PublicKey `json:"key"`
+	Signature ed25519.Signature `json:"signature"`
+
+	addr codec.Address
+}
+
+func (s *Session) address() codec.Address {
+	if s.addr == codec.EmptyAddress {
+		s.addr = SessionAddress(s.Account, s.Key)
+	}
+	return s.addr
+}
+
+func (*Session) GetTypeID() uint8 {
+	return consts.SessionAuthID
+}
+
+func (*Session) ComputeUnits(chain.Rules) uint64 {
+	return SessionComputeUnits
+}
+
+func (*Session) ValidRange(chain.Rules) (int64, int64) {
+	return -1, -1
+}
+
+func (s *Session) Verify(_ context.Context, msg []byte) error {
+	if !ed25519.Verify(msg, s.Key, s.Signature) {
+		return ErrInvalidSessionSignature
+	}
+	return nil
+}
+
+func (s *Session) Actor() codec.Address {
+	return s.address()
+}
+
+func (s *Session) Sponsor() codec.Address {
+	return s.address()
+}
+
+func (*Session) Size() int {
+	return SessionSize
+}
+
+func (s *Session) Marshal(p *codec.Packer) {
+	p.PackAddress(s.Account)
+	p.PackFixedBytes(s.Key[:])
+	p.PackFixedBytes(s.Signature[:])
+}
+
+func UnmarshalSession(p *codec.Packer) (chain.Auth, error) {
+	var s Session
+	p.UnpackAddress(&s.Account)
+	key := s.Key[:] // avoid allocating additional memory
+	p.UnpackFixedBytes(ed25519.PublicKeyLen, &key)
+	signature := s.Signature[:]
+	p.UnpackFixedBytes(ed25519.SignatureLen, &signature)
+	return &s, p.Err()
+}
+
+// SessionFactory signs transactions with a session key of [account].
+type SessionFactory struct {
+	account codec.Address
+	priv    ed25519.PrivateKey
+}
+
+func NewSessionFactory(account codec.Address, priv ed25519.PrivateKey) *SessionFactory {
+	return &SessionFactory{account: account, priv: priv}
+}
+
+func (f *SessionFactory) Sign(msg []byte) (chain.Auth, error) {
+	return &Session{
+		Account:   f.account,
+		Key:       f.priv.PublicKey(),
+		Signature: ed25519.Sign(msg, f.priv),
+	}, nil
+}
+
+func (*SessionFactory) MaxUnits() (uint64, uint64) {
+	return SessionSize, SessionComputeUnits
+}
+
+func (f *SessionFactory) Address() codec.Address {
+	return SessionAddress(f.account, f.priv.PublicKey())
+}
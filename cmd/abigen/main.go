@@ -0,0 +1,55 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// "abigen" emits TypeScript and Rust encoders and decoders for every action
+// and result registered by the VM, generated from [vm.GetDescriptor].
+//
+//	go run ./cmd/abigen -lang ts -out clients/ts/actions.ts
+//	go run ./cmd/abigen -lang rust -out clients/rust/src/actions.rs
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rhombus-tech/vm/vm"
+)
+
+func main() {
+	lang := flag.String("lang", "ts", "target language: ts or rust")
+	out := flag.String("out", "", "output file (stdout if empty)")
+	flag.Parse()
+
+	if err := run(*lang, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "abigen failed %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(lang string, out string) error {
+	descriptor, err := vm.GetDescriptor()
+	if err != nil {
+		return err
+	}
+
+	var gen generator
+	switch lang {
+	case "ts", "typescript":
+		gen = tsGenerator{}
+	case "rust", "rs":
+		gen = rustGenerator{}
+	default:
+		return fmt.Errorf("unknown language %q", lang)
+	}
+
+	src, err := generate(gen, descriptor)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
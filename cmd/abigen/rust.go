@@ -0,0 +1,242 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rhombus-tech/vm/vm"
+)
+
+const rustRuntime = `use std::collections::BTreeMap;
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum DecodeError {
+    UnexpectedEnd,
+    InvalidUtf8,
+}
+
+#[derive(Default)]
+pub struct Writer {
+    buf: Vec<u8>,
+}
+
+impl Writer {
+    pub fn u8(&mut self, v: u8) { self.buf.push(v); }
+    pub fn u16(&mut self, v: u16) { self.buf.extend_from_slice(&v.to_be_bytes()); }
+    pub fn u32(&mut self, v: u32) { self.buf.extend_from_slice(&v.to_be_bytes()); }
+    pub fn u64(&mut self, v: u64) { self.buf.extend_from_slice(&v.to_be_bytes()); }
+    pub fn i64(&mut self, v: i64) { self.buf.extend_from_slice(&v.to_be_bytes()); }
+    pub fn bool(&mut self, v: bool) { self.buf.push(v as u8); }
+    pub fn fixed(&mut self, v: &[u8]) { self.buf.extend_from_slice(v); }
+    pub fn bytes(&mut self, v: &[u8]) { self.u32(v.len() as u32); self.buf.extend_from_slice(v); }
+    pub fn string(&mut self, v: &str) { self.u16(v.len() as u16); self.buf.extend_from_slice(v.as_bytes()); }
+    pub fn finish(self) -> Vec<u8> { self.buf }
+}
+
+pub struct Reader<'a> {
+    buf: &'a [u8],
+    off: usize,
+}
+
+impl<'a> Reader<'a> {
+    pub fn new(buf: &'a [u8]) -> Self { Reader { buf, off: 0 } }
+    fn take(&mut self, n: usize) -> Result<&'a [u8], DecodeError> {
+        if self.off + n > self.buf.len() {
+            return Err(DecodeError::UnexpectedEnd);
+        }
+        let b = &self.buf[self.off..self.off + n];
+        self.off += n;
+        Ok(b)
+    }
+    pub fn u8(&mut self) -> Result<u8, DecodeError> { Ok(self.take(1)?[0]) }
+    pub fn u16(&mut self) -> Result<u16, DecodeError> { Ok(u16::from_be_bytes(self.fixed()?)) }
+    pub fn u32(&mut self) -> Result<u32, DecodeError> { Ok(u32::from_be_bytes(self.fixed()?)) }
+    pub fn u64(&mut self) -> Result<u64, DecodeError> { Ok(u64::from_be_bytes(self.fixed()?)) }
+    pub fn i64(&mut self) -> Result<i64, DecodeError> { Ok(i64::from_be_bytes(self.fixed()?)) }
+    pub fn bool(&mut self) -> Result<bool, DecodeError> { Ok(self.u8()? != 0) }
+    pub fn fixed<const N: usize>(&mut self) -> Result<[u8; N], DecodeError> {
+        let mut out = [0u8; N];
+        out.copy_from_slice(self.take(N)?);
+        Ok(out)
+    }
+    pub fn bytes(&mut self) -> Result<Vec<u8>, DecodeError> {
+        let n = self.u32()? as usize;
+        Ok(self.take(n)?.to_vec())
+    }
+    pub fn string(&mut self) -> Result<String, DecodeError> {
+        let n = self.u16()? as usize;
+        String::from_utf8(self.take(n)?.to_vec()).map_err(|_| DecodeError::InvalidUtf8)
+    }
+    pub fn array<T>(&mut self, f: impl Fn(&mut Self) -> Result<T, DecodeError>) -> Result<Vec<T>, DecodeError> {
+        let n = self.u32()?;
+        (0..n).map(|_| f(self)).collect()
+    }
+    pub fn map<K: Ord, V>(
+        &mut self,
+        k: impl Fn(&mut Self) -> Result<K, DecodeError>,
+        v: impl Fn(&mut Self) -> Result<V, DecodeError>,
+    ) -> Result<BTreeMap<K, V>, DecodeError> {
+        let n = self.u32()?;
+        let mut out = BTreeMap::new();
+        for _ in 0..n {
+            let key = k(self)?;
+            out.insert(key, v(self)?);
+        }
+        Ok(out)
+    }
+    pub fn done(&self) -> bool { self.off == self.buf.len() }
+}
+`
+
+type rustGenerator struct{}
+
+func (rustGenerator) header(d *vm.Descriptor) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by abigen. DO NOT EDIT.\n\n")
+	b.WriteString(rustRuntime)
+	b.WriteString("\npub mod type_ids {\n")
+	for _, id := range typeIDs(d) {
+		fmt.Fprintf(&b, "    pub const %s: u8 = %s;\n", screamingSnake(id[0]), id[1])
+	}
+	b.WriteString("}\n\n/// Latest version of each versioned action, by type ID.\npub const VERSIONED: &[(u8, u8)] = &[\n")
+	for _, a := range d.Actions {
+		if a.Versioned {
+			fmt.Fprintf(&b, "    (%d, %d),\n", a.ID, a.LatestVersion)
+		}
+	}
+	b.WriteString("];\n")
+	return b.String()
+}
+
+func rustType(t *fieldType) string {
+	switch t.kind {
+	case kindUint8:
+		return "u8"
+	case kindUint16:
+		return "u16"
+	case kindUint32:
+		return "u32"
+	case kindUint64:
+		return "u64"
+	case kindInt64:
+		return "i64"
+	case kindBool:
+		return "bool"
+	case kindString:
+		return "String"
+	case kindBytes:
+		return "Vec<u8>"
+	case kindFixed:
+		return fmt.Sprintf("[u8; %d]", t.size)
+	case kindSlice:
+		return "Vec<" + rustType(t.elem) + ">"
+	case kindMap:
+		return "BTreeMap<" + rustType(t.key) + ", " + rustType(t.elem) + ">"
+	default:
+		return t.name
+	}
+}
+
+func (rustGenerator) structDecl(name string, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n#[derive(Debug, Clone, PartialEq)]\npub struct %s {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "    pub %s: %s,\n", f.name, rustType(f.typ))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// rustWrite emits statements writing [expr], which is always a reference.
+func rustWrite(b *strings.Builder, t *fieldType, expr string, depth int) {
+	pad := strings.Repeat("    ", depth)
+	switch t.kind {
+	case kindString, kindBytes, kindFixed:
+		fmt.Fprintf(b, "%sw.%s(%s);\n", pad, rustMethod(t), expr)
+	case kindSlice:
+		x := fmt.Sprintf("x%d", depth)
+		fmt.Fprintf(b, "%sw.u32((%s).len() as u32);\n", pad, expr)
+		fmt.Fprintf(b, "%sfor %s in (%s).iter() {\n", pad, x, expr)
+		rustWrite(b, t.elem, x, depth+1)
+		fmt.Fprintf(b, "%s}\n", pad)
+	case kindMap:
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		fmt.Fprintf(b, "%sw.u32((%s).len() as u32);\n", pad, expr)
+		fmt.Fprintf(b, "%sfor (%s, %s) in (%s).iter() {\n", pad, k, v, expr)
+		rustWrite(b, t.key, k, depth+1)
+		rustWrite(b, t.elem, v, depth+1)
+		fmt.Fprintf(b, "%s}\n", pad)
+	case kindStruct:
+		fmt.Fprintf(b, "%sencode_%s(w, %s);\n", pad, snake(t.name), expr)
+	default:
+		fmt.Fprintf(b, "%sw.%s(*%s);\n", pad, rustMethod(t), expr)
+	}
+}
+
+func rustMethod(t *fieldType) string {
+	switch t.kind {
+	case kindFixed:
+		return "fixed"
+	default:
+		return tsPrimitive[t.kind]
+	}
+}
+
+func rustRead(t *fieldType) string {
+	switch t.kind {
+	case kindFixed:
+		return fmt.Sprintf("r.fixed::<%d>()?", t.size)
+	case kindSlice:
+		return "r.array(|r| Ok(" + rustRead(t.elem) + "))?"
+	case kindMap:
+		return "r.map(|r| Ok(" + rustRead(t.key) + "), |r| Ok(" + rustRead(t.elem) + "))?"
+	case kindStruct:
+		return "decode_" + snake(t.name) + "(r)?"
+	default:
+		return "r." + rustMethod(t) + "()?"
+	}
+}
+
+func (rustGenerator) encoder(name string, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\npub fn encode_%s(w: &mut Writer, v: &%s) {\n", snake(name), name)
+	for _, f := range fields {
+		rustWrite(&b, f.typ, "&v."+f.name, 1)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (rustGenerator) decoder(name string, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\npub fn decode_%s(r: &mut Reader) -> Result<%s, DecodeError> {\n", snake(name), name)
+	fmt.Fprintf(&b, "    Ok(%s {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "        %s: %s,\n", f.name, rustRead(f.typ))
+	}
+	b.WriteString("    })\n}\n")
+	return b.String()
+}
+
+func snake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			prevUpper := i > 0 && s[i-1] >= 'A' && s[i-1] <= 'Z'
+			nextLower := i+1 < len(s) && s[i+1] >= 'a' && s[i+1] <= 'z'
+			if i > 0 && (!prevUpper || nextLower) {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func screamingSnake(s string) string {
+	return strings.ToUpper(snake(s))
+}
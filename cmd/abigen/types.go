@@ -0,0 +1,159 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/hypersdk/abi"
+	"github.com/ava-labs/hypersdk/codec"
+
+	"github.com/rhombus-tech/vm/vm"
+)
+
+type kind int
+
+const (
+	kindUint8 kind = iota
+	kindUint16
+	kindUint32
+	kindUint64
+	kindInt64
+	kindBool
+	kindString
+	kindBytes
+	kindFixed
+	kindSlice
+	kindMap
+	kindStruct
+)
+
+// fieldType is an ABI type string resolved to the layout used by
+// codec.Packer: big-endian integers, uint16-prefixed strings, uint32-prefixed
+// byte slices and element counts, and fixed arrays written as-is.
+type fieldType struct {
+	kind kind
+	size int        // kindFixed
+	elem *fieldType // kindSlice, and the value of kindMap
+	key  *fieldType // kindMap
+	name string     // kindStruct
+}
+
+var primitives = map[string]kind{
+	"uint8":  kindUint8,
+	"byte":   kindUint8,
+	"uint16": kindUint16,
+	"uint32": kindUint32,
+	"uint64": kindUint64,
+	"int64":  kindInt64,
+	"bool":   kindBool,
+	"string": kindString,
+}
+
+// opaque types are packed as their own byte encoding rather than field by
+// field.
+var opaque = map[string]struct{}{
+	"Event": {},
+}
+
+func parseType(s string) (*fieldType, error) {
+	if k, ok := primitives[s]; ok {
+		return &fieldType{kind: k}, nil
+	}
+	if _, ok := opaque[s]; ok {
+		return &fieldType{kind: kindBytes}, nil
+	}
+	switch {
+	case s == "Address":
+		return &fieldType{kind: kindFixed, size: codec.AddressLen}, nil
+	case s == "[]uint8" || s == "[]byte":
+		return &fieldType{kind: kindBytes}, nil
+	case strings.HasPrefix(s, "[]"):
+		elem, err := parseType(s[2:])
+		if err != nil {
+			return nil, err
+		}
+		return &fieldType{kind: kindSlice, elem: elem}, nil
+	case strings.HasPrefix(s, "["):
+		end := strings.IndexByte(s, ']')
+		if end < 0 || (s[end+1:] != "uint8" && s[end+1:] != "byte") {
+			return nil, fmt.Errorf("unsupported array type %q", s)
+		}
+		size, err := strconv.Atoi(s[1:end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length in %q", s)
+		}
+		return &fieldType{kind: kindFixed, size: size}, nil
+	case strings.HasPrefix(s, "map["):
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid map type %q", s)
+		}
+		key, err := parseType(s[4:end])
+		if err != nil {
+			return nil, err
+		}
+		val, err := parseType(s[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		return &fieldType{kind: kindMap, key: key, elem: val}, nil
+	default:
+		return &fieldType{kind: kindStruct, name: s}, nil
+	}
+}
+
+type field struct {
+	name string
+	typ  *fieldType
+}
+
+type generator interface {
+	header(d *vm.Descriptor) string
+	structDecl(name string, fields []field) string
+	encoder(name string, fields []field) string
+	decoder(name string, fields []field) string
+}
+
+func generate(gen generator, d *vm.Descriptor) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(gen.header(d))
+	for _, typ := range d.ABI.Types {
+		fields, err := resolveFields(typ)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", typ.Name, err)
+		}
+		buf.WriteString(gen.structDecl(typ.Name, fields))
+		buf.WriteString(gen.encoder(typ.Name, fields))
+		buf.WriteString(gen.decoder(typ.Name, fields))
+	}
+	return buf.Bytes(), nil
+}
+
+func resolveFields(typ abi.Type) ([]field, error) {
+	fields := make([]field, 0, len(typ.Fields))
+	for _, f := range typ.Fields {
+		t, err := parseType(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{name: f.Name, typ: t})
+	}
+	return fields, nil
+}
+
+// typeIDs lists the action and output type IDs by name.
+func typeIDs(d *vm.Descriptor) [][2]string {
+	ids := make([][2]string, 0, len(d.ABI.Actions)+len(d.ABI.Outputs))
+	for _, a := range d.ABI.Actions {
+		ids = append(ids, [2]string{a.Name, strconv.Itoa(int(a.ID))})
+	}
+	for _, o := range d.ABI.Outputs {
+		ids = append(ids, [2]string{o.Name, strconv.Itoa(int(o.ID))})
+	}
+	return ids
+}
@@ -0,0 +1,191 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rhombus-tech/vm/vm"
+)
+
+const tsRuntime = `export class Writer {
+  private buf: number[] = [];
+  u8(v: number) { this.buf.push(v & 0xff); }
+  u16(v: number) { this.u8(v >> 8); this.u8(v); }
+  u32(v: number) { this.u16(v >>> 16); this.u16(v & 0xffff); }
+  u64(v: bigint) { this.u32(Number(v >> 32n)); this.u32(Number(v & 0xffffffffn)); }
+  i64(v: bigint) { this.u64(BigInt.asUintN(64, v)); }
+  bool(v: boolean) { this.u8(v ? 1 : 0); }
+  fixed(v: Uint8Array, n: number) {
+    if (v.length !== n) throw new Error("expected " + n + " bytes");
+    this.buf.push(...v);
+  }
+  bytes(v: Uint8Array) { this.u32(v.length); this.buf.push(...v); }
+  string(v: string) { const b = new TextEncoder().encode(v); this.u16(b.length); this.buf.push(...b); }
+  finish(): Uint8Array { return Uint8Array.from(this.buf); }
+}
+
+export class Reader {
+  private off = 0;
+  constructor(private buf: Uint8Array) {}
+  private take(n: number): Uint8Array {
+    if (this.off + n > this.buf.length) throw new Error("unexpected end of input");
+    const b = this.buf.subarray(this.off, this.off + n);
+    this.off += n;
+    return b;
+  }
+  u8(): number { return this.take(1)[0]; }
+  u16(): number { return (this.u8() << 8) | this.u8(); }
+  u32(): number { return ((this.u16() << 16) | this.u16()) >>> 0; }
+  u64(): bigint { return (BigInt(this.u32()) << 32n) | BigInt(this.u32()); }
+  i64(): bigint { return BigInt.asIntN(64, this.u64()); }
+  bool(): boolean { return this.u8() !== 0; }
+  fixed(n: number): Uint8Array { return this.take(n).slice(); }
+  bytes(): Uint8Array { return this.fixed(this.u32()); }
+  string(): string { return new TextDecoder().decode(this.take(this.u16())); }
+  array<T>(f: () => T): T[] {
+    const n = this.u32();
+    const out: T[] = [];
+    for (let i = 0; i < n; i++) out.push(f());
+    return out;
+  }
+  map<V>(k: () => string, v: () => V): Record<string, V> {
+    const n = this.u32();
+    const out: Record<string, V> = {};
+    for (let i = 0; i < n; i++) out[k()] = v();
+    return out;
+  }
+  done(): boolean { return this.off === this.buf.length; }
+}
+`
+
+type tsGenerator struct{}
+
+func (tsGenerator) header(d *vm.Descriptor) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by abigen. DO NOT EDIT.\n\n")
+	b.WriteString(tsRuntime)
+	b.WriteString("\nexport const TypeIDs = {\n")
+	for _, id := range typeIDs(d) {
+		fmt.Fprintf(&b, "  %s: %s,\n", id[0], id[1])
+	}
+	b.WriteString("} as const;\n\nexport const Versioned: Record<number, number> = {\n")
+	for _, a := range d.Actions {
+		if a.Versioned {
+			fmt.Fprintf(&b, "  %d: %d,\n", a.ID, a.LatestVersion)
+		}
+	}
+	b.WriteString("};\n")
+	return b.String()
+}
+
+func tsType(t *fieldType) string {
+	switch t.kind {
+	case kindUint8, kindUint16, kindUint32:
+		return "number"
+	case kindUint64, kindInt64:
+		return "bigint"
+	case kindBool:
+		return "boolean"
+	case kindString:
+		return "string"
+	case kindBytes, kindFixed:
+		return "Uint8Array"
+	case kindSlice:
+		return tsType(t.elem) + "[]"
+	case kindMap:
+		return "Record<string, " + tsType(t.elem) + ">"
+	default:
+		return t.name
+	}
+}
+
+func (tsGenerator) structDecl(name string, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nexport interface %s {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "  %s: %s;\n", f.name, tsType(f.typ))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+var tsPrimitive = map[kind]string{
+	kindUint8:  "u8",
+	kindUint16: "u16",
+	kindUint32: "u32",
+	kindUint64: "u64",
+	kindInt64:  "i64",
+	kindBool:   "bool",
+	kindString: "string",
+	kindBytes:  "bytes",
+}
+
+func tsWrite(b *strings.Builder, t *fieldType, expr string, depth int) {
+	pad := strings.Repeat("  ", depth)
+	switch t.kind {
+	case kindFixed:
+		fmt.Fprintf(b, "%sw.fixed(%s, %d);\n", pad, expr, t.size)
+	case kindSlice:
+		x := fmt.Sprintf("x%d", depth)
+		fmt.Fprintf(b, "%sw.u32(%s.length);\n", pad, expr)
+		fmt.Fprintf(b, "%sfor (const %s of %s) {\n", pad, x, expr)
+		tsWrite(b, t.elem, x, depth+1)
+		fmt.Fprintf(b, "%s}\n", pad)
+	case kindMap:
+		k := fmt.Sprintf("k%d", depth)
+		fmt.Fprintf(b, "%sw.u32(Object.keys(%s).length);\n", pad, expr)
+		fmt.Fprintf(b, "%sfor (const %s of Object.keys(%s).sort()) {\n", pad, k, expr)
+		tsWrite(b, t.key, k, depth+1)
+		tsWrite(b, t.elem, expr+"["+k+"]", depth+1)
+		fmt.Fprintf(b, "%s}\n", pad)
+	case kindStruct:
+		fmt.Fprintf(b, "%sencode%s(w, %s);\n", pad, t.name, expr)
+	default:
+		fmt.Fprintf(b, "%sw.%s(%s);\n", pad, tsPrimitive[t.kind], expr)
+	}
+}
+
+func tsRead(t *fieldType) string {
+	switch t.kind {
+	case kindFixed:
+		return fmt.Sprintf("r.fixed(%d)", t.size)
+	case kindSlice:
+		return "r.array(() => " + tsRead(t.elem) + ")"
+	case kindMap:
+		return "r.map(() => " + tsRead(t.key) + ", () => " + tsRead(t.elem) + ")"
+	case kindStruct:
+		return "decode" + t.name + "(r)"
+	default:
+		return "r." + tsPrimitive[t.kind] + "()"
+	}
+}
+
+func (tsGenerator) encoder(name string, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nexport function encode%s(w: Writer, v: %s) {\n", name, name)
+	for _, f := range fields {
+		tsWrite(&b, f.typ, "v."+f.name, 1)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (tsGenerator) decoder(name string, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nexport function decode%s(r: Reader): %s {\n", name, name)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "  const %s = %s;\n", f.name, tsRead(f.typ))
+	}
+	b.WriteString("  return {")
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(" " + f.name)
+	}
+	b.WriteString(" };\n}\n")
+	return b.String()
+}
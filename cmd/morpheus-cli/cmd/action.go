@@ -5,12 +5,14 @@ package cmd
 
 import (
 	"context"
+	"encoding/hex"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ava-labs/hypersdk-starter-kit/actions"
 	"github.com/ava-labs/hypersdk/chain"
 	"github.com/ava-labs/hypersdk/cli/prompt"
+	"github.com/ava-labs/hypersdk/utils"
 )
 
 var actionCmd = &cobra.Command{
@@ -61,3 +63,32 @@ var transferCmd = &cobra.Command{
 		return err
 	},
 }
+
+var estimateCmd = &cobra.Command{
+	Use:   "estimate [action hex]",
+	Short: "Estimate the units and fee of an unsigned action",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		ctx := context.Background()
+		_, priv, _, _, bcli, _, err := handler.DefaultActor()
+		if err != nil {
+			return err
+		}
+		blob, err := hex.DecodeString(args[0])
+		if err != nil {
+			return err
+		}
+		estimate, err := bcli.EstimateUnits(ctx, priv.Address, blob)
+		if err != nil {
+			return err
+		}
+		utils.Outf(
+			"{{yellow}}type:{{/}} %d {{yellow}}compute units:{{/}} %d {{yellow}}state keys:{{/}} %d {{yellow}}fee:{{/}} %d\n",
+			estimate.TypeID,
+			estimate.ComputeUnits,
+			estimate.StateKeys,
+			estimate.Fee,
+		)
+		return nil
+	},
+}
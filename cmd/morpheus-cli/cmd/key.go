@@ -4,9 +4,13 @@
 package cmd
 
 import (
+	"encoding/hex"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/ava-labs/hypersdk-starter-kit/auth"
+	"github.com/ava-labs/hypersdk-starter-kit/vm"
 	"github.com/ava-labs/hypersdk/utils"
 )
 
@@ -84,3 +88,27 @@ var balanceKeyCmd = &cobra.Command{
 		return handler.Root().Balance(checkAllChains)
 	},
 }
+
+var accessTokenCmd = &cobra.Command{
+	Use: "access-token [secret] [region...]",
+	PreRunE: func(_ *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return ErrInvalidArgs
+		}
+		return nil
+	},
+	RunE: func(_ *cobra.Command, args []string) error {
+		secret, err := hex.DecodeString(args[0])
+		if err != nil {
+			return err
+		}
+		expiry := time.Now().Add(accessTokenTTL)
+		token := vm.IssueAccessToken(secret, args[1:], expiry)
+		utils.Outf(
+			"{{green}}access token:{{/}} %s {{yellow}}expires:{{/}} %s\n",
+			token,
+			expiry.Format(time.RFC3339),
+		)
+		return nil
+	},
+}
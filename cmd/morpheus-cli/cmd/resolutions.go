@@ -63,12 +63,14 @@ func sendAndWait(
 func handleTx(tx *chain.Transaction, result *chain.Result) {
 	actor := tx.Auth.Actor()
 	if !result.Success {
+		code, msg := vm.ResultError(result)
 		utils.Outf(
-			"%s {{yellow}}%s{{/}} {{yellow}}actor:{{/}} %s {{yellow}}error:{{/}} [%s] {{yellow}}fee (max %.2f%%):{{/}} %s %s {{yellow}}consumed:{{/}} [%s]\n",
+			"%s {{yellow}}%s{{/}} {{yellow}}actor:{{/}} %s {{yellow}}error (%s):{{/}} [%s] {{yellow}}fee (max %.2f%%):{{/}} %s %s {{yellow}}consumed:{{/}} [%s]\n",
 			"❌",
 			tx.ID(),
 			actor,
-			result.Error,
+			code,
+			msg,
 			float64(result.Fee)/float64(tx.Base.MaxFee)*100,
 			utils.FormatBalance(result.Fee),
 			consts.Symbol,
@@ -31,6 +31,11 @@ var (
 	hideTxs               bool
 	checkAllChains        bool
 	spamDefaults          bool
+	spamMix               string
+	spamRegions           []string
+	spamRamp              string
+	spamFaults            string
+	accessTokenTTL        time.Duration
 	prometheusBaseURI     string
 	prometheusOpenBrowser bool
 	prometheusFile        string
@@ -122,6 +127,13 @@ func init() {
 		importKeyCmd,
 		setKeyCmd,
 		balanceKeyCmd,
+		accessTokenCmd,
+	)
+	accessTokenCmd.PersistentFlags().DurationVar(
+		&accessTokenTTL,
+		"ttl",
+		24*time.Hour,
+		"how long the region subscription token is valid",
 	)
 
 	// chain
@@ -141,6 +153,7 @@ func init() {
 	// actions
 	actionCmd.AddCommand(
 		transferCmd,
+		estimateCmd,
 	)
 
 	runSpamCmd.PersistentFlags().BoolVar(
@@ -149,6 +162,30 @@ func init() {
 		false,
 		"use default spam parameters",
 	)
+	runSpamCmd.PersistentFlags().StringVar(
+		&spamMix,
+		"mix",
+		"transfer=1",
+		"workload mix as kind=weight (transfer, create_object, send_event, tee_exec)",
+	)
+	runSpamCmd.PersistentFlags().StringSliceVar(
+		&spamRegions,
+		"regions",
+		nil,
+		"regions to send TEEExec actions to, each signed by its own enclave pair",
+	)
+	runSpamCmd.PersistentFlags().StringVar(
+		&spamRamp,
+		"ramp",
+		"",
+		"issuance ramp as duration:tps steps (e.g. 30s:100,2m:500)",
+	)
+	runSpamCmd.PersistentFlags().StringVar(
+		&spamFaults,
+		"faults",
+		"",
+		"faults the mock enclaves inject as kind=rate[:duration] (e.g. drop=0.1,delay=0.2:2s,corrupt=0.05,skew=0.1:-90s)",
+	)
 
 	// spam
 	spamCmd.AddCommand(
@@ -5,10 +5,12 @@ package cmd
 
 import (
 	"context"
+	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ava-labs/hypersdk-starter-kit/auth"
+	"github.com/ava-labs/hypersdk-starter-kit/mocktee"
 	"github.com/ava-labs/hypersdk-starter-kit/throughput"
 )
 
@@ -29,6 +31,36 @@ var runSpamCmd = &cobra.Command{
 	},
 	RunE: func(_ *cobra.Command, args []string) error {
 		ctx := context.Background()
-		return handler.Root().Spam(ctx, &throughput.SpamHelper{KeyType: args[0]}, spamDefaults)
+		profile, err := spamProfile()
+		if err != nil {
+			return err
+		}
+		helper := &throughput.SpamHelper{KeyType: args[0], Profile: profile}
+		if err := handler.Root().Spam(ctx, helper, spamDefaults); err != nil {
+			return err
+		}
+		helper.Report(os.Stdout)
+		return nil
 	},
 }
+
+func spamProfile() (*throughput.Profile, error) {
+	mix, err := throughput.ParseMix(spamMix)
+	if err != nil {
+		return nil, err
+	}
+	profile := &throughput.Profile{Mix: mix, Regions: spamRegions}
+	if spamRamp != "" {
+		profile.Ramp, err = throughput.ParseRamp(spamRamp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if spamFaults != "" {
+		profile.Faults, err = mocktee.ParseFaults(spamFaults)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return profile, profile.Validate()
+}
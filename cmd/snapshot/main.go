@@ -0,0 +1,138 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// "snapshot" exports ShuttleVM state, or a single region of it, to a
+// portable file with a manifest hash, and imports such a file into the state
+// database of a new node. The node must not have the database open.
+//
+//	go run ./cmd/snapshot export -db ~/.shuttlevm/state -out state.snap [-region us-east]
+//	go run ./cmd/snapshot import -db ./seed/state -in state.snap
+//	go run ./cmd/snapshot inspect -in state.snap
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/leveldb"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var errUsage = errors.New("usage: snapshot export|import|inspect [flags]")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "snapshot failed %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	dbPath := fs.String("db", "", "path to the state database")
+	file := new(string)
+	fs.StringVar(file, "out", "", "snapshot file to write (export)")
+	fs.StringVar(file, "in", "", "snapshot file to read (import, inspect)")
+	region := fs.String("region", "", "export only this region")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errUsage
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "export":
+		return export(ctx, *dbPath, *file, *region)
+	case "import":
+		return importSnapshot(ctx, *dbPath, *file)
+	case "inspect":
+		return inspect(ctx, *file)
+	default:
+		return errUsage
+	}
+}
+
+func export(ctx context.Context, dbPath string, out string, region string) error {
+	db, err := openDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	manifest, err := storage.ExportSnapshot(ctx, db, f, region)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return printManifest(manifest)
+}
+
+func importSnapshot(ctx context.Context, dbPath string, in string) error {
+	db, err := openDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifest, err := storage.ImportSnapshot(ctx, f, db)
+	if err != nil {
+		return err
+	}
+	return printManifest(manifest)
+}
+
+func inspect(ctx context.Context, in string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifest, err := storage.ReadSnapshot(ctx, f, func(_, _ []byte) error { return nil })
+	if err != nil {
+		return err
+	}
+	return printManifest(manifest)
+}
+
+func openDB(dbPath string) (database.Database, error) {
+	if dbPath == "" {
+		return nil, errors.New("-db is required")
+	}
+	return leveldb.New(dbPath, nil, logging.NoLog{}, prometheus.NewRegistry())
+}
+
+func printManifest(manifest *storage.Manifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// "statecheck" runs [storage.CheckConsistency] offline against a LevelDB
+// directory holding raw state keys, such as an imported snapshot. The node
+// must not have the database open.
+//
+//	go run ./cmd/statecheck -db ~/.shuttlevm/state -max-supply 10000000000000000000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ava-labs/avalanchego/database/leveldb"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "", "path to the state database")
+	maxSupply := flag.Uint64("max-supply", 0, "upper bound on the sum of balances (0 skips the check)")
+	flag.Parse()
+
+	if err := run(*dbPath, *maxSupply); err != nil {
+		fmt.Fprintf(os.Stderr, "statecheck failed %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("state is consistent")
+}
+
+func run(dbPath string, maxSupply uint64) error {
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	db, err := leveldb.New(dbPath, nil, logging.NoLog{}, prometheus.NewRegistry())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return storage.CheckConsistency(context.Background(), db, maxSupply)
+}
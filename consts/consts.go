@@ -17,8 +17,179 @@ const (
     MaxCodeSize    = 1024 * 1024    // 1MB
     MaxStorageSize = 1024 * 1024    // 1MB
     MaxIDLength    = 256
+
+    // Per-key limits for the object key-value namespace
+    MaxObjectKVKeySize   = 256
+    MaxObjectKVValueSize = 64 * 1024 // 64KiB
+
+    // Chunked code uploads. Chunks are appended in order and an upload
+    // untouched for UploadTimeout (in milliseconds) may be reclaimed.
+    MaxChunkSize    = 64 * 1024 // 64KiB
+    MaxUploadChunks = MaxCodeSize / MaxChunkSize
+    UploadTimeout   = 10 * 60 * 1000 // 10 minutes
+
+    // Parameter schemas an object declares for its exported functions
+    MaxSchemasPerObject = 64
+    MaxSchemaParams     = 32
+
+    // Code header layout shared by the validator and the storage read path.
+    // The first reserved byte holds flags.
+    CodeHeaderMagic = "\x00SHUTTLE"
+    CodeHeaderSize  = 16 // Magic (8) + Format (1) + Version (1) + Reserved (6)
+    CodeFlagsOffset = 10
+    CodeFlagZstd    = 1 << 0 // body after the header is zstd compressed
+    // Flags this binary understands. Code with any other flag set is
+    // rejected rather than misread.
+    CodeKnownFlags = CodeFlagZstd
+    // The second reserved byte is the ABI version the code is built
+    // against, the next two the minimum runtime version, big-endian. Zero
+    // means ABI version 1 and any runtime. The last two must be zero.
+    CodeABIOffset        = 11
+    CodeMinRuntimeOffset = 12
+    CodeABIVersion       = 1
+    CodeRuntimeVersion   = 1
+
+    // CodeHostVersion is the version of the host interface enclaves offer
+    // object code. Custom format code requiring a later one is rejected.
+    CodeHostVersion = 1
+
+    // State update values at least this large are stored zstd compressed
+    CompressThreshold = 1024
+
+    // State update values at least this large are also stored as blobs
+    // that later updates can reference by hash
+    MinBlobSize = 128
+
+    // Regions running as their own lanes settle a state root per epoch.
+    // A settlement may be challenged for SettlementWindow (in seconds)
+    // before it becomes the region's root.
+    SettlementWindow = 24 * 60 * 60 // 1 day
+
+    // Enclaves registered from attestation evidence must re-attest within
+    // AttestationValidity (in seconds) or stop being accepted
+    AttestationValidity = 7 * 24 * 60 * 60 // 1 week
+
+    // Enclaves removed from a region stay on record, inactive, for
+    // EnclaveRetention (in seconds) before anyone may prune them
+    EnclaveRetention = 30 * 24 * 60 * 60 // 30 days
+
+    // A region's enclave pair publishes randomness each epoch by revealing
+    // seeds it committed to in the last one. For BeaconRevealWindow (in
+    // seconds) after a publication, only the committed pair may publish
+    // the next epoch.
+    BeaconRevealWindow = 60 * 60 // 1 hour
+
+    // A registered name resolves for NameTerm (in seconds) from its
+    // registration or renewal. For NameGracePeriod after it lapses, only
+    // its owner may renew it.
+    NameTerm        = 365 * 24 * 60 * 60 // 1 year
+    NameGracePeriod = 30 * 24 * 60 * 60  // 30 days
+
+    // An event's idempotency key is remembered for IdempotencyWindow (in
+    // seconds), during which retries carrying it are rejected
+    IdempotencyWindow     = 10 * 60 // 10 minutes
+    MaxIdempotencyKeySize = 64
+    BeaconSeedSize     = 32
+
+    // Data feeds keep their last FeedHistory values in state
+    FeedHistory    = 1024
+    MaxFeedSigners = 16
+
+    // A region's keyring holds at most MaxKeyringKeys application key
+    // versions; rotation drops retired versions of a name beyond
+    // KeyringHistory
+    MaxKeyringKeys = 64
+    KeyringHistory = 4
+    MaxKeyNameSize = 64
+
+    // Hard caps on attacker-controlled counts, checked while unmarshaling
+    // before any slice or map is allocated
+    MinTEEsPerRegion   = 2 // at least one attestation pair
+    MaxTEEsPerRegion   = 16
+    MaxEventsPerExec   = 256
+    MaxStateUpdates    = 1024
+    MaxTimeStampsCount = 16
+    MaxObjectReads     = 1024
+    MaxCallFrames      = 256
+
+    // Bounds on the logs of one execution
+    MaxLogsPerExec = 64
+    MaxLogTopics   = 4
+    MaxLogDataSize = 1024
+
+    // Caps on the attestations, and the Roughtime stamps they carry, that
+    // one transaction asks validators to verify
+    MaxTxAttestations = 8
+    MaxTxTimeStamps   = 4 * MaxTimeStampsCount
+
+    // MaxRegionTemplates bounds the region templates governance maintains
+    MaxRegionTemplates = 16
+
+    // Registered names are 3 to 64 lowercase letters, digits, '-' and '.'
+    MinNameLen = 3
+    MaxNameLen = 64
+
+    // Bounds on the discovery metadata of an object
+    MaxObjectDescriptionLen = 256
+    MaxObjectTags           = 8
+    MaxObjectTagLen         = 32
+    MaxObjectExports        = 64
+    MaxObjectInterfaces     = 8
+    MaxInterfaceFunctions   = 32
+
+    // MaxCallDepth bounds nesting of object-to-object calls in one execution
+    MaxCallDepth = 8
+
+    // Resource limits of one execution in a region that sets none. A
+    // region's limits may not exceed the Max bounds.
+    DefaultMaxMemoryPages   = 256 // 16 MiB of 64 KiB Wasm pages
+    DefaultMaxValueStack    = 64 * 1024
+    DefaultMaxHostCallDepth = 16
+    DefaultExecDeadlineMs   = 2_000
+    MaxMemoryPages          = 65_536
+    MaxValueStack           = 1 << 20
+    MaxHostCallDepth        = 64
+    MaxExecDeadlineMs       = 30_000
+
+    // ExecUnitsPerMs is charged for each millisecond an execution ran, so
+    // its deadline also bounds what it costs
+    ExecUnitsPerMs = 10
+
+    // MaxHostCryptoUnits bounds the units one execution may spend in the
+    // crypto host functions
+    MaxHostCryptoUnits = 10_000
+
+    // MaxEventTip bounds the tip, in compute units, of one event
+    MaxEventTip = 1_000_000
+
+    // EventMaxSkips is how many events of higher classes may be taken
+    // before a waiting event of a lower class, so none is starved
+    EventMaxSkips = 6
+
+    // Bounds on the scope of one session key grant
+    MaxSessionActionTypes = 32
+    MaxSessionRegions     = 16
+
+    // MaxCheckpointValidators bounds the governed checkpoint committee, so
+    // its encoding fits in one parameter value
+    MaxCheckpointValidators = 48
+
+    // MaxSagaSteps bounds the steps of one saga
+    MaxSagaSteps = 16
+)
+
+// Event priority classes. The tip of a SendEventAction selects its class,
+// and TEEs take queued events from higher classes first.
+const (
+    EventPriorityBase uint8 = iota
+    EventPriorityHigh
+    EventPriorityUrgent
+    NumEventPriorities
 )
 
+// EventPriorityTips is the least tip of each class
+var EventPriorityTips = [NumEventPriorities]uint64{0, 100, 1_000}
+
 var ID ids.ID
 
 func init() {
@@ -36,3 +207,80 @@ var Version = &version.Semantic{
     Minor: 0,
     Patch: 1,
 }
+
+// Action wire format versions. Decoders accept up to LatestActionVersion;
+// a version is only accepted on chain from its activation height.
+const (
+    ActionVersion1      uint8 = 1
+    // TEEExecAction state updates may reference stored blobs by hash
+    ActionVersion2      uint8 = 2
+    // TEEExecAction carries regional state roots and a state witness
+    ActionVersion3      uint8 = 3
+    // TEEExecAction may carry the peer enclave's execution of its input
+    ActionVersion4      uint8 = 4
+    // SendEventAction may name a callback; TEEExecAction the event it completes
+    ActionVersion5      uint8 = 5
+    // TEEExecResult carries object reads and a cross-object call trace
+    ActionVersion6      uint8 = 6
+    // SendEventAction may carry its sender's per-object sequence nonce
+    ActionVersion7      uint8 = 7
+    // SendEventAction may carry a tip buying a higher priority class
+    ActionVersion8      uint8 = 8
+    // SendEventAction parameters may be sealed to the region's enclaves
+    ActionVersion9      uint8 = 9
+    // TEEExecAction pairs of BLS-capable enclaves may carry one aggregated
+    // signature, flagged in the attestation header
+    ActionVersion10     uint8 = 10
+    // SendEventAction may target an object by registered name
+    ActionVersion11     uint8 = 11
+    // CreateObjectAction may carry discovery metadata
+    ActionVersion12     uint8 = 12
+    // TEEExecResult reports the resources the execution used
+    ActionVersion13     uint8 = 13
+    // TEEExecResult carries contract logs
+    ActionVersion14     uint8 = 14
+    // SendEventAction may carry a client idempotency key
+    ActionVersion15     uint8 = 15
+    // TEEExecResult may report that the contract failed
+    ActionVersion16     uint8 = 16
+    LatestActionVersion       = ActionVersion16
+)
+
+type VersionActivation struct {
+    Version uint8  `json:"version"`
+    Height  uint64 `json:"height"`
+}
+
+// ActionVersionSchedule lists activation heights in ascending order. It is
+// the schedule of chains whose genesis sets none.
+var ActionVersionSchedule = []VersionActivation{
+    {Version: ActionVersion1, Height: 0},
+    {Version: ActionVersion2, Height: 0},
+    {Version: ActionVersion3, Height: 0},
+    {Version: ActionVersion4, Height: 0},
+    {Version: ActionVersion5, Height: 0},
+    {Version: ActionVersion6, Height: 0},
+    {Version: ActionVersion7, Height: 0},
+    {Version: ActionVersion8, Height: 0},
+    {Version: ActionVersion9, Height: 0},
+    {Version: ActionVersion10, Height: 0},
+    {Version: ActionVersion11, Height: 0},
+    {Version: ActionVersion12, Height: 0},
+    {Version: ActionVersion13, Height: 0},
+    {Version: ActionVersion14, Height: 0},
+    {Version: ActionVersion15, Height: 0},
+    {Version: ActionVersion16, Height: 0},
+}
+
+// MaxActionVersionAt returns the newest action version [schedule] activates
+// by [height]
+func MaxActionVersionAt(schedule []VersionActivation, height uint64) uint8 {
+    var v uint8
+    for _, activation := range schedule {
+        if height < activation.Height {
+            break
+        }
+        v = activation.Version
+    }
+    return v
+}
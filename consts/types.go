@@ -16,6 +16,105 @@ const (
     SendEventResultID          uint8 = 10
     CreateRegionResultID       uint8 = 11
     UpdateRegionResultID       uint8 = 12
+    TEEExecID                  uint8 = 13
+    TEEExecResultID            uint8 = 14
+    ClaimRewardsID             uint8 = 15
+    ClaimRewardsResultID       uint8 = 16
+    ProposeID                  uint8 = 17
+    ProposeResultID            uint8 = 18
+    VoteID                     uint8 = 19
+    VoteResultID               uint8 = 20
+    ExecuteProposalID          uint8 = 21
+    ExecuteProposalResultID    uint8 = 22
+    AdminID                    uint8 = 23
+    AdminResultID              uint8 = 24
+    StartUploadID              uint8 = 25
+    StartUploadResultID        uint8 = 26
+    AppendChunkID              uint8 = 27
+    AppendChunkResultID        uint8 = 28
+    CommitObjectID             uint8 = 29
+    CommitObjectResultID       uint8 = 30
+    SettleRegionID             uint8 = 31
+    SettleRegionResultID       uint8 = 32
+    ChallengeSettlementID      uint8 = 33
+    ChallengeSettlementResultID uint8 = 34
+    FinalizeSettlementID       uint8 = 35
+    FinalizeSettlementResultID uint8 = 36
+    SetNitroPolicyID           uint8 = 37
+    SetNitroPolicyResultID     uint8 = 38
+    RegisterNitroEnclaveID     uint8 = 39
+    RegisterNitroEnclaveResultID uint8 = 40
+    RegisterCCAEnclaveID       uint8 = 41
+    RegisterCCAEnclaveResultID uint8 = 42
+    SetPlatformPolicyID        uint8 = 43
+    SetPlatformPolicyResultID  uint8 = 44
+    PublishCollateralID        uint8 = 45
+    PublishCollateralResultID  uint8 = 46
+    ReattestEnclaveID          uint8 = 47
+    ReattestEnclaveResultID    uint8 = 48
+    QueueRequestID             uint8 = 49
+    QueueRequestResultID       uint8 = 50
+    ApproveID                  uint8 = 51
+    ApproveResultID            uint8 = 52
+    TransferFromID             uint8 = 53
+    TransferFromResultID       uint8 = 54
+    SetSponsorPolicyID         uint8 = 55
+    SetSponsorPolicyResultID   uint8 = 56
+    CreateAssetID              uint8 = 57
+    CreateAssetResultID        uint8 = 58
+    MintAssetID                uint8 = 59
+    MintAssetResultID          uint8 = 60
+    TransferAssetID            uint8 = 61
+    TransferAssetResultID      uint8 = 62
+    PublishRandomnessID        uint8 = 63
+    PublishRandomnessResultID  uint8 = 64
+    RegisterFeedID             uint8 = 65
+    RegisterFeedResultID       uint8 = 66
+    PublishFeedID              uint8 = 67
+    PublishFeedResultID        uint8 = 68
+    SealStorageID              uint8 = 69
+    SealStorageResultID        uint8 = 70
+    ResealStorageID            uint8 = 71
+    ResealStorageResultID      uint8 = 72
+    PublishAppKeyID            uint8 = 73
+    PublishAppKeyResultID      uint8 = 74
+    RotateAppKeyID             uint8 = 75
+    RotateAppKeyResultID       uint8 = 76
+    RevokeAppKeyID             uint8 = 77
+    RevokeAppKeyResultID       uint8 = 78
+    CreateRegionFromTemplateID       uint8 = 79
+    CreateRegionFromTemplateResultID uint8 = 80
+    RegisterNameID                   uint8 = 81
+    RegisterNameResultID             uint8 = 82
+    TransferNameID                   uint8 = 83
+    TransferNameResultID             uint8 = 84
+    SetExecLimitsID                  uint8 = 85
+    SetExecLimitsResultID            uint8 = 86
+    FreezeAndExportRegionID          uint8 = 87
+    FreezeAndExportRegionResultID    uint8 = 88
+    ImportRegionID                   uint8 = 89
+    ImportRegionResultID             uint8 = 90
+    AuthorizeSessionID               uint8 = 91
+    AuthorizeSessionResultID         uint8 = 92
+    RevokeSessionID                  uint8 = 93
+    RevokeSessionResultID            uint8 = 94
+    SubmitCheckpointID               uint8 = 95
+    SubmitCheckpointResultID         uint8 = 96
+    PruneEnclaveID                   uint8 = 97
+    PruneEnclaveResultID             uint8 = 98
+    PipelineID                       uint8 = 99
+    PipelineResultID                 uint8 = 100
+    PreemptEventID                   uint8 = 101
+    PreemptEventResultID             uint8 = 102
+    StartSagaID                      uint8 = 103
+    StartSagaResultID                uint8 = 104
+    WithdrawVoteID                   uint8 = 105
+    WithdrawVoteResultID             uint8 = 106
+)
+
+// Auth type IDs, after those of hypersdk's auth package
+const (
+    SessionAuthID uint8 = 3
 )
 
 var (
@@ -44,7 +143,194 @@ type AttestationType uint8
 const (
     AttestationSGX AttestationType = iota
     AttestationSEV
+    AttestationNitro
+    AttestationCCA
 )
 
 // Maximum allowed drift for Roughtime stamps
 const MaxTimeDrift = 5 * 60 // 5 minutes in seconds
+
+// Default distance, in seconds, beyond which a Roughtime stamp is discarded
+// as an outlier from the median of an attestation's stamps
+const StampRadius = 10
+
+// Blocks after a change of the governed Roughtime server set during which
+// stamps of the set it replaced are still accepted
+const RoughtimeGraceBlocks = 1_000
+
+// ParamID identifies a VM parameter that governance can change
+type ParamID uint8
+
+const (
+    ParamFeeSchedule ParamID = iota
+    ParamTimeDrift
+    ParamMaxBatchSize
+    ParamRoughtimeServers
+    ParamSettlementWindow
+    ParamAttestationValidity
+    ParamStampQuorum
+    ParamStampRadius
+    ParamVerificationBudget
+    ParamRegionTemplates
+    ParamRateLimits
+    ParamCheckpointCommittee
+    ParamEnclaveRetention
+    numParams
+)
+
+func (p ParamID) Valid() bool {
+    return p < numParams
+}
+
+// Governance timing and thresholds, in blocks and token units
+const (
+    VotingPeriod      = 1_000
+    MinActivationDelay = 100
+    MinProposalWeight uint64 = 1_000_000_000 // 1 RED
+    QuorumWeight      uint64 = 100_000_000_000
+)
+
+// ErrorCode identifies why an action failed so clients do not have to
+// parse error strings
+type ErrorCode uint16
+
+const (
+    ErrCodeNone ErrorCode = iota
+    ErrCodeUnknown
+    ErrCodeObjectExists
+    ErrCodeObjectNotFound
+    ErrCodeInvalidID
+    ErrCodeCodeTooLarge
+    ErrCodeStorageTooLarge
+    ErrCodeInvalidFunction
+    ErrCodeRegionExists
+    ErrCodeRegionNotFound
+    ErrCodeInvalidTEE
+    ErrCodeTooManyTEEs
+    ErrCodeTooFewTEEs
+    ErrCodeDuplicateTEE
+    ErrCodeTEENotInRegion
+    ErrCodeInvalidSignature
+    ErrCodeInvalidTimestamp
+    ErrCodeStaleTimestamp
+    ErrCodeInvalidEnclave
+    ErrCodeChecksumMismatch
+    ErrCodeInsufficientBalance
+    ErrCodeKVTooLarge
+    ErrCodeUploadNotFound
+    ErrCodeUploadExpired
+    ErrCodeInvalidCode
+    ErrCodeSettlementNotFound
+    ErrCodeSettlementDisputed
+    ErrCodeNitroPolicyMismatch
+    ErrCodePlatformPolicy
+    ErrCodeCollateral
+    ErrCodeEnclaveExpired
+    ErrCodeRequest
+    ErrCodeDivergence
+    ErrCodeCallback
+    ErrCodeObjectAccess
+    ErrCodeInvalidParams
+    ErrCodeAllowance
+    ErrCodeSponsor
+    ErrCodeAsset
+    ErrCodeEventNonce
+    ErrCodeBeacon
+    ErrCodeFeed
+    ErrCodeSealedStorage
+    ErrCodeKeyring
+    ErrCodeRegionTemplate
+    ErrCodeName
+    ErrCodeInterface
+    ErrCodeOutOfResources
+    ErrCodeDuplicateEvent
+    ErrCodeRegionFrozen
+    ErrCodeRegionImport
+    ErrCodeRateLimited
+    ErrCodeSession
+    ErrCodeCheckpoint
+    ErrCodeEnclaveRetention
+    ErrCodeTEEBusy
+    ErrCodeEventPreempt
+    ErrCodeSaga
+    ErrCodeExecFailed
+)
+
+var errorCodeNames = map[ErrorCode]string{
+    ErrCodeNone:                "none",
+    ErrCodeUnknown:             "unknown",
+    ErrCodeObjectExists:        "object_exists",
+    ErrCodeObjectNotFound:      "object_not_found",
+    ErrCodeInvalidID:           "invalid_id",
+    ErrCodeCodeTooLarge:        "code_too_large",
+    ErrCodeStorageTooLarge:     "storage_too_large",
+    ErrCodeInvalidFunction:     "invalid_function",
+    ErrCodeRegionExists:        "region_exists",
+    ErrCodeRegionNotFound:      "region_not_found",
+    ErrCodeInvalidTEE:          "invalid_tee",
+    ErrCodeTooManyTEEs:         "too_many_tees",
+    ErrCodeTooFewTEEs:          "too_few_tees",
+    ErrCodeDuplicateTEE:        "duplicate_tee",
+    ErrCodeTEENotInRegion:      "tee_not_in_region",
+    ErrCodeInvalidSignature:    "invalid_signature",
+    ErrCodeInvalidTimestamp:    "invalid_timestamp",
+    ErrCodeStaleTimestamp:      "stale_timestamp",
+    ErrCodeInvalidEnclave:      "invalid_enclave",
+    ErrCodeChecksumMismatch:    "checksum_mismatch",
+    ErrCodeInsufficientBalance: "insufficient_balance",
+    ErrCodeKVTooLarge:          "kv_too_large",
+    ErrCodeUploadNotFound:      "upload_not_found",
+    ErrCodeUploadExpired:       "upload_expired",
+    ErrCodeInvalidCode:         "invalid_code",
+    ErrCodeSettlementNotFound:  "settlement_not_found",
+    ErrCodeSettlementDisputed:  "settlement_disputed",
+    ErrCodeNitroPolicyMismatch: "nitro_policy_mismatch",
+    ErrCodePlatformPolicy:      "platform_policy",
+    ErrCodeCollateral:          "collateral",
+    ErrCodeEnclaveExpired:      "enclave_expired",
+    ErrCodeRequest:             "request",
+    ErrCodeDivergence:          "divergence",
+    ErrCodeCallback:            "callback",
+    ErrCodeObjectAccess:        "object_access",
+    ErrCodeInvalidParams:       "invalid_params",
+    ErrCodeAllowance:           "allowance",
+    ErrCodeSponsor:             "sponsor",
+    ErrCodeAsset:               "asset",
+    ErrCodeEventNonce:          "event_nonce",
+    ErrCodeBeacon:              "beacon",
+    ErrCodeFeed:                "feed",
+    ErrCodeSealedStorage:       "sealed_storage",
+    ErrCodeKeyring:             "keyring",
+    ErrCodeRegionTemplate:      "region_template",
+    ErrCodeName:                "name",
+    ErrCodeInterface:           "interface",
+    ErrCodeOutOfResources:      "out_of_resources",
+    ErrCodeDuplicateEvent:      "duplicate_event",
+    ErrCodeRegionFrozen:        "region_frozen",
+    ErrCodeRegionImport:        "region_import",
+    ErrCodeRateLimited:         "rate_limited",
+    ErrCodeSession:             "session",
+    ErrCodeCheckpoint:          "checkpoint",
+    ErrCodeEnclaveRetention:    "enclave_retention",
+    ErrCodeTEEBusy:             "tee_busy",
+    ErrCodeEventPreempt:        "event_preempt",
+    ErrCodeSaga:                "saga",
+    ErrCodeExecFailed:          "exec_failed",
+}
+
+func (c ErrorCode) String() string {
+    if name, ok := errorCodeNames[c]; ok {
+        return name
+    }
+    return errorCodeNames[ErrCodeUnknown]
+}
+
+// ParseErrorCode returns the code named [name] by String.
+func ParseErrorCode(name string) (ErrorCode, bool) {
+    for code, n := range errorCodeNames {
+        if n == name {
+            return code, true
+        }
+    }
+    return 0, false
+}
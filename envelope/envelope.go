@@ -0,0 +1,253 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package envelope seals payloads to the encryption keys enclaves publish
+// when they register, so that only code running inside a region's enclaves
+// can read them.
+//
+// An envelope is hybrid encrypted: the payload is sealed once under a fresh
+// AES-256-GCM data key, and the data key is wrapped for each recipient with
+// a key agreed between an ephemeral X25519 key and the recipient's. The
+// layout is
+//
+//	[version][ephemeral key][recipient count]
+//	([key ID][wrapped data key])...
+//	[sealed payload]
+//
+// Its format can be checked without any key, which is all the chain does.
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	Version = 1
+	// KeySize is the size of an X25519 public key
+	KeySize = 32
+	// KeyIDSize is the size of the ID a recipient's wrapped key is filed
+	// under
+	KeyIDSize     = 8
+	MaxRecipients = 16
+
+	dataKeySize   = 32
+	tagSize       = 16
+	wrappedSize   = dataKeySize + tagSize
+	recipientSize = KeyIDSize + wrappedSize
+	headerSize    = 1 + KeySize + 1
+)
+
+var (
+	ErrMalformed         = errors.New("malformed envelope")
+	ErrVersion           = errors.New("unsupported envelope version")
+	ErrNoRecipients      = errors.New("envelope has no recipients")
+	ErrTooManyRecipients = errors.New("envelope has too many recipients")
+	ErrInvalidKey        = errors.New("invalid encryption key")
+	ErrNotRecipient      = errors.New("not a recipient of envelope")
+)
+
+// wrapDomain separates key wrapping keys from other uses of the shared
+// secret.
+const wrapDomain = "shuttlevm/envelope"
+
+// KeyID identifies the recipient with encryption key [pub] within an
+// envelope.
+func KeyID(pub []byte) []byte {
+	h := sha256.Sum256(pub)
+	return h[:KeyIDSize]
+}
+
+// Overhead is how much larger than its payload an envelope for
+// [recipients] keys is.
+func Overhead(recipients int) int {
+	return headerSize + recipients*recipientSize + tagSize
+}
+
+// GenerateKey returns a new X25519 encryption key.
+func GenerateKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// ParseKey checks [pub] is an X25519 public key.
+func ParseKey(pub []byte) (*ecdh.PublicKey, error) {
+	key, err := ecdh.X25519().NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	return key, nil
+}
+
+// Seal encrypts [payload] so that any holder of a private key matching one
+// of [recipients] can open it. [aad] is authenticated but not encrypted;
+// Open must be given the same.
+func Seal(recipients [][]byte, payload, aad []byte) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	if len(recipients) > MaxRecipients {
+		return nil, ErrTooManyRecipients
+	}
+	ephemeral, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, Overhead(len(recipients))+len(payload))
+	b = append(b, Version)
+	b = append(b, ephemeral.PublicKey().Bytes()...)
+	b = append(b, byte(len(recipients)))
+	for _, recipient := range recipients {
+		pub, err := ParseKey(recipient)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := ephemeral.ECDH(pub)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		wrap, err := newAEAD(wrapKey(shared, ephemeral.PublicKey().Bytes(), recipient))
+		if err != nil {
+			return nil, err
+		}
+		keyID := KeyID(recipient)
+		b = append(b, keyID...)
+		b = wrap.Seal(b, zeroNonce(wrap), dataKey, keyID)
+	}
+	sealer, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return sealer.Seal(b, zeroNonce(sealer), payload, aad), nil
+}
+
+// Check reports whether [b] is well formed: a supported version, between
+// one and [MaxRecipients] recipients, and room for the payload tag. It
+// cannot tell whether the payload decrypts.
+func Check(b []byte) error {
+	_, err := parse(b)
+	return err
+}
+
+// Recipients returns the key IDs [b] is sealed to, in order.
+func Recipients(b []byte) ([][]byte, error) {
+	env, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([][]byte, len(env.recipients))
+	for i, r := range env.recipients {
+		ids[i] = r[:KeyIDSize]
+	}
+	return ids, nil
+}
+
+// Open decrypts the envelope [b] with [priv], which must match one of its
+// recipients, and [aad] it was sealed with.
+func Open(priv *ecdh.PrivateKey, b, aad []byte) ([]byte, error) {
+	env, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ParseKey(env.ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	pub := priv.PublicKey().Bytes()
+	wrap, err := newAEAD(wrapKey(shared, env.ephemeral, pub))
+	if err != nil {
+		return nil, err
+	}
+	keyID := KeyID(pub)
+	for _, r := range env.recipients {
+		// Key IDs may collide; only the right one unwraps
+		if !bytes.Equal(r[:KeyIDSize], keyID) {
+			continue
+		}
+		dataKey, err := wrap.Open(nil, zeroNonce(wrap), r[KeyIDSize:], keyID)
+		if err != nil {
+			continue
+		}
+		opener, err := newAEAD(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		return opener.Open(nil, zeroNonce(opener), env.sealed, aad)
+	}
+	return nil, ErrNotRecipient
+}
+
+type envelope struct {
+	ephemeral  []byte
+	recipients [][]byte
+	sealed     []byte
+}
+
+func parse(b []byte) (*envelope, error) {
+	if len(b) < headerSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMalformed, len(b))
+	}
+	if b[0] != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, b[0])
+	}
+	count := int(b[headerSize-1])
+	if count == 0 {
+		return nil, ErrNoRecipients
+	}
+	if count > MaxRecipients {
+		return nil, ErrTooManyRecipients
+	}
+	if len(b) < Overhead(count) {
+		return nil, fmt.Errorf("%w: %d bytes for %d recipients", ErrMalformed, len(b), count)
+	}
+	env := &envelope{
+		ephemeral:  b[1 : 1+KeySize],
+		recipients: make([][]byte, count),
+	}
+	offset := headerSize
+	for i := range env.recipients {
+		env.recipients[i] = b[offset : offset+recipientSize]
+		offset += recipientSize
+	}
+	env.sealed = b[offset:]
+	return env, nil
+}
+
+// wrapKey derives the key that wraps the data key for [recipient].
+func wrapKey(shared, ephemeral, recipient []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(wrapDomain))
+	h.Write(shared)
+	h.Write(ephemeral)
+	h.Write(recipient)
+	return h.Sum(nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// zeroNonce is safe because every key an envelope uses encrypts exactly one
+// message: the data key is fresh, and wrapping keys are derived from a fresh
+// ephemeral key.
+func zeroNonce(aead cipher.AEAD) []byte {
+	return make([]byte, aead.NonceSize())
+}
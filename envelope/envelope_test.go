@@ -0,0 +1,77 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package envelope
+
+import (
+	"crypto/ecdh"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	require := require.New(t)
+
+	first, err := GenerateKey()
+	require.NoError(err)
+	second, err := GenerateKey()
+	require.NoError(err)
+	outsider, err := GenerateKey()
+	require.NoError(err)
+
+	payload := []byte("transfer 100 to alice")
+	aad := []byte("wallet/transfer")
+	b, err := Seal([][]byte{first.PublicKey().Bytes(), second.PublicKey().Bytes()}, payload, aad)
+	require.NoError(err)
+	require.Len(b, Overhead(2)+len(payload))
+	require.NoError(Check(b))
+
+	recipients, err := Recipients(b)
+	require.NoError(err)
+	require.Equal([][]byte{KeyID(first.PublicKey().Bytes()), KeyID(second.PublicKey().Bytes())}, recipients)
+
+	for _, priv := range []*ecdh.PrivateKey{first, second} {
+		opened, err := Open(priv, b, aad)
+		require.NoError(err)
+		require.Equal(payload, opened)
+	}
+	_, err = Open(outsider, b, aad)
+	require.ErrorIs(err, ErrNotRecipient)
+
+	// The payload is bound to its additional data
+	_, err = Open(first, b, []byte("wallet/withdraw"))
+	require.Error(err)
+
+	b[len(b)-1] ^= 1
+	_, err = Open(first, b, aad)
+	require.Error(err)
+}
+
+func TestCheck(t *testing.T) {
+	require := require.New(t)
+
+	key, err := GenerateKey()
+	require.NoError(err)
+	b, err := Seal([][]byte{key.PublicKey().Bytes()}, nil, nil)
+	require.NoError(err)
+	require.NoError(Check(b))
+
+	require.ErrorIs(Check(nil), ErrMalformed)
+	require.ErrorIs(Check(b[:len(b)-1]), ErrMalformed)
+
+	bad := append([]byte{}, b...)
+	bad[0] = Version + 1
+	require.ErrorIs(Check(bad), ErrVersion)
+
+	bad = append([]byte{}, b...)
+	bad[headerSize-1] = 0
+	require.ErrorIs(Check(bad), ErrNoRecipients)
+	bad[headerSize-1] = MaxRecipients + 1
+	require.ErrorIs(Check(bad), ErrTooManyRecipients)
+
+	_, err = Seal(nil, []byte{1}, nil)
+	require.ErrorIs(err, ErrNoRecipients)
+	_, err = Seal([][]byte{{1, 2, 3}}, []byte{1}, nil)
+	require.ErrorIs(err, ErrInvalidKey)
+}
@@ -0,0 +1,212 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package manifest defines the container of custom format code: named
+// sections, each with an integrity hash, behind a manifest listing them.
+// The chain validates containers with it when objects are created and
+// enclave workers use it to load them.
+//
+// A container follows the code header and is laid out as
+//
+//	[version][section count]
+//	([name length][name][data length][SHA-256 of data])...
+//	[data]...
+//
+// with the data of the sections in manifest order. Sections other than the
+// ones named here may be carried; they are hashed but not interpreted.
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	Version     = 1
+	MaxSections = 16
+	MaxNameLen  = 32
+
+	// SectionCode holds the object's executable code and is required
+	SectionCode = "code"
+	// SectionData holds the initial contents of the object's memory
+	SectionData = "data"
+	// SectionExports lists the functions the code exports
+	SectionExports = "exports"
+	// SectionHostVersion is the minimum version of the host interface the
+	// code requires, a big-endian uint32
+	SectionHostVersion = "host_version"
+
+	hashLen = sha256.Size
+)
+
+var (
+	ErrMalformed        = errors.New("malformed manifest")
+	ErrVersion          = errors.New("unsupported manifest version")
+	ErrDuplicateSection = errors.New("duplicate manifest section")
+	ErrMissingCode      = errors.New("manifest has no code section")
+	ErrIntegrity        = errors.New("section hash mismatch")
+)
+
+type Section struct {
+	Name string
+	Data []byte
+}
+
+// Manifest is a parsed container. Its sections share memory with the
+// parsed bytes.
+type Manifest struct {
+	Sections []Section
+}
+
+// Get returns the data of section [name], or nil if there is none.
+func (m *Manifest) Get(name string) []byte {
+	for _, s := range m.Sections {
+		if s.Name == name {
+			return s.Data
+		}
+	}
+	return nil
+}
+
+// Exports returns the function names listed in the exports section, a
+// big-endian uint16 count followed by length-prefixed names.
+func (m *Manifest) Exports() ([]string, error) {
+	b := m.Get(SectionExports)
+	if b == nil {
+		return nil, nil
+	}
+	if len(b) < 2 {
+		return nil, fmt.Errorf("%w: exports", ErrMalformed)
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	exports := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if len(b) < 1 || len(b) < 1+int(b[0]) {
+			return nil, fmt.Errorf("%w: exports", ErrMalformed)
+		}
+		nameLen := int(b[0])
+		exports = append(exports, string(b[1:1+nameLen]))
+		b = b[1+nameLen:]
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("%w: exports", ErrMalformed)
+	}
+	return exports, nil
+}
+
+// HostVersion returns the host interface version the code requires, zero
+// if it has no host version section.
+func (m *Manifest) HostVersion() (uint32, error) {
+	b := m.Get(SectionHostVersion)
+	if b == nil {
+		return 0, nil
+	}
+	if len(b) != 4 {
+		return 0, fmt.Errorf("%w: host version", ErrMalformed)
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// Parse decodes the container [b] and checks the hash of every section.
+func Parse(b []byte) (*Manifest, error) {
+	if len(b) < 2 {
+		return nil, ErrMalformed
+	}
+	if b[0] != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, b[0])
+	}
+	count := int(b[1])
+	if count > MaxSections {
+		return nil, fmt.Errorf("%w: %d sections", ErrMalformed, count)
+	}
+	b = b[2:]
+
+	type entry struct {
+		name string
+		size uint32
+		hash []byte
+	}
+	entries := make([]entry, count)
+	for i := range entries {
+		if len(b) < 1 || len(b) < 1+int(b[0])+4+hashLen {
+			return nil, ErrMalformed
+		}
+		nameLen := int(b[0])
+		if nameLen == 0 || nameLen > MaxNameLen {
+			return nil, fmt.Errorf("%w: name length %d", ErrMalformed, nameLen)
+		}
+		entries[i] = entry{
+			name: string(b[1 : 1+nameLen]),
+			size: binary.BigEndian.Uint32(b[1+nameLen:]),
+			hash: b[1+nameLen+4 : 1+nameLen+4+hashLen],
+		}
+		b = b[1+nameLen+4+hashLen:]
+	}
+
+	m := &Manifest{Sections: make([]Section, count)}
+	for i, e := range entries {
+		for _, prev := range entries[:i] {
+			if prev.name == e.name {
+				return nil, fmt.Errorf("%w: %s", ErrDuplicateSection, e.name)
+			}
+		}
+		if uint64(e.size) > uint64(len(b)) {
+			return nil, fmt.Errorf("%w: section %s truncated", ErrMalformed, e.name)
+		}
+		data := b[:e.size]
+		b = b[e.size:]
+		if digest := sha256.Sum256(data); !bytes.Equal(digest[:], e.hash) {
+			return nil, fmt.Errorf("%w: %s", ErrIntegrity, e.name)
+		}
+		m.Sections[i] = Section{Name: e.name, Data: data}
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMalformed, len(b))
+	}
+	if len(m.Get(SectionCode)) == 0 {
+		return nil, ErrMissingCode
+	}
+	return m, nil
+}
+
+// Build encodes [sections] as a container, hashing each.
+func Build(sections ...Section) ([]byte, error) {
+	if len(sections) > MaxSections {
+		return nil, fmt.Errorf("%w: %d sections", ErrMalformed, len(sections))
+	}
+	b := []byte{Version, byte(len(sections))}
+	for _, s := range sections {
+		if len(s.Name) == 0 || len(s.Name) > MaxNameLen {
+			return nil, fmt.Errorf("%w: name length %d", ErrMalformed, len(s.Name))
+		}
+		digest := sha256.Sum256(s.Data)
+		b = append(b, byte(len(s.Name)))
+		b = append(b, s.Name...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(s.Data)))
+		b = append(b, digest[:]...)
+	}
+	for _, s := range sections {
+		b = append(b, s.Data...)
+	}
+	return b, nil
+}
+
+// EncodeExports encodes [exports], names of at most 255 bytes, as the data
+// of an exports section.
+func EncodeExports(exports []string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(exports)))
+	for _, e := range exports {
+		b = append(b, byte(len(e)))
+		b = append(b, e...)
+	}
+	return b
+}
+
+// EncodeHostVersion encodes [v] as the data of a host version section.
+func EncodeHostVersion(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
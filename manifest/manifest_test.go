@@ -0,0 +1,77 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildParse(t *testing.T) {
+	require := require.New(t)
+
+	b, err := Build(
+		Section{Name: SectionCode, Data: []byte{0xde, 0xad, 0xbe, 0xef}},
+		Section{Name: SectionData, Data: []byte("initial memory")},
+		Section{Name: SectionExports, Data: EncodeExports([]string{"transfer", "balance_of"})},
+		Section{Name: SectionHostVersion, Data: EncodeHostVersion(1)},
+		Section{Name: "debug", Data: nil},
+	)
+	require.NoError(err)
+
+	m, err := Parse(b)
+	require.NoError(err)
+	require.Len(m.Sections, 5)
+	require.Equal([]byte{0xde, 0xad, 0xbe, 0xef}, m.Get(SectionCode))
+	require.Equal([]byte("initial memory"), m.Get(SectionData))
+	require.Nil(m.Get("symbols"))
+	exports, err := m.Exports()
+	require.NoError(err)
+	require.Equal([]string{"transfer", "balance_of"}, exports)
+	hostVersion, err := m.HostVersion()
+	require.NoError(err)
+	require.Equal(uint32(1), hostVersion)
+}
+
+func TestParseRejects(t *testing.T) {
+	require := require.New(t)
+
+	code := Section{Name: SectionCode, Data: []byte{1, 2, 3}}
+	b, err := Build(code)
+	require.NoError(err)
+
+	// Any change to a section's data breaks its hash
+	tampered := append([]byte{}, b...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Parse(tampered)
+	require.ErrorIs(err, ErrIntegrity)
+
+	_, err = Parse(b[:len(b)-1])
+	require.ErrorIs(err, ErrMalformed)
+	_, err = Parse(append(append([]byte{}, b...), 0))
+	require.ErrorIs(err, ErrMalformed)
+
+	versioned := append([]byte{}, b...)
+	versioned[0] = Version + 1
+	_, err = Parse(versioned)
+	require.ErrorIs(err, ErrVersion)
+
+	b, err = Build(code, code)
+	require.NoError(err)
+	_, err = Parse(b)
+	require.ErrorIs(err, ErrDuplicateSection)
+
+	b, err = Build(Section{Name: SectionData, Data: []byte{1}})
+	require.NoError(err)
+	_, err = Parse(b)
+	require.ErrorIs(err, ErrMissingCode)
+
+	b, err = Build(code, Section{Name: SectionExports, Data: []byte{0, 2, 1, 'a'}})
+	require.NoError(err)
+	m, err := Parse(b)
+	require.NoError(err)
+	_, err = m.Exports()
+	require.ErrorIs(err, ErrMalformed)
+}
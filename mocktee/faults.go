@@ -0,0 +1,155 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package mocktee
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rhombus-tech/vm/attestation"
+)
+
+// Fault kinds [ParseFaults] accepts.
+const (
+	FaultDrop    = "drop"
+	FaultDelay   = "delay"
+	FaultCorrupt = "corrupt"
+	FaultSkew    = "skew"
+)
+
+var ErrInvalidFault = errors.New("invalid fault")
+
+// Faults are the failures mock enclaves inject into what they attest, so
+// operators can rehearse how the chain's verifier and failover logic
+// respond to a misbehaving worker. Each rate is the share, from 0 to 1,
+// of attestations the fault strikes. The zero value injects none.
+type Faults struct {
+	// Drop withholds results, as a worker failing after it executed
+	Drop float64 `json:"drop"`
+	// Delay holds results for DelayFor before they are submitted
+	Delay    float64       `json:"delay"`
+	DelayFor time.Duration `json:"delayFor"`
+	// Corrupt flips a bit of the enclave signature
+	Corrupt float64 `json:"corrupt"`
+	// Skew moves the Roughtime stamps by SkewBy, which may be negative.
+	// The stamps are not signed by the enclave, so only the drift check
+	// catches it.
+	Skew   float64       `json:"skew"`
+	SkewBy time.Duration `json:"skewBy"`
+	// Seed seeds the draws, so a run's faults can be replayed
+	Seed int64 `json:"seed"`
+}
+
+func (f *Faults) Validate() error {
+	for _, rate := range []struct {
+		kind  string
+		value float64
+	}{{FaultDrop, f.Drop}, {FaultDelay, f.Delay}, {FaultCorrupt, f.Corrupt}, {FaultSkew, f.Skew}} {
+		if rate.value < 0 || rate.value > 1 {
+			return fmt.Errorf("%w: %s rate %v", ErrInvalidFault, rate.kind, rate.value)
+		}
+	}
+	if f.DelayFor < 0 {
+		return fmt.Errorf("%w: negative delay", ErrInvalidFault)
+	}
+	return nil
+}
+
+// ParseFaults parses "kind=rate[:duration],..." such as
+// "drop=0.1,delay=0.2:2s,corrupt=0.05,skew=0.1:-90s". Delay and skew take
+// the duration they hold results or move stamps by.
+func ParseFaults(s string) (*Faults, error) {
+	f := &Faults{}
+	for _, part := range strings.Split(s, ",") {
+		kind, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFault, part)
+		}
+		value, duration, timed := strings.Cut(value, ":")
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		var d time.Duration
+		if timed {
+			if d, err = time.ParseDuration(duration); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case kind == FaultDrop && !timed:
+			f.Drop = rate
+		case kind == FaultDelay && timed:
+			f.Delay, f.DelayFor = rate, d
+		case kind == FaultCorrupt && !timed:
+			f.Corrupt = rate
+		case kind == FaultSkew && timed:
+			f.Skew, f.SkewBy = rate, d
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFault, part)
+		}
+	}
+	return f, f.Validate()
+}
+
+// Injector draws the faults of [Faults] for each attestation. A nil
+// injector injects none. It is safe for concurrent use.
+type Injector struct {
+	faults Faults
+
+	l   sync.Mutex
+	rng *rand.Rand
+}
+
+func NewInjector(f Faults) (*Injector, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &Injector{
+		faults: f,
+		rng:    rand.New(rand.NewSource(f.Seed)), //nolint:gosec
+	}, nil
+}
+
+// Inject corrupts the signature or skews the stamps of [a] as drawn, and
+// returns what to do with the action carrying it: whether to drop it, and
+// how long to hold it before submission.
+func (i *Injector) Inject(a *attestation.Attestation) (drop bool, delay time.Duration) {
+	if i == nil {
+		return false, 0
+	}
+	i.l.Lock()
+	defer i.l.Unlock()
+
+	if i.strikes(i.faults.Corrupt) && len(a.Signature) > 0 {
+		// Copied so actions sharing the signature keep theirs
+		sig := append([]byte(nil), a.Signature...)
+		sig[0] ^= 0x01
+		a.Signature = sig
+	}
+	if i.strikes(i.faults.Skew) {
+		skew := int64(i.faults.SkewBy / time.Second)
+		stamps := make([]attestation.Stamp, len(a.Stamps))
+		for j, stamp := range a.Stamps {
+			stamp.Time = uint64(max(int64(stamp.Time)+skew, 0))
+			stamps[j] = stamp
+		}
+		a.Stamps = stamps
+	}
+	if i.strikes(i.faults.Delay) {
+		delay = i.faults.DelayFor
+	}
+	return i.strikes(i.faults.Drop), delay
+}
+
+// strikes draws whether a fault of [rate] strikes. Every fault draws, so
+// the faults of a seed do not depend on the rates of the others.
+func (i *Injector) strikes(rate float64) bool {
+	return i.rng.Float64() < rate
+}
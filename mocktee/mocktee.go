@@ -0,0 +1,360 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package mocktee generates enclave key pairs, signed SGX/SEV-shaped quotes
+// and Nitro attestation documents for tests and load generation. It is not
+// used by the VM.
+package mocktee
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/bls"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/envelope"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	EnclaveSGX = "SGX"
+	EnclaveSEV = "SEV"
+	// EnclaveNitro enclaves are registered from a [NitroIssuer] document and
+	// known by the hash of their key rather than their address
+	EnclaveNitro = string(attestation.Nitro)
+
+	// RoughtimeServers is how many stamps are attached to each attestation;
+	// TEEExecAction requires at least three.
+	RoughtimeServers = 3
+)
+
+// Enclave is a mock TEE. Its address doubles as the enclave ID, except for
+// Nitro enclaves.
+type Enclave struct {
+	Type        string
+	PrivateKey  ed25519.PrivateKey
+	Address     codec.Address
+	Measurement []byte
+	// EncryptionKey opens event parameters sealed to the enclave
+	EncryptionKey *ecdh.PrivateKey
+	// BLSKey, if set, is the key the enclave registers and signs with, so
+	// its signatures can be aggregated
+	BLSKey *bls.PrivateKey
+}
+
+// NewEnclave generates a key pair and random measurement for an enclave of
+// [enclaveType].
+func NewEnclave(enclaveType string) (*Enclave, error) {
+	if enclaveType != EnclaveSGX && enclaveType != EnclaveSEV && enclaveType != EnclaveNitro {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQuoteType, enclaveType)
+	}
+	priv, err := ed25519.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	measurement := make([]byte, sha256.Size)
+	if _, err := rand.Read(measurement); err != nil {
+		return nil, err
+	}
+	encryptionKey, err := envelope.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Enclave{
+		Type:          enclaveType,
+		PrivateKey:    priv,
+		Address:       auth.NewED25519Address(priv.PublicKey()),
+		Measurement:   measurement,
+		EncryptionKey: encryptionKey,
+	}, nil
+}
+
+// NewBLSEnclave is [NewEnclave] for a BLS-capable enclave.
+func NewBLSEnclave(enclaveType string) (*Enclave, error) {
+	e, err := NewEnclave(enclaveType)
+	if err != nil {
+		return nil, err
+	}
+	if e.BLSKey, err = bls.GeneratePrivateKey(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewPair generates one SGX and one SEV enclave, the pair a region needs.
+func NewPair() (*Enclave, *Enclave, error) {
+	sgx, err := NewEnclave(EnclaveSGX)
+	if err != nil {
+		return nil, nil, err
+	}
+	sev, err := NewEnclave(EnclaveSEV)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sgx, sev, nil
+}
+
+func (e *Enclave) ID() []byte {
+	if e.Type == EnclaveNitro {
+		return attestation.KeyEnclaveID(e.RegisteredKey())
+	}
+	return e.Address[:]
+}
+
+func (e *Enclave) PublicKey() ed25519.PublicKey {
+	return e.PrivateKey.PublicKey()
+}
+
+// RegisteredKey is the key the enclave registers to have its signatures
+// verified: its BLS public key if it has one, its ed25519 key otherwise.
+func (e *Enclave) RegisteredKey() []byte {
+	if e.BLSKey != nil {
+		return bls.PublicKeyToBytes(bls.PublicFromPrivateKey(e.BLSKey))
+	}
+	pub := e.PublicKey()
+	return pub[:]
+}
+
+// PublishedKey is the encryption key the enclave publishes for its region.
+func (e *Enclave) PublishedKey() storage.EncryptionKey {
+	return storage.EncryptionKey{
+		EnclaveID: e.ID(),
+		Key:       e.EncryptionKey.PublicKey().Bytes(),
+	}
+}
+
+// OpenParameters decrypts the sealed parameters of [event] as the enclave
+// would before executing it.
+func (e *Enclave) OpenParameters(event *actions.SendEventAction) ([]byte, error) {
+	return event.OpenParameters(e.EncryptionKey)
+}
+
+// Sign returns the enclave signature over [digest].
+func (e *Enclave) Sign(digest []byte) []byte {
+	if e.BLSKey != nil {
+		return bls.SignatureToBytes(bls.Sign(digest, e.BLSKey))
+	}
+	sig := ed25519.Sign(digest, e.PrivateKey)
+	return sig[:]
+}
+
+// Quote returns a signed quote whose report data is [digest].
+func (e *Enclave) Quote(digest []byte) ([]byte, error) {
+	return BuildQuote(e.Type, e.PrivateKey, e.Measurement, digest)
+}
+
+// Attest builds a TEEExecAction for [result] in [regionID], signed over the
+// result digest, with Roughtime stamps at [timestamp] (unix milliseconds).
+func (e *Enclave) Attest(regionID string, result actions.TEEExecResult, timestamp int64) (*actions.TEEExecAction, error) {
+	return e.AttestRequest(regionID, nil, nil, result, timestamp)
+}
+
+// AttestRequest is [Attest] for an execution serving the request queued for
+// [txData], authorized by the requester's [userSig].
+func (e *Enclave) AttestRequest(regionID string, txData, userSig []byte, result actions.TEEExecResult, timestamp int64) (*actions.TEEExecAction, error) {
+	return e.attest(regionID, txData, userSig, ids.Empty, result, timestamp)
+}
+
+// AttestEvent is [Attest] for an execution completing the event
+// [eventID], which delivers [result] to the event's callback.
+func (e *Enclave) AttestEvent(regionID string, eventID ids.ID, result actions.TEEExecResult, timestamp int64) (*actions.TEEExecAction, error) {
+	return e.attest(regionID, nil, nil, eventID, result, timestamp)
+}
+
+func (e *Enclave) attest(regionID string, txData, userSig []byte, eventID ids.ID, result actions.TEEExecResult, timestamp int64) (*actions.TEEExecAction, error) {
+	digest, err := result.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return &actions.TEEExecAction{
+		Version:    consts.LatestActionVersion,
+		RegionID:   regionID,
+		TxData:     txData,
+		UserSig:    userSig,
+		EventID:    eventID,
+		ExecResult: result,
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(e.Type),
+			EnclaveID:   e.ID(),
+			Signature:   e.Sign(actions.ExecDigest(digest, regionID, txData, eventID)),
+			Stamps:      stamps(timestamp),
+		},
+	}, nil
+}
+
+// stamps returns unsigned Roughtime stamps of [timestamp], in unix
+// milliseconds, from [RoughtimeServers] servers.
+func stamps(timestamp int64) []attestation.Stamp {
+	s := make([]attestation.Stamp, RoughtimeServers)
+	for i := range s {
+		s[i] = attestation.Stamp{
+			ServerID: fmt.Sprintf("roughtime-%d", i),
+			Time:     uint64(timestamp / 1000),
+		}
+	}
+	return s
+}
+
+// AttestPeer attaches the enclave's execution of the input of [action],
+// producing [result], as the peer execution a dual-execution region
+// requires.
+func (e *Enclave) AttestPeer(action *actions.TEEExecAction, result actions.TEEExecResult, timestamp int64) error {
+	peer, err := e.attest(action.RegionID, action.TxData, action.UserSig, action.EventID, result, timestamp)
+	if err != nil {
+		return err
+	}
+	action.Peer = &actions.PeerExecution{
+		ExecResult:  peer.ExecResult,
+		Attestation: peer.Attestation,
+	}
+	return nil
+}
+
+// AggregatePeer attaches the enclave's execution of the input of [action]
+// as its peer execution, with the signatures of both enclaves aggregated
+// into [action]'s attestation. Both enclaves must be BLS-capable and
+// produce the same result.
+func (e *Enclave) AggregatePeer(action *actions.TEEExecAction, timestamp int64) error {
+	if err := e.AttestPeer(action, action.ExecResult, timestamp); err != nil {
+		return err
+	}
+	aggregate, err := attestation.Aggregate(action.Attestation.Signature, action.Peer.Attestation.Signature)
+	if err != nil {
+		return err
+	}
+	action.Attestation.Signature = aggregate
+	action.Attestation.Flags |= attestation.FlagAggregated
+	action.Peer.Attestation.Signature = nil
+	return nil
+}
+
+// Settle builds a SettleRegionAction moving [regionID] from [prevRoot] to
+// [stateRoot] at [epoch], signed by the enclave.
+func (e *Enclave) Settle(regionID string, epoch uint64, prevRoot, stateRoot ids.ID) *actions.SettleRegionAction {
+	return &actions.SettleRegionAction{
+		RegionID:  regionID,
+		Epoch:     epoch,
+		PrevRoot:  prevRoot,
+		StateRoot: stateRoot,
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(e.Type),
+			EnclaveID:   e.ID(),
+			Signature:   e.Sign(actions.SettlementDigest(regionID, epoch, prevRoot, stateRoot)),
+		},
+	}
+}
+
+// ShareRandomness builds the enclave's share of the beacon of [regionID] at
+// [epoch], revealing [seed] and committing to [next] for the next epoch.
+func (e *Enclave) ShareRandomness(regionID string, epoch uint64, seed, next []byte) actions.RandomnessShare {
+	commitment := actions.BeaconCommitment(next)
+	return actions.RandomnessShare{
+		Seed: seed,
+		Next: commitment,
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(e.Type),
+			EnclaveID:   e.ID(),
+			Signature:   e.Sign(actions.BeaconShareDigest(regionID, epoch, seed, commitment)),
+		},
+	}
+}
+
+// PublishFeed builds a PublishFeedAction of [value] as [round] of [feedID],
+// served by [regionID], stamped at [timestamp].
+func (e *Enclave) PublishFeed(feedID ids.ID, regionID string, round, value uint64, timestamp int64) *actions.PublishFeedAction {
+	return &actions.PublishFeedAction{
+		FeedID:   feedID,
+		RegionID: regionID,
+		Round:    round,
+		Value:    value,
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(e.Type),
+			EnclaveID:   e.ID(),
+			Signature:   e.Sign(actions.FeedDigest(feedID, round, value)),
+			Stamps:      stamps(timestamp),
+		},
+	}
+}
+
+// SignKeyring builds the enclave's half of the pair signature applying
+// keyring change [op] to [version] of [name] in [regionID].
+func (e *Enclave) SignKeyring(op uint8, regionID, name string, version uint64, purpose uint8, publicKey []byte) attestation.Attestation {
+	return attestation.Attestation{
+		EnclaveType: attestation.EnclaveType(e.Type),
+		EnclaveID:   e.ID(),
+		Signature:   e.Sign(actions.KeyringDigest(op, regionID, name, version, purpose, publicKey)),
+	}
+}
+
+// SealStorage seals [plain] as the storage of [objectID] to [keys], the
+// published keys of enclaves of [regionID]. [sequence] is that of the
+// sealed storage it replaces, zero if there is none.
+func (e *Enclave) SealStorage(objectID, regionID string, sequence uint64, plain []byte, keys []storage.EncryptionKey) (*actions.SealStorageAction, error) {
+	blob, recipients, err := sealStorage(objectID, plain, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &actions.SealStorageAction{
+		ObjectID:   objectID,
+		RegionID:   regionID,
+		Blob:       blob,
+		Recipients: recipients,
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(e.Type),
+			EnclaveID:   e.ID(),
+			Signature:   e.Sign(actions.SealStorageDigest(objectID, sequence, sha256.Sum256(blob), recipients)),
+		},
+	}, nil
+}
+
+// ResealStorage opens [blob], the sealed storage of [objectID] at
+// [sequence], and seals the same storage to [keys] instead.
+func (e *Enclave) ResealStorage(objectID, regionID string, sequence uint64, blob []byte, keys []storage.EncryptionKey) (*actions.ResealStorageAction, error) {
+	plain, err := e.OpenStorage(objectID, blob)
+	if err != nil {
+		return nil, err
+	}
+	resealed, recipients, err := sealStorage(objectID, plain, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &actions.ResealStorageAction{
+		ObjectID:   objectID,
+		RegionID:   regionID,
+		Blob:       resealed,
+		Recipients: recipients,
+		Attestation: attestation.Attestation{
+			EnclaveType: attestation.EnclaveType(e.Type),
+			EnclaveID:   e.ID(),
+			Signature:   e.Sign(actions.ResealStorageDigest(objectID, sequence, sha256.Sum256(resealed), recipients)),
+		},
+	}, nil
+}
+
+// OpenStorage decrypts [blob], sealed storage of [objectID].
+func (e *Enclave) OpenStorage(objectID string, blob []byte) ([]byte, error) {
+	return envelope.Open(e.EncryptionKey, blob, actions.StorageAAD(objectID))
+}
+
+func sealStorage(objectID string, plain []byte, keys []storage.EncryptionKey) ([]byte, [][]byte, error) {
+	pubs := make([][]byte, len(keys))
+	recipients := make([][]byte, len(keys))
+	for i, k := range keys {
+		pubs[i] = k.Key
+		recipients[i] = k.EnclaveID
+	}
+	blob, err := envelope.Seal(pubs, plain, actions.StorageAAD(objectID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return blob, recipients, nil
+}
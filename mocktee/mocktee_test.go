@@ -0,0 +1,132 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package mocktee
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+)
+
+func TestQuoteRoundTrip(t *testing.T) {
+	for _, enclaveType := range []string{EnclaveSGX, EnclaveSEV} {
+		t.Run(enclaveType, func(t *testing.T) {
+			require := require.New(t)
+
+			enclave, err := NewEnclave(enclaveType)
+			require.NoError(err)
+			digest := sha256.Sum256([]byte("action"))
+
+			quote, err := enclave.Quote(digest[:])
+			require.NoError(err)
+			q, err := ParseQuote(enclaveType, quote)
+			require.NoError(err)
+			require.Equal(enclave.Measurement, q.Measurement)
+			require.NoError(q.Verify(enclave.PublicKey(), digest[:]))
+
+			other := sha256.Sum256([]byte("other"))
+			require.ErrorIs(q.Verify(enclave.PublicKey(), other[:]), ErrReportData)
+
+			quote[4] ^= 0xff
+			q, err = ParseQuote(enclaveType, quote)
+			require.NoError(err)
+			require.ErrorIs(q.Verify(enclave.PublicKey(), digest[:]), ErrQuoteSignature)
+		})
+	}
+}
+
+func TestAttestSignsDigest(t *testing.T) {
+	require := require.New(t)
+
+	sgx, _, err := NewPair()
+	require.NoError(err)
+	result := actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: map[string][]byte{"counter": {1}},
+	}
+	action, err := sgx.Attest("us-east", result, 1_700_000_000_000)
+	require.NoError(err)
+	require.Len(action.Attestation.Stamps, RoughtimeServers)
+
+	digest, err := result.Digest()
+	require.NoError(err)
+	var sig ed25519.Signature
+	copy(sig[:], action.Attestation.Signature)
+	require.True(ed25519.Verify(digest, sgx.PublicKey(), sig))
+}
+
+func TestNitroIssuer(t *testing.T) {
+	require := require.New(t)
+
+	now := time.UnixMilli(1_700_000_000_000)
+	issuer, err := NewNitroIssuer(now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(err)
+	enclave, err := NewEnclave(EnclaveNitro)
+	require.NoError(err)
+
+	register, err := issuer.Register(enclave, "us-east", now.UnixMilli())
+	require.NoError(err)
+	root, err := x509.ParseCertificate(issuer.Root)
+	require.NoError(err)
+	doc, err := attestation.VerifyNitroDocument(register.Document, root)
+	require.NoError(err)
+	policy := issuer.Policy()
+	require.NoError(policy.Match(doc))
+
+	// The enclave is known by the ID its registration assigns
+	require.Equal(enclave.RegisteredKey(), doc.PublicKey)
+	require.Equal(attestation.KeyEnclaveID(doc.PublicKey), enclave.ID())
+
+	sgx, err := NewEnclave(EnclaveSGX)
+	require.NoError(err)
+	_, err = issuer.Register(sgx, "us-east", now.UnixMilli())
+	require.ErrorIs(err, ErrUnknownQuoteType)
+}
+
+func TestFaults(t *testing.T) {
+	require := require.New(t)
+
+	faults, err := ParseFaults("drop=1, delay=1:2s,corrupt=1,skew=1:-90s")
+	require.NoError(err)
+	require.Equal(&Faults{Drop: 1, Delay: 1, DelayFor: 2 * time.Second, Corrupt: 1, Skew: 1, SkewBy: -90 * time.Second}, faults)
+	for _, invalid := range []string{"drop=2", "drop=0.1:1s", "skew=0.1", "crash=0.1"} {
+		_, err := ParseFaults(invalid)
+		require.ErrorIs(err, ErrInvalidFault, invalid)
+	}
+
+	enclave, err := NewEnclave(EnclaveSGX)
+	require.NoError(err)
+	action, err := enclave.Attest("us-east", actions.TEEExecResult{ContractAddr: []byte("contract")}, 1_700_000_000_000)
+	require.NoError(err)
+	original := action.Attestation
+
+	// Every fault strikes, leaving the original attestation as it was
+	injector, err := NewInjector(*faults)
+	require.NoError(err)
+	drop, delay := injector.Inject(&action.Attestation)
+	require.True(drop)
+	require.Equal(2*time.Second, delay)
+	require.NotEqual(original.Signature, action.Attestation.Signature)
+	require.Equal(original.Signature[1:], action.Attestation.Signature[1:])
+	require.Equal(uint64(1_700_000_000-90), action.Attestation.Stamps[0].Time)
+	require.Equal(uint64(1_700_000_000), original.Stamps[0].Time)
+
+	// None does without faults, or without an injector
+	none, err := NewInjector(Faults{})
+	require.NoError(err)
+	for _, injector := range []*Injector{none, nil} {
+		a := original
+		drop, delay := injector.Inject(&a)
+		require.False(drop)
+		require.Zero(delay)
+		require.Equal(original, a)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package mocktee
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+)
+
+// NitroIssuer stands in for the AWS Nitro root and hypervisor. It signs
+// attestation documents binding the keys of mock enclaves, so they can be
+// registered with RegisterNitroEnclaveAction on a chain whose genesis
+// trusts [Root], as enclaves are on a live network.
+type NitroIssuer struct {
+	Key *ecdsa.PrivateKey
+	// Root is the DER of the self-signed certificate of [Key]
+	Root []byte
+	// Image is the PCR0 every document reports
+	Image []byte
+}
+
+// NewNitroIssuer generates an issuer whose root is valid from [notBefore]
+// to [notAfter].
+func NewNitroIssuer(notBefore, notAfter time.Time) (*NitroIssuer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	// The root signs documents itself, standing in for the hypervisor's
+	// certificate as well
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	root, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	image := make([]byte, sha512.Size384)
+	if _, err := rand.Read(image); err != nil {
+		return nil, err
+	}
+	return &NitroIssuer{Key: key, Root: root, Image: image}, nil
+}
+
+// Policy is the region policy accepting the issuer's documents.
+func (n *NitroIssuer) Policy() attestation.NitroPolicy {
+	return attestation.NitroPolicy{
+		PCRs: []attestation.PCR{{Index: 0, Value: n.Image}},
+	}
+}
+
+// Register returns the action registering the Nitro [enclave] with
+// [regionID], attested at [timestamp] (unix milliseconds). The document
+// publishes the enclave's encryption key.
+func (n *NitroIssuer) Register(enclave *Enclave, regionID string, timestamp int64) (*actions.RegisterNitroEnclaveAction, error) {
+	if enclave.Type != EnclaveNitro {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQuoteType, enclave.Type)
+	}
+	doc, err := attestation.SignNitroDocument(&attestation.NitroDocument{
+		ModuleID:    fmt.Sprintf("i-mock-%x", enclave.ID()[:4]),
+		Timestamp:   uint64(timestamp),
+		PCRs:        map[uint8][]byte{0: n.Image},
+		Certificate: n.Root,
+		PublicKey:   enclave.RegisteredKey(),
+		UserData:    enclave.EncryptionKey.PublicKey().Bytes(),
+	}, n.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &actions.RegisterNitroEnclaveAction{RegionID: regionID, Document: doc}, nil
+}
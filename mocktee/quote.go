@@ -0,0 +1,133 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package mocktee
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+)
+
+var (
+	ErrUnknownQuoteType = errors.New("unknown quote type")
+	ErrMalformedQuote   = errors.New("malformed quote")
+	ErrQuoteSignature   = errors.New("invalid quote signature")
+	ErrReportData       = errors.New("quote report data mismatch")
+)
+
+// Quote layouts follow the SGX DCAP v3 quote and the SEV-SNP attestation
+// report closely enough that offset-based parsers accept them. Only the
+// fields a verifier inspects are populated; the signature is ed25519 over
+// the signed region rather than the vendor ECDSA chain.
+const (
+	// SGX: 48-byte header, 384-byte report body, then a length-prefixed
+	// signature section holding the signature and attestation key.
+	sgxVersion        = 3
+	sgxAttKeyType     = 2
+	sgxHeaderLen      = 48
+	sgxBodyLen        = 384
+	sgxMeasurementOff = sgxHeaderLen + 64
+	sgxReportDataOff  = sgxHeaderLen + 320
+	sgxSignedLen      = sgxHeaderLen + sgxBodyLen
+	sgxSigSectionLen  = ed25519.SignatureLen + ed25519.PublicKeyLen
+	sgxQuoteLen       = sgxSignedLen + 4 + sgxSigSectionLen
+
+	// SEV-SNP: 0x2A0-byte signed report followed by a 0x200-byte signature.
+	sevVersion        = 2
+	sevReportDataOff  = 0x50
+	sevMeasurementOff = 0x90
+	sevSignedLen      = 0x2A0
+	sevSignatureLen   = 0x200
+	sevQuoteLen       = sevSignedLen + sevSignatureLen
+
+	reportDataLen  = 64
+	measurementLen = 32
+)
+
+// Quote is a parsed mock quote.
+type Quote struct {
+	Type        string
+	Measurement []byte
+	ReportData  []byte
+	Signature   ed25519.Signature
+
+	signed []byte
+}
+
+// BuildQuote returns an [enclaveType]-shaped quote binding [digest] into its
+// report data, signed by [priv].
+func BuildQuote(enclaveType string, priv ed25519.PrivateKey, measurement, digest []byte) ([]byte, error) {
+	if len(digest) > reportDataLen {
+		return nil, ErrMalformedQuote
+	}
+	switch enclaveType {
+	case EnclaveSGX:
+		quote := make([]byte, sgxQuoteLen)
+		binary.LittleEndian.PutUint16(quote[0:], sgxVersion)
+		binary.LittleEndian.PutUint16(quote[2:], sgxAttKeyType)
+		copy(quote[sgxMeasurementOff:sgxMeasurementOff+measurementLen], measurement)
+		copy(quote[sgxReportDataOff:sgxReportDataOff+reportDataLen], digest)
+		binary.LittleEndian.PutUint32(quote[sgxSignedLen:], sgxSigSectionLen)
+		sig := ed25519.Sign(quote[:sgxSignedLen], priv)
+		pub := priv.PublicKey()
+		copy(quote[sgxSignedLen+4:], sig[:])
+		copy(quote[sgxSignedLen+4+ed25519.SignatureLen:], pub[:])
+		return quote, nil
+	case EnclaveSEV:
+		quote := make([]byte, sevQuoteLen)
+		binary.LittleEndian.PutUint32(quote[0:], sevVersion)
+		copy(quote[sevReportDataOff:sevReportDataOff+reportDataLen], digest)
+		copy(quote[sevMeasurementOff:sevMeasurementOff+measurementLen], measurement)
+		sig := ed25519.Sign(quote[:sevSignedLen], priv)
+		copy(quote[sevSignedLen:], sig[:])
+		return quote, nil
+	default:
+		return nil, ErrUnknownQuoteType
+	}
+}
+
+// ParseQuote decodes a quote produced by [BuildQuote]. It does not check
+// the signature.
+func ParseQuote(enclaveType string, quote []byte) (*Quote, error) {
+	q := &Quote{Type: enclaveType}
+	switch enclaveType {
+	case EnclaveSGX:
+		if len(quote) != sgxQuoteLen ||
+			binary.LittleEndian.Uint16(quote[0:]) != sgxVersion ||
+			binary.LittleEndian.Uint32(quote[sgxSignedLen:]) != sgxSigSectionLen {
+			return nil, ErrMalformedQuote
+		}
+		q.Measurement = quote[sgxMeasurementOff : sgxMeasurementOff+measurementLen]
+		q.ReportData = quote[sgxReportDataOff : sgxReportDataOff+reportDataLen]
+		copy(q.Signature[:], quote[sgxSignedLen+4:])
+		q.signed = quote[:sgxSignedLen]
+	case EnclaveSEV:
+		if len(quote) != sevQuoteLen || binary.LittleEndian.Uint32(quote[0:]) != sevVersion {
+			return nil, ErrMalformedQuote
+		}
+		q.Measurement = quote[sevMeasurementOff : sevMeasurementOff+measurementLen]
+		q.ReportData = quote[sevReportDataOff : sevReportDataOff+reportDataLen]
+		copy(q.Signature[:], quote[sevSignedLen:])
+		q.signed = quote[:sevSignedLen]
+	default:
+		return nil, ErrUnknownQuoteType
+	}
+	return q, nil
+}
+
+// Verify checks the quote signature against [pub] and that the report data
+// carries [digest].
+func (q *Quote) Verify(pub ed25519.PublicKey, digest []byte) error {
+	if !ed25519.Verify(q.signed, pub, q.Signature) {
+		return ErrQuoteSignature
+	}
+	want := make([]byte, reportDataLen)
+	copy(want, digest)
+	if !bytes.Equal(q.ReportData, want) {
+		return ErrReportData
+	}
+	return nil
+}
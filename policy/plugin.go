@@ -0,0 +1,35 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package policy
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+// PluginSymbol is the variable a policy plugin exports, of type
+// actions.ContentPolicy.
+const PluginSymbol = "ContentPolicy"
+
+// LoadPlugin opens the Go plugin at [path] and returns the policy it
+// exports as [PluginSymbol]. Plugins must be built with the same Go version
+// and module versions as the node, with go build -buildmode=plugin.
+func LoadPlugin(path string) (actions.ContentPolicy, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	// Lookup returns a pointer to the exported variable
+	v, ok := sym.(*actions.ContentPolicy)
+	if !ok || *v == nil {
+		return nil, fmt.Errorf("%w: %s does not export an actions.ContentPolicy %s", ErrInvalidPolicy, path, PluginSymbol)
+	}
+	return *v, nil
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package policy provides the compiled-in content policies operators can
+// enable, and loads others built as Go plugins. Policies are installed with
+// [actions.SetContentPolicies]; the vm package does so from its
+// contentPolicy config.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+var (
+	ErrDeniedCode    = errors.New("code hash is denied")
+	ErrParamEntropy  = errors.New("parameter entropy exceeds maximum")
+	ErrInvalidPolicy = errors.New("invalid content policy")
+)
+
+var (
+	_ actions.ContentPolicy = (*CodeDenylist)(nil)
+	_ actions.ContentPolicy = (*MaxParamEntropy)(nil)
+	_ actions.ContentPolicy = (*Regional)(nil)
+)
+
+// CodeDenylist rejects objects whose code hashes to one of a set, such as
+// known malicious or sanctioned contracts.
+type CodeDenylist struct {
+	hashes map[ids.ID]struct{}
+}
+
+func NewCodeDenylist(hashes []ids.ID) *CodeDenylist {
+	d := &CodeDenylist{hashes: make(map[ids.ID]struct{}, len(hashes))}
+	for _, h := range hashes {
+		d.hashes[h] = struct{}{}
+	}
+	return d
+}
+
+func (*CodeDenylist) Name() string {
+	return "codeDenylist"
+}
+
+func (d *CodeDenylist) CheckObject(_ context.Context, obj *actions.ObjectContent) error {
+	if _, ok := d.hashes[obj.CodeHash]; ok {
+		return fmt.Errorf("%w: %s", ErrDeniedCode, obj.CodeHash)
+	}
+	return nil
+}
+
+func (*CodeDenylist) CheckEvent(context.Context, *actions.EventContent) error {
+	return nil
+}
+
+// MaxParamEntropy rejects events whose parameters carry more than a bound
+// of Shannon entropy, in bits per byte, to keep opaque payloads off chain.
+// Sealed parameters are exempt: they are encrypted by design.
+type MaxParamEntropy struct {
+	max float64
+}
+
+func NewMaxParamEntropy(bound float64) (*MaxParamEntropy, error) {
+	if bound <= 0 || bound > 8 {
+		return nil, fmt.Errorf("%w: entropy bound %v outside (0, 8]", ErrInvalidPolicy, bound)
+	}
+	return &MaxParamEntropy{max: bound}, nil
+}
+
+func (*MaxParamEntropy) Name() string {
+	return "maxParamEntropy"
+}
+
+func (*MaxParamEntropy) CheckObject(context.Context, *actions.ObjectContent) error {
+	return nil
+}
+
+func (m *MaxParamEntropy) CheckEvent(_ context.Context, event *actions.EventContent) error {
+	if event.Encrypted {
+		return nil
+	}
+	if e := Entropy(event.Parameters); e > m.max {
+		return fmt.Errorf("%w: %.2f > %.2f bits per byte", ErrParamEntropy, e, m.max)
+	}
+	return nil
+}
+
+// Entropy returns the Shannon entropy of the bytes of [b], in bits per
+// byte.
+func Entropy(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, c := range b {
+		counts[c]++
+	}
+	var e float64
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(len(b))
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// Regional applies policies to the objects and events of one region only.
+// Content not listed in a region is not checked.
+type Regional struct {
+	regionID string
+	policies []actions.ContentPolicy
+}
+
+func NewRegional(regionID string, policies ...actions.ContentPolicy) *Regional {
+	return &Regional{regionID: regionID, policies: policies}
+}
+
+func (r *Regional) Name() string {
+	return "region:" + r.regionID
+}
+
+func (r *Regional) CheckObject(ctx context.Context, obj *actions.ObjectContent) error {
+	if obj.RegionID != r.regionID {
+		return nil
+	}
+	for _, p := range r.policies {
+		if err := p.CheckObject(ctx, obj); err != nil {
+			return fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (r *Regional) CheckEvent(ctx context.Context, event *actions.EventContent) error {
+	if event.RegionID != r.regionID {
+		return nil
+	}
+	for _, p := range r.policies {
+		if err := p.CheckEvent(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Set configures the compiled-in policies. Zero fields enable none.
+type Set struct {
+	DenyCodeHashes  []ids.ID `json:"denyCodeHashes"`
+	MaxParamEntropy float64  `json:"maxParamEntropy"`
+}
+
+// Build returns the policies [s] enables.
+func (s Set) Build() ([]actions.ContentPolicy, error) {
+	var policies []actions.ContentPolicy
+	if len(s.DenyCodeHashes) > 0 {
+		policies = append(policies, NewCodeDenylist(s.DenyCodeHashes))
+	}
+	if s.MaxParamEntropy != 0 {
+		p, err := NewMaxParamEntropy(s.MaxParamEntropy)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
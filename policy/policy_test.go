@@ -0,0 +1,50 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+func TestPolicies(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	require.Zero(Entropy(nil))
+	require.Zero(Entropy([]byte("aaaa")))
+	require.InDelta(1.0, Entropy([]byte("abab")), 1e-9)
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	require.InDelta(8.0, Entropy(all), 1e-9)
+
+	denied := ids.ID(sha256.Sum256([]byte("bad")))
+	policies, err := Set{DenyCodeHashes: []ids.ID{denied}, MaxParamEntropy: 4}.Build()
+	require.NoError(err)
+	require.Len(policies, 2)
+	regional := NewRegional("us-east", policies...)
+
+	bad := &actions.ObjectContent{ID: "x", RegionID: "us-east", CodeHash: denied}
+	require.ErrorIs(regional.CheckObject(ctx, bad), ErrDeniedCode)
+	bad.RegionID = "eu-west"
+	require.NoError(regional.CheckObject(ctx, bad))
+
+	event := &actions.EventContent{Target: "x", RegionID: "us-east", Parameters: all}
+	require.ErrorIs(regional.CheckEvent(ctx, event), ErrParamEntropy)
+	event.Encrypted = true
+	require.NoError(regional.CheckEvent(ctx, event))
+
+	_, err = Set{MaxParamEntropy: 9}.Build()
+	require.ErrorIs(err, ErrInvalidPolicy)
+	_, err = LoadPlugin("missing.so")
+	require.Error(err)
+}
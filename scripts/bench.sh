@@ -0,0 +1,21 @@
+#!/usr/bin/env bash
+# Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+# See the file LICENSE for licensing terms.
+
+set -e
+
+# Runs the verifier hot-path benchmarks in a layout benchstat can compare:
+#   ./scripts/bench.sh > old.txt
+#   (apply change)
+#   ./scripts/bench.sh > new.txt
+#   benchstat old.txt new.txt
+if ! [[ "$0" =~ scripts/bench.sh ]]; then
+  echo "must be run from repository root"
+  exit 255
+fi
+
+COUNT=${COUNT:-10}
+BENCH=${BENCH:-.}
+
+go test -run='^$' -bench="${BENCH}" -benchmem -count="${COUNT}" \
+  ./actions/... ./verifier/... ./testvm/...
@@ -0,0 +1,157 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrAdminSetMissing = errors.New("admin set not initialized")
+
+// AdminSet is the M-of-N key set allowed to gate action types. It is
+// written once from genesis.
+type AdminSet struct {
+	Threshold uint8               `serialize:"true" json:"threshold"`
+	Keys      []ed25519.PublicKey `serialize:"true" json:"keys"`
+}
+
+// ActionGate controls when an action type may execute. An action type with
+// no gate is always enabled.
+type ActionGate struct {
+	Disabled         bool   `serialize:"true" json:"disabled"`
+	ActivationHeight uint64 `serialize:"true" json:"activation_height"`
+}
+
+// AdminVersion is an action version activated by the admin keys ahead of
+// the version schedule of the rules. Every version up to it is active from
+// its height.
+type AdminVersion struct {
+	Version uint8  `serialize:"true" json:"version"`
+	Height  uint64 `serialize:"true" json:"height"`
+}
+
+// Enabled reports whether the gated action type may execute at [height].
+func (g *ActionGate) Enabled(height uint64) bool {
+	return !g.Disabled && height >= g.ActivationHeight
+}
+
+// [adminSetPrefix]
+func AdminSetKey() []byte {
+	return []byte{adminSetPrefix}
+}
+
+// [adminNoncePrefix]
+func AdminNonceKey() []byte {
+	return []byte{adminNoncePrefix}
+}
+
+// [actionGatePrefix] + [typeID]
+func ActionGateKey(typeID uint8) []byte {
+	return []byte{actionGatePrefix, typeID}
+}
+
+// [adminVersionPrefix]
+func AdminVersionKey() []byte {
+	return []byte{adminVersionPrefix}
+}
+
+func GetAdminSet(ctx context.Context, im state.Immutable) (*AdminSet, error) {
+	v, err := im.GetValue(ctx, AdminSetKey())
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrAdminSetMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	var set AdminSet
+	if err := codec.Unmarshal(v, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func SetAdminSet(ctx context.Context, mu state.Mutable, set *AdminSet) error {
+	v, err := codec.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, AdminSetKey(), v)
+}
+
+// GetAdminNonce returns the nonce the next admin action must carry.
+func GetAdminNonce(ctx context.Context, im state.Immutable) (uint64, error) {
+	return getUint64(ctx, im, AdminNonceKey())
+}
+
+func SetAdminNonce(ctx context.Context, mu state.Mutable, nonce uint64) error {
+	return setUint64(ctx, mu, AdminNonceKey(), nonce)
+}
+
+// GetActionGate returns the gate of [typeID], or nil if it has none.
+func GetActionGate(ctx context.Context, im state.Immutable, typeID uint8) (*ActionGate, error) {
+	v, err := im.GetValue(ctx, ActionGateKey(typeID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var gate ActionGate
+	if err := codec.Unmarshal(v, &gate); err != nil {
+		return nil, err
+	}
+	return &gate, nil
+}
+
+func SetActionGate(ctx context.Context, mu state.Mutable, typeID uint8, gate *ActionGate) error {
+	v, err := codec.Marshal(gate)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ActionGateKey(typeID), v)
+}
+
+// ActionEnabled reports whether [typeID] may execute at the current height.
+func ActionEnabled(ctx context.Context, im state.Immutable, typeID uint8) (bool, error) {
+	gate, err := GetActionGate(ctx, im, typeID)
+	if err != nil || gate == nil {
+		return err == nil, err
+	}
+	height, err := GetHeight(ctx, im)
+	if err != nil {
+		return false, err
+	}
+	return gate.Enabled(height), nil
+}
+
+// GetAdminVersion returns the action version activated by the admin keys, or
+// nil if they have activated none.
+func GetAdminVersion(ctx context.Context, im state.Immutable) (*AdminVersion, error) {
+	v, err := im.GetValue(ctx, AdminVersionKey())
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var version AdminVersion
+	if err := codec.Unmarshal(v, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func SetAdminVersion(ctx context.Context, mu state.Mutable, version *AdminVersion) error {
+	v, err := codec.Marshal(version)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, AdminVersionKey(), v)
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrInsufficientAllowance = errors.New("insufficient allowance")
+
+// [allowancePrefix] + [owner] + [spender]
+func AllowanceKey(owner codec.Address, spender codec.Address) []byte {
+	k := make([]byte, 1+2*codec.AddressLen)
+	k[0] = allowancePrefix
+	copy(k[1:], owner[:])
+	copy(k[1+codec.AddressLen:], spender[:])
+	return k
+}
+
+// GetAllowance returns how much of [owner]'s balance [spender] may still
+// move.
+func GetAllowance(ctx context.Context, im state.Immutable, owner codec.Address, spender codec.Address) (uint64, error) {
+	return getUint64(ctx, im, AllowanceKey(owner, spender))
+}
+
+// SetAllowance replaces the allowance [owner] gives [spender]. A zero
+// amount removes it.
+func SetAllowance(ctx context.Context, mu state.Mutable, owner codec.Address, spender codec.Address, amount uint64) error {
+	if amount == 0 {
+		return mu.Remove(ctx, AllowanceKey(owner, spender))
+	}
+	return setUint64(ctx, mu, AllowanceKey(owner, spender), amount)
+}
+
+// SpendAllowance moves [amount] from [owner] to [to] on behalf of
+// [spender], reducing its allowance. It returns the remaining allowance.
+func SpendAllowance(
+	ctx context.Context,
+	mu state.Mutable,
+	owner codec.Address,
+	spender codec.Address,
+	to codec.Address,
+	amount uint64,
+) (uint64, error) {
+	allowance, err := GetAllowance(ctx, mu, owner, spender)
+	if err != nil {
+		return 0, err
+	}
+	if allowance < amount {
+		return 0, fmt.Errorf("%w: (allowance=%d, amount=%d)", ErrInsufficientAllowance, allowance, amount)
+	}
+	if err := SetAllowance(ctx, mu, owner, spender, allowance-amount); err != nil {
+		return 0, err
+	}
+	if _, err := SubBalance(ctx, mu, owner, amount); err != nil {
+		return 0, err
+	}
+	if _, err := AddBalance(ctx, mu, to, amount, true); err != nil {
+		return 0, err
+	}
+	return allowance - amount, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// AssetBalancesVersion is the schema version that scopes balances by asset.
+const AssetBalancesVersion uint32 = BaseSchemaVersion + 1
+
+// Asset is a token other than the native one. Only its owner mints it.
+type Asset struct {
+	Name     string        `serialize:"true" json:"name"`
+	Symbol   string        `serialize:"true" json:"symbol"`
+	Decimals uint8         `serialize:"true" json:"decimals"`
+	Owner    codec.Address `serialize:"true" json:"owner"`
+	// RegionID is set for a region's metering asset (see [RegionAssetID])
+	RegionID string `serialize:"true" json:"region_id"`
+	Supply   uint64 `serialize:"true" json:"supply"`
+	// MaxSupply caps Supply; zero means uncapped
+	MaxSupply uint64 `serialize:"true" json:"max_supply"`
+}
+
+// regionAssetDomain separates region asset IDs from action IDs
+const regionAssetDomain = "shuttlevm/region-asset"
+
+// RegionAssetID is the ID of the metering asset of [regionID]. A region has
+// at most one, so executions can declare its keys without reading state.
+func RegionAssetID(regionID string) ids.ID {
+	return sha256.Sum256(append([]byte(regionAssetDomain), regionID...))
+}
+
+// [assetPrefix] + [assetID]
+func AssetKey(assetID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = assetPrefix
+	copy(k[1:], assetID[:])
+	return k
+}
+
+// GetAsset returns [assetID], or nil if it does not exist.
+func GetAsset(ctx context.Context, im state.Immutable, assetID ids.ID) (*Asset, error) {
+	v, err := im.GetValue(ctx, AssetKey(assetID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var a Asset
+	if err := codec.Unmarshal(v, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func SetAsset(ctx context.Context, mu state.Mutable, assetID ids.ID, a *Asset) error {
+	v, err := codec.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, AssetKey(assetID), v)
+}
+
+// nativeBalanceKeyLen is the length of a balance key before balances were
+// scoped by asset: [balancePrefix] + [address] + [chunks]
+const nativeBalanceKeyLen = 1 + codec.AddressLen + consts.Uint16Len
+
+// scopeNativeBalance moves a balance written before [AssetBalancesVersion]
+// under [NativeAsset]. Keys already scoped are left as they are, so the
+// migration can rerun.
+func scopeNativeBalance(key, value []byte) ([]byte, []byte, error) {
+	if len(key) != nativeBalanceKeyLen {
+		return key, value, nil
+	}
+	newKey := make([]byte, 0, len(key)+ids.IDLen)
+	newKey = append(newKey, balancePrefix)
+	newKey = append(newKey, NativeAsset[:]...)
+	newKey = append(newKey, key[1:]...)
+	return newKey, value, nil
+}
+
+func init() {
+	if err := DefaultMigrations.Register(Migration{
+		Version: AssetBalancesVersion,
+		Name:    "asset-scoped balances",
+		Eager: func(ctx context.Context, db MigrationDB) error {
+			return RewritePrefix(ctx, db, balancePrefix, scopeNativeBalance)
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// balanceAsset returns the asset of a balance key.
+func balanceAsset(key []byte) (ids.ID, bool) {
+	if len(key) != 1+ids.IDLen+codec.AddressLen+consts.Uint16Len {
+		return ids.Empty, false
+	}
+	return ids.ID(key[1 : 1+ids.IDLen]), true
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// AuditEntry records an admin operation on a region: creating or updating
+// it, changing its attestation policies, or registering or reattesting an
+// enclave. Entries are keyed by the ID of the action and never rewritten.
+type AuditEntry struct {
+	// TypeID is the type of the action
+	TypeID uint8         `serialize:"true" json:"type_id"`
+	Actor  codec.Address `serialize:"true" json:"actor"`
+	// Height is the chain height the action executed on top of
+	Height    uint64 `serialize:"true" json:"height"`
+	Timestamp int64  `serialize:"true" json:"timestamp"`
+	// EnclaveID is the enclave registered or reattested, if any
+	EnclaveID []byte `serialize:"true" json:"enclave_id,omitempty"`
+	// Attestations are the SHA-256 hashes of the evidence the operation
+	// was admitted on: attestation documents and tokens, or the admin
+	// signatures of a policy change
+	Attestations []ids.ID `serialize:"true" json:"attestations"`
+	// Prev is the action ID of the region's previous entry, empty for the
+	// first, so entries can be walked back from the head
+	Prev ids.ID `serialize:"true" json:"prev"`
+}
+
+// AuditHead points at the latest audit entry of a region.
+type AuditHead struct {
+	Latest ids.ID `serialize:"true" json:"latest"`
+	Count  uint64 `serialize:"true" json:"count"`
+}
+
+// [auditPrefix] + [regionID] + [actionID]
+func AuditKey(regionID string, actionID ids.ID) []byte {
+	return regionScopedKey(auditPrefix, regionID, actionID[:])
+}
+
+// [auditHeadPrefix] + [regionID]
+func AuditHeadKey(regionID string) []byte {
+	return regionScopedKey(auditHeadPrefix, regionID)
+}
+
+// GetAuditHead returns the audit head of [regionID], zero if nothing was
+// recorded.
+func GetAuditHead(ctx context.Context, im state.Immutable, regionID string) (*AuditHead, error) {
+	v, err := im.GetValue(ctx, AuditHeadKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return &AuditHead{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h AuditHead
+	if err := codec.Unmarshal(v, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func SetAuditHead(ctx context.Context, mu state.Mutable, regionID string, h *AuditHead) error {
+	v, err := codec.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, AuditHeadKey(regionID), v)
+}
+
+// GetAuditEntry returns the audit entry of action [actionID] in [regionID],
+// or nil if it recorded none.
+func GetAuditEntry(ctx context.Context, im state.Immutable, regionID string, actionID ids.ID) (*AuditEntry, error) {
+	v, err := im.GetValue(ctx, AuditKey(regionID, actionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e AuditEntry
+	if err := codec.Unmarshal(v, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func SetAuditEntry(ctx context.Context, mu state.Mutable, regionID string, actionID ids.ID, e *AuditEntry) error {
+	v, err := codec.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, AuditKey(regionID, actionID), v)
+}
+
+// GetAuditHeadFromState returns the audit head of [regionID], zero if
+// nothing was recorded.
+func GetAuditHeadFromState(ctx context.Context, f ReadState, regionID string) (*AuditHead, error) {
+	values, errs := f(ctx, [][]byte{AuditHeadKey(regionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return &AuditHead{}, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var h AuditHead
+	if err := codec.Unmarshal(values[0], &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// GetAuditEntryFromState returns the audit entry of action [actionID] in
+// [regionID], or nil if it recorded none.
+func GetAuditEntryFromState(ctx context.Context, f ReadState, regionID string, actionID ids.ID) (*AuditEntry, error) {
+	values, errs := f(ctx, [][]byte{AuditKey(regionID, actionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var e AuditEntry
+	if err := codec.Unmarshal(values[0], &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
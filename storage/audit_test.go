@@ -0,0 +1,109 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+func TestRegionAuditLog(t *testing.T) {
+	ctx := context.Background()
+	v := testvm.New()
+	admin := codectest.NewRandomAddress()
+	tees := []codec.Address{codectest.NewRandomAddress(), codectest.NewRandomAddress(), codectest.NewRandomAddress()}
+	createID := ids.GenerateTestID()
+	updateID := ids.GenerateTestID()
+
+	require.NoError(t, v.Advance(ctx, 10, time.Second))
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:     "Create",
+			Actor:    admin,
+			ActionID: createID,
+			Action: &actions.CreateRegionAction{
+				Version:  consts.LatestActionVersion,
+				RegionID: testvm.Region,
+				TEEs:     tees[:2],
+			},
+		},
+	})
+
+	require.NoError(t, v.Advance(ctx, 5, time.Second))
+	v.RunSteps(ctx, t, []testvm.Step{
+		{
+			// A failed operation leaves no entry
+			Name:  "TooFewTEEs",
+			Actor: admin,
+			Action: &actions.UpdateRegionAction{
+				Version:  consts.LatestActionVersion,
+				RegionID: testvm.Region,
+				RemTEEs:  tees[:1],
+			},
+			ExpectedErr: actions.ErrTooFewTEEs,
+		},
+		{
+			Name:     "Update",
+			Actor:    admin,
+			ActionID: updateID,
+			Action: &actions.UpdateRegionAction{
+				Version:  consts.LatestActionVersion,
+				RegionID: testvm.Region,
+				AddTEEs:  tees[2:],
+				RemTEEs:  tees[:1],
+			},
+		},
+	})
+
+	head, err := storage.GetAuditHead(ctx, v.State, testvm.Region)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), head.Count)
+	require.Equal(t, updateID, head.Latest)
+
+	// Entries link back to the one before them
+	tests := []struct {
+		name   string
+		id     ids.ID
+		typeID uint8
+		height uint64
+		prev   ids.ID
+	}{
+		{
+			name:   "Update",
+			id:     updateID,
+			typeID: consts.UpdateRegionID,
+			height: 15,
+			prev:   createID,
+		},
+		{
+			name:   "Create",
+			id:     createID,
+			typeID: consts.CreateRegionID,
+			height: 10,
+			prev:   ids.Empty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			entry, err := storage.GetAuditEntry(ctx, v.State, testvm.Region, tt.id)
+			require.NoError(err)
+			require.Equal(tt.typeID, entry.TypeID)
+			require.Equal(admin, entry.Actor)
+			require.Equal(tt.height, entry.Height)
+			require.Equal(tt.prev, entry.Prev)
+		})
+	}
+}
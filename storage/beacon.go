@@ -0,0 +1,103 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Beacon is the randomness a region's enclave pair published for an epoch.
+// A published beacon never changes.
+type Beacon struct {
+	Randomness ids.ID `serialize:"true" json:"randomness"`
+	// Enclaves are the pair that contributed seeds, in ascending order
+	Enclaves [][]byte `serialize:"true" json:"enclaves"`
+	// PublishedAt is the block time, in unix seconds, of the publication
+	PublishedAt uint64 `serialize:"true" json:"published_at"`
+}
+
+// BeaconCommitment is the hash of the seed an enclave must reveal for the
+// next epoch.
+type BeaconCommitment struct {
+	EnclaveID  []byte `serialize:"true" json:"enclave_id"`
+	Commitment ids.ID `serialize:"true" json:"commitment"`
+}
+
+// BeaconHead is where a region's beacon stands: the next epoch to publish,
+// the last randomness, and the commitments of the pair that published it.
+type BeaconHead struct {
+	Epoch       uint64             `serialize:"true" json:"epoch"`
+	Randomness  ids.ID             `serialize:"true" json:"randomness"`
+	Commitments []BeaconCommitment `serialize:"true" json:"commitments"`
+	// RevealBy is the block time, in unix seconds, until which only the
+	// committed pair may publish the next epoch
+	RevealBy uint64 `serialize:"true" json:"reveal_by"`
+}
+
+// [beaconPrefix] + [regionID] + [epoch]
+func BeaconKey(regionID string, epoch uint64) []byte {
+	return regionScopedKey(beaconPrefix, regionID, binary.BigEndian.AppendUint64(nil, epoch))
+}
+
+// [beaconHeadPrefix] + [regionID]
+func BeaconHeadKey(regionID string) []byte {
+	return regionScopedKey(beaconHeadPrefix, regionID)
+}
+
+// GetBeacon returns the beacon of [epoch] in [regionID], or nil if it was
+// not published.
+func GetBeacon(ctx context.Context, im state.Immutable, regionID string, epoch uint64) (*Beacon, error) {
+	v, err := im.GetValue(ctx, BeaconKey(regionID, epoch))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Beacon
+	if err := codec.Unmarshal(v, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func SetBeacon(ctx context.Context, mu state.Mutable, regionID string, epoch uint64, b *Beacon) error {
+	v, err := codec.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, BeaconKey(regionID, epoch), v)
+}
+
+// GetBeaconHead returns the beacon head of [regionID]. A region that never
+// published starts at epoch 0 without commitments.
+func GetBeaconHead(ctx context.Context, im state.Immutable, regionID string) (*BeaconHead, error) {
+	v, err := im.GetValue(ctx, BeaconHeadKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return &BeaconHead{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h BeaconHead
+	if err := codec.Unmarshal(v, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func SetBeaconHead(ctx context.Context, mu state.Mutable, regionID string, h *BeaconHead) error {
+	v, err := codec.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, BeaconHeadKey(regionID), v)
+}
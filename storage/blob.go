@@ -0,0 +1,55 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// [blobPrefix] + [sha256(value)]
+func BlobKey(hash ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = blobPrefix
+	copy(k[1:], hash[:])
+	return k
+}
+
+// BlobHash is the reference under which [value] is stored.
+func BlobHash(value []byte) ids.ID {
+	return sha256.Sum256(value)
+}
+
+// GetBlob returns the value stored under [hash]. The second return value is
+// false if no such blob exists.
+func GetBlob(ctx context.Context, im state.Immutable, hash ids.ID) ([]byte, bool, error) {
+	v, err := im.GetValue(ctx, BlobKey(hash))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	v, err = decodeValue(v, MaxRegionStateValueSize)
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// PutBlob stores [value] under its hash and returns the hash. Storing the
+// same value again rewrites identical bytes.
+func PutBlob(ctx context.Context, mu state.Mutable, value []byte) (ids.ID, error) {
+	hash := BlobHash(value)
+	v, err := encodeValue(value)
+	if err != nil {
+		return ids.Empty, err
+	}
+	return hash, mu.Insert(ctx, BlobKey(hash), v)
+}
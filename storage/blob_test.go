@@ -0,0 +1,80 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+// These tests run actions on a testvm.VM, which imports storage, so they
+// live in the storage_test package.
+
+func TestStateUpdateBlobRefs(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+
+	config := bytes.Repeat([]byte("config"), 64)
+	first := actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: map[string][]byte{"a": config, "b": config},
+	}
+	// The repeated value is sent once
+	deduped := first.WithBlobRefs(func(ids.ID) bool { return false })
+	require.Len(t, deduped.StateUpdates, 1)
+	require.Len(t, deduped.StateRefs, 1)
+	stored := f.Exec(t, f.SGX, first)
+	stored.ExecResult = deduped
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Store",
+			Actor:  f.SGX.Address,
+			Action: stored,
+		},
+	})
+
+	// A later execution references the blob stored by the first
+	second := actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: map[string][]byte{"c": config},
+	}
+	referenced := f.Exec(t, f.SEV, second)
+	referenced.ExecResult = second.WithBlobRefs(func(hash ids.ID) bool {
+		_, ok, err := storage.GetBlob(ctx, f.State, hash)
+		return err == nil && ok
+	})
+	require.Empty(t, referenced.ExecResult.StateUpdates)
+	missing := *referenced
+	missing.ExecResult.StateRefs = map[string]ids.ID{"d": {1}}
+
+	f.RunSteps(ctx, t, []testvm.Step{
+		{
+			Name:   "Reference",
+			Actor:  f.SEV.Address,
+			Action: referenced,
+			Assertion: func(ctx context.Context, t *testing.T, _ codec.Typed) {
+				for _, key := range []string{"a", "b", "c"} {
+					value, err := storage.GetRegionState(ctx, f.State, testvm.Region, []byte(key))
+					require.NoError(t, err)
+					require.Equal(t, config, value)
+				}
+			},
+		},
+		{
+			Name:        "BlobNotFound",
+			Actor:       f.SEV.Address,
+			Action:      &missing,
+			ExpectedErr: actions.ErrBlobNotFound,
+		},
+	})
+}
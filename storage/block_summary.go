@@ -0,0 +1,81 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+// BlockSummary condenses the execution of an accepted block for monitoring.
+type BlockSummary struct {
+	Height    uint64 `serialize:"true" json:"height"`
+	BlockID   ids.ID `serialize:"true" json:"block_id"`
+	Timestamp int64  `serialize:"true" json:"timestamp"`
+	Txs       uint32 `serialize:"true" json:"txs"`
+	FailedTxs uint32 `serialize:"true" json:"failed_txs"`
+	// Actions counts the block's actions by type, in ascending type order
+	Actions []ActionCount `serialize:"true" json:"actions"`
+	// Regions lists the regions successful actions acted on, sorted
+	Regions []string `serialize:"true" json:"regions"`
+	// StateBytes is the payload successful actions wrote: state updates,
+	// code, chunks and event parameters
+	StateBytes uint64 `serialize:"true" json:"state_bytes"`
+	// AttestationFailures counts transactions that failed on an
+	// attestation, enclave or timestamp check
+	AttestationFailures uint32 `serialize:"true" json:"attestation_failures"`
+}
+
+type ActionCount struct {
+	TypeID uint8  `serialize:"true" json:"type_id"`
+	Count  uint32 `serialize:"true" json:"count"`
+}
+
+// [blockSummaryPrefix] + [height]
+func BlockSummaryKey(height uint64) []byte {
+	k := make([]byte, 1+consts.Uint64Len)
+	k[0] = blockSummaryPrefix
+	binary.BigEndian.PutUint64(k[1:], height)
+	return k
+}
+
+// GetBlockSummaryFromState returns the summary of the block at [height], or
+// nil if none is kept for it.
+func GetBlockSummaryFromState(ctx context.Context, f ReadState, height uint64) (*BlockSummary, error) {
+	values, errs := f(ctx, [][]byte{BlockSummaryKey(height)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var s BlockSummary
+	if err := codec.Unmarshal(values[0], &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// PutBlockSummary stores [s] and drops the summary that falls out of the
+// last [retain] blocks. Summaries are written as blocks are accepted, like
+// lazy migrations, rather than by an action.
+func PutBlockSummary(db database.KeyValueWriterDeleter, s *BlockSummary, retain uint64) error {
+	v, err := codec.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := db.Put(BlockSummaryKey(s.Height), v); err != nil {
+		return err
+	}
+	if retain == 0 || s.Height < retain {
+		return nil
+	}
+	return db.Delete(BlockSummaryKey(s.Height - retain))
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSummaryRetention(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	read := func(_ context.Context, keys [][]byte) ([][]byte, []error) {
+		values, errs := make([][]byte, len(keys)), make([]error, len(keys))
+		for i, key := range keys {
+			values[i], errs[i] = db.Get(key)
+		}
+		return values, errs
+	}
+
+	for height := uint64(1); height <= 5; height++ {
+		require.NoError(PutBlockSummary(db, &BlockSummary{
+			Height:  height,
+			Txs:     2,
+			Actions: []ActionCount{{TypeID: 1, Count: 3}},
+			Regions: []string{"us-east"},
+		}, 3))
+	}
+
+	// Only the last three blocks are kept
+	for height := uint64(1); height <= 2; height++ {
+		s, err := GetBlockSummaryFromState(ctx, read, height)
+		require.NoError(err)
+		require.Nil(s)
+	}
+	s, err := GetBlockSummaryFromState(ctx, read, 5)
+	require.NoError(err)
+	require.Equal(&BlockSummary{
+		Height:  5,
+		Txs:     2,
+		Actions: []ActionCount{{TypeID: 1, Count: 3}},
+		Regions: []string{"us-east"},
+	}, s)
+}
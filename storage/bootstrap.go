@@ -0,0 +1,180 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var ErrInvalidBootstrapCursor = errors.New("invalid bootstrap cursor")
+
+// BootstrapSection is a part of a region's bootstrap stream. Sections are
+// read in order.
+type BootstrapSection uint8
+
+const (
+	BootstrapObjects BootstrapSection = iota
+	BootstrapEvents
+	BootstrapRequests
+	bootstrapDone
+)
+
+// bootstrapPrefixes are the region-scoped records each section reads.
+var bootstrapPrefixes = [...]byte{
+	BootstrapObjects:  objectIndexPrefix,
+	BootstrapEvents:   execEventPrefix,
+	BootstrapRequests: requestPrefix,
+}
+
+// BootstrapCursor is where a bootstrap stream resumes: the section, and
+// the first key of it still to read.
+type BootstrapCursor struct {
+	Section BootstrapSection `json:"section"`
+	Key     []byte           `json:"key"`
+}
+
+// BootstrapReader is state that can also be iterated, such as a view of
+// the merkle database.
+type BootstrapReader interface {
+	state.Immutable
+	database.Iteratee
+}
+
+type BootstrapObject struct {
+	ID      string `json:"id"`
+	Code    []byte `json:"code"`
+	Storage []byte `json:"storage"`
+}
+
+// BootstrapEvent is an event a TEE execution stored for the region.
+type BootstrapEvent struct {
+	Contract []byte `json:"contract"`
+	Index    uint64 `json:"index"`
+	Event    []byte `json:"event"`
+}
+
+type BootstrapRequest struct {
+	ID ids.ID `json:"id"`
+	Request
+}
+
+// BootstrapPage is one page of what an enclave needs to start serving a
+// region.
+type BootstrapPage struct {
+	// RegionRoot is the latest attested state root of the region
+	RegionRoot ids.ID             `json:"region_root"`
+	Objects    []BootstrapObject  `json:"objects"`
+	Events     []BootstrapEvent   `json:"events"`
+	Requests   []BootstrapRequest `json:"requests"`
+	// Next is where the stream resumes, nil once it is complete
+	Next *BootstrapCursor `json:"next,omitempty"`
+}
+
+// GetBootstrapPage reads at most [limit] records of [regionID] from
+// [cursor], or from the start if it is nil: its listed objects, the events
+// its executions stored, and its pending requests. Fulfilled requests and
+// objects no longer stored are skipped but count against [limit].
+func GetBootstrapPage(ctx context.Context, r BootstrapReader, regionID string, cursor *BootstrapCursor, limit int) (*BootstrapPage, error) {
+	if cursor == nil {
+		cursor = &BootstrapCursor{}
+	}
+	if cursor.Section >= bootstrapDone {
+		return nil, ErrInvalidBootstrapCursor
+	}
+	root, err := GetRegionRoot(ctx, r, regionID)
+	if err != nil {
+		return nil, err
+	}
+	page := &BootstrapPage{
+		RegionRoot: root,
+		Objects:    []BootstrapObject{},
+		Events:     []BootstrapEvent{},
+		Requests:   []BootstrapRequest{},
+	}
+
+	read := 0
+	for section, start := cursor.Section, cursor.Key; section < bootstrapDone; section, start = section+1, nil {
+		prefix := regionScopedKey(bootstrapPrefixes[section], regionID)
+		if start == nil {
+			start = prefix
+		} else if !bytes.HasPrefix(start, prefix) {
+			return nil, ErrInvalidBootstrapCursor
+		}
+		it := r.NewIteratorWithStartAndPrefix(start, prefix)
+		for it.Next() {
+			if read == limit {
+				page.Next = &BootstrapCursor{Section: section, Key: bytes.Clone(it.Key())}
+				it.Release()
+				return page, nil
+			}
+			if err := ctx.Err(); err != nil {
+				it.Release()
+				return nil, err
+			}
+			if err := page.add(ctx, r, section, it.Key()[len(prefix):], it.Value()); err != nil {
+				it.Release()
+				return nil, err
+			}
+			read++
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// add adds the record of [section] with [suffix] after the region in its
+// key and [value] to [p].
+func (p *BootstrapPage) add(ctx context.Context, im state.Immutable, section BootstrapSection, suffix, value []byte) error {
+	switch section {
+	case BootstrapObjects:
+		id := string(suffix)
+		obj, err := GetObject(ctx, im, id)
+		if err != nil || obj == nil {
+			return err
+		}
+		code, err := DecodeCode(obj["code"], consts.MaxCodeSize)
+		if err != nil {
+			return err
+		}
+		p.Objects = append(p.Objects, BootstrapObject{ID: id, Code: code, Storage: obj["storage"]})
+	case BootstrapEvents:
+		if len(suffix) < 8 {
+			return nil
+		}
+		n := len(suffix) - 8
+		p.Events = append(p.Events, BootstrapEvent{
+			Contract: bytes.Clone(suffix[:n]),
+			Index:    binary.BigEndian.Uint64(suffix[n:]),
+			Event:    bytes.Clone(value),
+		})
+	case BootstrapRequests:
+		var r Request
+		if err := codec.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		if r.Status != RequestPending {
+			return nil
+		}
+		id, err := ids.ToID(suffix)
+		if err != nil {
+			return err
+		}
+		p.Requests = append(p.Requests, BootstrapRequest{ID: id, Request: r})
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBootstrapPage(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	mu := dbState{db}
+
+	root := ids.GenerateTestID()
+	require.NoError(SetRegionRoot(ctx, mu, "us-east", root))
+	for i, id := range []string{"a", "b"} {
+		require.NoError(SetObject(ctx, mu, id, map[string][]byte{"code": {byte(i)}, "storage": {1}}))
+		require.NoError(AppendIndex(ctx, mu, ObjectIndexHeadKey("us-east"), ObjectIndexKey("us-east", id), id))
+	}
+	require.NoError(AppendIndex(ctx, mu, ObjectIndexHeadKey("eu-west"), ObjectIndexKey("eu-west", "a"), "a"))
+	require.NoError(db.Put(ExecEventKey("us-east", []byte("a"), 0), []byte{7}))
+	pending, fulfilled := ids.GenerateTestID(), ids.GenerateTestID()
+	require.NoError(SetRequest(ctx, mu, "us-east", pending, &Request{Requester: codectest.NewRandomAddress()}))
+	require.NoError(SetRequest(ctx, mu, "us-east", fulfilled, &Request{Status: RequestFulfilled}))
+
+	var (
+		objects  []BootstrapObject
+		events   []BootstrapEvent
+		requests []BootstrapRequest
+		cursor   *BootstrapCursor
+		pages    int
+	)
+	for {
+		page, err := GetBootstrapPage(ctx, mu, "us-east", cursor, 2)
+		require.NoError(err)
+		require.Equal(root, page.RegionRoot)
+		objects = append(objects, page.Objects...)
+		events = append(events, page.Events...)
+		requests = append(requests, page.Requests...)
+		pages++
+		if cursor = page.Next; cursor == nil {
+			break
+		}
+	}
+	require.Equal(3, pages)
+	require.Equal([]BootstrapObject{{ID: "a", Code: []byte{0}, Storage: []byte{1}}, {ID: "b", Code: []byte{1}, Storage: []byte{1}}}, objects)
+	require.Equal([]BootstrapEvent{{Contract: []byte("a"), Index: 0, Event: []byte{7}}}, events)
+	require.Len(requests, 1)
+	require.Equal(pending, requests[0].ID)
+
+	_, err := GetBootstrapPage(ctx, mu, "us-east", &BootstrapCursor{Section: BootstrapEvents, Key: ObjectIndexKey("us-east", "a")}, 2)
+	require.ErrorIs(err, ErrInvalidBootstrapCursor)
+	_, err = GetBootstrapPage(ctx, mu, "us-east", &BootstrapCursor{Section: bootstrapDone}, 2)
+	require.ErrorIs(err, ErrInvalidBootstrapCursor)
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Callback is registered by an event that asks for its result. It is
+// removed when a TEE execution of the event is applied, which queues a
+// CallbackEvent in its place.
+type Callback struct {
+	Object   string `serialize:"true" json:"object"`
+	Function string `serialize:"true" json:"function"`
+}
+
+// CallbackEvent delivers the result of an event to the function its sender
+// named.
+type CallbackEvent struct {
+	Object   string `serialize:"true" json:"object"`
+	Function string `serialize:"true" json:"function"`
+	// ResultHash is the digest of the execution result the enclave signed
+	ResultHash ids.ID `serialize:"true" json:"result_hash"`
+	// QueuedAt is the block time, in unix milliseconds, of the execution
+	QueuedAt int64 `serialize:"true" json:"queued_at"`
+}
+
+// [callbackPrefix] + [eventID]
+func CallbackKey(eventID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = callbackPrefix
+	copy(k[1:], eventID[:])
+	return k
+}
+
+// [callbackEventPrefix] + [eventID]
+func CallbackEventKey(eventID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = callbackEventPrefix
+	copy(k[1:], eventID[:])
+	return k
+}
+
+// GetCallback returns the callback awaiting [eventID], or nil if there is
+// none.
+func GetCallback(ctx context.Context, im state.Immutable, eventID ids.ID) (*Callback, error) {
+	v, err := im.GetValue(ctx, CallbackKey(eventID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Callback
+	if err := codec.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func SetCallback(ctx context.Context, mu state.Mutable, eventID ids.ID, c *Callback) error {
+	v, err := codec.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, CallbackKey(eventID), v)
+}
+
+func RemoveCallback(ctx context.Context, mu state.Mutable, eventID ids.ID) error {
+	return mu.Remove(ctx, CallbackKey(eventID))
+}
+
+// GetCallbackEvent returns the delivery queued for [eventID], or nil if the
+// event has not completed.
+func GetCallbackEvent(ctx context.Context, im state.Immutable, eventID ids.ID) (*CallbackEvent, error) {
+	v, err := im.GetValue(ctx, CallbackEventKey(eventID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e CallbackEvent
+	if err := codec.Unmarshal(v, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func SetCallbackEvent(ctx context.Context, mu state.Mutable, eventID ids.ID, e *CallbackEvent) error {
+	v, err := codec.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, CallbackEventKey(eventID), v)
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Checkpoint is a region root a quorum of the checkpoint committee
+// co-signed at a height, so it can be proven with validator signatures
+// rather than enclave attestations alone.
+type Checkpoint struct {
+	Root ids.ID `serialize:"true" json:"root"`
+	// Committee is the hash of the committee that signed, and Signers the
+	// bitset of the signing members in its order
+	Committee ids.ID `serialize:"true" json:"committee"`
+	Signers   []byte `serialize:"true" json:"signers"`
+	Weight    uint64 `serialize:"true" json:"weight"`
+	// AggregateKey aggregates the BLS keys of the signers, and Signature
+	// their signatures over the checkpoint digest
+	AggregateKey []byte        `serialize:"true" json:"aggregate_key"`
+	Signature    []byte        `serialize:"true" json:"signature"`
+	Submitter    codec.Address `serialize:"true" json:"submitter"`
+}
+
+// [checkpointPrefix] + [regionID] + [height]
+func CheckpointKey(regionID string, height uint64) []byte {
+	return regionScopedKey(checkpointPrefix, regionID, binary.BigEndian.AppendUint64(nil, height))
+}
+
+// [checkpointHeadPrefix] + [regionID]
+func CheckpointHeadKey(regionID string) []byte {
+	return regionScopedKey(checkpointHeadPrefix, regionID)
+}
+
+// GetCheckpoint returns the checkpoint of [regionID] at [height], or nil if
+// there is none.
+func GetCheckpoint(ctx context.Context, im state.Immutable, regionID string, height uint64) (*Checkpoint, error) {
+	v, err := im.GetValue(ctx, CheckpointKey(regionID, height))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseCheckpoint(v)
+}
+
+func SetCheckpoint(ctx context.Context, mu state.Mutable, regionID string, height uint64, c *Checkpoint) error {
+	v, err := codec.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, CheckpointKey(regionID, height), v)
+}
+
+// GetCheckpointHead returns the height of the last checkpoint of
+// [regionID], zero if it has none.
+func GetCheckpointHead(ctx context.Context, im state.Immutable, regionID string) (uint64, error) {
+	return getUint64(ctx, im, CheckpointHeadKey(regionID))
+}
+
+func SetCheckpointHead(ctx context.Context, mu state.Mutable, regionID string, height uint64) error {
+	return setUint64(ctx, mu, CheckpointHeadKey(regionID), height)
+}
+
+// GetCheckpointHeadFromState returns the height of the last checkpoint of
+// [regionID], zero if it has none.
+func GetCheckpointHeadFromState(ctx context.Context, f ReadState, regionID string) (uint64, error) {
+	values, errs := f(ctx, [][]byte{CheckpointHeadKey(regionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return 0, nil
+	}
+	if errs[0] != nil {
+		return 0, errs[0]
+	}
+	if len(values[0]) != consts.Uint64Len {
+		return 0, fmt.Errorf("%w: unexpected value length %d", ErrInvalidBalance, len(values[0]))
+	}
+	return binary.BigEndian.Uint64(values[0]), nil
+}
+
+// GetCheckpointFromState returns the checkpoint of [regionID] at [height],
+// or nil if there is none.
+func GetCheckpointFromState(ctx context.Context, f ReadState, regionID string, height uint64) (*Checkpoint, error) {
+	values, errs := f(ctx, [][]byte{CheckpointKey(regionID, height)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return ParseCheckpoint(values[0])
+}
+
+// ParseCheckpoint decodes a stored checkpoint.
+func ParseCheckpoint(v []byte) (*Checkpoint, error) {
+	var c Checkpoint
+	if err := codec.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
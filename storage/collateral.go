@@ -0,0 +1,86 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+)
+
+var ErrSGXRootMissing = errors.New("SGX root certificate not initialized")
+
+// StoredCollateral is DCAP collateral accepted on chain. Validators judge
+// quotes against it instead of fetching collateral from Intel, so every
+// validator reaches the same verdict.
+type StoredCollateral struct {
+	Collateral attestation.Collateral `serialize:"true" json:"collateral"`
+	// Expiry is the unix millisecond time after which the collateral must
+	// not be used
+	Expiry int64 `serialize:"true" json:"expiry"`
+	// EvaluationDataNumber is Intel's TCB recovery counter, used to refuse
+	// rolling collateral back
+	EvaluationDataNumber uint32 `serialize:"true" json:"evaluation_data_number"`
+}
+
+// [sgxRootPrefix]
+func SGXRootKey() []byte {
+	return []byte{sgxRootPrefix}
+}
+
+// [collateralPrefix] + [fmspc]
+func CollateralKey(fmspc []byte) []byte {
+	k := make([]byte, 1+len(fmspc))
+	k[0] = collateralPrefix
+	copy(k[1:], fmspc)
+	return k
+}
+
+// GetSGXRoot returns the root certificate DCAP collateral must chain up to.
+// It is written once from genesis.
+func GetSGXRoot(ctx context.Context, im state.Immutable) (*x509.Certificate, error) {
+	v, err := im.GetValue(ctx, SGXRootKey())
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrSGXRootMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(v)
+}
+
+func SetSGXRoot(ctx context.Context, mu state.Mutable, der []byte) error {
+	return mu.Insert(ctx, SGXRootKey(), der)
+}
+
+// GetCollateral returns the collateral published for [fmspc], or nil if
+// there is none.
+func GetCollateral(ctx context.Context, im state.Immutable, fmspc []byte) (*StoredCollateral, error) {
+	v, err := im.GetValue(ctx, CollateralKey(fmspc))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c StoredCollateral
+	if err := codec.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func SetCollateral(ctx context.Context, mu state.Mutable, fmspc []byte, c *StoredCollateral) error {
+	v, err := codec.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, CollateralKey(fmspc), v)
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/utils/compression"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+// MaxRegionStateValueSize bounds a region state value once decompressed.
+const MaxRegionStateValueSize = consts.MaxStorageSize
+
+var (
+	ErrDecompressedTooLarge = errors.New("decompressed size exceeds limit")
+	ErrMissingCodeHeader    = errors.New("code has no header")
+)
+
+// zstdMagic starts every zstd frame. Stored values are self-describing:
+// a value that starts with it is compressed, any other value is raw. A raw
+// value that happens to start with it is stored compressed so it reads back
+// unchanged.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Compressed bytes become part of state, so every validator must produce
+// the same output for the same input. The zstd library version is pinned
+// by go.mod and must only change in a coordinated upgrade.
+func compress(data []byte) ([]byte, error) {
+	c, err := compression.NewZstdCompressor(int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return c.Compress(data)
+}
+
+// decompress inflates [data], failing as soon as the output would exceed
+// [maxSize] so a small payload cannot expand without bound.
+func decompress(data []byte, maxSize int) ([]byte, error) {
+	c, err := compression.NewZstdCompressor(int64(maxSize))
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.Decompress(data)
+	if errors.Is(err, compression.ErrDecompressedMsgTooLarge) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrDecompressedTooLarge, maxSize)
+	}
+	return out, err
+}
+
+// encodeValue compresses state values of at least [consts.CompressThreshold]
+// bytes.
+func encodeValue(v []byte) ([]byte, error) {
+	if len(v) < consts.CompressThreshold && !bytes.HasPrefix(v, zstdMagic) {
+		return v, nil
+	}
+	return compress(v)
+}
+
+// decodeValue reverses [encodeValue], inflating to at most [maxSize] bytes.
+func decodeValue(v []byte, maxSize int) ([]byte, error) {
+	if !bytes.HasPrefix(v, zstdMagic) {
+		return v, nil
+	}
+	return decompress(v, maxSize)
+}
+
+func hasCodeHeader(code []byte) bool {
+	return len(code) >= consts.CodeHeaderSize && string(code[:len(consts.CodeHeaderMagic)]) == consts.CodeHeaderMagic
+}
+
+// CompressCode compresses the body of [code] and sets [consts.CodeFlagZstd]
+// in its header. Code that is already compressed is returned unchanged.
+func CompressCode(code []byte) ([]byte, error) {
+	if !hasCodeHeader(code) {
+		return nil, ErrMissingCodeHeader
+	}
+	if code[consts.CodeFlagsOffset]&consts.CodeFlagZstd != 0 {
+		return code, nil
+	}
+	body, err := compress(code[consts.CodeHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, consts.CodeHeaderSize+len(body))
+	out = append(out, code[:consts.CodeHeaderSize]...)
+	out[consts.CodeFlagsOffset] |= consts.CodeFlagZstd
+	return append(out, body...), nil
+}
+
+// EncodeCode compresses [code] for storage if it has a header and is at
+// least [consts.CompressThreshold] bytes. Other code is stored as is.
+func EncodeCode(code []byte) ([]byte, error) {
+	if !hasCodeHeader(code) || len(code) < consts.CompressThreshold {
+		return code, nil
+	}
+	return CompressCode(code)
+}
+
+// DecodeCode returns [code] with its body decompressed and
+// [consts.CodeFlagZstd] cleared. The result is at most [maxSize] bytes,
+// header included. Code without the flag is returned unchanged.
+func DecodeCode(code []byte, maxSize int) ([]byte, error) {
+	if !hasCodeHeader(code) || code[consts.CodeFlagsOffset]&consts.CodeFlagZstd == 0 {
+		return code, nil
+	}
+	if maxSize < consts.CodeHeaderSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrDecompressedTooLarge, maxSize)
+	}
+	body, err := decompress(code[consts.CodeHeaderSize:], maxSize-consts.CodeHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, consts.CodeHeaderSize+len(body))
+	out = append(out, code[:consts.CodeHeaderSize]...)
+	out[consts.CodeFlagsOffset] &^= consts.CodeFlagZstd
+	return append(out, body...), nil
+}
+
+// GetObjectCode returns the decompressed code of [objectID], or nil if the
+// object does not exist.
+func GetObjectCode(ctx context.Context, im state.Immutable, objectID string) ([]byte, error) {
+	obj, err := GetObject(ctx, im, objectID)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	return DecodeCode(obj["code"], consts.MaxCodeSize)
+}
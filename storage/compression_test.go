@@ -0,0 +1,63 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestRegionStateCompression(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	mu := dbState{db}
+
+	small := []byte{1, 2, 3}
+	large := bytes.Repeat([]byte("state"), consts.CompressThreshold)
+	magic := append(bytes.Clone(zstdMagic), 1)
+	for _, value := range [][]byte{small, large, magic} {
+		require.NoError(SetRegionState(ctx, mu, "us-east", []byte("k"), value))
+		got, err := GetRegionState(ctx, mu, "us-east", []byte("k"))
+		require.NoError(err)
+		require.Equal(value, got)
+	}
+
+	raw, err := db.Get(RegionStateKey("us-east", []byte("k")))
+	require.NoError(err)
+	require.True(bytes.HasPrefix(raw, zstdMagic))
+	require.NoError(SetRegionState(ctx, mu, "us-east", []byte("k"), large))
+	raw, err = db.Get(RegionStateKey("us-east", []byte("k")))
+	require.NoError(err)
+	require.Less(len(raw), len(large))
+}
+
+func TestDecodeCodeLimit(t *testing.T) {
+	require := require.New(t)
+
+	header := make([]byte, consts.CodeHeaderSize)
+	copy(header, consts.CodeHeaderMagic)
+	code := append(header, make([]byte, 4*consts.MaxCodeSize)...)
+
+	compressed, err := CompressCode(code)
+	require.NoError(err)
+	require.Less(len(compressed), consts.MaxCodeSize)
+	require.NotZero(compressed[consts.CodeFlagsOffset] & consts.CodeFlagZstd)
+
+	decoded, err := DecodeCode(compressed, len(code))
+	require.NoError(err)
+	require.Equal(code, decoded)
+
+	_, err = DecodeCode(compressed, consts.MaxCodeSize)
+	require.ErrorIs(err, ErrDecompressedTooLarge)
+
+	_, err = CompressCode([]byte("no header"))
+	require.ErrorIs(err, ErrMissingCodeHeader)
+}
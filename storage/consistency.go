@@ -0,0 +1,228 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+var (
+	ErrOrphanEnclave      = errors.New("enclave of unknown region")
+	ErrOrphanEvent        = errors.New("event targets unknown object")
+	ErrOrphanObjectKV     = errors.New("kv entry of unknown object")
+	ErrOrphanExecEvent    = errors.New("exec event of unknown region")
+	ErrDuplicateRegionTEE = errors.New("duplicate TEE in region")
+	ErrSupplyExceeded     = errors.New("balances exceed supply")
+	ErrRewardsExceedPool  = errors.New("accrued rewards exceed region pool")
+	ErrMalformedKey       = errors.New("malformed state key")
+)
+
+// CheckConsistency walks the raw state in [db] and reports every violation
+// of the referential invariants between records:
+//   - enclave status and key records belong to an existing region
+//   - queued events target an existing object
+//   - object kv entries belong to an existing object
+//   - exec events belong to an existing region
+//   - region TEE lists contain no duplicates
+//   - native balances sum to at most [maxSupply] (skipped if zero), and
+//     balances of other assets to at most the asset's supply
+//   - accrued enclave rewards in a region do not exceed its pool
+//
+// All violations are joined into the returned error.
+func CheckConsistency(ctx context.Context, db database.Iteratee, maxSupply uint64) error {
+	var errs []error
+	report := func(err error) {
+		errs = append(errs, err)
+	}
+
+	regions := map[string]struct{}{}
+	if err := iteratePrefix(ctx, db, regionPrefix, func(key, value []byte) {
+		regionID := string(key[1:])
+		regions[regionID] = struct{}{}
+		if len(value)%codec.AddressLen != 0 {
+			report(fmt.Errorf("%w: %s", ErrCorruptRegion, regionID))
+			return
+		}
+		seen := map[string]struct{}{}
+		for i := 0; i < len(value); i += codec.AddressLen {
+			tee := string(value[i : i+codec.AddressLen])
+			if _, ok := seen[tee]; ok {
+				report(fmt.Errorf("%w: %s", ErrDuplicateRegionTEE, regionID))
+				return
+			}
+			seen[tee] = struct{}{}
+		}
+	}); err != nil {
+		return err
+	}
+
+	for _, prefix := range []byte{enclavePrefix, enclavePubKeyPrefix, enclaveExpiryPrefix} {
+		if err := iteratePrefix(ctx, db, prefix, func(key, _ []byte) {
+			regionID, _, ok := splitRegionScopedKey(key)
+			if !ok {
+				report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+				return
+			}
+			if _, ok := regions[regionID]; !ok {
+				report(fmt.Errorf("%w: %s", ErrOrphanEnclave, regionID))
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := iteratePrefix(ctx, db, execEventPrefix, func(key, _ []byte) {
+		regionID, _, ok := splitRegionScopedKey(key)
+		if !ok {
+			report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+			return
+		}
+		if _, ok := regions[regionID]; !ok {
+			report(fmt.Errorf("%w: %s", ErrOrphanExecEvent, regionID))
+		}
+	}); err != nil {
+		return err
+	}
+
+	objects := map[string]struct{}{}
+	if err := iteratePrefix(ctx, db, objectPrefix, func(key, _ []byte) {
+		objects[string(key[1:])] = struct{}{}
+	}); err != nil {
+		return err
+	}
+	// Event keys are [timestamp][id] with no separator, so an event is
+	// consistent if any suffix of the key names an existing object.
+	if err := iteratePrefix(ctx, db, eventPrefix, func(key, _ []byte) {
+		for i := 1; i < len(key); i++ {
+			if _, ok := objects[string(key[i:])]; ok {
+				return
+			}
+		}
+		report(fmt.Errorf("%w: %x", ErrOrphanEvent, key[1:]))
+	}); err != nil {
+		return err
+	}
+
+	if err := iteratePrefix(ctx, db, objectKVPrefix, func(key, _ []byte) {
+		objectID, _, ok := splitRegionScopedKey(key)
+		if !ok {
+			report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+			return
+		}
+		if _, ok := objects[objectID]; !ok {
+			report(fmt.Errorf("%w: %s", ErrOrphanObjectKV, objectID))
+		}
+	}); err != nil {
+		return err
+	}
+
+	supplies := map[ids.ID]uint64{}
+	if err := iteratePrefix(ctx, db, balancePrefix, func(key, value []byte) {
+		assetID, ok := balanceAsset(key)
+		if !ok {
+			report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+			return
+		}
+		bal, err := database.ParseUInt64(value)
+		if err != nil {
+			report(fmt.Errorf("%w: %x", ErrInvalidBalance, key[1+ids.IDLen:1+ids.IDLen+codec.AddressLen]))
+			return
+		}
+		if supplies[assetID], err = smath.Add(supplies[assetID], bal); err != nil {
+			report(fmt.Errorf("%w: %s overflow", ErrSupplyExceeded, assetID))
+		}
+	}); err != nil {
+		return err
+	}
+	if supply := supplies[NativeAsset]; maxSupply > 0 && supply > maxSupply {
+		report(fmt.Errorf("%w: (balances=%d, supply=%d)", ErrSupplyExceeded, supply, maxSupply))
+	}
+	if err := iteratePrefix(ctx, db, assetPrefix, func(key, value []byte) {
+		var asset Asset
+		if len(key) != 1+ids.IDLen || codec.Unmarshal(value, &asset) != nil {
+			report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+			return
+		}
+		assetID := ids.ID(key[1:])
+		if supplies[assetID] > asset.Supply {
+			report(fmt.Errorf("%w: %s (balances=%d, supply=%d)", ErrSupplyExceeded, assetID, supplies[assetID], asset.Supply))
+		}
+	}); err != nil {
+		return err
+	}
+
+	pools := map[string]uint64{}
+	if err := iteratePrefix(ctx, db, rewardPoolPrefix, func(key, value []byte) {
+		regionID, _, ok := splitRegionScopedKey(key)
+		if !ok || len(value) != consts.Uint64Len {
+			report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+			return
+		}
+		pools[regionID] = binary.BigEndian.Uint64(value)
+	}); err != nil {
+		return err
+	}
+	accrued := map[string]uint64{}
+	if err := iteratePrefix(ctx, db, enclaveRewardPrefix, func(key, value []byte) {
+		regionID, _, ok := splitRegionScopedKey(key)
+		if !ok || len(value) != consts.Uint64Len {
+			report(fmt.Errorf("%w: %x", ErrMalformedKey, key))
+			return
+		}
+		total, err := smath.Add(accrued[regionID], binary.BigEndian.Uint64(value))
+		if err != nil {
+			report(fmt.Errorf("%w: %s", ErrRewardsExceedPool, regionID))
+			return
+		}
+		accrued[regionID] = total
+	}); err != nil {
+		return err
+	}
+	for regionID, total := range accrued {
+		if total > pools[regionID] {
+			report(fmt.Errorf("%w: %s (rewards=%d, pool=%d)", ErrRewardsExceedPool, regionID, total, pools[regionID]))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// iteratePrefix calls [f] for every key under the one-byte [prefix].
+func iteratePrefix(ctx context.Context, db database.Iteratee, prefix byte, f func(key, value []byte)) error {
+	it := db.NewIteratorWithPrefix([]byte{prefix})
+	defer it.Release()
+
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f(bytes.Clone(it.Key()), bytes.Clone(it.Value()))
+	}
+	return it.Error()
+}
+
+// splitRegionScopedKey reverses [regionScopedKey], returning the region and
+// the remaining key parts.
+func splitRegionScopedKey(key []byte) (string, []byte, bool) {
+	if len(key) < 1+consts.Uint16Len {
+		return "", nil, false
+	}
+	n := int(binary.BigEndian.Uint16(key[1:]))
+	rest := key[1+consts.Uint16Len:]
+	if len(rest) < n {
+		return "", nil, false
+	}
+	return string(rest[:n]), rest[n:], true
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+)
+
+// dbState exposes a database as state.Mutable so the setters can write to
+// something the checker can iterate.
+type dbState struct {
+	database.Database
+}
+
+func (s dbState) GetValue(_ context.Context, key []byte) ([]byte, error) {
+	return s.Get(key)
+}
+
+func (s dbState) Insert(_ context.Context, key []byte, value []byte) error {
+	return s.Put(key, value)
+}
+
+func (s dbState) Remove(_ context.Context, key []byte) error {
+	return s.Delete(key)
+}
+
+func TestCheckConsistency(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	mu := dbState{db}
+
+	tee := codectest.NewRandomAddress()
+	require.NoError(SetRegion(ctx, mu, "us-east", []codec.Address{tee, codectest.NewRandomAddress()}))
+	require.NoError(SetEnclave(ctx, mu, "us-east", tee[:], EnclaveActive, []byte{1}))
+	require.NoError(SetObject(ctx, mu, "counter", map[string][]byte{"code": {1}}))
+	require.NoError(db.Put(EventKey("1700000000", "counter"), []byte{1}))
+	require.NoError(SetObjectKV(ctx, mu, "counter", []byte("n"), []byte{1}))
+	require.NoError(SetBalance(ctx, mu, tee, 100))
+	require.NoError(CollectFee(ctx, mu, "us-east", 10))
+	require.NoError(AccrueReward(ctx, mu, "us-east", tee[:], 10, 5))
+	require.NoError(CheckConsistency(ctx, db, 100))
+
+	require.NoError(SetRegion(ctx, mu, "eu-west", []codec.Address{tee, tee}))
+	require.NoError(SetEnclave(ctx, mu, "ap-south", tee[:], EnclaveActive, []byte{1}))
+	require.NoError(db.Put(EventKey("1700000000", "missing"), []byte{1}))
+	require.NoError(SetObjectKV(ctx, mu, "missing", []byte("n"), []byte{1}))
+	require.NoError(SetBalance(ctx, mu, codectest.NewRandomAddress(), 1))
+	require.NoError(AccrueReward(ctx, mu, "eu-west", tee[:], 0, 5))
+
+	err := CheckConsistency(ctx, db, 100)
+	require.ErrorIs(err, ErrDuplicateRegionTEE)
+	require.ErrorIs(err, ErrOrphanEnclave)
+	require.ErrorIs(err, ErrOrphanEvent)
+	require.ErrorIs(err, ErrOrphanObjectKV)
+	require.ErrorIs(err, ErrSupplyExceeded)
+	require.ErrorIs(err, ErrRewardsExceedPool)
+}
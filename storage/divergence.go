@@ -0,0 +1,55 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Divergence records a dual execution whose two enclaves attested different
+// results for the same input. Neither result was applied.
+type Divergence struct {
+	Enclave     []byte `serialize:"true" json:"enclave"`
+	Digest      ids.ID `serialize:"true" json:"digest"`
+	PeerEnclave []byte `serialize:"true" json:"peer_enclave"`
+	PeerDigest  ids.ID `serialize:"true" json:"peer_digest"`
+	// ReportedAt is the block time, in unix milliseconds, of the execution
+	ReportedAt int64 `serialize:"true" json:"reported_at"`
+}
+
+// [divergencePrefix] + [regionID] + [actionID]
+func DivergenceKey(regionID string, actionID ids.ID) []byte {
+	return regionScopedKey(divergencePrefix, regionID, actionID[:])
+}
+
+// GetDivergence returns the divergence recorded by action [actionID] in
+// [regionID], or nil if there is none.
+func GetDivergence(ctx context.Context, im state.Immutable, regionID string, actionID ids.ID) (*Divergence, error) {
+	v, err := im.GetValue(ctx, DivergenceKey(regionID, actionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d Divergence
+	if err := codec.Unmarshal(v, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func SetDivergence(ctx context.Context, mu state.Mutable, regionID string, actionID ids.ID, d *Divergence) error {
+	v, err := codec.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, DivergenceKey(regionID, actionID), v)
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+const (
+	EnclaveInactive byte = 0
+	EnclaveActive   byte = 1
+)
+
+// regionScopedKey builds [prefix] + [len(regionID)] + [regionID] + [parts...].
+// The length prefix keeps keys of different regions from colliding.
+func regionScopedKey(prefix byte, regionID string, parts ...[]byte) []byte {
+	size := 1 + consts.Uint16Len + len(regionID)
+	for _, part := range parts {
+		size += len(part)
+	}
+	k := make([]byte, 0, size)
+	k = append(k, prefix)
+	k = binary.BigEndian.AppendUint16(k, uint16(len(regionID)))
+	k = append(k, regionID...)
+	for _, part := range parts {
+		k = append(k, part...)
+	}
+	return k
+}
+
+// [enclavePrefix] + [regionID] + [enclaveID]
+func EnclaveKey(regionID string, enclaveID []byte) []byte {
+	return regionScopedKey(enclavePrefix, regionID, enclaveID)
+}
+
+// [enclavePubKeyPrefix] + [regionID] + [enclaveID]
+func EnclavePubKeyKey(regionID string, enclaveID []byte) []byte {
+	return regionScopedKey(enclavePubKeyPrefix, regionID, enclaveID)
+}
+
+// [regionStatePrefix] + [regionID] + [key]
+func RegionStateKey(regionID string, key []byte) []byte {
+	return regionScopedKey(regionStatePrefix, regionID, key)
+}
+
+// GetRegionState returns the value a TEE execution stored under [key] of
+// [regionID], or nil if it is unset.
+func GetRegionState(ctx context.Context, im state.Immutable, regionID string, key []byte) ([]byte, error) {
+	v, err := im.GetValue(ctx, RegionStateKey(regionID, key))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(v, MaxRegionStateValueSize)
+}
+
+// SetRegionState stores [value] under [key] of [regionID], compressing
+// large values.
+func SetRegionState(ctx context.Context, mu state.Mutable, regionID string, key []byte, value []byte) error {
+	v, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, RegionStateKey(regionID, key), v)
+}
+
+// [regionRootPrefix] + [regionID]
+func RegionRootKey(regionID string) []byte {
+	return regionScopedKey(regionRootPrefix, regionID)
+}
+
+// GetRegionRoot returns the last state root attested for [regionID], or
+// [ids.Empty] if none was.
+func GetRegionRoot(ctx context.Context, im state.Immutable, regionID string) (ids.ID, error) {
+	v, err := im.GetValue(ctx, RegionRootKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return ids.Empty, nil
+	}
+	if err != nil {
+		return ids.Empty, err
+	}
+	return ids.ToID(v)
+}
+
+func SetRegionRoot(ctx context.Context, mu state.Mutable, regionID string, root ids.ID) error {
+	return mu.Insert(ctx, RegionRootKey(regionID), root[:])
+}
+
+// [execEventPrefix] + [regionID] + [contractAddr] + [index]
+func ExecEventKey(regionID string, contractAddr []byte, index uint64) []byte {
+	return regionScopedKey(execEventPrefix, regionID, contractAddr, binary.BigEndian.AppendUint64(nil, index))
+}
+
+// GetEnclave returns the status byte and public key of an enclave. A missing
+// enclave is reported as inactive with a nil key.
+func GetEnclave(
+	ctx context.Context,
+	im state.Immutable,
+	regionID string,
+	enclaveID []byte,
+) (byte, []byte, error) {
+	status, err := im.GetValue(ctx, EnclaveKey(regionID, enclaveID))
+	if errors.Is(err, database.ErrNotFound) {
+		return EnclaveInactive, nil, nil
+	}
+	if err != nil {
+		return EnclaveInactive, nil, err
+	}
+	if len(status) != 1 {
+		return EnclaveInactive, nil, nil
+	}
+	pubKey, err := im.GetValue(ctx, EnclavePubKeyKey(regionID, enclaveID))
+	if errors.Is(err, database.ErrNotFound) {
+		return status[0], nil, nil
+	}
+	if err != nil {
+		return EnclaveInactive, nil, err
+	}
+	return status[0], pubKey, nil
+}
+
+func SetEnclave(
+	ctx context.Context,
+	mu state.Mutable,
+	regionID string,
+	enclaveID []byte,
+	status byte,
+	pubKey []byte,
+) error {
+	if err := mu.Insert(ctx, EnclaveKey(regionID, enclaveID), []byte{status}); err != nil {
+		return err
+	}
+	return mu.Insert(ctx, EnclavePubKeyKey(regionID, enclaveID), pubKey)
+}
+
+// [enclaveExpiryPrefix] + [regionID] + [enclaveID]
+func EnclaveExpiryKey(regionID string, enclaveID []byte) []byte {
+	return regionScopedKey(enclaveExpiryPrefix, regionID, enclaveID)
+}
+
+// GetEnclaveExpiry returns the unix millisecond time after which an
+// enclave must re-attest, or 0 if it was registered without attestation
+// evidence and never expires.
+func GetEnclaveExpiry(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte) (uint64, error) {
+	return getUint64(ctx, im, EnclaveExpiryKey(regionID, enclaveID))
+}
+
+func SetEnclaveExpiry(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte, expiry uint64) error {
+	return setUint64(ctx, mu, EnclaveExpiryKey(regionID, enclaveID), expiry)
+}
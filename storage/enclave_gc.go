@@ -0,0 +1,90 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// [enclaveRetiredPrefix] + [regionID] + [enclaveID]
+func EnclaveRetiredKey(regionID string, enclaveID []byte) []byte {
+	return regionScopedKey(enclaveRetiredPrefix, regionID, enclaveID)
+}
+
+// GetEnclaveRetired returns the unix millisecond time an enclave was
+// removed from [regionID], or 0 if it was not.
+func GetEnclaveRetired(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte) (uint64, error) {
+	return getUint64(ctx, im, EnclaveRetiredKey(regionID, enclaveID))
+}
+
+// RetireEnclave deactivates an enclave removed from [regionID] at
+// [timestamp]. Its records are kept for audit until [DeleteEnclave]
+// collects them.
+func RetireEnclave(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte, timestamp int64) error {
+	if err := mu.Insert(ctx, EnclaveKey(regionID, enclaveID), []byte{EnclaveInactive}); err != nil {
+		return err
+	}
+	return setUint64(ctx, mu, EnclaveRetiredKey(regionID, enclaveID), uint64(timestamp))
+}
+
+// ReinstateEnclave reactivates a retired enclave added back to [regionID]
+// before its records were collected.
+func ReinstateEnclave(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte) error {
+	if err := mu.Insert(ctx, EnclaveKey(regionID, enclaveID), []byte{EnclaveActive}); err != nil {
+		return err
+	}
+	return mu.Remove(ctx, EnclaveRetiredKey(regionID, enclaveID))
+}
+
+// DeleteEnclave removes the records of an enclave of [regionID]: its
+// status, public key, type, attestation expiry, retirement and published
+// encryption key. It no longer counts towards the region's platform mix.
+// Accrued rewards are kept until claimed.
+func DeleteEnclave(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte) error {
+	t, err := GetEnclaveType(ctx, mu, regionID, enclaveID)
+	if err != nil {
+		return err
+	}
+	if t != "" {
+		n, err := GetPlatformCount(ctx, mu, regionID, t)
+		if err != nil {
+			return err
+		}
+		if err := setUint64(ctx, mu, PlatformCountKey(regionID, t), n-min(n, 1)); err != nil {
+			return err
+		}
+	}
+	keys, err := GetEncryptionKeys(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	kept := make([]EncryptionKey, 0, len(keys))
+	for _, k := range keys {
+		if !bytes.Equal(k.EnclaveID, enclaveID) {
+			kept = append(kept, k)
+		}
+	}
+	if len(kept) != len(keys) {
+		if err := SetEncryptionKeys(ctx, mu, regionID, kept); err != nil {
+			return err
+		}
+	}
+	for _, k := range [][]byte{
+		EnclaveKey(regionID, enclaveID),
+		EnclavePubKeyKey(regionID, enclaveID),
+		EnclaveTypeKey(regionID, enclaveID),
+		EnclaveExpiryKey(regionID, enclaveID),
+		EnclaveRetiredKey(regionID, enclaveID),
+	} {
+		if err := mu.Remove(ctx, k); err != nil && !errors.Is(err, database.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
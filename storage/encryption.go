@@ -0,0 +1,71 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// EncryptionKey is the X25519 key an enclave published when it registered,
+// which clients seal event parameters to.
+type EncryptionKey struct {
+	EnclaveID []byte `serialize:"true" json:"enclave_id"`
+	Key       []byte `serialize:"true" json:"key"`
+}
+
+type encryptionKeys struct {
+	Keys []EncryptionKey `serialize:"true"`
+}
+
+// [encryptionKeysPrefix] + [regionID]
+func EncryptionKeysKey(regionID string) []byte {
+	return regionScopedKey(encryptionKeysPrefix, regionID)
+}
+
+// GetEncryptionKeys returns the encryption keys published in [regionID],
+// oldest first.
+func GetEncryptionKeys(ctx context.Context, im state.Immutable, regionID string) ([]EncryptionKey, error) {
+	v, err := im.GetValue(ctx, EncryptionKeysKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys encryptionKeys
+	if err := codec.Unmarshal(v, &keys); err != nil {
+		return nil, err
+	}
+	return keys.Keys, nil
+}
+
+func SetEncryptionKeys(ctx context.Context, mu state.Mutable, regionID string, keys []EncryptionKey) error {
+	v, err := codec.Marshal(&encryptionKeys{Keys: keys})
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, EncryptionKeysKey(regionID), v)
+}
+
+// GetEncryptionKeysFromState returns the encryption keys published in
+// [regionID], oldest first.
+func GetEncryptionKeysFromState(ctx context.Context, f ReadState, regionID string) ([]EncryptionKey, error) {
+	values, errs := f(ctx, [][]byte{EncryptionKeysKey(regionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var keys encryptionKeys
+	if err := codec.Unmarshal(values[0], &keys); err != nil {
+		return nil, err
+	}
+	return keys.Keys, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// eventQueuePrefix starts the key of each event queued for a TEE
+const eventQueuePrefix = "event:"
+
+// EventCharge records what a queued event was charged for its execution,
+// so the units can be refunded if the event is cancelled before a TEE
+// runs it.
+type EventCharge struct {
+	// Sender is the account refunds go to; events sent without one are
+	// not refunded
+	Sender codec.Address `serialize:"true" json:"sender"`
+	// Units are the event units and tip charged for the execution
+	Units uint64 `serialize:"true" json:"units"`
+	// Tip is the part of Units that bought the event's priority class
+	Tip uint64 `serialize:"true" json:"tip"`
+}
+
+// EventQueueKey is the key of the event [eventID] queued for [objectID].
+// Events are keyed by their ID, so the action queuing one and the
+// execution completing it can declare the key before they run.
+func EventQueueKey(eventID ids.ID, objectID string) []byte {
+	return []byte(eventQueuePrefix + eventID.String() + ":" + objectID)
+}
+
+// ParseEventQueueKey returns the object the event queued under [key] is
+// for, and false if [key] is not an event queue key.
+func ParseEventQueueKey(key []byte) (string, bool) {
+	rest, ok := strings.CutPrefix(string(key), eventQueuePrefix)
+	if !ok {
+		return "", false
+	}
+	eventID, objectID, ok := strings.Cut(rest, ":")
+	if !ok || objectID == "" {
+		return "", false
+	}
+	if _, err := ids.FromString(eventID); err != nil {
+		return "", false
+	}
+	return objectID, true
+}
+
+// [eventChargePrefix] + [queueKey]
+func EventChargeKey(queueKey []byte) []byte {
+	k := make([]byte, 1+len(queueKey))
+	k[0] = eventChargePrefix
+	copy(k[1:], queueKey)
+	return k
+}
+
+// GetEventCharge returns the charge of the event queued under [queueKey],
+// or nil if none was recorded.
+func GetEventCharge(ctx context.Context, im state.Immutable, queueKey []byte) (*EventCharge, error) {
+	v, err := im.GetValue(ctx, EventChargeKey(queueKey))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c EventCharge
+	if err := codec.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func SetEventCharge(ctx context.Context, mu state.Mutable, queueKey []byte, c *EventCharge) error {
+	v, err := codec.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, EventChargeKey(queueKey), v)
+}
+
+func RemoveEventCharge(ctx context.Context, mu state.Mutable, queueKey []byte) error {
+	err := mu.Remove(ctx, EventChargeKey(queueKey))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil
+	}
+	return err
+}
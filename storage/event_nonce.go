@@ -0,0 +1,26 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// [eventNoncePrefix] + [len(objectID)] + [objectID] + [sender]
+func EventNonceKey(objectID string, sender codec.Address) []byte {
+	return regionScopedKey(eventNoncePrefix, objectID, sender[:])
+}
+
+// GetEventNonce returns the last nonce [sender] used for events to
+// [objectID], or zero if it has sent none with a nonce.
+func GetEventNonce(ctx context.Context, im state.Immutable, objectID string, sender codec.Address) (uint64, error) {
+	return getUint64(ctx, im, EventNonceKey(objectID, sender))
+}
+
+func SetEventNonce(ctx context.Context, mu state.Mutable, objectID string, sender codec.Address, nonce uint64) error {
+	return setUint64(ctx, mu, EventNonceKey(objectID, sender), nonce)
+}
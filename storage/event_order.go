@@ -0,0 +1,142 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrBrokenEventChain = errors.New("broken event chain")
+
+// eventLinkContext separates link hashes from other hashes of the chain.
+const eventLinkContext = "shuttle event link v1\x00"
+
+// EventLink places an event in the hash chain of the events sent to an
+// object. Each link commits to the hash of the one before it, so a link
+// proven in state proves the order of every event before it on the chain.
+type EventLink struct {
+	// Seq is the event's position on the chain, starting at 1
+	Seq     uint64 `serialize:"true" json:"seq"`
+	EventID ids.ID `serialize:"true" json:"event_id"`
+	// VerifiedTime is the chain's verified time, in unix seconds, the
+	// event was enqueued at
+	VerifiedTime uint64 `serialize:"true" json:"verified_time"`
+	// Prev is the hash of the previous link, empty for the first
+	Prev ids.ID `serialize:"true" json:"prev"`
+	// PrevEvent is the event of the previous link, which it is stored
+	// under
+	PrevEvent ids.ID `serialize:"true" json:"prev_event"`
+}
+
+// Hash is the SHA-256 of the link's fields, each fixed size, after a
+// domain separator. PrevEvent is committed to through Prev.
+func (l *EventLink) Hash() ids.ID {
+	h := sha256.New()
+	h.Write([]byte(eventLinkContext))
+	h.Write(binary.BigEndian.AppendUint64(nil, l.Seq))
+	h.Write(l.EventID[:])
+	h.Write(binary.BigEndian.AppendUint64(nil, l.VerifiedTime))
+	h.Write(l.Prev[:])
+	return ids.ID(h.Sum(nil))
+}
+
+// EventChainHead is the last link of an object's event chain.
+type EventChainHead struct {
+	Count uint64 `serialize:"true" json:"count"`
+	Hash  ids.ID `serialize:"true" json:"hash"`
+	// Latest is the event of the last link
+	Latest ids.ID `serialize:"true" json:"latest"`
+}
+
+// [eventLinkPrefix] + [len(objectID)] + [objectID] + [eventID]
+func EventLinkKey(objectID string, eventID ids.ID) []byte {
+	return regionScopedKey(eventLinkPrefix, objectID, eventID[:])
+}
+
+// [eventChainHeadPrefix] + [len(objectID)] + [objectID]
+func EventChainHeadKey(objectID string) []byte {
+	return regionScopedKey(eventChainHeadPrefix, objectID)
+}
+
+// GetEventLink returns the link of [eventID] on the event chain of
+// [objectID], or nil if it was never sent there.
+func GetEventLink(ctx context.Context, im state.Immutable, objectID string, eventID ids.ID) (*EventLink, error) {
+	v, err := im.GetValue(ctx, EventLinkKey(objectID, eventID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseEventLink(v)
+}
+
+func SetEventLink(ctx context.Context, mu state.Mutable, objectID string, l *EventLink) error {
+	v, err := codec.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, EventLinkKey(objectID, l.EventID), v)
+}
+
+// GetEventChainHead returns the head of the event chain of [objectID],
+// zero if no event was sent to it.
+func GetEventChainHead(ctx context.Context, im state.Immutable, objectID string) (*EventChainHead, error) {
+	v, err := im.GetValue(ctx, EventChainHeadKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return &EventChainHead{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseEventChainHead(v)
+}
+
+func SetEventChainHead(ctx context.Context, mu state.Mutable, objectID string, h *EventChainHead) error {
+	v, err := codec.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, EventChainHeadKey(objectID), v)
+}
+
+// ParseEventLink decodes a stored event link.
+func ParseEventLink(v []byte) (*EventLink, error) {
+	var l EventLink
+	if err := codec.Unmarshal(v, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// ParseEventChainHead decodes a stored event chain head.
+func ParseEventChainHead(v []byte) (*EventChainHead, error) {
+	var h EventChainHead
+	if err := codec.Unmarshal(v, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// VerifyEventChain checks that [links] are consecutive links of one event
+// chain, each committing to the hash of the one before it and enqueued no
+// earlier. If it holds, and the last link is proven in state, every link
+// was enqueued in the order given.
+func VerifyEventChain(links []*EventLink) error {
+	for i := 1; i < len(links); i++ {
+		prev, l := links[i-1], links[i]
+		if l.Seq != prev.Seq+1 || l.Prev != prev.Hash() || l.PrevEvent != prev.EventID || l.VerifiedTime < prev.VerifiedTime {
+			return ErrBrokenEventChain
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+// ExecLimits bound the resources one execution in a region may use. A zero
+// field takes its default from [consts].
+type ExecLimits struct {
+	// MaxMemoryPages bounds the linear memory, in 64 KiB Wasm pages
+	MaxMemoryPages uint32 `serialize:"true" json:"max_memory_pages"`
+	// MaxValueStack bounds the values on the Wasm value stack
+	MaxValueStack uint32 `serialize:"true" json:"max_value_stack"`
+	// MaxHostCallDepth bounds nesting of host function calls
+	MaxHostCallDepth uint32 `serialize:"true" json:"max_host_call_depth"`
+	// DeadlineMs bounds the wall-clock time the enclave runs the execution
+	DeadlineMs uint64 `serialize:"true" json:"deadline_ms"`
+}
+
+// WithDefaults returns [l] with each zero field set to its default.
+func (l ExecLimits) WithDefaults() ExecLimits {
+	if l.MaxMemoryPages == 0 {
+		l.MaxMemoryPages = consts.DefaultMaxMemoryPages
+	}
+	if l.MaxValueStack == 0 {
+		l.MaxValueStack = consts.DefaultMaxValueStack
+	}
+	if l.MaxHostCallDepth == 0 {
+		l.MaxHostCallDepth = consts.DefaultMaxHostCallDepth
+	}
+	if l.DeadlineMs == 0 {
+		l.DeadlineMs = consts.DefaultExecDeadlineMs
+	}
+	return l
+}
+
+// [execLimitsPrefix] + [regionID]
+func ExecLimitsKey(regionID string) []byte {
+	return regionScopedKey(execLimitsPrefix, regionID)
+}
+
+// GetExecLimits returns the limits of executions in [regionID], defaults
+// included.
+func GetExecLimits(ctx context.Context, im state.Immutable, regionID string) (ExecLimits, error) {
+	v, err := im.GetValue(ctx, ExecLimitsKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return ExecLimits{}.WithDefaults(), nil
+	}
+	if err != nil {
+		return ExecLimits{}, err
+	}
+	return ParseExecLimits(v)
+}
+
+// SetExecLimits replaces the limits of [regionID]. Zero limits remove them,
+// restoring the defaults.
+func SetExecLimits(ctx context.Context, mu state.Mutable, regionID string, l *ExecLimits) error {
+	if *l == (ExecLimits{}) {
+		return mu.Remove(ctx, ExecLimitsKey(regionID))
+	}
+	v, err := codec.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ExecLimitsKey(regionID), v)
+}
+
+// GetExecLimitsFromState returns the limits of executions in [regionID],
+// defaults included.
+func GetExecLimitsFromState(ctx context.Context, f ReadState, regionID string) (ExecLimits, error) {
+	values, errs := f(ctx, [][]byte{ExecLimitsKey(regionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return ExecLimits{}.WithDefaults(), nil
+	}
+	if errs[0] != nil {
+		return ExecLimits{}, errs[0]
+	}
+	return ParseExecLimits(values[0])
+}
+
+// ParseExecLimits decodes stored limits, defaults included.
+func ParseExecLimits(b []byte) (ExecLimits, error) {
+	var l ExecLimits
+	if err := codec.Unmarshal(b, &l); err != nil {
+		return ExecLimits{}, err
+	}
+	return l.WithDefaults(), nil
+}
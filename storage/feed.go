@@ -0,0 +1,140 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Feed is a data feed whose values enclaves of a region attest to.
+type Feed struct {
+	Name     string        `serialize:"true" json:"name"`
+	RegionID string        `serialize:"true" json:"region_id"`
+	Decimals uint8         `serialize:"true" json:"decimals"`
+	Owner    codec.Address `serialize:"true" json:"owner"`
+	// Signers are the enclaves of the region that may publish values
+	Signers [][]byte `serialize:"true" json:"signers"`
+	// DeviationBPS is how far, in basis points, a value must move from the
+	// latest one to be published before the heartbeat
+	DeviationBPS uint32 `serialize:"true" json:"deviation_bps"`
+	// Heartbeat is the time, in seconds, after which a value may be
+	// published however little it moved. Zero requires every value to
+	// deviate.
+	Heartbeat uint64 `serialize:"true" json:"heartbeat"`
+	// Round is the round of Latest; zero until a value is published
+	Round  uint64    `serialize:"true" json:"round"`
+	Latest FeedValue `serialize:"true" json:"latest"`
+}
+
+// FeedValue is a value of a feed, scaled by its decimals.
+type FeedValue struct {
+	Round uint64 `serialize:"true" json:"round"`
+	Value uint64 `serialize:"true" json:"value"`
+	// ObservedAt is the Roughtime median, in unix seconds, of the
+	// attestation that published the value
+	ObservedAt uint64 `serialize:"true" json:"observed_at"`
+	EnclaveID  []byte `serialize:"true" json:"enclave_id"`
+}
+
+// [feedPrefix] + [feedID]
+func FeedKey(feedID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = feedPrefix
+	copy(k[1:], feedID[:])
+	return k
+}
+
+// [feedValuePrefix] + [feedID] + [round]
+func FeedValueKey(feedID ids.ID, round uint64) []byte {
+	k := make([]byte, 1+ids.IDLen+consts.Uint64Len)
+	k[0] = feedValuePrefix
+	copy(k[1:], feedID[:])
+	binary.BigEndian.PutUint64(k[1+ids.IDLen:], round)
+	return k
+}
+
+// GetFeed returns [feedID], or nil if it was never registered.
+func GetFeed(ctx context.Context, im state.Immutable, feedID ids.ID) (*Feed, error) {
+	v, err := im.GetValue(ctx, FeedKey(feedID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f Feed
+	if err := codec.Unmarshal(v, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func SetFeed(ctx context.Context, mu state.Mutable, feedID ids.ID, f *Feed) error {
+	v, err := codec.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, FeedKey(feedID), v)
+}
+
+func SetFeedValue(ctx context.Context, mu state.Mutable, feedID ids.ID, v *FeedValue) error {
+	b, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, FeedValueKey(feedID, v.Round), b)
+}
+
+func DeleteFeedValue(ctx context.Context, mu state.Mutable, feedID ids.ID, round uint64) error {
+	return mu.Remove(ctx, FeedValueKey(feedID, round))
+}
+
+// GetFeedFromState returns [feedID], or nil if it was never registered.
+func GetFeedFromState(ctx context.Context, f ReadState, feedID ids.ID) (*Feed, error) {
+	values, errs := f(ctx, [][]byte{FeedKey(feedID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var feed Feed
+	if err := codec.Unmarshal(values[0], &feed); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetFeedValuesFromState returns the values of [feedID] for [rounds],
+// skipping rounds no longer kept.
+func GetFeedValuesFromState(ctx context.Context, f ReadState, feedID ids.ID, rounds []uint64) ([]FeedValue, error) {
+	keys := make([][]byte, len(rounds))
+	for i, round := range rounds {
+		keys[i] = FeedValueKey(feedID, round)
+	}
+	values, errs := f(ctx, keys)
+	feedValues := make([]FeedValue, 0, len(rounds))
+	for i := range rounds {
+		if errors.Is(errs[i], database.ErrNotFound) {
+			continue
+		}
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		var v FeedValue
+		if err := codec.Unmarshal(values[i], &v); err != nil {
+			return nil, err
+		}
+		feedValues = append(feedValues, v)
+	}
+	return feedValues, nil
+}
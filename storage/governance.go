@@ -0,0 +1,198 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var (
+	ErrProposalNotFound = errors.New("proposal not found")
+	ErrParamPending     = errors.New("parameter already has a pending value")
+	ErrVoteNotFound     = errors.New("vote not found")
+)
+
+type Proposal struct {
+	Proposer         codec.Address `serialize:"true" json:"proposer"`
+	Param            uint8         `serialize:"true" json:"param"`
+	Value            []byte        `serialize:"true" json:"value"`
+	VoteEnd          uint64        `serialize:"true" json:"vote_end"`
+	ActivationHeight uint64        `serialize:"true" json:"activation_height"`
+	Yes              uint64        `serialize:"true" json:"yes"`
+	No               uint64        `serialize:"true" json:"no"`
+	Executed         bool          `serialize:"true" json:"executed"`
+}
+
+// Param holds the active value of a governed parameter and, once a
+// proposal passes, the value that replaces it at [ActivationHeight].
+type Param struct {
+	Current          []byte `serialize:"true" json:"current"`
+	Pending          []byte `serialize:"true" json:"pending"`
+	ActivationHeight uint64 `serialize:"true" json:"activation_height"`
+	HasPending       bool   `serialize:"true" json:"has_pending"`
+}
+
+// Value returns the parameter value in effect at [height], or nil if the
+// parameter was never set and the compiled-in default applies.
+func (p *Param) Value(height uint64) []byte {
+	if p.HasPending && height >= p.ActivationHeight {
+		return p.Pending
+	}
+	return p.Current
+}
+
+// [proposalPrefix] + [proposalID]
+func ProposalKey(id ids.ID) []byte {
+	k := make([]byte, 0, 1+ids.IDLen)
+	k = append(k, proposalPrefix)
+	return append(k, id[:]...)
+}
+
+// [votePrefix] + [proposalID] + [voter]
+func VoteKey(id ids.ID, voter codec.Address) []byte {
+	k := make([]byte, 0, 1+ids.IDLen+codec.AddressLen)
+	k = append(k, votePrefix)
+	k = append(k, id[:]...)
+	return append(k, voter[:]...)
+}
+
+// [paramPrefix] + [paramID]
+func ParamKey(param uint8) []byte {
+	return []byte{paramPrefix, param}
+}
+
+// GetHeight reads the chain height maintained by hypersdk under [HeightKey].
+func GetHeight(ctx context.Context, im state.Immutable) (uint64, error) {
+	return getUint64(ctx, im, HeightKey())
+}
+
+func GetProposal(ctx context.Context, im state.Immutable, id ids.ID) (*Proposal, error) {
+	v, err := im.GetValue(ctx, ProposalKey(id))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrProposalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p Proposal
+	if err := codec.Unmarshal(v, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func SetProposal(ctx context.Context, mu state.Mutable, id ids.ID, p *Proposal) error {
+	v, err := codec.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ProposalKey(id), v)
+}
+
+// HasVoted reports whether [voter] already voted on proposal [id].
+func HasVoted(ctx context.Context, im state.Immutable, id ids.ID, voter codec.Address) (bool, error) {
+	_, err := im.GetValue(ctx, VoteKey(id, voter))
+	if errors.Is(err, database.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RecordVote stores the weight [voter] voted with on proposal [id].
+func RecordVote(ctx context.Context, mu state.Mutable, id ids.ID, voter codec.Address, weight uint64) error {
+	return setUint64(ctx, mu, VoteKey(id, voter), weight)
+}
+
+// TakeVote removes the vote of [voter] on proposal [id] and returns the
+// weight it was cast with.
+func TakeVote(ctx context.Context, mu state.Mutable, id ids.ID, voter codec.Address) (uint64, error) {
+	weight, err := getUint64(ctx, mu, VoteKey(id, voter))
+	if err != nil {
+		return 0, err
+	}
+	if weight == 0 {
+		return 0, ErrVoteNotFound
+	}
+	return weight, mu.Remove(ctx, VoteKey(id, voter))
+}
+
+func GetParam(ctx context.Context, im state.Immutable, param uint8) (*Param, error) {
+	v, err := im.GetValue(ctx, ParamKey(param))
+	if errors.Is(err, database.ErrNotFound) {
+		return &Param{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p Param
+	if err := codec.Unmarshal(v, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetParamValueFromState returns the value of [param] in effect at the
+// current height, or nil if it was never set.
+func GetParamValueFromState(ctx context.Context, f ReadState, param uint8) ([]byte, error) {
+	values, errs := f(ctx, [][]byte{HeightKey(), ParamKey(param)})
+	var height uint64
+	switch {
+	case errors.Is(errs[0], database.ErrNotFound):
+	case errs[0] != nil:
+		return nil, errs[0]
+	case len(values[0]) != 8:
+		return nil, fmt.Errorf("%w: unexpected height length %d", ErrInvalidBalance, len(values[0]))
+	default:
+		height = binary.BigEndian.Uint64(values[0])
+	}
+	if errors.Is(errs[1], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[1] != nil {
+		return nil, errs[1]
+	}
+	var p Param
+	if err := codec.Unmarshal(values[1], &p); err != nil {
+		return nil, err
+	}
+	return p.Value(height), nil
+}
+
+// ScheduleParam queues [value] to take effect at [activationHeight]. A
+// previously scheduled value that is already active at [height] is folded
+// into the current value first; one that is not yet active is kept, and
+// [value] rejected, so a passed proposal is never silently replaced.
+func ScheduleParam(
+	ctx context.Context,
+	mu state.Mutable,
+	param uint8,
+	value []byte,
+	height uint64,
+	activationHeight uint64,
+) error {
+	p, err := GetParam(ctx, mu, param)
+	if err != nil {
+		return err
+	}
+	if p.HasPending && height < p.ActivationHeight {
+		return fmt.Errorf("%w: (activation=%d, height=%d)", ErrParamPending, p.ActivationHeight, height)
+	}
+	p.Current = p.Value(height)
+	p.Pending = value
+	p.ActivationHeight = activationHeight
+	p.HasPending = true
+	v, err := codec.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ParamKey(param), v)
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+)
+
+// Diagnostics is the TEE-related state a node reports on its health
+// endpoints.
+type Diagnostics struct {
+	Regions    []RegionLiveness      `json:"regions"`
+	Collateral []CollateralFreshness `json:"collateral"`
+	// QueuedEvents counts events waiting for a TEE to run them
+	QueuedEvents uint64 `json:"queuedEvents"`
+	// PendingCallbacks counts event results not yet picked up by the
+	// callback object
+	PendingCallbacks uint64 `json:"pendingCallbacks"`
+}
+
+// RegionLiveness counts the enclaves of a region by whether they can
+// currently execute. Live enclaves are active and not past their
+// attestation expiry.
+type RegionLiveness struct {
+	RegionID string `json:"regionId"`
+	Live     uint32 `json:"live"`
+	Expired  uint32 `json:"expired"`
+	Inactive uint32 `json:"inactive"`
+}
+
+// CollateralFreshness reports whether the DCAP collateral of a platform
+// family can still be used.
+type CollateralFreshness struct {
+	FMSPC  string `json:"fmspc"`
+	Expiry int64  `json:"expiry"`
+	Stale  bool   `json:"stale"`
+}
+
+// Diagnose walks the raw state in [db] and reports enclave liveness per
+// region, collateral freshness and event backlogs as of [now], in unix
+// milliseconds.
+func Diagnose(ctx context.Context, db database.Iteratee, now int64) (*Diagnostics, error) {
+	d := &Diagnostics{}
+
+	regions := map[string]*RegionLiveness{}
+	if err := iteratePrefix(ctx, db, regionPrefix, func(key, _ []byte) {
+		regionID := string(key[1:])
+		regions[regionID] = &RegionLiveness{RegionID: regionID}
+	}); err != nil {
+		return nil, err
+	}
+	expiries := map[string]uint64{}
+	if err := iteratePrefix(ctx, db, enclaveExpiryPrefix, func(key, value []byte) {
+		if expiry, err := database.ParseUInt64(value); err == nil {
+			expiries[string(key[1:])] = expiry
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if err := iteratePrefix(ctx, db, enclavePrefix, func(key, value []byte) {
+		regionID, _, ok := splitRegionScopedKey(key)
+		if !ok {
+			return
+		}
+		region, ok := regions[regionID]
+		if !ok {
+			return
+		}
+		switch expiry := expiries[string(key[1:])]; {
+		case len(value) != 1 || value[0] != EnclaveActive:
+			region.Inactive++
+		case expiry != 0 && uint64(now) > expiry:
+			region.Expired++
+		default:
+			region.Live++
+		}
+	}); err != nil {
+		return nil, err
+	}
+	for _, region := range regions {
+		d.Regions = append(d.Regions, *region)
+	}
+	sort.Slice(d.Regions, func(i, j int) bool {
+		return d.Regions[i].RegionID < d.Regions[j].RegionID
+	})
+
+	if err := iteratePrefix(ctx, db, collateralPrefix, func(key, value []byte) {
+		var c StoredCollateral
+		if err := codec.Unmarshal(value, &c); err != nil {
+			return
+		}
+		d.Collateral = append(d.Collateral, CollateralFreshness{
+			FMSPC:  hex.EncodeToString(key[1:]),
+			Expiry: c.Expiry,
+			Stale:  now > c.Expiry,
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := iteratePrefix(ctx, db, eventPrefix, func([]byte, []byte) {
+		d.QueuedEvents++
+	}); err != nil {
+		return nil, err
+	}
+	if err := iteratePrefix(ctx, db, callbackEventPrefix, func([]byte, []byte) {
+		d.PendingCallbacks++
+	}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	mu := dbState{db}
+	const now = 1_700_000_000_000
+
+	tee := codectest.NewRandomAddress()
+	require.NoError(SetRegion(ctx, mu, "us-east", []codec.Address{tee}))
+	require.NoError(SetRegion(ctx, mu, "eu-west", []codec.Address{tee}))
+	require.NoError(SetEnclave(ctx, mu, "us-east", []byte{1}, EnclaveActive, []byte{1}))
+	require.NoError(SetEnclave(ctx, mu, "us-east", []byte{2}, EnclaveActive, []byte{2}))
+	require.NoError(SetEnclaveExpiry(ctx, mu, "us-east", []byte{2}, now-1))
+	require.NoError(SetEnclaveExpiry(ctx, mu, "us-east", []byte{1}, now+1))
+	require.NoError(SetEnclave(ctx, mu, "eu-west", []byte{1}, EnclaveInactive, []byte{1}))
+	require.NoError(SetCollateral(ctx, mu, []byte{0xaa}, &StoredCollateral{Expiry: now + 1}))
+	require.NoError(SetCollateral(ctx, mu, []byte{0xbb}, &StoredCollateral{Expiry: now - 1}))
+	require.NoError(db.Put(EventKey("1700000000", "counter"), []byte{1}))
+
+	d, err := Diagnose(ctx, db, now)
+	require.NoError(err)
+	require.Equal([]RegionLiveness{
+		{RegionID: "eu-west", Inactive: 1},
+		{RegionID: "us-east", Live: 1, Expired: 1},
+	}, d.Regions)
+	require.Equal([]CollateralFreshness{
+		{FMSPC: "aa", Expiry: now + 1},
+		{FMSPC: "bb", Expiry: now - 1, Stale: true},
+	}, d.Collateral)
+	require.Equal(uint64(1), d.QueuedEvents)
+	require.Zero(d.PendingCallbacks)
+}
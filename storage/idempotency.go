@@ -0,0 +1,56 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// IdempotencyRecord is kept for an event sent with an idempotency key, so
+// retries of it are recognized until it expires.
+type IdempotencyRecord struct {
+	EventID ids.ID `serialize:"true" json:"event_id"`
+	// Expiry is when, in unix seconds, the key may be used again
+	Expiry uint64 `serialize:"true" json:"expiry"`
+}
+
+// [idempotencyPrefix] + [len(objectID)] + [objectID] + [sender] + [key]
+func IdempotencyKey(objectID string, sender codec.Address, key string) []byte {
+	return regionScopedKey(idempotencyPrefix, objectID, sender[:], []byte(key))
+}
+
+func ParseIdempotencyRecord(v []byte) (*IdempotencyRecord, error) {
+	var r IdempotencyRecord
+	if err := codec.Unmarshal(v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetIdempotencyRecord returns the record of [key] for events from [sender]
+// to [objectID], or nil if the key was never used.
+func GetIdempotencyRecord(ctx context.Context, im state.Immutable, objectID string, sender codec.Address, key string) (*IdempotencyRecord, error) {
+	v, err := im.GetValue(ctx, IdempotencyKey(objectID, sender, key))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseIdempotencyRecord(v)
+}
+
+func SetIdempotencyRecord(ctx context.Context, mu state.Mutable, objectID string, sender codec.Address, key string, r *IdempotencyRecord) error {
+	v, err := codec.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, IdempotencyKey(objectID, sender, key), v)
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// FunctionSig is an exported function of an object's code: its name and
+// its Wasm parameter and result value types.
+type FunctionSig struct {
+	Name    string `serialize:"true" json:"name"`
+	Params  []byte `serialize:"true" json:"params"`
+	Results []byte `serialize:"true" json:"results"`
+}
+
+// Interface is a set of functions an object may declare it implements.
+type Interface struct {
+	Functions []FunctionSig `serialize:"true" json:"functions"`
+}
+
+// ID identifies [i] by the SHA-256 hash of its functions in name order, so
+// the same set of signatures has the same ID however it is listed.
+func (i *Interface) ID() ids.ID {
+	fns := slices.Clone(i.Functions)
+	slices.SortFunc(fns, func(a, b FunctionSig) int {
+		return bytes.Compare([]byte(a.Name), []byte(b.Name))
+	})
+	h := sha256.New()
+	for _, fn := range fns {
+		for _, part := range [][]byte{[]byte(fn.Name), fn.Params, fn.Results} {
+			h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(part))))
+			h.Write(part)
+		}
+	}
+	return ids.ID(h.Sum(nil))
+}
+
+// [interfacePrefix] + [interfaceID]
+func InterfaceKey(interfaceID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = interfacePrefix
+	copy(k[1:], interfaceID[:])
+	return k
+}
+
+// [interfaceIndexPrefix] + [regionID] + [interfaceID] + [objectID]
+func InterfaceIndexKey(regionID string, interfaceID ids.ID, objectID string) []byte {
+	return regionScopedKey(interfaceIndexPrefix, regionID, interfaceID[:], []byte(objectID))
+}
+
+// [interfaceIndexHeadPrefix] + [regionID] + [interfaceID]
+func InterfaceIndexHeadKey(regionID string, interfaceID ids.ID) []byte {
+	return regionScopedKey(interfaceIndexHeadPrefix, regionID, interfaceID[:])
+}
+
+// SetInterface stores [i] under its ID. Objects declaring the same
+// signatures store the same value.
+func SetInterface(ctx context.Context, mu state.Mutable, i *Interface) error {
+	v, err := codec.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, InterfaceKey(i.ID()), v)
+}
+
+// GetInterfaceFromState returns the interface [interfaceID], or nil if no
+// object ever declared it.
+func GetInterfaceFromState(ctx context.Context, f ReadState, interfaceID ids.ID) (*Interface, error) {
+	values, errs := f(ctx, [][]byte{InterfaceKey(interfaceID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var i Interface
+	if err := codec.Unmarshal(values[0], &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// GetInterfaceIndexFromState returns the IDs of at most [limit] objects in
+// [regionID] implementing [interfaceID], latest first, from [from] or, if
+// it is empty, from the latest. It also returns the object to continue
+// from, empty once all were returned, and how many implement it in all.
+func GetInterfaceIndexFromState(ctx context.Context, f ReadState, regionID string, interfaceID ids.ID, from string, limit uint64) ([]string, string, uint64, error) {
+	return walkIndexFromState(ctx, f, InterfaceIndexHeadKey(regionID, interfaceID), func(objectID string) []byte {
+		return InterfaceIndexKey(regionID, interfaceID, objectID)
+	}, from, limit)
+}
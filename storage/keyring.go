@@ -0,0 +1,128 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Purposes of an application key
+const (
+	// AppKeyEncryption keys are X25519 keys clients seal data to
+	AppKeyEncryption uint8 = 1
+	// AppKeyVerification keys are ed25519 keys that verify outputs the
+	// region signs
+	AppKeyVerification uint8 = 2
+)
+
+// Statuses of an application key version
+const (
+	AppKeyActive  uint8 = 1
+	AppKeyRetired uint8 = 2
+	AppKeyRevoked uint8 = 3
+)
+
+// AppKey is a version of an application-level key a region's enclaves
+// publish. Each name has at most one active version; rotation retires it,
+// and retired versions still verify what they signed until revoked.
+type AppKey struct {
+	Name      string `serialize:"true" json:"name"`
+	Purpose   uint8  `serialize:"true" json:"purpose"`
+	Version   uint64 `serialize:"true" json:"version"`
+	PublicKey []byte `serialize:"true" json:"public_key"`
+	Status    uint8  `serialize:"true" json:"status"`
+	// PublishedAt and ChangedAt are block times in unix seconds. ChangedAt
+	// is when the version was retired or revoked, zero while active.
+	PublishedAt uint64 `serialize:"true" json:"published_at"`
+	ChangedAt   uint64 `serialize:"true" json:"changed_at"`
+}
+
+// Keyring is the application keys of a region, in publication order.
+type Keyring struct {
+	Keys []AppKey `serialize:"true" json:"keys"`
+}
+
+// Latest returns the newest version of [name], or nil if it was never
+// published.
+func (k *Keyring) Latest(name string) *AppKey {
+	for i := len(k.Keys) - 1; i >= 0; i-- {
+		if k.Keys[i].Name == name {
+			return &k.Keys[i]
+		}
+	}
+	return nil
+}
+
+// Version returns [version] of [name], or nil if it is not kept.
+func (k *Keyring) Version(name string, version uint64) *AppKey {
+	for i := range k.Keys {
+		if k.Keys[i].Name == name && k.Keys[i].Version == version {
+			return &k.Keys[i]
+		}
+	}
+	return nil
+}
+
+// Named returns the kept versions of [name], oldest first.
+func (k *Keyring) Named(name string) []AppKey {
+	var keys []AppKey
+	for _, key := range k.Keys {
+		if key.Name == name {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// [keyringPrefix] + [regionID]
+func KeyringKey(regionID string) []byte {
+	return regionScopedKey(keyringPrefix, regionID)
+}
+
+// GetKeyring returns the keyring of [regionID], empty if no key was
+// published.
+func GetKeyring(ctx context.Context, im state.Immutable, regionID string) (*Keyring, error) {
+	v, err := im.GetValue(ctx, KeyringKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return &Keyring{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var k Keyring
+	if err := codec.Unmarshal(v, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func SetKeyring(ctx context.Context, mu state.Mutable, regionID string, k *Keyring) error {
+	v, err := codec.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, KeyringKey(regionID), v)
+}
+
+// GetKeyringFromState returns the keyring of [regionID], empty if no key
+// was published.
+func GetKeyringFromState(ctx context.Context, f ReadState, regionID string) (*Keyring, error) {
+	values, errs := f(ctx, [][]byte{KeyringKey(regionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return &Keyring{}, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var k Keyring
+	if err := codec.Unmarshal(values[0], &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
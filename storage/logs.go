@@ -0,0 +1,232 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+// BlockBloomBytes is the size of a block's bloom filter
+const BlockBloomBytes = 256
+
+var ErrInvalidBlockBloom = errors.New("invalid block bloom")
+
+// Log is a record a contract emits for clients to filter by topic. Unlike
+// events, logs are not queued for execution.
+type Log struct {
+	Topics []ids.ID `serialize:"true" json:"topics"`
+	Data   []byte   `serialize:"true" json:"data"`
+}
+
+// BlockLog is a log of an accepted block with where it was emitted.
+type BlockLog struct {
+	TxID     ids.ID `serialize:"true" json:"tx_id"`
+	RegionID string `serialize:"true" json:"region_id"`
+	Contract []byte `serialize:"true" json:"contract"`
+	// Index is the position of the log in its block
+	Index  uint32   `serialize:"true" json:"index"`
+	Topics []ids.ID `serialize:"true" json:"topics"`
+	Data   []byte   `serialize:"true" json:"data"`
+}
+
+type BlockLogs struct {
+	Height uint64     `serialize:"true" json:"height"`
+	Logs   []BlockLog `serialize:"true" json:"logs"`
+}
+
+// BlockBloom is a bloom filter of the regions and objects the successful
+// actions of a block acted on, and of the contracts and topics of its logs.
+// Each value sets three bits taken from its SHA-256 hash.
+type BlockBloom [BlockBloomBytes]byte
+
+func (b *BlockBloom) Add(v []byte) {
+	for _, bit := range bloomBits(v) {
+		b[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// Test reports whether [v] may have been added. False positives are
+// possible, false negatives are not.
+func (b *BlockBloom) Test(v []byte) bool {
+	for _, bit := range bloomBits(v) {
+		if b[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether nothing was added to [b].
+func (b *BlockBloom) Empty() bool {
+	return *b == BlockBloom{}
+}
+
+func (b BlockBloom) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(b[:])), nil
+}
+
+func (b *BlockBloom) UnmarshalText(text []byte) error {
+	s, ok := strings.CutPrefix(string(text), "0x")
+	if !ok || hex.DecodedLen(len(s)) != BlockBloomBytes {
+		return ErrInvalidBlockBloom
+	}
+	if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+		return ErrInvalidBlockBloom
+	}
+	return nil
+}
+
+func bloomBits(v []byte) [3]uint {
+	h := sha256.Sum256(v)
+	var bits [3]uint
+	for i := range bits {
+		bits[i] = uint(binary.BigEndian.Uint16(h[2*i:])) % (BlockBloomBytes * 8)
+	}
+	return bits
+}
+
+// AddLogs adds the regions, contracts and topics of [logs] to [b].
+func (b *BlockBloom) AddLogs(logs []BlockLog) {
+	for _, log := range logs {
+		b.Add([]byte(log.RegionID))
+		b.Add(log.Contract)
+		for _, topic := range log.Topics {
+			b.Add(topic[:])
+		}
+	}
+}
+
+// LogFilter selects logs by region, contract and topics. Empty fields, and
+// topics set to ids.Empty, match anything. Topics match by position.
+type LogFilter struct {
+	RegionID string   `json:"region_id"`
+	Contract []byte   `json:"contract"`
+	Topics   []ids.ID `json:"topics"`
+}
+
+// MayMatch reports whether a block with [bloom] may have logs matching [f].
+func (f *LogFilter) MayMatch(bloom *BlockBloom) bool {
+	if f.RegionID != "" && !bloom.Test([]byte(f.RegionID)) {
+		return false
+	}
+	if len(f.Contract) > 0 && !bloom.Test(f.Contract) {
+		return false
+	}
+	for _, topic := range f.Topics {
+		if topic != ids.Empty && !bloom.Test(topic[:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether [log] matches [f].
+func (f *LogFilter) Matches(log *BlockLog) bool {
+	if f.RegionID != "" && f.RegionID != log.RegionID {
+		return false
+	}
+	if len(f.Contract) > 0 && !bytes.Equal(f.Contract, log.Contract) {
+		return false
+	}
+	if len(f.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, topic := range f.Topics {
+		if topic != ids.Empty && topic != log.Topics[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// [blockLogsPrefix] + [height]
+func BlockLogsKey(height uint64) []byte {
+	return heightKeyed(blockLogsPrefix, height)
+}
+
+// [blockBloomPrefix] + [height]
+func BlockBloomKey(height uint64) []byte {
+	return heightKeyed(blockBloomPrefix, height)
+}
+
+func heightKeyed(prefix byte, height uint64) []byte {
+	k := make([]byte, 1+consts.Uint64Len)
+	k[0] = prefix
+	binary.BigEndian.PutUint64(k[1:], height)
+	return k
+}
+
+// PutBlockLogs stores the logs of a block and its bloom filter, and drops
+// those of the block that falls out of the last [retain]. Blocks without
+// logs, or with an empty filter, do not store them. Like summaries, logs
+// are written as blocks are accepted rather than by an action.
+func PutBlockLogs(db database.KeyValueWriterDeleter, l *BlockLogs, bloom *BlockBloom, retain uint64) error {
+	if len(l.Logs) > 0 {
+		v, err := codec.Marshal(l)
+		if err != nil {
+			return err
+		}
+		if err := db.Put(BlockLogsKey(l.Height), v); err != nil {
+			return err
+		}
+	}
+	if !bloom.Empty() {
+		if err := db.Put(BlockBloomKey(l.Height), bloom[:]); err != nil {
+			return err
+		}
+	}
+	if retain == 0 || l.Height < retain {
+		return nil
+	}
+	if err := db.Delete(BlockLogsKey(l.Height - retain)); err != nil {
+		return err
+	}
+	return db.Delete(BlockBloomKey(l.Height - retain))
+}
+
+// GetBlockBloomFromState returns the bloom filter of the block at
+// [height], or nil if it is empty or no longer kept.
+func GetBlockBloomFromState(ctx context.Context, f ReadState, height uint64) (*BlockBloom, error) {
+	values, errs := f(ctx, [][]byte{BlockBloomKey(height)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	if len(values[0]) != BlockBloomBytes {
+		return nil, ErrInvalidBlockBloom
+	}
+	var b BlockBloom
+	copy(b[:], values[0])
+	return &b, nil
+}
+
+// GetBlockLogsFromState returns the logs of the block at [height], or nil
+// if it has no logs or they are no longer kept.
+func GetBlockLogsFromState(ctx context.Context, f ReadState, height uint64) (*BlockLogs, error) {
+	values, errs := f(ctx, [][]byte{BlockLogsKey(height)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var l BlockLogs
+	if err := codec.Unmarshal(values[0], &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
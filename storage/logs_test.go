@@ -0,0 +1,99 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockLogs(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	read := func(_ context.Context, keys [][]byte) ([][]byte, []error) {
+		values, errs := make([][]byte, len(keys)), make([]error, len(keys))
+		for i, key := range keys {
+			values[i], errs[i] = db.Get(key)
+		}
+		return values, errs
+	}
+
+	transfer, approval := ids.ID{1}, ids.ID{2}
+	for height := uint64(1); height <= 4; height++ {
+		l := &BlockLogs{
+			Height: height,
+			Logs: []BlockLog{{
+				RegionID: "us-east",
+				Contract: []byte("token"),
+				Topics:   []ids.ID{transfer, {byte(height)}},
+				Data:     []byte{byte(height)},
+			}},
+		}
+		bloom := new(BlockBloom)
+		bloom.Add([]byte("vault"))
+		bloom.AddLogs(l.Logs)
+		require.NoError(PutBlockLogs(db, l, bloom, 3))
+	}
+
+	// Only the last three blocks are kept
+	bloom, err := GetBlockBloomFromState(ctx, read, 1)
+	require.NoError(err)
+	require.Nil(bloom)
+	logs, err := GetBlockLogsFromState(ctx, read, 1)
+	require.NoError(err)
+	require.Nil(logs)
+
+	bloom, err = GetBlockBloomFromState(ctx, read, 4)
+	require.NoError(err)
+	require.NotNil(bloom)
+	logs, err = GetBlockLogsFromState(ctx, read, 4)
+	require.NoError(err)
+	require.Len(logs.Logs, 1)
+
+	// The bloom filter never rules out a matching block
+	for _, f := range []LogFilter{
+		{},
+		{RegionID: "us-east"},
+		{Contract: []byte("token")},
+		{Topics: []ids.ID{transfer}},
+		{Topics: []ids.ID{ids.Empty, {4}}},
+	} {
+		require.True(f.MayMatch(bloom))
+		require.True(f.Matches(&logs.Logs[0]))
+	}
+	for _, f := range []LogFilter{
+		{RegionID: "eu-west"},
+		{Contract: []byte("nft")},
+		{Topics: []ids.ID{approval}},
+		{Topics: []ids.ID{{4}}},
+		{Topics: []ids.ID{transfer, {4}, ids.Empty}},
+	} {
+		require.False(f.Matches(&logs.Logs[0]))
+	}
+	require.False((&LogFilter{Topics: []ids.ID{approval}}).MayMatch(&BlockBloom{}))
+	require.True(bloom.Test([]byte("vault")))
+
+	// A block without logs keeps its filter
+	bloom = new(BlockBloom)
+	bloom.Add([]byte("vault"))
+	require.NoError(PutBlockLogs(db, &BlockLogs{Height: 5}, bloom, 3))
+	stored, err := GetBlockBloomFromState(ctx, read, 5)
+	require.NoError(err)
+	require.Equal(bloom, stored)
+	logs, err = GetBlockLogsFromState(ctx, read, 5)
+	require.NoError(err)
+	require.Nil(logs)
+
+	text, err := bloom.MarshalText()
+	require.NoError(err)
+	var decoded BlockBloom
+	require.NoError(decoded.UnmarshalText(text))
+	require.Equal(*bloom, decoded)
+	require.ErrorIs(decoded.UnmarshalText(text[:len(text)-2]), ErrInvalidBlockBloom)
+}
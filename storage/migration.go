@@ -0,0 +1,273 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+// BaseSchemaVersion is the layout described at the top of storage.go. State
+// written before versioning existed has no version key and is treated as
+// this version.
+const BaseSchemaVersion uint32 = 1
+
+var (
+	ErrMigrationOrder    = errors.New("migrations must increase the schema version by one")
+	ErrSchemaTooNew      = errors.New("state schema is newer than this binary")
+	ErrMigrationPending  = errors.New("lazy migration still in progress")
+	ErrCorruptSchemaInfo = errors.New("corrupt schema version record")
+)
+
+// MigrationDB is the raw state a migration reads and rewrites.
+type MigrationDB interface {
+	database.Iteratee
+	database.KeyValueReaderWriterDeleter
+}
+
+// RewriteFunc maps a record to its new key and value. Returning a nil key
+// deletes the record.
+type RewriteFunc func(key, value []byte) ([]byte, []byte, error)
+
+// Migration moves state from Version-1 to Version.
+//
+// Eager, if set, runs to completion at startup before any block executes.
+// Lazy, if set, rewrites the records under Prefix a batch at a time as
+// blocks are accepted; until it finishes, readers of Prefix must accept both
+// layouts. Later migrations wait for a lazy migration to finish.
+type Migration struct {
+	Version uint32
+	Name    string
+	Eager   func(ctx context.Context, db MigrationDB) error
+	Prefix  byte
+	Lazy    RewriteFunc
+}
+
+// Migrations is an ordered registry of schema migrations.
+type Migrations struct {
+	migrations []Migration
+}
+
+// DefaultMigrations is run by the VM at startup.
+var DefaultMigrations = &Migrations{}
+
+// Register adds [m], which must produce the version after the latest
+// registered one.
+func (r *Migrations) Register(m Migration) error {
+	if m.Version != r.Latest()+1 {
+		return fmt.Errorf("%w: %s targets %d after %d", ErrMigrationOrder, m.Name, m.Version, r.Latest())
+	}
+	r.migrations = append(r.migrations, m)
+	return nil
+}
+
+// Latest returns the schema version the registry migrates to.
+func (r *Migrations) Latest() uint32 {
+	if len(r.migrations) == 0 {
+		return BaseSchemaVersion
+	}
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+// Run applies every pending migration in order. Eager migrations must be
+// idempotent, since a crash before the version is recorded reruns them. Run
+// stops at the first unfinished lazy migration and returns
+// [ErrMigrationPending]; [Migrations.Step] then continues it.
+func (r *Migrations) Run(ctx context.Context, db MigrationDB) error {
+	version, err := GetSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > r.Latest() {
+		return fmt.Errorf("%w: (state=%d, binary=%d)", ErrSchemaTooNew, version, r.Latest())
+	}
+	for _, m := range r.pending(version) {
+		started, err := db.Has(migrationCursorKey(m.Version))
+		if err != nil {
+			return err
+		}
+		if m.Eager != nil && !started {
+			if err := m.Eager(ctx, db); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+		}
+		if m.Lazy != nil {
+			if !started {
+				if err := db.Put(migrationCursorKey(m.Version), []byte{m.Prefix}); err != nil {
+					return err
+				}
+			}
+			return ErrMigrationPending
+		}
+		if err := setSchemaVersion(db, m.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Step rewrites up to [limit] records of the current lazy migration and
+// runs the migrations after it once it completes. It is a no-op when
+// nothing is pending.
+func (r *Migrations) Step(ctx context.Context, db MigrationDB, limit int) error {
+	version, err := GetSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	pending := r.pending(version)
+	if len(pending) == 0 {
+		return nil
+	}
+	m := pending[0]
+	started, err := db.Has(migrationCursorKey(m.Version))
+	if err != nil {
+		return err
+	}
+	if m.Lazy == nil || !started {
+		return ignorePending(r.Run(ctx, db))
+	}
+	done, err := step(ctx, db, m, limit)
+	if err != nil || !done {
+		return err
+	}
+	if err := setSchemaVersion(db, m.Version); err != nil {
+		return err
+	}
+	return ignorePending(r.Run(ctx, db))
+}
+
+func ignorePending(err error) error {
+	if errors.Is(err, ErrMigrationPending) {
+		return nil
+	}
+	return err
+}
+
+func (r *Migrations) pending(version uint32) []Migration {
+	i := sort.Search(len(r.migrations), func(i int) bool {
+		return r.migrations[i].Version > version
+	})
+	return r.migrations[i:]
+}
+
+// step rewrites up to [limit] records of a lazy migration starting at its
+// cursor, and reports whether the prefix is fully migrated. Lazy rewrites
+// must not move records to later keys under the same prefix, or they would
+// be rewritten twice.
+func step(ctx context.Context, db MigrationDB, m Migration, limit int) (bool, error) {
+	cursorKey := migrationCursorKey(m.Version)
+	start, err := db.Get(cursorKey)
+	if err != nil {
+		return false, err
+	}
+	last, n, err := rewriteRange(ctx, db, m.Prefix, start, limit, m.Lazy)
+	if err != nil {
+		return false, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	if limit > 0 && n == limit {
+		// Resume strictly after the last rewritten key
+		return false, db.Put(cursorKey, append(last, 0))
+	}
+	if err := db.Delete(cursorKey); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RewritePrefix eagerly applies [f] to every record under [prefix].
+func RewritePrefix(ctx context.Context, db MigrationDB, prefix byte, f RewriteFunc) error {
+	_, _, err := rewriteRange(ctx, db, prefix, []byte{prefix}, 0, f)
+	return err
+}
+
+// rewriteRange applies [f] to up to [limit] records (all if not positive)
+// under [prefix] starting at [start]. Writes are buffered until iteration
+// ends so the iterator never observes its own rewrites.
+func rewriteRange(
+	ctx context.Context,
+	db MigrationDB,
+	prefix byte,
+	start []byte,
+	limit int,
+	f RewriteFunc,
+) ([]byte, int, error) {
+	type write struct {
+		oldKey, newKey, value []byte
+	}
+	var (
+		writes []write
+		last   []byte
+	)
+	it := db.NewIteratorWithStartAndPrefix(start, []byte{prefix})
+	for (limit <= 0 || len(writes) < limit) && it.Next() {
+		if err := ctx.Err(); err != nil {
+			it.Release()
+			return nil, 0, err
+		}
+		key := bytes.Clone(it.Key())
+		newKey, value, err := f(key, bytes.Clone(it.Value()))
+		if err != nil {
+			it.Release()
+			return nil, 0, err
+		}
+		writes = append(writes, write{oldKey: key, newKey: newKey, value: value})
+		last = key
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, w := range writes {
+		if !bytes.Equal(w.oldKey, w.newKey) {
+			if err := db.Delete(w.oldKey); err != nil {
+				return nil, 0, err
+			}
+		}
+		if w.newKey == nil {
+			continue
+		}
+		if err := db.Put(w.newKey, w.value); err != nil {
+			return nil, 0, err
+		}
+	}
+	return last, len(writes), nil
+}
+
+// [schemaVersionPrefix]
+func SchemaVersionKey() []byte {
+	return []byte{schemaVersionPrefix}
+}
+
+// [migrationCursorPrefix] + [version]
+func migrationCursorKey(version uint32) []byte {
+	return binary.BigEndian.AppendUint32([]byte{migrationCursorPrefix}, version)
+}
+
+// GetSchemaVersion returns the schema version of the state in [db].
+func GetSchemaVersion(db database.KeyValueReader) (uint32, error) {
+	v, err := db.Get(SchemaVersionKey())
+	if errors.Is(err, database.ErrNotFound) {
+		return BaseSchemaVersion, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != consts.Uint32Len {
+		return 0, ErrCorruptSchemaInfo
+	}
+	return binary.BigEndian.Uint32(v), nil
+}
+
+func setSchemaVersion(db database.KeyValueWriter, version uint32) error {
+	return db.Put(SchemaVersionKey(), binary.BigEndian.AppendUint32(nil, version))
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+)
+
+// countPrefixRegions rewrites region records from the current concatenated
+// layout to one led by a uint16 TEE count.
+func countPrefixRegions(key, value []byte) ([]byte, []byte, error) {
+	count := uint16(len(value) / codec.AddressLen)
+	return key, append(binary.BigEndian.AppendUint16(nil, count), value...), nil
+}
+
+func seedRegions(t *testing.T, mu dbState, n int) map[string][]codec.Address {
+	regions := make(map[string][]codec.Address, n)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("region-%d", i)
+		tees := []codec.Address{codectest.NewRandomAddress(), codectest.NewRandomAddress()}
+		require.NoError(t, SetRegion(context.Background(), mu, id, tees))
+		regions[id] = tees
+	}
+	return regions
+}
+
+func requireCountPrefixed(t *testing.T, mu dbState, regions map[string][]codec.Address) {
+	for id, tees := range regions {
+		v, err := mu.Get(RegionKey(id))
+		require.NoError(t, err)
+		require.Equal(t, uint16(len(tees)), binary.BigEndian.Uint16(v))
+		require.Equal(t, tees[0][:], v[2:2+codec.AddressLen])
+	}
+}
+
+func TestEagerMigration(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	mu := dbState{memdb.New()}
+	regions := seedRegions(t, mu, 3)
+
+	migrations := &Migrations{}
+	require.NoError(migrations.Register(Migration{
+		Version: 2,
+		Name:    "count-prefix regions",
+		Eager: func(ctx context.Context, db MigrationDB) error {
+			return RewritePrefix(ctx, db, regionPrefix, countPrefixRegions)
+		},
+	}))
+	require.ErrorIs(migrations.Register(Migration{Version: 4}), ErrMigrationOrder)
+
+	version, err := GetSchemaVersion(mu)
+	require.NoError(err)
+	require.Equal(BaseSchemaVersion, version)
+
+	require.NoError(migrations.Run(ctx, mu))
+	requireCountPrefixed(t, mu, regions)
+	version, err = GetSchemaVersion(mu)
+	require.NoError(err)
+	require.Equal(uint32(2), version)
+
+	// Already at the latest version, so nothing is rewritten twice
+	require.NoError(migrations.Run(ctx, mu))
+	requireCountPrefixed(t, mu, regions)
+
+	require.ErrorIs((&Migrations{}).Run(ctx, mu), ErrSchemaTooNew)
+}
+
+func TestLazyMigration(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	mu := dbState{memdb.New()}
+	regions := seedRegions(t, mu, 5)
+
+	var eagerRan bool
+	migrations := &Migrations{}
+	require.NoError(migrations.Register(Migration{
+		Version: 2,
+		Name:    "count-prefix regions",
+		Prefix:  regionPrefix,
+		Lazy:    countPrefixRegions,
+	}))
+	require.NoError(migrations.Register(Migration{
+		Version: 3,
+		Name:    "after lazy",
+		Eager: func(context.Context, MigrationDB) error {
+			eagerRan = true
+			return nil
+		},
+	}))
+
+	require.ErrorIs(migrations.Run(ctx, mu), ErrMigrationPending)
+	require.ErrorIs(migrations.Run(ctx, mu), ErrMigrationPending)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(migrations.Step(ctx, mu, 2))
+		require.False(eagerRan)
+	}
+	require.NoError(migrations.Step(ctx, mu, 2))
+	require.True(eagerRan)
+	requireCountPrefixed(t, mu, regions)
+
+	version, err := GetSchemaVersion(mu)
+	require.NoError(err)
+	require.Equal(uint32(3), version)
+	has, err := mu.Has(migrationCursorKey(2))
+	require.NoError(err)
+	require.False(has)
+}
+
+func TestScopeNativeBalances(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	mu := dbState{memdb.New()}
+
+	// A balance in the layout before assets, and one already scoped
+	legacy := codectest.NewRandomAddress()
+	key := append([]byte{balancePrefix}, legacy[:]...)
+	key = binary.BigEndian.AppendUint16(key, BalanceChunks)
+	require.NoError(mu.Put(key, binary.BigEndian.AppendUint64(nil, 7)))
+	scoped := codectest.NewRandomAddress()
+	require.NoError(SetBalance(ctx, mu, scoped, 9))
+
+	require.NoError(DefaultMigrations.Run(ctx, mu))
+	has, err := mu.Has(key)
+	require.NoError(err)
+	require.False(has)
+	for addr, want := range map[codec.Address]uint64{legacy: 7, scoped: 9} {
+		balance, err := GetBalance(ctx, mu, addr)
+		require.NoError(err)
+		require.Equal(want, balance)
+	}
+	version, err := GetSchemaVersion(mu)
+	require.NoError(err)
+	require.Equal(AssetBalancesVersion, version)
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Kinds of name targets
+const (
+	NameRegion uint8 = 1
+	NameObject uint8 = 2
+)
+
+// NameRecord maps a human-readable name to a region or object ID.
+type NameRecord struct {
+	Owner  codec.Address `serialize:"true" json:"owner"`
+	Kind   uint8         `serialize:"true" json:"kind"`
+	Target string        `serialize:"true" json:"target"`
+	// ExpiresAt is the block time, in unix seconds, from which the name
+	// no longer resolves
+	ExpiresAt uint64 `serialize:"true" json:"expires_at"`
+}
+
+// [namePrefix] + [name]
+func NameKey(name string) []byte {
+	k := make([]byte, 1+len(name))
+	k[0] = namePrefix
+	copy(k[1:], name)
+	return k
+}
+
+// GetName returns the record of [name], or nil if it was never registered.
+func GetName(ctx context.Context, im state.Immutable, name string) (*NameRecord, error) {
+	v, err := im.GetValue(ctx, NameKey(name))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseName(v)
+}
+
+func SetName(ctx context.Context, mu state.Mutable, name string, r *NameRecord) error {
+	v, err := codec.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, NameKey(name), v)
+}
+
+// GetNameFromState returns the record of [name], or nil if it was never
+// registered.
+func GetNameFromState(ctx context.Context, f ReadState, name string) (*NameRecord, error) {
+	values, errs := f(ctx, [][]byte{NameKey(name)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return ParseName(values[0])
+}
+
+// ParseName decodes a stored name record.
+func ParseName(v []byte) (*NameRecord, error) {
+	var r NameRecord
+	if err := codec.Unmarshal(v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+)
+
+var ErrNitroRootMissing = errors.New("nitro root certificate not initialized")
+
+// [nitroRootPrefix]
+func NitroRootKey() []byte {
+	return []byte{nitroRootPrefix}
+}
+
+// [nitroPolicyPrefix] + [regionID]
+func NitroPolicyKey(regionID string) []byte {
+	return regionScopedKey(nitroPolicyPrefix, regionID)
+}
+
+// GetNitroRoot returns the root certificate Nitro attestation documents
+// must chain up to. It is written once from genesis.
+func GetNitroRoot(ctx context.Context, im state.Immutable) (*x509.Certificate, error) {
+	v, err := im.GetValue(ctx, NitroRootKey())
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, ErrNitroRootMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(v)
+}
+
+func SetNitroRoot(ctx context.Context, mu state.Mutable, der []byte) error {
+	return mu.Insert(ctx, NitroRootKey(), der)
+}
+
+// GetNitroPolicy returns the PCR policy of [regionID], or nil if the region
+// does not accept Nitro enclaves.
+func GetNitroPolicy(ctx context.Context, im state.Immutable, regionID string) (*attestation.NitroPolicy, error) {
+	v, err := im.GetValue(ctx, NitroPolicyKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p attestation.NitroPolicy
+	if err := codec.Unmarshal(v, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetNitroPolicy replaces the PCR policy of [regionID]. An empty policy
+// stops the region accepting new Nitro enclaves.
+func SetNitroPolicy(ctx context.Context, mu state.Mutable, regionID string, p *attestation.NitroPolicy) error {
+	if len(p.PCRs) == 0 {
+		return mu.Remove(ctx, NitroPolicyKey(regionID))
+	}
+	v, err := codec.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, NitroPolicyKey(regionID), v)
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// [objectCreatorPrefix] + [len(objectID)] + [objectID]
+func ObjectCreatorKey(objectID string) []byte {
+	return regionScopedKey(objectCreatorPrefix, objectID)
+}
+
+// GetObjectCreator returns the account that created [objectID], or the
+// empty address if none is recorded.
+func GetObjectCreator(ctx context.Context, im state.Immutable, objectID string) (codec.Address, error) {
+	v, err := im.GetValue(ctx, ObjectCreatorKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return codec.EmptyAddress, nil
+	}
+	if err != nil {
+		return codec.EmptyAddress, err
+	}
+	return codec.ToAddress(v)
+}
+
+func SetObjectCreator(ctx context.Context, mu state.Mutable, objectID string, creator codec.Address) error {
+	return mu.Insert(ctx, ObjectCreatorKey(objectID), creator[:])
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var (
+	ErrKVKeyTooLarge   = errors.New("object kv key exceeds maximum")
+	ErrKVValueTooLarge = errors.New("object kv value exceeds maximum")
+	ErrKVEmptyKey      = errors.New("object kv key is empty")
+)
+
+// [objectKVPrefix] + [len(objectID)] + [objectID] + [key]
+//
+// The length prefix keeps one object's keys from running into another's,
+// the same layout region-scoped records use.
+func ObjectKVKey(objectID string, key []byte) []byte {
+	return regionScopedKey(objectKVPrefix, objectID, key)
+}
+
+// ObjectKVPrefix is the common prefix of every key of [objectID].
+func ObjectKVPrefix(objectID string) []byte {
+	return regionScopedKey(objectKVPrefix, objectID)
+}
+
+func checkObjectKV(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrKVEmptyKey
+	}
+	if len(key) > consts.MaxObjectKVKeySize {
+		return fmt.Errorf("%w: %d bytes", ErrKVKeyTooLarge, len(key))
+	}
+	if len(value) > consts.MaxObjectKVValueSize {
+		return fmt.Errorf("%w: %d bytes", ErrKVValueTooLarge, len(value))
+	}
+	return nil
+}
+
+// GetObjectKV returns the value stored under [key] of [objectID]. The second
+// return value is false if the key is not set.
+func GetObjectKV(
+	ctx context.Context,
+	im state.Immutable,
+	objectID string,
+	key []byte,
+) ([]byte, bool, error) {
+	v, err := im.GetValue(ctx, ObjectKVKey(objectID, key))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	v, err = decodeValue(v, consts.MaxObjectKVValueSize)
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// SetObjectKV stores [value] under [key] of [objectID], compressing large
+// values. An empty value deletes the key.
+func SetObjectKV(
+	ctx context.Context,
+	mu state.Mutable,
+	objectID string,
+	key []byte,
+	value []byte,
+) error {
+	if err := checkObjectKV(key, value); err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return mu.Remove(ctx, ObjectKVKey(objectID, key))
+	}
+	v, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ObjectKVKey(objectID, key), v)
+}
+
+// IterateObjectKV calls [f] with every key and value of [objectID] in key
+// order. It reads raw state, so it is meant for migrations and tooling
+// rather than action execution.
+func IterateObjectKV(
+	ctx context.Context,
+	db database.Iteratee,
+	objectID string,
+	f func(key, value []byte) error,
+) error {
+	prefix := ObjectKVPrefix(objectID)
+	return iterateAll(ctx, db, prefix, func(key, value []byte) error {
+		v, err := decodeValue(value, consts.MaxObjectKVValueSize)
+		if err != nil {
+			return err
+		}
+		return f(bytes.Clone(key[len(prefix):]), bytes.Clone(v))
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestObjectKV(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	db := memdb.New()
+	mu := dbState{db}
+
+	require.NoError(SetObjectKV(ctx, mu, "counter", []byte("b"), []byte{2}))
+	require.NoError(SetObjectKV(ctx, mu, "counter", []byte("a"), []byte{1}))
+	require.NoError(SetObjectKV(ctx, mu, "counter2", []byte("a"), []byte{3}))
+
+	v, ok, err := GetObjectKV(ctx, mu, "counter", []byte("a"))
+	require.NoError(err)
+	require.True(ok)
+	require.Equal([]byte{1}, v)
+
+	var keys [][]byte
+	require.NoError(IterateObjectKV(ctx, db, "counter", func(key, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	require.Equal([][]byte{[]byte("a"), []byte("b")}, keys)
+
+	require.NoError(SetObjectKV(ctx, mu, "counter", []byte("a"), nil))
+	_, ok, err = GetObjectKV(ctx, mu, "counter", []byte("a"))
+	require.NoError(err)
+	require.False(ok)
+
+	require.ErrorIs(SetObjectKV(ctx, mu, "counter", nil, []byte{1}), ErrKVEmptyKey)
+	require.ErrorIs(SetObjectKV(ctx, mu, "counter", bytes.Repeat([]byte{1}, consts.MaxObjectKVKeySize+1), []byte{1}), ErrKVKeyTooLarge)
+	require.ErrorIs(SetObjectKV(ctx, mu, "counter", []byte("a"), make([]byte, consts.MaxObjectKVValueSize+1)), ErrKVValueTooLarge)
+}
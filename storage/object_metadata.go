@@ -0,0 +1,205 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// ObjectMetadata describes an object to tooling discovering what it can
+// call. It is supplied by the object's creator and not checked against its
+// code.
+type ObjectMetadata struct {
+	Name        string `serialize:"true" json:"name"`
+	Description string `serialize:"true" json:"description"`
+	// RegionID is the region the object is listed in
+	RegionID string `serialize:"true" json:"region_id"`
+	// Tags are free-form labels, such as the standards the object follows
+	Tags []string `serialize:"true" json:"tags"`
+	// Exports are the functions events may call
+	Exports []string `serialize:"true" json:"exports"`
+	// Interfaces are checked against the code's export table when the
+	// object is created, and index it under their IDs
+	Interfaces []Interface `serialize:"true" json:"interfaces"`
+}
+
+// HasTag reports whether [m] is tagged [tag].
+func (m *ObjectMetadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// [objectMetadataPrefix] + [objectID]
+func ObjectMetadataKey(objectID string) []byte {
+	k := make([]byte, 1+len(objectID))
+	k[0] = objectMetadataPrefix
+	copy(k[1:], objectID)
+	return k
+}
+
+// IndexHead is the head of an index of the objects of a region: how many
+// it lists, and the one listed last. Each entry names the object listed
+// before it, so listing an object only writes keys known from its ID.
+type IndexHead struct {
+	Count  uint64 `serialize:"true" json:"count"`
+	Latest string `serialize:"true" json:"latest"`
+}
+
+// IndexEntry lists an object after [Prev], empty for the first.
+type IndexEntry struct {
+	Prev string `serialize:"true" json:"prev"`
+}
+
+// [objectIndexPrefix] + [regionID] + [objectID]
+func ObjectIndexKey(regionID string, objectID string) []byte {
+	return regionScopedKey(objectIndexPrefix, regionID, []byte(objectID))
+}
+
+// [objectIndexHeadPrefix] + [regionID]
+func ObjectIndexHeadKey(regionID string) []byte {
+	return regionScopedKey(objectIndexHeadPrefix, regionID)
+}
+
+// GetIndexHead returns the head of the index stored under [headKey], zero
+// if it lists nothing.
+func GetIndexHead(ctx context.Context, im state.Immutable, headKey []byte) (*IndexHead, error) {
+	v, err := im.GetValue(ctx, headKey)
+	if errors.Is(err, database.ErrNotFound) {
+		return &IndexHead{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h IndexHead
+	if err := codec.Unmarshal(v, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// AppendIndex lists [objectID] last in the index whose head is stored
+// under [headKey] and whose entry for [objectID] is [entryKey].
+func AppendIndex(ctx context.Context, mu state.Mutable, headKey []byte, entryKey []byte, objectID string) error {
+	head, err := GetIndexHead(ctx, mu, headKey)
+	if err != nil {
+		return err
+	}
+	entry, err := codec.Marshal(&IndexEntry{Prev: head.Latest})
+	if err != nil {
+		return err
+	}
+	if err := mu.Insert(ctx, entryKey, entry); err != nil {
+		return err
+	}
+	v, err := codec.Marshal(&IndexHead{Count: head.Count + 1, Latest: objectID})
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, headKey, v)
+}
+
+// walkIndexFromState returns at most [limit] objects of the index whose
+// head is stored under [headKey], latest first, starting at [from] or, if
+// it is empty, at the latest. It also returns the object to continue from,
+// empty once the first listed was returned, and how many are listed in all.
+func walkIndexFromState(
+	ctx context.Context,
+	f ReadState,
+	headKey []byte,
+	entryKey func(string) []byte,
+	from string,
+	limit uint64,
+) ([]string, string, uint64, error) {
+	values, errs := f(ctx, [][]byte{headKey})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, "", 0, nil
+	}
+	if errs[0] != nil {
+		return nil, "", 0, errs[0]
+	}
+	var head IndexHead
+	if err := codec.Unmarshal(values[0], &head); err != nil {
+		return nil, "", 0, err
+	}
+	next := from
+	if next == "" {
+		next = head.Latest
+	}
+	var objectIDs []string
+	for next != "" && uint64(len(objectIDs)) < limit {
+		values, errs := f(ctx, [][]byte{entryKey(next)})
+		if errs[0] != nil {
+			return nil, "", 0, errs[0]
+		}
+		var entry IndexEntry
+		if err := codec.Unmarshal(values[0], &entry); err != nil {
+			return nil, "", 0, err
+		}
+		objectIDs = append(objectIDs, next)
+		next = entry.Prev
+	}
+	return objectIDs, next, head.Count, nil
+}
+
+// ParseObjectMetadata decodes stored object metadata.
+func ParseObjectMetadata(v []byte) (*ObjectMetadata, error) {
+	var m ObjectMetadata
+	if err := codec.Unmarshal(v, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetObjectMetadata returns the metadata of [objectID], or nil if it was
+// created without any.
+func GetObjectMetadata(ctx context.Context, im state.Immutable, objectID string) (*ObjectMetadata, error) {
+	v, err := im.GetValue(ctx, ObjectMetadataKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseObjectMetadata(v)
+}
+
+func SetObjectMetadata(ctx context.Context, mu state.Mutable, objectID string, m *ObjectMetadata) error {
+	v, err := codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ObjectMetadataKey(objectID), v)
+}
+
+// GetObjectMetadataFromState returns the metadata of [objectID], or nil if
+// it was created without any.
+func GetObjectMetadataFromState(ctx context.Context, f ReadState, objectID string) (*ObjectMetadata, error) {
+	values, errs := f(ctx, [][]byte{ObjectMetadataKey(objectID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return ParseObjectMetadata(values[0])
+}
+
+// GetObjectIndexFromState returns the IDs of at most [limit] objects
+// listed in [regionID], latest first, from [from] or, if it is empty, from
+// the latest. It also returns the object to continue from, empty once the
+// whole index was returned, and how many objects are listed in all.
+func GetObjectIndexFromState(ctx context.Context, f ReadState, regionID string, from string, limit uint64) ([]string, string, uint64, error) {
+	return walkIndexFromState(ctx, f, ObjectIndexHeadKey(regionID), func(objectID string) []byte {
+		return ObjectIndexKey(regionID, objectID)
+	}, from, limit)
+}
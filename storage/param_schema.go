@@ -0,0 +1,55 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrMalformedParamSchema = errors.New("malformed parameter schema")
+
+// [paramSchemaPrefix] + [len(objectID)] + [objectID] + [function]
+func ParamSchemaKey(objectID string, function string) []byte {
+	return regionScopedKey(paramSchemaPrefix, objectID, []byte(function))
+}
+
+// GetParamSchema returns the parameter types [function] of [objectID]
+// accepts, one byte each. The second return value is false if the object
+// declared no schema for the function.
+func GetParamSchema(ctx context.Context, im state.Immutable, objectID string, function string) ([]byte, bool, error) {
+	v, err := im.GetValue(ctx, ParamSchemaKey(objectID, function))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	params, err := ParseParamSchema(v)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s/%s", err, objectID, function)
+	}
+	return params, true, nil
+}
+
+// ParseParamSchema returns the parameter types in a stored schema value.
+// The count is stored first so a function taking no parameters has a
+// non-empty value.
+func ParseParamSchema(v []byte) ([]byte, error) {
+	if len(v) == 0 || int(v[0]) != len(v)-1 {
+		return nil, ErrMalformedParamSchema
+	}
+	return v[1:], nil
+}
+
+func SetParamSchema(ctx context.Context, mu state.Mutable, objectID string, function string, params []byte) error {
+	v := make([]byte, 0, 1+len(params))
+	v = append(v, byte(len(params)))
+	v = append(v, params...)
+	return mu.Insert(ctx, ParamSchemaKey(objectID, function), v)
+}
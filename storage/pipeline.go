@@ -0,0 +1,39 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// [pipelineNextPrefix] + [objectID]
+func PipelineNextKey(objectID string) []byte {
+	k := make([]byte, 1+len(objectID))
+	k[0] = pipelineNextPrefix
+	copy(k[1:], objectID)
+	return k
+}
+
+// GetPipelineNext returns the object a pipeline routes input to after
+// [objectID], or "" if [objectID] ends its pipeline.
+func GetPipelineNext(ctx context.Context, im state.Immutable, objectID string) (string, error) {
+	v, err := im.GetValue(ctx, PipelineNextKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(v), nil
+}
+
+// SetPipelineNext routes the input of the pipeline to [next] after
+// [objectID]. An empty [next] ends the pipeline at [objectID].
+func SetPipelineNext(ctx context.Context, mu state.Mutable, objectID string, next string) error {
+	return mu.Insert(ctx, PipelineNextKey(objectID), []byte(next))
+}
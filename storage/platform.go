@@ -0,0 +1,158 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/attestation"
+)
+
+var ErrInvalidPlatformKey = errors.New("CCA platform key is not an ECDSA key")
+
+// CCAPlatformKeys are the PKIX-encoded CPAKs trusted to sign CCA platform
+// tokens. They are written once from genesis.
+type CCAPlatformKeys struct {
+	Keys [][]byte `serialize:"true" json:"keys"`
+}
+
+// [enclaveTypePrefix] + [regionID] + [enclaveID]
+func EnclaveTypeKey(regionID string, enclaveID []byte) []byte {
+	return regionScopedKey(enclaveTypePrefix, regionID, enclaveID)
+}
+
+// [platformCountPrefix] + [regionID] + [enclaveType]
+func PlatformCountKey(regionID string, t attestation.EnclaveType) []byte {
+	return regionScopedKey(platformCountPrefix, regionID, []byte(t))
+}
+
+// [platformPolicyPrefix] + [regionID]
+func PlatformPolicyKey(regionID string) []byte {
+	return regionScopedKey(platformPolicyPrefix, regionID)
+}
+
+// [ccaPlatformKeysPrefix]
+func CCAPlatformKeysKey() []byte {
+	return []byte{ccaPlatformKeysPrefix}
+}
+
+// GetEnclaveType returns the type recorded for an enclave, or "" if none
+// was.
+func GetEnclaveType(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte) (attestation.EnclaveType, error) {
+	v, err := im.GetValue(ctx, EnclaveTypeKey(regionID, enclaveID))
+	if errors.Is(err, database.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return attestation.EnclaveType(v), nil
+}
+
+// SetEnclaveType records the type of an enclave and counts it towards the
+// platform mix of [regionID]. An enclave's type never changes once
+// recorded, so recording it again is a no-op.
+func SetEnclaveType(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte, t attestation.EnclaveType) error {
+	prev, err := GetEnclaveType(ctx, mu, regionID, enclaveID)
+	if err != nil {
+		return err
+	}
+	if prev != "" {
+		return nil
+	}
+	if err := mu.Insert(ctx, EnclaveTypeKey(regionID, enclaveID), []byte(t)); err != nil {
+		return err
+	}
+	n, err := GetPlatformCount(ctx, mu, regionID, t)
+	if err != nil {
+		return err
+	}
+	return setUint64(ctx, mu, PlatformCountKey(regionID, t), n+1)
+}
+
+// GetPlatformCount returns how many enclaves of type [t] are registered in
+// [regionID].
+func GetPlatformCount(ctx context.Context, im state.Immutable, regionID string, t attestation.EnclaveType) (uint64, error) {
+	return getUint64(ctx, im, PlatformCountKey(regionID, t))
+}
+
+// GetPlatformPolicy returns the platform policy of [regionID], or nil if
+// the region accepts any enclave type.
+func GetPlatformPolicy(ctx context.Context, im state.Immutable, regionID string) (*attestation.PlatformPolicy, error) {
+	v, err := im.GetValue(ctx, PlatformPolicyKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p attestation.PlatformPolicy
+	if err := codec.Unmarshal(v, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetPlatformPolicy replaces the platform policy of [regionID]. A policy
+// with no requirements removes it.
+func SetPlatformPolicy(ctx context.Context, mu state.Mutable, regionID string, p *attestation.PlatformPolicy) error {
+	if len(p.Requirements) == 0 {
+		return mu.Remove(ctx, PlatformPolicyKey(regionID))
+	}
+	v, err := codec.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, PlatformPolicyKey(regionID), v)
+}
+
+// GetCCAPlatformKeys returns the CPAKs trusted to sign CCA platform tokens.
+func GetCCAPlatformKeys(ctx context.Context, im state.Immutable) ([]*ecdsa.PublicKey, error) {
+	v, err := im.GetValue(ctx, CCAPlatformKeysKey())
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var set CCAPlatformKeys
+	if err := codec.Unmarshal(v, &set); err != nil {
+		return nil, err
+	}
+	keys := make([]*ecdsa.PublicKey, len(set.Keys))
+	for i, der := range set.Keys {
+		if keys[i], err = ParseCCAPlatformKey(der); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func SetCCAPlatformKeys(ctx context.Context, mu state.Mutable, set *CCAPlatformKeys) error {
+	v, err := codec.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, CCAPlatformKeysKey(), v)
+}
+
+// ParseCCAPlatformKey decodes a PKIX-encoded CPAK.
+func ParseCCAPlatformKey(der []byte) (*ecdsa.PublicKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidPlatformKey
+	}
+	return key, nil
+}
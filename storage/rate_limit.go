@@ -0,0 +1,58 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// RateCounter counts the recent actions of an account, in its last block
+// and in its last two windows of the governed rate limits.
+type RateCounter struct {
+	Height  uint64 `serialize:"true" json:"height"`
+	InBlock uint32 `serialize:"true" json:"in_block"`
+	// Window is the index of the current window, the block time divided by
+	// the window length
+	Window   uint64 `serialize:"true" json:"window"`
+	Current  uint32 `serialize:"true" json:"current"`
+	Previous uint32 `serialize:"true" json:"previous"`
+}
+
+// [rateCounterPrefix] + [address]
+func RateCounterKey(addr codec.Address) []byte {
+	k := make([]byte, 1+codec.AddressLen)
+	k[0] = rateCounterPrefix
+	copy(k[1:], addr[:])
+	return k
+}
+
+// GetRateCounter returns the counter of [addr], zero if it never acted
+// under a rate limit.
+func GetRateCounter(ctx context.Context, im state.Immutable, addr codec.Address) (*RateCounter, error) {
+	v, err := im.GetValue(ctx, RateCounterKey(addr))
+	if errors.Is(err, database.ErrNotFound) {
+		return &RateCounter{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c RateCounter
+	if err := codec.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func SetRateCounter(ctx context.Context, mu state.Mutable, addr codec.Address, c *RateCounter) error {
+	v, err := codec.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, RateCounterKey(addr), v)
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Receipt records a TEE execution whose result was applied. It is keyed by
+// the ID of the action that applied it, which commits to the transaction ID
+// and the action's index in it, so a merkle proof of the key against a
+// block's state root proves the execution happened on chain.
+type Receipt struct {
+	Enclave []byte `serialize:"true" json:"enclave"`
+	// ResultHash is the digest of the execution result the enclave signed
+	ResultHash ids.ID `serialize:"true" json:"result_hash"`
+	// RegionRoot is the region's attested state root after the execution
+	RegionRoot ids.ID `serialize:"true" json:"region_root"`
+	// Timestamp is the block time, in unix milliseconds, of the execution
+	Timestamp int64 `serialize:"true" json:"timestamp"`
+}
+
+// [receiptPrefix] + [regionID] + [actionID]
+func ReceiptKey(regionID string, actionID ids.ID) []byte {
+	return regionScopedKey(receiptPrefix, regionID, actionID[:])
+}
+
+// GetReceipt returns the receipt of action [actionID] in [regionID], or nil
+// if it applied no execution there.
+func GetReceipt(ctx context.Context, im state.Immutable, regionID string, actionID ids.ID) (*Receipt, error) {
+	v, err := im.GetValue(ctx, ReceiptKey(regionID, actionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Receipt
+	if err := codec.Unmarshal(v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func SetReceipt(ctx context.Context, mu state.Mutable, regionID string, actionID ids.ID, r *Receipt) error {
+	v, err := codec.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, ReceiptKey(regionID, actionID), v)
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrCorruptRegion = errors.New("corrupt region record")
+
+// [regionPrefix] + [regionID]
+func RegionKey(regionID string) []byte {
+	k := make([]byte, 1+len(regionID))
+	k[0] = regionPrefix
+	copy(k[1:], []byte(regionID))
+	return k
+}
+
+// GetRegion returns the TEE set of [regionID]. The second return value is
+// false if the region does not exist.
+func GetRegion(
+	ctx context.Context,
+	im state.Immutable,
+	regionID string,
+) ([]codec.Address, bool, error) {
+	v, err := im.GetValue(ctx, RegionKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(v)%codec.AddressLen != 0 {
+		return nil, false, ErrCorruptRegion
+	}
+	tees := make([]codec.Address, len(v)/codec.AddressLen)
+	for i := range tees {
+		copy(tees[i][:], v[i*codec.AddressLen:])
+	}
+	return tees, true, nil
+}
+
+// SetRegion stores the TEE set of [regionID] as concatenated addresses.
+func SetRegion(
+	ctx context.Context,
+	mu state.Mutable,
+	regionID string,
+	tees []codec.Address,
+) error {
+	v := make([]byte, 0, len(tees)*codec.AddressLen)
+	for _, tee := range tees {
+		v = append(v, tee[:]...)
+	}
+	return mu.Insert(ctx, RegionKey(regionID), v)
+}
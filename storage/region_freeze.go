@@ -0,0 +1,175 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var (
+	ErrNotRegionRecord   = errors.New("record is not part of the region")
+	ErrImportOverflow    = errors.New("more records than the snapshot holds")
+	ErrImportHashInvalid = errors.New("imported records do not match the snapshot hash")
+)
+
+// RegionFreeze halts a region: no execution, request or TEE set change is
+// accepted for it until it is lifted. Regions are frozen so their state can
+// be exported, and while their state is imported.
+type RegionFreeze struct {
+	// FrozenAt is the block time, in unix milliseconds, of the freeze
+	FrozenAt int64 `serialize:"true" json:"frozen_at"`
+	// RegionRoot is the latest attested state root of the region when it
+	// was frozen
+	RegionRoot ids.ID `serialize:"true" json:"region_root"`
+}
+
+// RegionImport tracks a region snapshot being imported in chunks.
+type RegionImport struct {
+	// Importer is the only account that may submit the remaining chunks
+	Importer     codec.Address `serialize:"true" json:"importer"`
+	SnapshotHash ids.ID        `serialize:"true" json:"snapshot_hash"`
+	Records      uint64        `serialize:"true" json:"records"`
+	Imported     uint64        `serialize:"true" json:"imported"`
+	// HashState is the SHA-256 state over the records imported so far
+	HashState []byte `serialize:"true" json:"hash_state"`
+}
+
+// SnapshotRecord is a key and value of a snapshot.
+type SnapshotRecord struct {
+	Key   []byte `serialize:"true" json:"key"`
+	Value []byte `serialize:"true" json:"value"`
+}
+
+// [regionFreezePrefix] + [regionID]
+func RegionFreezeKey(regionID string) []byte {
+	return regionScopedKey(regionFreezePrefix, regionID)
+}
+
+// [regionImportPrefix] + [regionID]
+func RegionImportKey(regionID string) []byte {
+	return regionScopedKey(regionImportPrefix, regionID)
+}
+
+// GetRegionFreeze returns the freeze of [regionID], or nil if it is not
+// frozen.
+func GetRegionFreeze(ctx context.Context, im state.Immutable, regionID string) (*RegionFreeze, error) {
+	v, err := im.GetValue(ctx, RegionFreezeKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f RegionFreeze
+	if err := codec.Unmarshal(v, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func SetRegionFreeze(ctx context.Context, mu state.Mutable, regionID string, f *RegionFreeze) error {
+	v, err := codec.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, RegionFreezeKey(regionID), v)
+}
+
+func RemoveRegionFreeze(ctx context.Context, mu state.Mutable, regionID string) error {
+	return mu.Remove(ctx, RegionFreezeKey(regionID))
+}
+
+// GetRegionImport returns the import in progress into [regionID], or nil
+// if there is none.
+func GetRegionImport(ctx context.Context, im state.Immutable, regionID string) (*RegionImport, error) {
+	v, err := im.GetValue(ctx, RegionImportKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r RegionImport
+	if err := codec.Unmarshal(v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func SetRegionImport(ctx context.Context, mu state.Mutable, regionID string, r *RegionImport) error {
+	v, err := codec.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, RegionImportKey(regionID), v)
+}
+
+func RemoveRegionImport(ctx context.Context, mu state.Mutable, regionID string) error {
+	return mu.Remove(ctx, RegionImportKey(regionID))
+}
+
+// InRegionSnapshot reports whether [key] is one a snapshot of [regionID]
+// may hold.
+func InRegionSnapshot(regionID string, key []byte) bool {
+	if bytes.Equal(key, RegionKey(regionID)) || bytes.Equal(key, SchemaVersionKey()) {
+		return true
+	}
+	if len(key) == 0 || !slices.Contains(regionScopedPrefixes, key[0]) {
+		return false
+	}
+	id, _, ok := splitRegionScopedKey(key)
+	return ok && id == regionID
+}
+
+// Apply writes [records], the next of the snapshot of [regionID], to [mu]
+// and adds them to the hash. The schema version record is hashed but not
+// written: the state keeps its own. Once all records are in, the hash must
+// match the snapshot's, and Apply reports the import done.
+func (r *RegionImport) Apply(ctx context.Context, mu state.Mutable, regionID string, records []SnapshotRecord) (bool, error) {
+	if uint64(len(records)) > r.Records-r.Imported {
+		return false, ErrImportOverflow
+	}
+	h := sha256.New()
+	if len(r.HashState) > 0 {
+		if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(r.HashState); err != nil {
+			return false, err
+		}
+	}
+	for _, record := range records {
+		if !InRegionSnapshot(regionID, record.Key) {
+			return false, fmt.Errorf("%w: %x", ErrNotRegionRecord, record.Key)
+		}
+		h.Write(appendSnapshotRecord(nil, record.Key, record.Value))
+		if bytes.Equal(record.Key, SchemaVersionKey()) {
+			continue
+		}
+		if err := mu.Insert(ctx, record.Key, record.Value); err != nil {
+			return false, err
+		}
+	}
+	r.Imported += uint64(len(records))
+	if r.Imported == r.Records {
+		if ids.ID(h.Sum(nil)) != r.SnapshotHash {
+			return false, ErrImportHashInvalid
+		}
+		return true, nil
+	}
+	hashState, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	r.HashState = hashState
+	return false, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// [regionTemplatePrefix] + [regionID]
+func RegionTemplateKey(regionID string) []byte {
+	return regionScopedKey(regionTemplatePrefix, regionID)
+}
+
+// GetRegionTemplate returns the encoded template [regionID] was created
+// from, or nil if it was created without one. The template is copied when
+// the region is created, so later governance changes do not alter it.
+func GetRegionTemplate(ctx context.Context, im state.Immutable, regionID string) ([]byte, error) {
+	v, err := im.GetValue(ctx, RegionTemplateKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	return v, err
+}
+
+func SetRegionTemplate(ctx context.Context, mu state.Mutable, regionID string, template []byte) error {
+	return mu.Insert(ctx, RegionTemplateKey(regionID), template)
+}
+
+// GetRegionTemplateFromState returns the encoded template [regionID] was
+// created from, or nil if it was created without one.
+func GetRegionTemplateFromState(ctx context.Context, f ReadState, regionID string) ([]byte, error) {
+	values, errs := f(ctx, [][]byte{RegionTemplateKey(regionID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	return values[0], errs[0]
+}
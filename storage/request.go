@@ -0,0 +1,64 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+type RequestStatus uint8
+
+const (
+	RequestPending RequestStatus = iota
+	RequestFulfilled
+)
+
+// Request is user input queued for a region's enclaves. A TEE execution
+// serving it must carry the requester's signature over the input.
+type Request struct {
+	Requester codec.Address `serialize:"true" json:"requester"`
+	Status    RequestStatus `serialize:"true" json:"status"`
+	// QueuedAt is the block time, in unix milliseconds, the request was
+	// queued
+	QueuedAt int64 `serialize:"true" json:"queued_at"`
+	// Result is the digest of the execution result that fulfilled the
+	// request. Other enclaves of the region may only attest the same result.
+	Result ids.ID `serialize:"true" json:"result"`
+}
+
+// [requestPrefix] + [regionID] + [requestID]
+func RequestKey(regionID string, requestID ids.ID) []byte {
+	return regionScopedKey(requestPrefix, regionID, requestID[:])
+}
+
+// GetRequest returns the request [requestID] of [regionID], or nil if none
+// was queued.
+func GetRequest(ctx context.Context, im state.Immutable, regionID string, requestID ids.ID) (*Request, error) {
+	v, err := im.GetValue(ctx, RequestKey(regionID, requestID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Request
+	if err := codec.Unmarshal(v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func SetRequest(ctx context.Context, mu state.Mutable, regionID string, requestID ids.ID, r *Request) error {
+	v, err := codec.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, RequestKey(regionID, requestID), v)
+}
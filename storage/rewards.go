@@ -0,0 +1,162 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/consts"
+	"github.com/ava-labs/hypersdk/state"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+var (
+	ErrInsufficientRewardPool = errors.New("insufficient region reward pool")
+	ErrInsufficientFees       = errors.New("insufficient collected fees")
+)
+
+// [collectedFeesPrefix] + [regionID]
+func CollectedFeesKey(regionID string) []byte {
+	return regionScopedKey(collectedFeesPrefix, regionID)
+}
+
+// [rewardPoolPrefix] + [regionID]
+func RewardPoolKey(regionID string) []byte {
+	return regionScopedKey(rewardPoolPrefix, regionID)
+}
+
+// [enclaveRewardPrefix] + [regionID] + [enclaveID]
+func EnclaveRewardKey(regionID string, enclaveID []byte) []byte {
+	return regionScopedKey(enclaveRewardPrefix, regionID, enclaveID)
+}
+
+// GetRewardPool returns the fees collected in [regionID] that have not been
+// paid out yet.
+func GetRewardPool(ctx context.Context, im state.Immutable, regionID string) (uint64, error) {
+	return getUint64(ctx, im, RewardPoolKey(regionID))
+}
+
+// GetCollectedFees returns the fees charged for actions on [regionID] and
+// not yet paid into its reward pool.
+func GetCollectedFees(ctx context.Context, im state.Immutable, regionID string) (uint64, error) {
+	return getUint64(ctx, im, CollectedFeesKey(regionID))
+}
+
+// CollectFee records [amount] charged for an action on [regionID] as a fee.
+func CollectFee(ctx context.Context, mu state.Mutable, regionID string, amount uint64) error {
+	return addUint64(ctx, mu, CollectedFeesKey(regionID), amount)
+}
+
+// ReturnFee removes [amount] refunded for an action on [regionID] from its
+// collected fees.
+func ReturnFee(ctx context.Context, mu state.Mutable, regionID string, amount uint64) error {
+	collected, err := GetCollectedFees(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	ncollected, err := smath.Sub(collected, amount)
+	if err != nil {
+		return fmt.Errorf("%w: (collected=%d, refund=%d)", ErrInsufficientFees, collected, amount)
+	}
+	return setUint64(ctx, mu, CollectedFeesKey(regionID), ncollected)
+}
+
+// GetEnclaveReward returns the rewards accrued to an enclave and not yet
+// claimed.
+func GetEnclaveReward(ctx context.Context, im state.Immutable, regionID string, enclaveID []byte) (uint64, error) {
+	return getUint64(ctx, im, EnclaveRewardKey(regionID, enclaveID))
+}
+
+// AccrueReward moves [fee] from the fees collected in [regionID] to its pool
+// and credits [share] of it to the enclave that produced the execution.
+func AccrueReward(
+	ctx context.Context,
+	mu state.Mutable,
+	regionID string,
+	enclaveID []byte,
+	fee uint64,
+	share uint64,
+) error {
+	collected, err := GetCollectedFees(ctx, mu, regionID)
+	if err != nil {
+		return err
+	}
+	ncollected, err := smath.Sub(collected, fee)
+	if err != nil {
+		return fmt.Errorf("%w: (collected=%d, fee=%d)", ErrInsufficientFees, collected, fee)
+	}
+	if err := setUint64(ctx, mu, CollectedFeesKey(regionID), ncollected); err != nil {
+		return err
+	}
+	if err := addUint64(ctx, mu, RewardPoolKey(regionID), fee); err != nil {
+		return err
+	}
+	return addUint64(ctx, mu, EnclaveRewardKey(regionID, enclaveID), share)
+}
+
+// TakeEnclaveReward clears the accrued rewards of an enclave, debits them
+// from the region pool and returns the amount.
+func TakeEnclaveReward(
+	ctx context.Context,
+	mu state.Mutable,
+	regionID string,
+	enclaveID []byte,
+) (uint64, error) {
+	rewardKey := EnclaveRewardKey(regionID, enclaveID)
+	reward, err := getUint64(ctx, mu, rewardKey)
+	if err != nil || reward == 0 {
+		return 0, err
+	}
+	poolKey := RewardPoolKey(regionID)
+	pool, err := getUint64(ctx, mu, poolKey)
+	if err != nil {
+		return 0, err
+	}
+	npool, err := smath.Sub(pool, reward)
+	if err != nil {
+		return 0, fmt.Errorf("%w: (pool=%d, reward=%d)", ErrInsufficientRewardPool, pool, reward)
+	}
+	if err := setUint64(ctx, mu, poolKey, npool); err != nil {
+		return 0, err
+	}
+	return reward, mu.Remove(ctx, rewardKey)
+}
+
+func getUint64(ctx context.Context, im state.Immutable, key []byte) (uint64, error) {
+	v, err := im.GetValue(ctx, key)
+	if errors.Is(err, database.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != consts.Uint64Len {
+		return 0, fmt.Errorf("%w: unexpected value length %d", ErrInvalidBalance, len(v))
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+func setUint64(ctx context.Context, mu state.Mutable, key []byte, v uint64) error {
+	return mu.Insert(ctx, key, binary.BigEndian.AppendUint64(nil, v))
+}
+
+func addUint64(ctx context.Context, mu state.Mutable, key []byte, amount uint64) error {
+	if amount == 0 {
+		return nil
+	}
+	v, err := getUint64(ctx, mu, key)
+	if err != nil {
+		return err
+	}
+	nv, err := smath.Add(v, amount)
+	if err != nil {
+		return err
+	}
+	return setUint64(ctx, mu, key, nv)
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// Statuses of a saga
+const (
+	// SagaRunning awaits the event of step Step
+	SagaRunning uint8 = iota
+	// SagaCompleted ran every step
+	SagaCompleted
+	// SagaCompensating awaits the compensating event of step Step, after
+	// a later step failed
+	SagaCompensating
+	// SagaCompensated undid every step that ran before the one that failed
+	SagaCompensated
+	// SagaStuck failed to undo step Step and stops there, for its owner
+	// to resolve
+	SagaStuck
+)
+
+// SagaStep is one step of a saga: an event sent to Object, and the event
+// sent to it to undo the step if a later one fails. A step without a
+// Compensate function is not undone.
+type SagaStep struct {
+	Object               string `serialize:"true" json:"object"`
+	Function             string `serialize:"true" json:"function"`
+	Parameters           []byte `serialize:"true" json:"parameters"`
+	Compensate           string `serialize:"true" json:"compensate"`
+	CompensateParameters []byte `serialize:"true" json:"compensate_parameters"`
+}
+
+// Saga is a workflow of events across objects, and possibly regions, that
+// cannot apply atomically. It is stored under the ID of the event it
+// awaits and moves to the next event as each completes, so the execution
+// of an event finds its saga from the event ID alone. A saga that is done
+// stays under its last event.
+type Saga struct {
+	// ID is the action that started the saga
+	ID     ids.ID        `serialize:"true" json:"id"`
+	Owner  codec.Address `serialize:"true" json:"owner"`
+	Steps  []SagaStep    `serialize:"true" json:"steps"`
+	Step   uint32        `serialize:"true" json:"step"`
+	Status uint8         `serialize:"true" json:"status"`
+}
+
+// Done reports whether the saga awaits no more events.
+func (s *Saga) Done() bool {
+	return s.Status != SagaRunning && s.Status != SagaCompensating
+}
+
+// SagaEvent is an event a saga queued for Object. TEEs execute it under
+// the ID it is queued by, as they do events sent to the object.
+type SagaEvent struct {
+	Object     string `serialize:"true" json:"object"`
+	Function   string `serialize:"true" json:"function"`
+	Parameters []byte `serialize:"true" json:"parameters"`
+	// QueuedAt is the block time, in unix milliseconds, it was queued at
+	QueuedAt int64 `serialize:"true" json:"queued_at"`
+}
+
+// [sagaPrefix] + [eventID]
+func SagaKey(eventID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = sagaPrefix
+	copy(k[1:], eventID[:])
+	return k
+}
+
+// [sagaEventPrefix] + [eventID]
+func SagaEventKey(eventID ids.ID) []byte {
+	k := make([]byte, 1+ids.IDLen)
+	k[0] = sagaEventPrefix
+	copy(k[1:], eventID[:])
+	return k
+}
+
+// GetSaga returns the saga awaiting [eventID], or nil if there is none.
+func GetSaga(ctx context.Context, im state.Immutable, eventID ids.ID) (*Saga, error) {
+	v, err := im.GetValue(ctx, SagaKey(eventID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Saga
+	if err := codec.Unmarshal(v, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func SetSaga(ctx context.Context, mu state.Mutable, eventID ids.ID, s *Saga) error {
+	v, err := codec.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SagaKey(eventID), v)
+}
+
+func RemoveSaga(ctx context.Context, mu state.Mutable, eventID ids.ID) error {
+	return mu.Remove(ctx, SagaKey(eventID))
+}
+
+// GetSagaEvent returns the saga event queued as [eventID], or nil if there
+// is none.
+func GetSagaEvent(ctx context.Context, im state.Immutable, eventID ids.ID) (*SagaEvent, error) {
+	v, err := im.GetValue(ctx, SagaEventKey(eventID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e SagaEvent
+	if err := codec.Unmarshal(v, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func SetSagaEvent(ctx context.Context, mu state.Mutable, eventID ids.ID, e *SagaEvent) error {
+	v, err := codec.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SagaEventKey(eventID), v)
+}
+
+func RemoveSagaEvent(ctx context.Context, mu state.Mutable, eventID ids.ID) error {
+	return mu.Remove(ctx, SagaEventKey(eventID))
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// SealedStorage tracks the storage of an object that its region's enclaves
+// keep sealed. The chain only holds the hash of the sealed blob; the blob
+// itself travels in the action that sealed it and is kept by the region.
+type SealedStorage struct {
+	RegionID string `serialize:"true" json:"region_id"`
+	Hash     ids.ID `serialize:"true" json:"hash"`
+	Size     uint64 `serialize:"true" json:"size"`
+	// Recipients are the enclaves the blob is sealed to, the only ones able
+	// to update or reseal it
+	Recipients [][]byte `serialize:"true" json:"recipients"`
+	// Sequence counts seals and reseals, so an older blob cannot be
+	// restored
+	Sequence uint64 `serialize:"true" json:"sequence"`
+}
+
+// IsRecipient reports whether the blob is sealed to [enclaveID].
+func (s *SealedStorage) IsRecipient(enclaveID []byte) bool {
+	for _, r := range s.Recipients {
+		if bytes.Equal(r, enclaveID) {
+			return true
+		}
+	}
+	return false
+}
+
+// [sealedStoragePrefix] + [objectID]
+func SealedStorageKey(objectID string) []byte {
+	k := make([]byte, 1+len(objectID))
+	k[0] = sealedStoragePrefix
+	copy(k[1:], objectID)
+	return k
+}
+
+// GetSealedStorage returns the sealed storage of [objectID], or nil if its
+// storage is kept in the clear.
+func GetSealedStorage(ctx context.Context, im state.Immutable, objectID string) (*SealedStorage, error) {
+	v, err := im.GetValue(ctx, SealedStorageKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s SealedStorage
+	if err := codec.Unmarshal(v, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func SetSealedStorage(ctx context.Context, mu state.Mutable, objectID string, s *SealedStorage) error {
+	v, err := codec.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SealedStorageKey(objectID), v)
+}
+
+// GetSealedStorageFromState returns the sealed storage of [objectID], or
+// nil if its storage is kept in the clear.
+func GetSealedStorageFromState(ctx context.Context, f ReadState, objectID string) (*SealedStorage, error) {
+	values, errs := f(ctx, [][]byte{SealedStorageKey(objectID)})
+	if errors.Is(errs[0], database.ErrNotFound) {
+		return nil, nil
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var s SealedStorage
+	if err := codec.Unmarshal(values[0], &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
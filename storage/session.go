@@ -0,0 +1,60 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// SessionGrant is what [Account] authorized a session key to do.
+type SessionGrant struct {
+	Account codec.Address `serialize:"true" json:"account"`
+	// Expiry is the block time, in milliseconds, after which the session
+	// may no longer act
+	Expiry      int64   `serialize:"true" json:"expiry"`
+	ActionTypes []uint8 `serialize:"true" json:"action_types"`
+	// Regions, when set, are the only regions the session may act on
+	Regions []string `serialize:"true" json:"regions"`
+}
+
+// [sessionPrefix] + [session address]
+func SessionKey(session codec.Address) []byte {
+	k := make([]byte, 1+codec.AddressLen)
+	k[0] = sessionPrefix
+	copy(k[1:], session[:])
+	return k
+}
+
+// GetSessionGrant returns the grant of [session], or nil if there is none.
+func GetSessionGrant(ctx context.Context, im state.Immutable, session codec.Address) (*SessionGrant, error) {
+	v, err := im.GetValue(ctx, SessionKey(session))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var g SessionGrant
+	if err := codec.Unmarshal(v, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func SetSessionGrant(ctx context.Context, mu state.Mutable, session codec.Address, g *SessionGrant) error {
+	v, err := codec.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SessionKey(session), v)
+}
+
+func RemoveSessionGrant(ctx context.Context, mu state.Mutable, session codec.Address) error {
+	return mu.Remove(ctx, SessionKey(session))
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+type SettlementStatus uint8
+
+const (
+	SettlementPending SettlementStatus = iota
+	SettlementDisputed
+	SettlementFinal
+)
+
+// Settlement is a state root a lane region posted for an epoch. It becomes
+// the region's root once its challenge deadline passes undisputed.
+type Settlement struct {
+	PrevRoot  ids.ID           `serialize:"true" json:"prev_root"`
+	StateRoot ids.ID           `serialize:"true" json:"state_root"`
+	EnclaveID []byte           `serialize:"true" json:"enclave_id"`
+	Submitter codec.Address    `serialize:"true" json:"submitter"`
+	Status    SettlementStatus `serialize:"true" json:"status"`
+	// Deadline is the block time, in seconds, until which the settlement
+	// may be challenged.
+	Deadline uint64 `serialize:"true" json:"deadline"`
+	// Challenger reported a divergent root for the epoch
+	Challenger codec.Address `serialize:"true" json:"challenger"`
+}
+
+// [settlementPrefix] + [regionID] + [epoch]
+func SettlementKey(regionID string, epoch uint64) []byte {
+	return regionScopedKey(settlementPrefix, regionID, binary.BigEndian.AppendUint64(nil, epoch))
+}
+
+// [settlementHeadPrefix] + [regionID]
+func SettlementHeadKey(regionID string) []byte {
+	return regionScopedKey(settlementHeadPrefix, regionID)
+}
+
+// GetSettlement returns the settlement of [epoch] in [regionID], or nil if
+// none was posted.
+func GetSettlement(ctx context.Context, im state.Immutable, regionID string, epoch uint64) (*Settlement, error) {
+	v, err := im.GetValue(ctx, SettlementKey(regionID, epoch))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Settlement
+	if err := codec.Unmarshal(v, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func SetSettlement(ctx context.Context, mu state.Mutable, regionID string, epoch uint64, s *Settlement) error {
+	v, err := codec.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SettlementKey(regionID, epoch), v)
+}
+
+func DeleteSettlement(ctx context.Context, mu state.Mutable, regionID string, epoch uint64) error {
+	return mu.Remove(ctx, SettlementKey(regionID, epoch))
+}
+
+// GetSettlementHead returns the next epoch [regionID] settles. Epochs
+// before it are final.
+func GetSettlementHead(ctx context.Context, im state.Immutable, regionID string) (uint64, error) {
+	return getUint64(ctx, im, SettlementHeadKey(regionID))
+}
+
+func SetSettlementHead(ctx context.Context, mu state.Mutable, regionID string, epoch uint64) error {
+	return setUint64(ctx, mu, SettlementHeadKey(regionID), epoch)
+}
+
+// [settlementOpenPrefix] + [regionID]
+func SettlementOpenKey(regionID string) []byte {
+	return regionScopedKey(settlementOpenPrefix, regionID)
+}
+
+// GetOpenSettlement returns the enclave that posted the settlement of
+// [regionID] awaiting finalization, or nil if none is open.
+func GetOpenSettlement(ctx context.Context, im state.Immutable, regionID string) ([]byte, error) {
+	v, err := im.GetValue(ctx, SettlementOpenKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	return v, err
+}
+
+func SetOpenSettlement(ctx context.Context, mu state.Mutable, regionID string, enclaveID []byte) error {
+	return mu.Insert(ctx, SettlementOpenKey(regionID), enclaveID)
+}
+
+func DeleteOpenSettlement(ctx context.Context, mu state.Mutable, regionID string) error {
+	return mu.Remove(ctx, SettlementOpenKey(regionID))
+}
@@ -0,0 +1,245 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+// SnapshotFormatVersion is written to every snapshot manifest.
+const SnapshotFormatVersion = 1
+
+// maxSnapshotRecordLen bounds a single key or value read from a snapshot so
+// a corrupt length cannot trigger a huge allocation.
+const maxSnapshotRecordLen = 64 * 1024 * 1024
+
+var (
+	snapshotMagic = []byte("SVMSNAP\x00")
+
+	ErrNotSnapshot          = errors.New("not a state snapshot")
+	ErrSnapshotHash         = errors.New("snapshot hash does not match manifest")
+	ErrSnapshotVersion      = errors.New("unsupported snapshot format version")
+	ErrSnapshotRecordTooBig = errors.New("snapshot record too large")
+)
+
+// Manifest describes a snapshot. Hash is the SHA-256 of every record in
+// file order, so two exports of the same state produce the same hash.
+type Manifest struct {
+	FormatVersion uint32 `json:"formatVersion"`
+	SchemaVersion uint32 `json:"schemaVersion"`
+	Region        string `json:"region,omitempty"`
+	Records       uint64 `json:"records"`
+	Hash          string `json:"hash"`
+	// Frozen is the freeze of the exported region, if it was frozen. Only
+	// the state of a frozen region is known not to change after export.
+	Frozen *RegionFreeze `json:"frozen,omitempty"`
+}
+
+// regionScopedPrefixes hold records keyed by [regionScopedKey].
+var regionScopedPrefixes = []byte{
+	enclavePrefix,
+	enclavePubKeyPrefix,
+	enclaveExpiryPrefix,
+	regionStatePrefix,
+	execEventPrefix,
+	rewardPoolPrefix,
+	enclaveRewardPrefix,
+	requestPrefix,
+	divergencePrefix,
+	receiptPrefix,
+	beaconPrefix,
+	beaconHeadPrefix,
+	encryptionKeysPrefix,
+	keyringPrefix,
+	auditPrefix,
+	auditHeadPrefix,
+	regionTemplatePrefix,
+	objectIndexPrefix,
+	objectIndexHeadPrefix,
+	interfaceIndexPrefix,
+	interfaceIndexHeadPrefix,
+	execLimitsPrefix,
+	collectedFeesPrefix,
+}
+
+// ExportSnapshot writes the state in [db] to [w]. If [regionID] is set, only
+// the region record and the records scoped to it are exported.
+//
+// The file is the magic bytes, then length-prefixed key/value records, then
+// an empty key and the JSON manifest.
+func ExportSnapshot(ctx context.Context, db MigrationDB, w io.Writer, regionID string) (*Manifest, error) {
+	schema, err := GetSchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{
+		FormatVersion: SnapshotFormatVersion,
+		SchemaVersion: schema,
+		Region:        regionID,
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(snapshotMagic); err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	write := func(key, value []byte) error {
+		record := appendSnapshotRecord(nil, key, value)
+		h.Write(record)
+		manifest.Records++
+		_, err := bw.Write(record)
+		return err
+	}
+
+	if regionID == "" {
+		if err := iterateAll(ctx, db, nil, write); err != nil {
+			return nil, err
+		}
+	} else {
+		region, err := db.Get(RegionKey(regionID))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, regionID)
+		}
+		if err := write(SchemaVersionKey(), binary.BigEndian.AppendUint32(nil, schema)); err != nil {
+			return nil, err
+		}
+		if err := write(RegionKey(regionID), region); err != nil {
+			return nil, err
+		}
+		freeze, err := db.Get(RegionFreezeKey(regionID))
+		switch {
+		case err == nil:
+			manifest.Frozen = new(RegionFreeze)
+			if err := codec.Unmarshal(freeze, manifest.Frozen); err != nil {
+				return nil, err
+			}
+		case !errors.Is(err, database.ErrNotFound):
+			return nil, err
+		}
+		for _, prefix := range regionScopedPrefixes {
+			if err := iterateAll(ctx, db, regionScopedKey(prefix, regionID), write); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifest.Hash = hex.EncodeToString(h.Sum(nil))
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := bw.Write(appendSnapshotRecord(nil, nil, manifestBytes)); err != nil {
+		return nil, err
+	}
+	return manifest, bw.Flush()
+}
+
+// ImportSnapshot reads a snapshot from [r] into [db]. Nothing is written
+// unless the records match the manifest hash.
+func ImportSnapshot(ctx context.Context, r io.Reader, db database.Batcher) (*Manifest, error) {
+	batch := db.NewBatch()
+	manifest, err := ReadSnapshot(ctx, r, batch.Put)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, batch.Write()
+}
+
+// ReadSnapshot calls [f] for every record in the snapshot read from [r] and
+// returns the manifest once the records are verified against it. Callers
+// must not commit the records until ReadSnapshot returns without error.
+func ReadSnapshot(ctx context.Context, r io.Reader, f func(key, value []byte) error) (*Manifest, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, snapshotMagic) {
+		return nil, ErrNotSnapshot
+	}
+
+	h := sha256.New()
+	var records uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		key, err := readSnapshotField(br)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readSnapshotField(br)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) == 0 {
+			var manifest Manifest
+			if err := json.Unmarshal(value, &manifest); err != nil {
+				return nil, err
+			}
+			if manifest.FormatVersion != SnapshotFormatVersion {
+				return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, manifest.FormatVersion)
+			}
+			if manifest.Hash != hex.EncodeToString(h.Sum(nil)) || manifest.Records != records {
+				return nil, ErrSnapshotHash
+			}
+			return &manifest, nil
+		}
+		h.Write(appendSnapshotRecord(nil, key, value))
+		records++
+		if err := f(key, value); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func appendSnapshotRecord(b []byte, key, value []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(key)))
+	b = append(b, key...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(value)))
+	return append(b, value...)
+}
+
+func readSnapshotField(r io.Reader) ([]byte, error) {
+	var size [consts.Uint32Len]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotSnapshot, err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxSnapshotRecordLen {
+		return nil, ErrSnapshotRecordTooBig
+	}
+	field := make([]byte, n)
+	if _, err := io.ReadFull(r, field); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotSnapshot, err)
+	}
+	return field, nil
+}
+
+// iterateAll calls [f] for every key under [prefix], or every key if
+// [prefix] is empty, stopping at the first error.
+func iterateAll(ctx context.Context, db database.Iteratee, prefix []byte, f func(key, value []byte) error) error {
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"slices"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	src := memdb.New()
+	mu := dbState{src}
+
+	east := codectest.NewRandomAddress()
+	west := codectest.NewRandomAddress()
+	require.NoError(SetRegion(ctx, mu, "us-east", []codec.Address{east}))
+	require.NoError(SetEnclave(ctx, mu, "us-east", east[:], EnclaveActive, []byte{1}))
+	require.NoError(SetRegion(ctx, mu, "us-west", []codec.Address{west}))
+	require.NoError(SetEnclave(ctx, mu, "us-west", west[:], EnclaveActive, []byte{2}))
+	require.NoError(SetBalance(ctx, mu, east, 100))
+
+	var full bytes.Buffer
+	manifest, err := ExportSnapshot(ctx, src, &full, "")
+	require.NoError(err)
+	require.Equal(uint64(7), manifest.Records)
+
+	dst := memdb.New()
+	imported, err := ImportSnapshot(ctx, bytes.NewReader(full.Bytes()), dst)
+	require.NoError(err)
+	require.Equal(manifest, imported)
+	bal, err := GetBalance(ctx, dbState{dst}, east)
+	require.NoError(err)
+	require.Equal(uint64(100), bal)
+
+	// Re-exporting the imported state reproduces the hash
+	var again bytes.Buffer
+	manifest2, err := ExportSnapshot(ctx, dst, &again, "")
+	require.NoError(err)
+	require.Equal(manifest.Hash, manifest2.Hash)
+
+	var region bytes.Buffer
+	manifest, err = ExportSnapshot(ctx, src, &region, "us-west")
+	require.NoError(err)
+	require.Equal("us-west", manifest.Region)
+	regionDB := memdb.New()
+	_, err = ImportSnapshot(ctx, bytes.NewReader(region.Bytes()), regionDB)
+	require.NoError(err)
+	_, ok, err := GetRegion(ctx, dbState{regionDB}, "us-west")
+	require.NoError(err)
+	require.True(ok)
+	_, ok, err = GetRegion(ctx, dbState{regionDB}, "us-east")
+	require.NoError(err)
+	require.False(ok)
+
+	// A flipped byte is caught before anything is written
+	corrupt := bytes.Clone(full.Bytes())
+	corrupt[len(snapshotMagic)+8] ^= 0xff
+	empty := memdb.New()
+	_, err = ImportSnapshot(ctx, bytes.NewReader(corrupt), empty)
+	require.ErrorIs(err, ErrSnapshotHash)
+	it := empty.NewIterator()
+	defer it.Release()
+	require.False(it.Next())
+}
+
+func TestRegionImport(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	src := memdb.New()
+	mu := dbState{src}
+
+	tee := codectest.NewRandomAddress()
+	root := ids.GenerateTestID()
+	require.NoError(SetRegion(ctx, mu, "us-west", []codec.Address{tee}))
+	require.NoError(SetEnclave(ctx, mu, "us-west", tee[:], EnclaveActive, []byte{2}))
+	require.NoError(SetRegionRoot(ctx, mu, "us-west", root))
+	require.NoError(SetRegionFreeze(ctx, mu, "us-west", &RegionFreeze{FrozenAt: 1, RegionRoot: root}))
+
+	var buf bytes.Buffer
+	manifest, err := ExportSnapshot(ctx, src, &buf, "us-west")
+	require.NoError(err)
+	require.Equal(&RegionFreeze{FrozenAt: 1, RegionRoot: root}, manifest.Frozen)
+	var records []SnapshotRecord
+	_, err = ReadSnapshot(ctx, bytes.NewReader(buf.Bytes()), func(key, value []byte) error {
+		records = append(records, SnapshotRecord{Key: bytes.Clone(key), Value: bytes.Clone(value)})
+		return nil
+	})
+	require.NoError(err)
+	hash, err := hex.DecodeString(manifest.Hash)
+	require.NoError(err)
+
+	newImport := func() *RegionImport {
+		return &RegionImport{SnapshotHash: ids.ID(hash), Records: manifest.Records}
+	}
+
+	// Chunks resume the hash where the last left off
+	dst := dbState{memdb.New()}
+	imp := newImport()
+	for i := 0; i < len(records); i += 2 {
+		done, err := imp.Apply(ctx, dst, "us-west", records[i:min(i+2, len(records))])
+		require.NoError(err)
+		require.Equal(i+2 >= len(records), done)
+	}
+	tees, ok, err := GetRegion(ctx, dst, "us-west")
+	require.NoError(err)
+	require.True(ok)
+	require.Equal([]codec.Address{tee}, tees)
+	freeze, err := GetRegionFreeze(ctx, dst, "us-west")
+	require.NoError(err)
+	require.Nil(freeze)
+
+	_, err = newImport().Apply(ctx, dst, "us-west", append(records, records[0]))
+	require.ErrorIs(err, ErrImportOverflow)
+	_, err = newImport().Apply(ctx, dst, "us-east", records)
+	require.ErrorIs(err, ErrNotRegionRecord)
+	_, err = newImport().Apply(ctx, dst, "us-west", []SnapshotRecord{{Key: BalanceKey(tee), Value: []byte{1}}})
+	require.ErrorIs(err, ErrNotRegionRecord)
+
+	tampered := slices.Clone(records)
+	last := len(tampered) - 1
+	tampered[last].Value = append(bytes.Clone(tampered[last].Value), 0)
+	_, err = newImport().Apply(ctx, dst, "us-west", tampered)
+	require.ErrorIs(err, ErrImportHashInvalid)
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+// SponsorPolicy lets an object pay for the executions of events sent to it.
+// Its payer deposits a budget, which is held in the policy rather than in
+// any balance until it is spent or withdrawn.
+type SponsorPolicy struct {
+	Payer codec.Address `serialize:"true" json:"payer"`
+	// MaxPerEvent caps what one execution is reimbursed
+	MaxPerEvent uint64 `serialize:"true" json:"max_per_event"`
+	// Budget is what remains of the deposit
+	Budget uint64 `serialize:"true" json:"budget"`
+}
+
+// [sponsorPolicyPrefix] + [len(objectID)] + [objectID]
+func SponsorPolicyKey(objectID string) []byte {
+	return regionScopedKey(sponsorPolicyPrefix, objectID)
+}
+
+// GetSponsorPolicy returns the policy of [objectID], or nil if the object
+// has none.
+func GetSponsorPolicy(ctx context.Context, im state.Immutable, objectID string) (*SponsorPolicy, error) {
+	v, err := im.GetValue(ctx, SponsorPolicyKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p SponsorPolicy
+	if err := codec.Unmarshal(v, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func SetSponsorPolicy(ctx context.Context, mu state.Mutable, objectID string, p *SponsorPolicy) error {
+	v, err := codec.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, SponsorPolicyKey(objectID), v)
+}
+
+func RemoveSponsorPolicy(ctx context.Context, mu state.Mutable, objectID string) error {
+	return mu.Remove(ctx, SponsorPolicyKey(objectID))
+}
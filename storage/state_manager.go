@@ -9,8 +9,6 @@ import (
     "github.com/ava-labs/hypersdk/chain"
     "github.com/ava-labs/hypersdk/codec"
     "github.com/ava-labs/hypersdk/state"
-    
-    "github.com/rhombus-tech/vm/actions"
 )
 
 const (
@@ -64,6 +62,9 @@ func (*StateManager) Deduct(
     mu state.Mutable,
     amount uint64,
 ) error {
+    // The compute fee of each action is collected for its region when it
+    // executes. Deduct has no action to attribute the rest to, so the rest
+    // is burned.
     _, err := SubBalance(ctx, mu, addr, amount)
     return err
 }
@@ -110,12 +111,12 @@ func (*StateManager) SetObject(ctx context.Context, mu state.Mutable, id string,
 }
 
 // QueueEvent adds an event to the state
-func (*StateManager) QueueEvent(ctx context.Context, mu state.Mutable, event *actions.SendEventAction) error {
-    key := []byte(fmt.Sprintf("%s%s:%s", EventPrefix, roughtime.Now(), event.IDTo))
+func (*StateManager) QueueEvent(ctx context.Context, mu state.Mutable, now uint64, idTo string, functionCall string, parameters []byte) error {
+    key := []byte(fmt.Sprintf("%s%d:%s", EventPrefix, now, idTo))
     
     eventData := map[string]interface{}{
-        "function_call": event.FunctionCall,
-        "parameters":    event.Parameters,
+        "function_call": functionCall,
+        "parameters":    parameters,
     }
 
     eventBytes, err := codec.Marshal(eventData)
@@ -7,8 +7,10 @@ import (
    "encoding/binary"
    "errors"
    "fmt"
+   "strconv"
 
    "github.com/ava-labs/avalanchego/database"
+   "github.com/ava-labs/avalanchego/ids"
    "github.com/ava-labs/hypersdk/codec"
    "github.com/ava-labs/hypersdk/consts"
    "github.com/ava-labs/hypersdk/state"
@@ -21,7 +23,7 @@ type ReadState func(context.Context, [][]byte) ([][]byte, []error)
 // / (height) => store in root
 //   -> [heightPrefix] => height
 // 0x0/ (balance)
-//   -> [owner] => balance
+//   -> [assetID][owner] => balance
 // 0x1/ (hypersdk-height)
 // 0x2/ (hypersdk-timestamp)
 // 0x3/ (hypersdk-fee)
@@ -31,6 +33,124 @@ type ReadState func(context.Context, [][]byte) ([][]byte, []error)
 //   -> [timestamp][id] => event
 // 0x6/ (input)
 //   -> input object id
+// 0x7/ (region)
+//   -> [regionID] => TEE addresses
+// 0x8/ (enclave)
+//   -> [regionID][enclaveID] => status
+// 0x9/ (enclave pubkey)
+//   -> [regionID][enclaveID] => public key
+// 0xa/ (region state)
+//   -> [regionID][key] => value
+// 0xb/ (exec event)
+//   -> [regionID][contractAddr][index] => event
+// 0xc/ (reward pool)
+//   -> [regionID] => unpaid fees
+// 0xd/ (enclave reward)
+//   -> [regionID][enclaveID] => accrued rewards
+// 0xe/ (proposal)
+//   -> [proposalID] => proposal
+// 0xf/ (vote)
+//   -> [proposalID][voter] => weight
+// 0x10/ (param)
+//   -> [paramID] => current and scheduled value
+// 0x11/ (admin set) => M-of-N admin keys
+// 0x12/ (admin nonce) => next admin action nonce
+// 0x13/ (action gate)
+//   -> [typeID] => disabled flag and activation height
+// 0x14/ (schema version) => layout version of this state
+// 0x15/ (migration cursor)
+//   -> [version] => next key to rewrite for a lazy migration
+// 0x16/ (object kv)
+//   -> [objectID][key] => value
+// 0x17/ (upload)
+//   -> [objectID] => owner, size and expiry of a chunked upload
+// 0x18/ (upload chunk)
+//   -> [objectID][index] => chunk
+// 0x19/ (blob)
+//   -> [sha256] => state update value
+// 0x1a/ (region root)
+//   -> [regionID] => last attested regional state root
+// 0x1b/ (settlement)
+//   -> [regionID][epoch] => settled lane root and its challenge deadline
+// 0x1c/ (settlement head)
+//   -> [regionID] => next epoch a lane region settles
+// 0x1d/ (nitro root) => DER of the AWS Nitro root certificate
+// 0x1e/ (nitro policy)
+//   -> [regionID] => PCRs a Nitro enclave must report to serve the region
+// 0x1f/ (enclave type)
+//   -> [regionID][enclaveID] => enclave type
+// 0x20/ (platform count)
+//   -> [regionID][enclaveType] => active enclaves of the type
+// 0x21/ (platform policy)
+//   -> [regionID] => allowed enclave types and required mix
+// 0x22/ (cca platform keys) => CPAKs trusted to sign CCA platform tokens
+// 0x23/ (sgx root) => DER of the Intel SGX root CA certificate
+// 0x24/ (collateral)
+//   -> [fmspc] => latest DCAP collateral for the platform family
+// 0x25/ (enclave expiry)
+//   -> [regionID][enclaveID] => unix ms the enclave's attestation lapses
+// 0x26/ (request)
+//   -> [regionID][requestID] => queued user request and its fulfillment
+// 0x27/ (divergence)
+//   -> [regionID][actionID] => enclave pair results that did not match
+// 0x28/ (receipt)
+//   -> [regionID][actionID] => receipt of an applied TEE execution
+// 0x29/ (callback)
+//   -> [eventID] => object and function awaiting the event's result
+// 0x2a/ (callback event)
+//   -> [eventID] => queued delivery of an event's result to its callback
+// 0x2b/ (param schema)
+//   -> [objectID][function] => parameter types the function accepts
+// 0x2c/ (allowance)
+//   -> [owner][spender] => amount the spender may move
+// 0x2d/ (sponsor policy)
+//   -> [objectID] => payer and remaining budget for events sent to it
+// 0x2e/ (asset)
+//   -> [assetID] => metadata, owner and supply of a non-native asset
+// 0x2f/ (event nonce)
+//   -> [objectID][sender] => last nonce the sender used for events to the object
+// 0x30/ (block summary)
+//   -> [height] => execution summary of a recent accepted block
+// 0x31/ (beacon)
+//   -> [regionID][epoch] => randomness the region's enclave pair published
+// 0x32/ (beacon head)
+//   -> [regionID] => next beacon epoch and the pair's seed commitments
+// 0x33/ (feed)
+//   -> [feedID] => signers, thresholds and latest value of a data feed
+// 0x34/ (feed value)
+//   -> [feedID][round] => a recent value of a data feed
+// 0x35/ (encryption keys)
+//   -> [regionID] => encryption keys the region's enclaves published
+// 0x36/ (sealed storage)
+//   -> [objectID] => hash and recipients of an object's sealed storage
+// 0x37/ (keyring)
+//   -> [regionID] => application keys the region's enclaves published
+// 0x38/ (audit)
+//   -> [regionID][actionID] => an admin operation on the region
+// 0x39/ (audit head)
+//   -> [regionID] => latest audit entry of the region and the entry count
+// 0x3a/ (region template)
+//   -> [regionID] => the governance template the region was created from
+// 0x3b/ (name)
+//   -> [name] => owner, target and expiry of a registered name
+// 0x3c/ (object metadata)
+//   -> [objectID] => name, description, tags and exports of an object
+// 0x3d/ (object index)
+//   -> [regionID][index] => ID of an object listed in the region
+// 0x3e/ (object index count)
+//   -> [regionID] => number of objects listed in the region
+// 0x3f/ (interface)
+//   -> [interfaceID] => function signatures of an interface
+// 0x40/ (interface index)
+//   -> [regionID][interfaceID][index] => ID of an object implementing it
+// 0x41/ (interface index count)
+//   -> [regionID][interfaceID] => number of objects implementing it
+// 0x42/ (exec limits)
+//   -> [regionID] => resource limits of one execution in the region
+// 0x43/ (block logs)
+//   -> [height] => contract logs emitted in the block
+// 0x44/ (block bloom)
+//   -> [height] => bloom filter of the block's regions, objects and topics
 
 const (
    // Active state
@@ -43,6 +163,186 @@ const (
    objectPrefix    = 0x4
    eventPrefix     = 0x5
    inputPrefix     = 0x6
+
+   // Regional execution state
+   regionPrefix        = 0x7
+   enclavePrefix       = 0x8
+   enclavePubKeyPrefix = 0x9
+   regionStatePrefix   = 0xa
+   execEventPrefix     = 0xb
+   rewardPoolPrefix    = 0xc
+   enclaveRewardPrefix = 0xd
+
+   // Governance state
+   proposalPrefix      = 0xe
+   votePrefix          = 0xf
+   paramPrefix         = 0x10
+
+   // Emergency admin state
+   adminSetPrefix      = 0x11
+   adminNoncePrefix    = 0x12
+   actionGatePrefix    = 0x13
+
+   // Schema migration state
+   schemaVersionPrefix   = 0x14
+   migrationCursorPrefix = 0x15
+
+   // Object key-value state
+   objectKVPrefix = 0x16
+
+   // Chunked code upload state
+   uploadPrefix      = 0x17
+   uploadChunkPrefix = 0x18
+
+   // Content-addressed state update values
+   blobPrefix = 0x19
+
+   // Attested regional state roots
+   regionRootPrefix = 0x1a
+
+   // Cross-region settlement of regional lanes
+   settlementPrefix     = 0x1b
+   settlementHeadPrefix = 0x1c
+
+   // Nitro Enclaves attestation state
+   nitroRootPrefix   = 0x1d
+   nitroPolicyPrefix = 0x1e
+
+   // Enclave platform state
+   enclaveTypePrefix     = 0x1f
+   platformCountPrefix   = 0x20
+   platformPolicyPrefix  = 0x21
+   ccaPlatformKeysPrefix = 0x22
+
+   // DCAP collateral state
+   sgxRootPrefix    = 0x23
+   collateralPrefix = 0x24
+
+   // Enclave attestation validity
+   enclaveExpiryPrefix = 0x25
+
+   // Queued user requests
+   requestPrefix = 0x26
+
+   // Dual-execution divergence reports
+   divergencePrefix = 0x27
+
+   // Execution receipts
+   receiptPrefix = 0x28
+
+   // Event callbacks
+   callbackPrefix      = 0x29
+   callbackEventPrefix = 0x2a
+
+   // Object function parameter schemas
+   paramSchemaPrefix = 0x2b
+
+   // Allowances and sponsored fees
+   allowancePrefix     = 0x2c
+   sponsorPolicyPrefix = 0x2d
+
+   // Non-native assets
+   assetPrefix = 0x2e
+
+   // Ordered event sequences
+   eventNoncePrefix = 0x2f
+
+   // Summaries of recent accepted blocks
+   blockSummaryPrefix = 0x30
+
+   // Region randomness beacons
+   beaconPrefix     = 0x31
+   beaconHeadPrefix = 0x32
+
+   // Attested data feeds
+   feedPrefix      = 0x33
+   feedValuePrefix = 0x34
+
+   // Enclave encryption keys
+   encryptionKeysPrefix = 0x35
+
+   // Enclave-sealed object storage
+   sealedStoragePrefix = 0x36
+
+   // Region application keyrings
+   keyringPrefix = 0x37
+
+   // Region admin audit log
+   auditPrefix     = 0x38
+   auditHeadPrefix = 0x39
+
+   // Templates regions were created from
+   regionTemplatePrefix = 0x3a
+
+   // Human-readable names of regions and objects
+   namePrefix = 0x3b
+
+   // Discovery metadata of objects and the per-region index of them
+   objectMetadataPrefix  = 0x3c
+   objectIndexPrefix     = 0x3d
+   objectIndexHeadPrefix = 0x3e
+
+   // Interfaces objects declare and the per-region index of implementers
+   interfacePrefix          = 0x3f
+   interfaceIndexPrefix     = 0x40
+   interfaceIndexHeadPrefix = 0x41
+
+   // Resource limits of executions in a region
+   execLimitsPrefix = 0x42
+
+   // Contract logs of recent blocks, and their bloom filters
+   blockLogsPrefix  = 0x43
+   blockBloomPrefix = 0x44
+
+   // Client idempotency keys of recent events
+   idempotencyPrefix = 0x45
+
+   // Frozen regions, and region snapshots being imported
+   regionFreezePrefix = 0x46
+   regionImportPrefix = 0x47
+
+   // Per-account counters of recent actions, for governed rate limits
+   rateCounterPrefix = 0x48
+
+   // Session key grants, by session address
+   sessionPrefix = 0x49
+
+   // Validator co-signed checkpoints of region roots
+   checkpointPrefix     = 0x4a
+   checkpointHeadPrefix = 0x4b
+
+   // Roughtime anchors of regions' verified time
+   timeAnchorPrefix = 0x4c
+
+   // Hash chains of the events sent to each object
+   eventLinkPrefix      = 0x4d
+   eventChainHeadPrefix = 0x4e
+
+   // Enclaves removed from their region, awaiting garbage collection
+   enclaveRetiredPrefix = 0x50
+
+   // Enclaves that posted a region's open settlement
+   settlementOpenPrefix = 0x51
+
+   // The object each pipeline stage routes input to next
+   pipelineNextPrefix = 0x52
+
+   // Charges of queued events, refunded if they are cancelled
+   eventChargePrefix = 0x53
+
+   // Sagas, under the event each awaits, and the events they queue
+   sagaPrefix      = 0x54
+   sagaEventPrefix = 0x55
+
+   // Fees charged for the actions on a region, not yet paid into its
+   // reward pool
+   collectedFeesPrefix = 0x56
+
+   // Action version an admin action activated ahead of the rules
+   adminVersionPrefix = 0x57
+
+   // Accounts that created each object
+   objectCreatorPrefix = 0x58
 )
 
 const BalanceChunks uint16 = 1
@@ -53,12 +353,21 @@ var (
    feeKey      = []byte{feePrefix}
 )
 
-// [balancePrefix] + [address]
-func BalanceKey(addr codec.Address) (k []byte) {
-   k = make([]byte, 1+codec.AddressLen+consts.Uint16Len)
+// NativeAsset is the asset ID of the chain's native token, which pays fees
+var NativeAsset = ids.Empty
+
+// [balancePrefix] + [NativeAsset] + [address]
+func BalanceKey(addr codec.Address) []byte {
+   return AssetBalanceKey(NativeAsset, addr)
+}
+
+// [balancePrefix] + [assetID] + [address]
+func AssetBalanceKey(assetID ids.ID, addr codec.Address) (k []byte) {
+   k = make([]byte, 1+ids.IDLen+codec.AddressLen+consts.Uint16Len)
    k[0] = balancePrefix
-   copy(k[1:], addr[:])
-   binary.BigEndian.PutUint16(k[1+codec.AddressLen:], BalanceChunks)
+   copy(k[1:], assetID[:])
+   copy(k[1+ids.IDLen:], addr[:])
+   binary.BigEndian.PutUint16(k[1+ids.IDLen+codec.AddressLen:], BalanceChunks)
    return
 }
 
@@ -68,16 +377,26 @@ func GetBalance(
    im state.Immutable,
    addr codec.Address,
 ) (uint64, error) {
-   _, bal, _, err := getBalance(ctx, im, addr)
+   return GetAssetBalance(ctx, im, NativeAsset, addr)
+}
+
+func GetAssetBalance(
+   ctx context.Context,
+   im state.Immutable,
+   assetID ids.ID,
+   addr codec.Address,
+) (uint64, error) {
+   _, bal, _, err := getBalance(ctx, im, assetID, addr)
    return bal, err
 }
 
 func getBalance(
    ctx context.Context,
    im state.Immutable,
+   assetID ids.ID,
    addr codec.Address,
 ) ([]byte, uint64, bool, error) {
-   k := BalanceKey(addr)
+   k := AssetBalanceKey(assetID, addr)
    bal, exists, err := innerGetBalance(im.GetValue(ctx, k))
    return k, bal, exists, err
 }
@@ -88,7 +407,16 @@ func GetBalanceFromState(
    f ReadState,
    addr codec.Address,
 ) (uint64, error) {
-   k := BalanceKey(addr)
+   return GetAssetBalanceFromState(ctx, f, NativeAsset, addr)
+}
+
+func GetAssetBalanceFromState(
+   ctx context.Context,
+   f ReadState,
+   assetID ids.ID,
+   addr codec.Address,
+) (uint64, error) {
+   k := AssetBalanceKey(assetID, addr)
    values, errs := f(ctx, [][]byte{k})
    bal, _, err := innerGetBalance(values[0], errs[0])
    return bal, err
@@ -137,7 +465,18 @@ func AddBalance(
    amount uint64,
    create bool,
 ) (uint64, error) {
-   key, bal, exists, err := getBalance(ctx, mu, addr)
+   return AddAssetBalance(ctx, mu, NativeAsset, addr, amount, create)
+}
+
+func AddAssetBalance(
+   ctx context.Context,
+   mu state.Mutable,
+   assetID ids.ID,
+   addr codec.Address,
+   amount uint64,
+   create bool,
+) (uint64, error) {
+   key, bal, exists, err := getBalance(ctx, mu, assetID, addr)
    if err != nil {
        return 0, err
    }
@@ -163,7 +502,17 @@ func SubBalance(
    addr codec.Address,
    amount uint64,
 ) (uint64, error) {
-   key, bal, ok, err := getBalance(ctx, mu, addr)
+   return SubAssetBalance(ctx, mu, NativeAsset, addr, amount)
+}
+
+func SubAssetBalance(
+   ctx context.Context,
+   mu state.Mutable,
+   assetID ids.ID,
+   addr codec.Address,
+   amount uint64,
+) (uint64, error) {
+   key, bal, ok, err := getBalance(ctx, mu, assetID, addr)
    if !ok {
        return 0, ErrInvalidAddress
    }
@@ -254,14 +603,17 @@ func SetObject(
    return mu.Insert(ctx, k, v)
 }
 
+// QueueEvent queues an event for [id] at [now], the verified time in unix
+// seconds the caller executes at.
 func QueueEvent(
    ctx context.Context,
    mu state.Mutable,
+   now uint64,
    id string,
    functionCall string,
    parameters []byte,
 ) error {
-   k := EventKey(roughtime.Now(), id)
+   k := EventKey(strconv.FormatUint(now, 10), id)
    event := map[string]interface{}{
        "function_call": functionCall,
        "parameters":    parameters,
@@ -0,0 +1,65 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrCorruptTimeAnchor = errors.New("corrupt time anchor")
+
+const timeAnchorLen = 16
+
+// TimeAnchor ties a region's verified time to Roughtime: the trusted
+// median of an attestation the region executed, and the block time it was
+// committed at.
+type TimeAnchor struct {
+	// Median is in unix seconds
+	Median uint64
+	// BlockTime is in unix milliseconds
+	BlockTime int64
+}
+
+// [timeAnchorPrefix] + [regionID]
+func TimeAnchorKey(regionID string) []byte {
+	return regionScopedKey(timeAnchorPrefix, regionID)
+}
+
+// GetTimeAnchor returns the time anchor of [regionID], or nil if the
+// region has none.
+func GetTimeAnchor(ctx context.Context, im state.Immutable, regionID string) (*TimeAnchor, error) {
+	v, err := im.GetValue(ctx, TimeAnchorKey(regionID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(v) != timeAnchorLen {
+		return nil, fmt.Errorf("%w: %d bytes", ErrCorruptTimeAnchor, len(v))
+	}
+	return &TimeAnchor{
+		Median:    binary.BigEndian.Uint64(v),
+		BlockTime: int64(binary.BigEndian.Uint64(v[8:])),
+	}, nil
+}
+
+func SetTimeAnchor(ctx context.Context, mu state.Mutable, regionID string, anchor *TimeAnchor) error {
+	v := binary.BigEndian.AppendUint64(make([]byte, 0, timeAnchorLen), anchor.Median)
+	v = binary.BigEndian.AppendUint64(v, uint64(anchor.BlockTime))
+	return mu.Insert(ctx, TimeAnchorKey(regionID), v)
+}
+
+// GetTimestamp reads the time, in unix milliseconds, of the last accepted
+// block, maintained by hypersdk under [TimestampKey].
+func GetTimestamp(ctx context.Context, im state.Immutable) (int64, error) {
+	v, err := getUint64(ctx, im, TimestampKey())
+	return int64(v), err
+}
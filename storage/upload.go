@@ -0,0 +1,98 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+)
+
+var ErrMissingChunk = errors.New("upload chunk missing")
+
+// Upload tracks code being uploaded for an object in chunks. Chunks are
+// appended in order, so [Chunks] is also the index of the next one.
+type Upload struct {
+	Owner    codec.Address `serialize:"true" json:"owner"`
+	Size     uint32        `serialize:"true" json:"size"`
+	Received uint32        `serialize:"true" json:"received"`
+	Chunks   uint16        `serialize:"true" json:"chunks"`
+	// Expiry is the block timestamp, in milliseconds, after which the
+	// upload is abandoned and may be reclaimed.
+	Expiry int64 `serialize:"true" json:"expiry"`
+}
+
+// Expired reports whether the upload was abandoned as of [timestamp].
+func (u *Upload) Expired(timestamp int64) bool {
+	return timestamp > u.Expiry
+}
+
+// [uploadPrefix] + [objectID]
+func UploadKey(objectID string) []byte {
+	return regionScopedKey(uploadPrefix, objectID)
+}
+
+// [uploadChunkPrefix] + [objectID] + [index]
+func UploadChunkKey(objectID string, index uint16) []byte {
+	return regionScopedKey(uploadChunkPrefix, objectID, binary.BigEndian.AppendUint16(nil, index))
+}
+
+// GetUpload returns the pending upload of [objectID], or nil if there is
+// none.
+func GetUpload(ctx context.Context, im state.Immutable, objectID string) (*Upload, error) {
+	v, err := im.GetValue(ctx, UploadKey(objectID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var u Upload
+	if err := codec.Unmarshal(v, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func SetUpload(ctx context.Context, mu state.Mutable, objectID string, u *Upload) error {
+	v, err := codec.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return mu.Insert(ctx, UploadKey(objectID), v)
+}
+
+func SetUploadChunk(ctx context.Context, mu state.Mutable, objectID string, index uint16, chunk []byte) error {
+	return mu.Insert(ctx, UploadChunkKey(objectID, index), chunk)
+}
+
+// AssembleUpload concatenates the chunks of [u] in order.
+func AssembleUpload(ctx context.Context, im state.Immutable, objectID string, u *Upload) ([]byte, error) {
+	code := make([]byte, 0, u.Received)
+	for i := uint16(0); i < u.Chunks; i++ {
+		chunk, err := im.GetValue(ctx, UploadChunkKey(objectID, i))
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrMissingChunk
+		}
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, chunk...)
+	}
+	return code, nil
+}
+
+// DeleteUpload removes [u] and all of its chunks.
+func DeleteUpload(ctx context.Context, mu state.Mutable, objectID string, u *Upload) error {
+	for i := uint16(0); i < u.Chunks; i++ {
+		if err := mu.Remove(ctx, UploadChunkKey(objectID, i)); err != nil {
+			return err
+		}
+	}
+	return mu.Remove(ctx, UploadKey(objectID))
+}
@@ -6,6 +6,7 @@ package e2e_test
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/ava-labs/avalanchego/tests/fixture/e2e"
 	"github.com/stretchr/testify/require"
@@ -18,13 +19,28 @@ import (
 	"github.com/ava-labs/hypersdk/auth"
 	"github.com/ava-labs/hypersdk/tests/fixture"
 
+	"github.com/rhombus-tech/vm/tests/harness"
+
 	he2e "github.com/ava-labs/hypersdk/tests/e2e"
 	ginkgo "github.com/onsi/ginkgo/v2"
 )
 
 const owner = "morpheusvm-e2e-tests"
 
-var flagVars *e2e.FlagVars
+var (
+	flagVars *e2e.FlagVars
+
+	// harnessConfig and payer drive the ShuttleVM specs in every process
+	harnessConfig *harness.Config
+	payer         []byte
+)
+
+// suiteEnv is the state the first process shares with every process.
+type suiteEnv struct {
+	Env     []byte          `json:"env"`
+	Harness *harness.Config `json:"harness"`
+	Payer   []byte          `json:"payer"`
+}
 
 func TestE2e(t *testing.T) {
 	ginkgo.RunSpecs(t, "morpheusvm e2e test suites")
@@ -41,7 +57,11 @@ var _ = ginkgo.SynchronizedBeforeSuite(func() []byte {
 	gen, workloadFactory, spamKey, err := workload.New(100 /* minBlockGap: 100ms */)
 	require.NoError(err)
 
-	genesisBytes, err := json.Marshal(gen)
+	// Genesis trusts the harness's admin keys and Nitro issuer, so it can
+	// deploy regions served by mock enclaves
+	config, err := harness.NewConfig(24 * time.Hour)
+	require.NoError(err)
+	genesisBytes, err := json.Marshal(config.Genesis(gen))
 	require.NoError(err)
 
 	expectedABI, err := abi.NewABI(vm.ActionParser.GetRegisteredTypes(), vm.OutputParser.GetRegisteredTypes())
@@ -59,10 +79,22 @@ var _ = ginkgo.SynchronizedBeforeSuite(func() []byte {
 	tc := e2e.NewTestContext()
 	he2e.SetWorkload(consts.Name, workloadFactory, expectedABI, parser, &spamHelper, spamKey)
 
-	return fixture.NewTestEnvironment(tc, flagVars, owner, consts.Name, consts.ID, genesisBytes).Marshal()
+	envBytes, err := json.Marshal(&suiteEnv{
+		Env:     fixture.NewTestEnvironment(tc, flagVars, owner, consts.Name, consts.ID, genesisBytes).Marshal(),
+		Harness: config,
+		Payer:   spamKey.Bytes,
+	})
+	require.NoError(err)
+	return envBytes
 }, func(envBytes []byte) {
 	// Run in every ginkgo process
+	require := require.New(ginkgo.GinkgoT())
+
+	var env suiteEnv
+	require.NoError(json.Unmarshal(envBytes, &env))
+	harnessConfig = env.Harness
+	payer = env.Payer
 
 	// Initialize the local test environment from the global state
-	e2e.InitSharedTestEnvironment(ginkgo.GinkgoT(), envBytes)
+	e2e.InitSharedTestEnvironment(ginkgo.GinkgoT(), env.Env)
 })
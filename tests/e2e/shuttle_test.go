@@ -0,0 +1,97 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package e2e_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/tests/fixture/e2e"
+	"github.com/ava-labs/hypersdk/auth"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/tests/harness"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+)
+
+// shuttleTimeout bounds each ShuttleVM spec, which waits on several blocks
+// per transaction.
+const shuttleTimeout = 5 * time.Minute
+
+// chainURIs returns the URI of the chain on every node of the network.
+func chainURIs(tc *e2e.GinkgoTestContext) []string {
+	network := e2e.GetEnv(tc).GetNetwork()
+	chainID := network.GetSubnet(consts.Name).Chains[0].ChainID
+	nodes := network.GetNodeURIs()
+	uris := make([]string, len(nodes))
+	for i, node := range nodes {
+		uris[i] = fmt.Sprintf("%s/ext/bc/%s", node.URI, chainID)
+	}
+	return uris
+}
+
+var _ = ginkgo.Describe("[ShuttleVM]", func() {
+	tc := e2e.NewTestContext()
+	require := require.New(tc)
+
+	ginkgo.It("executes events through regional enclaves on every node", func() {
+		ctx := tc.ContextWithTimeout(shuttleTimeout)
+		h, err := harness.New(ctx, harnessConfig, chainURIs(tc), auth.NewED25519Factory(ed25519.PrivateKey(payer)))
+		require.NoError(err)
+		from, err := h.Height(ctx)
+		require.NoError(err)
+
+		region, err := h.DeployRegion(ctx, "e2e-"+ids.GenerateTestID().String()[:8], 2)
+		require.NoError(err)
+		require.NoError(h.CreateObject(ctx, region, "counter", []byte{0, 'a', 's', 'm'}, "increment"))
+
+		// Each event is completed by the next enclave, and every node
+		// serves the same proven receipt of its execution
+		for i := range 4 {
+			eventID, err := h.SendEvent(ctx, "counter", "increment", []byte{byte(i)})
+			require.NoError(err)
+			result := actions.TEEExecResult{
+				ContractAddr: []byte("counter"),
+				StateUpdates: map[string][]byte{"counter": {byte(i + 1)}},
+			}
+			enclave := i % len(region.Enclaves)
+			txID, err := h.Execute(ctx, region, enclave, eventID, result)
+			require.NoError(err)
+
+			receipt, err := h.Receipt(ctx, region, txID, 0)
+			require.NoError(err)
+			digest, err := result.Digest()
+			require.NoError(err)
+			require.Equal(ids.ID(digest), receipt.ResultHash)
+			require.Equal(region.Enclaves[enclave].ID(), receipt.Enclave)
+		}
+
+		_, err = h.WaitConverged(ctx, from)
+		require.NoError(err)
+	})
+
+	ginkgo.It("rejects executions by enclaves of another region", func() {
+		ctx := tc.ContextWithTimeout(shuttleTimeout)
+		h, err := harness.New(ctx, harnessConfig, chainURIs(tc), auth.NewED25519Factory(ed25519.PrivateKey(payer)))
+		require.NoError(err)
+
+		east, err := h.DeployRegion(ctx, "e2e-east-"+ids.GenerateTestID().String()[:8], 2)
+		require.NoError(err)
+		west, err := h.DeployRegion(ctx, "e2e-west-"+ids.GenerateTestID().String()[:8], 2)
+		require.NoError(err)
+
+		// An east enclave's attestation names the west region, where it
+		// is not registered
+		intruder := &harness.Region{ID: west.ID, Enclaves: east.Enclaves}
+		_, err = h.Execute(ctx, intruder, 0, ids.GenerateTestID(), actions.TEEExecResult{
+			ContractAddr: []byte("counter"),
+		})
+		require.ErrorIs(err, harness.ErrTxFailed)
+	})
+})
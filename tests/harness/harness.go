@@ -0,0 +1,386 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package harness drives a multi-node ShuttleVM network for end-to-end
+// tests. It deploys regions served by mock Nitro enclaves, pushes events
+// through them to attested executions, and checks that every node accepted
+// the same blocks and serves the same proven receipts.
+package harness
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/api/jsonrpc"
+	"github.com/ava-labs/hypersdk/api/ws"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/pubsub"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/mocktee"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/vm"
+)
+
+// pollInterval is how often nodes are polled while waiting on them.
+const pollInterval = 100 * time.Millisecond
+
+var (
+	ErrTxFailed       = errors.New("transaction failed")
+	ErrDiverged       = errors.New("nodes diverged")
+	ErrUnknownEnclave = errors.New("unknown enclave")
+)
+
+// Config is what every process driving the network must share: the admin
+// keys and the Nitro issuer trusted by genesis. It is JSON so it can be
+// passed along with the test environment.
+type Config struct {
+	AdminKeys []ed25519.PrivateKey `json:"adminKeys"`
+	// NitroKey is the SEC 1 DER of the Nitro issuer's key
+	NitroKey   []byte `json:"nitroKey"`
+	NitroRoot  []byte `json:"nitroRoot"`
+	NitroImage []byte `json:"nitroImage"`
+}
+
+// NewConfig generates a 2-of-3 admin set and a Nitro issuer valid for
+// [validity] from now.
+func NewConfig(validity time.Duration) (*Config, error) {
+	keys := make([]ed25519.PrivateKey, 3)
+	for i := range keys {
+		key, err := ed25519.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	now := time.Now()
+	issuer, err := mocktee.NewNitroIssuer(now.Add(-time.Hour), now.Add(validity))
+	if err != nil {
+		return nil, err
+	}
+	nitroKey, err := x509.MarshalECPrivateKey(issuer.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		AdminKeys:  keys,
+		NitroKey:   nitroKey,
+		NitroRoot:  issuer.Root,
+		NitroImage: issuer.Image,
+	}, nil
+}
+
+// Genesis extends [base] with the admin set and Nitro root of [c].
+func (c *Config) Genesis(base *genesis.DefaultGenesis) *vm.Genesis {
+	admin := &storage.AdminSet{Threshold: uint8(len(c.AdminKeys)/2 + 1)}
+	for _, key := range c.AdminKeys {
+		admin.Keys = append(admin.Keys, key.PublicKey())
+	}
+	return &vm.Genesis{
+		DefaultGenesis: base,
+		Admin:          admin,
+		NitroRoot:      c.NitroRoot,
+	}
+}
+
+func (c *Config) issuer() (*mocktee.NitroIssuer, error) {
+	key, err := x509.ParseECPrivateKey(c.NitroKey)
+	if err != nil {
+		return nil, err
+	}
+	return &mocktee.NitroIssuer{Key: key, Root: c.NitroRoot, Image: c.NitroImage}, nil
+}
+
+// Region is a region deployed by the harness, with the enclaves serving it.
+type Region struct {
+	ID       string
+	Enclaves []*mocktee.Enclave
+}
+
+// Harness submits transactions to the nodes of a network, round-robin, so
+// they travel by gossip to the builders.
+type Harness struct {
+	config  *Config
+	issuer  *mocktee.NitroIssuer
+	payer   chain.AuthFactory
+	uris    []string
+	cli     []*jsonrpc.JSONRPCClient
+	lcli    []*vm.JSONRPCClient
+	parser  chain.Parser
+	chainID ids.ID
+
+	// l serializes submissions, whose admin nonces and websocket listens
+	// are sequential
+	l          sync.Mutex
+	next       int
+	adminNonce uint64
+}
+
+// New returns a harness submitting to the chain at each of [uris], paying
+// fees with [payer]. The chain must have been started from the genesis of
+// [config] and have seen no admin actions.
+func New(ctx context.Context, config *Config, uris []string, payer chain.AuthFactory) (*Harness, error) {
+	if len(uris) == 0 {
+		return nil, errors.New("no node URIs")
+	}
+	issuer, err := config.issuer()
+	if err != nil {
+		return nil, err
+	}
+	h := &Harness{
+		config: config,
+		issuer: issuer,
+		payer:  payer,
+		uris:   uris,
+	}
+	for _, uri := range uris {
+		h.cli = append(h.cli, jsonrpc.NewJSONRPCClient(uri))
+		h.lcli = append(h.lcli, vm.NewJSONRPCClient(uri))
+	}
+	if h.parser, err = h.lcli[0].Parser(ctx); err != nil {
+		return nil, err
+	}
+	if _, _, h.chainID, err = h.cli[0].Network(ctx); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Height returns the height of the last block the first node accepted.
+func (h *Harness) Height(ctx context.Context) (uint64, error) {
+	_, height, _, err := h.cli[0].Accepted(ctx)
+	return height, err
+}
+
+// Submit sends [acts] in one transaction through the next node and waits
+// for it to be accepted. It returns the transaction ID, and the result if
+// the transaction failed.
+func (h *Harness) Submit(ctx context.Context, acts ...chain.Action) (ids.ID, *chain.Result, error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	return h.submit(ctx, acts...)
+}
+
+func (h *Harness) submit(ctx context.Context, acts ...chain.Action) (ids.ID, *chain.Result, error) {
+	node := h.next % len(h.uris)
+	h.next++
+
+	_, tx, _, err := h.cli[node].GenerateTransaction(ctx, h.parser, acts, h.payer)
+	if err != nil {
+		return ids.Empty, nil, err
+	}
+	client, err := ws.NewWebSocketClient(h.uris[node], ws.DefaultHandshakeTimeout, pubsub.MaxPendingMessages, pubsub.MaxReadMessageSize)
+	if err != nil {
+		return ids.Empty, nil, err
+	}
+	defer client.Close()
+	if err := client.RegisterTx(tx); err != nil {
+		return ids.Empty, nil, err
+	}
+	for {
+		txID, txErr, result, err := client.ListenTx(ctx)
+		if err != nil {
+			return ids.Empty, nil, err
+		}
+		if txErr != nil {
+			return ids.Empty, nil, txErr
+		}
+		if txID != tx.ID() {
+			continue
+		}
+		if !result.Success {
+			code, msg := vm.ResultError(result)
+			return txID, result, fmt.Errorf("%w: %s (%s): %s", ErrTxFailed, txID, code, msg)
+		}
+		return txID, nil, nil
+	}
+}
+
+// admin signs [digest] with a threshold of the admin keys.
+func (h *Harness) admin(digest []byte) []actions.AdminSignature {
+	threshold := len(h.config.AdminKeys)/2 + 1
+	sigs := make([]actions.AdminSignature, threshold)
+	for i, key := range h.config.AdminKeys[:threshold] {
+		sigs[i] = actions.AdminSignature{
+			PublicKey: key.PublicKey(),
+			Signature: ed25519.Sign(digest, key),
+		}
+	}
+	return sigs
+}
+
+// DeployRegion creates [regionID] with [enclaves] mock Nitro enclaves,
+// sets the admin-signed policy accepting the issuer's documents, and
+// registers each enclave.
+func (h *Harness) DeployRegion(ctx context.Context, regionID string, enclaves int) (*Region, error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	r := &Region{ID: regionID}
+	tees := make([]codec.Address, enclaves)
+	for i := range tees {
+		e, err := mocktee.NewEnclave(mocktee.EnclaveNitro)
+		if err != nil {
+			return nil, err
+		}
+		r.Enclaves = append(r.Enclaves, e)
+		tees[i] = e.Address
+	}
+	if _, _, err := h.submit(ctx, &actions.CreateRegionAction{Version: consts.LatestActionVersion, RegionID: regionID, TEEs: tees}); err != nil {
+		return nil, err
+	}
+
+	policy := &actions.SetNitroPolicyAction{
+		RegionID: regionID,
+		Policy:   h.issuer.Policy(),
+		Nonce:    h.adminNonce,
+	}
+	policy.Signatures = h.admin(policy.Digest(h.chainID))
+	if _, _, err := h.submit(ctx, policy); err != nil {
+		return nil, err
+	}
+	h.adminNonce++
+
+	for _, e := range r.Enclaves {
+		register, err := h.issuer.Register(e, regionID, time.Now().UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		if _, _, err := h.submit(ctx, register); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// CreateObject creates [objectID] listed in [r], exporting [functions].
+func (h *Harness) CreateObject(ctx context.Context, r *Region, objectID string, code []byte, functions ...string) error {
+	_, _, err := h.Submit(ctx, &actions.CreateObjectAction{
+		Version: consts.LatestActionVersion,
+		ID:      objectID,
+		Code:    code,
+		Metadata: &storage.ObjectMetadata{
+			Name:     objectID,
+			RegionID: r.ID,
+			Exports:  functions,
+		},
+	})
+	return err
+}
+
+// SendEvent calls [function] of [objectID] with [params], and returns the
+// event ID its execution must complete.
+func (h *Harness) SendEvent(ctx context.Context, objectID, function string, params []byte) (ids.ID, error) {
+	event := &actions.SendEventAction{
+		Version:      consts.LatestActionVersion,
+		IDTo:         objectID,
+		FunctionCall: function,
+		Parameters:   params,
+	}
+	if _, _, err := h.Submit(ctx, event); err != nil {
+		return ids.Empty, err
+	}
+	return event.EventID(), nil
+}
+
+// Execute submits the execution of event [eventID] producing [result], as
+// attested by enclave [enclave] of [r]. It returns the ID of the
+// transaction, whose first action the receipt is recorded for.
+func (h *Harness) Execute(ctx context.Context, r *Region, enclave int, eventID ids.ID, result actions.TEEExecResult) (ids.ID, error) {
+	if enclave < 0 || enclave >= len(r.Enclaves) {
+		return ids.Empty, fmt.Errorf("%w: %d of region %s", ErrUnknownEnclave, enclave, r.ID)
+	}
+	exec, err := r.Enclaves[enclave].AttestEvent(r.ID, eventID, result, time.Now().UnixMilli())
+	if err != nil {
+		return ids.Empty, err
+	}
+	txID, _, err := h.Submit(ctx, exec)
+	return txID, err
+}
+
+// WaitConverged waits until every node accepted a block at the highest
+// height any node has accepted now, and checks they accepted the same
+// blocks at each height from [from]. It returns that height.
+func (h *Harness) WaitConverged(ctx context.Context, from uint64) (uint64, error) {
+	var height uint64
+	for _, cli := range h.cli {
+		_, accepted, _, err := cli.Accepted(ctx)
+		if err != nil {
+			return 0, err
+		}
+		height = max(height, accepted)
+	}
+	for _, cli := range h.cli {
+		if err := waitHeight(ctx, cli, height); err != nil {
+			return 0, err
+		}
+	}
+	for i := max(from, 1); i <= height; i++ {
+		var want *storage.BlockSummary
+		for node, lcli := range h.lcli {
+			summary, err := lcli.BlockSummary(ctx, i)
+			if err != nil {
+				return 0, fmt.Errorf("%s at %d: %w", h.uris[node], i, err)
+			}
+			if want == nil {
+				want = summary
+				continue
+			}
+			if summary.BlockID != want.BlockID {
+				return 0, fmt.Errorf("%w: %s accepted %s at %d, %s accepted %s", ErrDiverged, h.uris[0], want.BlockID, i, h.uris[node], summary.BlockID)
+			}
+		}
+	}
+	return height, nil
+}
+
+func waitHeight(ctx context.Context, cli *jsonrpc.JSONRPCClient, height uint64) error {
+	for {
+		_, accepted, _, err := cli.Accepted(ctx)
+		if err != nil {
+			return err
+		}
+		if accepted >= height {
+			return nil
+		}
+		select {
+		case <-time.After(pollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Receipt fetches the receipt of action [actionIndex] of [txID] in [r] from
+// every node, each checked against its proof, and checks the nodes agree on
+// it.
+func (h *Harness) Receipt(ctx context.Context, r *Region, txID ids.ID, actionIndex uint8) (*storage.Receipt, error) {
+	var want *vm.ReceiptReply
+	for node, lcli := range h.lcli {
+		reply, err := lcli.Receipt(ctx, r.ID, txID, actionIndex)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", h.uris[node], err)
+		}
+		if want == nil {
+			want = reply
+			continue
+		}
+		// Nodes may be at different heights, so only the proven value
+		// must match, not the root it is proven against
+		if !bytes.Equal(reply.Key, want.Key) || !bytes.Equal(reply.Value, want.Value) {
+			return nil, fmt.Errorf("%w: receipt of %s from %s", ErrDiverged, txID, h.uris[node])
+		}
+	}
+	return want.Receipt, nil
+}
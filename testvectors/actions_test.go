@@ -0,0 +1,106 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !protowire
+
+package testvectors
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+
+	"github.com/rhombus-tech/vm/actions"
+
+	hconsts "github.com/ava-labs/hypersdk/consts"
+)
+
+// packed is an action with a hand-written encoding
+type packed interface {
+	Marshal(p *codec.Packer)
+}
+
+func pack(action packed) []byte {
+	p := codec.NewWriter(0, hconsts.NetworkSizeLimit)
+	action.Marshal(p)
+	return p.Bytes()
+}
+
+// TestActionEncodings locks the packed encodings of actions, which the
+// protowire build replaces.
+func TestActionEncodings(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    func(in Inputs) packed
+		unmarshal func(p *codec.Packer) (chain.Action, error)
+	}{
+		{
+			name: "action/create-object/v1",
+			action: func(in Inputs) packed {
+				return &actions.CreateObjectAction{
+					Version: in.Version,
+					ID:      in.ObjectID,
+					Code:    in.Code,
+					Storage: in.Storage,
+				}
+			},
+			unmarshal: actions.UnmarshalCreateObject,
+		},
+		{
+			name: "action/send-event/v7",
+			action: func(in Inputs) packed {
+				return &actions.SendEventAction{
+					Version:      in.Version,
+					IDTo:         in.ObjectID,
+					FunctionCall: in.FunctionCall,
+					Parameters:   in.Parameters,
+					Sender:       codec.Address(in.Sender),
+					Nonce:        in.Nonce,
+				}
+			},
+			unmarshal: actions.UnmarshalSendEvent,
+		},
+		{
+			name: "action/set-input-object/v1",
+			action: func(in Inputs) packed {
+				return &actions.SetInputObjectAction{
+					Version: in.Version,
+					ID:      in.ObjectID,
+				}
+			},
+			unmarshal: actions.UnmarshalSetInputObject,
+		},
+		{
+			name: "action/tee-exec/v5",
+			action: func(in Inputs) packed {
+				return &actions.TEEExecAction{
+					Version:         in.Version,
+					RegionID:        in.RegionID,
+					TxData:          in.TxData,
+					ExecResult:      resultOf(in),
+					Attestation:     *attestationOf(in),
+					MaxComputeUnits: in.MaxComputeUnits,
+					EventID:         toID(in.EventID),
+				}
+			},
+			unmarshal: actions.UnmarshalTEEExecAction,
+		},
+	}
+	for _, tt := range tests {
+		check(t, tt.name, func(in Inputs) ([]byte, error) {
+			b := pack(tt.action(in))
+
+			// The encoding decodes to an action encoding the same
+			decoded, err := tt.unmarshal(codec.NewReader(b, hconsts.NetworkSizeLimit))
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(b, pack(decoded.(packed))) {
+				return nil, errRoundTrip
+			}
+			return b, nil
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package testvectors holds canonical encodings of the VM's wire,
+// attestation and quote formats. Its tests lock them in; other
+// implementations, such as the Rust TEE worker, check theirs against
+// vectors.json. It is not used by the VM.
+package testvectors
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownVector = errors.New("unknown test vector")
+
+//go:embed vectors.json
+var vectorsJSON []byte
+
+// Hex is bytes encoded as a hex string in vectors.json.
+type Hex []byte
+
+func (h Hex) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *Hex) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
+
+// Stamp is a Roughtime stamp of an attestation.
+type Stamp struct {
+	ServerID  string `json:"server_id"`
+	Time      uint64 `json:"time"`
+	Signature Hex    `json:"signature,omitempty"`
+}
+
+// Inputs are what a vector encodes. Each vector sets the fields its
+// format uses; the rest are empty. Seeds are ed25519 private key seeds.
+type Inputs struct {
+	Seed            Hex            `json:"seed,omitempty"`
+	EnclaveType     string         `json:"enclave_type,omitempty"`
+	EnclaveID       Hex            `json:"enclave_id,omitempty"`
+	Signature       Hex            `json:"signature,omitempty"`
+	Stamps          []Stamp        `json:"stamps,omitempty"`
+	Measurement     Hex            `json:"measurement,omitempty"`
+	ReportData      Hex            `json:"report_data,omitempty"`
+	RegionID        string         `json:"region_id,omitempty"`
+	TxData          Hex            `json:"tx_data,omitempty"`
+	EventID         Hex            `json:"event_id,omitempty"`
+	ResultDigest    Hex            `json:"result_digest,omitempty"`
+	ContractAddr    Hex            `json:"contract_addr,omitempty"`
+	StateUpdates    map[string]Hex `json:"state_updates,omitempty"`
+	PreStateRoot    Hex            `json:"pre_state_root,omitempty"`
+	StateRoot       Hex            `json:"state_root,omitempty"`
+	Version         uint8          `json:"version,omitempty"`
+	ObjectID        string         `json:"object_id,omitempty"`
+	Code            Hex            `json:"code,omitempty"`
+	Storage         Hex            `json:"storage,omitempty"`
+	FunctionCall    string         `json:"function_call,omitempty"`
+	Parameters      Hex            `json:"parameters,omitempty"`
+	Sender          Hex            `json:"sender,omitempty"`
+	Nonce           uint64         `json:"nonce,omitempty"`
+	MaxComputeUnits uint64         `json:"max_compute_units,omitempty"`
+}
+
+// Vector is the canonical encoding [Hex] of [Inputs] in the format
+// [Name] names.
+type Vector struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Inputs      Inputs `json:"inputs"`
+	Hex         Hex    `json:"hex"`
+}
+
+// Load decodes the vectors of vectors.json.
+func Load() ([]Vector, error) {
+	var vectors []Vector
+	if err := json.Unmarshal(vectorsJSON, &vectors); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// Find returns the vector named [name] of [vectors].
+func Find(vectors []Vector, name string) (*Vector, error) {
+	for i := range vectors {
+		if vectors[i].Name == name {
+			return &vectors[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownVector, name)
+}
@@ -0,0 +1,186 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package testvectors
+
+import (
+	"bytes"
+	stded25519 "crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/mocktee"
+
+	hconsts "github.com/ava-labs/hypersdk/consts"
+)
+
+var errRoundTrip = errors.New("decoded value encodes differently")
+
+var update = flag.Bool("update", false, "rewrite vectors.json with the current encodings")
+
+// vectors are shared by the tests, which record their encodings in them
+// when -update is set
+var vectors []Vector
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	var err error
+	if vectors, err = Load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if *update && code == 0 {
+		b, err := json.MarshalIndent(vectors, "", "  ")
+		if err == nil {
+			err = os.WriteFile("vectors.json", append(b, '\n'), 0o644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// check compares the encoding [encode] gives the inputs of vector [name]
+// to the vector's, or records it when -update is set.
+func check(t *testing.T, name string, encode func(in Inputs) ([]byte, error)) {
+	t.Run(name, func(t *testing.T) {
+		require := require.New(t)
+		v, err := Find(vectors, name)
+		require.NoError(err)
+		b, err := encode(v.Inputs)
+		require.NoError(err)
+		if *update {
+			v.Hex = b
+			return
+		}
+		require.Equal(hex.EncodeToString(v.Hex), hex.EncodeToString(b))
+	})
+}
+
+func privateKey(seed []byte) ed25519.PrivateKey {
+	var priv ed25519.PrivateKey
+	copy(priv[:], stded25519.NewKeyFromSeed(seed))
+	return priv
+}
+
+func toID(b []byte) ids.ID {
+	var id ids.ID
+	copy(id[:], b)
+	return id
+}
+
+func attestationOf(in Inputs) *attestation.Attestation {
+	a := &attestation.Attestation{
+		EnclaveType: attestation.EnclaveType(in.EnclaveType),
+		EnclaveID:   in.EnclaveID,
+		Signature:   in.Signature,
+	}
+	for _, stamp := range in.Stamps {
+		a.Stamps = append(a.Stamps, attestation.Stamp{
+			ServerID:  stamp.ServerID,
+			Time:      stamp.Time,
+			Signature: stamp.Signature,
+		})
+	}
+	return a
+}
+
+func resultOf(in Inputs) actions.TEEExecResult {
+	result := actions.TEEExecResult{
+		ContractAddr: in.ContractAddr,
+		PreStateRoot: toID(in.PreStateRoot),
+		StateRoot:    toID(in.StateRoot),
+	}
+	if len(in.StateUpdates) > 0 {
+		result.StateUpdates = make(map[string][]byte, len(in.StateUpdates))
+		for key, value := range in.StateUpdates {
+			result.StateUpdates[key] = value
+		}
+	}
+	return result
+}
+
+func TestQuotes(t *testing.T) {
+	for _, name := range []string{"quote/sgx", "quote/sev"} {
+		check(t, name, func(in Inputs) ([]byte, error) {
+			priv := privateKey(in.Seed)
+			quote, err := mocktee.BuildQuote(in.EnclaveType, priv, in.Measurement, in.ReportData)
+			if err != nil {
+				return nil, err
+			}
+			q, err := mocktee.ParseQuote(in.EnclaveType, quote)
+			if err != nil {
+				return nil, err
+			}
+			return quote, q.Verify(priv.PublicKey(), in.ReportData)
+		})
+	}
+}
+
+func TestAttestation(t *testing.T) {
+	check(t, "attestation/stamp-message", func(in Inputs) ([]byte, error) {
+		return attestationOf(in).Stamps[0].Message(), nil
+	})
+	check(t, "attestation/encoding", func(in Inputs) ([]byte, error) {
+		p := codec.NewWriter(0, hconsts.NetworkSizeLimit)
+		attestationOf(in).Marshal(p)
+		if err := p.Err(); err != nil {
+			return nil, err
+		}
+
+		// The encoding decodes to an attestation encoding the same
+		decoded, err := attestation.Unmarshal(codec.NewReader(p.Bytes(), hconsts.NetworkSizeLimit))
+		if err != nil {
+			return nil, err
+		}
+		again := codec.NewWriter(0, hconsts.NetworkSizeLimit)
+		decoded.Marshal(again)
+		if !bytes.Equal(p.Bytes(), again.Bytes()) {
+			return nil, errRoundTrip
+		}
+		return p.Bytes(), nil
+	})
+	check(t, "attestation/hash", func(in Inputs) ([]byte, error) {
+		hash := attestationOf(in).Hash()
+		return hash[:], nil
+	})
+}
+
+func TestDigests(t *testing.T) {
+	check(t, "request/id", func(in Inputs) ([]byte, error) {
+		id := actions.RequestID(in.RegionID, in.TxData)
+		return id[:], nil
+	})
+	check(t, "request/digest", func(in Inputs) ([]byte, error) {
+		return actions.RequestDigest(in.RegionID, in.TxData), nil
+	})
+	check(t, "request/user-sig", func(in Inputs) ([]byte, error) {
+		return actions.SignRequest(privateKey(in.Seed), in.RegionID, in.TxData), nil
+	})
+	for _, name := range []string{"result/digest", "result/digest-roots"} {
+		check(t, name, func(in Inputs) ([]byte, error) {
+			result := resultOf(in)
+			return result.Digest()
+		})
+	}
+	for _, name := range []string{"exec/digest", "exec/digest-request", "exec/digest-event"} {
+		check(t, name, func(in Inputs) ([]byte, error) {
+			return actions.ExecDigest(in.ResultDigest, in.RegionID, in.TxData, toID(in.EventID)), nil
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package testvm
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+// RegionResult is what the actions of one region returned when a
+// transaction was applied. Actions acting on no region report under "".
+type RegionResult struct {
+	RegionID string
+	// Positions are the indices of the region's actions in the transaction
+	Positions []int
+	Outputs   []codec.Typed
+}
+
+// RunTx executes [batch] as [actor] as the actions of one transaction:
+// either every action succeeds and all their updates, whatever regions
+// they touch, are applied, or the first failure is returned and none is.
+// Results are grouped by region, in order of each region's first action.
+func (v *VM) RunTx(ctx context.Context, actor codec.Address, batch []chain.Action) ([]RegionResult, error) {
+	pending := &pendingState{im: v.State, changes: make(map[string][]byte)}
+	var results []RegionResult
+	byRegion := make(map[string]int)
+	for i, action := range batch {
+		actionID := ids.Empty
+		if _, err := rand.Read(actionID[:]); err != nil {
+			return nil, err
+		}
+		output, err := v.runOn(ctx, pending, actor, actionID, action)
+		if err != nil {
+			return nil, err
+		}
+		regionID := actions.ActionRegion(action)
+		j, ok := byRegion[regionID]
+		if !ok {
+			j = len(results)
+			byRegion[regionID] = j
+			results = append(results, RegionResult{RegionID: regionID})
+		}
+		results[j].Positions = append(results[j].Positions, i)
+		results[j].Outputs = append(results[j].Outputs, output)
+	}
+	if err := pending.commit(ctx, v.State); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// pendingState buffers the writes of a transaction over [im] until they
+// are committed. A nil value marks a removed key.
+type pendingState struct {
+	im      state.Immutable
+	changes map[string][]byte
+}
+
+func (p *pendingState) GetValue(ctx context.Context, key []byte) ([]byte, error) {
+	if value, ok := p.changes[string(key)]; ok {
+		if value == nil {
+			return nil, database.ErrNotFound
+		}
+		return value, nil
+	}
+	return p.im.GetValue(ctx, key)
+}
+
+func (p *pendingState) Insert(_ context.Context, key []byte, value []byte) error {
+	p.changes[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (p *pendingState) Remove(_ context.Context, key []byte) error {
+	p.changes[string(key)] = nil
+	return nil
+}
+
+// commit applies the buffered writes to [mu] in key order.
+func (p *pendingState) commit(ctx context.Context, mu state.Mutable) error {
+	keys := make([]string, 0, len(p.changes))
+	for key := range p.changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := p.changes[key]
+		if value == nil {
+			if err := mu.Remove(ctx, []byte(key)); err != nil && !errors.Is(err, database.ErrNotFound) {
+				return err
+			}
+			continue
+		}
+		if err := mu.Insert(ctx, []byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
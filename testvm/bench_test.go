@@ -0,0 +1,48 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package testvm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+// BenchmarkApplyStateUpdates measures a TEEExecAction writing its state
+// updates through the declared-key checks.
+func BenchmarkApplyStateUpdates(b *testing.B) {
+	for _, updates := range []int{1, 16, 128} {
+		b.Run(fmt.Sprintf("updates=%d", updates), func(b *testing.B) {
+			require := require.New(b)
+			ctx := context.Background()
+			v := New()
+
+			sgx, _, err := v.NewRegion(ctx, "us-east")
+			require.NoError(err)
+			require.NoError(v.Mint(ctx, sgx.Address, 1<<62))
+
+			result := actions.TEEExecResult{
+				ContractAddr: []byte("contract"),
+				StateUpdates: make(map[string][]byte, updates),
+			}
+			for i := 0; i < updates; i++ {
+				result.StateUpdates[fmt.Sprintf("key-%d", i)] = make([]byte, 256)
+			}
+			action, err := v.Attest("us-east", sgx, result)
+			require.NoError(err)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := v.Run(ctx, sgx.Address, action); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
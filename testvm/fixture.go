@@ -0,0 +1,112 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package testvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	// Region is the region a Fixture registers
+	Region = "us-east"
+	// Funds is what a Fixture mints to its actor and SGX enclave
+	Funds = 1_000_000
+)
+
+// Fixture is the state most scenarios start from: a VM with [Region]
+// served by an SGX and SEV enclave pair, and a funded account to act as.
+type Fixture struct {
+	*VM
+	SGX   *Enclave
+	SEV   *Enclave
+	Actor codec.Address
+}
+
+// NewFixture registers [Region] on a new VM and mints [Funds] to a random
+// actor and to the SGX enclave, which submits most executions.
+func NewFixture(t testing.TB) *Fixture {
+	require := require.New(t)
+	ctx := context.Background()
+
+	v := New()
+	sgx, sev, err := v.NewRegion(ctx, Region)
+	require.NoError(err)
+	actor := codectest.NewRandomAddress()
+	require.NoError(v.Mint(ctx, actor, Funds))
+	require.NoError(v.Mint(ctx, sgx.Address, Funds))
+	return &Fixture{VM: v, SGX: sgx, SEV: sev, Actor: actor}
+}
+
+// Objects stores an object with placeholder code under each of [objectIDs],
+// created by [Actor].
+func (f *Fixture) Objects(t testing.TB, objectIDs ...string) {
+	ctx := context.Background()
+	for _, id := range objectIDs {
+		require.NoError(t, storage.SetObject(ctx, f.State, id, map[string][]byte{"code": {1}}))
+		require.NoError(t, storage.SetObjectCreator(ctx, f.State, id, f.Actor))
+	}
+}
+
+// Exec is [VM.Attest] in [Region], failing [t] on error.
+func (f *Fixture) Exec(t testing.TB, enclave *Enclave, result actions.TEEExecResult) *actions.TEEExecAction {
+	exec, err := f.Attest(Region, enclave, result)
+	require.NoError(t, err)
+	return exec
+}
+
+// Step is one action of a scenario. Unlike a chaintest.ActionTest, steps
+// share a VM: each runs against the state the steps before it left.
+type Step struct {
+	Name string
+
+	Action   chain.Action
+	Actor    codec.Address
+	ActionID ids.ID
+
+	// ExpectedOutputs is compared to the output when set
+	ExpectedOutputs codec.Typed
+	ExpectedErr     error
+
+	Assertion func(context.Context, *testing.T, codec.Typed)
+}
+
+// RunSteps runs [steps] in order as subtests of [t], stopping at the first
+// that fails. Steps without an ActionID run with a random one.
+func (v *VM) RunSteps(ctx context.Context, t *testing.T, steps []Step) {
+	for _, step := range steps {
+		ok := t.Run(step.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			var (
+				out codec.Typed
+				err error
+			)
+			if step.ActionID == ids.Empty {
+				out, err = v.Run(ctx, step.Actor, step.Action)
+			} else {
+				out, err = v.RunWithID(ctx, step.Actor, step.ActionID, step.Action)
+			}
+			require.ErrorIs(err, step.ExpectedErr)
+			if step.ExpectedOutputs != nil {
+				require.Equal(step.ExpectedOutputs, out)
+			}
+			if step.Assertion != nil {
+				step.Assertion(ctx, t, out)
+			}
+		})
+		if !ok {
+			t.FailNow()
+		}
+	}
+}
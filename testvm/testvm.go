@@ -0,0 +1,223 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package testvm runs actions end-to-end against in-memory state so
+// integration tests do not need avalanchego. It is not used by the VM.
+package testvm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/mocktee"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	EnclaveSGX = mocktee.EnclaveSGX
+	EnclaveSEV = mocktee.EnclaveSEV
+)
+
+var (
+	ErrUndeclaredKey     = errors.New("state key not declared by action")
+	ErrOutsideValidRange = errors.New("action outside its valid range")
+)
+
+// Enclave is a registered test TEE. Its address doubles as the enclave ID.
+type Enclave = mocktee.Enclave
+
+// VM is an in-memory chain: a state store plus the block context passed to
+// each action.
+type VM struct {
+	State     state.Mutable
+	Rules     chain.Rules
+	Timestamp int64
+	Height    uint64
+}
+
+func New() *VM {
+	return &VM{
+		State:     chaintest.NewInMemoryStore(),
+		Rules:     genesis.NewDefaultRules(),
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+// Mint credits [amount] to [addr], creating the account if needed.
+func (v *VM) Mint(ctx context.Context, addr codec.Address, amount uint64) error {
+	_, err := storage.AddBalance(ctx, v.State, addr, amount, true)
+	return err
+}
+
+func (v *VM) Balance(ctx context.Context, addr codec.Address) (uint64, error) {
+	return storage.GetBalance(ctx, v.State, addr)
+}
+
+// Advance moves the chain forward by [blocks] blocks of [gap] each.
+func (v *VM) Advance(ctx context.Context, blocks uint64, gap time.Duration) error {
+	v.Height += blocks
+	v.Timestamp += int64(blocks) * gap.Milliseconds()
+	return v.State.Insert(ctx, storage.HeightKey(), binary.BigEndian.AppendUint64(nil, v.Height))
+}
+
+// NewEnclave generates a key pair for an enclave of [enclaveType].
+func NewEnclave(enclaveType string) (*Enclave, error) {
+	return mocktee.NewEnclave(enclaveType)
+}
+
+// NewBLSEnclave generates a BLS-capable enclave of [enclaveType], whose
+// signatures can be aggregated with its peer's.
+func NewBLSEnclave(enclaveType string) (*Enclave, error) {
+	return mocktee.NewBLSEnclave(enclaveType)
+}
+
+// RegisterRegion writes [regionID] with [enclaves] as its TEE set and marks
+// each enclave active with its public key and type. Their encryption keys
+// are published as registration would.
+func (v *VM) RegisterRegion(ctx context.Context, regionID string, enclaves ...*Enclave) error {
+	tees := make([]codec.Address, len(enclaves))
+	keys := make([]storage.EncryptionKey, len(enclaves))
+	for i, e := range enclaves {
+		tees[i] = e.Address
+		keys[i] = e.PublishedKey()
+		if err := storage.SetEnclave(ctx, v.State, regionID, e.ID(), storage.EnclaveActive, e.RegisteredKey()); err != nil {
+			return err
+		}
+		if err := storage.SetEnclaveType(ctx, v.State, regionID, e.ID(), attestation.EnclaveType(e.Type)); err != nil {
+			return err
+		}
+	}
+	if err := storage.SetEncryptionKeys(ctx, v.State, regionID, keys); err != nil {
+		return err
+	}
+	return storage.SetRegion(ctx, v.State, regionID, tees)
+}
+
+// NewRegion generates an SGX and SEV enclave pair and registers them as
+// [regionID].
+func (v *VM) NewRegion(ctx context.Context, regionID string) (*Enclave, *Enclave, error) {
+	sgx, sev, err := mocktee.NewPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sgx, sev, v.RegisterRegion(ctx, regionID, sgx, sev)
+}
+
+// Attest builds a TEEExecAction for [result] signed by [enclave], with
+// Roughtime stamps at the current block time.
+func (v *VM) Attest(regionID string, enclave *Enclave, result actions.TEEExecResult) (*actions.TEEExecAction, error) {
+	return enclave.Attest(regionID, result, v.Timestamp)
+}
+
+// AttestRequest builds a TEEExecAction for [result] signed by [enclave],
+// serving the request queued for [txData] with the requester's [userSig].
+func (v *VM) AttestRequest(regionID string, enclave *Enclave, txData, userSig []byte, result actions.TEEExecResult) (*actions.TEEExecAction, error) {
+	return enclave.AttestRequest(regionID, txData, userSig, result, v.Timestamp)
+}
+
+// AttestEvent builds a TEEExecAction for [result] signed by [enclave],
+// completing the event [eventID].
+func (v *VM) AttestEvent(regionID string, enclave *Enclave, eventID ids.ID, result actions.TEEExecResult) (*actions.TEEExecAction, error) {
+	return enclave.AttestEvent(regionID, eventID, result, v.Timestamp)
+}
+
+// AttestDual builds a TEEExecAction for [result] signed by [enclave],
+// carrying [peer]'s execution of the same input producing [peerResult].
+func (v *VM) AttestDual(regionID string, enclave, peer *Enclave, result, peerResult actions.TEEExecResult) (*actions.TEEExecAction, error) {
+	action, err := v.Attest(regionID, enclave, result)
+	if err != nil {
+		return nil, err
+	}
+	if err := peer.AttestPeer(action, peerResult, v.Timestamp); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// AttestPair returns matching attestations of [result] from both enclaves
+// of a region.
+func (v *VM) AttestPair(regionID string, sgx, sev *Enclave, result actions.TEEExecResult) (*actions.TEEExecAction, *actions.TEEExecAction, error) {
+	a, err := v.Attest(regionID, sgx, result)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := v.Attest(regionID, sev, result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+// Run verifies and executes [action] as [actor] at the current block, with
+// a random action ID. Reads and writes outside the action's declared
+// StateKeys fail with [ErrUndeclaredKey].
+func (v *VM) Run(ctx context.Context, actor codec.Address, action chain.Action) (codec.Typed, error) {
+	actionID := ids.Empty
+	if _, err := rand.Read(actionID[:]); err != nil {
+		return nil, err
+	}
+	return v.RunWithID(ctx, actor, actionID, action)
+}
+
+// RunWithID is [Run] with a given [actionID], for checking state the
+// action keys by it.
+func (v *VM) RunWithID(ctx context.Context, actor codec.Address, actionID ids.ID, action chain.Action) (codec.Typed, error) {
+	return v.runOn(ctx, v.State, actor, actionID, action)
+}
+
+// runOn is [RunWithID] against [mu].
+func (v *VM) runOn(ctx context.Context, mu state.Mutable, actor codec.Address, actionID ids.ID, action chain.Action) (codec.Typed, error) {
+	start, end := action.ValidRange(v.Rules)
+	if (start >= 0 && v.Timestamp < start) || (end >= 0 && v.Timestamp > end) {
+		return nil, ErrOutsideValidRange
+	}
+	scoped := &scopedState{Mutable: mu, keys: action.StateKeys(actor, actionID)}
+	return action.Execute(ctx, v.Rules, scoped, v.Timestamp, actor, actionID)
+}
+
+// scopedState rejects access to keys an action did not declare.
+type scopedState struct {
+	state.Mutable
+	keys state.Keys
+}
+
+func (s *scopedState) check(key []byte, perm state.Permissions) error {
+	if !s.keys[string(key)].Has(perm) {
+		return fmt.Errorf("%w: %x", ErrUndeclaredKey, key)
+	}
+	return nil
+}
+
+func (s *scopedState) GetValue(ctx context.Context, key []byte) ([]byte, error) {
+	if err := s.check(key, state.Read); err != nil {
+		return nil, err
+	}
+	return s.Mutable.GetValue(ctx, key)
+}
+
+func (s *scopedState) Insert(ctx context.Context, key []byte, value []byte) error {
+	if err := s.check(key, state.Write); err != nil {
+		return err
+	}
+	return s.Mutable.Insert(ctx, key, value)
+}
+
+func (s *scopedState) Remove(ctx context.Context, key []byte) error {
+	if err := s.check(key, state.Write); err != nil {
+		return err
+	}
+	return s.Mutable.Remove(ctx, key)
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package testvm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestTEEExecEndToEnd(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	f := NewFixture(t)
+
+	result := actions.TEEExecResult{
+		ContractAddr: []byte("contract"),
+		StateUpdates: map[string][]byte{"counter": {1}},
+	}
+	first, second, err := f.AttestPair(Region, f.SGX, f.SEV, result)
+	require.NoError(err)
+
+	for _, action := range []*actions.TEEExecAction{first, second} {
+		out, err := f.Run(ctx, f.SGX.Address, action)
+		require.NoError(err)
+		require.True(out.(*actions.TEEExecOutput).Success)
+	}
+
+	value, err := f.State.GetValue(ctx, storage.RegionStateKey(Region, []byte("counter")))
+	require.NoError(err)
+	require.Equal([]byte{1}, value)
+}
+
+func TestRunRejectsUnknownRegion(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	v := New()
+
+	enclave, err := NewEnclave(EnclaveSGX)
+	require.NoError(err)
+	action, err := v.Attest("missing", enclave, actions.TEEExecResult{})
+	require.NoError(err)
+
+	_, err = v.Run(ctx, enclave.Address, action)
+	require.ErrorIs(err, actions.ErrInvalidRegion)
+}
@@ -8,8 +8,18 @@ package throughput
 
 import (
 	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
 
 	"github.com/ava-labs/hypersdk-starter-kit/actions"
+	"github.com/ava-labs/hypersdk-starter-kit/consts"
+	"github.com/ava-labs/hypersdk-starter-kit/mocktee"
 	"github.com/ava-labs/hypersdk-starter-kit/vm"
 	"github.com/ava-labs/hypersdk/api/ws"
 	"github.com/ava-labs/hypersdk/auth"
@@ -23,8 +33,21 @@ import (
 
 type SpamHelper struct {
 	KeyType string
-	cli     *vm.JSONRPCClient
-	ws      *ws.WebSocketClient
+	// Profile selects the workload. Nil sends only transfers.
+	Profile *Profile
+
+	cli *vm.JSONRPCClient
+	ws  *ws.WebSocketClient
+
+	l        sync.Mutex
+	start    time.Time
+	limiter  *rate.Limiter
+	enclaves map[string][2]*mocktee.Enclave
+	faults   *mocktee.Injector
+	objects  []string
+	next     int
+	tracker  *latencyTracker
+	cancel   context.CancelFunc
 }
 
 var _ throughput.SpamHelper = &SpamHelper{}
@@ -40,9 +63,98 @@ func (sh *SpamHelper) CreateClient(uri string) error {
 		return err
 	}
 	sh.ws = ws
+	return sh.setup()
+}
+
+// setup generates an enclave pair per region and starts watching accepted
+// blocks for latency. It runs once even if several clients are created.
+func (sh *SpamHelper) setup() error {
+	sh.l.Lock()
+	defer sh.l.Unlock()
+
+	if sh.tracker != nil {
+		return nil
+	}
+	if sh.Profile == nil {
+		sh.Profile = DefaultProfile()
+	}
+	if err := sh.Profile.Validate(); err != nil {
+		return err
+	}
+	sh.enclaves = make(map[string][2]*mocktee.Enclave, len(sh.Profile.Regions))
+	for _, region := range sh.Profile.Regions {
+		sgx, sev, err := mocktee.NewPair()
+		if err != nil {
+			return err
+		}
+		sh.enclaves[region] = [2]*mocktee.Enclave{sgx, sev}
+	}
+	if sh.Profile.Faults != nil {
+		faults, err := mocktee.NewInjector(*sh.Profile.Faults)
+		if err != nil {
+			return err
+		}
+		sh.faults = faults
+	}
+	sh.limiter = rate.NewLimiter(rate.Inf, 1)
+	sh.tracker = newLatencyTracker()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sh.cancel = cancel
+	parser, err := sh.cli.Parser(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := sh.ws.RegisterBlocks(); err != nil {
+		cancel()
+		return err
+	}
+	go sh.watchBlocks(ctx, parser)
 	return nil
 }
 
+func (sh *SpamHelper) watchBlocks(ctx context.Context, parser chain.Parser) {
+	for {
+		blk, _, _, err := sh.ws.ListenBlock(ctx, parser)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+		for _, tx := range blk.Txs {
+			for _, action := range tx.Actions {
+				if tag := actionTag(action); tag != nil {
+					sh.tracker.accepted(tag, now)
+				}
+			}
+		}
+	}
+}
+
+// Enclaves returns the mock SGX and SEV enclaves signing for [region], so
+// they can be registered before a run.
+func (sh *SpamHelper) Enclaves(region string) (*mocktee.Enclave, *mocktee.Enclave, bool) {
+	sh.l.Lock()
+	defer sh.l.Unlock()
+
+	pair, ok := sh.enclaves[region]
+	return pair[0], pair[1], ok
+}
+
+// Report stops latency tracking and writes per-kind latency histograms.
+func (sh *SpamHelper) Report(w io.Writer) {
+	sh.l.Lock()
+	tracker := sh.tracker
+	if sh.cancel != nil {
+		sh.cancel()
+	}
+	sh.l.Unlock()
+
+	if tracker != nil {
+		tracker.report(w)
+	}
+}
+
 func (sh *SpamHelper) GetParser(ctx context.Context) (chain.Parser, error) {
 	return sh.cli.Parser(ctx)
 }
@@ -56,10 +168,155 @@ func (sh *SpamHelper) LookupBalance(address codec.Address) (uint64, error) {
 	return balance, err
 }
 
-func (*SpamHelper) GetTransfer(address codec.Address, amount uint64, memo []byte) []chain.Action {
-	return []chain.Action{&actions.Transfer{
-		To:    address,
-		Value: amount,
-		Memo:  memo,
-	}}
+// GetTransfer is called by the spammer for every transaction. It waits for
+// the current ramp rate, then builds an action drawn from the profile mix.
+// [memo] is unique per call and is carried in each action so acceptance
+// can be matched back to it.
+func (sh *SpamHelper) GetTransfer(address codec.Address, amount uint64, memo []byte) []chain.Action {
+	kind := sh.nextKind()
+
+	var action chain.Action
+	switch kind {
+	case KindCreateObject:
+		id := objectID(memo)
+		sh.l.Lock()
+		sh.objects = append(sh.objects, id)
+		sh.l.Unlock()
+		action = &actions.CreateObjectAction{
+			Version: consts.LatestActionVersion,
+			ID:      id,
+			Code:    memo,
+		}
+	case KindSendEvent:
+		sh.l.Lock()
+		target := objectID(memo)
+		if len(sh.objects) > 0 {
+			target = sh.objects[rand.Intn(len(sh.objects))] //nolint:gosec
+		}
+		sh.l.Unlock()
+		action = &actions.SendEventAction{
+			Version:      consts.LatestActionVersion,
+			IDTo:         target,
+			FunctionCall: "spam",
+			Parameters:   memo,
+		}
+	case KindTEEExec:
+		tee, err := sh.teeExec(memo)
+		if err != nil {
+			kind = KindTransfer
+			break
+		}
+		action = tee
+	}
+	if kind == KindTransfer {
+		action = &actions.Transfer{
+			To:    address,
+			Value: amount,
+			Memo:  memo,
+		}
+	}
+	if sh.tracker != nil {
+		sh.tracker.built(memo, kind)
+	}
+	return []chain.Action{action}
+}
+
+// nextKind applies the ramp schedule and draws from the mix.
+func (sh *SpamHelper) nextKind() string {
+	sh.l.Lock()
+	if sh.Profile == nil {
+		sh.Profile = DefaultProfile()
+	}
+	if sh.start.IsZero() {
+		sh.start = time.Now()
+	}
+	limiter := sh.limiter
+	if limiter != nil && len(sh.Profile.Ramp) > 0 {
+		limiter.SetLimit(rate.Limit(sh.Profile.tps(time.Since(sh.start))))
+	}
+	mix := sh.Profile.Mix
+	sh.l.Unlock()
+
+	if limiter != nil {
+		_ = limiter.Wait(context.Background())
+	}
+	return mix.pick(uint64(rand.Int63n(int64(mix.total())))) //nolint:gosec
+}
+
+// teeExec attests to a result in the next region, alternating between its
+// SGX and SEV enclave.
+func (sh *SpamHelper) teeExec(memo []byte) (chain.Action, error) {
+	sh.l.Lock()
+	regions := sh.Profile.Regions
+	region := regions[sh.next%len(regions)]
+	pair := sh.enclaves[region]
+	enclave := pair[(sh.next/len(regions))%2]
+	faults := sh.faults
+	sh.next++
+	sh.l.Unlock()
+
+	if enclave == nil {
+		return nil, fmt.Errorf("no enclaves for region %s", region)
+	}
+	// The memo is carried in the result rather than TxData, which would
+	// need a queued request
+	action, err := enclave.Attest(region, actions.TEEExecResult{
+		ContractAddr: []byte(region),
+		StateUpdates: map[string][]byte{"spam": memo},
+	}, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if err := inject(faults, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// inject applies the faults [faults] draws to [action], waiting out any
+// delay. Dropped actions are reported as [ErrFaultDropped].
+func inject(faults *mocktee.Injector, action *actions.TEEExecAction) error {
+	drop, delay := faults.Inject(&action.Attestation)
+	if drop {
+		return ErrFaultDropped
+	}
+	time.Sleep(delay)
+	return nil
+}
+
+func objectID(memo []byte) string {
+	return "spam-" + hex.EncodeToString(memo)
+}
+
+// actionTag returns the memo GetTransfer embedded in [action].
+func actionTag(action chain.Action) []byte {
+	switch a := action.(type) {
+	case *actions.Transfer:
+		return a.Memo
+	case *actions.SendEventAction:
+		return a.Parameters
+	case *actions.TEEExecAction:
+		return a.ExecResult.StateUpdates["spam"]
+	case *actions.CreateObjectAction:
+		return a.Code
+	default:
+		return nil
+	}
+}
+
+// GetTEEExec returns an attestation of [result] in [regionID] signed by
+// [enclave], with the profile's faults injected. The enclave must already
+// be registered in the region for the action to execute.
+func (sh *SpamHelper) GetTEEExec(enclave *mocktee.Enclave, regionID string, result actions.TEEExecResult) ([]chain.Action, error) {
+	action, err := enclave.Attest(regionID, result, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	sh.l.Lock()
+	faults := sh.faults
+	sh.l.Unlock()
+	if err := inject(faults, action); err != nil {
+		return nil, err
+	}
+	return []chain.Action{action}, nil
 }
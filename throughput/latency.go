@@ -0,0 +1,127 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package throughput
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of each histogram bucket. Anything
+// slower lands in a final overflow bucket.
+var latencyBuckets = []time.Duration{
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// Histogram counts build-to-acceptance latencies for one action kind.
+type Histogram struct {
+	Counts []uint64
+	Total  uint64
+	Sum    time.Duration
+	Max    time.Duration
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{Counts: make([]uint64, len(latencyBuckets)+1)}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(latencyBuckets), func(i int) bool {
+		return d <= latencyBuckets[i]
+	})
+	h.Counts[i]++
+	h.Total++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// Mean returns the average latency, or 0 if nothing was observed.
+func (h *Histogram) Mean() time.Duration {
+	if h.Total == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Total)
+}
+
+// latencyTracker matches accepted actions back to when they were built.
+type latencyTracker struct {
+	l          sync.Mutex
+	pending    map[string]pendingAction
+	histograms map[string]*Histogram
+	issued     map[string]uint64
+}
+
+type pendingAction struct {
+	kind  string
+	built time.Time
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		pending:    map[string]pendingAction{},
+		histograms: map[string]*Histogram{},
+		issued:     map[string]uint64{},
+	}
+}
+
+func (t *latencyTracker) built(tag []byte, kind string) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.pending[string(tag)] = pendingAction{kind: kind, built: time.Now()}
+	t.issued[kind]++
+}
+
+func (t *latencyTracker) accepted(tag []byte, at time.Time) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	p, ok := t.pending[string(tag)]
+	if !ok {
+		return
+	}
+	delete(t.pending, string(tag))
+	h, ok := t.histograms[p.kind]
+	if !ok {
+		h = newHistogram()
+		t.histograms[p.kind] = h
+	}
+	h.observe(at.Sub(p.built))
+}
+
+// report writes one line per action kind followed by its bucket counts.
+func (t *latencyTracker) report(w io.Writer) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	for _, kind := range kindsInMixOrder {
+		issued := t.issued[kind]
+		if issued == 0 {
+			continue
+		}
+		h, ok := t.histograms[kind]
+		if !ok {
+			h = newHistogram()
+		}
+		fmt.Fprintf(w, "%s: issued=%d accepted=%d mean=%s max=%s\n", kind, issued, h.Total, h.Mean(), h.Max)
+		for i, count := range h.Counts {
+			if i < len(latencyBuckets) {
+				fmt.Fprintf(w, "  <=%-6s %d\n", latencyBuckets[i], count)
+			} else {
+				fmt.Fprintf(w, "  >%-7s %d\n", latencyBuckets[len(latencyBuckets)-1], count)
+			}
+		}
+	}
+}
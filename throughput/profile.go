@@ -0,0 +1,166 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package throughput
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/hypersdk-starter-kit/mocktee"
+)
+
+// Action kinds a Profile can mix. They key the latency report.
+const (
+	KindTransfer     = "transfer"
+	KindCreateObject = "create_object"
+	KindSendEvent    = "send_event"
+	KindTEEExec      = "tee_exec"
+)
+
+var (
+	ErrEmptyMix    = errors.New("workload mix has no weight")
+	ErrUnknownKind = errors.New("unknown action kind")
+	ErrInvalidRamp = errors.New("invalid ramp step")
+	ErrNoRegions   = errors.New("TEEExec in mix but no regions configured")
+	// ErrFaultDropped reports a TEEExec action withheld by the profile's
+	// faults
+	ErrFaultDropped = errors.New("TEEExec dropped by fault injection")
+)
+
+var kindsInMixOrder = []string{KindTransfer, KindCreateObject, KindSendEvent, KindTEEExec}
+
+// Mix weights each action kind. Weights are relative, so 30/30/40 and 3/3/4
+// produce the same workload.
+type Mix map[string]uint64
+
+// RampStep holds the issuance rate at TPS until After has elapsed since the
+// first action. The last step holds for the rest of the run.
+type RampStep struct {
+	After time.Duration
+	TPS   float64
+}
+
+// Profile configures a region-aware workload. TEEExec actions are signed
+// by mock enclaves; each region gets its own SGX/SEV pair, which must be
+// registered on chain (e.g. in genesis) before the run for them to succeed.
+// Faults, if set, are injected into their attestations.
+type Profile struct {
+	Mix     Mix
+	Regions []string
+	Ramp    []RampStep
+	Faults  *mocktee.Faults
+}
+
+// DefaultProfile sends only transfers, matching the hypersdk spammer.
+func DefaultProfile() *Profile {
+	return &Profile{Mix: Mix{KindTransfer: 1}}
+}
+
+func (p *Profile) Validate() error {
+	var total uint64
+	for kind, weight := range p.Mix {
+		if !validKind(kind) {
+			return fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return ErrEmptyMix
+	}
+	if p.Mix[KindTEEExec] > 0 && len(p.Regions) == 0 {
+		return ErrNoRegions
+	}
+	for i, step := range p.Ramp {
+		if step.TPS <= 0 || (i > 0 && step.After <= p.Ramp[i-1].After) {
+			return fmt.Errorf("%w: %d", ErrInvalidRamp, i)
+		}
+	}
+	if p.Faults != nil {
+		return p.Faults.Validate()
+	}
+	return nil
+}
+
+// pick maps [n] in [0, total weight) to an action kind.
+func (m Mix) pick(n uint64) string {
+	for _, kind := range kindsInMixOrder {
+		if n < m[kind] {
+			return kind
+		}
+		n -= m[kind]
+	}
+	return KindTransfer
+}
+
+func (m Mix) total() uint64 {
+	var total uint64
+	for _, weight := range m {
+		total += weight
+	}
+	return total
+}
+
+// tps returns the ramp rate [elapsed] into the run, or 0 if unthrottled.
+func (p *Profile) tps(elapsed time.Duration) float64 {
+	for _, step := range p.Ramp {
+		if elapsed < step.After {
+			return step.TPS
+		}
+	}
+	if len(p.Ramp) == 0 {
+		return 0
+	}
+	return p.Ramp[len(p.Ramp)-1].TPS
+}
+
+// ParseMix parses "kind=weight,..." such as
+// "create_object=30,send_event=30,tee_exec=40".
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(s, ",") {
+		kind, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || !validKind(kind) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKind, part)
+		}
+		w, err := strconv.ParseUint(weight, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		mix[kind] = w
+	}
+	return mix, nil
+}
+
+// ParseRamp parses "duration:tps,..." such as "30s:100,2m:500,5m:1000".
+func ParseRamp(s string) ([]RampStep, error) {
+	var steps []RampStep
+	for _, part := range strings.Split(s, ",") {
+		after, tps, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRamp, part)
+		}
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := strconv.ParseFloat(tps, 64)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, RampStep{After: d, TPS: rate})
+	}
+	return steps, nil
+}
+
+func validKind(kind string) bool {
+	for _, k := range kindsInMixOrder {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package throughput
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProfile(t *testing.T) {
+	require := require.New(t)
+
+	mix, err := ParseMix("create_object=30, send_event=30,tee_exec=40")
+	require.NoError(err)
+	require.Equal(Mix{KindCreateObject: 30, KindSendEvent: 30, KindTEEExec: 40}, mix)
+	require.Equal(KindCreateObject, mix.pick(0))
+	require.Equal(KindSendEvent, mix.pick(30))
+	require.Equal(KindTEEExec, mix.pick(99))
+
+	_, err = ParseMix("upload=1")
+	require.ErrorIs(err, ErrUnknownKind)
+
+	ramp, err := ParseRamp("30s:100,2m:500")
+	require.NoError(err)
+	profile := &Profile{Mix: mix, Ramp: ramp}
+	require.ErrorIs(profile.Validate(), ErrNoRegions)
+	profile.Regions = []string{"us-east"}
+	require.NoError(profile.Validate())
+	require.InDelta(100, profile.tps(10*time.Second), 0)
+	require.InDelta(500, profile.tps(time.Minute), 0)
+	require.InDelta(500, profile.tps(time.Hour), 0)
+}
+
+func TestLatencyReport(t *testing.T) {
+	require := require.New(t)
+
+	tracker := newLatencyTracker()
+	tracker.built([]byte("a"), KindSendEvent)
+	tracker.built([]byte("b"), KindSendEvent)
+	tracker.accepted([]byte("a"), time.Now().Add(300*time.Millisecond))
+	tracker.accepted([]byte("unknown"), time.Now())
+
+	h := tracker.histograms[KindSendEvent]
+	require.Equal(uint64(1), h.Total)
+	require.Equal(uint64(1), h.Counts[1])
+	require.Len(tracker.pending, 1)
+}
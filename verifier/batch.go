@@ -6,173 +6,346 @@ import (
    "context"
    "errors"
    "fmt"
+   "sync"
+   "sync/atomic"
 
+   "github.com/ava-labs/avalanchego/ids"
    "github.com/ava-labs/hypersdk/chain"
+   "github.com/ava-labs/hypersdk/codec"
+   "github.com/ava-labs/hypersdk/crypto/ed25519"
    "github.com/ava-labs/hypersdk/state"
 
    "github.com/rhombus-tech/vm/actions"
+   "github.com/rhombus-tech/vm/consts"
 )
 
 var (
    ErrBatchLimit        = errors.New("batch size exceeds limit")
    ErrDuplicateAction   = errors.New("duplicate action in batch")
    ErrConflictingAction = errors.New("conflicting actions in batch")
+   ErrBatchBudget       = errors.New("batch exceeds verification budget")
 )
 
 const (
-   MaxBatchSize = 256 // Maximum number of actions in a batch
+   MaxBatchSize = 256 // Default maximum number of actions in a batch, overridable by governance
+   // Default units of attestation verification, priced by
+   // [actions.VerificationLoad.Units], one batch may ask for. Overridable
+   // by governance.
+   MaxVerificationUnits = 20_000
 )
 
-// BatchVerifier handles verification of multiple actions
+// BatchVerifier handles verification of multiple actions. It holds no
+// per-batch state, so one verifier may check several batches concurrently
+// as long as its underlying state allows concurrent reads.
 type BatchVerifier struct {
    verifier *StateVerifier
-   
-   // Track object modifications within batch
-   objectModifications map[string]modificationInfo
-   eventQueue         map[string][]eventInfo
 }
 
-type modificationInfo struct {
-   created bool
-}
-
-type eventInfo struct {
-   timestamp    string
-   functionCall string
+// BatchPlan is the result of analyzing a batch: where it creates objects
+// and the region root each TEE execution follows. Actions are checked
+// against what the actions before them leave, as if the batch executed
+// serially. A plan is not modified after [PlanBatch] returns it and is
+// safe for concurrent use.
+type BatchPlan struct {
+   // created is the position of the action creating each object
+   created map[string]int
+   // roots is, for executions following another that set their region's
+   // root, the root that execution set
+   roots map[int]ids.ID
+   // regions is the positions of the actions of each region, in batch
+   // order
+   regions map[string][]int
 }
 
 func NewBatchVerifier(state state.Mutable) *BatchVerifier {
    return &BatchVerifier{
-       verifier:            New(state),
-       objectModifications: make(map[string]modificationInfo),
-       eventQueue:         make(map[string][]eventInfo),
+       verifier: New(state),
    }
 }
 
 // VerifyBatch verifies a batch of actions
-func (bv *BatchVerifier) VerifyBatch(ctx context.Context, actions []chain.Action) error {
-   if len(actions) > MaxBatchSize {
+func (bv *BatchVerifier) VerifyBatch(ctx context.Context, batch []chain.Action) error {
+   maxBatchSize, err := actions.Uint64Param(ctx, bv.verifier.state, consts.ParamMaxBatchSize, MaxBatchSize)
+   if err != nil {
+       return err
+   }
+   if uint64(len(batch)) > maxBatchSize {
        return ErrBatchLimit
    }
-
-   // Reset tracking maps
-   bv.objectModifications = make(map[string]modificationInfo)
-   bv.eventQueue = make(map[string][]eventInfo)
+   if err := bv.checkBudget(ctx, batch); err != nil {
+       return err
+   }
+   if err := bv.VerifySignatures(ctx, batch); err != nil {
+       return err
+   }
 
    // First pass: collect all modifications and check for conflicts
-   if err := bv.analyzeActions(ctx, actions); err != nil {
+   plan, err := PlanBatch(batch)
+   if err != nil {
        return err
    }
 
    // Second pass: verify each action in context of the batch
-   for _, action := range actions {
-       if err := bv.verifyAction(ctx, action); err != nil {
-           return err
+   return bv.verifyActions(ctx, plan, batch)
+}
+
+// checkBudget bounds the time spent verifying the attestations of
+// [batch]: their load, summed before any is verified, must fit the
+// governed verification budget.
+func (bv *BatchVerifier) checkBudget(ctx context.Context, batch []chain.Action) error {
+   budget, err := actions.Uint64Param(ctx, bv.verifier.state, consts.ParamVerificationBudget, MaxVerificationUnits)
+   if err != nil {
+       return err
+   }
+   var spent uint64
+   for _, action := range batch {
+       spent += actions.LoadOf(action).Units()
+       if spent > budget {
+           return fmt.Errorf("%w: %d > %d units", ErrBatchBudget, spent, budget)
        }
    }
+   return nil
+}
 
-   return bv.verifyBatchConstraints(ctx)
+// VerifySignatures checks the ed25519 signatures of every action of
+// [batch], user and enclave signatures alike, as one batch. Batch
+// verification only says whether all signatures hold, so if it fails
+// each is checked on its own to find the first action, in batch order,
+// carrying a bad one.
+func (bv *BatchVerifier) VerifySignatures(ctx context.Context, batch []chain.Action) error {
+   var (
+       msgs   []actions.SignedMessage
+       owners []chain.Action
+   )
+   for _, action := range batch {
+       signed, err := actions.Signatures(ctx, bv.verifier.state, action)
+       if err != nil {
+           return wrapActionError(action, err)
+       }
+       for range signed {
+           owners = append(owners, action)
+       }
+       msgs = append(msgs, signed...)
+   }
+   if len(msgs) >= ed25519.MinBatchSize {
+       b := ed25519.NewBatch(len(msgs))
+       for _, msg := range msgs {
+           b.Add(msg.Message, msg.PublicKey, msg.Signature)
+       }
+       if err := b.VerifyAsync()(); err == nil {
+           return nil
+       }
+   }
+   for i := range msgs {
+       if !msgs[i].Verify() {
+           return wrapActionError(owners[i], msgs[i].Err)
+       }
+   }
+   return nil
+}
+
+// workers is how many actions of a batch are verified at once
+var workers atomic.Int64
+
+// SetWorkers sets how many actions of a batch are verified concurrently.
+// Values below one verify them one at a time.
+func SetWorkers(n int) {
+   workers.Store(int64(n))
+}
+
+// verifyActions verifies the actions of [batch] on up to [workers]
+// goroutines. Whatever the concurrency, the error of the first failing
+// action in batch order is returned.
+func (bv *BatchVerifier) verifyActions(ctx context.Context, plan *BatchPlan, batch []chain.Action) error {
+   n := int(workers.Load())
+   if n <= 1 || len(batch) <= 1 {
+       for i, action := range batch {
+           if err := bv.verifyAction(ctx, plan, i, action); err != nil {
+               return err
+           }
+       }
+       return nil
+   }
+
+   errs := make([]error, len(batch))
+   slots := make(chan struct{}, n)
+   var wg sync.WaitGroup
+   for i, action := range batch {
+       slots <- struct{}{}
+       wg.Add(1)
+       go func() {
+           defer func() {
+               <-slots
+               wg.Done()
+           }()
+           errs[i] = bv.verifyAction(ctx, plan, i, action)
+       }()
+   }
+   wg.Wait()
+   for _, err := range errs {
+       if err != nil {
+           return err
+       }
+   }
+   return nil
 }
 
-// analyzeActions collects information about all actions in the batch
-func (bv *BatchVerifier) analyzeActions(ctx context.Context, actions []chain.Action) error {
-   for _, action := range actions {
+// PlanBatch collects information about all actions in the batch without
+// reading state. It rejects batches no serial execution of which succeeds:
+// ones creating an object twice, acting on an object before the action
+// creating it, or queuing two events to one object.
+func PlanBatch(batch []chain.Action) (*BatchPlan, error) {
+   plan := &BatchPlan{
+       created: make(map[string]int),
+       roots:   make(map[int]ids.ID),
+       regions: make(map[string][]int),
+   }
+   lastRoots := make(map[string]ids.ID)
+   for i, action := range batch {
        switch a := action.(type) {
        case *actions.CreateObjectAction:
-           if info, exists := bv.objectModifications[a.ID]; exists {
-               if info.created {
-                   return ErrDuplicateAction
-               }
+           if _, exists := plan.created[a.ID]; exists {
+               return nil, ErrDuplicateAction
            }
-           bv.objectModifications[a.ID] = modificationInfo{created: true}
+           plan.created[a.ID] = i
 
-       case *actions.SendEventAction:
-           events := bv.eventQueue[a.IDTo]
-           // Check for duplicate events with same timestamp
-           timestamp := roughtime.Now()
-           for _, event := range events {
-               if event.timestamp == timestamp {
-                   return ErrDuplicateAction
-               }
+       case *actions.CommitObjectAction:
+           if _, exists := plan.created[a.ObjectID]; exists {
+               return nil, ErrDuplicateAction
+           }
+           plan.created[a.ObjectID] = i
+
+       case *actions.TEEExecAction:
+           // An execution starts from the root the previous one in its
+           // region left, not the one in state
+           if root, ok := lastRoots[a.RegionID]; ok {
+               plan.roots[i] = root
            }
-           events = append(events, eventInfo{
-               timestamp:    timestamp,
-               functionCall: a.FunctionCall,
-           })
-           bv.eventQueue[a.IDTo] = events
-           
-       case *actions.SetInputObjectAction:
-           // Verify no conflicts with other actions
-           if info, exists := bv.objectModifications[a.ID]; exists && !info.created {
-               return ErrConflictingAction
+           if a.ExecResult.StateRoot != ids.Empty {
+               lastRoots[a.RegionID] = a.ExecResult.StateRoot
+           }
+       }
+       if regionID := actions.ActionRegion(action); regionID != "" {
+           plan.regions[regionID] = append(plan.regions[regionID], i)
+       }
+   }
+   if err := plan.checkConflicts(batch); err != nil {
+       return nil, err
+   }
+   if err := checkEventOrder(batch); err != nil {
+       return nil, err
+   }
+   return plan, nil
+}
+
+// checkConflicts rejects actions on an object the batch creates after
+// them. Executed in order, the action either finds no object or leaves the
+// creation failing with [actions.ErrObjectExists].
+func (p *BatchPlan) checkConflicts(batch []chain.Action) error {
+   for i, action := range batch {
+       for _, objectID := range targetObjects(action) {
+           if at, ok := p.created[objectID]; ok && at > i {
+               return fmt.Errorf("%w: %s used at %d, created at %d", ErrConflictingAction, objectID, i, at)
            }
        }
    }
    return nil
 }
 
-// verifyAction verifies an individual action within the batch context
-func (bv *BatchVerifier) verifyAction(ctx context.Context, action chain.Action) error {
-   // First verify the action individually
-   if err := bv.verifier.VerifyStateTransition(ctx, action); err != nil {
-       return err
+// checkEventOrder rejects batches queuing two events to one object. Each
+// event to an object extends the object's event chain from its head, so in
+// one batch the later event would conflict with the earlier one on it.
+func checkEventOrder(batch []chain.Action) error {
+   queued := make(map[string]int)
+   for i, action := range batch {
+       var target string
+       switch a := action.(type) {
+       case *actions.SendEventAction:
+           target = a.IDTo
+       case *actions.PipelineAction:
+           target = a.ID
+       default:
+           continue
+       }
+       if at, ok := queued[target]; ok {
+           return fmt.Errorf("%w: events to %s at %d and %d", ErrInvalidEventOrder, target, at, i)
+       }
+       queued[target] = i
    }
+   return nil
+}
 
-   // Then verify in batch context
+// targetObjects returns the objects [action] requires to exist.
+func targetObjects(action chain.Action) []string {
    switch a := action.(type) {
-   case *actions.CreateObjectAction:
-       return bv.verifyCreateInBatch(ctx, a)
-   case *actions.SendEventAction:
-       return bv.verifyEventInBatch(ctx, a)
    case *actions.SetInputObjectAction:
-       return bv.verifySetInputInBatch(ctx, a)
+       return []string{a.ID}
+   case *actions.SendEventAction:
+       return []string{a.IDTo}
+   case *actions.PipelineAction:
+       if a.Next == "" {
+           return []string{a.ID}
+       }
+       return []string{a.ID, a.Next}
    }
-
    return nil
 }
 
-func (bv *BatchVerifier) verifyCreateInBatch(ctx context.Context, action *actions.CreateObjectAction) error {
-   // Just verify the object hasn't already been created in this batch
-   if info, exists := bv.objectModifications[action.ID]; exists && info.created {
-       return ErrDuplicateAction
+// Regions returns the regions the batch acts on, each with the positions
+// of its actions in batch order. The actions of one transaction apply all
+// or none, so a transaction listing several regions updates every one of
+// them or none.
+func (p *BatchPlan) Regions() map[string][]int {
+   regions := make(map[string][]int, len(p.regions))
+   for regionID, positions := range p.regions {
+       regions[regionID] = append([]int(nil), positions...)
    }
-   return nil
+   return regions
 }
 
-func (bv *BatchVerifier) verifyEventInBatch(ctx context.Context, action *actions.SendEventAction) error {
-   // Verify target object exists and isn't being created in this batch
-   if info, exists := bv.objectModifications[action.IDTo]; exists && info.created {
-       return ErrConflictingAction
+// RegionWriteSets groups the keys the actions of [batch], executed by
+// [actor], may write by the region each action acts on. Keys of actions
+// acting on no region are grouped under "". A key written by actions of
+// several regions is in each of their sets.
+func RegionWriteSets(batch []chain.Action, actor codec.Address) map[string]state.Keys {
+   sets := make(map[string]state.Keys)
+   for _, action := range batch {
+       regionID := actions.ActionRegion(action)
+       set, ok := sets[regionID]
+       if !ok {
+           set = state.Keys{}
+           sets[regionID] = set
+       }
+       for key, perm := range action.StateKeys(actor, ids.Empty) {
+           if perm.Has(state.Write) {
+               set[key] = perm
+           }
+       }
    }
-   return nil
+   return sets
 }
 
-func (bv *BatchVerifier) verifySetInputInBatch(ctx context.Context, action *actions.SetInputObjectAction) error {
-   // Verify target object either exists or is being created in this batch
-   if info, exists := bv.objectModifications[action.ID]; !exists && !info.created {
-       return ErrConflictingAction
+// createdBefore reports whether [objectID] is created by an action before
+// position [pos]. A nil plan is a batch of one action.
+func (p *BatchPlan) createdBefore(objectID string, pos int) bool {
+   if p == nil {
+       return false
    }
-   return nil
+   at, ok := p.created[objectID]
+   return ok && at < pos
 }
 
-func (bv *BatchVerifier) verifyBatchConstraints(ctx context.Context) error {
-   // Verify event time ordering
-   if err := bv.verifyEventOrdering(ctx); err != nil {
-       return err
+// regionRoot returns the region root the execution at [pos] follows, if an
+// earlier one in the batch set it.
+func (p *BatchPlan) regionRoot(pos int) (ids.ID, bool) {
+   if p == nil {
+       return ids.Empty, false
    }
-   return nil
+   root, ok := p.roots[pos]
+   return root, ok
 }
 
-func (bv *BatchVerifier) verifyEventOrdering(ctx context.Context) error {
-   // Verify events are properly ordered by timestamp
-   for _, events := range bv.eventQueue {
-       lastTimestamp := ""
-       for _, event := range events {
-           if event.timestamp <= lastTimestamp {
-               return ErrInvalidEventOrder
-           }
-           lastTimestamp = event.timestamp
-       }
-   }
-   return nil
+// verifyAction verifies the action at [pos] within the batch context
+func (bv *BatchVerifier) verifyAction(ctx context.Context, plan *BatchPlan, pos int, action chain.Action) error {
+   return wrapActionError(action, bv.verifier.verifyStateTransition(ctx, plan, pos, action))
 }
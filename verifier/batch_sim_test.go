@@ -0,0 +1,256 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package verifier
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+const (
+	simRegion = "us-east"
+	// simUnknownRegion has no enclaves
+	simUnknownRegion = "eu-west"
+)
+
+// simObjects are the objects simulated batches touch. The first two exist
+// before the batch.
+var simObjects = []string{"alpha", "beta", "gamma", "delta"}
+
+// simFixture is the state simulated batches are verified against.
+type simFixture struct {
+	vm      *testvm.VM
+	enclave *testvm.Enclave
+	senders []codec.Address
+	// roots are the roots executions claim; the first is in state
+	roots []ids.ID
+}
+
+func newSimFixture(t *testing.T) *simFixture {
+	require := require.New(t)
+	ctx := context.Background()
+
+	f := &simFixture{
+		vm:      testvm.New(),
+		senders: []codec.Address{{1}, {2}},
+		roots:   []ids.ID{ids.GenerateTestID(), ids.GenerateTestID(), ids.GenerateTestID()},
+	}
+	sgx, _, err := f.vm.NewRegion(ctx, simRegion)
+	require.NoError(err)
+	f.enclave = sgx
+	require.NoError(storage.SetRegionRoot(ctx, f.vm.State, simRegion, f.roots[0]))
+	for _, id := range simObjects[:2] {
+		require.NoError(storage.SetObject(ctx, f.vm.State, id, map[string][]byte{"code": {0}}))
+	}
+	require.NoError(storage.SetEventNonce(ctx, f.vm.State, simObjects[0], f.senders[0], 2))
+	return f
+}
+
+// serial returns the state of [f] as the reference model sees it.
+func (f *simFixture) serial() *serialState {
+	return &serialState{
+		objects: map[string]bool{simObjects[0]: true, simObjects[1]: true},
+		nonces:  map[eventSeq]uint64{{object: simObjects[0], sender: f.senders[0]}: 2},
+		roots:   map[string]ids.ID{simRegion: f.roots[0]},
+		queued:  map[string]bool{},
+	}
+}
+
+// randomAction generates a create, input update, event or TEE execution
+// over the fixture's objects, senders and roots.
+func (f *simFixture) randomAction(r *rand.Rand) (chain.Action, error) {
+	object := simObjects[r.Intn(len(simObjects))]
+	switch r.Intn(4) {
+	case 0:
+		return &actions.CreateObjectAction{Version: consts.LatestActionVersion, ID: object, Code: []byte{0}}, nil
+	case 1:
+		return &actions.SetInputObjectAction{Version: consts.LatestActionVersion, ID: object}, nil
+	case 2:
+		return &actions.SendEventAction{
+			Version:      consts.LatestActionVersion,
+			IDTo:         object,
+			FunctionCall: "run",
+			Sender:       f.senders[r.Intn(len(f.senders))],
+			Nonce:        uint64(r.Intn(5)),
+		}, nil
+	default:
+		region := simRegion
+		if r.Intn(8) == 0 {
+			region = simUnknownRegion
+		}
+		// Either root may be left empty
+		pick := func() ids.ID {
+			if i := r.Intn(len(f.roots) + 1); i < len(f.roots) {
+				return f.roots[i]
+			}
+			return ids.Empty
+		}
+		return f.vm.Attest(region, f.enclave, actions.TEEExecResult{
+			ContractAddr: []byte("contract"),
+			PreStateRoot: pick(),
+			StateRoot:    pick(),
+		})
+	}
+}
+
+// eventSeq is the sequence of ordered events a sender sends to an object
+type eventSeq struct {
+	object string
+	sender codec.Address
+}
+
+// serialState is what the simulated actions read and write, executed one
+// at a time: the serializable execution [BatchVerifier] must agree with.
+type serialState struct {
+	objects map[string]bool
+	nonces  map[eventSeq]uint64
+	roots   map[string]ids.ID
+	// queued is the objects an event of the batch is queued to, which no
+	// other event of the batch may be
+	queued map[string]bool
+}
+
+// apply executes [action], reporting whether it succeeds.
+func (s *serialState) apply(action chain.Action) bool {
+	switch a := action.(type) {
+	case *actions.CreateObjectAction:
+		if s.objects[a.ID] {
+			return false
+		}
+		s.objects[a.ID] = true
+	case *actions.SetInputObjectAction:
+		return s.objects[a.ID]
+	case *actions.SendEventAction:
+		if !s.objects[a.IDTo] || s.queued[a.IDTo] {
+			return false
+		}
+		s.queued[a.IDTo] = true
+		if a.Nonce != 0 {
+			seq := eventSeq{object: a.IDTo, sender: a.Sender}
+			if a.Nonce != s.nonces[seq]+1 {
+				return false
+			}
+			s.nonces[seq] = a.Nonce
+		}
+	case *actions.TEEExecAction:
+		root, ok := s.roots[a.RegionID]
+		if !ok {
+			return false
+		}
+		if pre := a.ExecResult.PreStateRoot; pre != ids.Empty && pre != root {
+			return false
+		}
+		if a.ExecResult.StateRoot != ids.Empty {
+			s.roots[a.RegionID] = a.ExecResult.StateRoot
+		}
+	}
+	return true
+}
+
+// serializable reports whether every action of [batch] succeeds when
+// executed in order from [s].
+func serializable(s *serialState, batch []chain.Action) bool {
+	for _, action := range batch {
+		if !s.apply(action) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestBatchSerializable checks, for random batches and orderings of them,
+// that a batch is accepted exactly when executing it serially succeeds.
+// Failures name the seed to replay.
+func TestBatchSerializable(t *testing.T) {
+	const (
+		seeds     = 500
+		orderings = 4
+		maxSize   = 10
+	)
+	ctx := context.Background()
+	f := newSimFixture(t)
+	bv := NewBatchVerifier(f.vm.State)
+	t.Cleanup(func() { SetWorkers(0) })
+
+	var accepted, rejected int
+	for seed := int64(0); seed < seeds; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		batch := make([]chain.Action, 1+r.Intn(maxSize))
+		for i := range batch {
+			action, err := f.randomAction(r)
+			require.NoError(t, err, "seed %d", seed)
+			batch[i] = action
+		}
+		for ordering := 0; ordering < orderings; ordering++ {
+			r.Shuffle(len(batch), func(i, j int) { batch[i], batch[j] = batch[j], batch[i] })
+			want := serializable(f.serial(), batch)
+			if want {
+				accepted++
+			} else {
+				rejected++
+			}
+			for _, n := range []int{1, 4} {
+				SetWorkers(n)
+				err := bv.VerifyBatch(ctx, batch)
+				require.Equal(t, want, err == nil, "seed %d, ordering %d, workers %d: %v", seed, ordering, n, err)
+			}
+		}
+	}
+	// Both outcomes are common enough to exercise
+	require.Greater(t, accepted, seeds/10)
+	require.Greater(t, rejected, seeds/10)
+}
+
+// TestBatchOrder covers the orderings the batch analysis once got wrong.
+func TestBatchOrder(t *testing.T) {
+	ctx := context.Background()
+	f := newSimFixture(t)
+	bv := NewBatchVerifier(f.vm.State)
+
+	exec := func(pre, post ids.ID) *actions.TEEExecAction {
+		action, err := f.vm.Attest(simRegion, f.enclave, actions.TEEExecResult{
+			ContractAddr: []byte("contract"),
+			PreStateRoot: pre,
+			StateRoot:    post,
+		})
+		require.NoError(t, err)
+		return action
+	}
+	create := &actions.CreateObjectAction{Version: consts.LatestActionVersion, ID: "gamma", Code: []byte{0}}
+	event := &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "gamma", FunctionCall: "run", Sender: f.senders[1], Nonce: 1}
+	next := &actions.SendEventAction{Version: consts.LatestActionVersion, IDTo: "gamma", FunctionCall: "run", Sender: f.senders[1], Nonce: 2}
+	input := &actions.SetInputObjectAction{Version: consts.LatestActionVersion, ID: "gamma"}
+	first, second := exec(f.roots[0], f.roots[1]), exec(f.roots[1], f.roots[2])
+
+	tests := []struct {
+		name  string
+		batch []chain.Action
+		err   error
+	}{
+		{name: "use after create", batch: []chain.Action{create, event, input}},
+		{name: "event before create", batch: []chain.Action{event, create}, err: ErrConflictingAction},
+		{name: "input before create", batch: []chain.Action{input, create}, err: ErrConflictingAction},
+		{name: "second event", batch: []chain.Action{create, event, next}, err: ErrInvalidEventOrder},
+		{name: "duplicate create", batch: []chain.Action{create, create}, err: ErrDuplicateAction},
+		{name: "nonce gap", batch: []chain.Action{create, next}, err: actions.ErrEventNonceGap},
+		{name: "chained executions", batch: []chain.Action{first, second}},
+		{name: "stale execution", batch: []chain.Action{second, first}, err: actions.ErrStateRootMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, bv.VerifyBatch(ctx, tt.batch), tt.err)
+		})
+	}
+}
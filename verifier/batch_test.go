@@ -0,0 +1,90 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package verifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/testvm"
+)
+
+func TestBatchSignatures(t *testing.T) {
+	ctx := context.Background()
+	f := testvm.NewFixture(t)
+	userKey, err := ed25519.GeneratePrivateKey()
+	require.NoError(t, err)
+	bv := NewBatchVerifier(f.State)
+	result := actions.TEEExecResult{ContractAddr: []byte("contract")}
+
+	var batch []chain.Action
+	for i := range 4 {
+		txData := []byte{byte(i)}
+		exec, err := f.AttestRequest(testvm.Region, f.SGX, txData, actions.SignRequest(userKey, testvm.Region, txData), result)
+		require.NoError(t, err)
+		batch = append(batch, exec)
+	}
+	dual, err := f.AttestDual(testvm.Region, f.SGX, f.SEV, result, result)
+	require.NoError(t, err)
+	batch = append(batch, dual)
+	signed, err := actions.Signatures(ctx, f.State, dual)
+	require.NoError(t, err)
+	require.Len(t, signed, 2)
+
+	forgedUser := *batch[2].(*actions.TEEExecAction)
+	forgedUser.UserSig = actions.SignRequest(userKey, testvm.Region, []byte("other"))
+	forgedPeer := *dual
+	forgedPeer.Peer = &actions.PeerExecution{
+		ExecResult:  dual.Peer.ExecResult,
+		Attestation: dual.Peer.Attestation,
+	}
+	forgedPeer.Peer.Attestation.Signature = f.SEV.Sign([]byte("other"))
+
+	tests := []struct {
+		name        string
+		batch       []chain.Action
+		expectedErr error
+	}{
+		{
+			name:  "Valid",
+			batch: batch,
+		},
+		{
+			// A failing batch is checked item by item to name the culprit
+			name:        "ForgedUser",
+			batch:       []chain.Action{batch[0], batch[1], &forgedUser, batch[3], dual},
+			expectedErr: actions.ErrInvalidUserSig,
+		},
+		{
+			name:        "ForgedPeer",
+			batch:       append(batch[:4:4], &forgedPeer),
+			expectedErr: actions.ErrInvalidSignature,
+		},
+		{
+			// Below the batch size, signatures are checked one by one
+			name:        "Single",
+			batch:       []chain.Action{&forgedUser},
+			expectedErr: actions.ErrInvalidUserSig,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			err := bv.VerifySignatures(ctx, tt.batch)
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				return
+			}
+			var actionErr *actions.ActionError
+			require.ErrorAs(err, &actionErr)
+			require.Equal(consts.TEEExecID, actionErr.TypeID)
+		})
+	}
+}
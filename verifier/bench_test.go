@@ -0,0 +1,80 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package verifier
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/mocktee"
+)
+
+var batchSizes = []int{16, 64, 256}
+
+func BenchmarkAttestationVerify(b *testing.B) {
+	for _, enclaveType := range []string{mocktee.EnclaveSGX, mocktee.EnclaveSEV} {
+		enclave, err := mocktee.NewEnclave(enclaveType)
+		require.NoError(b, err)
+		digest := sha256.Sum256([]byte("result"))
+
+		b.Run(enclaveType+"/signature", func(b *testing.B) {
+			var sig ed25519.Signature
+			copy(sig[:], enclave.Sign(digest[:]))
+			pub := enclave.PublicKey()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if !ed25519.Verify(digest[:], pub, sig) {
+					b.Fatal("signature rejected")
+				}
+			}
+		})
+		b.Run(enclaveType+"/quote", func(b *testing.B) {
+			quote, err := enclave.Quote(digest[:])
+			require.NoError(b, err)
+			pub := enclave.PublicKey()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				q, err := mocktee.ParseQuote(enclaveType, quote)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := q.Verify(pub, digest[:]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBatchAnalyze(b *testing.B) {
+	for _, size := range batchSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			batch := make([]chain.Action, size)
+			for i := range batch {
+				id := fmt.Sprintf("object-%d", i)
+				if i%2 == 0 {
+					batch[i] = &actions.CreateObjectAction{Version: consts.LatestActionVersion, ID: id}
+				} else {
+					batch[i] = &actions.SetInputObjectAction{Version: consts.LatestActionVersion, ID: id}
+				}
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := PlanBatch(batch); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
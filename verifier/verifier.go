@@ -3,18 +3,21 @@ package verifier
 import (
     "context"
     "errors"
+    "fmt"
 
     "github.com/ava-labs/hypersdk/chain"
     "github.com/ava-labs/hypersdk/state"
 
     "github.com/rhombus-tech/vm/actions"
     "github.com/rhombus-tech/vm/consts"
+    "github.com/rhombus-tech/vm/envelope"
     "github.com/rhombus-tech/vm/storage"
 )
 
 var (
     ErrInputObjectMissing = errors.New("input object not found")
     ErrInvalidEventOrder  = errors.New("invalid event order")
+    ErrUnknownAction      = errors.New("unknown action type")
 )
 
 type StateVerifier struct {
@@ -64,17 +67,111 @@ func (v *StateVerifier) VerifyObjectState(ctx context.Context, obj map[string][]
     return nil
 }
 
+// VerifyStateTransition checks [action] against current state. Errors are
+// annotated with the action and the object it targets.
 func (v *StateVerifier) VerifyStateTransition(ctx context.Context, action chain.Action) error {
+    return wrapActionError(action, v.verifyStateTransition(ctx, nil, 0, action))
+}
+
+// verifyStateTransition checks the action at position [pos] of the batch
+// [plan] against current state and what the actions before it leave.
+func (v *StateVerifier) verifyStateTransition(ctx context.Context, plan *BatchPlan, pos int, action chain.Action) error {
+    // Reject action types disabled or not yet activated by the admin set
+    if err := actions.CheckActionEnabled(ctx, v.state, action.GetTypeID()); err != nil {
+        return err
+    }
+    // Reject wire versions not yet active at this height. Verification
+    // ahead of a block follows the default schedule; execution checks the
+    // chain's own.
+    if err := actions.CheckActionVersion(ctx, nil, v.state, action); err != nil {
+        return err
+    }
+
     switch a := action.(type) {
     case *actions.CreateObjectAction:
         return v.verifyCreateObject(ctx, a)
     case *actions.SetInputObjectAction:
-        return v.verifySetInputObject(ctx, a)
+        return v.verifySetInputObject(ctx, a, plan.createdBefore(a.ID, pos))
     case *actions.SendEventAction:
-        return v.verifyEvent(ctx, a)
+        return v.verifyEvent(ctx, a, plan.createdBefore(a.IDTo, pos))
+    case *actions.PipelineAction:
+        return v.verifyPipeline(ctx, a, plan, pos)
+    case *actions.TEEExecAction:
+        return v.verifyTEEExec(ctx, a, plan, pos)
+    case *actions.CommitObjectAction:
+        return v.verifyCommitObject(ctx, a)
+    case *actions.CreateRegionAction,
+        *actions.UpdateRegionAction,
+        *actions.ClaimRewardsAction,
+        *actions.ProposeAction,
+        *actions.VoteAction,
+        *actions.ExecuteProposalAction,
+        *actions.AdminAction,
+        *actions.StartUploadAction,
+        *actions.AppendChunkAction,
+        *actions.SettleRegionAction,
+        *actions.ChallengeSettlementAction,
+        *actions.FinalizeSettlementAction,
+        *actions.SetNitroPolicyAction,
+        *actions.RegisterNitroEnclaveAction,
+        *actions.RegisterCCAEnclaveAction,
+        *actions.SetPlatformPolicyAction,
+        *actions.PublishCollateralAction,
+        *actions.ReattestEnclaveAction,
+        *actions.QueueRequestAction,
+        *actions.ApproveAction,
+        *actions.TransferFromAction,
+        *actions.SetSponsorPolicyAction,
+        *actions.CreateAssetAction,
+        *actions.MintAssetAction,
+        *actions.TransferAssetAction,
+        *actions.PublishRandomnessAction,
+        *actions.RegisterFeedAction,
+        *actions.PublishFeedAction,
+        *actions.SealStorageAction,
+        *actions.ResealStorageAction,
+        *actions.PublishAppKeyAction,
+        *actions.RotateAppKeyAction,
+        *actions.RevokeAppKeyAction,
+        *actions.CreateRegionFromTemplateAction,
+        *actions.RegisterNameAction,
+        *actions.TransferNameAction,
+        *actions.SetExecLimitsAction,
+        *actions.FreezeAndExportRegionAction,
+        *actions.ImportRegionAction,
+        *actions.AuthorizeSessionAction,
+        *actions.RevokeSessionAction,
+        *actions.SubmitCheckpointAction,
+        *actions.PruneEnclaveAction,
+        *actions.PreemptEventAction,
+        *actions.StartSagaAction,
+        *actions.WithdrawVoteAction:
+        // Past the checks above, these depend on their actor, such as its
+        // balance, session or uploads, and are checked when they execute
+        return nil
     default:
-        return fmt.Errorf("unknown action type: %T", action)
+        return fmt.Errorf("%w: %T", ErrUnknownAction, action)
+    }
+}
+
+// wrapActionError annotates [err] with [action] and the object it targets.
+func wrapActionError(action chain.Action, err error) error {
+    var objectID string
+    switch a := action.(type) {
+    case *actions.CreateObjectAction:
+        objectID = a.ID
+    case *actions.SetInputObjectAction:
+        objectID = a.ID
+    case *actions.SendEventAction:
+        objectID = a.IDTo
+    case *actions.PipelineAction:
+        objectID = a.ID
+    case *actions.CommitObjectAction:
+        objectID = a.ObjectID
+    case *actions.TEEExecAction:
+        return actions.WrapActionError(action.GetTypeID(), a.RegionID, "", err)
     }
+    return actions.WrapActionError(action.GetTypeID(), "", objectID, err)
 }
 
 // Helper functions for specific verifications
@@ -94,7 +191,24 @@ func (v *StateVerifier) verifyCreateObject(ctx context.Context, action *actions.
     return v.VerifyObjectState(ctx, obj)
 }
 
-func (v *StateVerifier) verifySetInputObject(ctx context.Context, action *actions.SetInputObjectAction) error {
+// verifyCommitObject checks the uploaded object does not exist yet.
+func (v *StateVerifier) verifyCommitObject(ctx context.Context, action *actions.CommitObjectAction) error {
+    exists, err := storage.GetObject(ctx, v.state, action.ObjectID)
+    if err != nil {
+        return err
+    }
+    if exists != nil {
+        return actions.ErrObjectExists
+    }
+    return v.VerifyObjectState(ctx, map[string][]byte{"storage": action.Storage})
+}
+
+// verifySetInputObject checks the object exists, or that [created] by an
+// earlier action of the batch.
+func (v *StateVerifier) verifySetInputObject(ctx context.Context, action *actions.SetInputObjectAction, created bool) error {
+    if created {
+        return nil
+    }
     obj, err := storage.GetObject(ctx, v.state, action.ID)
     if err != nil {
         return err
@@ -106,24 +220,89 @@ func (v *StateVerifier) verifySetInputObject(ctx context.Context, action *action
     return nil
 }
 
-func (v *StateVerifier) verifyEvent(ctx context.Context, action *actions.SendEventAction) error {
-    targetObj, err := storage.GetObject(ctx, v.state, action.IDTo)
+// verifyEvent checks the event's target exists, or is [created] by an
+// earlier action of the batch.
+func (v *StateVerifier) verifyEvent(ctx context.Context, action *actions.SendEventAction, created bool) error {
+    if !created {
+        targetObj, err := storage.GetObject(ctx, v.state, action.IDTo)
+        if err != nil {
+            return err
+        }
+        if targetObj == nil {
+            return actions.ErrObjectNotFound
+        }
+
+        if err := v.verifyFunctionExists(targetObj, action.FunctionCall); err != nil {
+            return err
+        }
+    }
+
+    if len(action.Parameters) > consts.MaxStorageSize {
+        return actions.ErrStorageTooLarge
+    }
+    if action.Tip > consts.MaxEventTip {
+        return actions.ErrTipTooLarge
+    }
+    if action.Encrypted {
+        if err := envelope.Check(action.Parameters); err != nil {
+            return fmt.Errorf("%w: %w", actions.ErrSealedParams, err)
+        }
+    }
+
+    // A batch queues one event per object (see [PlanBatch]), so the
+    // nonce continues the sequence in state
+    if action.Nonce != 0 {
+        last, err := storage.GetEventNonce(ctx, v.state, action.IDTo, action.Sender)
+        if err != nil {
+            return err
+        }
+        if err := actions.CheckEventNonce(last, action.Nonce); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// verifyPipeline checks the stage as the event it sends, and that the next
+// stage, if any, exists or is created by an earlier action of the batch.
+func (v *StateVerifier) verifyPipeline(ctx context.Context, action *actions.PipelineAction, plan *BatchPlan, pos int) error {
+    if err := v.verifyEvent(ctx, action.Event(), plan.createdBefore(action.ID, pos)); err != nil {
+        return err
+    }
+    if action.Next == "" || plan.createdBefore(action.Next, pos) {
+        return nil
+    }
+    obj, err := storage.GetObject(ctx, v.state, action.Next)
     if err != nil {
         return err
     }
-    if targetObj == nil {
+    if obj == nil {
         return actions.ErrObjectNotFound
     }
+    return nil
+}
 
-    if err := v.verifyFunctionExists(targetObj, action.FunctionCall); err != nil {
+// verifyTEEExec checks the execution's region exists and that it starts
+// from the region root: the one the previous execution of the region in
+// the batch set, if any, else the one in state.
+func (v *StateVerifier) verifyTEEExec(ctx context.Context, action *actions.TEEExecAction, plan *BatchPlan, pos int) error {
+    _, exists, err := storage.GetRegion(ctx, v.state, action.RegionID)
+    if err != nil {
         return err
     }
-
-    if len(action.Parameters) > consts.MaxStorageSize {
-        return actions.ErrStorageTooLarge
+    if !exists {
+        return actions.ErrInvalidRegion
     }
 
-    return nil
+    root, ok := plan.regionRoot(pos)
+    if !ok {
+        root, err = storage.GetRegionRoot(ctx, v.state, action.RegionID)
+        if err != nil {
+            return err
+        }
+    }
+    return action.VerifyPreconditions(root)
 }
 
 func (v *StateVerifier) verifyFunctionExists(obj map[string][]byte, function string) error {
@@ -0,0 +1,117 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"github.com/ava-labs/hypersdk/abi"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+// actionResults maps each registered action type ID to the type ID of the
+// result it returns on success.
+var actionResults = map[uint8]uint8{
+	consts.CreateObjectID:             consts.CreateObjectResultID,
+	consts.SendEventID:                consts.SendEventResultID,
+	consts.SetInputObjectID:           consts.SetInputObjectResultID,
+	consts.CreateRegionID:             consts.CreateRegionResultID,
+	consts.UpdateRegionID:             consts.UpdateRegionResultID,
+	consts.TEEExecID:                  consts.TEEExecResultID,
+	consts.ClaimRewardsID:             consts.ClaimRewardsResultID,
+	consts.ProposeID:                  consts.ProposeResultID,
+	consts.VoteID:                     consts.VoteResultID,
+	consts.ExecuteProposalID:          consts.ExecuteProposalResultID,
+	consts.AdminID:                    consts.AdminResultID,
+	consts.StartUploadID:              consts.StartUploadResultID,
+	consts.AppendChunkID:              consts.AppendChunkResultID,
+	consts.CommitObjectID:             consts.CommitObjectResultID,
+	consts.SettleRegionID:             consts.SettleRegionResultID,
+	consts.ChallengeSettlementID:      consts.ChallengeSettlementResultID,
+	consts.FinalizeSettlementID:       consts.FinalizeSettlementResultID,
+	consts.SetNitroPolicyID:           consts.SetNitroPolicyResultID,
+	consts.RegisterNitroEnclaveID:     consts.RegisterNitroEnclaveResultID,
+	consts.RegisterCCAEnclaveID:       consts.RegisterCCAEnclaveResultID,
+	consts.SetPlatformPolicyID:        consts.SetPlatformPolicyResultID,
+	consts.PublishCollateralID:        consts.PublishCollateralResultID,
+	consts.ReattestEnclaveID:          consts.ReattestEnclaveResultID,
+	consts.QueueRequestID:             consts.QueueRequestResultID,
+	consts.ApproveID:                  consts.ApproveResultID,
+	consts.TransferFromID:             consts.TransferFromResultID,
+	consts.SetSponsorPolicyID:         consts.SetSponsorPolicyResultID,
+	consts.CreateAssetID:              consts.CreateAssetResultID,
+	consts.MintAssetID:                consts.MintAssetResultID,
+	consts.TransferAssetID:            consts.TransferAssetResultID,
+	consts.PublishRandomnessID:        consts.PublishRandomnessResultID,
+	consts.RegisterFeedID:             consts.RegisterFeedResultID,
+	consts.PublishFeedID:              consts.PublishFeedResultID,
+	consts.SealStorageID:              consts.SealStorageResultID,
+	consts.ResealStorageID:            consts.ResealStorageResultID,
+	consts.PublishAppKeyID:            consts.PublishAppKeyResultID,
+	consts.RotateAppKeyID:             consts.RotateAppKeyResultID,
+	consts.RevokeAppKeyID:             consts.RevokeAppKeyResultID,
+	consts.CreateRegionFromTemplateID: consts.CreateRegionFromTemplateResultID,
+	consts.RegisterNameID:             consts.RegisterNameResultID,
+	consts.TransferNameID:             consts.TransferNameResultID,
+	consts.SetExecLimitsID:            consts.SetExecLimitsResultID,
+	consts.FreezeAndExportRegionID:    consts.FreezeAndExportRegionResultID,
+	consts.ImportRegionID:             consts.ImportRegionResultID,
+	consts.AuthorizeSessionID:         consts.AuthorizeSessionResultID,
+	consts.RevokeSessionID:            consts.RevokeSessionResultID,
+	consts.SubmitCheckpointID:         consts.SubmitCheckpointResultID,
+	consts.PruneEnclaveID:             consts.PruneEnclaveResultID,
+	consts.PipelineID:                 consts.PipelineResultID,
+	consts.PreemptEventID:             consts.PreemptEventResultID,
+	consts.StartSagaID:                consts.StartSagaResultID,
+	consts.WithdrawVoteID:             consts.WithdrawVoteResultID,
+}
+
+// ActionDescriptor carries what the ABI cannot express about an action.
+type ActionDescriptor struct {
+	ID       uint8  `json:"id"`
+	Name     string `json:"name"`
+	ResultID uint8  `json:"resultId"`
+	// Versioned actions start their packed form with a version byte.
+	Versioned bool `json:"versioned"`
+	// LatestVersion is the newest version this binary encodes.
+	LatestVersion uint8 `json:"latestVersion,omitempty"`
+}
+
+// Descriptor is a machine-readable description of every registered action
+// and result so wallets and SDKs can generate encoders and forms.
+type Descriptor struct {
+	ABI     abi.ABI            `json:"abi"`
+	Actions []ActionDescriptor `json:"actions"`
+}
+
+// GetDescriptor builds the [Descriptor] from the registered parsers.
+func GetDescriptor() (*Descriptor, error) {
+	vmABI, err := abi.NewABI(ActionParser.GetRegisteredTypes(), OutputParser.GetRegisteredTypes())
+	if err != nil {
+		return nil, err
+	}
+	d := &Descriptor{ABI: vmABI}
+	for _, typ := range ActionParser.GetRegisteredTypes() {
+		desc := ActionDescriptor{
+			ID:       typ.GetTypeID(),
+			Name:     abiName(vmABI.Actions, typ.GetTypeID()),
+			ResultID: actionResults[typ.GetTypeID()],
+		}
+		if _, ok := typ.(actions.Versioned); ok {
+			desc.Versioned = true
+			desc.LatestVersion = consts.LatestActionVersion
+		}
+		d.Actions = append(d.Actions, desc)
+	}
+	return d, nil
+}
+
+func abiName(structs []abi.TypedStruct, id uint8) string {
+	for _, s := range structs {
+		if s.ID == id {
+			return s.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/hypersdk/consts"
+)
+
+var (
+	ErrMissingToken  = errors.New("missing access token")
+	ErrInvalidToken  = errors.New("invalid access token")
+	ErrExpiredToken  = errors.New("access token expired")
+	ErrRegionDenied  = errors.New("access token not valid for region")
+	ErrInvalidSecret = errors.New("invalid access token secret")
+)
+
+// AccessConfig gates region subscriptions. Regions listed in PrivateRegions
+// stream only to clients presenting a token for that region, signed with
+// Secret (hex). Other regions stay public.
+type AccessConfig struct {
+	Secret         string   `json:"secret"`
+	PrivateRegions []string `json:"privateRegions"`
+}
+
+func (c AccessConfig) secret() ([]byte, error) {
+	secret, err := hex.DecodeString(c.Secret)
+	if err != nil || (len(secret) == 0 && len(c.PrivateRegions) > 0) {
+		return nil, ErrInvalidSecret
+	}
+	return secret, nil
+}
+
+// Authorize checks that [r] may subscribe to [regionID]. The token is read
+// from the Authorization bearer header or, for browser WebSockets that
+// cannot set headers, the token query parameter.
+func (c AccessConfig) Authorize(r *http.Request, regionID string, now time.Time) error {
+	if !slices.Contains(c.PrivateRegions, regionID) {
+		return nil
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return ErrMissingToken
+	}
+	secret, err := c.secret()
+	if err != nil {
+		return err
+	}
+	regions, err := VerifyAccessToken(secret, token, now)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(regions, regionID) {
+		return fmt.Errorf("%w: %s", ErrRegionDenied, regionID)
+	}
+	return nil
+}
+
+// IssueAccessToken returns a token for [regions] valid until [expiry]. The
+// token is [expiry][count]([len][region])... followed by an HMAC-SHA256 of
+// those bytes, base64url encoded.
+func IssueAccessToken(secret []byte, regions []string, expiry time.Time) string {
+	payload := binary.BigEndian.AppendUint64(nil, uint64(expiry.Unix()))
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(regions)))
+	for _, region := range regions {
+		payload = binary.BigEndian.AppendUint16(payload, uint16(len(region)))
+		payload = append(payload, region...)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(payload))
+}
+
+// VerifyAccessToken checks the signature and expiry of [token] and returns
+// the regions it grants.
+func VerifyAccessToken(secret []byte, token string, now time.Time) ([]string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < consts.Uint64Len+consts.Uint16Len+sha256.Size {
+		return nil, ErrInvalidToken
+	}
+	payload, sig := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	expiry := int64(binary.BigEndian.Uint64(payload))
+	if now.Unix() > expiry {
+		return nil, ErrExpiredToken
+	}
+	count := int(binary.BigEndian.Uint16(payload[consts.Uint64Len:]))
+	rest := payload[consts.Uint64Len+consts.Uint16Len:]
+	regions := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if len(rest) < consts.Uint16Len {
+			return nil, ErrInvalidToken
+		}
+		n := int(binary.BigEndian.Uint16(rest))
+		rest = rest[consts.Uint16Len:]
+		if len(rest) < n {
+			return nil, ErrInvalidToken
+		}
+		regions = append(regions, string(rest[:n]))
+		rest = rest[n:]
+	}
+	return regions, nil
+}
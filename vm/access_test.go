@@ -0,0 +1,53 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessToken(t *testing.T) {
+	require := require.New(t)
+	secret := []byte("operator-secret")
+	now := time.Unix(1_700_000_000, 0)
+
+	token := IssueAccessToken(secret, []string{"us-east", "eu-west"}, now.Add(time.Hour))
+	regions, err := VerifyAccessToken(secret, token, now)
+	require.NoError(err)
+	require.Equal([]string{"us-east", "eu-west"}, regions)
+
+	_, err = VerifyAccessToken(secret, token, now.Add(2*time.Hour))
+	require.ErrorIs(err, ErrExpiredToken)
+	_, err = VerifyAccessToken([]byte("other"), token, now)
+	require.ErrorIs(err, ErrInvalidToken)
+	_, err = VerifyAccessToken(secret, token[:len(token)-2], now)
+	require.ErrorIs(err, ErrInvalidToken)
+}
+
+func TestAuthorizeRegion(t *testing.T) {
+	require := require.New(t)
+	secret := []byte("operator-secret")
+	now := time.Unix(1_700_000_000, 0)
+	access := AccessConfig{
+		Secret:         hex.EncodeToString(secret),
+		PrivateRegions: []string{"private"},
+	}
+
+	req := httptest.NewRequest("GET", RegionEventsEndpoint+"?region=public", nil)
+	require.NoError(access.Authorize(req, "public", now))
+	require.ErrorIs(access.Authorize(req, "private", now), ErrMissingToken)
+
+	token := IssueAccessToken(secret, []string{"private"}, now.Add(time.Minute))
+	req = httptest.NewRequest("GET", RegionEventsEndpoint+"?region=private&token="+token, nil)
+	require.NoError(access.Authorize(req, "private", now))
+
+	req = httptest.NewRequest("GET", RegionEventsEndpoint, nil)
+	req.Header.Set("Authorization", "Bearer "+IssueAccessToken(secret, []string{"public"}, now.Add(time.Minute)))
+	require.ErrorIs(access.Authorize(req, "private", now), ErrRegionDenied)
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// maxAuditTrail bounds the entries one auditTrail request returns
+const maxAuditTrail = 256
+
+var ErrAuditEntryMissing = errors.New("audit log entry missing")
+
+type AuditTrailArgs struct {
+	RegionID string `json:"regionId"`
+	// FromHeight and ToHeight bound the heights returned, inclusive. A
+	// zero ToHeight leaves the range open.
+	FromHeight uint64 `json:"fromHeight"`
+	ToHeight   uint64 `json:"toHeight"`
+	// Limit caps the entries returned, at most 256
+	Limit uint64 `json:"limit"`
+}
+
+type AuditRecord struct {
+	ActionID ids.ID `json:"actionId"`
+	storage.AuditEntry
+}
+
+type AuditTrailReply struct {
+	// Entries are oldest first. When more entries are in range than
+	// [Limit], the newest are returned.
+	Entries []AuditRecord `json:"entries"`
+	// Count is how many entries the region's log holds in all
+	Count uint64 `json:"count"`
+}
+
+// AuditTrail returns the admin operations on [RegionID] between
+// [FromHeight] and [ToHeight], walking the log back from its head.
+func (j *JSONRPCServer) AuditTrail(req *http.Request, args *AuditTrailArgs, reply *AuditTrailReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.AuditTrail")
+	defer span.End()
+
+	head, err := storage.GetAuditHeadFromState(ctx, j.vm.ReadState, args.RegionID)
+	if err != nil {
+		return err
+	}
+	limit := args.Limit
+	if limit == 0 || limit > maxAuditTrail {
+		limit = maxAuditTrail
+	}
+	reply.Count = head.Count
+	for actionID, seen := head.Latest, uint64(0); seen < head.Count && uint64(len(reply.Entries)) < limit; seen++ {
+		entry, err := storage.GetAuditEntryFromState(ctx, j.vm.ReadState, args.RegionID, actionID)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return ErrAuditEntryMissing
+		}
+		if entry.Height < args.FromHeight {
+			break
+		}
+		if args.ToHeight == 0 || entry.Height <= args.ToHeight {
+			reply.Entries = append(reply.Entries, AuditRecord{ActionID: actionID, AuditEntry: *entry})
+		}
+		actionID = entry.Prev
+	}
+	slices.Reverse(reply.Entries)
+	return nil
+}
+
+// AuditTrail returns up to [limit] admin operations on [regionID] between
+// heights [from] and [to], oldest first, and the size of its log.
+func (cli *JSONRPCClient) AuditTrail(ctx context.Context, regionID string, from, to, limit uint64) ([]AuditRecord, uint64, error) {
+	resp := new(AuditTrailReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"auditTrail",
+		&AuditTrailArgs{
+			RegionID:   regionID,
+			FromHeight: from,
+			ToHeight:   to,
+			Limit:      limit,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Entries, resp.Count, nil
+}
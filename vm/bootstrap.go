@@ -0,0 +1,89 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// maxBootstrapPage bounds the records one region bootstrap page reads
+const maxBootstrapPage = 256
+
+var ErrBootstrapUnavailable = errors.New("state is not available for bootstrap")
+
+type RegionBootstrapArgs struct {
+	RegionID string `json:"region_id"`
+	// Cursor is the Next of the previous page, nil for the first
+	Cursor *storage.BootstrapCursor `json:"cursor"`
+	// Limit bounds the records read, up to [maxBootstrapPage]. Zero means
+	// the maximum.
+	Limit int `json:"limit"`
+}
+
+type RegionBootstrapReply struct {
+	storage.BootstrapPage
+	// StateRoot is the chain state root the page was read at
+	StateRoot ids.ID `json:"state_root"`
+}
+
+// RegionBootstrap returns a page of what a newly onboarded enclave needs to
+// serve [RegionID] without replaying history: the region's latest attested
+// root, its objects, the events its executions stored and its pending
+// requests. Each page is read at the current state; a client that sees
+// [StateRoot] change between pages may restart for a consistent set.
+func (j *JSONRPCServer) RegionBootstrap(req *http.Request, args *RegionBootstrapArgs, reply *RegionBootstrapReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.RegionBootstrap")
+	defer span.End()
+
+	sdb, ok := j.vm.(stateDB)
+	if !ok {
+		return ErrBootstrapUnavailable
+	}
+	db, err := sdb.State()
+	if err != nil {
+		return err
+	}
+	view, err := db.NewView(ctx, merkledb.ViewChanges{})
+	if err != nil {
+		return err
+	}
+	limit := args.Limit
+	if limit <= 0 || limit > maxBootstrapPage {
+		limit = maxBootstrapPage
+	}
+	page, err := storage.GetBootstrapPage(ctx, view, args.RegionID, args.Cursor, limit)
+	if err != nil {
+		return err
+	}
+	root, err := view.GetMerkleRoot(ctx)
+	if err != nil {
+		return err
+	}
+	reply.BootstrapPage = *page
+	reply.StateRoot = root
+	return nil
+}
+
+// RegionBootstrap returns the page of [regionID] from [cursor], nil for the
+// first. The stream is complete once the page has no Next.
+func (cli *JSONRPCClient) RegionBootstrap(ctx context.Context, regionID string, cursor *storage.BootstrapCursor, limit int) (*RegionBootstrapReply, error) {
+	resp := new(RegionBootstrapReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"regionBootstrap",
+		&RegionBootstrapArgs{RegionID: regionID, Cursor: cursor, Limit: limit},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
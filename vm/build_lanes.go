@@ -0,0 +1,245 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/vm"
+)
+
+const BuildLanesNamespace = "buildLanes"
+
+// BuildLanesConfig sets how the blocks this node builds share space
+// between regions.
+type BuildLanesConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxRegionUnits caps the compute units of one region's transactions
+	// in a block. Zero means no cap.
+	MaxRegionUnits uint64 `json:"maxRegionUnits"`
+}
+
+func NewDefaultBuildLanesConfig() BuildLanesConfig {
+	return BuildLanesConfig{
+		Enabled:        true,
+		MaxRegionUnits: 1_000_000,
+	}
+}
+
+// BuildPolicy picks the transactions of the blocks this node builds from
+// the candidates the mempool streams. Candidates it holds back return to
+// the mempool for later blocks. Policies only shape this node's blocks:
+// they are not consensus rules, and blocks built by others are verified
+// as usual.
+type BuildPolicy interface {
+	// Reset starts a new block
+	Reset()
+	// Schedule returns, in order, the [candidates] to offer the block now,
+	// and those to hold back
+	Schedule(candidates []*chain.Transaction) (next, held []*chain.Transaction)
+}
+
+var (
+	buildPolicyLock sync.Mutex
+	// customPolicy, set with [SetBuildPolicy], takes precedence over
+	// configuredPolicy, set by [WithBuildLanes]
+	customPolicy     BuildPolicy
+	configuredPolicy BuildPolicy
+)
+
+// SetBuildPolicy installs a custom policy for the blocks this node builds,
+// in place of the one [WithBuildLanes] configures. Nil removes it.
+func SetBuildPolicy(policy BuildPolicy) {
+	buildPolicyLock.Lock()
+	defer buildPolicyLock.Unlock()
+
+	customPolicy = policy
+}
+
+// activeBuildPolicy returns the policy in effect, or nil if there is none.
+func activeBuildPolicy() BuildPolicy {
+	buildPolicyLock.Lock()
+	defer buildPolicyLock.Unlock()
+
+	if customPolicy != nil {
+		return customPolicy
+	}
+	return configuredPolicy
+}
+
+func WithBuildLanes() vm.Option {
+	return vm.NewOption(BuildLanesNamespace, NewDefaultBuildLanesConfig(), func(_ *vm.VM, config BuildLanesConfig) error {
+		buildPolicyLock.Lock()
+		defer buildPolicyLock.Unlock()
+
+		configuredPolicy = nil
+		if config.Enabled {
+			configuredPolicy = NewRegionLanes(config.MaxRegionUnits)
+		}
+		return nil
+	})
+}
+
+// TxStream is the part of the mempool blocks are built from: candidates
+// are streamed in batches, and those not included are restored.
+type TxStream interface {
+	StartStreaming(ctx context.Context)
+	PrepareStream(ctx context.Context, count int)
+	Stream(ctx context.Context, count int) []*chain.Transaction
+	FinishStreaming(ctx context.Context, restorable []*chain.Transaction) int
+}
+
+// maxScheduleBatches bounds the batches one Stream call pulls while the
+// policy holds back all it is offered.
+const maxScheduleBatches = 16
+
+// FairStream applies the installed [BuildPolicy] to a [TxStream]. Without
+// one, candidates pass through as the mempool streams them.
+type FairStream struct {
+	TxStream
+
+	policy BuildPolicy
+	held   []*chain.Transaction
+}
+
+// NewFairStream wraps the mempool [inner] that blocks are built from.
+func NewFairStream(inner TxStream) *FairStream {
+	return &FairStream{TxStream: inner}
+}
+
+func (f *FairStream) StartStreaming(ctx context.Context) {
+	f.policy = activeBuildPolicy()
+	f.held = nil
+	if f.policy != nil {
+		f.policy.Reset()
+	}
+	f.TxStream.StartStreaming(ctx)
+}
+
+// Stream returns the next candidates the policy offers. It pulls more
+// batches while the policy holds back everything, so the builder does not
+// take a held back batch for an empty mempool.
+func (f *FairStream) Stream(ctx context.Context, count int) []*chain.Transaction {
+	if f.policy == nil {
+		return f.TxStream.Stream(ctx, count)
+	}
+	for range maxScheduleBatches {
+		candidates := f.TxStream.Stream(ctx, count)
+		if len(candidates) == 0 {
+			return nil
+		}
+		next, held := f.policy.Schedule(candidates)
+		f.held = append(f.held, held...)
+		if len(next) > 0 {
+			return next
+		}
+	}
+	return nil
+}
+
+// FinishStreaming restores the held back candidates with [restorable].
+func (f *FairStream) FinishStreaming(ctx context.Context, restorable []*chain.Transaction) int {
+	restorable = append(restorable, f.held...)
+	f.held = nil
+	return f.TxStream.FinishStreaming(ctx, restorable)
+}
+
+// RegionLanes is the default [BuildPolicy]. Each region is a lane, and
+// transactions without a regional action share one more. Lanes are served
+// round-robin in the order they first appear in the block, one
+// transaction at a time, so a flood in one region cannot crowd out the
+// others. Once a region's transactions in the block reach [maxUnits]
+// compute units, the rest are held back for later blocks; its first
+// transaction is always offered. A transaction counts against the region
+// of its first regional action.
+type RegionLanes struct {
+	maxUnits uint64
+
+	order []string
+	next  int
+	units map[string]uint64
+}
+
+func NewRegionLanes(maxUnits uint64) *RegionLanes {
+	l := &RegionLanes{maxUnits: maxUnits}
+	l.Reset()
+	return l
+}
+
+func (l *RegionLanes) Reset() {
+	l.order = nil
+	l.next = 0
+	l.units = map[string]uint64{}
+}
+
+func (l *RegionLanes) Schedule(candidates []*chain.Transaction) ([]*chain.Transaction, []*chain.Transaction) {
+	regions := make([]string, len(candidates))
+	units := make([]uint64, len(candidates))
+	for i, tx := range candidates {
+		regions[i], units[i] = txRegion(tx), txUnits(tx)
+	}
+	nextIdx, heldIdx := l.schedule(regions, units)
+	next := make([]*chain.Transaction, len(nextIdx))
+	for i, j := range nextIdx {
+		next[i] = candidates[j]
+	}
+	held := make([]*chain.Transaction, len(heldIdx))
+	for i, j := range heldIdx {
+		held[i] = candidates[j]
+	}
+	return next, held
+}
+
+// schedule is [Schedule] over the regions and units of the candidates,
+// returning their indices.
+func (l *RegionLanes) schedule(regions []string, units []uint64) ([]int, []int) {
+	var held []int
+	lanes := map[string][]int{}
+	for i, region := range regions {
+		if _, ok := l.units[region]; !ok {
+			l.units[region] = 0
+			l.order = append(l.order, region)
+		}
+		if region != "" && l.maxUnits > 0 && l.units[region] > 0 && l.units[region]+units[i] > l.maxUnits {
+			held = append(held, i)
+			continue
+		}
+		l.units[region] += units[i]
+		lanes[region] = append(lanes[region], i)
+	}
+
+	next := make([]int, 0, len(regions)-len(held))
+	for len(next) < cap(next) {
+		region := l.order[l.next%len(l.order)]
+		l.next++
+		if lane := lanes[region]; len(lane) > 0 {
+			next = append(next, lane[0])
+			lanes[region] = lane[1:]
+		}
+	}
+	return next, held
+}
+
+// txRegion returns the region of the first regional action of [tx], or ""
+// if it has none.
+func txRegion(tx *chain.Transaction) string {
+	for _, action := range tx.Actions {
+		if region := actions.ActionRegion(action); region != "" {
+			return region
+		}
+	}
+	return ""
+}
+
+// txUnits sums the compute units of the actions of [tx]. Those of this
+// VM's actions do not depend on the rules.
+func txUnits(tx *chain.Transaction) uint64 {
+	var units uint64
+	for _, action := range tx.Actions {
+		units += action.ComputeUnits(nil)
+	}
+	return units
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionLanes(t *testing.T) {
+	require := require.New(t)
+	lanes := NewRegionLanes(300)
+
+	// A flood in "a" is interleaved with the other lanes, and held back
+	// once it reaches the cap
+	next, held := lanes.schedule(
+		[]string{"a", "a", "a", "a", "b", "", "b"},
+		[]uint64{100, 100, 100, 100, 100, 50, 100},
+	)
+	require.Equal([]int{0, 4, 5, 1, 6, 2}, next)
+	require.Equal([]int{3}, held)
+
+	// The rotation and the units carry over to the next batch of the block
+	next, held = lanes.schedule([]string{"a", "b", "c"}, []uint64{100, 100, 100})
+	require.Equal([]int{2, 1}, next)
+	require.Equal([]int{0}, held)
+
+	// A region's first transaction is offered even above the cap
+	lanes.Reset()
+	next, held = lanes.schedule([]string{"a", "a"}, []uint64{500, 1})
+	require.Equal([]int{0}, next)
+	require.Equal([]int{1}, held)
+
+	// Without a cap only the order changes
+	lanes = NewRegionLanes(0)
+	next, held = lanes.schedule([]string{"a", "a", "b"}, []uint64{1_000, 1_000, 1})
+	require.Equal([]int{0, 2, 1}, next)
+	require.Empty(held)
+}
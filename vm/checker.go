@@ -0,0 +1,76 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const CheckerNamespace = "consistencyChecker"
+
+// CheckerConfig runs [storage.CheckConsistency] after every [Every] accepted
+// blocks. Zero disables the checker.
+type CheckerConfig struct {
+	Every     uint64 `json:"every"`
+	MaxSupply uint64 `json:"maxSupply"`
+}
+
+func NewDefaultCheckerConfig() CheckerConfig {
+	return CheckerConfig{}
+}
+
+func WithConsistencyChecker() vm.Option {
+	return vm.NewOption(CheckerNamespace, NewDefaultCheckerConfig(), func(v *vm.VM, config CheckerConfig) error {
+		if config.Every == 0 {
+			return nil
+		}
+		vm.WithBlockSubscriptions(checkerFactory{vm: v, config: config})(v)
+		return nil
+	})
+}
+
+var _ event.SubscriptionFactory[*chain.StatefulBlock] = (*checkerFactory)(nil)
+
+type checkerFactory struct {
+	vm     *vm.VM
+	config CheckerConfig
+}
+
+func (f checkerFactory) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return &checker{vm: f.vm, config: f.config}, nil
+}
+
+type checker struct {
+	vm     *vm.VM
+	config CheckerConfig
+}
+
+// Accept logs invariant violations rather than failing the block: the block
+// is already accepted and the checker only reports.
+func (c *checker) Accept(blk *chain.StatefulBlock) error {
+	if blk.Height()%c.config.Every != 0 {
+		return nil
+	}
+	db, err := c.vm.State()
+	if err != nil {
+		return err
+	}
+	if err := storage.CheckConsistency(context.TODO(), db, c.config.MaxSupply); err != nil {
+		vmlog.Default().WithHeight(blk.Height()).Error("state consistency check failed", zap.Error(err))
+	}
+	return nil
+}
+
+func (*checker) Close() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type CheckpointArgs struct {
+	RegionID string `json:"regionId"`
+	// Height selects a checkpoint; zero selects the region's last one
+	Height uint64 `json:"height"`
+}
+
+type CheckpointReply struct {
+	Height uint64 `json:"height"`
+	// Checkpoint is nil if the region has none at [Height]
+	Checkpoint *storage.Checkpoint `json:"checkpoint,omitempty"`
+	// Committee is the checkpoint committee in effect now. Checkpoints
+	// signed by another committee report another hash.
+	Committee *actions.CheckpointCommittee `json:"committee,omitempty"`
+}
+
+// Checkpoint returns a validator co-signed checkpoint of a region root,
+// with the committee bridges verify its aggregate against.
+func (j *JSONRPCServer) Checkpoint(req *http.Request, args *CheckpointArgs, reply *CheckpointReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Checkpoint")
+	defer span.End()
+
+	value, err := storage.GetParamValueFromState(ctx, j.vm.ReadState, uint8(consts.ParamCheckpointCommittee))
+	if err != nil {
+		return err
+	}
+	if reply.Committee, _, err = actions.DecodeCheckpointCommittee(value); err != nil {
+		return err
+	}
+	reply.Height = args.Height
+	if reply.Height == 0 {
+		if reply.Height, err = storage.GetCheckpointHeadFromState(ctx, j.vm.ReadState, args.RegionID); err != nil || reply.Height == 0 {
+			return err
+		}
+	}
+	reply.Checkpoint, err = storage.GetCheckpointFromState(ctx, j.vm.ReadState, args.RegionID, reply.Height)
+	return err
+}
+
+// Checkpoint returns the checkpoint of [regionID] at [height], or its last
+// one if [height] is zero, and the current checkpoint committee.
+func (cli *JSONRPCClient) Checkpoint(ctx context.Context, regionID string, height uint64) (*CheckpointReply, error) {
+	resp := new(CheckpointReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"checkpoint",
+		&CheckpointArgs{RegionID: regionID, Height: height},
+		resp,
+	)
+	return resp, err
+}
@@ -66,6 +66,17 @@ func (cli *JSONRPCClient) Balance(ctx context.Context, addr codec.Address) (uint
 	return resp.Amount, err
 }
 
+func (cli *JSONRPCClient) Descriptor(ctx context.Context) (*Descriptor, error) {
+	resp := new(DescriptorReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"descriptor",
+		nil,
+		resp,
+	)
+	return resp.Descriptor, err
+}
+
 func (cli *JSONRPCClient) WaitForBalance(
 	ctx context.Context,
 	addr codec.Address,
@@ -0,0 +1,296 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/consensus/snowman"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const ClockMetricsEndpoint = "/clockmetrics"
+
+var ErrClockSkew = errors.New("local clock skewed from Roughtime")
+
+// ClockConfig sets how the node watches for clock skew. Attestations are
+// only accepted within the governed drift of block time, so a skewed
+// builder produces blocks whose executions fail.
+type ClockConfig struct {
+	// ProbeInterval is how often, in milliseconds, the local clock is
+	// compared with Roughtime. Zero disables the comparison.
+	ProbeInterval int64 `json:"probeInterval"`
+	// WarnSkew is the skew, in milliseconds, beyond which a warning is
+	// logged.
+	WarnSkew int64 `json:"warnSkew"`
+	// RefuseBuildSkew is the skew of the local clock from Roughtime, in
+	// milliseconds, beyond which the node refuses to build blocks. Zero
+	// never refuses.
+	RefuseBuildSkew int64 `json:"refuseBuildSkew"`
+}
+
+func NewDefaultClockConfig() ClockConfig {
+	return ClockConfig{
+		ProbeInterval: 60_000,
+		WarnSkew:      10_000,
+	}
+}
+
+// activeClock is the monitor of the running VM, consulted by [GuardedVM].
+var activeClock atomic.Pointer[clockMonitor]
+
+// clockMonitor compares three clocks: the local one, Roughtime, and the
+// chain's. Block timestamps are checked against the local clock and
+// against the Roughtime medians of the attestations the block carries.
+// The local clock is checked against Roughtime servers directly, as
+// blocks replayed while bootstrapping say nothing about it.
+type clockMonitor struct {
+	vm        *vm.VM
+	config    ClockConfig
+	roughtime RoughtimeConfig
+	file      *roughtimeFile
+
+	probing   atomic.Bool
+	lastProbe atomic.Int64
+	// localSkew is the last measured skew of the local clock ahead of
+	// Roughtime, valid once sampled is set
+	localSkew atomic.Int64
+	sampled   atomic.Bool
+
+	registry         *prometheus.Registry
+	localGauge       prometheus.Gauge
+	blockGauge       prometheus.Gauge
+	attestationGauge prometheus.Gauge
+}
+
+func newClockMonitor(v *vm.VM, config ClockConfig, roughtime RoughtimeConfig) (*clockMonitor, error) {
+	gauge := func(name, help string) prometheus.Gauge {
+		return prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "shuttle",
+			Subsystem: "clock",
+			Name:      name,
+			Help:      help,
+		})
+	}
+	m := &clockMonitor{
+		vm:               v,
+		config:           config,
+		roughtime:        roughtime,
+		file:             &roughtimeFile{path: roughtime.File},
+		registry:         prometheus.NewRegistry(),
+		localGauge:       gauge("local_skew_ms", "local clock minus the Roughtime median"),
+		blockGauge:       gauge("block_skew_ms", "last accepted block timestamp minus the local clock"),
+		attestationGauge: gauge("attestation_skew_ms", "last accepted block timestamp minus the Roughtime median of its attestations"),
+	}
+	for _, g := range []prometheus.Gauge{m.localGauge, m.blockGauge, m.attestationGauge} {
+		if err := m.registry.Register(g); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+var (
+	_ event.SubscriptionFactory[*chain.StatefulBlock] = (*clockMonitor)(nil)
+	_ event.Subscription[*chain.StatefulBlock]        = (*clockMonitor)(nil)
+	_ api.HandlerFactory[api.VM]                      = (*clockMetricsAPI)(nil)
+)
+
+func (m *clockMonitor) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return m, nil
+}
+
+// Accept samples block skew and, once [ClockConfig.ProbeInterval] has
+// passed since the last probe, probes Roughtime in the background.
+func (m *clockMonitor) Accept(blk *chain.StatefulBlock) error {
+	now := time.Now().UnixMilli()
+	log := vmlog.Default().WithHeight(blk.Height())
+
+	ahead := blk.Tmstmp - now
+	m.blockGauge.Set(float64(ahead))
+	// Only blocks from the future are reported: blocks replayed while
+	// catching up are legitimately behind
+	if m.config.WarnSkew > 0 && ahead > m.config.WarnSkew {
+		log.Warn("block timestamp ahead of local clock", zap.Int64("skewMs", ahead))
+	}
+
+	if median, ok := attestationMedian(blk.Txs, blk.Results()); ok {
+		skew := blk.Tmstmp - median
+		m.attestationGauge.Set(float64(skew))
+		if m.config.WarnSkew > 0 && abs(skew) > m.config.WarnSkew {
+			log.Warn("block timestamp far from Roughtime", zap.Int64("skewMs", skew))
+		}
+	}
+
+	if m.config.ProbeInterval > 0 && now-m.lastProbe.Load() >= m.config.ProbeInterval && m.probing.CompareAndSwap(false, true) {
+		go m.probe()
+	}
+	return nil
+}
+
+func (*clockMonitor) Close() error {
+	return nil
+}
+
+// clockMetricsAPI serves the monitor's gauges in the Prometheus format.
+type clockMetricsAPI struct {
+	registry *prometheus.Registry
+}
+
+func (a clockMetricsAPI) New(api.VM) (api.Handler, error) {
+	return api.Handler{
+		Path:    ClockMetricsEndpoint,
+		Handler: promhttp.HandlerFor(a.registry, promhttp.HandlerOpts{}),
+	}, nil
+}
+
+// probe measures the local clock against the median of the Roughtime
+// servers that answer, correcting each reply by half its round trip.
+func (m *clockMonitor) probe() {
+	defer m.probing.Store(false)
+	m.lastProbe.Store(time.Now().UnixMilli())
+
+	ctx := context.Background()
+	servers := m.file.servers(m.roughtime.Servers)
+	if db, err := m.vm.State(); err == nil {
+		if governed, err := actions.RoughtimeServers(ctx, db); err == nil && len(governed) > 0 {
+			servers = governed
+		}
+	}
+	timeout := time.Duration(m.roughtime.Timeout) * time.Millisecond
+	var skews []int64
+	for _, server := range servers {
+		start := time.Now()
+		midpoint, err := queryRoughtime(ctx, server, timeout)
+		if err != nil {
+			continue
+		}
+		end := time.Now()
+		local := start.Add(end.Sub(start) / 2)
+		skews = append(skews, local.Sub(midpoint).Milliseconds())
+	}
+	if len(skews) == 0 {
+		vmlog.Default().Warn("no Roughtime server answered the clock probe", zap.Strings("servers", servers))
+		return
+	}
+	slices.Sort(skews)
+	skew := skews[len(skews)/2]
+	m.localSkew.Store(skew)
+	m.sampled.Store(true)
+	m.localGauge.Set(float64(skew))
+	if m.config.WarnSkew > 0 && abs(skew) > m.config.WarnSkew {
+		vmlog.Default().Warn("local clock skewed from Roughtime",
+			zap.Int64("skewMs", skew),
+			zap.Int("servers", len(skews)),
+		)
+	}
+}
+
+// checkBuild returns [ErrClockSkew] while the last probe found the local
+// clock beyond [ClockConfig.RefuseBuildSkew] of Roughtime.
+func (m *clockMonitor) checkBuild() error {
+	if m.config.RefuseBuildSkew <= 0 || !m.sampled.Load() {
+		return nil
+	}
+	if skew := m.localSkew.Load(); abs(skew) > m.config.RefuseBuildSkew {
+		return fmt.Errorf("%w: %dms", ErrClockSkew, skew)
+	}
+	return nil
+}
+
+// attestationMedian returns, in unix milliseconds, the median of the
+// Roughtime medians of the executions applied by [txs].
+func attestationMedian(txs []*chain.Transaction, results []*chain.Result) (int64, bool) {
+	var medians []int64
+	for i, tx := range txs {
+		if i >= len(results) || !results[i].Success {
+			continue
+		}
+		for _, action := range tx.Actions {
+			exec, ok := action.(*actions.TEEExecAction)
+			if !ok {
+				continue
+			}
+			median, err := exec.Attestation.MedianTime()
+			if err != nil {
+				continue
+			}
+			medians = append(medians, int64(median)*1000)
+		}
+	}
+	if len(medians) == 0 {
+		return 0, false
+	}
+	slices.Sort(medians)
+	return medians[len(medians)/2], true
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// GuardedVM refuses to build blocks while the local clock is skewed beyond
+// [ClockConfig.RefuseBuildSkew]. It still verifies and accepts blocks built
+// by others. With [PrefilterConfig.Enabled], it also drops gossiped
+// transactions whose attestations fail before they reach the mempool. The
+// blocks it builds and parses report their verification and rejection to
+// the region event feed.
+type GuardedVM struct {
+	*vm.VM
+}
+
+// Guard wraps [v] so block building honors [ClockConfig.RefuseBuildSkew]
+// and gossip honors [PrefilterConfig].
+func Guard(v *vm.VM) *GuardedVM {
+	return &GuardedVM{VM: v}
+}
+
+func (g *GuardedVM) BuildBlock(ctx context.Context) (snowman.Block, error) {
+	if m := activeClock.Load(); m != nil {
+		if err := m.checkBuild(); err != nil {
+			vmlog.Default().Warn("refusing to build block", zap.Error(err))
+			return nil, err
+		}
+	}
+	blk, err := g.VM.BuildBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return track(blk), nil
+}
+
+func (g *GuardedVM) ParseBlock(ctx context.Context, source []byte) (snowman.Block, error) {
+	blk, err := g.VM.ParseBlock(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return track(blk), nil
+}
+
+func (g *GuardedVM) AppGossip(ctx context.Context, nodeID ids.NodeID, msg []byte) error {
+	if f := activePrefilter.Load(); f != nil {
+		if msg = f.filterGossip(ctx, msg); msg == nil {
+			return nil
+		}
+	}
+	return g.VM.AppGossip(ctx, nodeID, msg)
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// taggedMessage encodes [tags] and [values] as a Roughtime tagged message.
+func taggedMessage(tags []string, values [][]byte) []byte {
+	msg := binary.LittleEndian.AppendUint32(nil, uint32(len(tags)))
+	var end uint32
+	for _, v := range values[:len(values)-1] {
+		end += uint32(len(v))
+		msg = binary.LittleEndian.AppendUint32(msg, end)
+	}
+	for _, tag := range tags {
+		msg = append(msg, tag...)
+	}
+	for _, v := range values {
+		msg = append(msg, v...)
+	}
+	return msg
+}
+
+func roughtimeReply(midp uint64) []byte {
+	srep := taggedMessage(
+		[]string{"RADI", "MIDP"},
+		[][]byte{
+			binary.LittleEndian.AppendUint32(nil, 1),
+			binary.LittleEndian.AppendUint64(nil, midp),
+		},
+	)
+	return taggedMessage(
+		[]string{"SIG\x00", "SREP"},
+		[][]byte{make([]byte, 64), srep},
+	)
+}
+
+func TestRoughtimeMidpoint(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Unix(1_700_000_000, 0)
+	got, err := roughtimeMidpoint(roughtimeReply(uint64(now.UnixMicro())))
+	require.NoError(err)
+	require.True(now.Equal(got))
+
+	framed := append([]byte("ROUGHTIM"), make([]byte, 4)...)
+	got, err = roughtimeMidpoint(append(framed, roughtimeReply(uint64(now.Unix()))...))
+	require.NoError(err)
+	require.True(now.Equal(got))
+
+	_, err = roughtimeMidpoint(binary.LittleEndian.AppendUint32(nil, 5))
+	require.ErrorIs(err, ErrRoughtimeReply)
+}
+
+func TestQueryRoughtime(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Unix(1_700_000_000, 0)
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(err)
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 2*roughtimeRequestSize)
+		for {
+			_, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = server.WriteTo(roughtimeReply(uint64(now.UnixMicro())), addr)
+		}
+	}()
+	got, err := queryRoughtime(context.Background(), server.LocalAddr().String(), time.Second)
+	require.NoError(err)
+	require.True(now.Equal(got))
+}
+
+func TestClockCheckBuild(t *testing.T) {
+	require := require.New(t)
+
+	m := &clockMonitor{config: ClockConfig{RefuseBuildSkew: 5_000}}
+	// Nothing is refused before the first probe
+	require.NoError(m.checkBuild())
+
+	m.localSkew.Store(-4_000)
+	m.sampled.Store(true)
+	require.NoError(m.checkBuild())
+
+	m.localSkew.Store(-6_000)
+	require.ErrorIs(m.checkBuild(), ErrClockSkew)
+
+	m.config.RefuseBuildSkew = 0
+	require.NoError(m.checkBuild())
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/rhombus-tech/vm/manifest"
+)
+
+const (
+	// SectionSGXPackage holds the heap and stack sizes an SGX enclave is
+	// built with, and the SHA-256 hash of the code section it loads
+	SectionSGXPackage = "sgx_package"
+	// SectionSEVPolicy holds the guest policy an SEV-SNP VM is launched
+	// with, a big-endian uint64
+	SectionSEVPolicy = "sev_policy"
+)
+
+var (
+	DefaultSGXTransform = &SGXTransform{HeapSize: 64 << 20, StackSize: 1 << 20}
+	// SMT allowed, with the reserved bit the SNP ABI requires set
+	DefaultSEVTransform = &SEVTransform{Policy: 0x30000}
+)
+
+// SGXTransform adds the packaging metadata the SGX loader builds an
+// enclave from to custom format code. Other formats are packaged by the
+// loader itself and are returned unchanged.
+type SGXTransform struct {
+	HeapSize  uint64
+	StackSize uint64
+}
+
+func (t *SGXTransform) Transform(header *CodeHeader, body []byte) ([]byte, error) {
+	return addSection(header, body, SectionSGXPackage, func(m *manifest.Manifest) []byte {
+		digest := sha256.Sum256(m.Get(manifest.SectionCode))
+		b := binary.BigEndian.AppendUint64(nil, t.HeapSize)
+		b = binary.BigEndian.AppendUint64(b, t.StackSize)
+		return append(b, digest[:]...)
+	})
+}
+
+// SEVTransform stamps custom format code with the guest policy of the VM
+// that runs it. Other formats are returned unchanged.
+type SEVTransform struct {
+	Policy uint64
+}
+
+func (t *SEVTransform) Transform(header *CodeHeader, body []byte) ([]byte, error) {
+	return addSection(header, body, SectionSEVPolicy, func(*manifest.Manifest) []byte {
+		return binary.BigEndian.AppendUint64(nil, t.Policy)
+	})
+}
+
+// addSection adds section [name], built by [data], to a custom format
+// [body]. A section the creator already supplied is kept.
+func addSection(header *CodeHeader, body []byte, name string, data func(*manifest.Manifest) []byte) ([]byte, error) {
+	if header.Format != FormatCustom {
+		return body, nil
+	}
+	m, err := manifest.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if m.Get(name) != nil {
+		return body, nil
+	}
+	return manifest.Build(append(m.Sections, manifest.Section{Name: name, Data: data(m)})...)
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/manifest"
+)
+
+func TestPrepareCode(t *testing.T) {
+	require := require.New(t)
+	cv := NewCodeValidator(consts.MaxCodeSize)
+
+	container, err := manifest.Build(manifest.Section{Name: manifest.SectionCode, Data: []byte{1, 2, 3}})
+	require.NoError(err)
+	code, err := CreateCompressedCode(FormatCustom, 1, container)
+	require.NoError(err)
+	require.NoError(cv.ValidateCode(code))
+
+	prepared, err := cv.PrepareAll(code, []attestation.EnclaveType{attestation.SGX, attestation.SEV, attestation.Nitro})
+	require.NoError(err)
+	for _, enclaveType := range []attestation.EnclaveType{attestation.SGX, attestation.SEV, attestation.Nitro} {
+		require.NoError(cv.ValidateCode(prepared[enclaveType]))
+	}
+
+	sgx, err := manifest.Parse(prepared[attestation.SGX][HeaderSize:])
+	require.NoError(err)
+	require.Equal(DefaultSGXTransform.HeapSize, binary.BigEndian.Uint64(sgx.Get(SectionSGXPackage)))
+	require.Nil(sgx.Get(SectionSEVPolicy))
+	sev, err := manifest.Parse(prepared[attestation.SEV][HeaderSize:])
+	require.NoError(err)
+	require.Equal(DefaultSEVTransform.Policy, binary.BigEndian.Uint64(sev.Get(SectionSEVPolicy)))
+	// Types without a transform run the code as uploaded
+	require.Equal(CreateCode(FormatCustom, 1, container), prepared[attestation.Nitro])
+
+	// Other formats are not transformed
+	wasm := CreateCode(FormatWasm, 1, []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00})
+	out, err := cv.Prepare(wasm, attestation.SGX)
+	require.NoError(err)
+	require.Equal(wasm, out)
+
+	// Code no transform can prepare is rejected
+	sections := make([]manifest.Section, manifest.MaxSections)
+	for i := range sections {
+		sections[i] = manifest.Section{Name: fmt.Sprintf("s%d", i), Data: []byte{1}}
+	}
+	sections[0].Name = manifest.SectionCode
+	full, err := manifest.Build(sections...)
+	require.NoError(err)
+	require.ErrorIs(cv.ValidateCode(CreateCode(FormatCustom, 1, full)), ErrMalformedCode)
+	cv.RegisterTransform(attestation.SGX, nil)
+	cv.RegisterTransform(attestation.SEV, nil)
+	require.NoError(cv.ValidateCode(CreateCode(FormatCustom, 1, full)))
+}
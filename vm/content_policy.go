@@ -0,0 +1,71 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"slices"
+
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/policy"
+)
+
+const ContentPolicyNamespace = "contentPolicy"
+
+// ContentPolicyConfig sets the content policies the node enforces on the
+// objects and events it accepts. The embedded set applies to all content,
+// [Regions] to the content listed in each region, and [Plugins] are paths
+// of Go plugins exporting further policies.
+type ContentPolicyConfig struct {
+	policy.Set
+	Regions map[string]policy.Set `json:"regions"`
+	Plugins []string              `json:"plugins"`
+}
+
+func NewDefaultContentPolicyConfig() ContentPolicyConfig {
+	return ContentPolicyConfig{}
+}
+
+// Build returns the policies [c] enables, in the order they are checked:
+// the global set, the regional ones, then the plugins.
+func (c ContentPolicyConfig) Build() ([]actions.ContentPolicy, error) {
+	policies, err := c.Set.Build()
+	if err != nil {
+		return nil, err
+	}
+	regionIDs := make([]string, 0, len(c.Regions))
+	for regionID := range c.Regions {
+		regionIDs = append(regionIDs, regionID)
+	}
+	slices.Sort(regionIDs)
+	for _, regionID := range regionIDs {
+		regional, err := c.Regions[regionID].Build()
+		if err != nil {
+			return nil, err
+		}
+		if len(regional) > 0 {
+			policies = append(policies, policy.NewRegional(regionID, regional...))
+		}
+	}
+	for _, path := range c.Plugins {
+		p, err := policy.LoadPlugin(path)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func WithContentPolicy() vm.Option {
+	return vm.NewOption(ContentPolicyNamespace, NewDefaultContentPolicyConfig(), func(_ *vm.VM, config ContentPolicyConfig) error {
+		policies, err := config.Build()
+		if err != nil {
+			return err
+		}
+		actions.SetContentPolicies(policies...)
+		return nil
+	})
+}
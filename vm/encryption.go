@@ -0,0 +1,51 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type EncryptionKeysArgs struct {
+	RegionID string `json:"regionId"`
+}
+
+type EncryptionKeysReply struct {
+	// Keys are the X25519 keys event parameters for the region are sealed
+	// to, oldest first
+	Keys []storage.EncryptionKey `json:"keys"`
+}
+
+// EncryptionKeys returns the encryption keys the enclaves of [RegionID]
+// published when they registered.
+func (j *JSONRPCServer) EncryptionKeys(req *http.Request, args *EncryptionKeysArgs, reply *EncryptionKeysReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.EncryptionKeys")
+	defer span.End()
+
+	keys, err := storage.GetEncryptionKeysFromState(ctx, j.vm.ReadState, args.RegionID)
+	if err != nil {
+		return err
+	}
+	reply.Keys = keys
+	return nil
+}
+
+// EncryptionKeys returns the keys to seal event parameters for [regionID]
+// to, as [actions.SendEventAction.Seal] takes them.
+func (cli *JSONRPCClient) EncryptionKeys(ctx context.Context, regionID string) ([]storage.EncryptionKey, error) {
+	resp := new(EncryptionKeysReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"encryptionKeys",
+		&EncryptionKeysArgs{RegionID: regionID},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/fees"
+
+	"github.com/rhombus-tech/vm/actions"
+
+	smath "github.com/ava-labs/avalanchego/utils/math"
+)
+
+var ErrTrailingActionBytes = errors.New("action blob has trailing bytes")
+
+type EstimateUnitsArgs struct {
+	Actor codec.Address `json:"actor"`
+	// Action is the unsigned action: its type ID followed by its encoding
+	Action []byte `json:"action"`
+}
+
+type EstimateUnitsReply struct {
+	TypeID       uint8  `json:"typeId"`
+	ComputeUnits uint64 `json:"computeUnits"`
+	// StateKeys is the number of keys the action declares for [Actor]
+	StateKeys int `json:"stateKeys"`
+	// Fee is the compute and bandwidth the action costs at the current
+	// minimum unit prices
+	Fee uint64 `json:"fee"`
+}
+
+// EstimateUnits returns what [Action] would cost as [Actor] under the
+// current rules, without executing it, so clients can set the fee limits
+// of the transaction carrying it.
+func (j *JSONRPCServer) EstimateUnits(req *http.Request, args *EstimateUnitsArgs, reply *EstimateUnitsReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.EstimateUnits")
+	defer span.End()
+
+	rules := j.vm.Rules(time.Now().UnixMilli())
+	// Price with the governed fee schedule, as execution charges it
+	if sdb, ok := j.vm.(stateDB); ok {
+		db, err := sdb.State()
+		if err != nil {
+			return err
+		}
+		if rules, err = actions.GovernedRules(ctx, rules, db); err != nil {
+			return err
+		}
+	}
+	estimate, err := estimateUnits(rules, args.Actor, args.Action)
+	if err != nil {
+		return err
+	}
+	*reply = *estimate
+	return nil
+}
+
+// estimateUnits decodes the action in [blob] and prices it under [rules].
+func estimateUnits(rules chain.Rules, actor codec.Address, blob []byte) (*EstimateUnitsReply, error) {
+	p := codec.NewReader(blob, len(blob))
+	action, err := ActionParser.Unmarshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Empty() {
+		return nil, ErrTrailingActionBytes
+	}
+	units := action.ComputeUnits(rules)
+	prices := rules.GetMinUnitPrice()
+	compute, err := smath.Mul(units, prices[fees.Compute])
+	if err != nil {
+		return nil, err
+	}
+	bandwidth, err := smath.Mul(uint64(len(blob)), prices[fees.Bandwidth])
+	if err != nil {
+		return nil, err
+	}
+	fee, err := smath.Add(compute, bandwidth)
+	if err != nil {
+		return nil, err
+	}
+	return &EstimateUnitsReply{
+		TypeID:       action.GetTypeID(),
+		ComputeUnits: units,
+		StateKeys:    len(action.StateKeys(actor, ids.Empty)),
+		Fee:          fee,
+	}, nil
+}
+
+// EstimateUnits prices the unsigned [action] blob as [actor].
+func (cli *JSONRPCClient) EstimateUnits(ctx context.Context, actor codec.Address, action []byte) (*EstimateUnitsReply, error) {
+	resp := new(EstimateUnitsReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"estimateUnits",
+		&EstimateUnitsArgs{
+			Actor:  actor,
+			Action: action,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/fees"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestEstimateUnits(t *testing.T) {
+	require := require.New(t)
+	rules := genesis.NewDefaultRules()
+	actor := codectest.NewRandomAddress()
+
+	transfer := &actions.TransferAssetAction{AssetID: ids.GenerateTestID(), To: codectest.NewRandomAddress(), Value: 1}
+	encoded, err := codec.Marshal(transfer)
+	require.NoError(err)
+	blob := append([]byte{consts.TransferAssetID}, encoded...)
+	units := transfer.ComputeUnits(rules)
+
+	reply, err := estimateUnits(rules, actor, blob)
+	require.NoError(err)
+	prices := rules.GetMinUnitPrice()
+	require.Equal(&EstimateUnitsReply{
+		TypeID:       consts.TransferAssetID,
+		ComputeUnits: units,
+		StateKeys:    len(transfer.StateKeys(actor, ids.Empty)),
+		Fee:          units*prices[fees.Compute] + uint64(len(blob))*prices[fees.Bandwidth],
+	}, reply)
+
+	_, err = estimateUnits(rules, actor, append(blob, 0))
+	require.ErrorIs(err, ErrTrailingActionBytes)
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// maxEventOrderLinks bounds the links an event order proof walks.
+const maxEventOrderLinks = 4096
+
+var (
+	ErrEventNotEnqueued       = errors.New("event not enqueued to object")
+	ErrEventRangeTooLong      = errors.New("events too far apart to prove")
+	ErrInvalidEventOrderProof = errors.New("invalid event order proof")
+)
+
+type EventOrderArgs struct {
+	ObjectID string `json:"objectId"`
+	// Events are the IDs of the two events to order, as SendEvent returns
+	// them
+	Events [2]ids.ID `json:"events"`
+}
+
+type EventOrderReply struct {
+	// Links are the links of the object's event chain from the first of
+	// the events enqueued through the other, in order
+	Links []*storage.EventLink `json:"links"`
+	// Key is the state key the last link is stored under
+	Key []byte `json:"key"`
+	// Value is the encoded last link the proof commits to
+	Value     []byte `json:"value"`
+	StateRoot ids.ID `json:"stateRoot"`
+	// Proof is a protobuf-encoded merkledb proof of Key and Value
+	Proof []byte `json:"proof"`
+}
+
+// EventOrder proves which of two events sent to an object was enqueued
+// first: it returns the object's event chain between them, with a merkle
+// proof of its last link against the current chain state root.
+func (j *JSONRPCServer) EventOrder(req *http.Request, args *EventOrderArgs, reply *EventOrderReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.EventOrder")
+	defer span.End()
+
+	view, err := j.provableView(ctx)
+	if err != nil {
+		return err
+	}
+	links, err := eventOrder(ctx, view, args.ObjectID, args.Events)
+	if err != nil {
+		return err
+	}
+	key := storage.EventLinkKey(args.ObjectID, links[len(links)-1].EventID)
+	value, root, proof, err := proveValue(ctx, view, key)
+	if err != nil {
+		return err
+	}
+	reply.Links = links
+	reply.Key = key
+	reply.Value = value
+	reply.StateRoot = root
+	reply.Proof = proof
+	return nil
+}
+
+// eventOrder returns the links of the event chain of [objectID] from the
+// first of [events] enqueued through the other. It walks back from the
+// later one, each link naming the event of the one before it.
+func eventOrder(ctx context.Context, im state.Immutable, objectID string, events [2]ids.ID) ([]*storage.EventLink, error) {
+	var ends [2]*storage.EventLink
+	for i, eventID := range events {
+		link, err := storage.GetEventLink(ctx, im, objectID, eventID)
+		if err != nil {
+			return nil, err
+		}
+		if link == nil {
+			return nil, fmt.Errorf("%w: %s", ErrEventNotEnqueued, eventID)
+		}
+		ends[i] = link
+	}
+	first, last := ends[0], ends[1]
+	if first.Seq > last.Seq {
+		first, last = last, first
+	}
+	if last.Seq-first.Seq >= maxEventOrderLinks {
+		return nil, fmt.Errorf("%w: %d links", ErrEventRangeTooLong, last.Seq-first.Seq+1)
+	}
+	links := make([]*storage.EventLink, last.Seq-first.Seq+1)
+	links[len(links)-1] = last
+	for i := len(links) - 1; i > 0; i-- {
+		link, err := storage.GetEventLink(ctx, im, objectID, links[i].PrevEvent)
+		if err != nil {
+			return nil, err
+		}
+		if link == nil {
+			return nil, fmt.Errorf("%w: link %d", storage.ErrBrokenEventChain, links[i].Seq-1)
+		}
+		links[i-1] = link
+	}
+	return links, nil
+}
+
+// EventOrder fetches the event chain of [objectID] between events [first]
+// and [second] and checks it with [VerifyEventOrderProof]. The first link
+// is of the event enqueued first. Callers still need to check the returned
+// state root against a block they trust.
+func (cli *JSONRPCClient) EventOrder(ctx context.Context, objectID string, first ids.ID, second ids.ID) (*EventOrderReply, error) {
+	resp := new(EventOrderReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"eventOrder",
+		&EventOrderArgs{
+			ObjectID: objectID,
+			Events:   [2]ids.ID{first, second},
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyEventOrderProof(ctx, objectID, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// VerifyEventOrderProof checks that [r.Links] are consecutive links of the
+// event chain of [objectID], and that [r.Proof] proves the last of them in
+// the state committed to by [r.StateRoot]. Since each link commits to the
+// one before it, the events of [r.Links] were enqueued in their order.
+func VerifyEventOrderProof(ctx context.Context, objectID string, r *EventOrderReply) error {
+	if len(r.Links) == 0 {
+		return ErrInvalidEventOrderProof
+	}
+	if err := storage.VerifyEventChain(r.Links); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEventOrderProof, err)
+	}
+	last := r.Links[len(r.Links)-1]
+	if !bytes.Equal(r.Key, storage.EventLinkKey(objectID, last.EventID)) {
+		return ErrInvalidEventOrderProof
+	}
+	if err := verifyValueProof(ctx, r.StateRoot, r.Key, r.Value, r.Proof); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEventOrderProof, err)
+	}
+	expected, err := codec.Marshal(last)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, r.Value) {
+		return ErrInvalidEventOrderProof
+	}
+	return nil
+}
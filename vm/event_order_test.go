@@ -0,0 +1,60 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestEventOrder(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	mu := chaintest.NewInMemoryStore()
+
+	// Link five events onto the chain of "counter", the way SendEvent does
+	events := make([]ids.ID, 5)
+	head := &storage.EventChainHead{}
+	for i := range events {
+		events[i] = ids.GenerateTestID()
+		link := &storage.EventLink{
+			Seq:          head.Count + 1,
+			EventID:      events[i],
+			VerifiedTime: uint64(1000 + i),
+			Prev:         head.Hash,
+			PrevEvent:    head.Latest,
+		}
+		require.NoError(storage.SetEventLink(ctx, mu, "counter", link))
+		head = &storage.EventChainHead{Count: link.Seq, Hash: link.Hash(), Latest: link.EventID}
+	}
+
+	// Either order of the arguments walks the chain forward
+	for _, pair := range [][2]ids.ID{{events[1], events[3]}, {events[3], events[1]}} {
+		links, err := eventOrder(ctx, mu, "counter", pair)
+		require.NoError(err)
+		require.Len(links, 3)
+		require.Equal(events[1], links[0].EventID)
+		require.Equal(events[3], links[2].EventID)
+		require.NoError(storage.VerifyEventChain(links))
+	}
+
+	// A link rewritten or left out breaks the chain
+	links, err := eventOrder(ctx, mu, "counter", [2]ids.ID{events[0], events[4]})
+	require.NoError(err)
+	tampered := *links[2]
+	tampered.VerifiedTime++
+	require.ErrorIs(storage.VerifyEventChain([]*storage.EventLink{links[1], &tampered, links[3]}), storage.ErrBrokenEventChain)
+	require.ErrorIs(storage.VerifyEventChain([]*storage.EventLink{links[1], links[3]}), storage.ErrBrokenEventChain)
+
+	_, err = eventOrder(ctx, mu, "counter", [2]ids.ID{events[0], ids.GenerateTestID()})
+	require.ErrorIs(err, ErrEventNotEnqueued)
+	_, err = eventOrder(ctx, mu, "other", [2]ids.ID{events[0], events[1]})
+	require.ErrorIs(err, ErrEventNotEnqueued)
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type ExecLimitsArgs struct {
+	RegionID string `json:"region_id"`
+}
+
+type ExecLimitsReply struct {
+	Limits storage.ExecLimits `json:"limits"`
+}
+
+// ExecLimits returns the resource limits enclaves enforce on one execution
+// in a region, defaults included.
+func (j *JSONRPCServer) ExecLimits(req *http.Request, args *ExecLimitsArgs, reply *ExecLimitsReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.ExecLimits")
+	defer span.End()
+
+	limits, err := storage.GetExecLimitsFromState(ctx, j.vm.ReadState, args.RegionID)
+	if err != nil {
+		return err
+	}
+	reply.Limits = limits
+	return nil
+}
+
+// ExecLimits returns the resource limits of one execution in [regionID].
+func (cli *JSONRPCClient) ExecLimits(ctx context.Context, regionID string) (storage.ExecLimits, error) {
+	resp := new(ExecLimitsReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"execLimits",
+		&ExecLimitsArgs{RegionID: regionID},
+		resp,
+	)
+	if err != nil {
+		return storage.ExecLimits{}, err
+	}
+	return resp.Limits, nil
+}
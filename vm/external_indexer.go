@@ -0,0 +1,253 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const (
+	ExternalIndexerNamespace = "externalIndexer"
+
+	// acceptBlockMethod is BlockIndexer.AcceptBlock of
+	// proto/shuttlevm/v1/indexer.proto
+	acceptBlockMethod = "/shuttlevm.v1.BlockIndexer/AcceptBlock"
+)
+
+// ExternalIndexerConfig ships accepted blocks and the results of their
+// transactions to an external indexer over gRPC, as the AcceptedBlock
+// messages of proto/shuttlevm/v1/indexer.proto.
+type ExternalIndexerConfig struct {
+	// ServerAddress is the host:port of the indexer's BlockIndexer
+	// service. Empty disables the export.
+	ServerAddress string `json:"serverAddress"`
+	// BufferSize is how many accepted blocks may wait for the indexer.
+	// Blocks accepted while it is full are dropped, and the indexer sees
+	// a gap in heights it can fill from the node's RPCs.
+	BufferSize int `json:"bufferSize"`
+	// Timeout bounds each call, and RetryInterval is the wait after a
+	// failed one, in milliseconds. A block is retried until the indexer
+	// takes it, so blocks arrive in order.
+	Timeout       int64 `json:"timeout"`
+	RetryInterval int64 `json:"retryInterval"`
+}
+
+func NewDefaultExternalIndexerConfig() ExternalIndexerConfig {
+	return ExternalIndexerConfig{
+		BufferSize:    1_024,
+		Timeout:       10_000,
+		RetryInterval: 1_000,
+	}
+}
+
+func WithExternalIndexer() vm.Option {
+	return vm.NewOption(ExternalIndexerNamespace, NewDefaultExternalIndexerConfig(), func(v *vm.VM, config ExternalIndexerConfig) error {
+		if config.ServerAddress == "" {
+			return nil
+		}
+		if config.BufferSize < 1 || config.Timeout <= 0 || config.RetryInterval <= 0 {
+			return fmt.Errorf("%w: externalIndexer limits must be positive", ErrInvalidConfig)
+		}
+		conn, err := grpc.Dial(config.ServerAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		vm.WithBlockSubscriptions(newExternalIndexer(conn, config))(v)
+		return nil
+	})
+}
+
+var (
+	_ event.SubscriptionFactory[*chain.StatefulBlock] = (*externalIndexer)(nil)
+	_ event.Subscription[*chain.StatefulBlock]        = (*externalIndexer)(nil)
+)
+
+// externalIndexer queues accepted blocks and sends them to the indexer
+// from its own goroutine, so a slow or unreachable indexer does not hold
+// up acceptance.
+type externalIndexer struct {
+	conn   *grpc.ClientConn
+	config ExternalIndexerConfig
+
+	queue     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newExternalIndexer(conn *grpc.ClientConn, config ExternalIndexerConfig) *externalIndexer {
+	x := &externalIndexer{
+		conn:   conn,
+		config: config,
+		queue:  make(chan []byte, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go x.run()
+	return x
+}
+
+func (x *externalIndexer) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return x, nil
+}
+
+func (x *externalIndexer) Accept(blk *chain.StatefulBlock) error {
+	x.enqueue(blk.Height(), encodeAcceptedBlock(blk))
+	return nil
+}
+
+func (x *externalIndexer) enqueue(height uint64, msg []byte) {
+	select {
+	case x.queue <- msg:
+	default:
+		vmlog.Default().WithHeight(height).Warn("external indexer behind, dropping block")
+	}
+}
+
+func (x *externalIndexer) Close() error {
+	var err error
+	x.closeOnce.Do(func() {
+		close(x.done)
+		err = x.conn.Close()
+	})
+	return err
+}
+
+func (x *externalIndexer) run() {
+	for {
+		select {
+		case msg := <-x.queue:
+			if !x.send(msg) {
+				return
+			}
+		case <-x.done:
+			return
+		}
+	}
+}
+
+// send delivers [msg], retrying until it is taken. It returns false if the
+// indexer was closed first.
+func (x *externalIndexer) send(msg []byte) bool {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(x.config.Timeout)*time.Millisecond)
+		err := x.conn.Invoke(ctx, acceptBlockMethod, (*rawMessage)(&msg), new(rawMessage), grpc.ForceCodec(rawCodec{}))
+		cancel()
+		if err == nil {
+			return true
+		}
+		vmlog.Default().Warn("external indexer rejected block", zap.Error(err))
+		select {
+		case <-time.After(time.Duration(x.config.RetryInterval) * time.Millisecond):
+		case <-x.done:
+			return false
+		}
+	}
+}
+
+// encodeAcceptedBlock encodes [blk] as an AcceptedBlock message.
+func encodeAcceptedBlock(blk *chain.StatefulBlock) []byte {
+	id := blk.ID()
+	b := appendProtoBytes(nil, 1, id[:])
+	b = appendProtoVarint(b, 2, blk.Height())
+	b = appendProtoVarint(b, 3, uint64(blk.Tmstmp))
+	b = appendProtoBytes(b, 4, blk.Bytes())
+	results := blk.Results()
+	for i, tx := range blk.Txs {
+		if i >= len(results) {
+			break
+		}
+		b = appendProtoBytes(b, 5, encodeTxResult(tx.ID(), tx.Actions, results[i]))
+	}
+	return b
+}
+
+// encodeTxResult encodes the [result] of transaction [txID] with [acts] as
+// a TxResult message.
+func encodeTxResult(txID ids.ID, acts []chain.Action, result *chain.Result) []byte {
+	b := appendProtoBytes(nil, 1, txID[:])
+	if result.Success {
+		b = appendProtoVarint(b, 2, 1)
+	} else {
+		code, msg := ResultError(result)
+		b = appendProtoBytes(b, 3, []byte(code.String()))
+		b = appendProtoBytes(b, 4, []byte(msg))
+	}
+	for _, output := range result.Outputs {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, output)
+	}
+	b = appendProtoVarint(b, 6, result.Fee)
+	var types []byte
+	for _, action := range acts {
+		types = protowire.AppendVarint(types, uint64(action.GetTypeID()))
+	}
+	b = appendProtoBytes(b, 7, types)
+	for _, action := range acts {
+		// Every action has an entry, so regions line up with types
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendString(b, actions.ActionRegion(action))
+	}
+	return b
+}
+
+// appendProtoVarint appends field [num] unless [v] is its zero default.
+func appendProtoVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendProtoBytes appends field [num] unless [v] is empty.
+func appendProtoBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// rawMessage is a message already encoded as protobuf.
+type rawMessage []byte
+
+// rawCodec passes [rawMessage]s through gRPC as they are, so the export
+// needs no generated code. It is named "proto" for servers to decode the
+// messages with their generated types.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(*rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *m, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*m = append((*m)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
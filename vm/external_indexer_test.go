@@ -0,0 +1,146 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+// blockIndexerServer serves BlockIndexer.AcceptBlock, failing the first
+// [failures] calls.
+func blockIndexerServer(t *testing.T, failures int) (string, <-chan []byte) {
+	received := make(chan []byte, 16)
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "shuttlevm.v1.BlockIndexer",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "AcceptBlock",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var msg rawMessage
+				if err := dec(&msg); err != nil {
+					return nil, err
+				}
+				if failures > 0 {
+					failures--
+					return nil, errors.New("indexer unavailable")
+				}
+				received <- msg
+				return &rawMessage{}, nil
+			},
+		}},
+	}, struct{}{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), received
+}
+
+func TestExternalIndexerDelivery(t *testing.T) {
+	require := require.New(t)
+
+	addr, received := blockIndexerServer(t, 2)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(err)
+	x := newExternalIndexer(conn, ExternalIndexerConfig{BufferSize: 2, Timeout: 1_000, RetryInterval: 10})
+	defer x.Close()
+
+	// Blocks arrive in order, the first once the indexer takes it
+	x.enqueue(1, []byte("first"))
+	x.enqueue(2, []byte("second"))
+	for _, want := range []string{"first", "second"} {
+		select {
+		case msg := <-received:
+			require.Equal(want, string(msg))
+		case <-time.After(5 * time.Second):
+			require.FailNow("block not delivered", want)
+		}
+	}
+	require.NoError(x.Close())
+	require.NoError(x.Close())
+}
+
+func TestEncodeTxResult(t *testing.T) {
+	require := require.New(t)
+
+	txID := ids.GenerateTestID()
+	acts := []chain.Action{
+		&actions.CreateAssetAction{Name: "Credits", Symbol: "CRD"},
+		&actions.SubmitCheckpointAction{RegionID: "us-east"},
+	}
+	result := &chain.Result{
+		Error: []byte(fmt.Errorf("%w: 3 actions in block 7", actions.ErrRateLimited).Error()),
+		Fee:   42,
+	}
+
+	var (
+		gotID      []byte
+		errCode    string
+		fee        uint64
+		types      []uint64
+		regions    []string
+		successSet bool
+	)
+	b := encodeTxResult(txID, acts, result)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.Positive(n)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			require.Positive(n)
+			b = b[n:]
+			switch num {
+			case 2:
+				successSet = true
+			case 6:
+				fee = v
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			require.Positive(n)
+			b = b[n:]
+			switch num {
+			case 1:
+				gotID = v
+			case 3:
+				errCode = string(v)
+			case 7:
+				for len(v) > 0 {
+					typeID, n := protowire.ConsumeVarint(v)
+					require.Positive(n)
+					types = append(types, typeID)
+					v = v[n:]
+				}
+			case 8:
+				regions = append(regions, string(v))
+			}
+		default:
+			require.FailNow("unexpected wire type", num)
+		}
+	}
+	require.Equal(txID[:], gotID)
+	require.False(successSet)
+	require.Equal(consts.ErrCodeRateLimited.String(), errCode)
+	require.Equal(uint64(42), fee)
+	require.Equal([]uint64{uint64(consts.CreateAssetID), uint64(consts.SubmitCheckpointID)}, types)
+	require.Equal([]string{"", "us-east"}, regions)
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// maxFeedHistory bounds the values one feedHistory request returns
+const maxFeedHistory = 256
+
+var ErrFeedNotFound = errors.New("feed not found")
+
+type FeedArgs struct {
+	FeedID ids.ID `json:"feedId"`
+}
+
+type FeedReply struct {
+	// Feed carries the latest value and its round
+	Feed *storage.Feed `json:"feed"`
+}
+
+// Feed returns the feed [FeedID] with its latest value.
+func (j *JSONRPCServer) Feed(req *http.Request, args *FeedArgs, reply *FeedReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Feed")
+	defer span.End()
+
+	feed, err := storage.GetFeedFromState(ctx, j.vm.ReadState, args.FeedID)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return ErrFeedNotFound
+	}
+	reply.Feed = feed
+	return nil
+}
+
+type FeedHistoryArgs struct {
+	FeedID ids.ID `json:"feedId"`
+	// From is the first round returned
+	From uint64 `json:"from"`
+	// Limit caps the values returned, at most 256
+	Limit uint64 `json:"limit"`
+}
+
+type FeedHistoryReply struct {
+	// Values are in round order. Rounds older than the last
+	// [consts.FeedHistory] are no longer kept and are left out.
+	Values []storage.FeedValue `json:"values"`
+}
+
+// FeedHistory returns values of the feed [FeedID] from round [From] on.
+func (j *JSONRPCServer) FeedHistory(req *http.Request, args *FeedHistoryArgs, reply *FeedHistoryReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.FeedHistory")
+	defer span.End()
+
+	feed, err := storage.GetFeedFromState(ctx, j.vm.ReadState, args.FeedID)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return ErrFeedNotFound
+	}
+	from := max(args.From, 1)
+	if feed.Round > consts.FeedHistory {
+		from = max(from, feed.Round-consts.FeedHistory+1)
+	}
+	limit := args.Limit
+	if limit == 0 || limit > maxFeedHistory {
+		limit = maxFeedHistory
+	}
+	var rounds []uint64
+	for round := from; round <= feed.Round && uint64(len(rounds)) < limit; round++ {
+		rounds = append(rounds, round)
+	}
+	reply.Values, err = storage.GetFeedValuesFromState(ctx, j.vm.ReadState, args.FeedID, rounds)
+	return err
+}
+
+// Feed returns the feed [feedID] with its latest value.
+func (cli *JSONRPCClient) Feed(ctx context.Context, feedID ids.ID) (*storage.Feed, error) {
+	resp := new(FeedReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"feed",
+		&FeedArgs{FeedID: feedID},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Feed, nil
+}
+
+// FeedHistory returns up to [limit] values of [feedID] from round [from] on.
+func (cli *JSONRPCClient) FeedHistory(ctx context.Context, feedID ids.ID, from, limit uint64) ([]storage.FeedValue, error) {
+	resp := new(FeedHistoryReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"feedHistory",
+		&FeedHistoryArgs{
+			FeedID: feedID,
+			From:   from,
+			Limit:  limit,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Values, nil
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/trace"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrInvalidAdminThreshold = errors.New("admin threshold must be between 1 and the number of admin keys")
+	ErrDuplicateAdminKey     = errors.New("duplicate admin key")
+
+	_ genesis.Genesis               = (*Genesis)(nil)
+	_ genesis.GenesisAndRuleFactory = (*GenesisFactory)(nil)
+)
+
+// DefaultInputObject is the input object of chains whose genesis names none.
+const DefaultInputObject = "input"
+
+// Genesis extends the default genesis with the emergency admin key set, the
+// input object, the roots of trust for SGX collateral, Nitro and CCA
+// enclaves and the action version schedule.
+type Genesis struct {
+	*genesis.DefaultGenesis
+	Admin *storage.AdminSet `json:"admin,omitempty"`
+	// InputObject is the object user input is routed to until a
+	// SetInputObjectAction changes it. It defaults to [DefaultInputObject].
+	InputObject string `json:"input_object,omitempty"`
+	// NitroRoot is the DER of the AWS Nitro Enclaves root certificate.
+	// Without it no region accepts Nitro enclaves.
+	NitroRoot []byte `json:"nitro_root,omitempty"`
+	// CCAPlatformKeys are the PKIX DER of the CPAKs trusted to sign Arm CCA
+	// platform tokens. Without them no region accepts CCA enclaves.
+	CCAPlatformKeys [][]byte `json:"cca_platform_keys,omitempty"`
+	// SGXRoot is the DER of the Intel SGX root CA certificate. Without it no
+	// DCAP collateral can be published.
+	SGXRoot []byte `json:"sgx_root,omitempty"`
+	// ActionVersions is the height each action wire version activates at,
+	// for every version in order. It defaults to
+	// [consts.ActionVersionSchedule].
+	ActionVersions []consts.VersionActivation `json:"action_versions,omitempty"`
+}
+
+func (g *Genesis) InitializeState(ctx context.Context, tracer trace.Tracer, mu state.Mutable, balanceHandler chain.BalanceHandler) error {
+	if err := g.DefaultGenesis.InitializeState(ctx, tracer, mu, balanceHandler); err != nil {
+		return err
+	}
+	inputObject := g.InputObject
+	if inputObject == "" {
+		inputObject = DefaultInputObject
+	}
+	if err := storage.SetInputObject(ctx, mu, inputObject); err != nil {
+		return err
+	}
+	if len(g.NitroRoot) > 0 {
+		if _, err := x509.ParseCertificate(g.NitroRoot); err != nil {
+			return err
+		}
+		if err := storage.SetNitroRoot(ctx, mu, g.NitroRoot); err != nil {
+			return err
+		}
+	}
+	if len(g.CCAPlatformKeys) > 0 {
+		for _, der := range g.CCAPlatformKeys {
+			if _, err := storage.ParseCCAPlatformKey(der); err != nil {
+				return err
+			}
+		}
+		if err := storage.SetCCAPlatformKeys(ctx, mu, &storage.CCAPlatformKeys{Keys: g.CCAPlatformKeys}); err != nil {
+			return err
+		}
+	}
+	if len(g.SGXRoot) > 0 {
+		if _, err := x509.ParseCertificate(g.SGXRoot); err != nil {
+			return err
+		}
+		if err := storage.SetSGXRoot(ctx, mu, g.SGXRoot); err != nil {
+			return err
+		}
+	}
+	if g.Admin == nil {
+		return nil
+	}
+	if g.Admin.Threshold == 0 || int(g.Admin.Threshold) > len(g.Admin.Keys) {
+		return ErrInvalidAdminThreshold
+	}
+	// A key listed twice would count once towards the threshold
+	keys := make(map[ed25519.PublicKey]struct{}, len(g.Admin.Keys))
+	for _, key := range g.Admin.Keys {
+		if _, ok := keys[key]; ok {
+			return ErrDuplicateAdminKey
+		}
+		keys[key] = struct{}{}
+	}
+	return storage.SetAdminSet(ctx, mu, g.Admin)
+}
+
+type GenesisFactory struct{}
+
+func (GenesisFactory) Load(genesisBytes []byte, upgradeBytes []byte, networkID uint32, chainID ids.ID) (genesis.Genesis, genesis.RuleFactory, error) {
+	g := &Genesis{}
+	if err := json.Unmarshal(genesisBytes, g); err != nil {
+		return nil, nil, err
+	}
+	_, ruleFactory, err := genesis.DefaultGenesisFactory{}.Load(genesisBytes, upgradeBytes, networkID, chainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	actionVersions := g.ActionVersions
+	if len(actionVersions) == 0 {
+		actionVersions = consts.ActionVersionSchedule
+	}
+	if err := validateVersionSchedule(actionVersions); err != nil {
+		return nil, nil, err
+	}
+	return g, &RuleFactory{RuleFactory: ruleFactory, ActionVersions: actionVersions}, nil
+}
@@ -0,0 +1,364 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/hypersdk/api"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	HealthEndpoint    = "/health"
+	ReadinessEndpoint = "/readiness"
+
+	// roughtimeRequestSize is the size servers require requests be padded
+	// to, so they cannot be used to amplify traffic.
+	roughtimeRequestSize = 1024
+)
+
+var (
+	ErrHealthUnavailable    = errors.New("state unavailable")
+	ErrRoughtimeUnreachable = errors.New("too few Roughtime servers reachable")
+	ErrRoughtimeReply       = errors.New("malformed Roughtime reply")
+	ErrEventBacklog         = errors.New("event backlog too large")
+)
+
+// HealthConfig sets the checks of the readiness endpoint.
+type HealthConfig struct {
+	// MaxEventBacklog is how many queued events and pending callbacks the
+	// node may see before it reports itself not ready. Zero disables the
+	// check.
+	MaxEventBacklog uint64 `json:"maxEventBacklog"`
+}
+
+// RoughtimeConfig sets how the readiness endpoint probes Roughtime.
+type RoughtimeConfig struct {
+	// Servers are probed when governance has not set
+	// [consts.ParamRoughtimeServers].
+	Servers []string `json:"servers"`
+	// File, if set, names a JSON list of servers that replaces [Servers].
+	// It is read again whenever it changes, so the list can be updated
+	// without restarting the node.
+	File string `json:"file"`
+	// Timeout bounds each probe, in milliseconds.
+	Timeout int64 `json:"timeout"`
+}
+
+func NewDefaultRoughtimeConfig() RoughtimeConfig {
+	return RoughtimeConfig{
+		Timeout: 2_000,
+	}
+}
+
+// HealthReport is the body of both endpoints. Enclave liveness and
+// collateral freshness are chain-wide, so they are reported but never fail
+// a probe: taking every validator out of service would not bring an
+// enclave back.
+type HealthReport struct {
+	Healthy bool     `json:"healthy"`
+	Errors  []string `json:"errors,omitempty"`
+	// Roughtime is only probed for readiness
+	Roughtime []RoughtimeStatus `json:"roughtime,omitempty"`
+	*storage.Diagnostics
+}
+
+type RoughtimeStatus struct {
+	Server    string `json:"server"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+var _ api.HandlerFactory[api.VM] = (*healthAPI)(nil)
+
+type healthAPI struct {
+	path      string
+	config    HealthConfig
+	roughtime RoughtimeConfig
+	readiness bool
+}
+
+func (a healthAPI) New(v api.VM) (api.Handler, error) {
+	return api.Handler{
+		Path: a.path,
+		Handler: &healthHandler{
+			vm:        v,
+			config:    a.config,
+			roughtime: a.roughtime,
+			file:      &roughtimeFile{path: a.roughtime.File},
+			readiness: a.readiness,
+		},
+	}, nil
+}
+
+type healthHandler struct {
+	vm        api.VM
+	config    HealthConfig
+	roughtime RoughtimeConfig
+	file      *roughtimeFile
+	readiness bool
+}
+
+// ServeHTTP replies 200 with the report when the node is healthy, and 503
+// otherwise.
+func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.report(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// report reads the node's diagnostics. Liveness only needs state to be
+// readable; readiness also needs enough Roughtime servers to answer for
+// enclaves to stamp their attestations, and the event backlog to be within
+// [HealthConfig.MaxEventBacklog].
+func (h *healthHandler) report(ctx context.Context) *HealthReport {
+	report := &HealthReport{Healthy: true}
+	fail := func(err error) {
+		report.Healthy = false
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	sdb, ok := h.vm.(stateDB)
+	if !ok {
+		fail(ErrHealthUnavailable)
+		return report
+	}
+	db, err := sdb.State()
+	if err != nil {
+		fail(fmt.Errorf("%w: %w", ErrHealthUnavailable, err))
+		return report
+	}
+	report.Diagnostics, err = storage.Diagnose(ctx, db, time.Now().UnixMilli())
+	if err != nil {
+		fail(fmt.Errorf("%w: %w", ErrHealthUnavailable, err))
+		return report
+	}
+	if !h.readiness {
+		return report
+	}
+
+	backlog := report.QueuedEvents + report.PendingCallbacks
+	if h.config.MaxEventBacklog > 0 && backlog > h.config.MaxEventBacklog {
+		fail(fmt.Errorf("%w: %d", ErrEventBacklog, backlog))
+	}
+	servers, err := actions.RoughtimeServers(ctx, db)
+	if err != nil {
+		fail(err)
+		return report
+	}
+	if len(servers) == 0 {
+		servers = h.file.servers(h.roughtime.Servers)
+	}
+	timeout := time.Duration(h.roughtime.Timeout) * time.Millisecond
+	report.Roughtime = probeRoughtimeServers(ctx, servers, timeout)
+	var reachable int
+	for _, status := range report.Roughtime {
+		if status.Reachable {
+			reachable++
+		}
+	}
+	if reachable < min(attestation.MinStamps, len(servers)) {
+		fail(fmt.Errorf("%w: %d of %d", ErrRoughtimeUnreachable, reachable, len(servers)))
+	}
+	return report
+}
+
+// roughtimeFile holds the server list of [RoughtimeConfig.File], read
+// again when the file's modification time changes.
+type roughtimeFile struct {
+	path string
+
+	l       sync.Mutex
+	modTime time.Time
+	list    []string
+}
+
+// servers returns the servers listed in the file, or [fallback] if there is
+// no file or it was never read successfully. A file that fails to parse
+// leaves the last list in place.
+func (f *roughtimeFile) servers(fallback []string) []string {
+	if f.path == "" {
+		return fallback
+	}
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err == nil && !info.ModTime().Equal(f.modTime) {
+		var list []string
+		if b, err := os.ReadFile(f.path); err == nil && json.Unmarshal(b, &list) == nil {
+			f.list = list
+			f.modTime = info.ModTime()
+		}
+	}
+	if f.list == nil {
+		return fallback
+	}
+	return f.list
+}
+
+// probeRoughtimeServers probes [servers] concurrently.
+func probeRoughtimeServers(ctx context.Context, servers []string, timeout time.Duration) []RoughtimeStatus {
+	statuses := make([]RoughtimeStatus, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = RoughtimeStatus{Server: server, Reachable: true}
+			if err := probeRoughtime(ctx, server, timeout); err != nil {
+				statuses[i].Reachable = false
+				statuses[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// probeRoughtime sends a request to the Roughtime server at [addr] and
+// waits up to [timeout] for a reply. The reply's signature is not checked:
+// enclaves verify their stamps, and the probe only tells whether they can
+// get any.
+func probeRoughtime(ctx context.Context, addr string, timeout time.Duration) error {
+	reply, err := exchangeRoughtime(ctx, addr, timeout)
+	if err != nil {
+		return err
+	}
+	// A reply is a tagged message, which starts with its tag count
+	if len(reply) < 4 || binary.LittleEndian.Uint32(reply) == 0 {
+		return ErrRoughtimeReply
+	}
+	return nil
+}
+
+// queryRoughtime asks the Roughtime server at [addr] for the time. Like
+// [probeRoughtime], it does not check the reply's signature, so the result
+// is only fit for monitoring.
+func queryRoughtime(ctx context.Context, addr string, timeout time.Duration) (time.Time, error) {
+	reply, err := exchangeRoughtime(ctx, addr, timeout)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return roughtimeMidpoint(reply)
+}
+
+func exchangeRoughtime(ctx context.Context, addr string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	req, err := roughtimeRequest()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, 2*roughtimeRequestSize)
+	n, err := conn.Read(reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply[:n], nil
+}
+
+// roughtimeMidpoint reads the MIDP tag of the signed response (SREP) in
+// [reply]. Servers following the IETF drafts frame replies with a
+// "ROUGHTIM" header and give MIDP in seconds; older ones give it in
+// microseconds.
+func roughtimeMidpoint(reply []byte) (time.Time, error) {
+	if bytes.HasPrefix(reply, []byte("ROUGHTIM")) {
+		if len(reply) < 12 {
+			return time.Time{}, ErrRoughtimeReply
+		}
+		reply = reply[12:]
+	}
+	srep, ok := roughtimeTag(reply, "SREP")
+	if !ok {
+		return time.Time{}, ErrRoughtimeReply
+	}
+	midp, ok := roughtimeTag(srep, "MIDP")
+	if !ok || len(midp) != 8 {
+		return time.Time{}, ErrRoughtimeReply
+	}
+	v := binary.LittleEndian.Uint64(midp)
+	if v < 1<<40 {
+		return time.Unix(int64(v), 0), nil
+	}
+	return time.UnixMicro(int64(v)), nil
+}
+
+// roughtimeTag returns the value of [tag] in the tagged message [msg]: a
+// tag count n, n-1 value end offsets, n tags, then the values.
+func roughtimeTag(msg []byte, tag string) ([]byte, bool) {
+	if len(msg) < 4 {
+		return nil, false
+	}
+	n := int(binary.LittleEndian.Uint32(msg))
+	header := 4 + 4*(n-1) + 4*n
+	if n == 0 || n > 64 || len(msg) < header {
+		return nil, false
+	}
+	values := msg[header:]
+	start := 0
+	for i := 0; i < n; i++ {
+		end := len(values)
+		if i < n-1 {
+			end = int(binary.LittleEndian.Uint32(msg[4+4*i:]))
+		}
+		if end < start || end > len(values) {
+			return nil, false
+		}
+		tagAt := 4 + 4*(n-1) + 4*i
+		if string(msg[tagAt:tagAt+4]) == tag {
+			return values[start:end], true
+		}
+		start = end
+	}
+	return nil, false
+}
+
+// roughtimeRequest builds a request message: a random NONC tag and a PAD
+// tag filling it to [roughtimeRequestSize].
+func roughtimeRequest() ([]byte, error) {
+	const nonceSize = 64
+	req := make([]byte, roughtimeRequestSize)
+	binary.LittleEndian.PutUint32(req[0:], 2)
+	binary.LittleEndian.PutUint32(req[4:], nonceSize)
+	copy(req[8:], "NONC")
+	copy(req[12:], "PAD\xff")
+	if _, err := rand.Read(req[16 : 16+nonceSize]); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeRoughtime(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(err)
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 2*roughtimeRequestSize)
+		for {
+			n, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n != roughtimeRequestSize || string(buf[8:12]) != "NONC" {
+				continue
+			}
+			reply := binary.LittleEndian.AppendUint32(nil, 5)
+			_, _ = server.WriteTo(append(reply, buf[16:80]...), addr)
+		}
+	}()
+	require.NoError(probeRoughtime(ctx, server.LocalAddr().String(), time.Second))
+
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(err)
+	defer silent.Close()
+	statuses := probeRoughtimeServers(ctx, []string{server.LocalAddr().String(), silent.LocalAddr().String()}, 50*time.Millisecond)
+	require.Len(statuses, 2)
+	require.True(statuses[0].Reachable)
+	require.False(statuses[1].Reachable)
+	require.NotEmpty(statuses[1].Error)
+}
+
+func TestRoughtimeFile(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "roughtime.json")
+	file := &roughtimeFile{path: path}
+	fallback := []string{"fallback.example:2002"}
+
+	require.Equal(fallback, file.servers(fallback))
+
+	require.NoError(os.WriteFile(path, []byte(`["a.example:2002"]`), 0o600))
+	require.Equal([]string{"a.example:2002"}, file.servers(fallback))
+
+	// A rewrite is picked up; a malformed one keeps the last list
+	later := time.Now().Add(time.Minute)
+	require.NoError(os.WriteFile(path, []byte(`["b.example:2002"]`), 0o600))
+	require.NoError(os.Chtimes(path, later, later))
+	require.Equal([]string{"b.example:2002"}, file.servers(fallback))
+
+	require.NoError(os.WriteFile(path, []byte(`not json`), 0o600))
+	require.NoError(os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute)))
+	require.Equal([]string{"b.example:2002"}, file.servers(fallback))
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type ImplementersArgs struct {
+	RegionID    string `json:"regionId"`
+	InterfaceID ids.ID `json:"interfaceId"`
+	// From is the object to continue from, the Next of a previous reply,
+	// or empty to start at the latest
+	From string `json:"from"`
+	// Limit caps the objects returned, at most 256
+	Limit uint64 `json:"limit"`
+}
+
+type ImplementersReply struct {
+	// Interface holds the signatures of [InterfaceID], or nil if no object
+	// ever declared it
+	Interface *storage.Interface `json:"interface,omitempty"`
+	// ObjectIDs are latest created first
+	ObjectIDs []string `json:"objectIds"`
+	// Next is the object to continue from, empty once all were returned
+	Next string `json:"next"`
+	// Count is how many objects in the region implement the interface
+	Count uint64 `json:"count"`
+}
+
+// Implementers returns the objects in [RegionID] that declared, and were
+// checked to implement, [InterfaceID].
+func (j *JSONRPCServer) Implementers(req *http.Request, args *ImplementersArgs, reply *ImplementersReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Implementers")
+	defer span.End()
+
+	iface, err := storage.GetInterfaceFromState(ctx, j.vm.ReadState, args.InterfaceID)
+	if err != nil {
+		return err
+	}
+	limit := args.Limit
+	if limit == 0 || limit > maxObjectSearch {
+		limit = maxObjectSearch
+	}
+	objectIDs, next, count, err := storage.GetInterfaceIndexFromState(ctx, j.vm.ReadState, args.RegionID, args.InterfaceID, args.From, limit)
+	if err != nil {
+		return err
+	}
+	reply.Interface = iface
+	reply.ObjectIDs = objectIDs
+	reply.Next = next
+	reply.Count = count
+	return nil
+}
+
+// Implementers returns up to [limit] objects in [regionID] implementing
+// [interfaceID] from [from], and the object to continue from.
+func (cli *JSONRPCClient) Implementers(ctx context.Context, regionID string, interfaceID ids.ID, from string, limit uint64) ([]string, string, error) {
+	resp := new(ImplementersReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"implementers",
+		&ImplementersArgs{RegionID: regionID, InterfaceID: interfaceID, From: from, Limit: limit},
+		resp,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.ObjectIDs, resp.Next, nil
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ava-labs/hypersdk/chain"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var ErrUnknownActionType = errors.New("unknown action type")
+
+// jsonActions constructs an empty action for each type ID accepted as JSON
+// by simulate and submit. It mirrors the ActionParser registrations.
+var jsonActions = map[uint8]func() chain.Action{
+	consts.CreateObjectID:             func() chain.Action { return &actions.CreateObjectAction{} },
+	consts.SendEventID:                func() chain.Action { return &actions.SendEventAction{} },
+	consts.SetInputObjectID:           func() chain.Action { return &actions.SetInputObjectAction{} },
+	consts.CreateRegionID:             func() chain.Action { return &actions.CreateRegionAction{} },
+	consts.UpdateRegionID:             func() chain.Action { return &actions.UpdateRegionAction{} },
+	consts.TEEExecID:                  func() chain.Action { return &actions.TEEExecAction{} },
+	consts.ClaimRewardsID:             func() chain.Action { return &actions.ClaimRewardsAction{} },
+	consts.ProposeID:                  func() chain.Action { return &actions.ProposeAction{} },
+	consts.VoteID:                     func() chain.Action { return &actions.VoteAction{} },
+	consts.ExecuteProposalID:          func() chain.Action { return &actions.ExecuteProposalAction{} },
+	consts.AdminID:                    func() chain.Action { return &actions.AdminAction{} },
+	consts.StartUploadID:              func() chain.Action { return &actions.StartUploadAction{} },
+	consts.AppendChunkID:              func() chain.Action { return &actions.AppendChunkAction{} },
+	consts.CommitObjectID:             func() chain.Action { return &actions.CommitObjectAction{} },
+	consts.SettleRegionID:             func() chain.Action { return &actions.SettleRegionAction{} },
+	consts.ChallengeSettlementID:      func() chain.Action { return &actions.ChallengeSettlementAction{} },
+	consts.FinalizeSettlementID:       func() chain.Action { return &actions.FinalizeSettlementAction{} },
+	consts.SetNitroPolicyID:           func() chain.Action { return &actions.SetNitroPolicyAction{} },
+	consts.RegisterNitroEnclaveID:     func() chain.Action { return &actions.RegisterNitroEnclaveAction{} },
+	consts.RegisterCCAEnclaveID:       func() chain.Action { return &actions.RegisterCCAEnclaveAction{} },
+	consts.SetPlatformPolicyID:        func() chain.Action { return &actions.SetPlatformPolicyAction{} },
+	consts.PublishCollateralID:        func() chain.Action { return &actions.PublishCollateralAction{} },
+	consts.ReattestEnclaveID:          func() chain.Action { return &actions.ReattestEnclaveAction{} },
+	consts.QueueRequestID:             func() chain.Action { return &actions.QueueRequestAction{} },
+	consts.ApproveID:                  func() chain.Action { return &actions.ApproveAction{} },
+	consts.TransferFromID:             func() chain.Action { return &actions.TransferFromAction{} },
+	consts.SetSponsorPolicyID:         func() chain.Action { return &actions.SetSponsorPolicyAction{} },
+	consts.CreateAssetID:              func() chain.Action { return &actions.CreateAssetAction{} },
+	consts.MintAssetID:                func() chain.Action { return &actions.MintAssetAction{} },
+	consts.TransferAssetID:            func() chain.Action { return &actions.TransferAssetAction{} },
+	consts.PublishRandomnessID:        func() chain.Action { return &actions.PublishRandomnessAction{} },
+	consts.RegisterFeedID:             func() chain.Action { return &actions.RegisterFeedAction{} },
+	consts.PublishFeedID:              func() chain.Action { return &actions.PublishFeedAction{} },
+	consts.SealStorageID:              func() chain.Action { return &actions.SealStorageAction{} },
+	consts.ResealStorageID:            func() chain.Action { return &actions.ResealStorageAction{} },
+	consts.PublishAppKeyID:            func() chain.Action { return &actions.PublishAppKeyAction{} },
+	consts.RotateAppKeyID:             func() chain.Action { return &actions.RotateAppKeyAction{} },
+	consts.RevokeAppKeyID:             func() chain.Action { return &actions.RevokeAppKeyAction{} },
+	consts.CreateRegionFromTemplateID: func() chain.Action { return &actions.CreateRegionFromTemplateAction{} },
+	consts.RegisterNameID:             func() chain.Action { return &actions.RegisterNameAction{} },
+	consts.TransferNameID:             func() chain.Action { return &actions.TransferNameAction{} },
+	consts.SetExecLimitsID:            func() chain.Action { return &actions.SetExecLimitsAction{} },
+	consts.FreezeAndExportRegionID:    func() chain.Action { return &actions.FreezeAndExportRegionAction{} },
+	consts.ImportRegionID:             func() chain.Action { return &actions.ImportRegionAction{} },
+	consts.AuthorizeSessionID:         func() chain.Action { return &actions.AuthorizeSessionAction{} },
+	consts.RevokeSessionID:            func() chain.Action { return &actions.RevokeSessionAction{} },
+	consts.SubmitCheckpointID:         func() chain.Action { return &actions.SubmitCheckpointAction{} },
+	consts.PruneEnclaveID:             func() chain.Action { return &actions.PruneEnclaveAction{} },
+	consts.PipelineID:                 func() chain.Action { return &actions.PipelineAction{} },
+	consts.PreemptEventID:             func() chain.Action { return &actions.PreemptEventAction{} },
+	consts.StartSagaID:                func() chain.Action { return &actions.StartSagaAction{} },
+	consts.WithdrawVoteID:             func() chain.Action { return &actions.WithdrawVoteAction{} },
+}
+
+// ActionFromJSON decodes [payload] into the action registered for [typeID].
+// Byte fields are expected as 0x-prefixed hex strings.
+func ActionFromJSON(typeID uint8, payload []byte) (chain.Action, error) {
+	newAction, ok := jsonActions[typeID]
+	if !ok {
+		return nil, ErrUnknownActionType
+	}
+	action := newAction()
+	if err := json.Unmarshal(payload, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
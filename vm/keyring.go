@@ -0,0 +1,58 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type AppKeysArgs struct {
+	RegionID string `json:"regionId"`
+	// Name filters the keys to the versions of one name when set
+	Name string `json:"name"`
+}
+
+type AppKeysReply struct {
+	// Keys are in publication order, retired and revoked versions included
+	Keys []storage.AppKey `json:"keys"`
+}
+
+// AppKeys returns the application keys [RegionID] published.
+func (j *JSONRPCServer) AppKeys(req *http.Request, args *AppKeysArgs, reply *AppKeysReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.AppKeys")
+	defer span.End()
+
+	keyring, err := storage.GetKeyringFromState(ctx, j.vm.ReadState, args.RegionID)
+	if err != nil {
+		return err
+	}
+	if len(args.Name) > 0 {
+		reply.Keys = keyring.Named(args.Name)
+		return nil
+	}
+	reply.Keys = keyring.Keys
+	return nil
+}
+
+// AppKeys returns the application keys [regionID] published under [name],
+// or all of them if [name] is empty.
+func (cli *JSONRPCClient) AppKeys(ctx context.Context, regionID, name string) ([]storage.AppKey, error) {
+	resp := new(AppKeysReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"appKeys",
+		&AppKeysArgs{
+			RegionID: regionID,
+			Name:     name,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const LoggingNamespace = "logging"
+
+// LoggingConfig sets the verbosity of the VM's log lines. When [Regions] is
+// set, lines about any other region are dropped.
+type LoggingConfig struct {
+	Level   string   `json:"level"`
+	Regions []string `json:"regions"`
+}
+
+func NewDefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		Level: logging.Info.String(),
+	}
+}
+
+// WithLogging installs the logger of [vmlog.Default], writing through the
+// VM's own logger.
+func WithLogging() vm.Option {
+	return vm.NewOption(LoggingNamespace, NewDefaultLoggingConfig(), func(v *vm.VM, config LoggingConfig) error {
+		level, err := logging.ToLevel(config.Level)
+		if err != nil {
+			return err
+		}
+		vmlog.SetDefault(vmlog.New(v.Logger(), level, config.Regions))
+		return nil
+	})
+}
@@ -0,0 +1,306 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	LogIndexNamespace = "logIndex"
+
+	// maxLogBlocks bounds the blocks one logs query scans
+	maxLogBlocks = 1_024
+	// maxLogResults bounds the logs one query returns
+	maxLogResults = 1_024
+)
+
+var ErrLogRange = errors.New("invalid log block range")
+
+// LogIndexConfig keeps the contract logs and the bloom filter of each of
+// the last [Retain] accepted blocks in state. Zero disables the index.
+type LogIndexConfig struct {
+	Retain uint64 `json:"retain"`
+}
+
+func NewDefaultLogIndexConfig() LogIndexConfig {
+	return LogIndexConfig{
+		Retain: 1_024,
+	}
+}
+
+func WithLogIndex() vm.Option {
+	return vm.NewOption(LogIndexNamespace, NewDefaultLogIndexConfig(), func(v *vm.VM, config LogIndexConfig) error {
+		if config.Retain == 0 {
+			return nil
+		}
+		vm.WithBlockSubscriptions(logIndexerFactory{vm: v, config: config})(v)
+		return nil
+	})
+}
+
+var _ event.SubscriptionFactory[*chain.StatefulBlock] = (*logIndexerFactory)(nil)
+
+type logIndexerFactory struct {
+	vm     *vm.VM
+	config LogIndexConfig
+}
+
+func (f logIndexerFactory) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return &logIndexer{vm: f.vm, config: f.config}, nil
+}
+
+type logIndexer struct {
+	vm     *vm.VM
+	config LogIndexConfig
+}
+
+func (l *logIndexer) Accept(blk *chain.StatefulBlock) error {
+	db, err := l.vm.State()
+	if err != nil {
+		return err
+	}
+	logs, bloom := indexBlock(blk.Height(), blk.Txs, blk.Results())
+	return storage.PutBlockLogs(db, logs, bloom, l.config.Retain)
+}
+
+func (*logIndexer) Close() error {
+	return nil
+}
+
+// indexBlock gathers the logs of the executions in [txs] that were
+// applied, and the bloom filter of them and of the regions and objects the
+// successful transactions acted on. Failed transactions, and executions
+// reported unsuccessful in their output, applied nothing.
+func indexBlock(height uint64, txs []*chain.Transaction, results []*chain.Result) (*storage.BlockLogs, *storage.BlockBloom) {
+	logs := &storage.BlockLogs{Height: height}
+	bloom := new(storage.BlockBloom)
+	for i, tx := range txs {
+		if i >= len(results) || !results[i].Success {
+			continue
+		}
+		for j, action := range tx.Actions {
+			if regionID := actions.ActionRegion(action); regionID != "" {
+				bloom.Add([]byte(regionID))
+			}
+			for _, objectID := range actionObjects(action) {
+				bloom.Add([]byte(objectID))
+			}
+			exec, ok := action.(*actions.TEEExecAction)
+			if !ok || len(exec.ExecResult.Logs) == 0 || !execApplied(results[i], j) {
+				continue
+			}
+			for _, log := range exec.ExecResult.Logs {
+				logs.Logs = append(logs.Logs, storage.BlockLog{
+					TxID:     tx.ID(),
+					RegionID: exec.RegionID,
+					Contract: exec.ExecResult.ContractAddr,
+					Index:    uint32(len(logs.Logs)),
+					Topics:   log.Topics,
+					Data:     log.Data,
+				})
+			}
+		}
+	}
+	bloom.AddLogs(logs.Logs)
+	return logs, bloom
+}
+
+// actionObjects returns the objects [action] acts on, if any.
+func actionObjects(action chain.Action) []string {
+	switch a := action.(type) {
+	case *actions.CreateObjectAction:
+		return []string{a.ID}
+	case *actions.SetInputObjectAction:
+		return []string{a.ID}
+	case *actions.SendEventAction:
+		if a.CallbackObject != "" {
+			return []string{a.IDTo, a.CallbackObject}
+		}
+		return []string{a.IDTo}
+	case *actions.PipelineAction:
+		if a.Next != "" {
+			return []string{a.ID, a.Next}
+		}
+		return []string{a.ID}
+	case *actions.CommitObjectAction:
+		return []string{a.ObjectID}
+	case *actions.StartSagaAction:
+		objects := make([]string, 0, len(a.Steps))
+		for _, step := range a.Steps {
+			objects = append(objects, step.Object)
+		}
+		return objects
+	case *actions.SetSponsorPolicyAction:
+		return []string{a.ObjectID}
+	case *actions.SealStorageAction:
+		return []string{a.ObjectID}
+	case *actions.ResealStorageAction:
+		return []string{a.ObjectID}
+	case *actions.TEEExecAction:
+		objects := []string{string(a.ExecResult.ContractAddr)}
+		for _, call := range a.ExecResult.Calls {
+			objects = append(objects, call.Callee)
+		}
+		return objects
+	}
+	return nil
+}
+
+// execApplied reports whether the output of the [j]th action of [result]
+// is a successful execution.
+func execApplied(result *chain.Result, j int) bool {
+	if j >= len(result.Outputs) {
+		return false
+	}
+	b := result.Outputs[j]
+	output, err := OutputParser.Unmarshal(codec.NewReader(b, len(b)))
+	if err != nil {
+		return false
+	}
+	out, ok := output.(*actions.TEEExecOutput)
+	return ok && out.Success
+}
+
+type LogsArgs struct {
+	storage.LogFilter
+	FromHeight uint64 `json:"from_height"`
+	ToHeight   uint64 `json:"to_height"`
+}
+
+type LogsReply struct {
+	Logs []LogEntry `json:"logs"`
+	// Next is the height to resume from when the result was cut short,
+	// zero otherwise
+	Next uint64 `json:"next,omitempty"`
+}
+
+type LogEntry struct {
+	Height uint64 `json:"height"`
+	storage.BlockLog
+}
+
+// Logs returns the logs matching a filter in the blocks from [FromHeight]
+// to [ToHeight], both included. Blocks whose bloom filter rules out a
+// match are not read. Only the logs of blocks the index still keeps are
+// found.
+func (j *JSONRPCServer) Logs(req *http.Request, args *LogsArgs, reply *LogsReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Logs")
+	defer span.End()
+
+	if args.ToHeight < args.FromHeight || args.ToHeight-args.FromHeight >= maxLogBlocks {
+		return ErrLogRange
+	}
+	reply.Logs = []LogEntry{}
+	for height := args.FromHeight; height <= args.ToHeight; height++ {
+		bloom, err := storage.GetBlockBloomFromState(ctx, j.vm.ReadState, height)
+		if err != nil {
+			return err
+		}
+		if bloom == nil || !args.MayMatch(bloom) {
+			continue
+		}
+		logs, err := storage.GetBlockLogsFromState(ctx, j.vm.ReadState, height)
+		if err != nil {
+			return err
+		}
+		if logs == nil {
+			continue
+		}
+		var matched []LogEntry
+		for _, log := range logs.Logs {
+			if args.Matches(&log) {
+				matched = append(matched, LogEntry{Height: height, BlockLog: log})
+			}
+		}
+		// Blocks are returned whole, so a query can resume from Next
+		if len(reply.Logs) > 0 && len(reply.Logs)+len(matched) > maxLogResults {
+			reply.Next = height
+			return nil
+		}
+		reply.Logs = append(reply.Logs, matched...)
+	}
+	return nil
+}
+
+// Logs returns the logs matching [filter] in the blocks from [from] to
+// [to], and the height to resume from if not all fit in one reply.
+func (cli *JSONRPCClient) Logs(ctx context.Context, filter storage.LogFilter, from, to uint64) ([]LogEntry, uint64, error) {
+	resp := new(LogsReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"logs",
+		&LogsArgs{LogFilter: filter, FromHeight: from, ToHeight: to},
+		resp,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Logs, resp.Next, nil
+}
+
+type BlockBloomsArgs struct {
+	FromHeight uint64 `json:"from_height"`
+	ToHeight   uint64 `json:"to_height"`
+}
+
+type BlockBloomsReply struct {
+	// Blooms are the filters of the blocks in the range that have one, in
+	// height order
+	Blooms []HeightBloom `json:"blooms"`
+}
+
+type HeightBloom struct {
+	Height uint64             `json:"height"`
+	Bloom  storage.BlockBloom `json:"bloom"`
+}
+
+// BlockBlooms returns the bloom filters of the blocks from [FromHeight] to
+// [ToHeight], both included, so clients can skip blocks that cannot hold
+// a region, object or topic they look for. A block without a filter acted
+// on none.
+func (j *JSONRPCServer) BlockBlooms(req *http.Request, args *BlockBloomsArgs, reply *BlockBloomsReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.BlockBlooms")
+	defer span.End()
+
+	if args.ToHeight < args.FromHeight || args.ToHeight-args.FromHeight >= maxLogBlocks {
+		return ErrLogRange
+	}
+	reply.Blooms = []HeightBloom{}
+	for height := args.FromHeight; height <= args.ToHeight; height++ {
+		bloom, err := storage.GetBlockBloomFromState(ctx, j.vm.ReadState, height)
+		if err != nil {
+			return err
+		}
+		if bloom != nil {
+			reply.Blooms = append(reply.Blooms, HeightBloom{Height: height, Bloom: *bloom})
+		}
+	}
+	return nil
+}
+
+// BlockBlooms returns the bloom filters of the blocks from [from] to [to].
+func (cli *JSONRPCClient) BlockBlooms(ctx context.Context, from, to uint64) ([]HeightBloom, error) {
+	resp := new(BlockBloomsReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"blockBlooms",
+		&BlockBloomsArgs{FromHeight: from, ToHeight: to},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Blooms, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const MigrationsNamespace = "migrations"
+
+// MigrationsConfig bounds how many records a lazy migration rewrites after
+// each accepted block.
+type MigrationsConfig struct {
+	BatchSize int `json:"batchSize"`
+}
+
+func NewDefaultMigrationsConfig() MigrationsConfig {
+	return MigrationsConfig{
+		BatchSize: 1_024,
+	}
+}
+
+// WithMigrations brings state up to [storage.DefaultMigrations.Latest] at
+// startup and continues any lazy migration as blocks are accepted.
+func WithMigrations() vm.Option {
+	return vm.NewOption(MigrationsNamespace, NewDefaultMigrationsConfig(), func(v *vm.VM, config MigrationsConfig) error {
+		db, err := v.State()
+		if err != nil {
+			return err
+		}
+		err = storage.DefaultMigrations.Run(context.TODO(), db)
+		if errors.Is(err, storage.ErrMigrationPending) {
+			vm.WithBlockSubscriptions(migrationFactory{vm: v, config: config})(v)
+			return nil
+		}
+		return err
+	})
+}
+
+var _ event.SubscriptionFactory[*chain.StatefulBlock] = (*migrationFactory)(nil)
+
+type migrationFactory struct {
+	vm     *vm.VM
+	config MigrationsConfig
+}
+
+func (f migrationFactory) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return &migrationStepper{vm: f.vm, config: f.config}, nil
+}
+
+type migrationStepper struct {
+	vm     *vm.VM
+	config MigrationsConfig
+}
+
+func (m *migrationStepper) Accept(*chain.StatefulBlock) error {
+	db, err := m.vm.State()
+	if err != nil {
+		return err
+	}
+	return storage.DefaultMigrations.Step(context.TODO(), db, m.config.BatchSize)
+}
+
+func (*migrationStepper) Close() error {
+	return nil
+}
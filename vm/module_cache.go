@@ -0,0 +1,253 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const ModuleCacheNamespace = "moduleCache"
+
+// ModuleCacheConfig sizes the compiled Wasm module cache.
+type ModuleCacheConfig struct {
+	// MaxBytes bounds the summed size of the cached modules. Zero disables
+	// the cache.
+	MaxBytes uint64 `json:"maxBytes"`
+	// Precompile compiles the Wasm code of objects as blocks creating them
+	// are accepted, so their first execution does not pay for it
+	Precompile bool `json:"precompile"`
+}
+
+func NewDefaultModuleCacheConfig() ModuleCacheConfig {
+	return ModuleCacheConfig{
+		MaxBytes:   256 << 20,
+		Precompile: true,
+	}
+}
+
+// ModuleCompiler compiles Wasm for a runtime. Modules are cached by code
+// hash alone, so compilation must be deterministic: the same features and
+// limits on every node and every call, with no tuning to the host.
+type ModuleCompiler interface {
+	Compile(ctx context.Context, wasm []byte) (CompiledModule, error)
+}
+
+type CompiledModule interface {
+	// Size is what the module counts against [ModuleCacheConfig.MaxBytes]
+	Size() uint64
+	Close(ctx context.Context) error
+}
+
+// ModuleCache holds compiled modules by the SHA-256 hash of their Wasm,
+// evicting the least recently used once they exceed its size bound. It is
+// safe for concurrent use.
+type ModuleCache struct {
+	compiler ModuleCompiler
+	maxBytes uint64
+
+	l       sync.Mutex
+	bytes   uint64
+	lru     *list.List // of *cachedModule, most recent first
+	modules map[ids.ID]*list.Element
+}
+
+type cachedModule struct {
+	hash   ids.ID
+	module CompiledModule
+}
+
+func NewModuleCache(compiler ModuleCompiler, maxBytes uint64) *ModuleCache {
+	return &ModuleCache{
+		compiler: compiler,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		modules:  map[ids.ID]*list.Element{},
+	}
+}
+
+// Get returns the compiled module of [wasm], compiling it on a miss.
+// Callers must not close it; the cache does on eviction.
+func (c *ModuleCache) Get(ctx context.Context, wasm []byte) (CompiledModule, error) {
+	hash := ids.ID(sha256.Sum256(wasm))
+	if module, ok := c.lookup(hash); ok {
+		return module, nil
+	}
+	// Compile without the lock; if another caller raced us, keep theirs
+	module, err := c.compiler.Compile(ctx, wasm)
+	if err != nil {
+		return nil, err
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	if e, ok := c.modules[hash]; ok {
+		_ = module.Close(ctx)
+		c.lru.MoveToFront(e)
+		return e.Value.(*cachedModule).module, nil
+	}
+	c.modules[hash] = c.lru.PushFront(&cachedModule{hash: hash, module: module})
+	c.bytes += module.Size()
+	c.evict(ctx)
+	return module, nil
+}
+
+// Precompile compiles [wasm] into the cache ahead of its first use.
+func (c *ModuleCache) Precompile(ctx context.Context, wasm []byte) error {
+	_, err := c.Get(ctx, wasm)
+	return err
+}
+
+func (c *ModuleCache) lookup(hash ids.ID) (CompiledModule, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	e, ok := c.modules[hash]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedModule).module, true
+}
+
+// evict drops the least recently used modules until the cache fits its
+// bound. The most recent module is kept even if it alone exceeds it.
+func (c *ModuleCache) evict(ctx context.Context) {
+	for c.bytes > c.maxBytes && c.lru.Len() > 1 {
+		e := c.lru.Back()
+		m := c.lru.Remove(e).(*cachedModule)
+		delete(c.modules, m.hash)
+		c.bytes -= m.module.Size()
+		_ = m.module.Close(ctx)
+	}
+}
+
+// Len returns how many modules are cached.
+func (c *ModuleCache) Len() int {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.lru.Len()
+}
+
+// Bytes returns the summed size of the cached modules.
+func (c *ModuleCache) Bytes() uint64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.bytes
+}
+
+var (
+	moduleCompiler ModuleCompiler
+	moduleCache    *ModuleCache
+)
+
+// SetModuleCompiler installs the compiler of the runtime executing object
+// code. [WithModuleCache] caches what it compiles.
+func SetModuleCompiler(compiler ModuleCompiler) {
+	moduleCompiler = compiler
+}
+
+// Modules returns the compiled module cache, or nil if no compiler is
+// installed or the cache is disabled.
+func Modules() *ModuleCache {
+	return moduleCache
+}
+
+func WithModuleCache() vm.Option {
+	return vm.NewOption(ModuleCacheNamespace, NewDefaultModuleCacheConfig(), func(v *vm.VM, config ModuleCacheConfig) error {
+		if moduleCompiler == nil || config.MaxBytes == 0 {
+			return nil
+		}
+		moduleCache = NewModuleCache(moduleCompiler, config.MaxBytes)
+		if config.Precompile {
+			vm.WithBlockSubscriptions(precompilerFactory{vm: v, cache: moduleCache})(v)
+		}
+		return nil
+	})
+}
+
+var _ event.SubscriptionFactory[*chain.StatefulBlock] = (*precompilerFactory)(nil)
+
+type precompilerFactory struct {
+	vm    *vm.VM
+	cache *ModuleCache
+}
+
+func (f precompilerFactory) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return &precompiler{vm: f.vm, cache: f.cache}, nil
+}
+
+type precompiler struct {
+	vm    *vm.VM
+	cache *ModuleCache
+}
+
+// Accept compiles the Wasm code of the objects created by successful
+// transactions of [blk]. Code that fails to compile is logged, not
+// rejected: the block is already accepted and execution reports the error.
+func (p *precompiler) Accept(blk *chain.StatefulBlock) error {
+	ctx := context.TODO()
+	db, err := p.vm.State()
+	if err != nil {
+		return err
+	}
+	results := blk.Results()
+	for i, tx := range blk.Txs {
+		if i >= len(results) || !results[i].Success {
+			continue
+		}
+		for _, action := range tx.Actions {
+			var code []byte
+			switch a := action.(type) {
+			case *actions.CreateObjectAction:
+				if code, err = storage.DecodeCode(a.Code, consts.MaxCodeSize); err != nil {
+					continue
+				}
+			case *actions.CommitObjectAction:
+				if code, err = storage.GetObjectCode(ctx, db, a.ObjectID); err != nil {
+					return err
+				}
+			}
+			wasm, ok := wasmBody(code)
+			if !ok {
+				continue
+			}
+			if err := p.cache.Precompile(ctx, wasm); err != nil {
+				vmlog.Default().WithHeight(blk.Height()).Warn("object code failed to compile", zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+func (*precompiler) Close() error {
+	return nil
+}
+
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+
+// wasmBody returns the Wasm module of decompressed [code], which is either
+// bare or behind a code header of [FormatWasm].
+func wasmBody(code []byte) ([]byte, bool) {
+	if len(code) >= HeaderSize && bytes.Equal(code[:len(HeaderMagic)], []byte(HeaderMagic)) {
+		if code[8] != FormatWasm {
+			return nil, false
+		}
+		code = code[HeaderSize:]
+	}
+	return code, bytes.HasPrefix(code, wasmMagic)
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sizedCompiler compiles a module to as many bytes as its Wasm, counting
+// compilations.
+type sizedCompiler struct {
+	compiles int
+}
+
+type sizedModule struct {
+	size   uint64
+	closed bool
+}
+
+func (m *sizedModule) Size() uint64 { return m.size }
+
+func (m *sizedModule) Close(context.Context) error {
+	m.closed = true
+	return nil
+}
+
+func (c *sizedCompiler) Compile(_ context.Context, wasm []byte) (CompiledModule, error) {
+	c.compiles++
+	return &sizedModule{size: uint64(len(wasm))}, nil
+}
+
+func TestModuleCache(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	compiler := &sizedCompiler{}
+	cache := NewModuleCache(compiler, 24)
+
+	// Each module is 10 bytes, so two fit
+	module := func(b byte) []byte {
+		return append(append([]byte{}, wasmMagic...), b, b)
+	}
+	first, second, third := module(1), module(2), module(3)
+
+	a, err := cache.Get(ctx, first)
+	require.NoError(err)
+	again, err := cache.Get(ctx, first)
+	require.NoError(err)
+	require.Same(a, again)
+	require.Equal(1, compiler.compiles)
+
+	require.NoError(cache.Precompile(ctx, second))
+	require.Equal(2, cache.Len())
+	require.Equal(uint64(20), cache.Bytes())
+
+	// Using first makes second the least recently used
+	_, err = cache.Get(ctx, first)
+	require.NoError(err)
+	require.NoError(cache.Precompile(ctx, third))
+	require.Equal(2, cache.Len())
+	require.Equal(3, compiler.compiles)
+	_, err = cache.Get(ctx, first)
+	require.NoError(err)
+	require.Equal(3, compiler.compiles)
+	require.False(a.(*sizedModule).closed)
+	_, err = cache.Get(ctx, second)
+	require.NoError(err)
+	require.Equal(4, compiler.compiles)
+}
+
+func TestWasmBody(t *testing.T) {
+	require := require.New(t)
+
+	wasm := append(append([]byte{}, wasmMagic...), 0x01)
+	body, ok := wasmBody(wasm)
+	require.True(ok)
+	require.Equal(wasm, body)
+	body, ok = wasmBody(CreateCode(FormatWasm, 1, wasm))
+	require.True(ok)
+	require.Equal(wasm, body)
+	_, ok = wasmBody(CreateCode(FormatRaw, 1, wasm))
+	require.False(ok)
+	_, ok = wasmBody([]byte{1, 2, 3})
+	require.False(ok)
+}
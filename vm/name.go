@@ -0,0 +1,50 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type ResolveNameArgs struct {
+	Name string `json:"name"`
+}
+
+type ResolveNameReply struct {
+	// Record is the registration of [Name], or nil if it was never
+	// registered. A name past its ExpiresAt no longer resolves.
+	Record *storage.NameRecord `json:"record,omitempty"`
+}
+
+// ResolveName returns the region or object a registered name refers to.
+func (j *JSONRPCServer) ResolveName(req *http.Request, args *ResolveNameArgs, reply *ResolveNameReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.ResolveName")
+	defer span.End()
+
+	record, err := storage.GetNameFromState(ctx, j.vm.ReadState, args.Name)
+	if err != nil {
+		return err
+	}
+	reply.Record = record
+	return nil
+}
+
+// ResolveName returns the registration of [name], or nil if it was never
+// registered.
+func (cli *JSONRPCClient) ResolveName(ctx context.Context, name string) (*storage.NameRecord, error) {
+	resp := new(ResolveNameReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"resolveName",
+		&ResolveNameArgs{Name: name},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Record, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+const (
+	// maxObjectSearch bounds the objects one searchObjects request returns
+	maxObjectSearch = 256
+	// maxObjectScan bounds the index entries one request scans
+	maxObjectScan = 1_024
+)
+
+type SearchObjectsArgs struct {
+	RegionID string `json:"regionId"`
+	// Tag, when set, only returns objects carrying it
+	Tag string `json:"tag"`
+	// Query, when set, only returns objects whose name or description
+	// contains it, ignoring case
+	Query string `json:"query"`
+	// From is the object to resume scanning from, the Next of a previous
+	// reply, or empty to scan from the latest
+	From string `json:"from"`
+	// Limit caps the objects returned, at most 256
+	Limit uint64 `json:"limit"`
+}
+
+type ObjectListing struct {
+	ObjectID string `json:"objectId"`
+	storage.ObjectMetadata
+}
+
+type SearchObjectsReply struct {
+	// Objects are latest created first
+	Objects []ObjectListing `json:"objects"`
+	// Next is the object to continue the search from, empty once the
+	// whole index was scanned. A request scans at most 1,024 entries, so
+	// fewer objects than asked for do not mean the search is done.
+	Next string `json:"next"`
+	// Count is how many objects the region lists in all
+	Count uint64 `json:"count"`
+}
+
+// SearchObjects returns the objects listed in [RegionID] that match [Tag]
+// and [Query], with their metadata, scanning the region's index from
+// [From].
+func (j *JSONRPCServer) SearchObjects(req *http.Request, args *SearchObjectsArgs, reply *SearchObjectsReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.SearchObjects")
+	defer span.End()
+
+	limit := args.Limit
+	if limit == 0 || limit > maxObjectSearch {
+		limit = maxObjectSearch
+	}
+	objectIDs, next, count, err := storage.GetObjectIndexFromState(ctx, j.vm.ReadState, args.RegionID, args.From, maxObjectScan)
+	if err != nil {
+		return err
+	}
+	reply.Count = count
+	reply.Next = next
+	query := strings.ToLower(args.Query)
+	for _, objectID := range objectIDs {
+		if uint64(len(reply.Objects)) == limit {
+			reply.Next = objectID
+			break
+		}
+		metadata, err := storage.GetObjectMetadataFromState(ctx, j.vm.ReadState, objectID)
+		if err != nil {
+			return err
+		}
+		if metadata != nil && matchesObject(metadata, args.Tag, query) {
+			reply.Objects = append(reply.Objects, ObjectListing{ObjectID: objectID, ObjectMetadata: *metadata})
+		}
+	}
+	return nil
+}
+
+func matchesObject(m *storage.ObjectMetadata, tag, query string) bool {
+	if tag != "" && !m.HasTag(tag) {
+		return false
+	}
+	return query == "" ||
+		strings.Contains(strings.ToLower(m.Name), query) ||
+		strings.Contains(strings.ToLower(m.Description), query)
+}
+
+// SearchObjects returns up to [limit] objects listed in [regionID] tagged
+// [tag], if set, and whose name or description contains [query], if set,
+// scanning from [from]. It also returns the object to continue from, empty
+// once the whole index was scanned.
+func (cli *JSONRPCClient) SearchObjects(ctx context.Context, regionID, tag, query, from string, limit uint64) ([]ObjectListing, string, error) {
+	resp := new(SearchObjectsReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"searchObjects",
+		&SearchObjectsArgs{RegionID: regionID, Tag: tag, Query: query, From: from, Limit: limit},
+		resp,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Objects, resp.Next, nil
+}
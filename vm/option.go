@@ -3,26 +3,129 @@
 
 package vm
 
-import "github.com/ava-labs/hypersdk/vm"
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/verifier"
+)
 
 const Namespace = "controller"
 
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config is read from the [Namespace] section of the node's VM config.
+// Fields left out of the JSON keep their defaults.
 type Config struct {
 	Enabled bool `json:"enabled"`
+	// VerifierWorkers is how many actions of a batch the verifier checks
+	// at once
+	VerifierWorkers int                `json:"verifierWorkers"`
+	Roughtime       RoughtimeConfig    `json:"roughtime"`
+	Clock           ClockConfig        `json:"clock"`
+	Health          HealthConfig       `json:"health"`
+	RegionEvents    RegionEventsConfig `json:"regionEvents"`
+	Replica         ReplicaConfig      `json:"replica"`
+	Access          AccessConfig       `json:"access"`
+	Prefilter       PrefilterConfig    `json:"prefilter"`
 }
 
 func NewDefaultConfig() Config {
 	return Config{
-		Enabled: true,
+		Enabled:         true,
+		VerifierWorkers: 1,
+		Roughtime:       NewDefaultRoughtimeConfig(),
+		Clock:           NewDefaultClockConfig(),
+		RegionEvents:    NewDefaultRegionEventsConfig(),
+		Replica:         NewDefaultReplicaConfig(),
+		Prefilter:       NewDefaultPrefilterConfig(),
 	}
 }
 
+// Validate reports the first setting in [c] the VM cannot run with.
+func (c Config) Validate() error {
+	if c.VerifierWorkers < 1 {
+		return fmt.Errorf("%w: verifierWorkers %d", ErrInvalidConfig, c.VerifierWorkers)
+	}
+	for _, server := range c.Roughtime.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return fmt.Errorf("%w: roughtime server %q: %w", ErrInvalidConfig, server, err)
+		}
+	}
+	if c.Roughtime.Timeout <= 0 {
+		return fmt.Errorf("%w: roughtime timeout %d", ErrInvalidConfig, c.Roughtime.Timeout)
+	}
+	if c.Clock.ProbeInterval < 0 || c.Clock.WarnSkew < 0 || c.Clock.RefuseBuildSkew < 0 {
+		return fmt.Errorf("%w: clock settings must not be negative", ErrInvalidConfig)
+	}
+	if c.Clock.RefuseBuildSkew > 0 && c.Clock.ProbeInterval == 0 {
+		return fmt.Errorf("%w: clock refuseBuildSkew needs a probeInterval", ErrInvalidConfig)
+	}
+	if c.RegionEvents.BufferSize < 1 {
+		return fmt.Errorf("%w: regionEvents bufferSize %d", ErrInvalidConfig, c.RegionEvents.BufferSize)
+	}
+	if c.RegionEvents.MaxSubscribers < 0 {
+		return fmt.Errorf("%w: regionEvents maxSubscribers %d", ErrInvalidConfig, c.RegionEvents.MaxSubscribers)
+	}
+	if c.RegionEvents.ReplayBlocks < 0 {
+		return fmt.Errorf("%w: regionEvents replayBlocks %d", ErrInvalidConfig, c.RegionEvents.ReplayBlocks)
+	}
+	if c.Replica.Enabled && (c.Replica.MaxConcurrentRequests < 1 || c.Replica.MaxRequestBytes < 1) {
+		return fmt.Errorf("%w: replica limits must be positive", ErrInvalidConfig)
+	}
+	if c.Prefilter.Enabled && c.Prefilter.CacheSize < 1 {
+		return fmt.Errorf("%w: prefilter cacheSize %d", ErrInvalidConfig, c.Prefilter.CacheSize)
+	}
+	if _, err := c.Access.secret(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// With returns the ShuttleVM options, configured from the node's VM config.
 func With() vm.Option {
-	return vm.NewOption(Namespace, NewDefaultConfig(), func(v *vm.VM, config Config) error {
+	return WithConfig(NewDefaultConfig())
+}
+
+// WithConfig is [With] with [config] in place of the defaults. Settings in
+// the node's VM config still take precedence.
+func WithConfig(config Config) vm.Option {
+	return vm.NewOption(Namespace, config, func(v *vm.VM, config Config) error {
 		if !config.Enabled {
 			return nil
 		}
-		vm.WithVMAPIs(jsonRPCServerFactory{})(v)
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		if config.Replica.Enabled {
+			// A replica never proposes blocks or gossips transactions, so it
+			// cannot act as a validator even if its node is staked
+			vm.WithManual()(v)
+		}
+		verifier.SetWorkers(config.VerifierWorkers)
+		hub := newRegionEventHub(config.Access, config.RegionEvents)
+		activeRegionHub.Store(hub)
+		clock, err := newClockMonitor(v, config.Clock, config.Roughtime)
+		if err != nil {
+			return err
+		}
+		activeClock.Store(clock)
+		vm.WithVMAPIs(
+			jsonRPCServerFactory{replica: config.Replica},
+			regionEventsAPI{hub: hub},
+			healthAPI{path: HealthEndpoint, config: config.Health, roughtime: config.Roughtime},
+			healthAPI{path: ReadinessEndpoint, config: config.Health, roughtime: config.Roughtime, readiness: true},
+			clockMetricsAPI{registry: clock.registry},
+		)(v)
+		vm.WithBlockSubscriptions(regionEventsFeed{hub: hub}, clock)(v)
+		if config.Prefilter.Enabled {
+			filter := newPrefilter(v, config.Prefilter)
+			activePrefilter.Store(filter)
+			vm.WithBlockSubscriptions(filter)(v)
+		}
 		return nil
 	})
 }
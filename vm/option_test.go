@@ -0,0 +1,41 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	require := require.New(t)
+
+	config := NewDefaultConfig()
+	require.NoError(config.Validate())
+
+	// Settings absent from the JSON keep their defaults
+	require.NoError(json.Unmarshal([]byte(`{"verifierWorkers":4,"roughtime":{"servers":["roughtime.example:2002"]}}`), &config))
+	require.NoError(config.Validate())
+	require.Equal(4, config.VerifierWorkers)
+	require.Equal(NewDefaultRoughtimeConfig().Timeout, config.Roughtime.Timeout)
+	require.Equal(NewDefaultRegionEventsConfig(), config.RegionEvents)
+
+	for _, update := range []string{
+		`{"verifierWorkers":0}`,
+		`{"roughtime":{"servers":["no-port"]}}`,
+		`{"roughtime":{"timeout":0}}`,
+		`{"clock":{"warnSkew":-1}}`,
+		`{"clock":{"probeInterval":0,"refuseBuildSkew":5000}}`,
+		`{"regionEvents":{"bufferSize":0}}`,
+		`{"regionEvents":{"replayBlocks":-1}}`,
+		`{"prefilter":{"enabled":true,"cacheSize":0}}`,
+		`{"access":{"privateRegions":["private"]}}`,
+	} {
+		config := NewDefaultConfig()
+		require.NoError(json.Unmarshal([]byte(update), &config))
+		require.Error(config.Validate(), update)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/state"
+	"github.com/ava-labs/hypersdk/vm"
+	"go.uber.org/zap"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+// gossipBatchSize is the number of transactions a gossip message is
+// expected to carry.
+const gossipBatchSize = 32
+
+// PrefilterConfig sets whether transactions gossiped by peers have their
+// attestations checked before they enter the mempool. Transactions whose
+// enclave signatures or Roughtime stamps fail against the last accepted
+// state are dropped, instead of failing in a block and taking its space.
+type PrefilterConfig struct {
+	Enabled bool `json:"enabled"`
+	// CacheSize is how many transaction verdicts are remembered until the
+	// next accepted block, so a transaction gossiped by several peers is
+	// checked once
+	CacheSize int `json:"cacheSize"`
+}
+
+func NewDefaultPrefilterConfig() PrefilterConfig {
+	return PrefilterConfig{
+		CacheSize: 4_096,
+	}
+}
+
+// activePrefilter is the filter of the running VM, consulted by
+// [GuardedVM]. It is nil unless [PrefilterConfig.Enabled] is set.
+var activePrefilter atomic.Pointer[prefilter]
+
+// prefilter checks the attestations of transactions with
+// [actions.PreVerify]. Verdicts are cached by transaction ID and dropped
+// on every accepted block, as they depend on the state they were reached
+// against.
+type prefilter struct {
+	vm *vm.VM
+
+	lock     sync.Mutex
+	verdicts *cache.LRU[ids.ID, error]
+}
+
+var (
+	_ event.SubscriptionFactory[*chain.StatefulBlock] = (*prefilter)(nil)
+	_ event.Subscription[*chain.StatefulBlock]        = (*prefilter)(nil)
+)
+
+func newPrefilter(v *vm.VM, config PrefilterConfig) *prefilter {
+	return &prefilter{
+		vm:       v,
+		verdicts: &cache.LRU[ids.ID, error]{Size: config.CacheSize},
+	}
+}
+
+func (f *prefilter) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return f, nil
+}
+
+func (f *prefilter) Accept(*chain.StatefulBlock) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.verdicts.Flush()
+	return nil
+}
+
+func (*prefilter) Close() error {
+	return nil
+}
+
+// check returns why [tx] would fail against [im] at [timestamp], if one
+// of its attestations is invalid, or why it carries more attestations
+// than one transaction may. It also returns why an installed content
+// policy rejects one of its actions, or why its session key may not take
+// one.
+func (f *prefilter) check(ctx context.Context, im state.Immutable, timestamp int64, tx *chain.Transaction) error {
+	txID := tx.ID()
+	f.lock.Lock()
+	verdict, ok := f.verdicts.Get(txID)
+	f.lock.Unlock()
+	if ok {
+		return verdict
+	}
+	verdict = actions.CheckTxLoad(tx.Actions)
+	for _, action := range tx.Actions {
+		if verdict != nil {
+			break
+		}
+		if verdict = actions.PreVerify(ctx, im, timestamp, action); verdict == nil {
+			verdict = actions.CheckContent(ctx, im, action)
+		}
+		if verdict == nil {
+			verdict = actions.CheckSession(ctx, im, tx.Auth.Actor(), action, timestamp)
+		}
+	}
+	f.lock.Lock()
+	f.verdicts.Put(txID, verdict)
+	f.lock.Unlock()
+	return verdict
+}
+
+// filter returns the transactions of [txs] whose attestations hold
+// against the last accepted state at the local time.
+func (f *prefilter) filter(ctx context.Context, txs []*chain.Transaction) []*chain.Transaction {
+	db, err := f.vm.State()
+	if err != nil {
+		return txs
+	}
+	timestamp := time.Now().UnixMilli()
+	kept := txs[:0:0]
+	for _, tx := range txs {
+		if err := f.check(ctx, db, timestamp, tx); err != nil {
+			vmlog.Default().Debug("dropping gossiped transaction",
+				zap.Stringer("tx", tx.ID()),
+				zap.Error(err),
+			)
+			continue
+		}
+		kept = append(kept, tx)
+	}
+	return kept
+}
+
+// filterGossip drops the transactions of a gossip message that fail the
+// prefilter. The first byte of [msg] routes it to a handler of the
+// network; the rest of a transaction gossip message is a batch of
+// transactions. Messages that do not parse as one are returned as is, as
+// are those whose transactions all pass. It returns nil when none pass.
+func (f *prefilter) filterGossip(ctx context.Context, msg []byte) []byte {
+	if len(msg) < 2 {
+		return msg
+	}
+	_, txs, err := chain.UnmarshalTxs(msg[1:], gossipBatchSize, ActionParser, AuthParser)
+	if err != nil || len(txs) == 0 {
+		return msg
+	}
+	kept := f.filter(ctx, txs)
+	switch {
+	case len(kept) == len(txs):
+		return msg
+	case len(kept) == 0:
+		return nil
+	}
+	batch, err := chain.MarshalTxs(kept)
+	if err != nil {
+		return msg
+	}
+	return append([]byte{msg[0]}, batch...)
+}
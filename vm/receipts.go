@@ -0,0 +1,183 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/ava-labs/avalanchego/proto/pb/sync"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var (
+	ErrReceiptNotFound     = errors.New("no receipt for action")
+	ErrProofsUnavailable   = errors.New("state proofs unavailable")
+	ErrInvalidReceiptProof = errors.New("invalid receipt proof")
+
+	errProofMismatch = errors.New("proof is of another key or value")
+)
+
+// stateDB is implemented by the hypersdk VM, whose state is a merkledb.
+type stateDB interface {
+	State() (merkledb.MerkleDB, error)
+}
+
+type ReceiptArgs struct {
+	RegionID    string `json:"regionId"`
+	TxID        ids.ID `json:"txId"`
+	ActionIndex uint8  `json:"actionIndex"`
+}
+
+type ReceiptReply struct {
+	Receipt *storage.Receipt `json:"receipt"`
+	// Key is the state key the receipt is stored under
+	Key []byte `json:"key"`
+	// Value is the encoded receipt the proof commits to
+	Value []byte `json:"value"`
+	// StateRoot is the chain state root the proof verifies against. Blocks
+	// built on this state carry it as their parent state root.
+	StateRoot ids.ID `json:"stateRoot"`
+	// Proof is a protobuf-encoded merkledb proof of Key and Value
+	Proof []byte `json:"proof"`
+}
+
+// Receipt returns the receipt of the execution applied by action
+// [ActionIndex] of transaction [TxID], with a merkle proof of it against the
+// current chain state root.
+func (j *JSONRPCServer) Receipt(req *http.Request, args *ReceiptArgs, reply *ReceiptReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Receipt")
+	defer span.End()
+
+	view, err := j.provableView(ctx)
+	if err != nil {
+		return err
+	}
+	key := storage.ReceiptKey(args.RegionID, chain.CreateActionID(args.TxID, args.ActionIndex))
+	value, root, proof, err := proveValue(ctx, view, key)
+	if errors.Is(err, database.ErrNotFound) {
+		return ErrReceiptNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var receipt storage.Receipt
+	if err := codec.Unmarshal(value, &receipt); err != nil {
+		return err
+	}
+	reply.Receipt = &receipt
+	reply.Key = key
+	reply.Value = value
+	reply.StateRoot = root
+	reply.Proof = proof
+	return nil
+}
+
+// provableView returns a fixed view of the chain state, so a root and
+// proofs taken from it agree even if a block is accepted meanwhile.
+func (j *JSONRPCServer) provableView(ctx context.Context) (merkledb.View, error) {
+	sdb, ok := j.vm.(stateDB)
+	if !ok {
+		return nil, ErrProofsUnavailable
+	}
+	db, err := sdb.State()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewView(ctx, merkledb.ViewChanges{})
+}
+
+// proveValue returns the value of [key] in [view], the view's root and a
+// protobuf-encoded merkledb proof of the value against it.
+func proveValue(ctx context.Context, view merkledb.View, key []byte) ([]byte, ids.ID, []byte, error) {
+	value, err := view.GetValue(ctx, key)
+	if err != nil {
+		return nil, ids.Empty, nil, err
+	}
+	root, err := view.GetMerkleRoot(ctx)
+	if err != nil {
+		return nil, ids.Empty, nil, err
+	}
+	proof, err := view.GetProof(ctx, key)
+	if err != nil {
+		return nil, ids.Empty, nil, err
+	}
+	encoded, err := proto.Marshal(proof.ToProto())
+	if err != nil {
+		return nil, ids.Empty, nil, err
+	}
+	return value, root, encoded, nil
+}
+
+// Receipt fetches the receipt of action [actionIndex] of [txID] in
+// [regionID] and checks its proof against the returned state root. Callers
+// still need to check that root against a block they trust.
+func (cli *JSONRPCClient) Receipt(ctx context.Context, regionID string, txID ids.ID, actionIndex uint8) (*ReceiptReply, error) {
+	resp := new(ReceiptReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"receipt",
+		&ReceiptArgs{
+			RegionID:    regionID,
+			TxID:        txID,
+			ActionIndex: actionIndex,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyReceiptProof(ctx, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// VerifyReceiptProof checks that [r.Proof] proves [r.Value] under [r.Key]
+// in the state committed to by [r.StateRoot], and that the value is the
+// encoding of [r.Receipt].
+func VerifyReceiptProof(ctx context.Context, r *ReceiptReply) error {
+	if err := verifyValueProof(ctx, r.StateRoot, r.Key, r.Value, r.Proof); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidReceiptProof, err)
+	}
+	if r.Receipt == nil {
+		return ErrInvalidReceiptProof
+	}
+	expected, err := codec.Marshal(r.Receipt)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, r.Value) {
+		return ErrInvalidReceiptProof
+	}
+	return nil
+}
+
+// verifyValueProof checks that [encoded], a protobuf-encoded merkledb
+// proof, proves [value] under [key] in the state committed to by [root].
+func verifyValueProof(ctx context.Context, root ids.ID, key []byte, value []byte, encoded []byte) error {
+	var pbProof pb.Proof
+	if err := proto.Unmarshal(encoded, &pbProof); err != nil {
+		return err
+	}
+	var proof merkledb.Proof
+	if err := proof.UnmarshalProto(&pbProof); err != nil {
+		return err
+	}
+	if proof.Key != merkledb.ToKey(key) || !proof.Value.HasValue() || !bytes.Equal(proof.Value.Value(), value) {
+		return errProofMismatch
+	}
+	return proof.Verify(ctx, root, merkledb.BranchFactorToTokenSize[merkledb.BranchFactor16], merkledb.DefaultHasher)
+}
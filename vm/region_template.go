@@ -0,0 +1,71 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ava-labs/hypersdk/codec"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+type RegionTemplatesArgs struct {
+	// RegionID, when set, also returns the template the region was
+	// created from
+	RegionID string `json:"regionId"`
+}
+
+type RegionTemplatesReply struct {
+	// Templates are the templates governance maintains at the current
+	// height
+	Templates []actions.RegionTemplate `json:"templates"`
+	// Region is the template [RegionID] was created from, or nil
+	Region *actions.RegionTemplate `json:"region,omitempty"`
+}
+
+// RegionTemplates returns the region templates new regions can be created
+// from.
+func (j *JSONRPCServer) RegionTemplates(req *http.Request, args *RegionTemplatesArgs, reply *RegionTemplatesReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.RegionTemplates")
+	defer span.End()
+
+	value, err := storage.GetParamValueFromState(ctx, j.vm.ReadState, uint8(consts.ParamRegionTemplates))
+	if err != nil {
+		return err
+	}
+	templates, err := actions.DecodeRegionTemplates(value)
+	if err != nil {
+		return err
+	}
+	reply.Templates = templates.Templates
+	if len(args.RegionID) == 0 {
+		return nil
+	}
+	encoded, err := storage.GetRegionTemplateFromState(ctx, j.vm.ReadState, args.RegionID)
+	if err != nil || encoded == nil {
+		return err
+	}
+	reply.Region = new(actions.RegionTemplate)
+	return codec.Unmarshal(encoded, reply.Region)
+}
+
+// RegionTemplates returns the governed region templates and, if [regionID]
+// is set, the template that region was created from.
+func (cli *JSONRPCClient) RegionTemplates(ctx context.Context, regionID string) ([]actions.RegionTemplate, *actions.RegionTemplate, error) {
+	resp := new(RegionTemplatesReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"regionTemplates",
+		&RegionTemplatesArgs{RegionID: regionID},
+		resp,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Templates, resp.Region, nil
+}
@@ -0,0 +1,594 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/consensus/snowman"
+	"github.com/ava-labs/hypersdk/api"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const (
+	RegionEventsEndpoint = "/regionevents"
+
+	regionWriteTimeout = 10 * time.Second
+)
+
+var (
+	ErrRegionSubscribersFull = errors.New("too many subscribers to region")
+	ErrReplayUnavailable     = errors.New("replay height no longer kept")
+	ErrInvalidReplayRange    = errors.New("invalid replay range")
+)
+
+// RegionEventsConfig sizes the region event feed.
+type RegionEventsConfig struct {
+	// BufferSize is how many events may queue for a slow client before it
+	// is disconnected.
+	BufferSize int `json:"bufferSize"`
+	// MaxSubscribers caps the subscribers of each region. Zero means no
+	// limit.
+	MaxSubscribers int `json:"maxSubscribers"`
+	// ReplayBlocks is how many of the last accepted blocks are kept in
+	// memory for clients to replay. Zero disables replay.
+	ReplayBlocks int `json:"replayBlocks"`
+}
+
+func NewDefaultRegionEventsConfig() RegionEventsConfig {
+	return RegionEventsConfig{
+		BufferSize:     256,
+		MaxSubscribers: 1_024,
+		ReplayBlocks:   256,
+	}
+}
+
+// EventStatus is how final a region event is.
+type EventStatus string
+
+const (
+	// EventProcessed is an execution in a block this node verified but
+	// consensus has not decided on. It may never be accepted.
+	EventProcessed EventStatus = "processed"
+	// EventAccepted is an execution in an accepted block. It is final.
+	EventAccepted EventStatus = "accepted"
+	// EventFinalized reports that the block of earlier processed events
+	// was accepted. It carries no execution.
+	EventFinalized EventStatus = "finalized"
+	// EventRejected reports that the block of earlier processed events was
+	// rejected, so its executions never happened. It carries no execution.
+	EventRejected EventStatus = "rejected"
+)
+
+// RegionEvent is pushed to subscribers of a region for every TEEExecAction
+// in it, for every removal of TEEs from it, and for the decision on blocks
+// they were sent processed.
+type RegionEvent struct {
+	Status   EventStatus `json:"status"`
+	Height   uint64      `json:"height"`
+	BlockID  ids.ID      `json:"blockId"`
+	RegionID string      `json:"regionId"`
+	// Replayed is set on accepted events sent from the history on connect
+	Replayed bool   `json:"replayed,omitempty"`
+	TxID     ids.ID `json:"txId,omitempty"`
+	// Success reports whether the transaction of the execution succeeded
+	Success    bool                   `json:"success,omitempty"`
+	EnclaveID  []byte                 `json:"enclaveId,omitempty"`
+	ExecResult *actions.TEEExecResult `json:"execResult,omitempty"`
+	// Reassignment is set on events of TEEs removed from the region
+	Reassignment *Reassignment `json:"reassignment,omitempty"`
+	// TxRegions lists, for a transaction acting on several regions, all
+	// of them. Its updates were applied to every one of them or, if it
+	// failed, to none.
+	TxRegions []string `json:"txRegions,omitempty"`
+}
+
+// Reassignment reports TEEs removed from a region. The events and
+// requests they were serving are not bound to them, so workers of the
+// region's remaining TEEs take over whatever the removed ones left
+// unexecuted. Removed workers should stop submitting: their enclaves are
+// retired with the removal.
+type Reassignment struct {
+	Removed []codec.Address `json:"removed"`
+	Added   []codec.Address `json:"added,omitempty"`
+}
+
+// activeRegionHub is the hub of the running VM, told by [GuardedVM] of the
+// blocks it verifies and rejects.
+var activeRegionHub atomic.Pointer[regionEventHub]
+
+// regionSub is one subscriber of a region. Only subscribers in processed
+// mode receive processed events and the decisions on their blocks.
+type regionSub struct {
+	ch        chan RegionEvent
+	processed bool
+}
+
+// processedBlock is a verified block consensus has not decided on, with
+// the regions it had executions in.
+type processedBlock struct {
+	height  uint64
+	regions map[string]struct{}
+}
+
+type acceptedBlock struct {
+	height uint64
+	events []RegionEvent
+}
+
+// heightRange is the accepted blocks a client replays. A zero [to]
+// replays up to the last accepted block and then follows new ones.
+type heightRange struct {
+	from, to uint64
+}
+
+// regionEventHub fans regional executions out to WebSocket subscribers of
+// each region, enforcing [AccessConfig] on connect. Subscribers get either
+// the executions of accepted blocks, which are final, or those of verified
+// blocks followed by whether each block was finalized or rejected.
+type regionEventHub struct {
+	access   AccessConfig
+	config   RegionEventsConfig
+	upgrader websocket.Upgrader
+
+	l         sync.Mutex
+	subs      map[string]map[*regionSub]struct{}
+	processed map[ids.ID]*processedBlock
+	// history holds the last [RegionEventsConfig.ReplayBlocks] accepted
+	// blocks, oldest first
+	history []acceptedBlock
+}
+
+func newRegionEventHub(access AccessConfig, config RegionEventsConfig) *regionEventHub {
+	return &regionEventHub{
+		access:    access,
+		config:    config,
+		subs:      map[string]map[*regionSub]struct{}{},
+		processed: map[ids.ID]*processedBlock{},
+	}
+}
+
+// ServeHTTP upgrades a request for ?region=<id> to a WebSocket that
+// receives that region's events as JSON. With mode=processed, executions
+// are sent as their block is verified, and the block is then reported
+// finalized or rejected; otherwise they are sent once accepted. With
+// from=<height>, the accepted executions from that height on are replayed
+// first. With to=<height> too, only that range is replayed and the socket
+// is closed.
+func (h *regionEventHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	regionID := query.Get("region")
+	if regionID == "" {
+		http.Error(w, "missing region", http.StatusBadRequest)
+		return
+	}
+	var processed bool
+	switch EventStatus(query.Get("mode")) {
+	case "", EventAccepted:
+	case EventProcessed:
+		processed = true
+	default:
+		http.Error(w, "invalid mode", http.StatusBadRequest)
+		return
+	}
+	replay, err := parseReplay(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.access.Authorize(r, regionID, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var (
+		sub      *regionSub
+		replayed []RegionEvent
+	)
+	if replay != nil && replay.to != 0 {
+		replayed, err = h.replay(regionID, replay)
+	} else {
+		sub, replayed, err = h.subscribe(regionID, processed, replay)
+	}
+	switch {
+	case errors.Is(err, ErrReplayUnavailable):
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if sub != nil {
+		defer h.unsubscribe(regionID, sub)
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	write := func(ev RegionEvent) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(regionWriteTimeout))
+		return conn.WriteJSON(ev)
+	}
+	for _, ev := range replayed {
+		if err := write(ev); err != nil {
+			return
+		}
+	}
+	if sub == nil {
+		_ = conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "replay complete"),
+			time.Now().Add(regionWriteTimeout),
+		)
+		return
+	}
+
+	// Drain client frames so close messages are noticed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case ev, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			if err := write(ev); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// parseReplay reads the replay range of a request, nil if it asks for none.
+func parseReplay(from, to string) (*heightRange, error) {
+	if from == "" {
+		if to != "" {
+			return nil, ErrInvalidReplayRange
+		}
+		return nil, nil
+	}
+	r := &heightRange{}
+	var err error
+	if r.from, err = strconv.ParseUint(from, 10, 64); err != nil {
+		return nil, ErrInvalidReplayRange
+	}
+	if to == "" {
+		return r, nil
+	}
+	if r.to, err = strconv.ParseUint(to, 10, 64); err != nil || r.to < r.from {
+		return nil, ErrInvalidReplayRange
+	}
+	return r, nil
+}
+
+// subscribe adds a subscriber of [regionID] and returns the accepted events
+// of [replay] it must be sent first. Both happen under one lock, so no
+// event is missed or sent twice between them.
+func (h *regionEventHub) subscribe(regionID string, processed bool, replay *heightRange) (*regionSub, []RegionEvent, error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	if h.config.MaxSubscribers > 0 && len(h.subs[regionID]) >= h.config.MaxSubscribers {
+		return nil, nil, ErrRegionSubscribersFull
+	}
+	replayed, err := h.replayLocked(regionID, replay)
+	if err != nil {
+		return nil, nil, err
+	}
+	sub := &regionSub{
+		ch:        make(chan RegionEvent, h.config.BufferSize),
+		processed: processed,
+	}
+	if h.subs[regionID] == nil {
+		h.subs[regionID] = map[*regionSub]struct{}{}
+	}
+	h.subs[regionID][sub] = struct{}{}
+	return sub, replayed, nil
+}
+
+func (h *regionEventHub) unsubscribe(regionID string, sub *regionSub) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	if _, ok := h.subs[regionID][sub]; ok {
+		delete(h.subs[regionID], sub)
+		close(sub.ch)
+	}
+}
+
+// replay returns the accepted events of [regionID] in [r]. Heights not yet
+// accepted are left out.
+func (h *regionEventHub) replay(regionID string, r *heightRange) ([]RegionEvent, error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	return h.replayLocked(regionID, r)
+}
+
+func (h *regionEventHub) replayLocked(regionID string, r *heightRange) ([]RegionEvent, error) {
+	if r == nil {
+		return nil, nil
+	}
+	if len(h.history) == 0 || r.from < h.history[0].height {
+		return nil, ErrReplayUnavailable
+	}
+	var events []RegionEvent
+	for _, blk := range h.history {
+		if blk.height < r.from {
+			continue
+		}
+		if r.to != 0 && blk.height > r.to {
+			break
+		}
+		for _, ev := range blk.events {
+			if ev.RegionID == regionID {
+				ev.Replayed = true
+				events = append(events, ev)
+			}
+		}
+	}
+	return events, nil
+}
+
+// send never blocks block processing: a subscriber whose buffer is full is
+// dropped. Only subscribers [to] selects are sent [ev].
+func (h *regionEventHub) send(ev RegionEvent, to func(*regionSub) bool) {
+	for sub := range h.subs[ev.RegionID] {
+		if !to(sub) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(h.subs[ev.RegionID], sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func processedSub(sub *regionSub) bool {
+	return sub.processed
+}
+
+// process sends the executions of a verified block to subscribers in
+// processed mode.
+func (h *regionEventHub) process(blockID ids.ID, height uint64, events []RegionEvent) {
+	if len(events) == 0 {
+		return
+	}
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	if _, ok := h.processed[blockID]; ok {
+		return
+	}
+	blk := &processedBlock{height: height, regions: map[string]struct{}{}}
+	for _, ev := range events {
+		blk.regions[ev.RegionID] = struct{}{}
+		h.send(ev, processedSub)
+	}
+	h.processed[blockID] = blk
+}
+
+// accept sends the executions of an accepted block and reports it
+// finalized to the regions it was processed in. Subscribers in processed
+// mode are sent the executions too if the block was not seen verified.
+func (h *regionEventHub) accept(blockID ids.ID, height uint64, events []RegionEvent) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	blk, seen := h.processed[blockID]
+	delete(h.processed, blockID)
+	for _, ev := range events {
+		h.send(ev, func(sub *regionSub) bool {
+			return !sub.processed || !seen
+		})
+	}
+	if seen {
+		for regionID := range blk.regions {
+			h.send(RegionEvent{
+				Status:   EventFinalized,
+				Height:   height,
+				BlockID:  blockID,
+				RegionID: regionID,
+			}, processedSub)
+		}
+	}
+
+	if h.config.ReplayBlocks == 0 {
+		return
+	}
+	h.history = append(h.history, acceptedBlock{height: height, events: events})
+	if len(h.history) > h.config.ReplayBlocks {
+		h.history[0] = acceptedBlock{}
+		h.history = h.history[1:]
+	}
+}
+
+// reject reports a verified block rejected to the regions it was processed
+// in.
+func (h *regionEventHub) reject(blockID ids.ID) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	blk, ok := h.processed[blockID]
+	if !ok {
+		return
+	}
+	delete(h.processed, blockID)
+	for regionID := range blk.regions {
+		h.send(RegionEvent{
+			Status:   EventRejected,
+			Height:   blk.height,
+			BlockID:  blockID,
+			RegionID: regionID,
+		}, processedSub)
+	}
+}
+
+// regionEvents returns the executions of [blk] as events of [status].
+func regionEvents(blk *chain.StatefulBlock, status EventStatus) []RegionEvent {
+	var events []RegionEvent
+	results := blk.Results()
+	for i, tx := range blk.Txs {
+		success := i < len(results) && results[i].Success
+		first := len(events)
+		for _, action := range tx.Actions {
+			switch a := action.(type) {
+			case *actions.TEEExecAction:
+				events = append(events, RegionEvent{
+					Status:     status,
+					Height:     blk.Height(),
+					BlockID:    blk.ID(),
+					RegionID:   a.RegionID,
+					TxID:       tx.ID(),
+					Success:    success,
+					EnclaveID:  a.Attestation.EnclaveID,
+					ExecResult: &a.ExecResult,
+				})
+			case *actions.UpdateRegionAction:
+				if len(a.RemTEEs) == 0 {
+					continue
+				}
+				events = append(events, RegionEvent{
+					Status:   status,
+					Height:   blk.Height(),
+					BlockID:  blk.ID(),
+					RegionID: a.RegionID,
+					TxID:     tx.ID(),
+					Success:  success,
+					Reassignment: &Reassignment{
+						Removed: a.RemTEEs,
+						Added:   a.AddTEEs,
+					},
+				})
+			}
+		}
+		if regions := txRegions(tx.Actions); len(regions) > 1 {
+			for j := first; j < len(events); j++ {
+				events[j].TxRegions = regions
+			}
+		}
+	}
+	return events
+}
+
+// txRegions returns the regions the actions of a transaction act on, in
+// order of their first action.
+func txRegions(txActions []chain.Action) []string {
+	var regions []string
+	seen := make(map[string]struct{})
+	for _, action := range txActions {
+		regionID := actions.ActionRegion(action)
+		if _, ok := seen[regionID]; ok || regionID == "" {
+			continue
+		}
+		seen[regionID] = struct{}{}
+		regions = append(regions, regionID)
+	}
+	return regions
+}
+
+var _ snowman.Block = (*trackedBlock)(nil)
+
+// trackedBlock tells the hub when its block is verified or rejected.
+// Acceptance is reported by [regionEventsFeed], which also sends the
+// executions of blocks the hub never saw verified.
+type trackedBlock struct {
+	*chain.StatefulBlock
+	hub *regionEventHub
+}
+
+// track wraps [blk] so the hub of the running VM learns of it, if there is
+// one.
+func track(blk snowman.Block) snowman.Block {
+	hub := activeRegionHub.Load()
+	stateful, ok := blk.(*chain.StatefulBlock)
+	if hub == nil || !ok {
+		return blk
+	}
+	return &trackedBlock{StatefulBlock: stateful, hub: hub}
+}
+
+func (b *trackedBlock) Verify(ctx context.Context) error {
+	if err := b.StatefulBlock.Verify(ctx); err != nil {
+		return err
+	}
+	b.hub.process(b.ID(), b.Height(), regionEvents(b.StatefulBlock, EventProcessed))
+	return nil
+}
+
+func (b *trackedBlock) Reject(ctx context.Context) error {
+	if err := b.StatefulBlock.Reject(ctx); err != nil {
+		return err
+	}
+	b.hub.reject(b.ID())
+	return nil
+}
+
+var (
+	_ api.HandlerFactory[api.VM]                      = (*regionEventsAPI)(nil)
+	_ event.SubscriptionFactory[*chain.StatefulBlock] = (*regionEventsFeed)(nil)
+	_ event.Subscription[*chain.StatefulBlock]        = (*regionEventsFeed)(nil)
+)
+
+type regionEventsAPI struct {
+	hub *regionEventHub
+}
+
+func (a regionEventsAPI) New(api.VM) (api.Handler, error) {
+	return api.Handler{
+		Path:    RegionEventsEndpoint,
+		Handler: a.hub,
+	}, nil
+}
+
+type regionEventsFeed struct {
+	hub *regionEventHub
+}
+
+func (f regionEventsFeed) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return f, nil
+}
+
+func (f regionEventsFeed) Accept(blk *chain.StatefulBlock) error {
+	events := regionEvents(blk, EventAccepted)
+	for _, ev := range events {
+		vmlog.Default().WithHeight(blk.Height()).WithRegion(ev.RegionID).WithEnclave(ev.EnclaveID).
+			Debug("regional execution accepted", zap.Stringer("tx", ev.TxID))
+	}
+	f.hub.accept(blk.ID(), blk.Height(), events)
+	return nil
+}
+
+func (regionEventsFeed) Close() error {
+	return nil
+}
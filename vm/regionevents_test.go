@@ -0,0 +1,89 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+func TestRegionEventHub(t *testing.T) {
+	require := require.New(t)
+	hub := newRegionEventHub(AccessConfig{}, RegionEventsConfig{BufferSize: 16, ReplayBlocks: 2})
+
+	accepted, _, err := hub.subscribe("us-east", false, nil)
+	require.NoError(err)
+	processed, _, err := hub.subscribe("us-east", true, nil)
+	require.NoError(err)
+	drain := func(sub *regionSub) []EventStatus {
+		var statuses []EventStatus
+		for len(sub.ch) > 0 {
+			statuses = append(statuses, (<-sub.ch).Status)
+		}
+		return statuses
+	}
+	events := func(height uint64, blockID ids.ID, status EventStatus) []RegionEvent {
+		return []RegionEvent{{Status: status, Height: height, BlockID: blockID, RegionID: "us-east", TxID: ids.GenerateTestID()}}
+	}
+
+	// Two competing blocks at height 1: one is rejected, the other accepted
+	a, b := ids.GenerateTestID(), ids.GenerateTestID()
+	hub.process(a, 1, events(1, a, EventProcessed))
+	hub.process(b, 1, events(1, b, EventProcessed))
+	hub.process(b, 1, events(1, b, EventProcessed))
+	require.Empty(drain(accepted))
+	require.Equal([]EventStatus{EventProcessed, EventProcessed}, drain(processed))
+
+	hub.reject(a)
+	hub.accept(b, 1, events(1, b, EventAccepted))
+	require.Equal([]EventStatus{EventAccepted}, drain(accepted))
+	require.Equal([]EventStatus{EventRejected, EventFinalized}, drain(processed))
+
+	// A block never seen verified is sent to every subscriber as accepted
+	c := ids.GenerateTestID()
+	hub.accept(c, 2, events(2, c, EventAccepted))
+	require.Equal([]EventStatus{EventAccepted}, drain(accepted))
+	require.Equal([]EventStatus{EventAccepted}, drain(processed))
+
+	// Only the last two accepted blocks can be replayed
+	d := ids.GenerateTestID()
+	hub.accept(d, 3, events(3, d, EventAccepted))
+	_, err = hub.replay("us-east", &heightRange{from: 1})
+	require.ErrorIs(err, ErrReplayUnavailable)
+	replayed, err := hub.replay("us-east", &heightRange{from: 2, to: 2})
+	require.NoError(err)
+	require.Len(replayed, 1)
+	require.True(replayed[0].Replayed)
+	require.Equal(uint64(2), replayed[0].Height)
+
+	sub, replayed, err := hub.subscribe("us-east", false, &heightRange{from: 2})
+	require.NoError(err)
+	require.Len(replayed, 2)
+	replayed, err = hub.replay("eu-west", &heightRange{from: 2})
+	require.NoError(err)
+	require.Empty(replayed)
+	hub.unsubscribe("us-east", sub)
+
+	for _, query := range [][2]string{{"", "3"}, {"x", ""}, {"3", "2"}} {
+		_, err := parseReplay(query[0], query[1])
+		require.ErrorIs(err, ErrInvalidReplayRange)
+	}
+}
+
+func TestTxRegions(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal([]string{"us-east", "eu-west"}, txRegions([]chain.Action{
+		&actions.TEEExecAction{RegionID: "us-east"},
+		&actions.Transfer{},
+		&actions.UpdateRegionAction{RegionID: "eu-west"},
+		&actions.TEEExecAction{RegionID: "us-east"},
+	}))
+	require.Empty(txRegions([]chain.Action{&actions.Transfer{}}))
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"net/http"
+)
+
+// ReplicaConfig turns the node into a read-only replica: it follows the
+// chain and serves queries and subscriptions, but never builds blocks or
+// gossips transactions, and caps the load the JSON-RPC API accepts. Use it
+// for public API endpoints.
+type ReplicaConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxConcurrentRequests is how many JSON-RPC requests are served at
+	// once; further requests are rejected with 503.
+	MaxConcurrentRequests int `json:"maxConcurrentRequests"`
+	// MaxRequestBytes bounds the size of a JSON-RPC request body.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+}
+
+func NewDefaultReplicaConfig() ReplicaConfig {
+	return ReplicaConfig{
+		Enabled:               false,
+		MaxConcurrentRequests: 64,
+		MaxRequestBytes:       64 * 1024,
+	}
+}
+
+// limit wraps [h] with the replica request limits. It returns [h] unchanged
+// when replica mode is off.
+func (c ReplicaConfig) limit(h http.Handler) http.Handler {
+	if !c.Enabled {
+		return h
+	}
+	slots := make(chan struct{}, c.MaxConcurrentRequests)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		default:
+			http.Error(w, "replica busy", http.StatusServiceUnavailable)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, c.MaxRequestBytes)
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"errors"
+
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/genesis"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+var (
+	ErrInvalidVersionSchedule = errors.New("action versions must activate in order, from 1 to the latest")
+
+	_ actions.VersionRules = (*Rules)(nil)
+	_ genesis.RuleFactory  = (*RuleFactory)(nil)
+)
+
+// Rules extends the default rules with the heights action versions
+// activate at.
+type Rules struct {
+	chain.Rules
+	ActionVersions []consts.VersionActivation
+}
+
+func (r *Rules) GetActionVersionSchedule() []consts.VersionActivation {
+	return r.ActionVersions
+}
+
+// RuleFactory wraps the rules of [genesis.DefaultGenesisFactory] in
+// [Rules].
+type RuleFactory struct {
+	genesis.RuleFactory
+	ActionVersions []consts.VersionActivation
+}
+
+func (f *RuleFactory) GetRules(t int64) chain.Rules {
+	return &Rules{
+		Rules:          f.RuleFactory.GetRules(t),
+		ActionVersions: f.ActionVersions,
+	}
+}
+
+// validateVersionSchedule checks that [schedule] activates each version
+// from 1 to [consts.LatestActionVersion] once, in order, at heights that
+// do not decrease.
+func validateVersionSchedule(schedule []consts.VersionActivation) error {
+	if len(schedule) != int(consts.LatestActionVersion) {
+		return ErrInvalidVersionSchedule
+	}
+	for i, activation := range schedule {
+		if activation.Version != uint8(i+1) {
+			return ErrInvalidVersionSchedule
+		}
+		if i > 0 && activation.Height < schedule[i-1].Height {
+			return ErrInvalidVersionSchedule
+		}
+	}
+	return nil
+}
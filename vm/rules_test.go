@@ -0,0 +1,138 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/trace"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/crypto/ed25519"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestGenesisActionVersions(t *testing.T) {
+	delayed := slices.Clone(consts.ActionVersionSchedule)
+	delayed[len(delayed)-1].Height = 10
+	decreasing := slices.Clone(delayed)
+	decreasing[len(decreasing)-2].Height = 11
+	reordered := slices.Clone(consts.ActionVersionSchedule)
+	reordered[0], reordered[1] = reordered[1], reordered[0]
+
+	tests := []struct {
+		name           string
+		actionVersions []consts.VersionActivation
+		expected       []consts.VersionActivation
+		expectedErr    error
+	}{
+		{
+			name:     "Default",
+			expected: consts.ActionVersionSchedule,
+		},
+		{
+			name:           "Delayed",
+			actionVersions: delayed,
+			expected:       delayed,
+		},
+		{
+			name:           "Missing",
+			actionVersions: delayed[:len(delayed)-1],
+			expectedErr:    ErrInvalidVersionSchedule,
+		},
+		{
+			name:           "Reordered",
+			actionVersions: reordered,
+			expectedErr:    ErrInvalidVersionSchedule,
+		},
+		{
+			name:           "DecreasingHeight",
+			actionVersions: decreasing,
+			expectedErr:    ErrInvalidVersionSchedule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			b, err := json.Marshal(&Genesis{
+				DefaultGenesis: genesis.NewDefaultGenesis(nil),
+				ActionVersions: tt.actionVersions,
+			})
+			require.NoError(err)
+			_, ruleFactory, err := GenesisFactory{}.Load(b, nil, 1, ids.Empty)
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr != nil {
+				return
+			}
+			require.Equal(tt.expected, actions.ActionVersionSchedule(ruleFactory.GetRules(0)))
+		})
+	}
+}
+
+func TestGenesisAdminSet(t *testing.T) {
+	keys := make([]ed25519.PublicKey, 2)
+	for i := range keys {
+		key, err := ed25519.GeneratePrivateKey()
+		require.NoError(t, err)
+		keys[i] = key.PublicKey()
+	}
+
+	tests := []struct {
+		name        string
+		admin       *storage.AdminSet
+		expectedErr error
+	}{
+		{
+			name:  "Valid",
+			admin: &storage.AdminSet{Threshold: 2, Keys: keys},
+		},
+		{
+			name:        "NoThreshold",
+			admin:       &storage.AdminSet{Keys: keys},
+			expectedErr: ErrInvalidAdminThreshold,
+		},
+		{
+			name:        "ThresholdAboveKeys",
+			admin:       &storage.AdminSet{Threshold: 3, Keys: keys},
+			expectedErr: ErrInvalidAdminThreshold,
+		},
+		{
+			// One key listed twice cannot meet a threshold of two alone
+			name:        "DuplicateKey",
+			admin:       &storage.AdminSet{Threshold: 2, Keys: []ed25519.PublicKey{keys[0], keys[0]}},
+			expectedErr: ErrDuplicateAdminKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := context.Background()
+
+			store := chaintest.NewInMemoryStore()
+			g := &Genesis{
+				DefaultGenesis: genesis.NewDefaultGenesis(nil),
+				Admin:          tt.admin,
+			}
+			err := g.InitializeState(ctx, trace.Noop, store, nil)
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr != nil {
+				return
+			}
+			set, err := storage.GetAdminSet(ctx, store)
+			require.NoError(err)
+			require.Equal(tt.admin, set)
+		})
+	}
+}
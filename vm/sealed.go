@@ -0,0 +1,54 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rhombus-tech/vm/storage"
+)
+
+var ErrStorageNotSealed = errors.New("object storage is not sealed")
+
+type SealedStorageArgs struct {
+	ObjectID string `json:"objectId"`
+}
+
+type SealedStorageReply struct {
+	Sealed *storage.SealedStorage `json:"sealed"`
+}
+
+// SealedStorage returns the hash, recipients and sequence of the sealed
+// storage of [ObjectID].
+func (j *JSONRPCServer) SealedStorage(req *http.Request, args *SealedStorageArgs, reply *SealedStorageReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.SealedStorage")
+	defer span.End()
+
+	sealed, err := storage.GetSealedStorageFromState(ctx, j.vm.ReadState, args.ObjectID)
+	if err != nil {
+		return err
+	}
+	if sealed == nil {
+		return ErrStorageNotSealed
+	}
+	reply.Sealed = sealed
+	return nil
+}
+
+// SealedStorage returns the sealed storage record of [objectID].
+func (cli *JSONRPCClient) SealedStorage(ctx context.Context, objectID string) (*storage.SealedStorage, error) {
+	resp := new(SealedStorageReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"sealedStorage",
+		&SealedStorageArgs{ObjectID: objectID},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sealed, nil
+}
@@ -17,14 +17,19 @@ const JSONRPCEndpoint = "/morpheusapi"
 
 var _ api.HandlerFactory[api.VM] = (*jsonRPCServerFactory)(nil)
 
-type jsonRPCServerFactory struct{}
+type jsonRPCServerFactory struct {
+	replica ReplicaConfig
+}
 
-func (jsonRPCServerFactory) New(vm api.VM) (api.Handler, error) {
+func (f jsonRPCServerFactory) New(vm api.VM) (api.Handler, error) {
 	handler, err := api.NewJSONRPCHandler(consts.Name, NewJSONRPCServer(vm))
+	if err != nil {
+		return api.Handler{}, err
+	}
 	return api.Handler{
 		Path:    JSONRPCEndpoint,
-		Handler: handler,
-	}, err
+		Handler: f.replica.limit(handler),
+	}, nil
 }
 
 type JSONRPCServer struct {
@@ -63,3 +68,16 @@ func (j *JSONRPCServer) Balance(req *http.Request, args *BalanceArgs, reply *Bal
 	reply.Amount = balance
 	return err
 }
+
+type DescriptorReply struct {
+	Descriptor *Descriptor `json:"descriptor"`
+}
+
+func (*JSONRPCServer) Descriptor(_ *http.Request, _ *struct{}, reply *DescriptorReply) error {
+	descriptor, err := GetDescriptor()
+	if err != nil {
+		return err
+	}
+	reply.Descriptor = descriptor
+	return nil
+}
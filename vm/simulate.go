@@ -0,0 +1,175 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/actions"
+)
+
+var (
+	ErrSimulationUnavailable = errors.New("simulation unavailable")
+	ErrOutsideValidRange     = errors.New("action outside its valid range")
+)
+
+type SimulateArgs struct {
+	Actor  codec.Address `json:"actor"`
+	TypeID uint8         `json:"typeId"`
+	// Action is the action as JSON, see [ActionFromJSON]
+	Action json.RawMessage `json:"action"`
+}
+
+// SimulateError is why a simulated action failed, with the action type and
+// the region and object it acted on when the action reported them.
+type SimulateError struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	TypeID   uint8  `json:"typeId"`
+	RegionID string `json:"regionId,omitempty"`
+	ObjectID string `json:"objectId,omitempty"`
+}
+
+type SimulateReply struct {
+	// Output is the action's result as JSON, unset if it failed
+	Output json.RawMessage `json:"output,omitempty"`
+	Error  *SimulateError  `json:"error,omitempty"`
+	// StateRoot is the chain state root the action was executed against
+	StateRoot ids.ID `json:"stateRoot"`
+}
+
+// Simulate executes [Action] as [Actor] against current state and discards
+// its writes. An action that fails is reported in the reply's Error rather
+// than as an RPC error, so callers can tell a failing action from a failing
+// node.
+func (j *JSONRPCServer) Simulate(req *http.Request, args *SimulateArgs, reply *SimulateReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.Simulate")
+	defer span.End()
+
+	action, err := ActionFromJSON(args.TypeID, args.Action)
+	if err != nil {
+		return err
+	}
+	sdb, ok := j.vm.(stateDB)
+	if !ok {
+		return ErrSimulationUnavailable
+	}
+	db, err := sdb.State()
+	if err != nil {
+		return err
+	}
+	view, err := db.NewView(ctx, merkledb.ViewChanges{})
+	if err != nil {
+		return err
+	}
+	root, err := view.GetMerkleRoot(ctx)
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().UnixMilli()
+	reply.StateRoot = root
+	output, err := simulate(ctx, view, j.vm.Rules(timestamp), timestamp, args.Actor, action)
+	if err != nil {
+		reply.Error = simulateError(action.GetTypeID(), err)
+		return nil
+	}
+	reply.Output, err = json.Marshal(output)
+	return err
+}
+
+// simulate checks and executes [action] on an overlay of [im].
+func simulate(
+	ctx context.Context,
+	im state.Immutable,
+	rules chain.Rules,
+	timestamp int64,
+	actor codec.Address,
+	action chain.Action,
+) (codec.Typed, error) {
+	start, end := action.ValidRange(rules)
+	if (start >= 0 && timestamp < start) || (end >= 0 && timestamp > end) {
+		return nil, ErrOutsideValidRange
+	}
+	actionID := ids.Empty
+	if _, err := rand.Read(actionID[:]); err != nil {
+		return nil, err
+	}
+	mu := &overlayState{im: im, changes: make(map[string][]byte)}
+	return action.Execute(ctx, rules, mu, timestamp, actor, actionID)
+}
+
+// simulateError reports [err], returned by an action of type [typeID].
+func simulateError(typeID uint8, err error) *SimulateError {
+	serr := &SimulateError{
+		Code:    actions.ErrorCodeOf(err).String(),
+		Message: err.Error(),
+		TypeID:  typeID,
+	}
+	var ae *actions.ActionError
+	if errors.As(err, &ae) {
+		serr.TypeID = ae.TypeID
+		serr.RegionID = ae.RegionID
+		serr.ObjectID = ae.ObjectID
+	}
+	return serr
+}
+
+// overlayState records writes over an immutable state. A nil value marks a
+// removed key.
+type overlayState struct {
+	im      state.Immutable
+	changes map[string][]byte
+}
+
+func (o *overlayState) GetValue(ctx context.Context, key []byte) ([]byte, error) {
+	if value, ok := o.changes[string(key)]; ok {
+		if value == nil {
+			return nil, database.ErrNotFound
+		}
+		return value, nil
+	}
+	return o.im.GetValue(ctx, key)
+}
+
+func (o *overlayState) Insert(_ context.Context, key []byte, value []byte) error {
+	o.changes[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (o *overlayState) Remove(_ context.Context, key []byte) error {
+	o.changes[string(key)] = nil
+	return nil
+}
+
+// Simulate executes the JSON encoded [action] of type [typeID] as [actor]
+// without submitting it.
+func (cli *JSONRPCClient) Simulate(ctx context.Context, actor codec.Address, typeID uint8, action []byte) (*SimulateReply, error) {
+	resp := new(SimulateReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"simulate",
+		&SimulateArgs{
+			Actor:  actor,
+			TypeID: typeID,
+			Action: action,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
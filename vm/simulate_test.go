@@ -0,0 +1,56 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+func TestSimulate(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	mu := chaintest.NewInMemoryStore()
+	rules := genesis.NewDefaultRules()
+
+	// Writes are discarded
+	output, err := simulate(ctx, mu, rules, 1, codec.EmptyAddress, &actions.StartUploadAction{ObjectID: "obj", Size: 1})
+	require.NoError(err)
+	require.NotNil(output)
+	u, err := storage.GetUpload(ctx, mu, "obj")
+	require.NoError(err)
+	require.Nil(u)
+
+	// Failures carry the object the action acted on
+	_, err = simulate(ctx, mu, rules, 1, codec.EmptyAddress, &actions.CommitObjectAction{ObjectID: "obj"})
+	require.ErrorIs(err, actions.ErrUploadNotFound)
+	require.Equal(consts.ErrCodeUploadNotFound, actions.ErrorCodeFromMessage(err.Error()))
+	require.Equal(&SimulateError{
+		Code:     consts.ErrCodeUploadNotFound.String(),
+		Message:  err.Error(),
+		TypeID:   consts.CommitObjectID,
+		ObjectID: "obj",
+	}, simulateError(consts.CommitObjectID, err))
+
+	// and the region
+	_, err = simulate(ctx, mu, rules, 1, codec.EmptyAddress, &actions.TEEExecAction{
+		Version:    consts.LatestActionVersion,
+		RegionID:   "r1",
+		ExecResult: actions.TEEExecResult{ContractAddr: []byte("counter")},
+	})
+	require.ErrorIs(err, actions.ErrInvalidRegion)
+	serr := simulateError(consts.TEEExecID, err)
+	require.Equal(consts.ErrCodeRegionNotFound.String(), serr.Code)
+	require.Equal("r1", serr.RegionID)
+	require.Equal("counter", serr.ObjectID)
+}
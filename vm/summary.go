@@ -0,0 +1,212 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/event"
+	"github.com/ava-labs/hypersdk/vm"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/storage"
+	"github.com/rhombus-tech/vm/vmlog"
+)
+
+const SummaryNamespace = "blockSummaries"
+
+var ErrSummaryNotFound = errors.New("no summary for block")
+
+// SummaryConfig keeps a [storage.BlockSummary] of each of the last [Retain]
+// accepted blocks in state. Zero disables summaries.
+type SummaryConfig struct {
+	Retain uint64 `json:"retain"`
+}
+
+func NewDefaultSummaryConfig() SummaryConfig {
+	return SummaryConfig{
+		Retain: 1_024,
+	}
+}
+
+func WithBlockSummaries() vm.Option {
+	return vm.NewOption(SummaryNamespace, NewDefaultSummaryConfig(), func(v *vm.VM, config SummaryConfig) error {
+		if config.Retain == 0 {
+			return nil
+		}
+		vm.WithBlockSubscriptions(summaryFactory{vm: v, config: config})(v)
+		return nil
+	})
+}
+
+var _ event.SubscriptionFactory[*chain.StatefulBlock] = (*summaryFactory)(nil)
+
+type summaryFactory struct {
+	vm     *vm.VM
+	config SummaryConfig
+}
+
+func (f summaryFactory) New() (event.Subscription[*chain.StatefulBlock], error) {
+	return &summarizer{vm: f.vm, config: f.config}, nil
+}
+
+type summarizer struct {
+	vm     *vm.VM
+	config SummaryConfig
+}
+
+func (s *summarizer) Accept(blk *chain.StatefulBlock) error {
+	db, err := s.vm.State()
+	if err != nil {
+		return err
+	}
+	summary := summarizeBlock(blk.Height(), blk.ID(), blk.Tmstmp, blk.Txs, blk.Results())
+	vmlog.Default().WithHeight(summary.Height).Debug("block accepted",
+		zap.Stringer("block", summary.BlockID),
+		zap.Uint32("txs", summary.Txs),
+		zap.Uint32("failed", summary.FailedTxs),
+		zap.Strings("regions", summary.Regions),
+	)
+	return storage.PutBlockSummary(db, summary, s.config.Retain)
+}
+
+func (*summarizer) Close() error {
+	return nil
+}
+
+// attestationErrors are the codes of failures in checking an enclave, its
+// attestation or its timestamps.
+var attestationErrors = map[consts.ErrorCode]bool{
+	consts.ErrCodeInvalidSignature:    true,
+	consts.ErrCodeInvalidTimestamp:    true,
+	consts.ErrCodeStaleTimestamp:      true,
+	consts.ErrCodeInvalidEnclave:      true,
+	consts.ErrCodeNitroPolicyMismatch: true,
+	consts.ErrCodePlatformPolicy:      true,
+	consts.ErrCodeCollateral:          true,
+	consts.ErrCodeEnclaveExpired:      true,
+}
+
+// summarizeBlock condenses [txs] and their [results]. Actions of failed
+// transactions are counted, but their regions and writes are not, as they
+// were rolled back.
+func summarizeBlock(
+	height uint64,
+	blkID ids.ID,
+	timestamp int64,
+	txs []*chain.Transaction,
+	results []*chain.Result,
+) *storage.BlockSummary {
+	summary := &storage.BlockSummary{
+		Height:    height,
+		BlockID:   blkID,
+		Timestamp: timestamp,
+		Txs:       uint32(len(txs)),
+	}
+	counts := map[uint8]uint32{}
+	regions := map[string]struct{}{}
+	for i, tx := range txs {
+		success := i < len(results) && results[i].Success
+		for _, action := range tx.Actions {
+			counts[action.GetTypeID()]++
+			if !success {
+				continue
+			}
+			if regionID := actions.ActionRegion(action); regionID != "" {
+				regions[regionID] = struct{}{}
+			}
+			summary.StateBytes += actionStateBytes(action)
+		}
+		if success {
+			continue
+		}
+		summary.FailedTxs++
+		if i < len(results) && attestationErrors[actions.ErrorCodeFromMessage(string(results[i].Error))] {
+			summary.AttestationFailures++
+		}
+	}
+	for typeID, count := range counts {
+		summary.Actions = append(summary.Actions, storage.ActionCount{TypeID: typeID, Count: count})
+	}
+	slices.SortFunc(summary.Actions, func(a, b storage.ActionCount) int {
+		return int(a.TypeID) - int(b.TypeID)
+	})
+	for regionID := range regions {
+		summary.Regions = append(summary.Regions, regionID)
+	}
+	slices.Sort(summary.Regions)
+	return summary
+}
+
+// actionStateBytes returns the size of the payload [action] writes to
+// state. Bookkeeping records are not counted.
+func actionStateBytes(action chain.Action) uint64 {
+	var n int
+	switch a := action.(type) {
+	case *actions.TEEExecAction:
+		for key, value := range a.ExecResult.StateUpdates {
+			n += len(key) + len(value)
+		}
+		for key := range a.ExecResult.StateRefs {
+			n += len(key) + ids.IDLen
+		}
+	case *actions.CreateObjectAction:
+		n = len(a.Code) + len(a.Storage)
+	case *actions.AppendChunkAction:
+		n = len(a.Data)
+	case *actions.SendEventAction:
+		n = len(a.Parameters)
+	case *actions.PipelineAction:
+		n = len(a.Parameters)
+	}
+	return uint64(n)
+}
+
+type BlockSummaryArgs struct {
+	Height uint64 `json:"height"`
+}
+
+type BlockSummaryReply struct {
+	Summary *storage.BlockSummary `json:"summary"`
+}
+
+// BlockSummary returns the summary of the accepted block at [Height], if it
+// is among the blocks whose summaries are kept.
+func (j *JSONRPCServer) BlockSummary(req *http.Request, args *BlockSummaryArgs, reply *BlockSummaryReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.BlockSummary")
+	defer span.End()
+
+	summary, err := storage.GetBlockSummaryFromState(ctx, j.vm.ReadState, args.Height)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		return ErrSummaryNotFound
+	}
+	reply.Summary = summary
+	return nil
+}
+
+// BlockSummary returns the summary of the accepted block at [height].
+func (cli *JSONRPCClient) BlockSummary(ctx context.Context, height uint64) (*storage.BlockSummary, error) {
+	resp := new(BlockSummaryReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"blockSummary",
+		&BlockSummaryArgs{Height: height},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Summary, nil
+}
@@ -4,6 +4,12 @@ import (
     "bytes"
     "encoding/binary"
     "errors"
+    "fmt"
+
+    "github.com/rhombus-tech/vm/attestation"
+    "github.com/rhombus-tech/vm/consts"
+    "github.com/rhombus-tech/vm/manifest"
+    "github.com/rhombus-tech/vm/storage"
 )
 
 var (
@@ -12,6 +18,9 @@ var (
     ErrMalformedCode     = errors.New("malformed code")
     ErrCodeTooLarge      = errors.New("code exceeds size limit")
     ErrInvalidHeader     = errors.New("invalid code header")
+    ErrUnknownFlags      = errors.New("unknown code header flags")
+    ErrUnsupportedABI    = errors.New("unsupported code ABI version")
+    ErrRuntimeTooOld     = errors.New("code requires a newer runtime")
 )
 
 const (
@@ -21,21 +30,83 @@ const (
     FormatCustom uint8 = 3
 
     // Header magic bytes for verification
-    HeaderMagic = "\x00SHUTTLE"
-    HeaderSize  = 16 // Magic (8) + Format (1) + Version (1) + Reserved (6)
+    HeaderMagic = consts.CodeHeaderMagic
+    HeaderSize  = consts.CodeHeaderSize
+
+    // Flags in Reserved[0]
+    FlagZstd uint8 = consts.CodeFlagZstd
 )
 
 // CodeHeader represents the metadata for code
 type CodeHeader struct {
     Format  uint8  // Code format identifier
     Version uint8  // Version number for the format
-    Reserved [6]byte // Reserved for future use
+    // Reserved[0] holds flags, Reserved[1] the ABI version and
+    // Reserved[2:4] the minimum runtime version. Reserved[4:6] is unused
+    // and must be zero.
+    Reserved [6]byte
+}
+
+// HeaderOptions are the header extensions [CreateCodeWithOptions] sets.
+type HeaderOptions struct {
+    // ABIVersion is the host ABI the code is built against, zero for 1
+    ABIVersion uint8
+    // MinRuntime is the oldest runtime that can run the code, zero for any
+    MinRuntime uint16
+}
+
+// Compressed reports whether the code body is zstd compressed
+func (h *CodeHeader) Compressed() bool {
+    return h.Reserved[0]&FlagZstd != 0
+}
+
+// ABIVersion returns the host ABI version the code is built against
+func (h *CodeHeader) ABIVersion() uint8 {
+    if h.Reserved[1] == 0 {
+        return 1
+    }
+    return h.Reserved[1]
+}
+
+// MinRuntime returns the oldest runtime version that can run the code
+func (h *CodeHeader) MinRuntime() uint16 {
+    return binary.BigEndian.Uint16(h.Reserved[2:4])
+}
+
+// Check rejects headers this binary cannot interpret: unknown flags,
+// extensions newer than it supports, or unused bytes that are set.
+func (h *CodeHeader) Check() error {
+    if unknown := h.Reserved[0] &^ consts.CodeKnownFlags; unknown != 0 {
+        return fmt.Errorf("%w: %#x", ErrUnknownFlags, unknown)
+    }
+    if abi := h.ABIVersion(); abi > consts.CodeABIVersion {
+        return fmt.Errorf("%w: %d > %d", ErrUnsupportedABI, abi, consts.CodeABIVersion)
+    }
+    if runtime := h.MinRuntime(); runtime > consts.CodeRuntimeVersion {
+        return fmt.Errorf("%w: %d > %d", ErrRuntimeTooOld, runtime, consts.CodeRuntimeVersion)
+    }
+    if h.Reserved[4] != 0 || h.Reserved[5] != 0 {
+        return fmt.Errorf("%w: reserved bytes set", ErrInvalidHeader)
+    }
+    return nil
 }
 
-// CodeValidator handles validation of code in different formats
+// Bytes encodes the header
+func (h *CodeHeader) Bytes() []byte {
+    b := make([]byte, HeaderSize)
+    copy(b, HeaderMagic)
+    b[8] = h.Format
+    b[9] = h.Version
+    copy(b[10:], h.Reserved[:])
+    return b
+}
+
+// CodeValidator handles validation of code in different formats, and its
+// preparation for the TEE types that run it
 type CodeValidator struct {
-    maxSize uint64
-    formats map[uint8]FormatValidator
+    maxSize    uint64
+    formats    map[uint8]FormatValidator
+    transforms map[attestation.EnclaveType]CodeTransform
 }
 
 // FormatValidator interface for different code formats
@@ -43,11 +114,19 @@ type FormatValidator interface {
     Validate(code []byte) error
 }
 
+// CodeTransform prepares validated code for one TEE type, such as by adding
+// the packaging metadata its loader expects. It receives the decompressed
+// body after the header and returns the body to run.
+type CodeTransform interface {
+    Transform(header *CodeHeader, body []byte) ([]byte, error)
+}
+
 // NewCodeValidator creates a new validator instance
 func NewCodeValidator(maxSize uint64) *CodeValidator {
     cv := &CodeValidator{
-        maxSize: maxSize,
-        formats: make(map[uint8]FormatValidator),
+        maxSize:    maxSize,
+        formats:    make(map[uint8]FormatValidator),
+        transforms: make(map[attestation.EnclaveType]CodeTransform),
     }
 
     // Register default format validators
@@ -55,24 +134,53 @@ func NewCodeValidator(maxSize uint64) *CodeValidator {
     cv.RegisterFormat(FormatWasm, &WasmValidator{})
     cv.RegisterFormat(FormatCustom, &CustomValidator{})
 
+    // Register default transforms
+    cv.RegisterTransform(attestation.SGX, DefaultSGXTransform)
+    cv.RegisterTransform(attestation.SEV, DefaultSEVTransform)
+
     return cv
 }
 
-// ValidateCode validates code bytes and returns the format
+// ValidateCode validates code bytes, and that every registered transform
+// can prepare them, so the code runs on any TEE type
 func (cv *CodeValidator) ValidateCode(code []byte) error {
+    header, body, err := cv.validate(code)
+    if err != nil {
+        return err
+    }
+    for enclaveType, transform := range cv.transforms {
+        if _, err := transform.Transform(header, body); err != nil {
+            return fmt.Errorf("%w: %s: %w", ErrMalformedCode, enclaveType, err)
+        }
+    }
+    return nil
+}
+
+// validate checks [code] and returns its header and decompressed body
+func (cv *CodeValidator) validate(code []byte) (*CodeHeader, []byte, error) {
     // Check size
     if uint64(len(code)) > cv.maxSize {
-        return ErrCodeTooLarge
+        return nil, nil, ErrCodeTooLarge
+    }
+
+    // Decompress a flagged body, bounded so a small payload cannot expand
+    // past the size limit
+    code, err := storage.DecodeCode(code, int(cv.maxSize))
+    if errors.Is(err, storage.ErrDecompressedTooLarge) {
+        return nil, nil, ErrCodeTooLarge
+    }
+    if err != nil {
+        return nil, nil, fmt.Errorf("%w: %w", ErrMalformedCode, err)
     }
 
     // Must have at least a header
     if len(code) < HeaderSize {
-        return ErrInvalidHeader
+        return nil, nil, ErrInvalidHeader
     }
 
     // Verify magic bytes
     if !bytes.Equal([]byte(code[:8]), []byte(HeaderMagic)) {
-        return ErrInvalidHeader
+        return nil, nil, ErrInvalidHeader
     }
 
     // Parse header
@@ -81,15 +189,61 @@ func (cv *CodeValidator) ValidateCode(code []byte) error {
         Version: code[9],
     }
     copy(header.Reserved[:], code[10:16])
+    if err := header.Check(); err != nil {
+        return nil, nil, err
+    }
 
     // Get validator for format
     validator, exists := cv.formats[header.Format]
     if !exists {
-        return ErrUnsupportedFormat
+        return nil, nil, ErrUnsupportedFormat
     }
 
     // Validate format-specific code (after header)
-    return validator.Validate(code[HeaderSize:])
+    if err := validator.Validate(code[HeaderSize:]); err != nil {
+        return nil, nil, err
+    }
+    return header, code[HeaderSize:], nil
+}
+
+// Prepare validates [code] and returns it, decompressed, as the TEE type
+// [enclaveType] runs it. Code for a type without a transform is returned
+// as validated.
+func (cv *CodeValidator) Prepare(code []byte, enclaveType attestation.EnclaveType) ([]byte, error) {
+    header, body, err := cv.validate(code)
+    if err != nil {
+        return nil, err
+    }
+    if transform, ok := cv.transforms[enclaveType]; ok {
+        if body, err = transform.Transform(header, body); err != nil {
+            return nil, fmt.Errorf("%w: %s: %w", ErrMalformedCode, enclaveType, err)
+        }
+    }
+    return append(header.Bytes(), body...), nil
+}
+
+// PrepareAll prepares [code] for each of [enclaveTypes], such as the types
+// a region's platform policy admits.
+func (cv *CodeValidator) PrepareAll(code []byte, enclaveTypes []attestation.EnclaveType) (map[attestation.EnclaveType][]byte, error) {
+    prepared := make(map[attestation.EnclaveType][]byte, len(enclaveTypes))
+    for _, enclaveType := range enclaveTypes {
+        out, err := cv.Prepare(code, enclaveType)
+        if err != nil {
+            return nil, err
+        }
+        prepared[enclaveType] = out
+    }
+    return prepared, nil
+}
+
+// RegisterTransform sets the transform preparing code for [enclaveType],
+// replacing any registered before. A nil transform removes it.
+func (cv *CodeValidator) RegisterTransform(enclaveType attestation.EnclaveType, transform CodeTransform) {
+    if transform == nil {
+        delete(cv.transforms, enclaveType)
+        return
+    }
+    cv.transforms[enclaveType] = transform
 }
 
 // RegisterFormat registers a new format validator
@@ -120,9 +274,7 @@ func (v *RawValidator) Validate(code []byte) error {
 type WasmValidator struct{}
 
 func (v *WasmValidator) Validate(code []byte) error {
-    // Wasm magic number (\0asm)
-    wasmMagic := []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
-    
+    // Wasm magic number (\0asm) and version
     if len(code) < len(wasmMagic) {
         return ErrMalformedCode
     }
@@ -141,31 +293,42 @@ func (v *WasmValidator) Validate(code []byte) error {
     return nil
 }
 
-// Custom format validator
+// Custom format validator: the body is a [manifest] container
 type CustomValidator struct{}
 
 func (v *CustomValidator) Validate(code []byte) error {
-    // Example custom format validation
-    if len(code) < 4 {
-        return ErrMalformedCode
+    m, err := manifest.Parse(code)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrMalformedCode, err)
     }
-    
-    // Example: check for specific structure
-    // - First 4 bytes: size of function table
-    tableSize := binary.LittleEndian.Uint32(code[:4])
-    if len(code) < int(tableSize)+4 {
-        return ErrMalformedCode
+    if _, err := m.Exports(); err != nil {
+        return fmt.Errorf("%w: %w", ErrMalformedCode, err)
+    }
+    hostVersion, err := m.HostVersion()
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrMalformedCode, err)
+    }
+    if hostVersion > consts.CodeHostVersion {
+        return fmt.Errorf("%w: requires host version %d > %d", ErrUnsupportedFormat, hostVersion, consts.CodeHostVersion)
     }
-    
     return nil
 }
 
 // Helper function to create code with proper header
 func CreateCode(format uint8, version uint8, code []byte) []byte {
-    header := make([]byte, HeaderSize)
-    copy(header, HeaderMagic)
-    header[8] = format
-    header[9] = version
-    
-    return append(header, code...)
+    return CreateCodeWithOptions(format, version, HeaderOptions{}, code)
+}
+
+// CreateCodeWithOptions is CreateCode with the header extensions [opts]
+func CreateCodeWithOptions(format uint8, version uint8, opts HeaderOptions, code []byte) []byte {
+    header := &CodeHeader{Format: format, Version: version}
+    header.Reserved[1] = opts.ABIVersion
+    binary.BigEndian.PutUint16(header.Reserved[2:4], opts.MinRuntime)
+    return append(header.Bytes(), code...)
+}
+
+// CreateCompressedCode is CreateCode with the body zstd compressed and
+// FlagZstd set
+func CreateCompressedCode(format uint8, version uint8, code []byte) ([]byte, error) {
+    return storage.CompressCode(CreateCode(format, version, code))
 }
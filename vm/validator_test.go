@@ -0,0 +1,47 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/attestation"
+	"github.com/rhombus-tech/vm/consts"
+)
+
+func TestCodeHeaderExtensions(t *testing.T) {
+	require := require.New(t)
+	cv := NewCodeValidator(consts.MaxCodeSize)
+	body := []byte{1, 0xaa, 0xbb}
+
+	opts := HeaderOptions{ABIVersion: consts.CodeABIVersion, MinRuntime: consts.CodeRuntimeVersion}
+	code := CreateCodeWithOptions(FormatRaw, 1, opts, body)
+	require.NoError(cv.ValidateCode(code))
+	compressed, err := CreateCompressedCode(FormatRaw, 1, body)
+	require.NoError(err)
+	require.NoError(cv.ValidateCode(compressed))
+
+	// Preparing code keeps its extensions
+	prepared, err := cv.Prepare(code, attestation.SGX)
+	require.NoError(err)
+	require.Equal(code, prepared)
+
+	tests := []struct {
+		name   string
+		mutate func(code []byte)
+		err    error
+	}{
+		{"unknown flag", func(code []byte) { code[consts.CodeFlagsOffset] |= 1 << 7 }, ErrUnknownFlags},
+		{"newer ABI", func(code []byte) { code[consts.CodeABIOffset] = consts.CodeABIVersion + 1 }, ErrUnsupportedABI},
+		{"newer runtime", func(code []byte) { code[consts.CodeMinRuntimeOffset+1] = consts.CodeRuntimeVersion + 1 }, ErrRuntimeTooOld},
+		{"unused byte", func(code []byte) { code[HeaderSize-1] = 1 }, ErrInvalidHeader},
+	}
+	for _, tt := range tests {
+		mutated := CreateCode(FormatRaw, 1, body)
+		tt.mutate(mutated)
+		require.ErrorIs(cv.ValidateCode(mutated), tt.err, tt.name)
+	}
+}
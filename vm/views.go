@@ -0,0 +1,163 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/x/merkledb"
+	"github.com/ava-labs/hypersdk/state"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// ViewPrefix marks the exported functions of an object that may be called
+// as views
+const ViewPrefix = "view_"
+
+const (
+	MaxViewOutputSize = 64 * 1024 // 64KiB
+	viewTimeout       = 2 * time.Second
+)
+
+var (
+	ErrViewsUnavailable   = errors.New("no view runtime installed")
+	ErrNotView            = errors.New("function is not a view")
+	ErrReadOnlyView       = errors.New("views cannot write state")
+	ErrViewOutputTooLarge = errors.New("view output exceeds maximum")
+)
+
+// ViewRuntime runs object code locally, outside any enclave. Its output is
+// not attested.
+type ViewRuntime interface {
+	Call(ctx context.Context, code []byte, store *ViewStore, function string, args []byte) ([]byte, error)
+}
+
+var viewRuntime ViewRuntime
+
+// SetViewRuntime installs the runtime serving the view JSON-RPC method.
+// Nodes without one reject view calls with [ErrViewsUnavailable].
+func SetViewRuntime(rt ViewRuntime) {
+	viewRuntime = rt
+}
+
+// ViewStore is an object's key-value namespace as seen by a view. Writes
+// fail, so a view cannot change state even in a local runtime.
+type ViewStore struct {
+	im       state.Immutable
+	objectID string
+}
+
+func (s *ViewStore) Get(ctx context.Context, key []byte) ([]byte, bool, error) {
+	return storage.GetObjectKV(ctx, s.im, s.objectID, key)
+}
+
+func (*ViewStore) Set(context.Context, []byte, []byte) error {
+	return ErrReadOnlyView
+}
+
+func (*ViewStore) Delete(context.Context, []byte) error {
+	return ErrReadOnlyView
+}
+
+type ViewArgs struct {
+	Object   string `json:"object"`
+	Function string `json:"function"`
+	Args     []byte `json:"args"`
+}
+
+type ViewReply struct {
+	Output []byte `json:"output"`
+	// Unverified is always set: the output was computed by the node's local
+	// runtime, not attested by an enclave, and is only fit for display
+	Unverified bool `json:"unverified"`
+	// StateRoot is the chain state root the view read
+	StateRoot ids.ID `json:"stateRoot"`
+}
+
+// View calls the read-only function [Function] of [Object] with [Args]
+// against current state in the node's local runtime.
+func (j *JSONRPCServer) View(req *http.Request, args *ViewArgs, reply *ViewReply) error {
+	ctx, span := j.vm.Tracer().Start(req.Context(), "Server.View")
+	defer span.End()
+
+	sdb, ok := j.vm.(stateDB)
+	if !ok {
+		return ErrViewsUnavailable
+	}
+	db, err := sdb.State()
+	if err != nil {
+		return err
+	}
+	view, err := db.NewView(ctx, merkledb.ViewChanges{})
+	if err != nil {
+		return err
+	}
+	output, err := callView(ctx, view, viewRuntime, args)
+	if err != nil {
+		return err
+	}
+	root, err := view.GetMerkleRoot(ctx)
+	if err != nil {
+		return err
+	}
+	reply.Output = output
+	reply.Unverified = true
+	reply.StateRoot = root
+	return nil
+}
+
+// callView runs [args] against [im] with [rt].
+func callView(ctx context.Context, im state.Immutable, rt ViewRuntime, args *ViewArgs) ([]byte, error) {
+	if rt == nil {
+		return nil, ErrViewsUnavailable
+	}
+	if !strings.HasPrefix(args.Function, ViewPrefix) {
+		return nil, fmt.Errorf("%w: %s", ErrNotView, args.Function)
+	}
+	code, err := storage.GetObjectCode(ctx, im, args.Object)
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, fmt.Errorf("%w: %s", actions.ErrObjectNotFound, args.Object)
+	}
+	ctx, cancel := context.WithTimeout(ctx, viewTimeout)
+	defer cancel()
+	output, err := rt.Call(ctx, code, &ViewStore{im: im, objectID: args.Object}, args.Function, args.Args)
+	if err != nil {
+		return nil, err
+	}
+	if len(output) > MaxViewOutputSize {
+		return nil, ErrViewOutputTooLarge
+	}
+	return output, nil
+}
+
+// View calls a read-only function of [object]. The output is computed by
+// the node without attestation; do not act on it.
+func (cli *JSONRPCClient) View(ctx context.Context, object, function string, args []byte) (*ViewReply, error) {
+	resp := new(ViewReply)
+	err := cli.requester.SendRequest(
+		ctx,
+		"view",
+		&ViewArgs{
+			Object:   object,
+			Function: function,
+			Args:     args,
+		},
+		resp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
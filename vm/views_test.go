@@ -0,0 +1,54 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/storage"
+)
+
+// kvRuntime returns the value of the key named by its arguments, and tries
+// to write it back when called as view_touch.
+type kvRuntime struct{}
+
+func (kvRuntime) Call(ctx context.Context, _ []byte, store *ViewStore, function string, args []byte) ([]byte, error) {
+	value, _, err := store.Get(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if function == "view_touch" {
+		if err := store.Set(ctx, args, value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func TestCallView(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	mu := chaintest.NewInMemoryStore()
+	require.NoError(storage.SetObject(ctx, mu, "counter", map[string][]byte{"code": {1}}))
+	require.NoError(storage.SetObjectKV(ctx, mu, "counter", []byte("count"), []byte{7}))
+
+	_, err := callView(ctx, mu, nil, &ViewArgs{Object: "counter", Function: "view_get"})
+	require.ErrorIs(err, ErrViewsUnavailable)
+
+	output, err := callView(ctx, mu, kvRuntime{}, &ViewArgs{Object: "counter", Function: "view_get", Args: []byte("count")})
+	require.NoError(err)
+	require.Equal([]byte{7}, output)
+
+	_, err = callView(ctx, mu, kvRuntime{}, &ViewArgs{Object: "counter", Function: "increment", Args: []byte("count")})
+	require.ErrorIs(err, ErrNotView)
+	_, err = callView(ctx, mu, kvRuntime{}, &ViewArgs{Object: "counter", Function: "view_touch", Args: []byte("count")})
+	require.ErrorIs(err, ErrReadOnlyView)
+	_, err = callView(ctx, mu, kvRuntime{}, &ViewArgs{Object: "missing", Function: "view_get"})
+	require.ErrorIs(err, actions.ErrObjectNotFound)
+}
@@ -3,17 +3,15 @@
 package vm
 
 import (
-   "fmt"
-
    "github.com/ava-labs/avalanchego/utils/wrappers"
    "github.com/ava-labs/hypersdk/auth"
    "github.com/ava-labs/hypersdk/chain"
    "github.com/ava-labs/hypersdk/codec"
-   "github.com/ava-labs/hypersdk/genesis"
    "github.com/ava-labs/hypersdk/vm"
    "github.com/ava-labs/hypersdk/vm/defaultvm"
 
    "github.com/rhombus-tech/vm/actions"
+   vmauth "github.com/rhombus-tech/vm/auth"
    "github.com/rhombus-tech/vm/consts"
    "github.com/rhombus-tech/vm/storage"
 )
@@ -33,63 +31,133 @@ func init() {
    errs := &wrappers.Errs{}
    errs.Add(
        // Register ShuttleVM actions
-       ActionParser.Register(&actions.CreateObjectAction{}, nil),
-       ActionParser.Register(&actions.SendEventAction{}, nil),
-       ActionParser.Register(&actions.SetInputObjectAction{}, nil),
+       ActionParser.Register(&actions.CreateObjectAction{}, actions.UnmarshalCreateObject),
+       ActionParser.Register(&actions.SendEventAction{}, actions.UnmarshalSendEvent),
+       ActionParser.Register(&actions.SetInputObjectAction{}, actions.UnmarshalSetInputObject),
+       ActionParser.Register(&actions.CreateRegionAction{}, actions.UnmarshalCreateRegion),
+       ActionParser.Register(&actions.UpdateRegionAction{}, actions.UnmarshalUpdateRegion),
+       ActionParser.Register(&actions.TEEExecAction{}, actions.UnmarshalTEEExecAction),
+       ActionParser.Register(&actions.ClaimRewardsAction{}, nil),
+       ActionParser.Register(&actions.ProposeAction{}, nil),
+       ActionParser.Register(&actions.VoteAction{}, nil),
+       ActionParser.Register(&actions.ExecuteProposalAction{}, nil),
+       ActionParser.Register(&actions.AdminAction{}, nil),
+       ActionParser.Register(&actions.StartUploadAction{}, nil),
+       ActionParser.Register(&actions.AppendChunkAction{}, nil),
+       ActionParser.Register(&actions.CommitObjectAction{}, nil),
+       ActionParser.Register(&actions.SettleRegionAction{}, nil),
+       ActionParser.Register(&actions.ChallengeSettlementAction{}, nil),
+       ActionParser.Register(&actions.FinalizeSettlementAction{}, nil),
+       ActionParser.Register(&actions.SetNitroPolicyAction{}, nil),
+       ActionParser.Register(&actions.RegisterNitroEnclaveAction{}, nil),
+       ActionParser.Register(&actions.RegisterCCAEnclaveAction{}, nil),
+       ActionParser.Register(&actions.SetPlatformPolicyAction{}, nil),
+       ActionParser.Register(&actions.PublishCollateralAction{}, nil),
+       ActionParser.Register(&actions.ReattestEnclaveAction{}, nil),
+       ActionParser.Register(&actions.QueueRequestAction{}, nil),
+       ActionParser.Register(&actions.ApproveAction{}, nil),
+       ActionParser.Register(&actions.TransferFromAction{}, nil),
+       ActionParser.Register(&actions.SetSponsorPolicyAction{}, nil),
+       ActionParser.Register(&actions.CreateAssetAction{}, nil),
+       ActionParser.Register(&actions.MintAssetAction{}, nil),
+       ActionParser.Register(&actions.TransferAssetAction{}, nil),
+       ActionParser.Register(&actions.PublishRandomnessAction{}, nil),
+       ActionParser.Register(&actions.RegisterFeedAction{}, nil),
+       ActionParser.Register(&actions.PublishFeedAction{}, nil),
+       ActionParser.Register(&actions.SealStorageAction{}, nil),
+       ActionParser.Register(&actions.ResealStorageAction{}, nil),
+       ActionParser.Register(&actions.PublishAppKeyAction{}, nil),
+       ActionParser.Register(&actions.RotateAppKeyAction{}, nil),
+       ActionParser.Register(&actions.RevokeAppKeyAction{}, nil),
+       ActionParser.Register(&actions.CreateRegionFromTemplateAction{}, nil),
+       ActionParser.Register(&actions.RegisterNameAction{}, nil),
+       ActionParser.Register(&actions.TransferNameAction{}, nil),
+       ActionParser.Register(&actions.SetExecLimitsAction{}, nil),
+       ActionParser.Register(&actions.FreezeAndExportRegionAction{}, nil),
+       ActionParser.Register(&actions.ImportRegionAction{}, nil),
+       ActionParser.Register(&actions.AuthorizeSessionAction{}, nil),
+       ActionParser.Register(&actions.RevokeSessionAction{}, nil),
+       ActionParser.Register(&actions.SubmitCheckpointAction{}, nil),
+       ActionParser.Register(&actions.PruneEnclaveAction{}, nil),
+       ActionParser.Register(&actions.PipelineAction{}, actions.UnmarshalPipeline),
+       ActionParser.Register(&actions.PreemptEventAction{}, nil),
+       ActionParser.Register(&actions.StartSagaAction{}, nil),
+       ActionParser.Register(&actions.WithdrawVoteAction{}, nil),
 
        // Register auth methods
        AuthParser.Register(&auth.ED25519{}, auth.UnmarshalED25519),
        AuthParser.Register(&auth.SECP256R1{}, auth.UnmarshalSECP256R1),
        AuthParser.Register(&auth.BLS{}, auth.UnmarshalBLS),
+       AuthParser.Register(&vmauth.Session{}, vmauth.UnmarshalSession),
 
        // Register output types (results from actions)
-       OutputParser.Register(&actions.CreateObjectResult{}, nil),
-       OutputParser.Register(&actions.SendEventResult{}, nil),
-       OutputParser.Register(&actions.SetInputObjectResult{}, nil),
+       OutputParser.Register(&actions.CreateObjectResult{}, actions.UnmarshalCreateObjectResult),
+       OutputParser.Register(&actions.SendEventResult{}, actions.UnmarshalSendEventResult),
+       OutputParser.Register(&actions.SetInputObjectResult{}, actions.UnmarshalSetInputObjectResult),
+       OutputParser.Register(&actions.CreateRegionResult{}, nil),
+       OutputParser.Register(&actions.UpdateRegionResult{}, nil),
+       OutputParser.Register(&actions.TEEExecOutput{}, nil),
+       OutputParser.Register(&actions.ClaimRewardsResult{}, nil),
+       OutputParser.Register(&actions.ProposeResult{}, nil),
+       OutputParser.Register(&actions.VoteResult{}, nil),
+       OutputParser.Register(&actions.ExecuteProposalResult{}, nil),
+       OutputParser.Register(&actions.AdminResult{}, nil),
+       OutputParser.Register(&actions.StartUploadResult{}, nil),
+       OutputParser.Register(&actions.AppendChunkResult{}, nil),
+       OutputParser.Register(&actions.CommitObjectResult{}, nil),
+       OutputParser.Register(&actions.SettleRegionResult{}, nil),
+       OutputParser.Register(&actions.ChallengeSettlementResult{}, nil),
+       OutputParser.Register(&actions.FinalizeSettlementResult{}, nil),
+       OutputParser.Register(&actions.SetNitroPolicyResult{}, nil),
+       OutputParser.Register(&actions.RegisterNitroEnclaveResult{}, nil),
+       OutputParser.Register(&actions.RegisterCCAEnclaveResult{}, nil),
+       OutputParser.Register(&actions.SetPlatformPolicyResult{}, nil),
+       OutputParser.Register(&actions.PublishCollateralResult{}, nil),
+       OutputParser.Register(&actions.ReattestEnclaveResult{}, nil),
+       OutputParser.Register(&actions.QueueRequestResult{}, nil),
+       OutputParser.Register(&actions.ApproveResult{}, nil),
+       OutputParser.Register(&actions.TransferFromResult{}, nil),
+       OutputParser.Register(&actions.SetSponsorPolicyResult{}, nil),
+       OutputParser.Register(&actions.CreateAssetResult{}, nil),
+       OutputParser.Register(&actions.MintAssetResult{}, nil),
+       OutputParser.Register(&actions.TransferAssetResult{}, nil),
+       OutputParser.Register(&actions.PublishRandomnessResult{}, nil),
+       OutputParser.Register(&actions.RegisterFeedResult{}, nil),
+       OutputParser.Register(&actions.PublishFeedResult{}, nil),
+       OutputParser.Register(&actions.SealStorageResult{}, nil),
+       OutputParser.Register(&actions.ResealStorageResult{}, nil),
+       OutputParser.Register(&actions.PublishAppKeyResult{}, nil),
+       OutputParser.Register(&actions.RotateAppKeyResult{}, nil),
+       OutputParser.Register(&actions.RevokeAppKeyResult{}, nil),
+       OutputParser.Register(&actions.CreateRegionFromTemplateResult{}, nil),
+       OutputParser.Register(&actions.RegisterNameResult{}, nil),
+       OutputParser.Register(&actions.TransferNameResult{}, nil),
+       OutputParser.Register(&actions.SetExecLimitsResult{}, nil),
+       OutputParser.Register(&actions.FreezeAndExportRegionResult{}, nil),
+       OutputParser.Register(&actions.ImportRegionResult{}, nil),
+       OutputParser.Register(&actions.AuthorizeSessionResult{}, nil),
+       OutputParser.Register(&actions.RevokeSessionResult{}, nil),
+       OutputParser.Register(&actions.SubmitCheckpointResult{}, nil),
+       OutputParser.Register(&actions.PruneEnclaveResult{}, nil),
+       OutputParser.Register(&actions.PipelineResult{}, actions.UnmarshalPipelineResult),
+       OutputParser.Register(&actions.PreemptEventResult{}, nil),
+       OutputParser.Register(&actions.StartSagaResult{}, nil),
+       OutputParser.Register(&actions.WithdrawVoteResult{}, nil),
    )
    if errs.Errored() {
        panic(errs.Err)
    }
-}
-
-type Config struct {
-   InputObjectID string
-}
-
-// With returns the ShuttleVM-specific options
-func With() vm.Option {
-   return func(v *vm.VM) error {
-       ctx := v.Context()
-       
-       // Set default input object
-       if err := storage.SetInputObject(ctx, v.State, "input"); err != nil {
-           return fmt.Errorf("failed to set input object: %w", err)
-       }
-
-       return nil
-   }
-}
-
-// WithConfig returns ShuttleVM options with custom configuration
-func WithConfig(config Config) vm.Option {
-   return func(v *vm.VM) error {
-       ctx := v.Context()
 
-       // Set custom input object
-       if err := storage.SetInputObject(ctx, v.State, config.InputObjectID); err != nil {
-           return fmt.Errorf("failed to set input object: %w", err)
-       }
-
-       return nil
-   }
+   // Code assembled from a chunked upload must be in a supported format
+   actions.SetCodeValidator(NewCodeValidator(consts.MaxCodeSize))
 }
 
 // NewWithOptions returns a VM with the specified options
 func New(options ...vm.Option) (*vm.VM, error) {
-   options = append(options, With()) // Add ShuttleVM API
+   options = append(options, With(), WithLogging(), WithMigrations(), WithConsistencyChecker(), WithBlockSummaries(), WithLogIndex(), WithModuleCache(), WithBuildLanes(), WithContentPolicy(), WithExternalIndexer()) // Add ShuttleVM API
    return defaultvm.New(
        consts.Version,
-       genesis.DefaultGenesisFactory{},
+       GenesisFactory{},
        &storage.StateManager{},
        ActionParser,
        AuthParser,
@@ -101,10 +169,10 @@ func New(options ...vm.Option) (*vm.VM, error) {
 
 // NewWithConfig creates a new VM with custom configuration
 func NewWithConfig(config Config, options ...vm.Option) (*vm.VM, error) {
-   options = append(options, WithConfig(config)) // Add configured ShuttleVM API
+   options = append(options, WithConfig(config), WithLogging(), WithMigrations(), WithConsistencyChecker(), WithBlockSummaries(), WithLogIndex(), WithModuleCache(), WithBuildLanes(), WithContentPolicy(), WithExternalIndexer()) // Add configured ShuttleVM API
    return defaultvm.New(
        consts.Version,
-       genesis.DefaultGenesisFactory{},
+       GenesisFactory{},
        &storage.StateManager{},
        ActionParser,
        AuthParser,
@@ -113,3 +181,13 @@ func NewWithConfig(config Config, options ...vm.Option) (*vm.VM, error) {
        options...,
    )
 }
+
+// ResultError returns the error code and message recorded for a transaction
+// result. Successful results report ErrCodeNone.
+func ResultError(result *chain.Result) (consts.ErrorCode, string) {
+   if result.Success {
+       return consts.ErrCodeNone, ""
+   }
+   msg := string(result.Error)
+   return actions.ErrorCodeFromMessage(msg), msg
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/hypersdk/chain"
+	"github.com/ava-labs/hypersdk/chain/chaintest"
+	"github.com/ava-labs/hypersdk/codec"
+	"github.com/ava-labs/hypersdk/codec/codectest"
+	"github.com/ava-labs/hypersdk/genesis"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rhombus-tech/vm/actions"
+	"github.com/rhombus-tech/vm/consts"
+	"github.com/rhombus-tech/vm/verifier"
+)
+
+func TestResultError(t *testing.T) {
+	tee := codectest.NewRandomAddress()
+
+	// execErr is the error [action] fails with, as a transaction including
+	// it would record
+	execErr := func(action chain.Action) error {
+		_, err := action.Execute(
+			context.Background(),
+			genesis.NewDefaultRules(),
+			chaintest.NewInMemoryStore(),
+			1,
+			codec.EmptyAddress,
+			ids.GenerateTestID(),
+		)
+		require.Error(t, err)
+		return err
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		code consts.ErrorCode
+	}{
+		{
+			name: "InvalidTEE",
+			err:  execErr(&actions.CreateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", TEEs: []codec.Address{tee, codec.EmptyAddress}}),
+			code: consts.ErrCodeInvalidTEE,
+		},
+		{
+			name: "TooFewTEEs",
+			err:  execErr(&actions.CreateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", TEEs: []codec.Address{tee}}),
+			code: consts.ErrCodeTooFewTEEs,
+		},
+		{
+			name: "RegionNotFound",
+			err:  execErr(&actions.UpdateRegionAction{Version: consts.LatestActionVersion, RegionID: "us-east", AddTEEs: []codec.Address{tee}}),
+			code: consts.ErrCodeRegionNotFound,
+		},
+		{
+			// The code is recovered though the message does not start with
+			// the coded error's
+			name: "Wrapped",
+			err:  actions.WrapActionError(consts.CreateRegionID, "us-east", "", fmt.Errorf("tee 1: %w", actions.ErrInvalidTEE)),
+			code: consts.ErrCodeInvalidTEE,
+		},
+		{
+			name: "Uncoded",
+			err:  fmt.Errorf("tee 1: %w", actions.ErrInvalidTEE),
+			code: consts.ErrCodeUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			code, msg := ResultError(&chain.Result{Error: []byte(tt.err.Error())})
+			require.Equal(tt.code, code)
+			require.Equal(tt.err.Error(), msg)
+		})
+	}
+
+	code, msg := ResultError(&chain.Result{Success: true})
+	require.Equal(t, consts.ErrCodeNone, code)
+	require.Empty(t, msg)
+}
+
+func TestVerifierHandlesActions(t *testing.T) {
+	ctx := context.Background()
+	v := verifier.New(chaintest.NewInMemoryStore())
+	payload := []byte(fmt.Sprintf(`{"version":%d}`, consts.LatestActionVersion))
+
+	for typeID := range jsonActions {
+		action, err := ActionFromJSON(typeID, payload)
+		require.NoError(t, err)
+		require.NotErrorIs(t, v.VerifyStateTransition(ctx, action), verifier.ErrUnknownAction, "%T", action)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package vmlog tags VM log lines with the block height, region, enclave and
+// action they concern, so operators can follow a single region.
+package vmlog
+
+import (
+	"encoding/hex"
+	"sync/atomic"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"go.uber.org/zap"
+)
+
+// Logger writes to an underlying logger at or above a level. When regions
+// are given, lines tagged with any other region are dropped; untagged lines
+// are always written. Loggers are immutable, so the With methods return
+// copies and a Logger may be shared between goroutines.
+type Logger struct {
+	log     logging.Logger
+	level   logging.Level
+	regions map[string]struct{}
+	region  string
+	fields  []zap.Field
+}
+
+// New returns a Logger writing to [log] at or above [level], filtered to
+// [regions] if any are given.
+func New(log logging.Logger, level logging.Level, regions []string) *Logger {
+	l := &Logger{log: log, level: level}
+	if len(regions) > 0 {
+		l.regions = make(map[string]struct{}, len(regions))
+		for _, regionID := range regions {
+			l.regions[regionID] = struct{}{}
+		}
+	}
+	return l
+}
+
+var discard = New(logging.NoLog{}, logging.Off, nil)
+
+var current atomic.Pointer[Logger]
+
+// SetDefault makes [l] the logger returned by [Default].
+func SetDefault(l *Logger) {
+	current.Store(l)
+}
+
+// Default returns the logger installed by [SetDefault], or one discarding
+// everything until the VM installs one.
+func Default() *Logger {
+	if l := current.Load(); l != nil {
+		return l
+	}
+	return discard
+}
+
+func (l *Logger) with(field zap.Field) *Logger {
+	c := *l
+	c.fields = l.all([]zap.Field{field})
+	return &c
+}
+
+// all appends [fields] to the logger's tags without writing into spare
+// capacity another copy may share.
+func (l *Logger) all(fields []zap.Field) []zap.Field {
+	return append(l.fields[:len(l.fields):len(l.fields)], fields...)
+}
+
+func (l *Logger) WithHeight(height uint64) *Logger {
+	return l.with(zap.Uint64("height", height))
+}
+
+// WithRegion tags lines with [regionID]. An empty region adds no tag.
+func (l *Logger) WithRegion(regionID string) *Logger {
+	if regionID == "" {
+		return l
+	}
+	c := l.with(zap.String("region", regionID))
+	c.region = regionID
+	return c
+}
+
+func (l *Logger) WithEnclave(enclaveID []byte) *Logger {
+	return l.with(zap.String("enclave", hex.EncodeToString(enclaveID)))
+}
+
+func (l *Logger) WithAction(typeID uint8) *Logger {
+	return l.with(zap.Uint8("action", typeID))
+}
+
+// Enabled reports whether a line at [level] would be written.
+func (l *Logger) Enabled(level logging.Level) bool {
+	if level < l.level {
+		return false
+	}
+	if l.region != "" && l.regions != nil {
+		if _, ok := l.regions[l.region]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Logger) Debug(msg string, fields ...zap.Field) {
+	if l.Enabled(logging.Debug) {
+		l.log.Debug(msg, l.all(fields)...)
+	}
+}
+
+func (l *Logger) Info(msg string, fields ...zap.Field) {
+	if l.Enabled(logging.Info) {
+		l.log.Info(msg, l.all(fields)...)
+	}
+}
+
+func (l *Logger) Warn(msg string, fields ...zap.Field) {
+	if l.Enabled(logging.Warn) {
+		l.log.Warn(msg, l.all(fields)...)
+	}
+}
+
+func (l *Logger) Error(msg string, fields ...zap.Field) {
+	if l.Enabled(logging.Error) {
+		l.log.Error(msg, l.all(fields)...)
+	}
+}
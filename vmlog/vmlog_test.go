@@ -0,0 +1,50 @@
+// Copyright (C) 2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vmlog
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEnabled(t *testing.T) {
+	require := require.New(t)
+
+	l := New(logging.NoLog{}, logging.Info, []string{"us-east"})
+	require.False(l.Enabled(logging.Debug))
+	require.True(l.Enabled(logging.Info))
+
+	// Untagged lines and lines of a filtered region are written
+	require.True(l.WithHeight(7).Enabled(logging.Warn))
+	require.True(l.WithRegion("us-east").Enabled(logging.Warn))
+	require.False(l.WithRegion("eu-west").Enabled(logging.Error))
+
+	// Without a filter every region is written
+	require.True(New(logging.NoLog{}, logging.Info, nil).WithRegion("eu-west").Enabled(logging.Info))
+}
+
+func TestWithDoesNotAlias(t *testing.T) {
+	require := require.New(t)
+
+	base := New(logging.NoLog{}, logging.Info, nil).WithHeight(1)
+	base.fields = append(make([]zap.Field, 0, 4), base.fields...)
+	a := base.WithRegion("a")
+	b := base.WithRegion("b")
+	require.Len(a.fields, 2)
+	require.Equal("a", a.fields[1].String)
+	require.Equal("b", b.fields[1].String)
+}
+
+func TestDefault(t *testing.T) {
+	require := require.New(t)
+
+	require.False(Default().Enabled(logging.Fatal))
+	l := New(logging.NoLog{}, logging.Debug, nil)
+	SetDefault(l)
+	t.Cleanup(func() { current.Store(nil) })
+	require.Same(l, Default())
+}